@@ -0,0 +1,178 @@
+/**
+*  @file
+*  @copyright defined in scan-api/LICENSE
+ */
+
+package abi
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/seeleteam/scan-api/common"
+)
+
+const (
+	wordSize     = 32
+	selectorSize = 4
+)
+
+var (
+	errMethodNotFound  = errors.New("method not found in abi")
+	errArgumentCount   = errors.New("argument count mismatch")
+	errUnsupportedType = errors.New("unsupported abi type")
+	errShortOutput     = errors.New("output data is too short")
+)
+
+// Argument describe an input or output parameter of a contract method
+type Argument struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+// Method describe a contract function in the abi json
+type Method struct {
+	Type            string     `json:"type"`
+	Name            string     `json:"name"`
+	Constant        bool       `json:"constant"`
+	StateMutability string     `json:"stateMutability"`
+	Inputs          []Argument `json:"inputs"`
+	Outputs         []Argument `json:"outputs"`
+}
+
+// ABI holds the functions declared by a contract abi json
+type ABI struct {
+	Methods []Method
+}
+
+// Parse parse an abi json string which is stored for a verified contract
+func Parse(abiJSON string) (*ABI, error) {
+	var entries []Method
+	if err := json.Unmarshal([]byte(abiJSON), &entries); err != nil {
+		return nil, err
+	}
+
+	var ret ABI
+	for _, entry := range entries {
+		// an entry without type is a function by the solidity abi spec
+		if entry.Type == "" || entry.Type == "function" {
+			entry.Type = "function"
+			ret.Methods = append(ret.Methods, entry)
+		}
+	}
+	return &ret, nil
+}
+
+// IsView return true if the method does not modify the contract state
+func (m *Method) IsView() bool {
+	switch m.StateMutability {
+	case "view", "pure":
+		return true
+	case "":
+		return m.Constant
+	}
+	return false
+}
+
+// Signature return the canonical signature of the method, e.g. transfer(address,uint256)
+func (m *Method) Signature() string {
+	types := make([]string, len(m.Inputs))
+	for i, input := range m.Inputs {
+		types[i] = input.Type
+	}
+	return m.Name + "(" + strings.Join(types, ",") + ")"
+}
+
+// Selector return the first four bytes of the keccak256 hash of the signature
+func (m *Method) Selector() []byte {
+	return common.Keccak256([]byte(m.Signature()))[:selectorSize]
+}
+
+// ViewMethods return all the methods which could be called without a transaction
+func (a *ABI) ViewMethods() []Method {
+	var methods []Method
+	for _, m := range a.Methods {
+		if m.IsView() {
+			methods = append(methods, m)
+		}
+	}
+	return methods
+}
+
+// MethodByName find the method by name, the argument count is used to pick overloaded methods
+func (a *ABI) MethodByName(name string, argCount int) (*Method, error) {
+	for i := range a.Methods {
+		m := &a.Methods[i]
+		if m.Name == name && len(m.Inputs) == argCount {
+			return m, nil
+		}
+	}
+	return nil, errMethodNotFound
+}
+
+// Pack encode the method selector and arguments into the call payload
+func (m *Method) Pack(args []string) (string, error) {
+	if len(args) != len(m.Inputs) {
+		return "", errArgumentCount
+	}
+
+	var types []*abiType
+	var values []interface{}
+	for i, input := range m.Inputs {
+		t, err := parseType(input.Type)
+		if err != nil {
+			return "", err
+		}
+		v, err := t.parseArg(args[i])
+		if err != nil {
+			return "", fmt.Errorf("argument %d (%s): %v", i, input.Type, err)
+		}
+		types = append(types, t)
+		values = append(values, v)
+	}
+
+	data, err := packTuple(types, values)
+	if err != nil {
+		return "", err
+	}
+	return "0x" + hex.EncodeToString(append(m.Selector(), data...)), nil
+}
+
+// Unpack decode the call result into the method outputs
+func (m *Method) Unpack(result string) ([]interface{}, error) {
+	data, err := decodeHex(result)
+	if err != nil {
+		return nil, err
+	}
+
+	var types []*abiType
+	for _, output := range m.Outputs {
+		t, err := parseType(output.Type)
+		if err != nil {
+			return nil, err
+		}
+		types = append(types, t)
+	}
+	return unpackTuple(types, data)
+}
+
+// decodeHex decode an hex string with or without 0x prefix
+func decodeHex(s string) ([]byte, error) {
+	s = strings.TrimPrefix(strings.TrimPrefix(s, "0x"), "0X")
+	if len(s)%2 == 1 {
+		s = "0" + s
+	}
+	return hex.DecodeString(s)
+}
+
+// parseArraySize parse the size between the square brackets, -1 for dynamic array
+func parseArraySize(s string) (int, error) {
+	if s == "" {
+		return -1, nil
+	}
+	return strconv.Atoi(s)
+}
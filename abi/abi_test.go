@@ -0,0 +1,99 @@
+/**
+*  @file
+*  @copyright defined in scan-api/LICENSE
+ */
+
+package abi
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testABI = `[
+	{"constant":true,"inputs":[{"name":"owner","type":"address"}],"name":"balanceOf","outputs":[{"name":"","type":"uint256"}],"type":"function"},
+	{"inputs":[],"name":"name","outputs":[{"name":"","type":"string"}],"stateMutability":"view","type":"function"},
+	{"inputs":[{"name":"to","type":"address"},{"name":"value","type":"uint256"}],"name":"transfer","outputs":[{"name":"","type":"bool"}],"stateMutability":"nonpayable","type":"function"},
+	{"inputs":[{"name":"ids","type":"uint8[]"}],"name":"sum","outputs":[{"name":"","type":"int256"},{"name":"","type":"uint8[2]"}],"stateMutability":"pure","type":"function"},
+	{"anonymous":false,"inputs":[],"name":"Transfer","type":"event"}
+]`
+
+func Test_Parse(t *testing.T) {
+	a, err := Parse(testABI)
+	assert.NoError(t, err)
+	assert.Equal(t, 4, len(a.Methods))
+
+	views := a.ViewMethods()
+	assert.Equal(t, 3, len(views))
+	assert.Equal(t, "balanceOf", views[0].Name)
+	assert.Equal(t, "name", views[1].Name)
+	assert.Equal(t, "sum", views[2].Name)
+
+	_, err = a.MethodByName("balanceOf", 2)
+	assert.Equal(t, errMethodNotFound, err)
+
+	_, err = Parse("not json")
+	assert.Error(t, err)
+}
+
+func Test_Selector(t *testing.T) {
+	a, _ := Parse(testABI)
+	m, err := a.MethodByName("transfer", 2)
+	assert.NoError(t, err)
+	assert.Equal(t, "transfer(address,uint256)", m.Signature())
+	assert.Equal(t, "a9059cbb", hex.EncodeToString(m.Selector()))
+}
+
+func Test_Pack(t *testing.T) {
+	a, _ := Parse(testABI)
+
+	m, _ := a.MethodByName("balanceOf", 1)
+	payload, err := m.Pack([]string{"0x0a57a2714e193b7ac50475ce625f2dcfb483d741"})
+	assert.NoError(t, err)
+	assert.Equal(t, "0x70a08231"+
+		"0000000000000000000000000a57a2714e193b7ac50475ce625f2dcfb483d741", payload)
+
+	m, _ = a.MethodByName("sum", 1)
+	payload, err = m.Pack([]string{`[1, "2"]`})
+	assert.NoError(t, err)
+	assert.Equal(t, "0x"+hex.EncodeToString(m.Selector())+
+		"0000000000000000000000000000000000000000000000000000000000000020"+
+		"0000000000000000000000000000000000000000000000000000000000000002"+
+		"0000000000000000000000000000000000000000000000000000000000000001"+
+		"0000000000000000000000000000000000000000000000000000000000000002", payload)
+
+	_, err = m.Pack([]string{`[256]`})
+	assert.Error(t, err)
+
+	_, err = m.Pack([]string{})
+	assert.Equal(t, errArgumentCount, err)
+
+	m, _ = a.MethodByName("balanceOf", 1)
+	_, err = m.Pack([]string{"0x1234"})
+	assert.Error(t, err)
+}
+
+func Test_Unpack(t *testing.T) {
+	a, _ := Parse(testABI)
+
+	m, _ := a.MethodByName("name", 0)
+	values, err := m.Unpack("0x" +
+		"0000000000000000000000000000000000000000000000000000000000000020" +
+		"0000000000000000000000000000000000000000000000000000000000000005" +
+		"7365656c65000000000000000000000000000000000000000000000000000000")
+	assert.NoError(t, err)
+	assert.Equal(t, []interface{}{"seele"}, values)
+
+	m, _ = a.MethodByName("sum", 1)
+	values, err = m.Unpack("0x" +
+		"fffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffd" +
+		"0000000000000000000000000000000000000000000000000000000000000001" +
+		"0000000000000000000000000000000000000000000000000000000000000002")
+	assert.NoError(t, err)
+	assert.Equal(t, []interface{}{"-3", []interface{}{"1", "2"}}, values)
+
+	_, err = m.Unpack("0x00")
+	assert.Equal(t, errShortOutput, err)
+}
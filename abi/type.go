@@ -0,0 +1,423 @@
+/**
+*  @file
+*  @copyright defined in scan-api/LICENSE
+ */
+
+package abi
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const (
+	uintKind = iota
+	intKind
+	addressKind
+	boolKind
+	fixedBytesKind
+	bytesKind
+	stringKind
+	sliceKind
+	arrayKind
+)
+
+const addressSize = 20
+
+var arrayTypeRegexp = regexp.MustCompile(`^(.+)\[([0-9]*)\]$`)
+
+// abiType is a parsed solidity type
+type abiType struct {
+	kind int
+	size int      // bit size for ints, byte size for fixed bytes, length for arrays
+	elem *abiType // element type of slices and arrays
+}
+
+// parseType parse the solidity type string used in the abi json
+func parseType(s string) (*abiType, error) {
+	if matches := arrayTypeRegexp.FindStringSubmatch(s); matches != nil {
+		elem, err := parseType(matches[1])
+		if err != nil {
+			return nil, err
+		}
+		size, err := parseArraySize(matches[2])
+		if err != nil {
+			return nil, err
+		}
+		if size < 0 {
+			return &abiType{kind: sliceKind, elem: elem}, nil
+		}
+		return &abiType{kind: arrayKind, size: size, elem: elem}, nil
+	}
+
+	switch {
+	case s == "address":
+		return &abiType{kind: addressKind}, nil
+	case s == "bool":
+		return &abiType{kind: boolKind}, nil
+	case s == "string":
+		return &abiType{kind: stringKind}, nil
+	case s == "bytes":
+		return &abiType{kind: bytesKind}, nil
+	case strings.HasPrefix(s, "uint"):
+		size, err := parseBitSize(strings.TrimPrefix(s, "uint"))
+		if err != nil {
+			return nil, err
+		}
+		return &abiType{kind: uintKind, size: size}, nil
+	case strings.HasPrefix(s, "int"):
+		size, err := parseBitSize(strings.TrimPrefix(s, "int"))
+		if err != nil {
+			return nil, err
+		}
+		return &abiType{kind: intKind, size: size}, nil
+	case strings.HasPrefix(s, "bytes"):
+		size, err := strconv.Atoi(strings.TrimPrefix(s, "bytes"))
+		if err != nil || size <= 0 || size > wordSize {
+			return nil, errUnsupportedType
+		}
+		return &abiType{kind: fixedBytesKind, size: size}, nil
+	}
+
+	return nil, errUnsupportedType
+}
+
+// parseBitSize parse the bit size of uint<M> and int<M>, default to 256
+func parseBitSize(s string) (int, error) {
+	if s == "" {
+		return 256, nil
+	}
+	size, err := strconv.Atoi(s)
+	if err != nil || size <= 0 || size > 256 || size%8 != 0 {
+		return 0, errUnsupportedType
+	}
+	return size, nil
+}
+
+// isDynamic return true if the type is encoded in the tail of a tuple
+func (t *abiType) isDynamic() bool {
+	switch t.kind {
+	case stringKind, bytesKind, sliceKind:
+		return true
+	case arrayKind:
+		return t.elem.isDynamic()
+	}
+	return false
+}
+
+// headSize return the size of the type in the head of a tuple
+func (t *abiType) headSize() int {
+	if t.kind == arrayKind && !t.isDynamic() {
+		return t.size * t.elem.headSize()
+	}
+	return wordSize
+}
+
+// parseArg convert the argument from its string form into a go value
+func (t *abiType) parseArg(arg string) (interface{}, error) {
+	switch t.kind {
+	case uintKind, intKind:
+		v, ok := parseBigInt(arg)
+		if !ok {
+			return nil, fmt.Errorf("invalid integer %q", arg)
+		}
+		return v, nil
+	case addressKind:
+		b, err := decodeHex(arg)
+		if err != nil || len(b) != addressSize {
+			return nil, fmt.Errorf("invalid address %q", arg)
+		}
+		return b, nil
+	case boolKind:
+		return strconv.ParseBool(arg)
+	case fixedBytesKind:
+		b, err := decodeHex(arg)
+		if err != nil || len(b) > t.size {
+			return nil, fmt.Errorf("invalid bytes%d %q", t.size, arg)
+		}
+		return b, nil
+	case bytesKind:
+		return decodeHex(arg)
+	case stringKind:
+		return arg, nil
+	case sliceKind, arrayKind:
+		var elems []json.RawMessage
+		if err := json.Unmarshal([]byte(arg), &elems); err != nil {
+			return nil, fmt.Errorf("invalid array %q", arg)
+		}
+		if t.kind == arrayKind && len(elems) != t.size {
+			return nil, fmt.Errorf("array length should be %d", t.size)
+		}
+		values := make([]interface{}, len(elems))
+		for i, elem := range elems {
+			// elements could be written as json strings or bare numbers and booleans
+			var s string
+			if err := json.Unmarshal(elem, &s); err != nil {
+				s = string(elem)
+			}
+			v, err := t.elem.parseArg(s)
+			if err != nil {
+				return nil, err
+			}
+			values[i] = v
+		}
+		return values, nil
+	}
+	return nil, errUnsupportedType
+}
+
+// parseBigInt parse a decimal or 0x prefixed hex integer
+func parseBigInt(s string) (*big.Int, bool) {
+	if strings.HasPrefix(s, "0x") || strings.HasPrefix(s, "0X") {
+		return new(big.Int).SetString(s[2:], 16)
+	}
+	return new(big.Int).SetString(s, 10)
+}
+
+// pack encode a value of the type, dynamic types return their tail encoding
+func (t *abiType) pack(v interface{}) ([]byte, error) {
+	switch t.kind {
+	case uintKind, intKind:
+		n := v.(*big.Int)
+		if err := t.checkRange(n); err != nil {
+			return nil, err
+		}
+		return packInt(n), nil
+	case addressKind:
+		return leftPad(v.([]byte)), nil
+	case boolKind:
+		if v.(bool) {
+			return packInt(big.NewInt(1)), nil
+		}
+		return packInt(big.NewInt(0)), nil
+	case fixedBytesKind:
+		return rightPad(v.([]byte)), nil
+	case bytesKind:
+		return packBytes(v.([]byte)), nil
+	case stringKind:
+		return packBytes([]byte(v.(string))), nil
+	case sliceKind, arrayKind:
+		values := v.([]interface{})
+		types := make([]*abiType, len(values))
+		for i := range values {
+			types[i] = t.elem
+		}
+		data, err := packTuple(types, values)
+		if err != nil {
+			return nil, err
+		}
+		if t.kind == sliceKind {
+			data = append(packInt(big.NewInt(int64(len(values)))), data...)
+		}
+		return data, nil
+	}
+	return nil, errUnsupportedType
+}
+
+// checkRange check the integer fits in the bit size of the type
+func (t *abiType) checkRange(n *big.Int) error {
+	if t.kind == uintKind {
+		if n.Sign() < 0 || n.BitLen() > t.size {
+			return fmt.Errorf("%s out of range for uint%d", n, t.size)
+		}
+		return nil
+	}
+
+	limit := new(big.Int).Lsh(big.NewInt(1), uint(t.size-1))
+	if n.Cmp(limit) >= 0 || n.Cmp(new(big.Int).Neg(limit)) < 0 {
+		return fmt.Errorf("%s out of range for int%d", n, t.size)
+	}
+	return nil
+}
+
+// packTuple encode the values as a tuple with the head and tail parts
+func packTuple(types []*abiType, values []interface{}) ([]byte, error) {
+	headLen := 0
+	for _, t := range types {
+		headLen += t.headSize()
+	}
+
+	var head, tail []byte
+	for i, t := range types {
+		data, err := t.pack(values[i])
+		if err != nil {
+			return nil, err
+		}
+		if t.isDynamic() {
+			head = append(head, packInt(big.NewInt(int64(headLen+len(tail))))...)
+			tail = append(tail, data...)
+		} else {
+			head = append(head, data...)
+		}
+	}
+	return append(head, tail...), nil
+}
+
+// packInt encode the integer into a word with two's complement for negatives
+func packInt(n *big.Int) []byte {
+	if n.Sign() < 0 {
+		n = new(big.Int).Add(n, new(big.Int).Lsh(big.NewInt(1), 256))
+	}
+	return leftPad(n.Bytes())
+}
+
+// packBytes encode the length followed by the padded bytes
+func packBytes(b []byte) []byte {
+	data := packInt(big.NewInt(int64(len(b))))
+	for i := 0; i < len(b); i += wordSize {
+		end := i + wordSize
+		if end > len(b) {
+			end = len(b)
+		}
+		data = append(data, rightPad(b[i:end])...)
+	}
+	return data
+}
+
+func leftPad(b []byte) []byte {
+	word := make([]byte, wordSize)
+	copy(word[wordSize-len(b):], b)
+	return word
+}
+
+func rightPad(b []byte) []byte {
+	word := make([]byte, wordSize)
+	copy(word, b)
+	return word
+}
+
+// unpack decode a value of the type at the offset of the data
+func (t *abiType) unpack(data []byte, offset int) (interface{}, error) {
+	if t.isDynamic() {
+		ptr, err := readInt(data, offset)
+		if err != nil {
+			return nil, err
+		}
+		return t.unpackTail(data, ptr)
+	}
+	return t.unpackStatic(data, offset)
+}
+
+// unpackStatic decode a value of a static type stored at the offset
+func (t *abiType) unpackStatic(data []byte, offset int) (interface{}, error) {
+	switch t.kind {
+	case uintKind, intKind:
+		word, err := readWord(data, offset)
+		if err != nil {
+			return nil, err
+		}
+		n := new(big.Int).SetBytes(word)
+		if t.kind == intKind && word[0]&0x80 != 0 {
+			n.Sub(n, new(big.Int).Lsh(big.NewInt(1), 256))
+		}
+		return n.String(), nil
+	case addressKind:
+		word, err := readWord(data, offset)
+		if err != nil {
+			return nil, err
+		}
+		return "0x" + hex.EncodeToString(word[wordSize-addressSize:]), nil
+	case boolKind:
+		word, err := readWord(data, offset)
+		if err != nil {
+			return nil, err
+		}
+		return word[wordSize-1] == 1, nil
+	case fixedBytesKind:
+		word, err := readWord(data, offset)
+		if err != nil {
+			return nil, err
+		}
+		return "0x" + hex.EncodeToString(word[:t.size]), nil
+	case arrayKind:
+		return t.unpackElems(data, offset, t.size)
+	}
+	return nil, errUnsupportedType
+}
+
+// unpackTail decode a value of a dynamic type whose encoding starts at the offset
+func (t *abiType) unpackTail(data []byte, offset int) (interface{}, error) {
+	switch t.kind {
+	case bytesKind, stringKind:
+		length, err := readInt(data, offset)
+		if err != nil {
+			return nil, err
+		}
+		start := offset + wordSize
+		if start+length > len(data) {
+			return nil, errShortOutput
+		}
+		if t.kind == stringKind {
+			return string(data[start : start+length]), nil
+		}
+		return "0x" + hex.EncodeToString(data[start:start+length]), nil
+	case sliceKind:
+		length, err := readInt(data, offset)
+		if err != nil {
+			return nil, err
+		}
+		return t.unpackElems(data[offset+wordSize:], 0, length)
+	case arrayKind:
+		return t.unpackElems(data[offset:], 0, t.size)
+	}
+	return nil, errUnsupportedType
+}
+
+// unpackElems decode count elements encoded as a tuple at the offset
+func (t *abiType) unpackElems(data []byte, offset int, count int) (interface{}, error) {
+	if offset > len(data) {
+		return nil, errShortOutput
+	}
+	values := make([]interface{}, count)
+	pos := 0
+	for i := 0; i < count; i++ {
+		v, err := t.elem.unpack(data[offset:], pos)
+		if err != nil {
+			return nil, err
+		}
+		values[i] = v
+		pos += t.elem.headSize()
+	}
+	return values, nil
+}
+
+// unpackTuple decode the tuple encoded values of the types
+func unpackTuple(types []*abiType, data []byte) ([]interface{}, error) {
+	values := make([]interface{}, len(types))
+	offset := 0
+	for i, t := range types {
+		v, err := t.unpack(data, offset)
+		if err != nil {
+			return nil, err
+		}
+		values[i] = v
+		offset += t.headSize()
+	}
+	return values, nil
+}
+
+func readWord(data []byte, offset int) ([]byte, error) {
+	if offset < 0 || offset+wordSize > len(data) {
+		return nil, errShortOutput
+	}
+	return data[offset : offset+wordSize], nil
+}
+
+// readInt read a word as a length or an offset
+func readInt(data []byte, offset int) (int, error) {
+	word, err := readWord(data, offset)
+	if err != nil {
+		return 0, err
+	}
+	n := new(big.Int).SetBytes(word)
+	if !n.IsInt64() || n.Int64() > int64(len(data)) {
+		return 0, errors.New("invalid offset or length in output data")
+	}
+	return int(n.Int64()), nil
+}
@@ -0,0 +1,239 @@
+/**
+*  @file
+*  @copyright defined in scan-api/LICENSE
+ */
+
+package cache
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hashicorp/golang-lru"
+	"github.com/seeleteam/scan-api/log"
+)
+
+// the defaults of the cache
+const (
+	DefaultSize          = 2048
+	DefaultMaxEntrySize  = 256 * 1024
+	DefaultInterval      = time.Second
+	DefaultConfirmations = 12
+
+	shardCount = 4
+
+	// an immutable response is kept by the clients and the proxies for a year
+	immutableControl = "public, max-age=31536000, immutable"
+	// a mutable response is revalidated by its etag on every request
+	mutableControl = "no-cache"
+)
+
+// HeightDB is the part of the block database the cache follows
+type HeightDB interface {
+	GetBlockHeight(shardNumber int) (uint64, error)
+}
+
+// Cache keep the successful responses of the routes it wraps in an in-process LRU, and answers
+// If-None-Match by their strong etags. The entries of a shard are valid until the syncer moves
+// the indexed height of the shard, the entries of all the shards until it moves any height. The
+// settled blocks, transactions and debts without volatile fields are immutable and never invalidated.
+type Cache struct {
+	hits        uint64
+	misses      uint64
+	notModified uint64
+
+	// MaxEntrySize is the size of the largest body kept, a larger body is sent without being kept
+	MaxEntrySize int
+	// Interval is the period of the polls of the heights
+	Interval time.Duration
+	// Confirmations is the number of blocks over a block before it is settled, a block under
+	// the last confirmations blocks may still be removed by a reorg
+	Confirmations uint64
+
+	db  HeightDB
+	lru *lru.Cache
+
+	mu       sync.RWMutex
+	heights  [shardCount + 1]uint64 // the next height to sync of every shard, 0 is unused
+	versions [shardCount + 1]uint64 // moved with the height of every shard, 0 is moved with any shard
+}
+
+// Stats is the counters of the cache since the server started
+type Stats struct {
+	Hits        uint64   `json:"hits" doc:"the responses served from the cache"`
+	Misses      uint64   `json:"misses" doc:"the responses rendered by their handlers"`
+	NotModified uint64   `json:"notModified" doc:"the responses answered with 304 by If-None-Match"`
+	HitRate     float64  `json:"hitRate" doc:"hits / (hits + misses), 0 before the first request"`
+	Entries     int      `json:"entries" doc:"the responses kept in the cache"`
+	Heights     []uint64 `json:"heights" doc:"the indexed heights of the shards 1 to 4 the entries are valid for"`
+}
+
+// entry is a kept response, scope is the shard whose height invalidates it or 0 for any shard
+type entry struct {
+	body        []byte
+	contentType string
+	etag        string
+	scope       int
+	version     uint64
+	immutable   bool
+}
+
+// scopeFunc return the scope of a successful response, a negative scope is not kept
+type scopeFunc func(c *gin.Context, body []byte) (scope int, immutable bool)
+
+// New return a cache following the heights of the database
+func New(db HeightDB) *Cache {
+	l, _ := lru.New(DefaultSize)
+	return &Cache{
+		MaxEntrySize:  DefaultMaxEntrySize,
+		Interval:      DefaultInterval,
+		Confirmations: DefaultConfirmations,
+		db:            db,
+		lru:           l,
+	}
+}
+
+// Run poll the heights every interval
+func (c *Cache) Run() {
+	for {
+		c.poll()
+		time.Sleep(c.Interval)
+	}
+}
+
+// poll move the version of every shard whose height moved, a reorg moves it too
+func (c *Cache) poll() {
+	for shard := 1; shard <= shardCount; shard++ {
+		height, err := c.db.GetBlockHeight(shard)
+		if err != nil {
+			log.Error("[cache] get the height of shard %d: %v", shard, err)
+			continue
+		}
+		c.mu.Lock()
+		if c.heights[shard] != height {
+			c.heights[shard] = height
+			c.versions[shard]++
+			c.versions[0]++
+		}
+		c.mu.Unlock()
+	}
+}
+
+func (c *Cache) height(shard int) uint64 {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.heights[shard]
+}
+
+func (c *Cache) snapshot() [shardCount + 1]uint64 {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.versions
+}
+
+// Stats return the counters of the cache
+func (c *Cache) Stats() *Stats {
+	stats := &Stats{
+		Hits:        atomic.LoadUint64(&c.hits),
+		Misses:      atomic.LoadUint64(&c.misses),
+		NotModified: atomic.LoadUint64(&c.notModified),
+		Entries:     c.lru.Len(),
+		Heights:     make([]uint64, shardCount),
+	}
+	if total := stats.Hits + stats.Misses; total > 0 {
+		stats.HitRate = float64(stats.Hits) / float64(total)
+	}
+	c.mu.RLock()
+	copy(stats.Heights, c.heights[1:])
+	c.mu.RUnlock()
+	return stats
+}
+
+// StatsHandler respond the counters of the cache in {data}
+func (c *Cache) StatsHandler() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		ctx.JSON(http.StatusOK, gin.H{"data": c.Stats()})
+	}
+}
+
+// wrap serve the kept response of the request if it is still valid, otherwise run the handler
+// and keep its response if it succeeded
+func (c *Cache) wrap(handler gin.HandlerFunc, scope scopeFunc) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		key := ctx.Request.Method + " " + ctx.Request.URL.RequestURI()
+		if value, ok := c.lru.Get(key); ok {
+			e := value.(*entry)
+			if e.immutable || c.snapshot()[e.scope] == e.version {
+				atomic.AddUint64(&c.hits, 1)
+				c.respond(ctx, e, "HIT")
+				return
+			}
+			c.lru.Remove(key)
+		}
+		atomic.AddUint64(&c.misses, 1)
+
+		// the versions are read before the handler, so a response rendered while a height moves
+		// is not kept for the new height
+		versions := c.snapshot()
+		w := newBufferWriter(ctx.Writer)
+		ctx.Writer = w
+		handler(ctx)
+		ctx.Writer = w.ResponseWriter
+
+		body := w.body.Bytes()
+		if w.status != http.StatusOK || !succeeded(body) {
+			w.flush()
+			return
+		}
+		e := &entry{body: body, contentType: w.Header().Get("Content-Type"), etag: etagOf(body)}
+		e.scope, e.immutable = scope(ctx, body)
+		if e.scope >= 0 && len(body) <= c.MaxEntrySize {
+			e.version = versions[e.scope]
+			c.lru.Add(key, e)
+		}
+		c.respond(ctx, e, "MISS")
+	}
+}
+
+// respond send a response with its etag, or 304 if the client has it
+func (c *Cache) respond(ctx *gin.Context, e *entry, status string) {
+	header := ctx.Writer.Header()
+	header.Set("ETag", e.etag)
+	header.Set("X-Cache", status)
+	if e.immutable {
+		header.Set("Cache-Control", immutableControl)
+	} else {
+		header.Set("Cache-Control", mutableControl)
+	}
+
+	if matchETag(ctx.GetHeader("If-None-Match"), e.etag) {
+		atomic.AddUint64(&c.notModified, 1)
+		ctx.Status(http.StatusNotModified)
+		ctx.Writer.WriteHeaderNow()
+		return
+	}
+	ctx.Data(http.StatusOK, e.contentType, e.body)
+}
+
+// etagOf return the strong etag of a body
+func etagOf(body []byte) string {
+	sum := sha1.Sum(body)
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// matchETag compare the etags of If-None-Match to the etag, a GET compares them weakly
+func matchETag(ifNoneMatch, etag string) bool {
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
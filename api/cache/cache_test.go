@@ -0,0 +1,183 @@
+/**
+*  @file
+*  @copyright defined in scan-api/LICENSE
+ */
+
+package cache
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/seeleteam/scan-api/database"
+	"github.com/seeleteam/scan-api/log"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestCache(t *testing.T) (*database.Client, *Cache, *gin.Engine, map[string]int) {
+	log.NewLogger("", "error", false)
+	gin.SetMode(gin.TestMode)
+	db := database.NewMemoryClient(1)
+	c := New(db)
+	c.Confirmations = 1
+
+	// every handler counts its calls and responds its body
+	calls := make(map[string]int)
+	respond := func(body string) gin.HandlerFunc {
+		return func(ctx *gin.Context) {
+			calls[ctx.Request.URL.Path]++
+			ctx.Data(http.StatusOK, "application/json; charset=utf-8", []byte(body))
+		}
+	}
+	e := gin.New()
+	e.GET("/blocks", c.Shard(func(ctx *gin.Context) {
+		calls["/blocks"]++
+		ctx.JSON(http.StatusOK, gin.H{"code": 0, "data": gin.H{"shard": ctx.Query("s"), "call": calls["/blocks"]}})
+	}))
+	e.GET("/count", c.AllShards(respond(`{"code":0,"data":{"count":1}}`)))
+	e.GET("/block", c.Settled(respond(`{"code":0,"data":{"shardnumber":1,"height":1}}`)))
+	e.GET("/debt", c.Settled(respond(`{"data":{"shardNumber":2,"height":1}}`)))
+	e.GET("/tx", c.Settled(respond(`{"code":0,"data":{"shardnumber":1,"block":1,"age":"1 secs ago"}}`)))
+	e.GET("/pending", c.Settled(respond(`{"code":0,"data":{"shardnumber":1,"block":0,"pending":true}}`)))
+	e.GET("/error", c.AllShards(respond(`{"code":3,"message":"could not get block from db","data":{}}`)))
+	return db, c, e, calls
+}
+
+func get(e *gin.Engine, path, etag string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("GET", path, nil)
+	if etag != "" {
+		r.Header.Set("If-None-Match", etag)
+	}
+	e.ServeHTTP(w, r)
+	return w
+}
+
+func addBlock(t *testing.T, db *database.Client, shard int, height int64) {
+	assert.Nil(t, db.AddBlock(&database.DBBlock{HeadHash: "0x0b", Height: height, ShardNumber: shard}))
+}
+
+func Test_Shard(t *testing.T) {
+	db, c, e, calls := newTestCache(t)
+	c.poll()
+
+	w := get(e, "/blocks?s=1", "")
+	assert.Equal(t, w.Code, http.StatusOK)
+	assert.Equal(t, w.Header().Get("X-Cache"), "MISS")
+	assert.Equal(t, w.Header().Get("Cache-Control"), mutableControl)
+	assert.Equal(t, w.Header().Get("Content-Type"), "application/json; charset=utf-8")
+	etag := w.Header().Get("ETag")
+	assert.NotEmpty(t, etag)
+
+	hit := get(e, "/blocks?s=1", "")
+	assert.Equal(t, hit.Header().Get("X-Cache"), "HIT")
+	assert.Equal(t, hit.Header().Get("ETag"), etag)
+	assert.Equal(t, hit.Body.String(), w.Body.String())
+	assert.Equal(t, calls["/blocks"], 1)
+
+	// the client has the response
+	w = get(e, "/blocks?s=1", `"other", W/`+etag)
+	assert.Equal(t, w.Code, http.StatusNotModified)
+	assert.Equal(t, w.Body.Len(), 0)
+	assert.Equal(t, get(e, "/blocks?s=1", `"other"`).Code, http.StatusOK)
+
+	// a shard is invalidated by its height only, a list of all the shards by any height
+	get(e, "/blocks?s=2", "")
+	get(e, "/count", "")
+	addBlock(t, db, 1, 0)
+	c.poll()
+	assert.Equal(t, get(e, "/blocks?s=1", "").Header().Get("X-Cache"), "MISS")
+	assert.Equal(t, get(e, "/blocks?s=2", "").Header().Get("X-Cache"), "HIT")
+	assert.Equal(t, get(e, "/count", "").Header().Get("X-Cache"), "MISS")
+	assert.Equal(t, calls["/blocks"], 3)
+
+	// a response without a valid shard is kept for all the shards
+	get(e, "/blocks?s=9", "")
+	addBlock(t, db, 2, 0)
+	c.poll()
+	assert.Equal(t, get(e, "/blocks?s=9", "").Header().Get("X-Cache"), "MISS")
+
+	// so is a list of an address
+	get(e, "/blocks?s=2&from=0x01", "")
+	addBlock(t, db, 1, 1)
+	c.poll()
+	assert.Equal(t, get(e, "/blocks?s=2&from=0x01", "").Header().Get("X-Cache"), "MISS")
+}
+
+func Test_Settled(t *testing.T) {
+	db, c, e, calls := newTestCache(t)
+	c.poll()
+
+	// the block 1 of shard 1 is not synchronized yet
+	w := get(e, "/block", "")
+	assert.Equal(t, w.Header().Get("Cache-Control"), mutableControl)
+	addBlock(t, db, 1, 1)
+	c.poll()
+	assert.Equal(t, get(e, "/block", "").Header().Get("X-Cache"), "MISS")
+
+	// it is settled under a confirmation
+	addBlock(t, db, 1, 2)
+	c.poll()
+	w = get(e, "/block", "")
+	assert.Equal(t, w.Header().Get("X-Cache"), "MISS")
+	assert.Equal(t, w.Header().Get("Cache-Control"), immutableControl)
+	addBlock(t, db, 1, 3)
+	c.poll()
+	w = get(e, "/block", "")
+	assert.Equal(t, w.Header().Get("X-Cache"), "HIT")
+	assert.Equal(t, w.Header().Get("Cache-Control"), immutableControl)
+	assert.Equal(t, get(e, "/block", w.Header().Get("ETag")).Code, http.StatusNotModified)
+	assert.Equal(t, calls["/block"], 3)
+
+	// a debt is of its shard
+	get(e, "/debt", "")
+	addBlock(t, db, 1, 4)
+	c.poll()
+	assert.Equal(t, get(e, "/debt", "").Header().Get("X-Cache"), "HIT")
+	addBlock(t, db, 2, 3)
+	c.poll()
+	assert.Equal(t, get(e, "/debt", "").Header().Get("Cache-Control"), immutableControl)
+
+	// a settled response with the age is kept until the height moves
+	w = get(e, "/tx", "")
+	assert.Equal(t, w.Header().Get("Cache-Control"), mutableControl)
+	assert.Equal(t, get(e, "/tx", "").Header().Get("X-Cache"), "HIT")
+	assert.Equal(t, get(e, "/tx", w.Header().Get("ETag")).Code, http.StatusNotModified)
+	addBlock(t, db, 1, 5)
+	c.poll()
+	assert.Equal(t, get(e, "/tx", "").Header().Get("X-Cache"), "MISS")
+
+	// a pending transaction is not kept, but it has an etag
+	w = get(e, "/pending", "")
+	assert.NotEmpty(t, w.Header().Get("ETag"))
+	assert.Equal(t, get(e, "/pending", "").Header().Get("X-Cache"), "MISS")
+	assert.Equal(t, calls["/pending"], 2)
+}
+
+func Test_ErrorAndStats(t *testing.T) {
+	_, c, e, calls := newTestCache(t)
+
+	// a v1 error is sent with 200 and a code, it is neither kept nor tagged
+	w := get(e, "/error", "")
+	assert.Equal(t, w.Code, http.StatusOK)
+	assert.Equal(t, w.Body.String(), `{"code":3,"message":"could not get block from db","data":{}}`)
+	assert.Empty(t, w.Header().Get("ETag"))
+	get(e, "/error", "")
+	assert.Equal(t, calls["/error"], 2)
+
+	// a body over the max size is sent without being kept
+	c.MaxEntrySize = 10
+	get(e, "/count", "")
+	assert.Equal(t, get(e, "/count", "").Header().Get("X-Cache"), "MISS")
+	c.MaxEntrySize = DefaultMaxEntrySize
+	get(e, "/count", "")
+	get(e, "/count", "")
+
+	s := httptest.NewRecorder()
+	engine := gin.New()
+	engine.GET("/cache", c.StatsHandler())
+	engine.ServeHTTP(s, httptest.NewRequest("GET", "/cache", nil))
+	assert.Equal(t, s.Body.String(), `{"data":{"hits":1,"misses":5,"notModified":0,"hitRate":0.16666666666666666,"entries":1,"heights":[0,0,0,0]}}`)
+}
@@ -0,0 +1,118 @@
+/**
+*  @file
+*  @copyright defined in scan-api/LICENSE
+ */
+
+package cache
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// the query parameters of the shard, s in v1 and shard in v2
+var shardParams = []string{"s", "shard"}
+
+// addressParams select a list of an address, whose transactions are in all the shards
+var addressParams = []string{"address", "from", "to", "contract"}
+
+// volatileFields change with the time or the height of the shard, a response with them is not immutable
+var volatileFields = []string{"age", "maxheight", "minheight"}
+
+// Settled cache a block, a transaction or a debt. It is immutable once it is settled unless it has
+// volatile fields, otherwise it is kept until the height of its shard moves. A pending transaction
+// is not kept.
+func (c *Cache) Settled(handler gin.HandlerFunc) gin.HandlerFunc {
+	return c.wrap(handler, c.settled)
+}
+
+// Shard cache a list of the shard of the query, it is kept until the height of the shard moves.
+// A list without a valid shard, or of an address, is kept until any height moves.
+func (c *Cache) Shard(handler gin.HandlerFunc) gin.HandlerFunc {
+	return c.wrap(handler, shardScope)
+}
+
+// AllShards cache a response of all the shards, such as a count, it is kept until any height moves
+func (c *Cache) AllShards(handler gin.HandlerFunc) gin.HandlerFunc {
+	return c.wrap(handler, func(*gin.Context, []byte) (int, bool) {
+		return 0, false
+	})
+}
+
+// settled read the shard and the height of the data of a v1 or a v2 response. The keys are
+// matched without case, shardnumber of a block or a transaction and shardNumber of a debt
+func (c *Cache) settled(ctx *gin.Context, body []byte) (int, bool) {
+	var resp struct {
+		Data struct {
+			ShardNumber int     `json:"shardnumber"`
+			Height      *uint64 `json:"height"`
+			Block       *uint64 `json:"block"`
+			Pending     bool    `json:"pending"`
+		} `json:"data"`
+	}
+	var fields struct {
+		Data map[string]json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return 0, false
+	}
+	if err := json.Unmarshal(body, &fields); err != nil {
+		return 0, false
+	}
+	data := resp.Data
+	if data.Pending {
+		return -1, false
+	}
+	if data.ShardNumber < 1 || data.ShardNumber > shardCount {
+		return 0, false
+	}
+
+	height := data.Height
+	if height == nil {
+		height = data.Block
+	}
+	for key := range fields.Data {
+		for _, field := range volatileFields {
+			if strings.EqualFold(key, field) {
+				return data.ShardNumber, false
+			}
+		}
+	}
+	// the height of the shard is the next height to sync
+	if height != nil && *height+c.Confirmations < c.height(data.ShardNumber) {
+		return data.ShardNumber, true
+	}
+	return data.ShardNumber, false
+}
+
+func shardScope(ctx *gin.Context, body []byte) (int, bool) {
+	for _, name := range addressParams {
+		if _, ok := ctx.GetQuery(name); ok {
+			return 0, false
+		}
+	}
+	for _, name := range shardParams {
+		if value, ok := ctx.GetQuery(name); ok {
+			shard, err := strconv.Atoi(value)
+			if err != nil || shard < 1 || shard > shardCount {
+				return 0, false
+			}
+			return shard, false
+		}
+	}
+	return 0, false
+}
+
+// succeeded report whether a body is a success, a v1 error is sent with 200 and a code
+func succeeded(body []byte) bool {
+	var resp struct {
+		Code *int `json:"code"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return false
+	}
+	return resp.Code == nil || *resp.Code == 0
+}
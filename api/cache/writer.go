@@ -0,0 +1,57 @@
+/**
+*  @file
+*  @copyright defined in scan-api/LICENSE
+ */
+
+package cache
+
+import (
+	"bytes"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// bufferWriter keep the status and the body written by a handler, the headers are written to
+// the response directly
+type bufferWriter struct {
+	gin.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func newBufferWriter(w gin.ResponseWriter) *bufferWriter {
+	return &bufferWriter{ResponseWriter: w, status: http.StatusOK}
+}
+
+func (w *bufferWriter) WriteHeader(code int) {
+	w.status = code
+}
+
+func (w *bufferWriter) WriteHeaderNow() {}
+
+func (w *bufferWriter) Write(data []byte) (int, error) {
+	return w.body.Write(data)
+}
+
+func (w *bufferWriter) WriteString(s string) (int, error) {
+	return w.body.WriteString(s)
+}
+
+func (w *bufferWriter) Status() int {
+	return w.status
+}
+
+func (w *bufferWriter) Size() int {
+	return w.body.Len()
+}
+
+func (w *bufferWriter) Written() bool {
+	return w.body.Len() > 0
+}
+
+// flush send the kept response as the handler wrote it
+func (w *bufferWriter) flush() {
+	w.ResponseWriter.WriteHeader(w.status)
+	w.ResponseWriter.Write(w.body.Bytes())
+}
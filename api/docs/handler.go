@@ -0,0 +1,40 @@
+/**
+*  @file
+*  @copyright defined in scan-api/LICENSE
+ */
+
+package docs
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+// JSONHandler serve the OpenAPI document of the spec, it is generated at the first request
+// when all the routes are registered
+func (s *Spec) JSONHandler() gin.HandlerFunc {
+	var once sync.Once
+	var doc []byte
+	var err error
+	return func(c *gin.Context) {
+		once.Do(func() {
+			doc, err = json.MarshalIndent(s.Document(), "", "  ")
+		})
+		if err != nil {
+			c.String(http.StatusInternalServerError, err.Error())
+			return
+		}
+		c.Data(http.StatusOK, "application/json; charset=utf-8", doc)
+	}
+}
+
+// UIHandler serve the docs page, it reads openapi.json next to its own path and needs no
+// file from the internet
+func UIHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(uiPage))
+	}
+}
@@ -0,0 +1,137 @@
+/**
+*  @file
+*  @copyright defined in scan-api/LICENSE
+ */
+
+package docs
+
+// OpenAPIVersion is the version of the OpenAPI specification the document follows
+const OpenAPIVersion = "3.0.3"
+
+// the types of the parameters and the schemas
+const (
+	String  = "string"
+	Integer = "integer"
+	Number  = "number"
+	Boolean = "boolean"
+	Object  = "object"
+	Array   = "array"
+)
+
+// Document is an OpenAPI 3 document
+type Document struct {
+	OpenAPI    string                           `json:"openapi"`
+	Info       Info                             `json:"info"`
+	Tags       []Tag                            `json:"tags,omitempty"`
+	Paths      map[string]map[string]*Operation `json:"paths"`
+	Components Components                       `json:"components"`
+}
+
+// Info describe the api
+type Info struct {
+	Title       string `json:"title"`
+	Version     string `json:"version"`
+	Description string `json:"description,omitempty"`
+}
+
+// Tag is a group of operations
+type Tag struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+}
+
+// Components are the schemas referred by the operations
+type Components struct {
+	Schemas map[string]*Schema `json:"schemas"`
+}
+
+// Operation is a method of a path
+type Operation struct {
+	OperationID string               `json:"operationId"`
+	Tags        []string             `json:"tags,omitempty"`
+	Summary     string               `json:"summary"`
+	Description string               `json:"description,omitempty"`
+	Parameters  []*Param             `json:"parameters,omitempty"`
+	RequestBody *RequestBody         `json:"requestBody,omitempty"`
+	Responses   map[string]*Response `json:"responses"`
+	Deprecated  bool                 `json:"deprecated,omitempty"`
+}
+
+// Param is a query or path parameter
+type Param struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+// RequestBody is the body of a request
+type RequestBody struct {
+	Required bool                  `json:"required,omitempty"`
+	Content  map[string]*MediaType `json:"content"`
+}
+
+// Response is a response of an operation
+type Response struct {
+	Description string                `json:"description"`
+	Content     map[string]*MediaType `json:"content,omitempty"`
+}
+
+// MediaType is the schema of a body in a content type
+type MediaType struct {
+	Schema *Schema `json:"schema,omitempty"`
+}
+
+// Schema is the json schema of a value, a named struct is a component referred by Ref
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Nullable             bool               `json:"nullable,omitempty"`
+	Enum                 []interface{}      `json:"enum,omitempty"`
+	Default              interface{}        `json:"default,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	Maximum              *float64           `json:"maximum,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	AllOf                []*Schema          `json:"allOf,omitempty"`
+	OneOf                []*Schema          `json:"oneOf,omitempty"`
+}
+
+// Query return a query parameter
+func Query(name, typ, description string) *Param {
+	return &Param{Name: name, In: "query", Description: description, Schema: &Schema{Type: typ}}
+}
+
+// Path return a path parameter, it is always required
+func Path(name, description string) *Param {
+	return &Param{Name: name, In: "path", Description: description, Required: true, Schema: &Schema{Type: String}}
+}
+
+// Require mark the parameter required
+func (p *Param) Require() *Param {
+	p.Required = true
+	return p
+}
+
+// Default set the value used when the parameter is omitted
+func (p *Param) Default(value interface{}) *Param {
+	p.Schema.Default = value
+	return p
+}
+
+// Enum set the values accepted by the parameter
+func (p *Param) Enum(values ...interface{}) *Param {
+	p.Schema.Enum = values
+	return p
+}
+
+// Range set the min and max value of a number parameter
+func (p *Param) Range(min, max float64) *Param {
+	p.Schema.Minimum, p.Schema.Maximum = &min, &max
+	return p
+}
@@ -0,0 +1,245 @@
+/**
+*  @file
+*  @copyright defined in scan-api/LICENSE
+ */
+
+package docs
+
+import (
+	"encoding"
+	"encoding/json"
+	"math/big"
+	"path"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+var (
+	bigIntType        = reflect.TypeOf(big.Int{})
+	timeType          = reflect.TypeOf(time.Time{})
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// Body describe the schema of a request or response body
+type Body func(s *Schemas) *Schema
+
+// Schemas generate the schemas of the go values as encoding/json marshals them, every named struct
+// is a component so that a type used by several routes is described once
+type Schemas struct {
+	components map[string]*Schema
+	names      map[reflect.Type]string
+}
+
+// NewSchemas return an empty set of components
+func NewSchemas() *Schemas {
+	return &Schemas{
+		components: make(map[string]*Schema),
+		names:      make(map[reflect.Type]string),
+	}
+}
+
+// Components return the schemas of the named structs generated so far
+func (s *Schemas) Components() map[string]*Schema {
+	return s.components
+}
+
+// Of return the body of a value of the type of v, any value if v is nil
+func Of(v interface{}) Body {
+	return func(s *Schemas) *Schema {
+		if v == nil {
+			return &Schema{}
+		}
+		return s.Of(reflect.TypeOf(v))
+	}
+}
+
+// ArrayOf return the body of an array of values of the type of v
+func ArrayOf(v interface{}) Body {
+	return func(s *Schemas) *Schema {
+		return &Schema{Type: Array, Items: Of(v)(s)}
+	}
+}
+
+// MapOf return the body of an object whose keys are described by keys and whose values have the type of v
+func MapOf(keys string, v interface{}) Body {
+	return func(s *Schemas) *Schema {
+		return &Schema{Type: Object, Description: keys, AdditionalProperties: Of(v)(s)}
+	}
+}
+
+// Fields return the body of an object with the properties, all of them are required
+func Fields(properties map[string]Body) Body {
+	return func(s *Schemas) *Schema {
+		schema := &Schema{Type: Object, Properties: make(map[string]*Schema)}
+		for name, body := range properties {
+			schema.Properties[name] = body(s)
+			schema.Required = append(schema.Required, name)
+		}
+		sort.Strings(schema.Required)
+		return schema
+	}
+}
+
+// OneOf return the body of a value matching one of the bodies
+func OneOf(bodies ...Body) Body {
+	return func(s *Schemas) *Schema {
+		schema := &Schema{}
+		for _, body := range bodies {
+			schema.OneOf = append(schema.OneOf, body(s))
+		}
+		return schema
+	}
+}
+
+// Nullable return the body which may also be null
+func Nullable(body Body) Body {
+	return func(s *Schemas) *Schema {
+		schema := body(s)
+		if schema.Ref != "" {
+			return &Schema{Nullable: true, AllOf: []*Schema{schema}}
+		}
+		schema.Nullable = true
+		return schema
+	}
+}
+
+// Describe add a description to the body
+func Describe(body Body, description string) Body {
+	return func(s *Schemas) *Schema {
+		return describe(body(s), description)
+	}
+}
+
+// describe add a description to the schema
+func describe(schema *Schema, description string) *Schema {
+	if schema.Ref != "" {
+		// the siblings of a reference are ignored, so the reference is wrapped
+		return &Schema{Description: description, AllOf: []*Schema{schema}}
+	}
+	schema.Description = description
+	return schema
+}
+
+// Of return the schema of the type
+func (s *Schemas) Of(t reflect.Type) *Schema {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch {
+	case t == bigIntType:
+		return &Schema{Type: Integer}
+	case t == timeType:
+		return &Schema{Type: String, Format: "date-time"}
+	case t.Implements(jsonMarshalerType) || reflect.PtrTo(t).Implements(jsonMarshalerType):
+		// the json is defined by the type itself
+		return &Schema{}
+	case t.Implements(textMarshalerType) || reflect.PtrTo(t).Implements(textMarshalerType):
+		return &Schema{Type: String}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: Boolean}
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint8, reflect.Uint16:
+		return &Schema{Type: Integer, Format: "int32"}
+	case reflect.Int, reflect.Int64, reflect.Uint, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: Integer, Format: "int64"}
+	case reflect.Float32:
+		return &Schema{Type: Number, Format: "float"}
+	case reflect.Float64:
+		return &Schema{Type: Number, Format: "double"}
+	case reflect.String:
+		return &Schema{Type: String}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: String, Format: "byte"}
+		}
+		return &Schema{Type: Array, Items: s.Of(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: Object, AdditionalProperties: s.Of(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return s.structOf(t)
+		}
+		return &Schema{Ref: "#/components/schemas/" + s.component(t)}
+	}
+	// interfaces may hold any value
+	return &Schema{}
+}
+
+// component return the name of the component of a named struct, it is generated at the first use
+func (s *Schemas) component(t reflect.Type) string {
+	if name, ok := s.names[t]; ok {
+		return name
+	}
+
+	name := t.Name()
+	if _, taken := s.components[name]; taken {
+		name = path.Base(t.PkgPath()) + "." + name
+	}
+	s.names[t] = name
+	// the placeholder stops the recursion of a self referencing type
+	s.components[name] = &Schema{}
+	*s.components[name] = *s.structOf(t)
+	return name
+}
+
+// structOf return the object schema of the exported fields of a struct, the embedded structs
+// without a json name are flattened like encoding/json does
+func (s *Schemas) structOf(t reflect.Type) *Schema {
+	schema := &Schema{Type: Object, Properties: make(map[string]*Schema)}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, options := tag, ""
+		if comma := strings.Index(tag, ","); comma >= 0 {
+			name, options = tag[:comma], tag[comma:]
+		}
+
+		fieldType := field.Type
+		for fieldType.Kind() == reflect.Ptr {
+			fieldType = fieldType.Elem()
+		}
+		if field.Anonymous && name == "" && fieldType.Kind() == reflect.Struct {
+			embedded := s.structOf(fieldType)
+			for property, value := range embedded.Properties {
+				if _, ok := schema.Properties[property]; !ok {
+					schema.Properties[property] = value
+				}
+			}
+			schema.Required = append(schema.Required, embedded.Required...)
+			continue
+		}
+		if field.PkgPath != "" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		property := s.Of(field.Type)
+		if strings.Contains(options, ",string") {
+			property = &Schema{Type: String}
+		}
+		switch field.Type.Kind() {
+		case reflect.Ptr, reflect.Slice, reflect.Map:
+			property.Nullable = property.Ref == ""
+		}
+		if description := field.Tag.Get("doc"); description != "" {
+			property = describe(property, description)
+		}
+		schema.Properties[name] = property
+		if !strings.Contains(options, ",omitempty") {
+			schema.Required = append(schema.Required, name)
+		}
+	}
+	sort.Strings(schema.Required)
+	return schema
+}
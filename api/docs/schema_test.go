@@ -0,0 +1,66 @@
+/**
+*  @file
+*  @copyright defined in scan-api/LICENSE
+ */
+
+package docs
+
+import (
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testBase struct {
+	ID string `json:"id"`
+}
+
+type testNode struct {
+	testBase
+	Name     string            `json:"name" doc:"the name"`
+	Count    uint64            `json:"count,omitempty"`
+	Amount   *big.Int          `json:"amount"`
+	Children []*testNode       `json:"children"`
+	Labels   map[string]string `json:"labels"`
+	Raw      []byte            `json:"raw"`
+	Plain    float64
+	Skipped  int `json:"-"`
+	hidden   int
+}
+
+func Test_Schemas(t *testing.T) {
+	s := NewSchemas()
+	schema := s.Of(reflect.TypeOf([]testNode{}))
+	assert.Equal(t, schema.Type, Array)
+	assert.Equal(t, schema.Items.Ref, "#/components/schemas/testNode")
+
+	node := s.Components()["testNode"]
+	assert.Equal(t, node.Type, Object)
+	assert.Equal(t, len(node.Properties), 8)
+	assert.Equal(t, node.Required, []string{"Plain", "amount", "children", "id", "labels", "name", "raw"})
+	assert.Equal(t, node.Properties["id"].Type, String)
+	assert.Equal(t, node.Properties["name"].Description, "the name")
+	assert.Equal(t, node.Properties["count"].Format, "int64")
+	assert.Equal(t, node.Properties["amount"].Type, Integer)
+	assert.Equal(t, node.Properties["amount"].Nullable, true)
+	// the self reference is a reference to the component being generated
+	assert.Equal(t, node.Properties["children"].Items.Ref, "#/components/schemas/testNode")
+	assert.Equal(t, node.Properties["labels"].AdditionalProperties.Type, String)
+	assert.Equal(t, node.Properties["raw"].Format, "byte")
+	assert.Equal(t, node.Properties["Plain"].Type, Number)
+
+	body := Fields(map[string]Body{"node": Describe(Of(testNode{}), "a node"), "any": Of(nil)})(s)
+	assert.Equal(t, body.Required, []string{"any", "node"})
+	assert.Equal(t, body.Properties["node"].AllOf[0].Ref, "#/components/schemas/testNode")
+	assert.Equal(t, body.Properties["any"], &Schema{})
+}
+
+func Test_OpenAPIPath(t *testing.T) {
+	assert.Equal(t, OpenAPIPath("/api/v2/blocks/:id/txs"), "/api/v2/blocks/{id}/txs")
+	assert.Equal(t, OpenAPIPath("/static/*file"), "/static/{file}")
+	assert.Equal(t, operationID("GET", "/api/v1/contract/methods"), "getApiV1ContractMethods")
+	assert.Equal(t, joinPaths("/api/v1", "./nodes"), "/api/v1/nodes")
+	assert.Equal(t, joinPaths("/api/docs", ""), "/api/docs")
+}
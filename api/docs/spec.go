@@ -0,0 +1,233 @@
+/**
+*  @file
+*  @copyright defined in scan-api/LICENSE
+ */
+
+package docs
+
+import (
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Route describe a registered route, its method and path are taken from the registration
+type Route struct {
+	Tag         string
+	Summary     string
+	Description string
+	Params      []*Param
+	Form        []*Param // the form fields of a POST
+	Body        Body     // the json body of a POST
+	Data        Body     // the data of a success, wrapped by the envelope of the group
+	ContentType string   // application/json by default
+	Deprecated  bool
+
+	method string
+	path   string
+	group  *Group
+}
+
+// Envelope describe how a group of routes wraps its data and the errors they respond, the
+// data is sent as it is if the group has no envelope
+type Envelope struct {
+	Wrap   func(data *Schema) *Schema
+	Errors []Error
+}
+
+// Error is an error response of the routes in an envelope
+type Error struct {
+	Status      int
+	Description string
+	Body        Body
+}
+
+// Spec collect the routes documented when they are registered and generate the OpenAPI document
+type Spec struct {
+	info   Info
+	tags   []Tag
+	routes []*Route
+}
+
+// NewSpec return a spec without routes
+func NewSpec(info Info, tags ...Tag) *Spec {
+	return &Spec{info: info, tags: tags}
+}
+
+// Group is a gin router group which documents the routes it registers
+type Group struct {
+	spec     *Spec
+	group    *gin.RouterGroup
+	envelope *Envelope
+}
+
+// Group return the documented group of a gin router group, the envelope may be nil
+func (s *Spec) Group(group *gin.RouterGroup, envelope *Envelope) *Group {
+	return &Group{spec: s, group: group, envelope: envelope}
+}
+
+// Group return the documented sub group, it has the envelope of its parent
+func (g *Group) Group(relativePath string) *Group {
+	return &Group{spec: g.spec, group: g.group.Group(relativePath), envelope: g.envelope}
+}
+
+// GET register and document a GET route
+func (g *Group) GET(relativePath string, handler gin.HandlerFunc, route Route) {
+	g.handle(http.MethodGet, relativePath, handler, route)
+}
+
+// POST register and document a POST route
+func (g *Group) POST(relativePath string, handler gin.HandlerFunc, route Route) {
+	g.handle(http.MethodPost, relativePath, handler, route)
+}
+
+func (g *Group) handle(method, relativePath string, handler gin.HandlerFunc, route Route) {
+	g.group.Handle(method, relativePath, handler)
+	route.method = method
+	route.path = joinPaths(g.group.BasePath(), relativePath)
+	route.group = g
+	g.spec.routes = append(g.spec.routes, &route)
+}
+
+// joinPaths join the paths as gin does, the trailing slash is kept
+func joinPaths(absolutePath, relativePath string) string {
+	if relativePath == "" {
+		return absolutePath
+	}
+	joined := path.Join(absolutePath, relativePath)
+	if strings.HasSuffix(relativePath, "/") && !strings.HasSuffix(joined, "/") {
+		return joined + "/"
+	}
+	return joined
+}
+
+// OpenAPIPath convert a gin path to an OpenAPI path, :name and *name are {name}
+func OpenAPIPath(ginPath string) string {
+	segments := strings.Split(ginPath, "/")
+	for i, segment := range segments {
+		if strings.HasPrefix(segment, ":") || strings.HasPrefix(segment, "*") {
+			segments[i] = "{" + segment[1:] + "}"
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+// operationID return the id of an operation, such as getApiV1Block for GET /api/v1/block
+func operationID(method, openAPIPath string) string {
+	id := strings.ToLower(method)
+	for _, segment := range strings.FieldsFunc(openAPIPath, func(r rune) bool {
+		return r == '/' || r == '{' || r == '}' || r == '.' || r == '-' || r == '_'
+	}) {
+		id += strings.ToUpper(segment[:1]) + segment[1:]
+	}
+	return id
+}
+
+// Document generate the OpenAPI document of the routes registered so far
+func (s *Spec) Document() *Document {
+	schemas := NewSchemas()
+	doc := &Document{
+		OpenAPI: OpenAPIVersion,
+		Info:    s.info,
+		Tags:    s.tags,
+		Paths:   make(map[string]map[string]*Operation),
+	}
+
+	for _, route := range s.routes {
+		openAPIPath := OpenAPIPath(route.path)
+		if doc.Paths[openAPIPath] == nil {
+			doc.Paths[openAPIPath] = make(map[string]*Operation)
+		}
+		doc.Paths[openAPIPath][strings.ToLower(route.method)] = route.operation(openAPIPath, schemas)
+	}
+	doc.Components.Schemas = schemas.Components()
+	return doc
+}
+
+// operation generate the operation of the route
+func (r *Route) operation(openAPIPath string, schemas *Schemas) *Operation {
+	op := &Operation{
+		OperationID: operationID(r.method, openAPIPath),
+		Summary:     r.Summary,
+		Description: r.Description,
+		Parameters:  append([]*Param(nil), r.Params...),
+		Responses:   make(map[string]*Response),
+		Deprecated:  r.Deprecated,
+	}
+	if r.Tag != "" {
+		op.Tags = []string{r.Tag}
+	}
+
+	// the path parameters are always documented even if the route does not describe them
+	for _, segment := range strings.Split(openAPIPath, "/") {
+		if strings.HasPrefix(segment, "{") && !r.hasParam(segment[1:len(segment)-1]) {
+			op.Parameters = append(op.Parameters, Path(segment[1:len(segment)-1], ""))
+		}
+	}
+
+	if len(r.Form) > 0 {
+		form := &Schema{Type: Object, Properties: make(map[string]*Schema)}
+		for _, field := range r.Form {
+			property := *field.Schema
+			property.Description = field.Description
+			form.Properties[field.Name] = &property
+			if field.Required {
+				form.Required = append(form.Required, field.Name)
+			}
+		}
+		op.RequestBody = &RequestBody{
+			Required: len(form.Required) > 0,
+			Content:  map[string]*MediaType{"application/x-www-form-urlencoded": {Schema: form}},
+		}
+	}
+
+	if r.Body != nil {
+		op.RequestBody = &RequestBody{
+			Required: true,
+			Content:  map[string]*MediaType{"application/json": {Schema: r.Body(schemas)}},
+		}
+	}
+
+	contentType := r.ContentType
+	if contentType == "" {
+		contentType = "application/json"
+	}
+	var data *Schema
+	if r.Data != nil {
+		data = r.Data(schemas)
+	}
+	envelope := r.group.envelope
+	if envelope != nil && envelope.Wrap != nil {
+		if data == nil {
+			data = &Schema{}
+		}
+		data = envelope.Wrap(data)
+	}
+	op.Responses["200"] = &Response{Description: "success"}
+	if data != nil {
+		op.Responses["200"].Content = map[string]*MediaType{contentType: {Schema: data}}
+	}
+
+	if envelope != nil {
+		for _, e := range envelope.Errors {
+			response := &Response{Description: e.Description}
+			if e.Body != nil {
+				response.Content = map[string]*MediaType{"application/json": {Schema: e.Body(schemas)}}
+			}
+			op.Responses[strconv.Itoa(e.Status)] = response
+		}
+	}
+	return op
+}
+
+func (r *Route) hasParam(name string) bool {
+	for _, param := range r.Params {
+		if param.Name == name && param.In == "path" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,245 @@
+/**
+*  @file
+*  @copyright defined in scan-api/LICENSE
+ */
+
+package docs
+
+// uiPage is the bundled docs page, it renders the operations of openapi.json with their
+// parameters and response schemas and sends requests to try them
+const uiPage = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>API docs</title>
+<style>
+body { font-family: -apple-system, "Segoe UI", Helvetica, Arial, sans-serif; margin: 0; color: #222; background: #f6f7f9; }
+header { background: #1f2d3d; color: #fff; padding: 16px 24px; }
+header h1 { margin: 0; font-size: 22px; }
+header p { margin: 6px 0 0; color: #c8d1dc; white-space: pre-wrap; }
+main { max-width: 1100px; margin: 0 auto; padding: 16px 24px; }
+#filter { width: 100%; box-sizing: border-box; padding: 8px; font-size: 14px; margin-bottom: 12px; }
+h2 { font-size: 18px; margin: 24px 0 4px; }
+.tagdesc { color: #666; margin: 0 0 8px; }
+.op { background: #fff; border: 1px solid #dde1e6; border-radius: 4px; margin: 6px 0; }
+.op > .head { cursor: pointer; padding: 8px 12px; display: flex; gap: 12px; align-items: center; }
+.method { font-weight: bold; font-size: 12px; color: #fff; border-radius: 3px; padding: 3px 0; width: 56px; text-align: center; }
+.get { background: #2f80ed; } .post { background: #27ae60; }
+.path { font-family: Menlo, Consolas, monospace; }
+.summary { color: #555; }
+.deprecated .path { text-decoration: line-through; }
+.body { display: none; padding: 8px 12px 12px; border-top: 1px solid #eee; }
+.open > .body { display: block; }
+table { border-collapse: collapse; width: 100%; margin: 6px 0; }
+th, td { text-align: left; padding: 4px 8px; border-bottom: 1px solid #eee; vertical-align: top; font-size: 13px; }
+td input { width: 100%; box-sizing: border-box; }
+pre { background: #f3f4f6; padding: 8px; overflow: auto; font-size: 12px; max-height: 480px; }
+button { padding: 6px 16px; cursor: pointer; }
+.status { font-weight: bold; margin-left: 8px; }
+h4 { margin: 12px 0 4px; }
+</style>
+</head>
+<body>
+<header><h1 id="title">API docs</h1><p id="description"></p></header>
+<main>
+<input id="filter" placeholder="filter by path or summary">
+<div id="ops">loading openapi.json ...</div>
+</main>
+<script>
+(function () {
+  var specURL = location.pathname.replace(/\/+$/, "") + "/openapi.json";
+  var spec;
+  var ops = {};
+
+  function esc(s) {
+    return String(s === undefined ? "" : s).replace(/[&<>"]/g, function (c) {
+      return { "&": "&amp;", "<": "&lt;", ">": "&gt;", "\"": "&quot;" }[c];
+    });
+  }
+
+  function resolve(schema) {
+    while (schema && schema.$ref) {
+      schema = spec.components.schemas[schema.$ref.split("/").pop()];
+    }
+    return schema || {};
+  }
+
+  // typeOf describe the type of a schema in one word
+  function typeOf(schema) {
+    if (schema.$ref) return schema.$ref.split("/").pop();
+    if (schema.allOf) return typeOf(schema.allOf[0]);
+    if (schema.oneOf) return schema.oneOf.map(typeOf).join(" | ");
+    if (schema.type === "array") return typeOf(schema.items || {}) + "[]";
+    if (schema.type === "object" && schema.additionalProperties) return "map of " + typeOf(schema.additionalProperties);
+    return (schema.type || "any") + (schema.format ? " (" + schema.format + ")" : "");
+  }
+
+  // render write the schema as an indented outline, the components are expanded once per branch
+  function render(schema, indent, seen) {
+    if (schema.allOf) schema = schema.allOf[0];
+    if (schema.oneOf) {
+      return schema.oneOf.map(function (one) {
+        return indent + "one of " + esc(typeOf(one)) + "\n" + render(one, indent + "  ", seen);
+      }).join("");
+    }
+    var name = schema.$ref ? schema.$ref.split("/").pop() : "";
+    if (name && seen[name]) return "";
+    var inner = {};
+    for (var k in seen) inner[k] = true;
+    if (name) inner[name] = true;
+    schema = resolve(schema);
+    if (schema.type === "array") return render(schema.items || {}, indent, inner);
+    if (schema.type === "object" && schema.additionalProperties) return render(schema.additionalProperties, indent, inner);
+    var out = "";
+    var props = schema.properties || {};
+    var required = schema.required || [];
+    Object.keys(props).forEach(function (p) {
+      var prop = props[p];
+      var line = indent + p + ": " + typeOf(prop);
+      if (required.indexOf(p) < 0) line += ", optional";
+      if (prop.nullable) line += ", nullable";
+      if (prop.description) line += "  // " + prop.description;
+      out += esc(line) + "\n" + render(prop, indent + "  ", inner);
+    });
+    return out;
+  }
+
+  function paramRows(params, id) {
+    var rows = "";
+    params.forEach(function (p, i) {
+      var schema = p.schema || {};
+      var hint = schema["default"] !== undefined ? "default " + schema["default"] : "";
+      if (schema.enum) hint = schema.enum.join(" | ");
+      rows += "<tr><td>" + esc(p.name) + (p.required ? " *" : "") + "</td><td>" + esc(p["in"]) +
+        "</td><td>" + esc(typeOf(schema)) + "</td><td>" + esc(p.description) + "</td><td><input data-op=\"" +
+        id + "\" data-i=\"" + i + "\" placeholder=\"" + esc(hint) + "\"></td></tr>";
+    });
+    return rows;
+  }
+
+  function operationHTML(path, method, op) {
+    var id = op.operationId;
+    var params = (op.parameters || []).slice();
+    var form = op.requestBody && op.requestBody.content["application/x-www-form-urlencoded"];
+    var body = op.requestBody && op.requestBody.content["application/json"];
+    if (form) {
+      var props = form.schema.properties;
+      Object.keys(props).forEach(function (name) {
+        params.push({ name: name, "in": "form", description: props[name].description, schema: props[name],
+          required: (form.schema.required || []).indexOf(name) >= 0 });
+      });
+    }
+    if (body) {
+      params.push({ name: "body", "in": "body", description: "the json body", schema: body.schema, required: true });
+    }
+    var html = "<div class=\"op" + (op.deprecated ? " deprecated" : "") + "\" data-search=\"" +
+      esc((path + " " + op.summary).toLowerCase()) + "\"><div class=\"head\"><span class=\"method " + method + "\">" +
+      method.toUpperCase() + "</span><span class=\"path\">" + esc(path) + "</span><span class=\"summary\">" +
+      esc(op.summary) + "</span></div><div class=\"body\">";
+    if (op.description) html += "<p>" + esc(op.description) + "</p>";
+    if (params.length) {
+      html += "<h4>Parameters</h4><table><tr><th>name</th><th>in</th><th>type</th><th>description</th><th>value</th></tr>" +
+        paramRows(params, id) + "</table>";
+    }
+    html += "<h4>Responses</h4>";
+    Object.keys(op.responses).sort().forEach(function (status) {
+      var response = op.responses[status];
+      html += "<div><b>" + esc(status) + "</b> " + esc(response.description) + "</div>";
+      var content = response.content || {};
+      Object.keys(content).forEach(function (type) {
+        var schema = content[type].schema;
+        if (schema) html += "<pre>" + esc(type) + "\n" + render(schema, "", {}) + "</pre>";
+      });
+    });
+    html += "<button data-try=\"" + id + "\">Try it</button><span class=\"status\"></span><pre class=\"result\" hidden></pre></div></div>";
+    ops[id] = { path: path, method: method, params: params };
+    return html;
+  }
+
+  function tryIt(button) {
+    var op = ops[button.getAttribute("data-try")];
+    var url = op.path;
+    var query = [];
+    var form = [];
+    var body = null;
+    var inputs = document.querySelectorAll("input[data-op=\"" + button.getAttribute("data-try") + "\"]");
+    Array.prototype.forEach.call(inputs, function (input) {
+      var p = op.params[input.getAttribute("data-i")];
+      if (input.value === "") return;
+      var pair = encodeURIComponent(p.name) + "=" + encodeURIComponent(input.value);
+      if (p["in"] === "path") url = url.replace("{" + p.name + "}", encodeURIComponent(input.value));
+      else if (p["in"] === "form") form.push(pair);
+      else if (p["in"] === "body") body = input.value;
+      else query.push(pair);
+    });
+    if (query.length) url += "?" + query.join("&");
+    var init = { method: op.method.toUpperCase() };
+    if (body !== null) {
+      init.headers = { "Content-Type": "application/json" };
+      init.body = body;
+    } else if (op.method === "post") {
+      init.headers = { "Content-Type": "application/x-www-form-urlencoded" };
+      init.body = form.join("&");
+    }
+    var status = button.nextSibling;
+    var result = status.nextSibling;
+    status.textContent = "...";
+    fetch(url, init).then(function (res) {
+      status.textContent = res.status + " " + url;
+      return res.text();
+    }).then(function (text) {
+      try { text = JSON.stringify(JSON.parse(text), null, 2); } catch (e) {}
+      result.textContent = text;
+      result.hidden = false;
+    }).catch(function (e) {
+      status.textContent = String(e);
+    });
+  }
+
+  function show() {
+    document.title = spec.info.title;
+    document.getElementById("title").textContent = spec.info.title + " " + spec.info.version;
+    document.getElementById("description").textContent = spec.info.description || "";
+    var byTag = {};
+    var order = (spec.tags || []).map(function (t) { return t.name; });
+    Object.keys(spec.paths).sort().forEach(function (path) {
+      Object.keys(spec.paths[path]).sort().forEach(function (method) {
+        var op = spec.paths[path][method];
+        var tag = (op.tags || ["other"])[0];
+        if (order.indexOf(tag) < 0) order.push(tag);
+        (byTag[tag] = byTag[tag] || []).push(operationHTML(path, method, op));
+      });
+    });
+    var html = "";
+    order.forEach(function (tag) {
+      if (!byTag[tag]) return;
+      var desc = (spec.tags || []).filter(function (t) { return t.name === tag; })[0];
+      html += "<section><h2>" + esc(tag) + "</h2>" + (desc && desc.description ? "<p class=\"tagdesc\">" +
+        esc(desc.description) + "</p>" : "") + byTag[tag].join("") + "</section>";
+    });
+    document.getElementById("ops").innerHTML = html;
+  }
+
+  document.addEventListener("click", function (e) {
+    var head = e.target.closest(".head");
+    if (head) head.parentNode.classList.toggle("open");
+    if (e.target.hasAttribute("data-try")) tryIt(e.target);
+  });
+  document.getElementById("filter").addEventListener("input", function (e) {
+    var q = e.target.value.toLowerCase();
+    Array.prototype.forEach.call(document.querySelectorAll(".op"), function (op) {
+      op.style.display = op.getAttribute("data-search").indexOf(q) >= 0 ? "" : "none";
+    });
+  });
+
+  fetch(specURL).then(function (res) { return res.json(); }).then(function (doc) {
+    spec = doc;
+    show();
+  }).catch(function (e) {
+    document.getElementById("ops").textContent = "could not load " + specURL + ": " + e;
+  });
+})();
+</script>
+</body>
+</html>
+`
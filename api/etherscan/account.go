@@ -0,0 +1,196 @@
+/**
+*  @file
+*  @copyright defined in scan-api/LICENSE
+ */
+
+package etherscan
+
+import (
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/seeleteam/scan-api/database"
+	"gopkg.in/mgo.v2"
+)
+
+const (
+	// maxAccounts is the number of addresses of balancemulti
+	maxAccounts = 20
+
+	// maxResults is the number of transactions of txlist, page x offset can not be over it
+	maxResults = 10000
+
+	errResultWindow = "Result window is too large, PageNo x Offset size must be less than or equal to 10000"
+)
+
+// balance get the balance of an address, 0 if the address is unknown
+func (h *Handler) balance(c *gin.Context) *Response {
+	address, valid := addressParam(c)
+	if !valid {
+		return failure(errInvalidAddress)
+	}
+	account, err := h.DBClient.GetAccountByAddress(address)
+	if err == mgo.ErrNotFound {
+		return success("0")
+	} else if err != nil {
+		return failure(errDatabase)
+	}
+	return success(strconv.FormatInt(account.Balance, 10))
+}
+
+// balanceMulti get the balances of the addresses separated by commas, in the order of the addresses
+func (h *Handler) balanceMulti(c *gin.Context) *Response {
+	addresses := strings.Split(param(c, "address"), ",")
+	if len(addresses) > maxAccounts {
+		return failure(errTooManyAccounts)
+	}
+	for _, address := range addresses {
+		if !isAddress(address) {
+			return failure(errInvalidAddress)
+		}
+	}
+
+	accounts, err := h.DBClient.GetAccountsByAddresses(addresses)
+	if err != nil {
+		return failure(errDatabase)
+	}
+	balances := make(map[string]int64, len(accounts))
+	for _, account := range accounts {
+		balances[account.Address] = account.Balance
+	}
+	result := make([]*Balance, len(addresses))
+	for i, address := range addresses {
+		result[i] = &Balance{Account: address, Balance: strconv.FormatInt(balances[address], 10)}
+	}
+	return success(result)
+}
+
+// txList list the transactions from or to an address in the blocks from startblock to endblock,
+// sort is asc by default or desc
+func (h *Handler) txList(c *gin.Context) *Response {
+	address, valid := addressParam(c)
+	if !valid {
+		return failure(errInvalidAddress)
+	}
+	startBlock, ok := uintParam(c, "startblock", 0)
+	if !ok {
+		return failure(errInvalidBlock)
+	}
+	endBlock, ok := uintParam(c, "endblock", math.MaxInt64)
+	if !ok {
+		return failure(errInvalidBlock)
+	}
+	// etherscan lists all the transactions up to the window if there is no page
+	page, ok := uintParam(c, "page", 1)
+	if !ok || page < 1 {
+		return failure(errInvalidParam)
+	}
+	offset, ok := uintParam(c, "offset", maxResults)
+	if !ok || offset < 1 {
+		return failure(errInvalidParam)
+	}
+	if page > maxResults || offset > maxResults || page*offset > maxResults {
+		return failure(errResultWindow)
+	}
+	sort := param(c, "sort")
+	if sort != "" && sort != "asc" && sort != "desc" {
+		return failure(errInvalidParam)
+	}
+
+	txs, err := h.DBClient.GetTxsByAddressAndBlocks(address, startBlock, endBlock, sort != "desc", int(offset), int((page-1)*offset))
+	if err != nil {
+		return failure(errDatabase)
+	}
+	if len(txs) == 0 {
+		return &Response{Status: statusNotOK, Message: messageNoTxs, Result: []*Tx{}}
+	}
+	result, err := h.createTxs(txs)
+	if err != nil {
+		return failure(errDatabase)
+	}
+	return success(result)
+}
+
+// createTxs convert the transactions, their blocks are read by one query for each shard
+func (h *Handler) createTxs(txs []*database.DBTx) ([]*Tx, error) {
+	heights := make(map[int][]uint64)
+	for _, tx := range txs {
+		heights[tx.ShardNumber] = append(heights[tx.ShardNumber], tx.Block)
+	}
+	blocks := make(map[int]map[uint64]*database.DBBlock, len(heights))
+	latest := make(map[int]uint64, len(heights))
+	for shard, shardHeights := range heights {
+		shardBlocks, err := h.DBClient.GetBlocksByHeights(shard, shardHeights)
+		if err != nil {
+			return nil, err
+		}
+		blocks[shard] = make(map[uint64]*database.DBBlock, len(shardBlocks))
+		for _, block := range shardBlocks {
+			blocks[shard][uint64(block.Height)] = block
+		}
+		// the next height to sync
+		if latest[shard], err = h.DBClient.GetBlockHeight(shard); err != nil {
+			return nil, err
+		}
+	}
+
+	result := make([]*Tx, len(txs))
+	for i, tx := range txs {
+		item := &Tx{
+			BlockNumber:     strconv.FormatUint(tx.Block, 10),
+			TimeStamp:       strconv.FormatInt(tx.Timestamp, 10),
+			Hash:            tx.Hash,
+			Nonce:           tx.AccountNonce,
+			From:            tx.From,
+			To:              tx.To,
+			Value:           strconv.FormatInt(tx.Amount, 10),
+			Gas:             strconv.FormatInt(tx.GasLimit, 10),
+			GasPrice:        strconv.FormatInt(tx.GasPrice, 10),
+			IsError:         "0",
+			TxReceiptStatus: "1",
+			Input:           tx.Payload,
+			ContractAddress: tx.ContractAddress,
+			GasUsed:         strconv.FormatInt(tx.UsedGas, 10),
+			Shard:           tx.ShardNumber,
+		}
+		if tx.Receipt.Failed {
+			item.IsError, item.TxReceiptStatus = "1", "0"
+		}
+		if item.Input == "" {
+			item.Input = "0x"
+		}
+		if next := latest[tx.ShardNumber]; next > tx.Block {
+			item.Confirmations = strconv.FormatUint(next-tx.Block, 10)
+		} else {
+			item.Confirmations = "0"
+		}
+		if block := blocks[tx.ShardNumber][tx.Block]; block != nil {
+			item.BlockHash = block.HeadHash
+			item.TransactionIndex = strconv.Itoa(txIndex(block, tx.Hash))
+		}
+		result[i] = item
+	}
+	return result, nil
+}
+
+// txIndex return the index of a transaction in its block, the index is not stored with the transaction
+func txIndex(block *database.DBBlock, hash string) int {
+	for i, tx := range block.Txs {
+		if tx.Hash == hash {
+			return i
+		}
+	}
+	return 0
+}
+
+// uintParam return an unsigned parameter, or its default if it is empty
+func uintParam(c *gin.Context, name string, defaultValue uint64) (uint64, bool) {
+	value := param(c, name)
+	if value == "" {
+		return defaultValue, true
+	}
+	n, err := strconv.ParseUint(value, 10, 64)
+	return n, err == nil
+}
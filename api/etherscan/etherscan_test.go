@@ -0,0 +1,219 @@
+/**
+*  @file
+*  @copyright defined in scan-api/LICENSE
+ */
+
+package etherscan
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/seeleteam/scan-api/database"
+	"github.com/seeleteam/scan-api/log"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	testSender   = "0x4c10f2cd2159bb432094e3be7e17904c2b4aeb21"
+	testReceiver = "0x1b9ddc9ea4a5d5d7e5e71df1e1b0f5e2c4d6b3b1"
+	testContract = "0x0a4e5c2b9d8f6e3a1b7c9d0e2f4a6b8c0d2e4f61"
+	testUnknown  = "0x0000000000000000000000000000000000000001"
+	testTxHash   = "0x00000000000000000000000000000000000000000000000000000000000a0102"
+)
+
+func newTestEngine(t *testing.T) *gin.Engine {
+	log.NewLogger("", "error", false)
+	gin.SetMode(gin.TestMode)
+	db := database.NewMemoryClient(1)
+	for height := int64(0); height < 3; height++ {
+		block := &database.DBBlock{
+			HeadHash: "0x0b0" + string(rune('0'+height)), PreHash: "0x0b0", Height: height, ShardNumber: 1,
+			Creator: testSender, Timestamp: 1500000000 + height*10, Reward: 150, TxFee: 3, DebtFee: 1,
+			Difficulty: "255", Nonce: "16",
+		}
+		if height == 1 {
+			block.Txs = []database.DBSimpleTxInBlock{
+				{Hash: "0x0a0101", From: testSender, To: testReceiver, Amount: 5, GasLimit: 21000},
+				{Hash: testTxHash, From: testReceiver, To: testSender, Amount: 7, GasLimit: 21000},
+			}
+		}
+		assert.Nil(t, db.AddBlock(block))
+	}
+	assert.Nil(t, db.AddTxs(
+		&database.DBTx{Hash: "0x0a0101", From: testSender, To: testReceiver, Amount: 5, Block: 1, Idx: 1, ShardNumber: 1, Timestamp: 1500000010, AccountNonce: "1", GasLimit: 21000},
+		&database.DBTx{Hash: testTxHash, From: testReceiver, To: testSender, Amount: 7, Block: 1, Idx: 2, ShardNumber: 1, Timestamp: 1500000010, AccountNonce: "10", GasLimit: 21000, Payload: "0x01"},
+		&database.DBTx{Hash: "0x0a0201", From: testSender, ContractAddress: testContract, TxType: 1, Block: 2, Idx: 3, ShardNumber: 1, Timestamp: 1500000020},
+	))
+	assert.Nil(t, db.AddAccount(&database.DBAccount{Address: testSender, ShardNumber: 1, Balance: 100}))
+	assert.Nil(t, db.AddAccount(&database.DBAccount{Address: testReceiver, ShardNumber: 1, Balance: 200}))
+	assert.Nil(t, db.AddAccount(&database.DBAccount{Address: testContract, ShardNumber: 1, AccType: 1, ABI: `[{"type":"function","name":"get"}]`}))
+
+	e := gin.New()
+	h := NewHandler(db)
+	e.GET("/api", h.API())
+	e.POST("/api", h.API())
+	return e
+}
+
+func get(t *testing.T, e *gin.Engine, query string, body interface{}) {
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, httptest.NewRequest("GET", "/api?"+query, nil))
+	assert.Equal(t, w.Code, http.StatusOK)
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), body), w.Body.String())
+}
+
+func Test_Account(t *testing.T) {
+	e := newTestEngine(t)
+
+	var resp Response
+	get(t, e, "module=account&action=balance&tag=latest&address="+testReceiver, &resp)
+	assert.Equal(t, resp, Response{Status: "1", Message: "OK", Result: "200"})
+	get(t, e, "module=account&action=balance&address="+testUnknown, &resp)
+	assert.Equal(t, resp.Result, "0")
+	get(t, e, "module=account&action=balance&address=0x12", &resp)
+	assert.Equal(t, resp, Response{Status: "0", Message: "NOTOK", Result: errInvalidAddress})
+
+	var balances struct {
+		Status string
+		Result []*Balance
+	}
+	get(t, e, "module=account&action=balancemulti&address="+testSender+","+testUnknown, &balances)
+	assert.Equal(t, balances.Status, "1")
+	assert.Equal(t, balances.Result, []*Balance{{Account: testSender, Balance: "100"}, {Account: testUnknown, Balance: "0"}})
+	get(t, e, "module=account&action=balancemulti&address="+strings.Repeat(testSender+",", 20)+testSender, &resp)
+	assert.Equal(t, resp.Result, errTooManyAccounts)
+}
+
+func Test_TxList(t *testing.T) {
+	e := newTestEngine(t)
+
+	var txs struct {
+		Status  string
+		Message string
+		Result  []*Tx
+	}
+	get(t, e, "module=account&action=txlist&address="+testSender, &txs)
+	assert.Equal(t, txs.Status, "1")
+	if !assert.Equal(t, len(txs.Result), 3) {
+		return
+	}
+	assert.Equal(t, *txs.Result[1], Tx{
+		BlockNumber: "1", TimeStamp: "1500000010", Hash: testTxHash, Nonce: "10", BlockHash: "0x0b01",
+		TransactionIndex: "1", From: testReceiver, To: testSender, Value: "7", Gas: "21000", GasPrice: "0",
+		IsError: "0", TxReceiptStatus: "1", Input: "0x01", GasUsed: "0", Confirmations: "2", Shard: 1,
+	})
+	assert.Equal(t, txs.Result[2].ContractAddress, testContract)
+	assert.Equal(t, txs.Result[2].Input, "0x")
+
+	get(t, e, "module=account&action=txlist&sort=desc&page=1&offset=1&address="+testSender, &txs)
+	assert.Equal(t, len(txs.Result), 1)
+	assert.Equal(t, txs.Result[0].Hash, "0x0a0201")
+	get(t, e, "module=account&action=txlist&startblock=2&endblock=2&address="+testReceiver, &txs)
+	assert.Equal(t, txs.Status, "0")
+	assert.Equal(t, txs.Message, messageNoTxs)
+	assert.Equal(t, txs.Result, []*Tx{})
+
+	var resp Response
+	get(t, e, "module=account&action=txlist&page=101&offset=100&address="+testSender, &resp)
+	assert.Equal(t, resp.Result, errResultWindow)
+	get(t, e, "module=account&action=txlist&sort=up&address="+testSender, &resp)
+	assert.Equal(t, resp.Result, errInvalidParam)
+}
+
+func Test_BlockAndContract(t *testing.T) {
+	e := newTestEngine(t)
+
+	var reward struct {
+		Status string
+		Result BlockReward
+	}
+	get(t, e, "module=block&action=getblockreward&blockno=1", &reward)
+	assert.Equal(t, reward.Status, "1")
+	assert.Equal(t, reward.Result, BlockReward{
+		BlockNumber: "1", TimeStamp: "1500000010", BlockMiner: testSender, BlockReward: "154", Uncles: []string{}, UncleInclusionReward: "0",
+	})
+
+	var resp Response
+	get(t, e, "module=block&action=getblockreward&blockno=9", &resp)
+	assert.Equal(t, resp.Result, errBlockNotFound)
+	get(t, e, "module=block&action=getblockreward&blockno=1&shard=5", &resp)
+	assert.Equal(t, resp.Result, errInvalidShard)
+
+	get(t, e, "module=contract&action=getabi&address="+testContract, &resp)
+	assert.Equal(t, resp, Response{Status: "1", Message: "OK", Result: `[{"type":"function","name":"get"}]`})
+	get(t, e, "module=contract&action=getabi&address="+testSender, &resp)
+	assert.Equal(t, resp, Response{Status: "0", Message: "NOTOK", Result: errNotVerified})
+
+	get(t, e, "module=stats&action=ethsupply", &resp)
+	assert.Equal(t, resp.Result, errInvalidModule)
+	get(t, e, "module=account&action=tokentx", &resp)
+	assert.Equal(t, resp.Result, errInvalidAction)
+
+	// the parameters are read from the form of a POST too
+	w := httptest.NewRecorder()
+	form := url.Values{"module": {"account"}, "action": {"balance"}, "address": {testSender}}
+	r := httptest.NewRequest("POST", "/api", strings.NewReader(form.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	e.ServeHTTP(w, r)
+	assert.Equal(t, w.Body.String(), `{"status":"1","message":"OK","result":"100"}`)
+}
+
+func Test_Proxy(t *testing.T) {
+	e := newTestEngine(t)
+
+	var resp struct {
+		JSONRPC string
+		ID      json.RawMessage
+		Result  json.RawMessage
+		Error   *ProxyError
+	}
+	get(t, e, "module=proxy&action=eth_blockNumber", &resp)
+	assert.Equal(t, resp.JSONRPC, "2.0")
+	assert.Equal(t, string(resp.ID), "1")
+	assert.Equal(t, string(resp.Result), `"0x2"`)
+
+	get(t, e, "module=proxy&action=eth_getBlockTransactionCountByNumber&tag=0x1&id=7", &resp)
+	assert.Equal(t, string(resp.ID), "7")
+	assert.Equal(t, string(resp.Result), `"0x2"`)
+
+	var block RPCBlock
+	get(t, e, "module=proxy&action=eth_getBlockByNumber&tag=0x1&boolean=false", &resp)
+	assert.Nil(t, json.Unmarshal(resp.Result, &block))
+	assert.Equal(t, block.Number, "0x1")
+	assert.Equal(t, block.Hash, "0x0b01")
+	assert.Equal(t, block.Difficulty, "0xff")
+	assert.Equal(t, block.Nonce, "0x10")
+	assert.Equal(t, block.Transactions, []interface{}{"0x0a0101", testTxHash})
+
+	get(t, e, "module=proxy&action=eth_getBlockByNumber&tag=latest&boolean=true", &resp)
+	assert.Nil(t, json.Unmarshal(resp.Result, &block))
+	assert.Equal(t, block.Number, "0x2")
+	get(t, e, "module=proxy&action=eth_getBlockByNumber&tag=0x9", &resp)
+	assert.Equal(t, string(resp.Result), "null")
+	get(t, e, "module=proxy&action=eth_getBlockByNumber&tag=9", &resp)
+	assert.Equal(t, resp.Error.Code, rpcInvalidParams)
+
+	var tx RPCTx
+	get(t, e, "module=proxy&action=eth_getTransactionByHash&txhash="+testTxHash, &resp)
+	assert.Nil(t, json.Unmarshal(resp.Result, &tx))
+	assert.Equal(t, tx.Nonce, "0xa")
+	assert.Equal(t, *tx.BlockHash, "0x0b01")
+	assert.Equal(t, *tx.TransactionIndex, "0x1")
+	assert.Equal(t, tx.Value, "0x7")
+	assert.Equal(t, tx.Gas, "0x5208")
+
+	get(t, e, "module=proxy&action=eth_getTransactionByBlockNumberAndIndex&tag=0x1&index=0x0", &resp)
+	assert.Nil(t, json.Unmarshal(resp.Result, &tx))
+	assert.Equal(t, tx.Hash, "0x0a0101")
+
+	resp.Result, resp.Error = nil, nil
+	get(t, e, "module=proxy&action=eth_sendRawTransaction&hex=0x00", &resp)
+	assert.Nil(t, resp.Result)
+	assert.Equal(t, resp.Error.Code, rpcMethodNotFound)
+}
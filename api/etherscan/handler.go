@@ -0,0 +1,180 @@
+/**
+*  @file
+*  @copyright defined in scan-api/LICENSE
+ */
+
+package etherscan
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/seeleteam/scan-api/api/handlers"
+	"gopkg.in/mgo.v2"
+)
+
+const (
+	shardCount    = 4
+	addressLength = 42
+	txHashLength  = 66
+
+	// the status and the messages of the responses
+	statusOK     = "1"
+	statusNotOK  = "0"
+	messageOK    = "OK"
+	messageNotOK = "NOTOK"
+	messageNoTxs = "No transactions found"
+)
+
+// the errors are sent in the result with the messages of etherscan, so the clients which check
+// them keep working
+const (
+	errInvalidModule   = "Error! Missing Or invalid Module name"
+	errInvalidAction   = "Error! Missing Or invalid Action name"
+	errInvalidAddress  = "Error! Invalid address format"
+	errInvalidBlock    = "Error! Invalid block number"
+	errInvalidShard    = "Error! Invalid shard number"
+	errInvalidParam    = "Error! Invalid parameter"
+	errBlockNotFound   = "Error! Block not found"
+	errNotVerified     = "Contract source code not verified"
+	errDatabase        = "Error! Database error"
+	errTooManyAccounts = "Error! Too many addresses, at most 20 are allowed"
+)
+
+// Response is the body of the actions of the account, block and contract modules. Status is 1 for
+// a success and 0 for an error or an empty list, the result of an error is its message
+type Response struct {
+	Status  string      `json:"status" doc:"1 for a success, 0 for an error or an empty list"`
+	Message string      `json:"message" doc:"OK, NOTOK or the reason of an empty list"`
+	Result  interface{} `json:"result" doc:"the data of the action, or the error message"`
+}
+
+// Handler answer the etherscan api over the block database, the etherscan clients only change their
+// base url. The blocks of the block and proxy modules are of the shard parameter, the first shard
+// by default, the addresses are of all the shards
+type Handler struct {
+	DBClient handlers.BlockInfoDB
+}
+
+// NewHandler return a handler of the etherscan api
+func NewHandler(DBClient handlers.BlockInfoDB) *Handler {
+	return &Handler{DBClient: DBClient}
+}
+
+type action func(c *gin.Context) *Response
+
+// API dispatch a request by its module and action, the parameters are read from the url or
+// from the form of a POST. Etherscan responds 200 for the errors too
+func (h *Handler) API() gin.HandlerFunc {
+	modules := map[string]map[string]action{
+		"account": {
+			"balance":      h.balance,
+			"balancemulti": h.balanceMulti,
+			"txlist":       h.txList,
+		},
+		"block": {
+			"getblockreward": h.blockReward,
+		},
+		"contract": {
+			"getabi": h.abi,
+		},
+	}
+
+	return func(c *gin.Context) {
+		module := param(c, "module")
+		if module == "proxy" {
+			c.JSON(http.StatusOK, h.proxy(c))
+			return
+		}
+		actions, ok := modules[module]
+		if !ok {
+			c.JSON(http.StatusOK, failure(errInvalidModule))
+			return
+		}
+		fn, ok := actions[param(c, "action")]
+		if !ok {
+			c.JSON(http.StatusOK, failure(errInvalidAction))
+			return
+		}
+		c.JSON(http.StatusOK, fn(c))
+	}
+}
+
+// blockReward get the reward of a block, which includes the fees paid to the miner
+func (h *Handler) blockReward(c *gin.Context) *Response {
+	shard, ok := shardParam(c)
+	if !ok {
+		return failure(errInvalidShard)
+	}
+	height, err := strconv.ParseUint(param(c, "blockno"), 10, 64)
+	if err != nil {
+		return failure(errInvalidBlock)
+	}
+	block, err := h.DBClient.GetBlockByHeight(shard, height)
+	if err == mgo.ErrNotFound {
+		return failure(errBlockNotFound)
+	} else if err != nil {
+		return failure(errDatabase)
+	}
+
+	return success(&BlockReward{
+		BlockNumber:          strconv.FormatInt(block.Height, 10),
+		TimeStamp:            strconv.FormatInt(block.Timestamp, 10),
+		BlockMiner:           block.Creator,
+		BlockReward:          strconv.FormatInt(block.Reward+block.TxFee+block.DebtFee, 10),
+		Uncles:               []string{},
+		UncleInclusionReward: "0",
+	})
+}
+
+// abi get the abi json of a verified contract
+func (h *Handler) abi(c *gin.Context) *Response {
+	address, ok := addressParam(c)
+	if !ok {
+		return failure(errInvalidAddress)
+	}
+	account, err := h.DBClient.GetAccountByAddress(address)
+	if err == mgo.ErrNotFound || (err == nil && account.ABI == "") {
+		return failure(errNotVerified)
+	} else if err != nil {
+		return failure(errDatabase)
+	}
+	return success(account.ABI)
+}
+
+func success(result interface{}) *Response {
+	return &Response{Status: statusOK, Message: messageOK, Result: result}
+}
+
+func failure(err string) *Response {
+	return &Response{Status: statusNotOK, Message: messageNotOK, Result: err}
+}
+
+// param return a parameter of the url or of the form of a POST
+func param(c *gin.Context, name string) string {
+	if value, ok := c.GetQuery(name); ok {
+		return value
+	}
+	return c.PostForm(name)
+}
+
+// shardParam return the shard parameter, the first shard by default
+func shardParam(c *gin.Context) (int, bool) {
+	value := param(c, "shard")
+	if value == "" {
+		return 1, true
+	}
+	shard, err := strconv.Atoi(value)
+	return shard, err == nil && shard >= 1 && shard <= shardCount
+}
+
+func addressParam(c *gin.Context) (string, bool) {
+	address := param(c, "address")
+	return address, isAddress(address)
+}
+
+func isAddress(address string) bool {
+	return len(address) == addressLength && strings.HasPrefix(address, "0x")
+}
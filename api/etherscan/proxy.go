@@ -0,0 +1,288 @@
+/**
+*  @file
+*  @copyright defined in scan-api/LICENSE
+ */
+
+package etherscan
+
+import (
+	"encoding/json"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/seeleteam/scan-api/database"
+	"gopkg.in/mgo.v2"
+)
+
+// the json-rpc error codes
+const (
+	rpcMethodNotFound = -32601
+	rpcInvalidParams  = -32602
+	rpcInternalError  = -32603
+)
+
+type proxyAction func(c *gin.Context) (interface{}, *ProxyError)
+
+// proxy answer the proxy module by the indexed blocks and transactions instead of a node, the
+// action is the json-rpc method
+func (h *Handler) proxy(c *gin.Context) *ProxyResponse {
+	actions := map[string]proxyAction{
+		"eth_blockNumber":                         h.blockNumber,
+		"eth_getBlockByNumber":                    h.blockByNumber,
+		"eth_getBlockTransactionCountByNumber":    h.blockTxCount,
+		"eth_getTransactionByHash":                h.txByHash,
+		"eth_getTransactionByBlockNumberAndIndex": h.txByBlockAndIndex,
+	}
+
+	resp := &ProxyResponse{JSONRPC: "2.0", ID: json.RawMessage("1")}
+	if id := param(c, "id"); id != "" {
+		if _, err := strconv.ParseInt(id, 10, 64); err == nil {
+			resp.ID = json.RawMessage(id)
+		} else {
+			resp.ID, _ = json.Marshal(id)
+		}
+	}
+
+	action := param(c, "action")
+	fn, ok := actions[action]
+	if !ok {
+		resp.Error = &ProxyError{Code: rpcMethodNotFound, Message: "the method " + action + " does not exist/is not available"}
+		return resp
+	}
+	result, perr := fn(c)
+	if perr != nil {
+		resp.Error = perr
+		return resp
+	}
+	if resp.Result, _ = json.Marshal(result); resp.Result == nil {
+		resp.Error = &ProxyError{Code: rpcInternalError, Message: "the result could not be encoded"}
+	}
+	return resp
+}
+
+func invalidParams(message string) *ProxyError {
+	return &ProxyError{Code: rpcInvalidParams, Message: message}
+}
+
+func internalError(err error) *ProxyError {
+	return &ProxyError{Code: rpcInternalError, Message: err.Error()}
+}
+
+// latestHeight return the height of the latest block of the shard
+func (h *Handler) latestHeight(shard int) (uint64, *ProxyError) {
+	next, err := h.DBClient.GetBlockHeight(shard)
+	if err != nil {
+		return 0, internalError(err)
+	}
+	if next == 0 {
+		return 0, nil
+	}
+	return next - 1, nil
+}
+
+// blockParam return the block of the tag parameter, which is a hex height, latest, pending or
+// earliest, nil if it is not found
+func (h *Handler) blockParam(c *gin.Context) (*database.DBBlock, *ProxyError) {
+	shard, ok := shardParam(c)
+	if !ok {
+		return nil, invalidParams("the shard must be a number from 1 to " + strconv.Itoa(shardCount))
+	}
+
+	var height uint64
+	switch tag := param(c, "tag"); tag {
+	case "latest", "pending":
+		var perr *ProxyError
+		if height, perr = h.latestHeight(shard); perr != nil {
+			return nil, perr
+		}
+	case "earliest":
+	default:
+		n, ok := parseHex(tag)
+		if !ok {
+			return nil, invalidParams("invalid argument 0: the tag must be a hex number, latest, pending or earliest")
+		}
+		height = n
+	}
+
+	block, err := h.DBClient.GetBlockByHeight(shard, height)
+	if err == mgo.ErrNotFound {
+		return nil, nil
+	} else if err != nil {
+		return nil, internalError(err)
+	}
+	return block, nil
+}
+
+// blockNumber get the height of the latest block of the shard
+func (h *Handler) blockNumber(c *gin.Context) (interface{}, *ProxyError) {
+	shard, ok := shardParam(c)
+	if !ok {
+		return nil, invalidParams("the shard must be a number from 1 to " + strconv.Itoa(shardCount))
+	}
+	height, perr := h.latestHeight(shard)
+	if perr != nil {
+		return nil, perr
+	}
+	return hexUint(height), nil
+}
+
+// blockByNumber get a block with the hashes of its transactions, or with its transactions if
+// boolean is true
+func (h *Handler) blockByNumber(c *gin.Context) (interface{}, *ProxyError) {
+	block, perr := h.blockParam(c)
+	if perr != nil || block == nil {
+		return nil, perr
+	}
+
+	full := param(c, "boolean") == "true"
+	txs := make([]interface{}, len(block.Txs))
+	for i := range block.Txs {
+		if full {
+			txs[i] = createBlockTx(block, i)
+		} else {
+			txs[i] = block.Txs[i].Hash
+		}
+	}
+	return &RPCBlock{
+		Number:           hexInt(block.Height),
+		Hash:             block.HeadHash,
+		ParentHash:       block.PreHash,
+		Nonce:            hexDecimal(block.Nonce),
+		StateRoot:        block.StateHash,
+		TransactionsRoot: block.TxHash,
+		ReceiptsRoot:     block.ReceiptHash,
+		Miner:            block.Creator,
+		Difficulty:       hexDecimal(block.Difficulty),
+		TotalDifficulty:  hexDecimal(block.TotalDifficulty),
+		ExtraData:        block.ExtraData,
+		GasUsed:          hexInt(block.UsedGas),
+		Timestamp:        hexInt(block.Timestamp),
+		Transactions:     txs,
+		Uncles:           []string{},
+	}, nil
+}
+
+// blockTxCount get the number of transactions of a block
+func (h *Handler) blockTxCount(c *gin.Context) (interface{}, *ProxyError) {
+	block, perr := h.blockParam(c)
+	if perr != nil || block == nil {
+		return nil, perr
+	}
+	return hexInt(int64(len(block.Txs))), nil
+}
+
+// txByHash get a transaction or a pending transaction by its hash
+func (h *Handler) txByHash(c *gin.Context) (interface{}, *ProxyError) {
+	hash := param(c, "txhash")
+	if len(hash) != txHashLength || !strings.HasPrefix(hash, "0x") {
+		return nil, invalidParams("invalid argument 0: the txhash must be a 0x prefixed hash of 32 bytes")
+	}
+
+	tx, err := h.DBClient.GetTxByHash(hash)
+	if err == mgo.ErrNotFound {
+		pending, err := h.DBClient.GetPendingTxByHash(hash)
+		if err == mgo.ErrNotFound {
+			return nil, nil
+		} else if err != nil {
+			return nil, internalError(err)
+		}
+		return createTx(pending, nil), nil
+	} else if err != nil {
+		return nil, internalError(err)
+	}
+
+	block, err := h.DBClient.GetBlockByHeight(tx.ShardNumber, tx.Block)
+	if err != nil && err != mgo.ErrNotFound {
+		return nil, internalError(err)
+	}
+	return createTx(tx, block), nil
+}
+
+// txByBlockAndIndex get a transaction by its block and its hex index in the block
+func (h *Handler) txByBlockAndIndex(c *gin.Context) (interface{}, *ProxyError) {
+	index, ok := parseHex(param(c, "index"))
+	if !ok {
+		return nil, invalidParams("invalid argument 1: the index must be a hex number")
+	}
+	block, perr := h.blockParam(c)
+	if perr != nil || block == nil || index >= uint64(len(block.Txs)) {
+		return nil, perr
+	}
+	return createBlockTx(block, int(index)), nil
+}
+
+// createBlockTx convert a transaction of a block, the block does not hold its nonce and its input
+func createBlockTx(block *database.DBBlock, i int) *RPCTx {
+	t := block.Txs[i]
+	height, index := hexInt(block.Height), hexInt(int64(i))
+	return &RPCTx{
+		Hash:             t.Hash,
+		BlockHash:        &block.HeadHash,
+		BlockNumber:      &height,
+		TransactionIndex: &index,
+		From:             t.From,
+		To:               optional(t.To),
+		Value:            hexInt(t.Amount),
+		Gas:              hexInt(t.GasLimit),
+		GasPrice:         hexInt(t.GasPrice),
+	}
+}
+
+// createTx convert a transaction, the block is nil for a pending transaction
+func createTx(tx *database.DBTx, block *database.DBBlock) *RPCTx {
+	result := &RPCTx{
+		Hash:     tx.Hash,
+		Nonce:    hexDecimal(tx.AccountNonce),
+		From:     tx.From,
+		To:       optional(tx.To),
+		Value:    hexInt(tx.Amount),
+		Gas:      hexInt(tx.GasLimit),
+		GasPrice: hexInt(tx.GasPrice),
+		Input:    tx.Payload,
+	}
+	if result.Input == "" {
+		result.Input = "0x"
+	}
+	if block != nil {
+		height, index := hexInt(block.Height), hexInt(int64(txIndex(block, tx.Hash)))
+		result.BlockHash = &block.HeadHash
+		result.BlockNumber = &height
+		result.TransactionIndex = &index
+	}
+	return result
+}
+
+// optional return nil for an empty string, the receiver of a contract creation is null
+func optional(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
+func hexInt(n int64) string {
+	return "0x" + strconv.FormatInt(n, 16)
+}
+
+func hexUint(n uint64) string {
+	return "0x" + strconv.FormatUint(n, 16)
+}
+
+// hexDecimal convert a decimal number stored as a string, such as a difficulty, to a hex quantity
+func hexDecimal(s string) string {
+	n, ok := new(big.Int).SetString(s, 10)
+	if !ok {
+		return "0x0"
+	}
+	return "0x" + n.Text(16)
+}
+
+func parseHex(s string) (uint64, bool) {
+	if !strings.HasPrefix(s, "0x") {
+		return 0, false
+	}
+	n, err := strconv.ParseUint(s[2:], 16, 64)
+	return n, err == nil
+}
@@ -0,0 +1,96 @@
+/**
+*  @file
+*  @copyright defined in scan-api/LICENSE
+ */
+
+package etherscan
+
+import "encoding/json"
+
+// Balance is an item of the result of balancemulti
+type Balance struct {
+	Account string `json:"account"`
+	Balance string `json:"balance"`
+}
+
+// Tx is an item of the result of txlist, the numbers are decimal strings as etherscan sends them
+type Tx struct {
+	BlockNumber      string `json:"blockNumber"`
+	TimeStamp        string `json:"timeStamp"`
+	Hash             string `json:"hash"`
+	Nonce            string `json:"nonce"`
+	BlockHash        string `json:"blockHash"`
+	TransactionIndex string `json:"transactionIndex" doc:"the index of the transaction in its block"`
+	From             string `json:"from"`
+	To               string `json:"to"`
+	Value            string `json:"value"`
+	Gas              string `json:"gas"`
+	GasPrice         string `json:"gasPrice"`
+	IsError          string `json:"isError"`
+	TxReceiptStatus  string `json:"txreceipt_status"`
+	Input            string `json:"input"`
+	ContractAddress  string `json:"contractAddress"`
+	GasUsed          string `json:"gasUsed"`
+	Confirmations    string `json:"confirmations"`
+	Shard            int    `json:"shard" doc:"the shard of the block, it is not in the etherscan api"`
+}
+
+// BlockReward is the result of getblockreward, the reward includes the fees paid to the miner
+type BlockReward struct {
+	BlockNumber          string   `json:"blockNumber"`
+	TimeStamp            string   `json:"timeStamp"`
+	BlockMiner           string   `json:"blockMiner"`
+	BlockReward          string   `json:"blockReward"`
+	Uncles               []string `json:"uncles" doc:"always empty"`
+	UncleInclusionReward string   `json:"uncleInclusionReward" doc:"always 0"`
+}
+
+// ProxyResponse is the json-rpc body of the proxy module, the result is null if the block or the
+// transaction is not found
+type ProxyResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *ProxyError     `json:"error,omitempty"`
+}
+
+// ProxyError is the json-rpc error of the proxy module
+type ProxyError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// RPCBlock is a block of the proxy module, the numbers are hex quantities as the node sends them.
+// Transactions is the hashes, or the transactions if the full transactions are asked
+type RPCBlock struct {
+	Number           string        `json:"number"`
+	Hash             string        `json:"hash"`
+	ParentHash       string        `json:"parentHash"`
+	Nonce            string        `json:"nonce"`
+	StateRoot        string        `json:"stateRoot"`
+	TransactionsRoot string        `json:"transactionsRoot"`
+	ReceiptsRoot     string        `json:"receiptsRoot"`
+	Miner            string        `json:"miner"`
+	Difficulty       string        `json:"difficulty"`
+	TotalDifficulty  string        `json:"totalDifficulty"`
+	ExtraData        string        `json:"extraData"`
+	GasUsed          string        `json:"gasUsed"`
+	Timestamp        string        `json:"timestamp"`
+	Transactions     []interface{} `json:"transactions"`
+	Uncles           []string      `json:"uncles"`
+}
+
+// RPCTx is a transaction of the proxy module, the block fields are null for a pending transaction
+type RPCTx struct {
+	Hash             string  `json:"hash"`
+	Nonce            string  `json:"nonce,omitempty"`
+	BlockHash        *string `json:"blockHash"`
+	BlockNumber      *string `json:"blockNumber"`
+	TransactionIndex *string `json:"transactionIndex"`
+	From             string  `json:"from"`
+	To               *string `json:"to"`
+	Value            string  `json:"value"`
+	Gas              string  `json:"gas"`
+	GasPrice         string  `json:"gasPrice"`
+	Input            string  `json:"input,omitempty"`
+}
@@ -0,0 +1,186 @@
+/**
+*  @file
+*  @copyright defined in scan-api/LICENSE
+ */
+
+package graphql
+
+import (
+	"sort"
+
+	"github.com/seeleteam/scan-api/api/handlers"
+	"github.com/seeleteam/scan-api/database"
+)
+
+// chartPoint is the value of a daily chart in a day of a shard
+type chartPoint struct {
+	TimeStamp   int64
+	ShardNumber int
+	Value       float64
+
+	values map[string]float64 // the values of all the kinds of the chart table
+}
+
+// chartKey identify the rows of a chart table read by a request, shardNumber 0 for all the shards
+type chartKey struct {
+	table       string
+	shardNumber int
+}
+
+// chartKind is a kind of the chart argument, several kinds are read from the same table
+type chartKind struct {
+	table       string
+	description string
+}
+
+// the tables of the charts
+const (
+	chartTxTbl         = "tx"
+	chartBlockTbl      = "block"
+	chartAddressTbl    = "address"
+	chartDifficultyTbl = "difficulty"
+	chartHashRateTbl   = "hashrate"
+	chartBlockTimeTbl  = "blocktime"
+	chartSupplyTbl     = "supply"
+)
+
+// chartKinds is the kinds of the chart argument
+var chartKinds = map[string]chartKind{
+	"TXS":           {chartTxTbl, "the number of transactions"},
+	"BLOCKS":        {chartBlockTbl, "the number of blocks"},
+	"REWARDS":       {chartBlockTbl, "the block rewards"},
+	"ADDRESSES":     {chartAddressTbl, "the total number of addresses"},
+	"NEW_ADDRESSES": {chartAddressTbl, "the number of new addresses"},
+	"DIFFICULTY":    {chartDifficultyTbl, "the average block difficulty"},
+	"HASHRATE":      {chartHashRateTbl, "the average hash rate"},
+	"BLOCK_TIME":    {chartBlockTimeTbl, "the average block time in seconds"},
+	"MINTED":        {chartSupplyTbl, "the coins minted"},
+	"FEES":          {chartSupplyTbl, "the fees paid"},
+}
+
+// readChart read the rows of a chart table, sorted by day and shard
+func readChart(db handlers.ChartInfoDB, key chartKey) ([]*chartPoint, error) {
+	var points []*chartPoint
+	add := func(timestamp int64, shardNumber int, values map[string]float64) {
+		points = append(points, &chartPoint{TimeStamp: timestamp, ShardNumber: shardNumber, values: values})
+	}
+	all := key.shardNumber == 0
+
+	switch key.table {
+	case chartTxTbl:
+		var rows []*database.DBOneDayTxInfo
+		var err error
+		if all {
+			rows, err = db.GetTransInfoChart()
+		} else {
+			rows, err = db.GetTransInfoChartByShardNumber(key.shardNumber)
+		}
+		if err != nil {
+			return nil, err
+		}
+		for _, row := range rows {
+			add(row.TimeStamp, row.ShardNumber, map[string]float64{"TXS": float64(row.TotalTxs)})
+		}
+	case chartBlockTbl:
+		var rows []*database.DBOneDayBlockInfo
+		var err error
+		if all {
+			rows, err = db.GetOneDayBlocksChart()
+		} else {
+			rows, err = db.GetOneDayBlocksChartByShardNumber(key.shardNumber)
+		}
+		if err != nil {
+			return nil, err
+		}
+		for _, row := range rows {
+			add(row.TimeStamp, row.ShardNumber, map[string]float64{
+				"BLOCKS":  float64(row.TotalBlocks),
+				"REWARDS": float64(row.Rewards),
+			})
+		}
+	case chartAddressTbl:
+		var rows []*database.DBOneDayAddressInfo
+		var err error
+		if all {
+			rows, err = db.GetOneDayAddressesChart()
+		} else {
+			rows, err = db.GetOneDayAddressesChartByShardNumber(key.shardNumber)
+		}
+		if err != nil {
+			return nil, err
+		}
+		for _, row := range rows {
+			add(row.TimeStamp, row.ShardNumber, map[string]float64{
+				"ADDRESSES":     float64(row.TotalAddresss),
+				"NEW_ADDRESSES": float64(row.TodayIncrease),
+			})
+		}
+	case chartDifficultyTbl:
+		var rows []*database.DBOneDayBlockDifficulty
+		var err error
+		if all {
+			rows, err = db.GetOneDayBlockDifficultyChart()
+		} else {
+			rows, err = db.GetOneDayBlockDifficultyChartByShardNumber(key.shardNumber)
+		}
+		if err != nil {
+			return nil, err
+		}
+		for _, row := range rows {
+			add(row.TimeStamp, row.ShardNumber, map[string]float64{"DIFFICULTY": row.Difficulty})
+		}
+	case chartHashRateTbl:
+		var rows []*database.DBOneDayHashRate
+		var err error
+		if all {
+			rows, err = db.GetHashRateChart()
+		} else {
+			rows, err = db.GetHashRateChartByShardNumber(key.shardNumber)
+		}
+		if err != nil {
+			return nil, err
+		}
+		for _, row := range rows {
+			add(row.TimeStamp, row.ShardNumber, map[string]float64{"HASHRATE": row.HashRate})
+		}
+	case chartBlockTimeTbl:
+		var rows []*database.DBOneDayBlockAvgTime
+		var err error
+		if all {
+			rows, err = db.GetOneDayBlockAvgTimeChart()
+		} else {
+			rows, err = db.GetOneDayBlockAvgTimeChartByShardNumber(key.shardNumber)
+		}
+		if err != nil {
+			return nil, err
+		}
+		for _, row := range rows {
+			add(row.TimeStamp, row.ShardNumber, map[string]float64{"BLOCK_TIME": row.AvgTime})
+		}
+	case chartSupplyTbl:
+		var rows []*database.DBOneDaySupply
+		var err error
+		if all {
+			rows, err = db.GetSupplyChart()
+		} else {
+			rows, err = db.GetSupplyChartByShardNumber(key.shardNumber)
+		}
+		if err != nil {
+			return nil, err
+		}
+		for _, row := range rows {
+			add(row.TimeStamp, row.ShardNumber, map[string]float64{
+				"MINTED": float64(row.Minted),
+				"FEES":   float64(row.Fees),
+			})
+		}
+	}
+
+	sort.SliceStable(points, func(i, j int) bool {
+		if points[i].TimeStamp != points[j].TimeStamp {
+			return points[i].TimeStamp < points[j].TimeStamp
+		}
+		return points[i].ShardNumber < points[j].ShardNumber
+	})
+	return points, nil
+}
@@ -0,0 +1,226 @@
+/**
+*  @file
+*  @copyright defined in scan-api/LICENSE
+ */
+
+package graphql
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	gql "github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/language/ast"
+)
+
+// the defaults of the limits of a query
+const (
+	DefaultMaxComplexity = 10000
+	DefaultMaxDepth      = 10
+
+	// defaultListSize is the estimated size of a list without a limit or a first argument,
+	// such as the transactions of a block
+	defaultListSize = 50
+
+	// maxCost is the complexity at which the computation stops growing, so a deep query does not overflow
+	maxCost = math.MaxInt32
+)
+
+// the arguments which limit the size of a list
+var sizeArgs = []string{"limit", "first"}
+
+// cost estimate the documents read by a query, it is computed on the validated document before
+// the query is executed
+type cost struct {
+	schema    gql.Schema
+	variables map[string]interface{}
+	fragments map[string]*ast.FragmentDefinition
+	visiting  map[string]bool
+}
+
+// complexityOf return the complexity and the depth of the operation. Every field costs 1, the cost
+// of the fields selected under a list is multiplied by the size of the list, which is the limit or
+// the first argument of the list or of the page of the list, or defaultListSize
+func complexityOf(schema gql.Schema, doc *ast.Document, operationName string, variables map[string]interface{}) (int, int, error) {
+	c := &cost{
+		schema:    schema,
+		variables: variables,
+		fragments: make(map[string]*ast.FragmentDefinition),
+		visiting:  make(map[string]bool),
+	}
+	var operation *ast.OperationDefinition
+	for _, definition := range doc.Definitions {
+		switch d := definition.(type) {
+		case *ast.FragmentDefinition:
+			c.fragments[d.Name.Value] = d
+		case *ast.OperationDefinition:
+			if operationName == "" || (d.Name != nil && d.Name.Value == operationName) {
+				operation = d
+			}
+		}
+	}
+	if operation == nil {
+		return 0, 0, fmt.Errorf("unknown operation %q", operationName)
+	}
+
+	var root *gql.Object
+	switch operation.Operation {
+	case ast.OperationTypeQuery:
+		root = schema.QueryType()
+	default:
+		return 0, 0, fmt.Errorf("%s is not supported", operation.Operation)
+	}
+	complexity, depth := c.selections(root, operation.SelectionSet, 0)
+	return complexity, depth, nil
+}
+
+// selections return the complexity and the depth of a selection set of the parent type, size is
+// the size argument of the field of the selection set which applies to the lists it selects
+func (c *cost) selections(parent gql.Type, set *ast.SelectionSet, size int) (int, int) {
+	if set == nil {
+		return 0, 0
+	}
+	complexity, depth := 0, 0
+	for _, selection := range set.Selections {
+		var fieldComplexity, fieldDepth int
+		switch s := selection.(type) {
+		case *ast.Field:
+			fieldComplexity, fieldDepth = c.field(parent, s, size)
+		case *ast.InlineFragment:
+			fieldComplexity, fieldDepth = c.selections(c.typeOf(s.TypeCondition, parent), s.SelectionSet, size)
+		case *ast.FragmentSpread:
+			name := s.Name.Value
+			fragment := c.fragments[name]
+			if fragment == nil || c.visiting[name] {
+				continue
+			}
+			c.visiting[name] = true
+			fieldComplexity, fieldDepth = c.selections(c.typeOf(fragment.TypeCondition, parent), fragment.SelectionSet, size)
+			c.visiting[name] = false
+		}
+		complexity += fieldComplexity
+		if complexity > maxCost {
+			complexity = maxCost
+		}
+		if fieldDepth > depth {
+			depth = fieldDepth
+		}
+	}
+	return complexity, depth
+}
+
+// field return the complexity and the depth of a field, the introspection fields cost 1
+func (c *cost) field(parent gql.Type, field *ast.Field, size int) (int, int) {
+	name := field.Name.Value
+	object, ok := parent.(*gql.Object)
+	if !ok || strings.HasPrefix(name, "__") {
+		return 1, 1
+	}
+	definition := object.Fields()[name]
+	if definition == nil {
+		return 1, 1
+	}
+
+	fieldSize := c.sizeOf(definition, field)
+	multiplier, childSize := 1, fieldSize
+	if isList(definition.Type) {
+		// the size of the field, or of the page of the list, or the estimate
+		multiplier = fieldSize
+		if multiplier == 0 {
+			multiplier = size
+		}
+		if multiplier <= 0 {
+			multiplier = defaultListSize
+		}
+		if multiplier > maxLimit {
+			// a larger size is rejected by the resolver of the list
+			multiplier = maxLimit
+		}
+		childSize = 0
+	}
+
+	complexity, depth := c.selections(namedType(definition.Type), field.SelectionSet, childSize)
+	if complexity >= maxCost/multiplier {
+		return maxCost, 1 + depth
+	}
+	return 1 + multiplier*complexity, 1 + depth
+}
+
+// sizeOf return the value of the size argument of the field, its default if it is omitted, or 0
+func (c *cost) sizeOf(definition *gql.FieldDefinition, field *ast.Field) int {
+	for _, arg := range definition.Args {
+		if !isSizeArg(arg.Name()) {
+			continue
+		}
+		for _, value := range field.Arguments {
+			if value.Name.Value == arg.Name() {
+				return c.intOf(value.Value)
+			}
+		}
+		if size, ok := arg.DefaultValue.(int); ok {
+			return size
+		}
+	}
+	return 0
+}
+
+// intOf return the int of a literal or a variable, 0 if it is not an int
+func (c *cost) intOf(value ast.Value) int {
+	switch v := value.(type) {
+	case *ast.IntValue:
+		i, _ := strconv.Atoi(v.Value)
+		return i
+	case *ast.Variable:
+		switch i := c.variables[v.Name.Value].(type) {
+		case int:
+			return i
+		case float64:
+			return int(i)
+		}
+	}
+	return 0
+}
+
+// typeOf return the type of the condition of a fragment
+func (c *cost) typeOf(condition *ast.Named, parent gql.Type) gql.Type {
+	if condition == nil {
+		return parent
+	}
+	if t := c.schema.Type(condition.Name.Value); t != nil {
+		return t
+	}
+	return parent
+}
+
+func isSizeArg(name string) bool {
+	for _, arg := range sizeArgs {
+		if arg == name {
+			return true
+		}
+	}
+	return false
+}
+
+func isList(t gql.Type) bool {
+	if nonNull, ok := t.(*gql.NonNull); ok {
+		t = nonNull.OfType
+	}
+	_, ok := t.(*gql.List)
+	return ok
+}
+
+// namedType return the type without the list and the non null wrappers
+func namedType(t gql.Type) gql.Type {
+	for {
+		switch wrapper := t.(type) {
+		case *gql.NonNull:
+			t = wrapper.OfType
+		case *gql.List:
+			t = wrapper.OfType
+		default:
+			return t
+		}
+	}
+}
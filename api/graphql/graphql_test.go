@@ -0,0 +1,275 @@
+/**
+*  @file
+*  @copyright defined in scan-api/LICENSE
+ */
+
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/seeleteam/scan-api/database"
+	"github.com/seeleteam/scan-api/log"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	testSender1  = "0x0000000000000000000000000000000000000011"
+	testSender2  = "0x0000000000000000000000000000000000000021"
+	testContract = "0x0000000000000000000000000000000000000032"
+)
+
+// countingDB count the reads of the memory client which the loader batches
+type countingDB struct {
+	*database.Client
+	reads map[string]int
+}
+
+func (db *countingDB) GetAccountsByAddresses(addresses []string) ([]*database.DBAccount, error) {
+	db.reads["accounts"]++
+	return db.Client.GetAccountsByAddresses(addresses)
+}
+
+func (db *countingDB) GetTxsByBlocks(shardNumber int, heights []uint64) ([]*database.DBTx, error) {
+	db.reads["blockTxs"]++
+	return db.Client.GetTxsByBlocks(shardNumber, heights)
+}
+
+func (db *countingDB) GetBlocksByHeights(shardNumber int, heights []uint64) ([]*database.DBBlock, error) {
+	db.reads["blocks"]++
+	return db.Client.GetBlocksByHeights(shardNumber, heights)
+}
+
+func (db *countingDB) GetOneDayBlocksChart() ([]*database.DBOneDayBlockInfo, error) {
+	db.reads["blockChart"]++
+	return db.Client.GetOneDayBlocksChart()
+}
+
+func (db *countingDB) GetNodeInfosByShardNumber(shardNumber int) ([]*database.DBNodeInfo, error) {
+	db.reads["nodes"]++
+	return db.Client.GetNodeInfosByShardNumber(shardNumber)
+}
+
+func newTestDB(t *testing.T) *countingDB {
+	log.NewLogger("", "error", false)
+	db := database.NewMemoryClient(1)
+	for height := int64(0); height < 3; height++ {
+		assert.Nil(t, db.AddBlock(&database.DBBlock{HeadHash: "0x0b0" + strconv.FormatInt(height, 10), Height: height, ShardNumber: 1, Creator: testSender1}))
+	}
+	assert.Nil(t, db.AddTxs(
+		&database.DBTx{Hash: "0x0a01", From: testSender1, To: testContract, Amount: 5000000000, Block: 1, Idx: 0, ShardNumber: 1},
+		&database.DBTx{Hash: "0x0a02", From: testSender2, To: testSender1, Block: 1, Idx: 1, ShardNumber: 1},
+		&database.DBTx{Hash: "0x0a03", From: testSender1, To: testSender2, Block: 1, Idx: 2, ShardNumber: 1},
+		&database.DBTx{Hash: "0x0a04", From: testSender2, To: testSender1, Block: 2, Idx: 0, ShardNumber: 1},
+	))
+	assert.Nil(t, db.AddAccount(&database.DBAccount{Address: testSender1, ShardNumber: 1, Balance: 100}))
+	assert.Nil(t, db.AddAccount(&database.DBAccount{Address: testSender2, ShardNumber: 1, Balance: 200}))
+	assert.Nil(t, db.AddAccount(&database.DBAccount{Address: testContract, ShardNumber: 1, AccType: 1, Creator: testSender1}))
+	return &countingDB{Client: db, reads: make(map[string]int)}
+}
+
+func do(t *testing.T, h *Handler, query string) (map[string]interface{}, []string, int) {
+	result, status := h.Do(context.Background(), &Request{Query: query})
+	var errs []string
+	for _, err := range result.Errors {
+		errs = append(errs, err.Message)
+	}
+	data, _ := result.Data.(map[string]interface{})
+	return data, errs, status
+}
+
+func Test_BlockWithTxsAndSenders(t *testing.T) {
+	db := newTestDB(t)
+	h := NewHandler(db, db, db)
+
+	data, errs, status := do(t, h, `{
+		block(shard: 1, height: 1) {
+			headHash
+			height
+			txs { hash amount fromAccount { address balance } }
+		}
+	}`)
+	assert.Equal(t, status, http.StatusOK)
+	assert.Empty(t, errs)
+
+	block := data["block"].(map[string]interface{})
+	assert.Equal(t, block["headHash"], "0x0b01")
+	assert.Equal(t, block["height"], int64(1))
+	txs := block["txs"].([]interface{})
+	assert.Equal(t, len(txs), 3)
+	var senders []interface{}
+	for _, tx := range txs {
+		senders = append(senders, tx.(map[string]interface{})["fromAccount"].(map[string]interface{})["balance"])
+	}
+	assert.Equal(t, txs[0].(map[string]interface{})["amount"], int64(5000000000))
+	assert.Equal(t, senders, []interface{}{int64(100), int64(200), int64(100)})
+
+	// the block, its transactions and all the senders are read by one query each
+	assert.Equal(t, db.reads, map[string]int{"blocks": 1, "blockTxs": 1, "accounts": 1})
+}
+
+func Test_BatchAcrossLevels(t *testing.T) {
+	db := newTestDB(t)
+	h := NewHandler(db, db, db)
+
+	data, errs, _ := do(t, h, `{
+		blocks(limit: 3) {
+			total
+			items { height creatorAccount { address } txs { hash block { headHash } toAccount { address contract { creatorAccount { balance } } } } }
+		}
+		a: account(address: "`+testSender2+`") { balance }
+		b: contract(address: "`+testSender1+`") { address }
+	}`)
+	assert.Empty(t, errs)
+	assert.Nil(t, data["b"])
+	assert.Equal(t, data["a"].(map[string]interface{})["balance"], int64(200))
+
+	items := data["blocks"].(map[string]interface{})["items"].([]interface{})
+	assert.Equal(t, len(items), 3)
+	assert.Equal(t, items[0].(map[string]interface{})["height"], int64(2))
+	tx := items[1].(map[string]interface{})["txs"].([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, tx["block"].(map[string]interface{})["headHash"], "0x0b01")
+	creator := tx["toAccount"].(map[string]interface{})["contract"].(map[string]interface{})["creatorAccount"]
+	assert.Equal(t, creator.(map[string]interface{})["balance"], int64(100))
+
+	// the accounts of a level are read together: the creators of the blocks with the accounts of
+	// the root, then the receivers of the transactions. The creators of the contracts are read by then
+	assert.Equal(t, db.reads["blockTxs"], 1)
+	assert.Equal(t, db.reads["blocks"], 1)
+	assert.Equal(t, db.reads["accounts"], 2)
+}
+
+func Test_ChartsAndNodes(t *testing.T) {
+	db := newTestDB(t)
+	assert.Nil(t, db.AddOneDayBlock(1, &database.DBOneDayBlockInfo{TotalBlocks: 10, Rewards: 30, TimeStamp: 86400, ShardNumber: 1}))
+	assert.Nil(t, db.AddOneDayBlock(2, &database.DBOneDayBlockInfo{TotalBlocks: 20, Rewards: 60, TimeStamp: 86400, ShardNumber: 2}))
+	assert.Nil(t, db.AddNodeInfo(&database.DBNodeInfo{ID: "n1", Host: "1.1.1.1", Port: "8057", ShardNumber: 1}))
+	h := NewHandler(db, db, db)
+
+	data, errs, _ := do(t, h, `{
+		blocks: chart(kind: BLOCKS) { shardNumber value }
+		rewards: chart(kind: REWARDS) { value }
+		all: nodes { id }
+		shard1: nodes(shard: 1) { host }
+	}`)
+	assert.Empty(t, errs)
+	assert.Equal(t, data["blocks"], []interface{}{
+		map[string]interface{}{"shardNumber": 1, "value": float64(10)},
+		map[string]interface{}{"shardNumber": 2, "value": float64(20)},
+	})
+	assert.Equal(t, data["rewards"], []interface{}{
+		map[string]interface{}{"value": float64(30)},
+		map[string]interface{}{"value": float64(60)},
+	})
+	assert.Equal(t, data["all"], []interface{}{map[string]interface{}{"id": "n1"}})
+	assert.Equal(t, data["shard1"], []interface{}{map[string]interface{}{"host": "1.1.1.1"}})
+
+	// the kinds of a chart table and the nodes of a shard are read once
+	assert.Equal(t, db.reads["blockChart"], 1)
+	assert.Equal(t, db.reads["nodes"], 4)
+}
+
+func Test_Complexity(t *testing.T) {
+	db := newTestDB(t)
+	h := NewHandler(db, db, db)
+
+	cases := []struct {
+		query      string
+		complexity int
+		depth      int
+	}{
+		{`{ block(height: 1) { headHash } }`, 2, 2},
+		// the page multiplies its items by its limit, a list without a limit by defaultListSize
+		{`{ blocks(limit: 2) { total items { height txs { hash } } } }`, 1 + 1 + 1 + 2*(1+1+defaultListSize*1), 4},
+		{`{ account(address: "0x01") { activities { hash } } }`, 1 + 1 + 10*1, 3},
+		{`query q($n: Int) { miners(limit: $n) { address } }`, 1 + 5*1, 2},
+		{`{ ...f } fragment f on Query { tx(hash: "0x01") { hash } }`, 2, 2},
+	}
+	for _, c := range cases {
+		doc, errs, _ := do(t, &Handler{schema: h.schema, MaxComplexity: 1, MaxDepth: 100}, c.query)
+		assert.Nil(t, doc)
+		assert.Equal(t, len(errs), 1, c.query)
+
+		parsed, err := parseQuery(c.query)
+		assert.Nil(t, err)
+		complexity, depth, err := complexityOf(h.schema, parsed, "", map[string]interface{}{"n": float64(5)})
+		assert.Nil(t, err)
+		assert.Equal(t, complexity, c.complexity, c.query)
+		assert.Equal(t, depth, c.depth, c.query)
+	}
+}
+
+func Test_Limits(t *testing.T) {
+	db := newTestDB(t)
+	h := NewHandler(db, db, db)
+
+	_, errs, status := do(t, h, `{
+		blocks(limit: 100) { items { txs { fromAccount { activities(first: 100) { tx { hash } } } } } }
+	}`)
+	assert.Equal(t, status, http.StatusBadRequest)
+	assert.True(t, strings.Contains(errs[0], "complexity"), errs[0])
+
+	h.MaxDepth = 2
+	_, errs, status = do(t, h, `{ block(height: 1) { txs { hash } } }`)
+	assert.Equal(t, status, http.StatusBadRequest)
+	assert.True(t, strings.Contains(errs[0], "depth"), errs[0])
+
+	// the rejected queries read nothing
+	assert.Empty(t, db.reads)
+
+	h.MaxDepth = DefaultMaxDepth
+	data, errs, status := do(t, h, `{ blocks(shard: 5) { total } block(height: 1) { height } }`)
+	assert.Equal(t, status, http.StatusOK)
+	assert.Equal(t, errs, []string{errInvalidShard.Error()})
+	assert.Nil(t, data["blocks"])
+	assert.NotNil(t, data["block"])
+
+	_, errs, status = do(t, h, `{ block { unknown } }`)
+	assert.Equal(t, status, http.StatusBadRequest)
+	assert.NotEmpty(t, errs)
+}
+
+func Test_QueryHandler(t *testing.T) {
+	db := newTestDB(t)
+	h := NewHandler(db, db, db)
+	gin.SetMode(gin.TestMode)
+	e := gin.New()
+	e.GET("/graphql", h.Query())
+	e.POST("/graphql", h.Query())
+
+	var result struct {
+		Data struct {
+			Block struct {
+				Height int64 `json:"height"`
+			} `json:"block"`
+		} `json:"data"`
+		Errors []interface{} `json:"errors"`
+	}
+
+	body := `{"query": "query b($h: Long) { block(height: $h) { height } }", "variables": {"h": 1}}`
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, httptest.NewRequest("POST", "/graphql", strings.NewReader(body)))
+	assert.Equal(t, w.Code, http.StatusOK)
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &result))
+	assert.Empty(t, result.Errors)
+	assert.Equal(t, result.Data.Block.Height, int64(1))
+
+	query := url.Values{"query": {"query b($h: Long) { block(height: $h) { headHash } }"}, "variables": {`{"h": 2}`}}
+	w = httptest.NewRecorder()
+	e.ServeHTTP(w, httptest.NewRequest("GET", "/graphql?"+query.Encode(), nil))
+	assert.Equal(t, w.Code, http.StatusOK)
+	assert.Equal(t, w.Body.String(), `{"data":{"block":{"headHash":"0x0b02"}}}`)
+
+	w = httptest.NewRecorder()
+	e.ServeHTTP(w, httptest.NewRequest("GET", "/graphql", nil))
+	assert.Equal(t, w.Code, http.StatusBadRequest)
+	assert.Equal(t, w.Body.String(), `{"data":null,"errors":[{"message":"query is empty","locations":[]}]}`)
+}
@@ -0,0 +1,132 @@
+/**
+*  @file
+*  @copyright defined in scan-api/LICENSE
+ */
+
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	gql "github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/gqlerrors"
+	"github.com/graphql-go/graphql/language/ast"
+	"github.com/graphql-go/graphql/language/parser"
+	"github.com/graphql-go/graphql/language/source"
+	"github.com/seeleteam/scan-api/api/handlers"
+)
+
+var errEmptyQuery = errors.New("query is empty")
+
+// Request is a graphql request, the json body of a POST or the url parameters of a GET
+type Request struct {
+	Query         string                 `json:"query"`
+	Variables     map[string]interface{} `json:"variables,omitempty" doc:"the values of the variables of the query"`
+	OperationName string                 `json:"operationName,omitempty" doc:"the operation to run if the query has several operations"`
+}
+
+// Handler run the graphql queries over the block, chart and node databases. A query is rejected
+// before it runs if its complexity or its depth is over the limits of the handler
+type Handler struct {
+	MaxComplexity int
+	MaxDepth      int
+
+	schema  gql.Schema
+	blockDB handlers.BlockInfoDB
+	chartDB handlers.ChartInfoDB
+	nodeDB  handlers.NodeInfoDB
+}
+
+// NewHandler return a handler with the default limits
+func NewHandler(blockDB handlers.BlockInfoDB, chartDB handlers.ChartInfoDB, nodeDB handlers.NodeInfoDB) *Handler {
+	schema, err := newSchema()
+	if err != nil {
+		// the schema does not depend on the input, an invalid schema is a bug
+		panic(err)
+	}
+	return &Handler{
+		MaxComplexity: DefaultMaxComplexity,
+		MaxDepth:      DefaultMaxDepth,
+		schema:        schema,
+		blockDB:       blockDB,
+		chartDB:       chartDB,
+		nodeDB:        nodeDB,
+	}
+}
+
+// Query handle a query sent by GET with the query, variables and operationName parameters or by
+// POST with a json body. An invalid request responds 400, a request which runs responds 200 with
+// the errors of the fields which failed
+func (h *Handler) Query() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req Request
+		if c.Request.Method == http.MethodPost {
+			if err := c.ShouldBindJSON(&req); err != nil {
+				c.JSON(http.StatusBadRequest, errorResult(err))
+				return
+			}
+		} else {
+			req.Query = c.Query("query")
+			req.OperationName = c.Query("operationName")
+			if variables := c.Query("variables"); variables != "" {
+				if err := json.Unmarshal([]byte(variables), &req.Variables); err != nil {
+					c.JSON(http.StatusBadRequest, errorResult(err))
+					return
+				}
+			}
+		}
+
+		result, status := h.Do(c.Request.Context(), &req)
+		c.JSON(status, result)
+	}
+}
+
+// Do check and run a request, it returns the result and the http status of the result
+func (h *Handler) Do(ctx context.Context, req *Request) (*gql.Result, int) {
+	if req.Query == "" {
+		return errorResult(errEmptyQuery), http.StatusBadRequest
+	}
+	doc, err := parseQuery(req.Query)
+	if err != nil {
+		return errorResult(err), http.StatusBadRequest
+	}
+	validation := gql.ValidateDocument(&h.schema, doc, nil)
+	if !validation.IsValid {
+		return &gql.Result{Errors: validation.Errors}, http.StatusBadRequest
+	}
+
+	complexity, depth, err := complexityOf(h.schema, doc, req.OperationName, req.Variables)
+	if err != nil {
+		return errorResult(err), http.StatusBadRequest
+	}
+	if h.MaxDepth > 0 && depth > h.MaxDepth {
+		return errorResult(fmt.Errorf("the query depth %d is over the limit %d", depth, h.MaxDepth)), http.StatusBadRequest
+	}
+	if h.MaxComplexity > 0 && complexity > h.MaxComplexity {
+		return errorResult(fmt.Errorf("the query complexity %d is over the limit %d", complexity, h.MaxComplexity)), http.StatusBadRequest
+	}
+
+	result := gql.Execute(gql.ExecuteParams{
+		Schema:        h.schema,
+		AST:           doc,
+		OperationName: req.OperationName,
+		Args:          req.Variables,
+		Context:       withLoader(ctx, newLoader(h.blockDB, h.chartDB, h.nodeDB)),
+	})
+	return result, http.StatusOK
+}
+
+func parseQuery(query string) (*ast.Document, error) {
+	return parser.Parse(parser.ParseParams{
+		Source: source.NewSource(&source.Source{Body: []byte(query), Name: "GraphQL request"}),
+	})
+}
+
+func errorResult(err error) *gql.Result {
+	return &gql.Result{Errors: gqlerrors.FormatErrors(err)}
+}
@@ -0,0 +1,283 @@
+/**
+*  @file
+*  @copyright defined in scan-api/LICENSE
+ */
+
+package graphql
+
+import (
+	"context"
+
+	"github.com/seeleteam/scan-api/api/handlers"
+	"github.com/seeleteam/scan-api/database"
+)
+
+// batch collect the keys asked by the resolvers of a request and load them by one query when the
+// first of their values is read, the values are kept until the end of the request
+type batch struct {
+	load    func(keys []interface{}) (map[interface{}]interface{}, error)
+	pending []interface{}
+	asked   map[interface{}]bool
+	values  map[interface{}]interface{}
+	errs    map[interface{}]error
+}
+
+func newBatch(load func(keys []interface{}) (map[interface{}]interface{}, error)) *batch {
+	return &batch{
+		load:   load,
+		asked:  make(map[interface{}]bool),
+		values: make(map[interface{}]interface{}),
+		errs:   make(map[interface{}]error),
+	}
+}
+
+// get ask for the value of the key and return a thunk which reads it, nil if it is not found.
+// graphql-go calls the thunks returned by the resolvers after the resolvers of the same level have
+// asked for their keys
+func (b *batch) get(key interface{}) func() (interface{}, error) {
+	if !b.asked[key] {
+		b.asked[key] = true
+		b.pending = append(b.pending, key)
+	}
+	return func() (interface{}, error) {
+		if _, ok := b.values[key]; !ok && b.errs[key] == nil {
+			b.flush()
+		}
+		return b.values[key], b.errs[key]
+	}
+}
+
+// flush load the pending keys
+func (b *batch) flush() {
+	keys := b.pending
+	b.pending = nil
+	if len(keys) == 0 {
+		return
+	}
+	values, err := b.load(keys)
+	for _, key := range keys {
+		if err != nil {
+			b.errs[key] = err
+			continue
+		}
+		b.values[key] = values[key]
+	}
+}
+
+// loader batch and cache the reads of the resolvers of one request, a loader is not safe for
+// concurrent use as graphql-go resolves the fields of a request one by one
+type loader struct {
+	blockDB handlers.BlockInfoDB
+	chartDB handlers.ChartInfoDB
+	nodeDB  handlers.NodeInfoDB
+
+	accounts   *batch         // by address
+	txs        *batch         // by hash
+	debts      *batch         // by hash
+	blocks     map[int]*batch // by shard and height
+	blockTxs   map[int]*batch // by shard and height
+	blockDebts map[int]*batch // by shard and height
+	nodes      map[int][]*database.DBNodeInfo
+	charts     map[chartKey][]*chartPoint
+}
+
+func newLoader(blockDB handlers.BlockInfoDB, chartDB handlers.ChartInfoDB, nodeDB handlers.NodeInfoDB) *loader {
+	l := &loader{
+		blockDB:    blockDB,
+		chartDB:    chartDB,
+		nodeDB:     nodeDB,
+		blocks:     make(map[int]*batch),
+		blockTxs:   make(map[int]*batch),
+		blockDebts: make(map[int]*batch),
+		nodes:      make(map[int][]*database.DBNodeInfo),
+		charts:     make(map[chartKey][]*chartPoint),
+	}
+	l.accounts = newBatch(l.loadAccounts)
+	l.txs = newBatch(l.loadTxs)
+	l.debts = newBatch(l.loadDebts)
+	return l
+}
+
+type loaderKey struct{}
+
+// withLoader return the context of a request with its loader
+func withLoader(ctx context.Context, l *loader) context.Context {
+	return context.WithValue(ctx, loaderKey{}, l)
+}
+
+// loaderOf return the loader of the request
+func loaderOf(ctx context.Context) *loader {
+	return ctx.Value(loaderKey{}).(*loader)
+}
+
+func stringKeys(keys []interface{}) []string {
+	strs := make([]string, len(keys))
+	for i, key := range keys {
+		strs[i] = key.(string)
+	}
+	return strs
+}
+
+func heightKeys(keys []interface{}) []uint64 {
+	heights := make([]uint64, len(keys))
+	for i, key := range keys {
+		heights[i] = key.(uint64)
+	}
+	return heights
+}
+
+func (l *loader) loadAccounts(keys []interface{}) (map[interface{}]interface{}, error) {
+	accounts, err := l.blockDB.GetAccountsByAddresses(stringKeys(keys))
+	if err != nil {
+		return nil, err
+	}
+	values := make(map[interface{}]interface{}, len(accounts))
+	for _, account := range accounts {
+		values[account.Address] = account
+	}
+	return values, nil
+}
+
+func (l *loader) loadTxs(keys []interface{}) (map[interface{}]interface{}, error) {
+	txs, err := l.blockDB.GetTxsByHashes(stringKeys(keys))
+	if err != nil {
+		return nil, err
+	}
+	values := make(map[interface{}]interface{}, len(txs))
+	for _, tx := range txs {
+		values[tx.Hash] = tx
+	}
+	return values, nil
+}
+
+func (l *loader) loadDebts(keys []interface{}) (map[interface{}]interface{}, error) {
+	debts, err := l.blockDB.GetDebtsByHashes(stringKeys(keys))
+	if err != nil {
+		return nil, err
+	}
+	values := make(map[interface{}]interface{}, len(debts))
+	for _, debt := range debts {
+		values[debt.Hash] = debt
+	}
+	return values, nil
+}
+
+// account ask for an account by its address
+func (l *loader) account(address string) func() (interface{}, error) {
+	return l.accounts.get(address)
+}
+
+// tx ask for a transaction by its hash
+func (l *loader) tx(hash string) func() (interface{}, error) {
+	return l.txs.get(hash)
+}
+
+// debt ask for a debt by its hash
+func (l *loader) debt(hash string) func() (interface{}, error) {
+	return l.debts.get(hash)
+}
+
+// block ask for a block by its height in the shard
+func (l *loader) block(shardNumber int, height uint64) func() (interface{}, error) {
+	b, ok := l.blocks[shardNumber]
+	if !ok {
+		b = newBatch(func(keys []interface{}) (map[interface{}]interface{}, error) {
+			blocks, err := l.blockDB.GetBlocksByHeights(shardNumber, heightKeys(keys))
+			if err != nil {
+				return nil, err
+			}
+			values := make(map[interface{}]interface{}, len(blocks))
+			for _, block := range blocks {
+				values[uint64(block.Height)] = block
+			}
+			return values, nil
+		})
+		l.blocks[shardNumber] = b
+	}
+	return b.get(height)
+}
+
+// txsOfBlock ask for the transactions of a block in the order of the block
+func (l *loader) txsOfBlock(shardNumber int, height uint64) func() (interface{}, error) {
+	b, ok := l.blockTxs[shardNumber]
+	if !ok {
+		b = newBatch(func(keys []interface{}) (map[interface{}]interface{}, error) {
+			txs, err := l.blockDB.GetTxsByBlocks(shardNumber, heightKeys(keys))
+			if err != nil {
+				return nil, err
+			}
+			values := make(map[interface{}]interface{}, len(keys))
+			lists := make(map[uint64][]*database.DBTx, len(keys))
+			for _, tx := range txs {
+				lists[tx.Block] = append(lists[tx.Block], tx)
+			}
+			for _, key := range keys {
+				values[key] = append([]*database.DBTx{}, lists[key.(uint64)]...)
+			}
+			return values, nil
+		})
+		l.blockTxs[shardNumber] = b
+	}
+	return b.get(height)
+}
+
+// debtsOfBlock ask for the debts of a block in the order of the block
+func (l *loader) debtsOfBlock(shardNumber int, height uint64) func() (interface{}, error) {
+	b, ok := l.blockDebts[shardNumber]
+	if !ok {
+		b = newBatch(func(keys []interface{}) (map[interface{}]interface{}, error) {
+			debts, err := l.blockDB.GetDebtsByBlocks(shardNumber, heightKeys(keys))
+			if err != nil {
+				return nil, err
+			}
+			values := make(map[interface{}]interface{}, len(keys))
+			lists := make(map[uint64][]*database.Debt, len(keys))
+			for _, debt := range debts {
+				lists[debt.Height] = append(lists[debt.Height], debt)
+			}
+			for _, key := range keys {
+				values[key] = append([]*database.Debt{}, lists[key.(uint64)]...)
+			}
+			return values, nil
+		})
+		l.blockDebts[shardNumber] = b
+	}
+	return b.get(height)
+}
+
+// nodesOf return the nodes of a shard, every shard is read once in a request
+func (l *loader) nodesOf(shardNumber int) ([]*database.DBNodeInfo, error) {
+	if nodes, ok := l.nodes[shardNumber]; ok {
+		return nodes, nil
+	}
+	nodes, err := l.nodeDB.GetNodeInfosByShardNumber(shardNumber)
+	if err != nil {
+		return nil, err
+	}
+	l.nodes[shardNumber] = nodes
+	return nodes, nil
+}
+
+// chart return the points of a chart, every chart table is read once in a request
+func (l *loader) chart(kind string, shardNumber int) ([]*chartPoint, error) {
+	key := chartKey{table: chartKinds[kind].table, shardNumber: shardNumber}
+	points, ok := l.charts[key]
+	if !ok {
+		var err error
+		points, err = readChart(l.chartDB, key)
+		if err != nil {
+			return nil, err
+		}
+		l.charts[key] = points
+	}
+
+	values := make([]*chartPoint, len(points))
+	for i, point := range points {
+		values[i] = &chartPoint{
+			TimeStamp:   point.TimeStamp,
+			ShardNumber: point.ShardNumber,
+			Value:       point.values[kind],
+		}
+	}
+	return values, nil
+}
@@ -0,0 +1,715 @@
+/**
+*  @file
+*  @copyright defined in scan-api/LICENSE
+ */
+
+package graphql
+
+import (
+	"errors"
+	"math"
+	"strconv"
+
+	gql "github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/language/ast"
+	"github.com/seeleteam/scan-api/database"
+	"gopkg.in/mgo.v2"
+)
+
+// the limits of the arguments
+const (
+	shardCount   = 4
+	defaultLimit = 25
+	maxLimit     = 100
+)
+
+var (
+	errInvalidShard  = errors.New("shard must be a number from 1 to " + strconv.Itoa(shardCount))
+	errInvalidLimit  = errors.New("limit must be a number from 1 to " + strconv.Itoa(maxLimit))
+	errInvalidFirst  = errors.New("first must be a number from 1 to " + strconv.Itoa(maxLimit))
+	errInvalidHeight = errors.New("height must not be negative")
+	errNoBlockID     = errors.New("block needs a height or a hash")
+)
+
+// Long is a 64 bit integer, the Int of graphql has 32 bits only which is not enough for the amounts and the heights
+var Long = gql.NewScalar(gql.ScalarConfig{
+	Name:        "Long",
+	Description: "a 64 bit integer",
+	Serialize:   coerceLong,
+	ParseValue:  coerceLong,
+	ParseLiteral: func(valueAST ast.Value) interface{} {
+		if v, ok := valueAST.(*ast.IntValue); ok {
+			if i, err := strconv.ParseInt(v.Value, 10, 64); err == nil {
+				return i
+			}
+		}
+		return nil
+	},
+})
+
+func coerceLong(value interface{}) interface{} {
+	switch v := value.(type) {
+	case int:
+		return int64(v)
+	case int64:
+		return v
+	case uint64:
+		if v <= math.MaxInt64 {
+			return int64(v)
+		}
+	case float64:
+		// the numbers of the json variables
+		if v == math.Trunc(v) && math.Abs(v) <= 1<<53 {
+			return int64(v)
+		}
+	}
+	return nil
+}
+
+// page is a page of a list listed by cursor
+type page struct {
+	Items interface{}
+	Total uint64
+	Next  string // empty for the last page
+	Prev  string // empty for the first page
+}
+
+func nonNull(t gql.Output) gql.Output {
+	return gql.NewNonNull(t)
+}
+
+func listOf(t gql.Output) gql.Output {
+	return gql.NewNonNull(gql.NewList(gql.NewNonNull(t)))
+}
+
+// notFound return nil for a document not found, a query of a single document returns null for it
+func notFound(v interface{}, err error) (interface{}, error) {
+	if err == mgo.ErrNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// shardOf return the shard argument, 0 is accepted for all the shards if all is set
+func shardOf(p gql.ResolveParams, all bool) (int, error) {
+	shard, _ := p.Args["shard"].(int)
+	if (shard == 0 && all) || (shard >= 1 && shard <= shardCount) {
+		return shard, nil
+	}
+	return 0, errInvalidShard
+}
+
+// sizeOf return the limit or the first argument
+func sizeOf(p gql.ResolveParams, name string, err error) (int, error) {
+	size, _ := p.Args[name].(int)
+	if size < 1 || size > maxLimit {
+		return 0, err
+	}
+	return size, nil
+}
+
+func shardArg(defaultValue int, description string) *gql.ArgumentConfig {
+	return &gql.ArgumentConfig{Type: gql.Int, DefaultValue: defaultValue, Description: description}
+}
+
+func pageArgs() gql.FieldConfigArgument {
+	return gql.FieldConfigArgument{
+		"shard":  shardArg(1, "the shard number"),
+		"limit":  {Type: gql.Int, DefaultValue: defaultLimit, Description: "the page size"},
+		"cursor": {Type: gql.String, Description: "the next or prev cursor of the last page, empty for the first page"},
+	}
+}
+
+func pageOf(name string, item gql.Output) *gql.Object {
+	return gql.NewObject(gql.ObjectConfig{
+		Name:        name,
+		Description: "a page listed by cursor",
+		Fields: gql.Fields{
+			"items": {Type: listOf(item)},
+			"total": {Type: nonNull(Long), Description: "the number of items of the list"},
+			"next":  {Type: nonNull(gql.String), Description: "the cursor of the next page, empty for the last page"},
+			"prev":  {Type: nonNull(gql.String), Description: "the cursor of the previous page, empty for the first page"},
+		},
+	})
+}
+
+// newPage convert the page of a database list
+func newPage(items interface{}, total uint64, p *database.Page) *page {
+	result := &page{Items: items, Total: total}
+	if p != nil {
+		result.Next, result.Prev = p.Next, p.Prev
+	}
+	return result
+}
+
+// newSchema return the schema of the chain data, the nested fields ask the loader of the request
+// for their documents so the documents of a level are read by one query
+func newSchema() (gql.Schema, error) {
+	var blockType, txType, debtType, accountType, contractType, activityType, minerType *gql.Object
+
+	blockType = gql.NewObject(gql.ObjectConfig{
+		Name: "Block",
+		Fields: gql.FieldsThunk(func() gql.Fields {
+			return gql.Fields{
+				"shardNumber":     {Type: nonNull(gql.Int)},
+				"height":          {Type: nonNull(Long)},
+				"headHash":        {Type: nonNull(gql.String), Description: "the block hash"},
+				"preHash":         {Type: nonNull(gql.String), Description: "the hash of the parent block"},
+				"stateHash":       {Type: nonNull(gql.String)},
+				"txHash":          {Type: nonNull(gql.String)},
+				"receiptHash":     {Type: nonNull(gql.String)},
+				"debtHash":        {Type: nonNull(gql.String)},
+				"txDebtHash":      {Type: nonNull(gql.String)},
+				"timestamp":       {Type: nonNull(Long)},
+				"difficulty":      {Type: nonNull(gql.String)},
+				"totalDifficulty": {Type: nonNull(gql.String)},
+				"creator":         {Type: nonNull(gql.String), Description: "the miner address"},
+				"nonce":           {Type: nonNull(gql.String)},
+				"extraData":       {Type: nonNull(gql.String)},
+				"pool":            {Type: nonNull(gql.String), Description: "the mining pool of the creator, empty if it is unknown"},
+				"reward":          {Type: nonNull(Long), Description: "the base reward paid by the coinbase transaction"},
+				"txFee":           {Type: nonNull(Long), Description: "the share of the transaction fees of the miner"},
+				"debtFee":         {Type: nonNull(Long), Description: "the share of the debt fees of the miner"},
+				"usedGas":         {Type: nonNull(Long)},
+				"txCount": {
+					Type: nonNull(gql.Int),
+					Resolve: func(p gql.ResolveParams) (interface{}, error) {
+						return len(p.Source.(*database.DBBlock).Txs), nil
+					},
+				},
+				"debtCount": {
+					Type: nonNull(gql.Int),
+					Resolve: func(p gql.ResolveParams) (interface{}, error) {
+						return len(p.Source.(*database.DBBlock).Debts), nil
+					},
+				},
+				"txs": {
+					Type:        listOf(txType),
+					Description: "the transactions in the order of the block",
+					Resolve: func(p gql.ResolveParams) (interface{}, error) {
+						block := p.Source.(*database.DBBlock)
+						return loaderOf(p.Context).txsOfBlock(block.ShardNumber, uint64(block.Height)), nil
+					},
+				},
+				"debts": {
+					Type:        listOf(debtType),
+					Description: "the debts in the order of the block",
+					Resolve: func(p gql.ResolveParams) (interface{}, error) {
+						block := p.Source.(*database.DBBlock)
+						return loaderOf(p.Context).debtsOfBlock(block.ShardNumber, uint64(block.Height)), nil
+					},
+				},
+				"creatorAccount": {
+					Type: accountType,
+					Resolve: func(p gql.ResolveParams) (interface{}, error) {
+						return loaderOf(p.Context).account(p.Source.(*database.DBBlock).Creator), nil
+					},
+				},
+			}
+		}),
+	})
+
+	txType = gql.NewObject(gql.ObjectConfig{
+		Name: "Transaction",
+		Fields: gql.FieldsThunk(func() gql.Fields {
+			return gql.Fields{
+				"hash":            {Type: nonNull(gql.String)},
+				"shardNumber":     {Type: nonNull(gql.Int)},
+				"txType":          {Type: nonNull(gql.Int), Description: "0 for a normal transaction, 1 for a contract creation"},
+				"from":            {Type: nonNull(gql.String)},
+				"to":              {Type: nonNull(gql.String)},
+				"amount":          {Type: nonNull(Long)},
+				"fee":             {Type: nonNull(Long)},
+				"gasPrice":        {Type: nonNull(Long)},
+				"gasLimit":        {Type: nonNull(Long)},
+				"usedGas":         {Type: nonNull(Long)},
+				"accountNonce":    {Type: nonNull(gql.String)},
+				"payload":         {Type: nonNull(gql.String)},
+				"timestamp":       {Type: nonNull(Long)},
+				"idx":             {Type: nonNull(Long), Description: "the position in the block"},
+				"pending":         {Type: nonNull(gql.Boolean)},
+				"contractAddress": {Type: nonNull(gql.String), Description: "the contract created by the transaction"},
+				"debtTxHash":      {Type: nonNull(gql.String), Description: "the hash of the debt of a cross shard transaction"},
+				"failed": {
+					Type: nonNull(gql.Boolean),
+					Resolve: func(p gql.ResolveParams) (interface{}, error) {
+						return p.Source.(*database.DBTx).Receipt.Failed, nil
+					},
+				},
+				"blockHeight": {
+					Type: nonNull(Long),
+					Resolve: func(p gql.ResolveParams) (interface{}, error) {
+						return p.Source.(*database.DBTx).Block, nil
+					},
+				},
+				"block": {
+					Type:        blockType,
+					Description: "null for a pending transaction",
+					Resolve: func(p gql.ResolveParams) (interface{}, error) {
+						tx := p.Source.(*database.DBTx)
+						if tx.Pending {
+							return nil, nil
+						}
+						return loaderOf(p.Context).block(tx.ShardNumber, tx.Block), nil
+					},
+				},
+				"fromAccount": {
+					Type: accountType,
+					Resolve: func(p gql.ResolveParams) (interface{}, error) {
+						return loaderOf(p.Context).account(p.Source.(*database.DBTx).From), nil
+					},
+				},
+				"toAccount": {
+					Type: accountType,
+					Resolve: func(p gql.ResolveParams) (interface{}, error) {
+						return loaderOf(p.Context).account(p.Source.(*database.DBTx).To), nil
+					},
+				},
+				"debt": {
+					Type:        debtType,
+					Description: "the debt of a cross shard transaction",
+					Resolve: func(p gql.ResolveParams) (interface{}, error) {
+						tx := p.Source.(*database.DBTx)
+						if tx.DebtTxHash == "" {
+							return nil, nil
+						}
+						return loaderOf(p.Context).debt(tx.DebtTxHash), nil
+					},
+				},
+			}
+		}),
+	})
+
+	debtType = gql.NewObject(gql.ObjectConfig{
+		Name: "Debt",
+		Fields: gql.FieldsThunk(func() gql.Fields {
+			return gql.Fields{
+				"hash":        {Type: nonNull(gql.String)},
+				"txHash":      {Type: nonNull(gql.String), Description: "the hash of the transaction of the debt"},
+				"shardNumber": {Type: nonNull(gql.Int)},
+				"from":        {Type: nonNull(gql.String)},
+				"to":          {Type: nonNull(gql.String)},
+				"amount":      {Type: nonNull(Long)},
+				"fee":         {Type: nonNull(Long)},
+				"payload":     {Type: nonNull(gql.String)},
+				"height":      {Type: nonNull(Long), Description: "the height of the block of the debt"},
+				"idx":         {Type: nonNull(Long), Description: "the position in the block"},
+				"tx": {
+					Type:        txType,
+					Description: "the transaction of the debt in the shard of its sender",
+					Resolve: func(p gql.ResolveParams) (interface{}, error) {
+						return loaderOf(p.Context).tx(p.Source.(*database.Debt).TxHash), nil
+					},
+				},
+			}
+		}),
+	})
+
+	activityType = gql.NewObject(gql.ObjectConfig{
+		Name:        "Activity",
+		Description: "a transfer which moves coins into or out of an address",
+		Fields: gql.FieldsThunk(func() gql.Fields {
+			return gql.Fields{
+				"kind":         {Type: nonNull(gql.String), Description: "sent, received, created, debt-in or reward"},
+				"counterparty": {Type: nonNull(gql.String)},
+				"amount":       {Type: nonNull(Long)},
+				"fee":          {Type: nonNull(Long)},
+				"txType":       {Type: nonNull(gql.Int)},
+				"hash":         {Type: nonNull(gql.String), Description: "the hash of the transaction, or of the debt of a debt-in"},
+				"idx":          {Type: nonNull(Long)},
+				"timestamp":    {Type: nonNull(Long)},
+				"blockHeight": {
+					Type: nonNull(Long),
+					Resolve: func(p gql.ResolveParams) (interface{}, error) {
+						return p.Source.(*database.DBAddressActivity).Block, nil
+					},
+				},
+				"tx": {
+					Type:        txType,
+					Description: "null for a debt-in",
+					Resolve: func(p gql.ResolveParams) (interface{}, error) {
+						activity := p.Source.(*database.DBAddressActivity)
+						if activity.Kind == database.ActivityDebtIn {
+							return nil, nil
+						}
+						return loaderOf(p.Context).tx(activity.Hash), nil
+					},
+				},
+				"debt": {
+					Type:        debtType,
+					Description: "the debt of a debt-in",
+					Resolve: func(p gql.ResolveParams) (interface{}, error) {
+						activity := p.Source.(*database.DBAddressActivity)
+						if activity.Kind != database.ActivityDebtIn {
+							return nil, nil
+						}
+						return loaderOf(p.Context).debt(activity.Hash), nil
+					},
+				},
+			}
+		}),
+	})
+
+	accountType = gql.NewObject(gql.ObjectConfig{
+		Name: "Account",
+		Fields: gql.FieldsThunk(func() gql.Fields {
+			return gql.Fields{
+				"address":     {Type: nonNull(gql.String)},
+				"shardNumber": {Type: nonNull(gql.Int)},
+				"balance":     {Type: nonNull(Long)},
+				"txCount":     {Type: nonNull(Long)},
+				"timestamp":   {Type: nonNull(Long)},
+				"isContract": {
+					Type: nonNull(gql.Boolean),
+					Resolve: func(p gql.ResolveParams) (interface{}, error) {
+						return p.Source.(*database.DBAccount).AccType == 1, nil
+					},
+				},
+				"activities": {
+					Type:        listOf(activityType),
+					Description: "the latest activities, the latest first",
+					Args: gql.FieldConfigArgument{
+						"first":     {Type: gql.Int, DefaultValue: 10, Description: "the number of activities"},
+						"direction": {Type: gql.String, DefaultValue: "", Description: "in or out, empty for both"},
+					},
+					Resolve: func(p gql.ResolveParams) (interface{}, error) {
+						first, err := sizeOf(p, "first", errInvalidFirst)
+						if err != nil {
+							return nil, err
+						}
+						direction, _ := p.Args["direction"].(string)
+						db := loaderOf(p.Context).blockDB
+						activities, _, err := db.GetAddressActivitiesByCursor(p.Source.(*database.DBAccount).Address, direction, "", first)
+						return activities, err
+					},
+				},
+				"contract": {
+					Type:        contractType,
+					Description: "the account as a contract, null if it is not a contract",
+					Resolve: func(p gql.ResolveParams) (interface{}, error) {
+						if account := p.Source.(*database.DBAccount); account.AccType == 1 {
+							return account, nil
+						}
+						return nil, nil
+					},
+				},
+			}
+		}),
+	})
+
+	contractType = gql.NewObject(gql.ObjectConfig{
+		Name: "Contract",
+		Fields: gql.FieldsThunk(func() gql.Fields {
+			return gql.Fields{
+				"address":       {Type: nonNull(gql.String)},
+				"shardNumber":   {Type: nonNull(gql.Int)},
+				"balance":       {Type: nonNull(Long)},
+				"txCount":       {Type: nonNull(Long)},
+				"timestamp":     {Type: nonNull(Long)},
+				"creator":       {Type: nonNull(gql.String), Description: "the address which deployed the contract, empty if it is unknown"},
+				"creationTx":    {Type: nonNull(gql.String)},
+				"creationBlock": {Type: nonNull(Long)},
+				"codeHash":      {Type: nonNull(gql.String), Description: "the hash of the runtime bytecode"},
+				"sourceCode":    {Type: nonNull(gql.String), Description: "the verified source code"},
+				"abi":           {Type: nonNull(gql.String), Description: "the verified abi json"},
+				"verified": {
+					Type: nonNull(gql.Boolean),
+					Resolve: func(p gql.ResolveParams) (interface{}, error) {
+						return p.Source.(*database.DBAccount).SourceCode != "", nil
+					},
+				},
+				"creatorAccount": {
+					Type: accountType,
+					Resolve: func(p gql.ResolveParams) (interface{}, error) {
+						contract := p.Source.(*database.DBAccount)
+						if contract.Creator == "" {
+							return nil, nil
+						}
+						return loaderOf(p.Context).account(contract.Creator), nil
+					},
+				},
+				"creationTransaction": {
+					Type: txType,
+					Resolve: func(p gql.ResolveParams) (interface{}, error) {
+						contract := p.Source.(*database.DBAccount)
+						if contract.CreationTx == "" {
+							return nil, nil
+						}
+						return loaderOf(p.Context).tx(contract.CreationTx), nil
+					},
+				},
+			}
+		}),
+	})
+
+	minerType = gql.NewObject(gql.ObjectConfig{
+		Name: "Miner",
+		Fields: gql.Fields{
+			"address":     {Type: nonNull(gql.String)},
+			"shardNumber": {Type: nonNull(gql.Int)},
+			"pool":        {Type: nonNull(gql.String)},
+			"mined":       {Type: nonNull(Long), Description: "the number of blocks mined"},
+			"revenue":     {Type: nonNull(Long), Description: "the rewards and the fees"},
+			"reward":      {Type: nonNull(Long)},
+			"txFee":       {Type: nonNull(Long)},
+			"debtFee":     {Type: nonNull(Long)},
+			"timestamp":   {Type: nonNull(Long)},
+			"account": {
+				Type: accountType,
+				Resolve: func(p gql.ResolveParams) (interface{}, error) {
+					return loaderOf(p.Context).account(p.Source.(*database.DBMiner).Address), nil
+				},
+			},
+		},
+	})
+
+	nodeType := gql.NewObject(gql.ObjectConfig{
+		Name: "Node",
+		Fields: gql.Fields{
+			"id":                   {Type: nonNull(gql.String)},
+			"shardNumber":          {Type: nonNull(gql.Int)},
+			"host":                 {Type: nonNull(gql.String)},
+			"port":                 {Type: nonNull(gql.String)},
+			"city":                 {Type: nonNull(gql.String)},
+			"region":               {Type: nonNull(gql.String)},
+			"country":              {Type: nonNull(gql.String)},
+			"client":               {Type: nonNull(gql.String)},
+			"caps":                 {Type: nonNull(gql.String)},
+			"lastSeen":             {Type: nonNull(Long)},
+			"longitudeAndLatitude": {Type: nonNull(gql.String)},
+		},
+	})
+
+	chartPointType := gql.NewObject(gql.ObjectConfig{
+		Name:        "ChartPoint",
+		Description: "the value of a daily chart in a day of a shard",
+		Fields: gql.Fields{
+			"timestamp":   {Type: nonNull(Long), Description: "the start of the day"},
+			"shardNumber": {Type: nonNull(gql.Int)},
+			"value":       {Type: nonNull(gql.Float)},
+		},
+	})
+
+	kinds := gql.EnumValueConfigMap{}
+	for name, kind := range chartKinds {
+		kinds[name] = &gql.EnumValueConfig{Value: name, Description: kind.description}
+	}
+	chartKindType := gql.NewEnum(gql.EnumConfig{Name: "ChartKind", Values: kinds})
+
+	query := gql.NewObject(gql.ObjectConfig{
+		Name: "Query",
+		Fields: gql.Fields{
+			"block": {
+				Type:        blockType,
+				Description: "a block by its hash or by its height in the shard",
+				Args: gql.FieldConfigArgument{
+					"shard":  shardArg(1, "the shard number of the height"),
+					"height": {Type: Long},
+					"hash":   {Type: gql.String, Description: "the block hash, height is ignored when it is set"},
+				},
+				Resolve: func(p gql.ResolveParams) (interface{}, error) {
+					l := loaderOf(p.Context)
+					if hash, _ := p.Args["hash"].(string); hash != "" {
+						return notFound(l.blockDB.GetBlockByHash(hash))
+					}
+					height, ok := p.Args["height"].(int64)
+					if !ok {
+						return nil, errNoBlockID
+					}
+					if height < 0 {
+						return nil, errInvalidHeight
+					}
+					shard, err := shardOf(p, false)
+					if err != nil {
+						return nil, err
+					}
+					return l.block(shard, uint64(height)), nil
+				},
+			},
+			"blocks": {
+				Type:        pageOf("BlockPage", blockType),
+				Description: "the blocks of a shard, the latest first",
+				Args:        pageArgs(),
+				Resolve: func(p gql.ResolveParams) (interface{}, error) {
+					shard, err := shardOf(p, false)
+					if err != nil {
+						return nil, err
+					}
+					limit, err := sizeOf(p, "limit", errInvalidLimit)
+					if err != nil {
+						return nil, err
+					}
+					cursor, _ := p.Args["cursor"].(string)
+					db := loaderOf(p.Context).blockDB
+					total, err := db.GetBlockHeight(shard)
+					if err != nil {
+						return nil, err
+					}
+					blocks, pg, err := db.GetBlocksByCursor(shard, cursor, limit)
+					if err != nil {
+						return nil, err
+					}
+					return newPage(blocks, total, pg), nil
+				},
+			},
+			"tx": {
+				Type:        txType,
+				Description: "a transaction or a pending transaction by its hash",
+				Args:        gql.FieldConfigArgument{"hash": {Type: gql.NewNonNull(gql.String)}},
+				Resolve: func(p gql.ResolveParams) (interface{}, error) {
+					l := loaderOf(p.Context)
+					hash := p.Args["hash"].(string)
+					tx := l.tx(hash)
+					return func() (interface{}, error) {
+						found, err := tx()
+						if found != nil || err != nil {
+							return found, err
+						}
+						return notFound(l.blockDB.GetPendingTxByHash(hash))
+					}, nil
+				},
+			},
+			"txs": {
+				Type:        pageOf("TransactionPage", txType),
+				Description: "the transactions of a shard, the latest first",
+				Args:        pageArgs(),
+				Resolve: func(p gql.ResolveParams) (interface{}, error) {
+					shard, err := shardOf(p, false)
+					if err != nil {
+						return nil, err
+					}
+					limit, err := sizeOf(p, "limit", errInvalidLimit)
+					if err != nil {
+						return nil, err
+					}
+					cursor, _ := p.Args["cursor"].(string)
+					db := loaderOf(p.Context).blockDB
+					total, err := db.GetTxCntByShardNumber(shard)
+					if err != nil {
+						return nil, err
+					}
+					txs, pg, err := db.GetTxsByCursor(shard, cursor, limit)
+					if err != nil {
+						return nil, err
+					}
+					return newPage(txs, total, pg), nil
+				},
+			},
+			"debt": {
+				Type:        debtType,
+				Description: "a debt by its hash",
+				Args:        gql.FieldConfigArgument{"hash": {Type: gql.NewNonNull(gql.String)}},
+				Resolve: func(p gql.ResolveParams) (interface{}, error) {
+					return loaderOf(p.Context).debt(p.Args["hash"].(string)), nil
+				},
+			},
+			"debts": {
+				Type:        pageOf("DebtPage", debtType),
+				Description: "the debts of a shard, the latest first",
+				Args:        pageArgs(),
+				Resolve: func(p gql.ResolveParams) (interface{}, error) {
+					shard, err := shardOf(p, false)
+					if err != nil {
+						return nil, err
+					}
+					limit, err := sizeOf(p, "limit", errInvalidLimit)
+					if err != nil {
+						return nil, err
+					}
+					cursor, _ := p.Args["cursor"].(string)
+					db := loaderOf(p.Context).blockDB
+					total, err := db.GetdebtCntByShardNumber(shard)
+					if err != nil {
+						return nil, err
+					}
+					debts, pg, err := db.GetdebtsByCursor(shard, cursor, limit)
+					if err != nil {
+						return nil, err
+					}
+					return newPage(debts, total, pg), nil
+				},
+			},
+			"account": {
+				Type:        accountType,
+				Description: "an account or a contract by its address",
+				Args:        gql.FieldConfigArgument{"address": {Type: gql.NewNonNull(gql.String)}},
+				Resolve: func(p gql.ResolveParams) (interface{}, error) {
+					return loaderOf(p.Context).account(p.Args["address"].(string)), nil
+				},
+			},
+			"contract": {
+				Type:        contractType,
+				Description: "a contract by its address, null if the address is not a contract",
+				Args:        gql.FieldConfigArgument{"address": {Type: gql.NewNonNull(gql.String)}},
+				Resolve: func(p gql.ResolveParams) (interface{}, error) {
+					account := loaderOf(p.Context).account(p.Args["address"].(string))
+					return func() (interface{}, error) {
+						found, err := account()
+						if err != nil || found == nil || found.(*database.DBAccount).AccType != 1 {
+							return nil, err
+						}
+						return found, nil
+					}, nil
+				},
+			},
+			"miners": {
+				Type:        listOf(minerType),
+				Description: "the miners which mined the most blocks",
+				Args: gql.FieldConfigArgument{
+					"limit": {Type: gql.Int, DefaultValue: 20, Description: "the number of miners"},
+				},
+				Resolve: func(p gql.ResolveParams) (interface{}, error) {
+					limit, err := sizeOf(p, "limit", errInvalidLimit)
+					if err != nil {
+						return nil, err
+					}
+					return loaderOf(p.Context).blockDB.GetMinerAccounts(limit)
+				},
+			},
+			"nodes": {
+				Type:        listOf(nodeType),
+				Description: "the nodes of a shard",
+				Args:        gql.FieldConfigArgument{"shard": shardArg(0, "the shard number, 0 for all the shards")},
+				Resolve: func(p gql.ResolveParams) (interface{}, error) {
+					shard, err := shardOf(p, true)
+					if err != nil {
+						return nil, err
+					}
+					l := loaderOf(p.Context)
+					if shard != 0 {
+						return l.nodesOf(shard)
+					}
+					var nodes []*database.DBNodeInfo
+					for i := 1; i <= shardCount; i++ {
+						shardNodes, err := l.nodesOf(i)
+						if err != nil {
+							return nil, err
+						}
+						nodes = append(nodes, shardNodes...)
+					}
+					return nodes, nil
+				},
+			},
+			"chart": {
+				Type:        listOf(chartPointType),
+				Description: "a daily chart, the earliest day first",
+				Args: gql.FieldConfigArgument{
+					"kind":  {Type: gql.NewNonNull(chartKindType)},
+					"shard": shardArg(0, "the shard number, 0 for the points of all the shards"),
+				},
+				Resolve: func(p gql.ResolveParams) (interface{}, error) {
+					shard, err := shardOf(p, true)
+					if err != nil {
+						return nil, err
+					}
+					return loaderOf(p.Context).chart(p.Args["kind"].(string), shard)
+				},
+			},
+		},
+	})
+
+	return gql.NewSchema(gql.SchemaConfig{Query: query})
+}
@@ -0,0 +1,213 @@
+/**
+*  @file
+*  @copyright defined in scan-api/LICENSE
+ */
+
+package handlers
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/seeleteam/scan-api/database"
+	"github.com/seeleteam/scan-api/log"
+)
+
+// the formats of the exported history of an address
+const (
+	ExportCSV  = "csv"
+	ExportJSON = "json"
+
+	exportBatchSize  = 500
+	exportDateFormat = "2006-01-02"
+)
+
+// errExportDone stop the streaming of the activities after the date range
+var errExportDone = errors.New("the date range is exported")
+
+// RetAccountHistoryRow describle an entry of the exported history of an address
+type RetAccountHistoryRow struct {
+	Time         string `json:"time" doc:"the utc time of the block, RFC 3339"`
+	ShardNumber  int    `json:"shardnumber"`
+	Block        uint64 `json:"block"`
+	Hash         string `json:"hash" doc:"the hash of the transaction or of the debt"`
+	Kind         string `json:"kind" doc:"sent, received, created, debt-in or reward"`
+	Direction    string `json:"direction" doc:"in or out"`
+	Counterparty string `json:"counterparty"`
+	Amount       int64  `json:"amount"`
+	Fee          int64  `json:"fee" doc:"the fee paid by the address, 0 for an entry in"`
+	Balance      int64  `json:"balance" doc:"the balance after the entry, summed from the indexed history of the address"`
+	Failed       bool   `json:"failed" doc:"the transaction failed, only its fee is paid and the amount is not moved"`
+}
+
+// csvRecord return the columns of the row in the order of the csv header
+func (r *RetAccountHistoryRow) csvRecord() []string {
+	return []string{r.Time, strconv.Itoa(r.ShardNumber), strconv.FormatUint(r.Block, 10), r.Hash, r.Kind, r.Direction,
+		r.Counterparty, strconv.FormatInt(r.Amount, 10), strconv.FormatInt(r.Fee, 10), strconv.FormatInt(r.Balance, 10),
+		strconv.FormatBool(r.Failed)}
+}
+
+var historyCSVHeader = []string{"time", "shardnumber", "block", "hash", "kind", "direction", "counterparty", "amount", "fee", "balance", "failed"}
+
+// exportDate parse an optional utc date of the export range, the zero time if it is missing
+func exportDate(c *gin.Context, param string) (time.Time, error) {
+	value := c.Query(param)
+	if value == "" {
+		return time.Time{}, nil
+	}
+	return time.Parse(exportDateFormat, value)
+}
+
+// historyWriter write the rows of an export in its format, the response is started by the first row
+type historyWriter struct {
+	c       *gin.Context
+	format  string
+	address string
+	started bool
+	rows    int
+	csv     *csv.Writer
+}
+
+func (w *historyWriter) start() {
+	if w.started {
+		return
+	}
+	w.started = true
+	contentType := "text/csv; charset=utf-8"
+	if w.format == ExportJSON {
+		contentType = "application/json; charset=utf-8"
+	}
+	w.c.Header("Content-Type", contentType)
+	w.c.Header("Content-Disposition", `attachment; filename="`+w.address+"."+w.format+`"`)
+	w.c.Status(http.StatusOK)
+	if w.format == ExportJSON {
+		w.c.Writer.WriteString("[")
+	} else {
+		w.csv = csv.NewWriter(w.c.Writer)
+		w.csv.Write(historyCSVHeader)
+	}
+}
+
+func (w *historyWriter) write(row *RetAccountHistoryRow) error {
+	w.start()
+	w.rows++
+	if w.format == ExportCSV {
+		return w.csv.Write(row.csvRecord())
+	}
+	data, err := json.Marshal(row)
+	if err != nil {
+		return err
+	}
+	if w.rows > 1 {
+		w.c.Writer.WriteString(",")
+	}
+	_, err = w.c.Writer.Write(append([]byte("\n"), data...))
+	return err
+}
+
+// flush send the rows written, the memory of an export is bounded by a batch
+func (w *historyWriter) flush() error {
+	if w.csv != nil {
+		w.csv.Flush()
+		if err := w.csv.Error(); err != nil {
+			return err
+		}
+	}
+	w.c.Writer.Flush()
+	return nil
+}
+
+func (w *historyWriter) end() {
+	w.start()
+	if w.format == ExportJSON {
+		w.c.Writer.WriteString("\n]\n")
+	}
+	w.flush()
+}
+
+// ExportAccount stream the transactions, debts and rewards of an address in the utc dates from and
+// to, the oldest first, with the fee paid and the running balance. The entries before from are
+// read for the balance but not written, so the cost of an export grows with the history of the
+// address before from. An error after the first row ends the response early.
+func (h *AccountHandler) ExportAccount() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		address := c.Query("address")
+		if len(address) != addressLength {
+			responseError(c, errParamInvalid, http.StatusBadRequest, apiParmaInvalid)
+			return
+		}
+		format := c.DefaultQuery("format", ExportCSV)
+		if format != ExportCSV && format != ExportJSON {
+			responseError(c, errParamInvalid, http.StatusBadRequest, apiParmaInvalid)
+			return
+		}
+		from, err := exportDate(c, "from")
+		if err != nil {
+			responseError(c, errParamInvalid, http.StatusBadRequest, apiParmaInvalid)
+			return
+		}
+		to, err := exportDate(c, "to")
+		if err != nil || (!to.IsZero() && to.Before(from)) {
+			responseError(c, errParamInvalid, http.StatusBadRequest, apiParmaInvalid)
+			return
+		}
+
+		w := &historyWriter{c: c, format: format, address: address}
+		var balance int64
+		// the activities of an address are in its shard, so the blocks are in the order of their time
+		err = h.DBClient.EachAddressActivity(address, exportBatchSize, func(activities []*database.DBAddressActivity) error {
+			for _, a := range activities {
+				row := &RetAccountHistoryRow{
+					ShardNumber:  a.ShardNumber,
+					Block:        a.Block,
+					Hash:         a.Hash,
+					Kind:         a.Kind,
+					Direction:    database.ActivityIn,
+					Counterparty: a.Counterparty,
+					Amount:       a.Amount,
+					Failed:       a.Failed,
+				}
+				// a failed transaction only takes the fee from the sender
+				switch {
+				case a.In() && !a.Failed:
+					balance += a.Amount
+				case !a.In():
+					row.Direction = database.ActivityOut
+					row.Fee = a.Fee
+					balance -= a.Fee
+					if !a.Failed {
+						balance -= a.Amount
+					}
+				}
+				row.Balance = balance
+
+				at := time.Unix(a.Timestamp, 0).UTC()
+				if !to.IsZero() && !at.Before(to.AddDate(0, 0, 1)) {
+					return errExportDone
+				}
+				if at.Before(from) {
+					continue
+				}
+				row.Time = at.Format(time.RFC3339)
+				if err := w.write(row); err != nil {
+					return err
+				}
+			}
+			return w.flush()
+		})
+		if err != nil && err != errExportDone {
+			if !w.started {
+				responseError(c, errGetAccountFromDB, http.StatusInternalServerError, apiDBQueryError)
+				return
+			}
+			log.Error("[export] export the history of %s: %v", address, err)
+			return
+		}
+		w.end()
+	}
+}
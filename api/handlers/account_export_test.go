@@ -0,0 +1,90 @@
+/**
+*  @file
+*  @copyright defined in scan-api/LICENSE
+ */
+
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/seeleteam/scan-api/database"
+	"github.com/seeleteam/scan-api/log"
+	"github.com/seeleteam/scan-api/rpc"
+	"github.com/stretchr/testify/assert"
+)
+
+const exportTestAddress = "0x0000000000000000000000000000000000000011"
+
+func newExportTestRouter(t *testing.T) *gin.Engine {
+	log.NewLogger("", "error", false)
+	db := database.NewMemoryClient(1)
+	var activities []*database.DBAddressActivity
+	for _, tx := range []*database.DBTx{
+		// 2018-10-18, 2018-10-19 and 2018-10-20 at 12:00 utc
+		{Hash: "0x0a", From: "0x0000000000000000000000000000000000000000", To: exportTestAddress, Amount: 100, Block: 1, Idx: 1, ShardNumber: 1, Timestamp: 1539864000},
+		{Hash: "0x0b", From: exportTestAddress, To: "0x02", Amount: 30, Fee: 2, Block: 2, Idx: 2, ShardNumber: 1, Timestamp: 1539950400},
+		{Hash: "0x0c", From: "0x03", To: exportTestAddress, Amount: 5, Fee: 1, Block: 3, Idx: 3, ShardNumber: 1, Timestamp: 1540036800},
+		// failed, only the fee is paid
+		{Hash: "0x0e", From: exportTestAddress, To: "0x02", Amount: 50, Fee: 3, Block: 4, Idx: 5, ShardNumber: 1, Timestamp: 1540036900,
+			Receipt: rpc.Receipt{Failed: true}},
+		{Hash: "0x0f", From: "0x03", To: exportTestAddress, Amount: 40, Fee: 1, Block: 4, Idx: 6, ShardNumber: 1, Timestamp: 1540036900,
+			Receipt: rpc.Receipt{Failed: true}},
+	} {
+		activities = append(activities, database.CreateTxActivities(tx)...)
+	}
+	activities = append(activities, database.CreateDebtActivity(&database.Debt{Hash: "0x0d", To: exportTestAddress, Amount: 7, Fee: 1,
+		Height: 3, Idx: 4, ShardNumber: 1}, "0x04", 1540036800))
+	assert.Nil(t, db.AddAddressActivities(activities...))
+
+	gin.SetMode(gin.TestMode)
+	e := gin.New()
+	e.GET("/account/export", NewAccHandler(db).ExportAccount())
+	return e
+}
+
+func export(e *gin.Engine, uri string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, httptest.NewRequest("GET", uri, nil))
+	return w
+}
+
+func Test_ExportAccount(t *testing.T) {
+	e := newExportTestRouter(t)
+
+	w := export(e, "/account/export?address="+exportTestAddress)
+	assert.Equal(t, w.Code, http.StatusOK)
+	assert.Equal(t, w.Header().Get("Content-Type"), "text/csv; charset=utf-8")
+	assert.Equal(t, w.Header().Get("Content-Disposition"), `attachment; filename="`+exportTestAddress+`.csv"`)
+	assert.Equal(t, strings.Split(strings.TrimSpace(w.Body.String()), "\n"), []string{
+		"time,shardnumber,block,hash,kind,direction,counterparty,amount,fee,balance,failed",
+		"2018-10-18T12:00:00Z,1,1,0x0a,reward,in,0x0000000000000000000000000000000000000000,100,0,100,false",
+		"2018-10-19T12:00:00Z,1,2,0x0b,sent,out,0x02,30,2,68,false",
+		"2018-10-20T12:00:00Z,1,3,0x0c,received,in,0x03,5,0,73,false",
+		"2018-10-20T12:00:00Z,1,3,0x0d,debt-in,in,0x04,7,0,80,false",
+		"2018-10-20T12:01:40Z,1,4,0x0e,sent,out,0x02,50,3,77,true",
+		"2018-10-20T12:01:40Z,1,4,0x0f,received,in,0x03,40,0,77,true",
+	})
+
+	// the balance counts the entries before the range
+	w = export(e, "/account/export?format=json&from=2018-10-19&to=2018-10-19&address="+exportTestAddress)
+	assert.Equal(t, w.Code, http.StatusOK)
+	var rows []*RetAccountHistoryRow
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &rows))
+	assert.Equal(t, rows, []*RetAccountHistoryRow{{Time: "2018-10-19T12:00:00Z", ShardNumber: 1, Block: 2, Hash: "0x0b", Kind: "sent",
+		Direction: "out", Counterparty: "0x02", Amount: 30, Fee: 2, Balance: 68}})
+
+	w = export(e, "/account/export?format=json&from=2019-01-01&address="+exportTestAddress)
+	assert.Equal(t, strings.TrimSpace(w.Body.String()), "[\n]")
+
+	for _, query := range []string{"address=0x01", "format=xml&address=" + exportTestAddress,
+		"from=19-10-2018&address=" + exportTestAddress, "from=2018-10-20&to=2018-10-19&address=" + exportTestAddress} {
+		w = export(e, "/account/export?"+query)
+		assert.Equal(t, w.Code, http.StatusBadRequest, query)
+	}
+}
@@ -193,6 +193,10 @@ func (h *AccountHandler) GetAccounts() gin.HandlerFunc {
 		}
 
 		accTbl := h.accTbls[shardNumber-1]
+		if cursor, ok := c.GetQuery("cursor"); ok {
+			h.getAccountsByCursor(c, accTbl, cursor, int(ps))
+			return
+		}
 		accCnt := accTbl.GetAccountCnt()
 
 		page, begin, end := getAccountBeginAndEndByPage(uint64(accCnt), p, ps)
@@ -215,6 +219,34 @@ func (h *AccountHandler) GetAccounts() gin.HandlerFunc {
 	}
 }
 
+//getAccountsByCursor get a page of the account list of the shard by cursor, not limited to the cached accounts
+func (h *AccountHandler) getAccountsByCursor(c *gin.Context, accTbl *AccountTbl, cursor string, ps int) {
+	accCnt, _ := h.DBClient.GetAccountCntByShardNumber(accTbl.shardNumber)
+	dbAccounts, page, err := h.DBClient.GetAccountsByCursor(accTbl.shardNumber, cursor, ps)
+	if err != nil {
+		responseCursorError(c, err, errGetAccountFromDB)
+		return
+	}
+
+	var accounts []*RetSimpleAccountInfo
+	for i := 0; i < len(dbAccounts); i++ {
+		simpleAccount := createRetSimpleAccountInfo(dbAccounts[i], accTbl.totalBalance)
+		simpleAccount.Rank = page.Begin + i + 1
+		accounts = append(accounts, simpleAccount)
+	}
+
+	pageInfo := cursorPageInfo(accCnt, page, len(accounts))
+	pageInfo["totalBalance"] = accTbl.totalBalance
+	c.JSON(http.StatusOK, gin.H{
+		"code":    apiOk,
+		"message": "",
+		"data": gin.H{
+			"pageInfo": pageInfo,
+			"list":     accounts,
+		},
+	})
+}
+
 //GetHomeAccounts handler for get account list
 func (h *AccountHandler) GetHomeAccounts() gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -234,8 +266,8 @@ func (h *AccountHandler) GetHomeAccounts() gin.HandlerFunc {
 	}
 }
 
-//GetAccountByAddressImpl use account info, account tx list and account pending tx list to assembly account information
-func (h *AccountHandler) GetAccountByAddressImpl(address string) *RetDetailAccountInfo {
+//GetAccountByAddressImpl use account info, account activities and account pending tx list in the direction to assembly account information
+func (h *AccountHandler) GetAccountByAddressImpl(address string, direction string) *RetDetailAccountInfo {
 	dbClient := h.DBClient
     begin := time.Now();
 	data, err := dbClient.GetAccountByAddress(address)
@@ -248,8 +280,8 @@ func (h *AccountHandler) GetAccountByAddressImpl(address string) *RetDetailAccou
 		return nil
 	}
 	begin = time.Now()
-	txs, err := dbClient.GetTxsByAddresses(address,false, txCount, 0)
-	log.Debug("getTxsByAddresss time:%d(s)",time.Since(begin))
+	activities, err := dbClient.GetAddressActivities(address, direction, txCount, 0)
+	log.Debug("GetAddressActivities time:%d(s)", time.Since(begin))
 
 	if err != nil {
 		return nil
@@ -262,7 +294,13 @@ func (h *AccountHandler) GetAccountByAddressImpl(address string) *RetDetailAccou
 		return nil
 	}
 
-	txs = append(pengdingTxs, txs...)
+	// the pending txs have no activities yet
+	var txs []*database.DBTx
+	for _, tx := range pengdingTxs {
+		if direction == "" || (direction == database.ActivityOut) == (tx.From == address) {
+			txs = append(txs, tx)
+		}
+	}
 
 	begin = time.Now()
 	var ttBalance int64
@@ -291,6 +329,9 @@ func (h *AccountHandler) GetAccountByAddressImpl(address string) *RetDetailAccou
 	log.Debug("update TxCount time:%d(s)",time.Since(begin))
 
 	detailAccount := createRetDetailAccountInfo(data, txs, ttBalance)
+	for _, tx := range createRetAccountActivityInfos(activities) {
+		detailAccount.Txs = append(detailAccount.Txs, *tx)
+	}
 	return detailAccount
 }
 
@@ -303,7 +344,13 @@ func (h *AccountHandler) GetAccountByAddress() gin.HandlerFunc {
 		// 	return
 		// }
 
-		detailAccount := h.GetAccountByAddressImpl(address)
+		direction := c.Query("direction")
+		if !validDirection(direction) {
+			responseError(c, errParamInvalid, http.StatusBadRequest, apiParmaInvalid)
+			return
+		}
+
+		detailAccount := h.GetAccountByAddressImpl(address, direction)
 
 		c.JSON(http.StatusOK, gin.H{
 			"code":    apiOk,
@@ -16,6 +16,7 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/seeleteam/scan-api/database"
 )
 
 const (
@@ -46,6 +47,7 @@ var (
 	errGetBlockFromDB                   = errors.New("could not get block data from db")
 	errGetTxFromDB                      = errors.New("could not get tx data from db")
 	errGetDebtFromDB                    = errors.New("could not get debt data from db")
+	errGetReplicaFromDB                 = errors.New("could not get replica status from db")
 	errGetAccountFromDB                 = errors.New("count not get account data from db")
 	errGetContractFromDB                = errors.New("count not get contract data from db")
 	errDBDataError                      = errors.New("db data is error")
@@ -56,8 +58,15 @@ var (
 	errGetBlockCountAndRewardChartError = errors.New("could not get block count and reward chart from db")
 	errGetHashRateChartError            = errors.New("could not get hashrate chart from db")
 	errGetTopMinerChartError            = errors.New("could not get top miner chart from db")
+	errGetMinerRevenueChartError        = errors.New("could not get miner revenue chart from db")
+	errGetSupplyFromDB                  = errors.New("could not get supply from db")
+	errGetSupplyChartError              = errors.New("could not get supply chart from db")
 	errGetNodeCountFromDB               = errors.New("could not get node count from db")
 	errGetNodeInfoFromDB                = errors.New("could not get node data from db")
+	errContractNotVerified              = errors.New("contract is not verified")
+	errContractABIInvalid               = errors.New("contract abi is invalid")
+	errShardNodeNotFound                = errors.New("no rpc node for the contract shard")
+	errCallContract                     = errors.New("could not call contract on node")
 )
 
 func responseError(c *gin.Context, err error, httpCode, code int) {
@@ -69,6 +78,31 @@ func responseError(c *gin.Context, err error, httpCode, code int) {
 	})
 }
 
+// responseCursorError respond the error of a listing by cursor, a cursor not created by the server is an invalid param
+func responseCursorError(c *gin.Context, err error, dbErr error) {
+	if err == database.ErrInvalidCursor {
+		responseError(c, errParamInvalid, http.StatusBadRequest, apiParmaInvalid)
+		return
+	}
+	responseError(c, dbErr, http.StatusInternalServerError, apiDBQueryError)
+}
+
+// validDirection check the direction of the address activities, empty for all the directions
+func validDirection(direction string) bool {
+	return direction == "" || direction == database.ActivityIn || direction == database.ActivityOut
+}
+
+// cursorPageInfo return the page info of a page listed by cursor, begin and end start from 1 as the page parameters
+func cursorPageInfo(totalCount interface{}, page *database.Page, size int) gin.H {
+	return gin.H{
+		"totalCount": totalCount,
+		"begin":      page.Begin + 1,
+		"end":        page.Begin + size,
+		"next":       page.Next,
+		"prev":       page.Prev,
+	}
+}
+
 //BlockHandler handle all block request
 type BlockHandler struct {
 	DBClient BlockInfoDB
@@ -177,6 +211,28 @@ func (h *BlockHandler) GetBlocks() gin.HandlerFunc {
 			return
 		}
 
+		if cursor, ok := c.GetQuery("cursor"); ok {
+			dbBlocks, page, err := dbClient.GetBlocksByCursor(shardNumber, cursor, int(ps))
+			if err != nil {
+				responseCursorError(c, err, errGetBlockFromDB)
+				return
+			}
+
+			var blocks []*RetSimpleBlockInfo
+			for i := 0; i < len(dbBlocks); i++ {
+				blocks = append(blocks, createRetSimpleBlockInfo(dbBlocks[i]))
+			}
+			c.JSON(http.StatusOK, gin.H{
+				"code":    apiOk,
+				"message": "",
+				"data": gin.H{
+					"pageInfo": cursorPageInfo(curBlockHeight, page, len(blocks)),
+					"list":     blocks,
+				},
+			})
+			return
+		}
+
 		page, begin, end := getBeginAndEndByPage(curBlockHeight, p, ps)
 		blocks := h.getBlocksByBeginAndEnd(shardNumber, begin, end)
 
@@ -291,6 +347,23 @@ func (h *BlockHandler) GetBlockCnt() gin.HandlerFunc {
 	}
 }
 
+// GetReplicas get the members of the database and the block heights they have synchronized,
+// a counter or chart read is stale if the height of its secondary is behind the primary
+func (h *BlockHandler) GetReplicas() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		replicas, err := h.DBClient.ReplicaStatus()
+		if err != nil {
+			responseError(c, errGetReplicaFromDB, http.StatusInternalServerError, apiDBQueryError)
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"code":    apiOk,
+			"message": "",
+			"data":    replicas,
+		})
+	}
+}
+
 // GetBlockProTime get the last block information
 func (h *BlockHandler) GetBlockProTime() gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -609,57 +682,21 @@ func (h *BlockHandler) GetTxsInBlock(c *gin.Context, shardNumber int, height, p,
 	})
 }
 
-//GetTxsInAccount get tx list from this account
-func (h *BlockHandler) GetTxsInAccount(c *gin.Context, address string, p, ps int) {
+//GetTxsInAccount get the activities of this account in the direction
+func (h *BlockHandler) GetTxsInAccount(c *gin.Context, address string, direction string, p, ps int) {
 	dbClient := h.DBClient
-	txCntInAccount,err := dbClient.GetTxCntByShardNumberAndAddress(-1, address)
-	txs, err := dbClient.GetTxsByAddresses(address,false,ps, p*ps)
+	txCntInAccount, err := dbClient.GetAddressActivityCnt(address, direction)
 	if err != nil {
-		responseError(c, errGetTxFromDB, http.StatusInternalServerError, apiDBQueryError)
+		responseError(c, errGetTxCountFromDB, http.StatusInternalServerError, apiDBQueryError)
 		return
 	}
-// no pending txs for now
-/*	pengdingTxs, err := dbClient.GetPendingTxsByAddress(address)
+	activities, err := dbClient.GetAddressActivities(address, direction, ps, p*ps)
 	if err != nil {
 		responseError(c, errGetTxFromDB, http.StatusInternalServerError, apiDBQueryError)
 		return
 	}
 
-	txs = append(pengdingTxs, txs...)*/
-
-	var retTxs []*RetDetailAccountTxInfo
-	for i := 0; i < len(txs); i++ {
-		data := txs[i]
-
-		timeStamp := big.NewInt(0)
-		var age string
-		if timeStamp.UnmarshalText([]byte(data.Timestamp)) == nil {
-			age = getElpasedTimeDesc(timeStamp)
-		}
-
-		var inOrOut bool
-		if data.To == address {
-			inOrOut = true
-		} else {
-			inOrOut = false
-		}
-
-		simpleTransaction := &RetDetailAccountTxInfo{
-			ShardNumber: data.ShardNumber,
-			TxType:      data.TxType,
-			Hash:        data.Hash,
-			Block:       data.Block,
-			From:        data.From,
-			To:          data.To,
-			Value:       data.Amount,
-			Age:         age,
-			Fee:         data.Fee,
-			InOrOut:     inOrOut,
-			Pending:     data.Pending,
-			Timestamp:	data.Timestamp,
-		}
-		retTxs = append(retTxs, simpleTransaction)
-	}
+	retTxs := createRetAccountActivityInfos(activities)
 
 	c.JSON(http.StatusOK, gin.H{
 		"code":    apiOk,
@@ -676,6 +713,27 @@ func (h *BlockHandler) GetTxsInAccount(c *gin.Context, address string, p, ps int
 	})
 }
 
+//GetTxsInAccountByCursor get a page of the activities of this account in the direction by cursor
+func (h *BlockHandler) GetTxsInAccountByCursor(c *gin.Context, address string, direction string, cursor string, ps int) {
+	dbClient := h.DBClient
+	txCntInAccount, _ := dbClient.GetAddressActivityCnt(address, direction)
+	activities, page, err := dbClient.GetAddressActivitiesByCursor(address, direction, cursor, ps)
+	if err != nil {
+		responseCursorError(c, err, errGetTxFromDB)
+		return
+	}
+
+	retTxs := createRetAccountActivityInfos(activities)
+	c.JSON(http.StatusOK, gin.H{
+		"code":    apiOk,
+		"message": "",
+		"data": gin.H{
+			"pageInfo": cursorPageInfo(txCntInAccount, page, len(retTxs)),
+			"list":     retTxs,
+		},
+	})
+}
+
 //GetTxs get all transactions by order or by block
 func (h *BlockHandler) GetTxs() gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -710,9 +768,51 @@ func (h *BlockHandler) GetTxs() gin.HandlerFunc {
 			}
 		}
 		//query transactions for one address
+		cursor, byCursor := c.GetQuery("cursor")
 		address, flag := c.GetQuery("address")
 		if flag {
-			h.GetTxsInAccount(c, address, p, ps)
+			direction := c.Query("direction")
+			if !validDirection(direction) {
+				responseError(c, errParamInvalid, http.StatusBadRequest, apiParmaInvalid)
+				return
+			}
+			if byCursor {
+				h.GetTxsInAccountByCursor(c, address, direction, cursor, ps)
+			} else {
+				h.GetTxsInAccount(c, address, direction, p, ps)
+			}
+			return
+		}
+		//query transactions by filter, the total of a filtered listing is not counted
+		if hasTxFilter(c) {
+			filter, verr := parseTxFilter(c, shardNumber)
+			if verr != nil {
+				responseError(c, verr, http.StatusBadRequest, apiParmaInvalid)
+				return
+			}
+			dbTrans, page, err := dbClient.GetTxsByFilter(filter, cursor, ps)
+			if err != nil {
+				verr = txFilterError(err)
+				if verr.Code == V2ErrInvalidParam {
+					responseError(c, verr, http.StatusBadRequest, apiParmaInvalid)
+				} else {
+					responseError(c, errGetTxFromDB, http.StatusInternalServerError, apiDBQueryError)
+				}
+				return
+			}
+
+			var txs []*RetSimpleTxInfo
+			for i := 0; i < len(dbTrans); i++ {
+				txs = append(txs, createRetSimpleTxInfo(dbTrans[i]))
+			}
+			c.JSON(http.StatusOK, gin.H{
+				"code":    apiOk,
+				"message": "",
+				"data": gin.H{
+					"pageInfo": cursorPageInfo(0, page, len(txs)),
+					"list":     txs,
+				},
+			})
 			return
 		}
 		//query transactions for one shard
@@ -721,6 +821,27 @@ func (h *BlockHandler) GetTxs() gin.HandlerFunc {
 			responseError(c, errGetTxCountFromDB, http.StatusInternalServerError, apiDBQueryError)
 			return
 		}
+		if byCursor {
+			dbTrans, page, err := dbClient.GetTxsByCursor(shardNumber, cursor, ps)
+			if err != nil {
+				responseCursorError(c, err, errGetTxFromDB)
+				return
+			}
+
+			var txs []*RetSimpleTxInfo
+			for i := 0; i < len(dbTrans); i++ {
+				txs = append(txs, createRetSimpleTxInfo(dbTrans[i]))
+			}
+			c.JSON(http.StatusOK, gin.H{
+				"code":    apiOk,
+				"message": "",
+				"data": gin.H{
+					"pageInfo": cursorPageInfo(txCnt, page, len(txs)),
+					"list":     txs,
+				},
+			})
+			return
+		}
 		dbTrans,err := dbClient.GetTxs(shardNumber,"timestamp",true, ps,p*ps)
 		if err != nil {
 			responseError(c, errGetTxCountFromDB, http.StatusInternalServerError, apiDBQueryError)
@@ -804,6 +925,28 @@ func (h *BlockHandler) Getdebts() gin.HandlerFunc {
 			return
 		}
 
+		if cursor, ok := c.GetQuery("cursor"); ok {
+			debttxs, page, err := dbClient.GetdebtsByCursor(shardNumber, cursor, int(ps))
+			if err != nil {
+				responseCursorError(c, err, errGetDebtFromDB)
+				return
+			}
+
+			var debts []*RetSimpledebtInfo
+			for i := 0; i < len(debttxs); i++ {
+				debts = append(debts, createRetSimpledebtInfo(debttxs[i]))
+			}
+			c.JSON(http.StatusOK, gin.H{
+				"code":    apiOk,
+				"message": "",
+				"data": gin.H{
+					"pageInfo": cursorPageInfo(debtCnt, page, len(debts)),
+					"list":     debts,
+				},
+			})
+			return
+		}
+
 		page, begin, end := getBeginAndEndByPage(debtCnt, p, ps)
 		debts := h.getdebtsByBeginAndEnd(shardNumber, begin, end)
 
@@ -900,6 +1043,28 @@ func (h *BlockHandler) GetPendingTxs() gin.HandlerFunc {
 			return
 		}
 
+		if cursor, ok := c.GetQuery("cursor"); ok {
+			dbTrans, page, err := dbClient.GetPendingTxsByCursor(shardNumber, cursor, int(ps))
+			if err != nil {
+				responseCursorError(c, err, errGetTxFromDB)
+				return
+			}
+
+			var txs []*RetSimpleTxInfo
+			for i := 0; i < len(dbTrans); i++ {
+				txs = append(txs, createRetSimpleTxInfo(dbTrans[i]))
+			}
+			c.JSON(http.StatusOK, gin.H{
+				"code":    apiOk,
+				"message": "",
+				"data": gin.H{
+					"pageInfo": cursorPageInfo(txCnt, page, len(txs)),
+					"list":     txs,
+				},
+			})
+			return
+		}
+
 		page, begin, end := getBeginAndEndByPage(txCnt, p, ps)
 		txs := h.getPendingTxsByBeginAndEnd(shardNumber, begin, end)
 
@@ -919,7 +1084,9 @@ func (h *BlockHandler) GetPendingTxs() gin.HandlerFunc {
 	}
 }
 
-//Search search something by transaction hash or block height
+//Search classify the content and find the blocks of a height, the block or the transaction of a hash,
+//the account or the contract of an address, or the accounts labeled by a name. The first hit is detailed
+//in info, all the hits are summarized in hits
 func (h *BlockHandler) Search(accHandler *AccountHandler, contractHandler *ContractHandler) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		dbClient := h.DBClient
@@ -930,83 +1097,118 @@ func (h *BlockHandler) Search(accHandler *AccountHandler, contractHandler *Contr
 			return
 		}
 
-		dbBlock, err := dbClient.GetBlockByHash(content)
-		if err == nil {
-			var maxHeight uint64
-			maxHeight, err = dbClient.GetBlockHeight(dbBlock.ShardNumber)
+		match, hits, err := findSearchHits(dbClient, content)
+		if err != nil {
+			responseError(c, errSearchFromDB, http.StatusInternalServerError, apiDBQueryError)
+			return
+		}
+		if len(hits) == 0 {
+			responseError(c, errParamInvalid, http.StatusOK, apiDBQueryError)
+			return
+		}
+
+		best := hits[0]
+		var info interface{}
+		switch {
+		case best.block != nil:
+			maxHeight, err := dbClient.GetBlockHeight(best.block.ShardNumber)
 			if err != nil {
 				responseError(c, errGetBlockHeightFromDB, http.StatusInternalServerError, apiDBQueryError)
 				return
 			}
-
-			detailBlock := createRetDetailBlockInfo(dbBlock, maxHeight, 0)
-			c.JSON(http.StatusOK, gin.H{
-				"code":    apiOk,
-				"message": "",
-				"data": gin.H{
-					"type": blockTypestr,
-					"info": detailBlock,
-				},
-			})
-			return
+			info = createRetDetailBlockInfo(best.block, maxHeight, 0)
+		case best.pending:
+			info = createRetSimpleTxInfo(best.tx)
+		case best.tx != nil:
+			info = createRetDetailTxInfo(best.tx)
+		default:
+			var detail *RetDetailAccountInfo
+			if best.account.AccType == 1 {
+				detail = contractHandler.GetContractByAddressImpl(best.account.Address)
+			} else {
+				detail = accHandler.GetAccountByAddressImpl(best.account.Address, "")
+			}
+			if detail == nil {
+				responseError(c, errGetAccountFromDB, http.StatusInternalServerError, apiDBQueryError)
+				return
+			}
+			info = detail
 		}
 
-		dbTx, err := dbClient.GetTxByHash(content)
-		if err == nil {
-			detailTx := createRetDetailTxInfo(dbTx)
+		c.JSON(http.StatusOK, gin.H{
+			"code":    apiOk,
+			"message": "",
+			"data": gin.H{
+				"type":   best.kind(),
+				"info":   info,
+				"match":  match,
+				"labels": retLabels(best.labels),
+				"hits":   searchSummaries(hits),
+			},
+		})
+	}
+}
 
-			c.JSON(http.StatusOK, gin.H{
-				"code":    apiOk,
-				"message": "",
-				"data": gin.H{
-					"type": transTypeStr,
-					"info": detailTx,
-				},
-			})
+//SearchSuggest complete the prefix of an address, or of the name of a label, a token or a verified contract
+func (h *BlockHandler) SearchSuggest() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		prefix, limit, verr := parseSuggest(c)
+		if verr != nil {
+			responseError(c, verr, http.StatusBadRequest, apiParmaInvalid)
 			return
 		}
 
-		dbTx, err = dbClient.GetPendingTxByHash(content)
-		if err == nil {
-			simpleTx := createRetSimpleTxInfo(dbTx)
-
-			c.JSON(http.StatusOK, gin.H{
-				"code":    apiOk,
-				"message": "",
-				"data": gin.H{
-					"type": transTypeStr,
-					"info": simpleTx,
-				},
-			})
+		suggestions, err := suggestSearch(h.DBClient, prefix, limit)
+		if err != nil {
+			responseError(c, errSearchFromDB, http.StatusInternalServerError, apiDBQueryError)
 			return
 		}
+		c.JSON(http.StatusOK, gin.H{
+			"code":    apiOk,
+			"message": "",
+			"data":    suggestions,
+		})
+	}
+}
 
-		dbAccount := accHandler.GetAccountByAddressImpl(content)
-		if dbAccount != nil {
-			c.JSON(http.StatusOK, gin.H{
-				"code":    apiOk,
-				"message": "",
-				"data": gin.H{
-					"type": accTypeStr,
-					"info": dbAccount,
-				},
-			})
+// GetSupply get the total supply, the supply of every shard and the coins minted today,
+// the supply is reconciled against the balances of the indexed accounts
+func (h *BlockHandler) GetSupply() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		supply, err := getSupplyInfo(h.DBClient)
+		if err != nil {
+			responseError(c, errGetSupplyFromDB, http.StatusInternalServerError, apiDBQueryError)
 			return
 		}
 
-		dbContract := contractHandler.GetContractByAddressImpl(content)
-		if dbContract != nil {
-			c.JSON(http.StatusOK, gin.H{
-				"code":    apiOk,
-				"message": "",
-				"data": gin.H{
-					"type": contractTypeStr,
-					"info": dbContract,
-				},
-			})
-			return
-		}
+		c.JSON(http.StatusOK, gin.H{
+			"code":    apiOk,
+			"message": "",
+			"data":    supply,
+		})
+	}
+}
+
+// getSupplyInfo read the supplies, the balances and the coins minted today of every shard
+func getSupplyInfo(dbClient BlockInfoDB) (*RetSupplyInfo, error) {
+	supplies, err := dbClient.GetSupply()
+	if err != nil {
+		return nil, err
+	}
+
+	balances, err := dbClient.GetTotalBalance()
+	if err != nil {
+		return nil, err
+	}
 
-		responseError(c, errParamInvalid, http.StatusOK, apiDBQueryError)
+	now := time.Now()
+	todayZeroTime := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	today := make(map[int]*database.DBOneDaySupply)
+	for _, supply := range supplies {
+		// the day row does not exist until the first block of the day is synced
+		if oneDaySupply, err := dbClient.GetOneDaySupply(supply.ShardNumber, todayZeroTime.Unix()); err == nil {
+			today[supply.ShardNumber] = oneDaySupply
+		}
 	}
+	return createRetSupplyInfo(supplies, today, balances), nil
 }
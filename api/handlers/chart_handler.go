@@ -594,3 +594,78 @@ func (h *ChartHandler) GetEveryHashRate() gin.HandlerFunc {
 		}
 	}
 }
+
+//GetMinerRevenue handler for every day revenue chart of an miner
+func (h *ChartHandler) GetMinerRevenue() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		address := c.Query("address")
+		if address == "" {
+			responseError(c, errParamInvalid, http.StatusBadRequest, apiParmaInvalid)
+			return
+		}
+		s, _ := strconv.ParseInt(c.Query("s"), 10, 64)
+		shardNumber := int(s)
+
+		dbClinet := h.DBClient
+
+		var revenues []*database.DBOneDayMinerRevenue
+		var err error
+		if shardNumber <= 0 {
+			revenues, err = dbClinet.GetMinerRevenueChart(address)
+		} else {
+			revenues, err = dbClinet.GetMinerRevenueChartByShardNumber(address, shardNumber)
+		}
+		if err != nil {
+			responseError(c, errGetMinerRevenueChartError, http.StatusInternalServerError, apiDBQueryError)
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"code":    apiOk,
+			"message": "",
+			"data":    revenues,
+		})
+	}
+}
+
+//GetSupplyChart handler for every day supply chart
+func (h *ChartHandler) GetSupplyChart() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		s, _ := strconv.ParseInt(c.Query("s"), 10, 64)
+		shardNumber := int(s)
+
+		dbClinet := h.DBClient
+
+		supplies, err := dbClinet.GetSupply()
+		if err != nil {
+			responseError(c, errGetSupplyChartError, http.StatusInternalServerError, apiDBQueryError)
+			return
+		}
+
+		var genesis int64
+		var oneDaySupplies []*database.DBOneDaySupply
+		if shardNumber <= 0 {
+			for _, supply := range supplies {
+				genesis += supply.Genesis
+			}
+			oneDaySupplies, err = dbClinet.GetSupplyChart()
+		} else {
+			for _, supply := range supplies {
+				if supply.ShardNumber == shardNumber {
+					genesis = supply.Genesis
+				}
+			}
+			oneDaySupplies, err = dbClinet.GetSupplyChartByShardNumber(shardNumber)
+		}
+		if err != nil {
+			responseError(c, errGetSupplyChartError, http.StatusInternalServerError, apiDBQueryError)
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"code":    apiOk,
+			"message": "",
+			"data":    createRetSupplyChart(genesis, oneDaySupplies),
+		})
+	}
+}
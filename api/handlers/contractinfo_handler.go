@@ -8,10 +8,15 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/seeleteam/scan-api/abi"
 	"github.com/seeleteam/scan-api/database"
 	"github.com/seeleteam/scan-api/log"
+	"github.com/seeleteam/scan-api/rpc"
 )
 
+// familyAddressNums is the default number of the contract addresses of a bytecode family
+const familyAddressNums = 10
+
 //ContractTbl describe
 type ContractTbl struct {
 	shardNumber   int
@@ -86,10 +91,12 @@ func (a *ContractTbl) getContractsByBeginAndEnd(begin, end uint64) []*RetSimpleA
 type ContractHandler struct {
 	contractTbls []*ContractTbl
 	DBClient     BlockInfoDB
+	rpcNodes     map[int]string
 }
 
-//NewContractHandler return an contractHandler to handler account request
-func NewContractHandler(DBClient BlockInfoDB) *ContractHandler {
+//NewContractHandler return an contractHandler to handler account request,
+//rpcNodes is the rpc address of a node for each shard used by contract calls
+func NewContractHandler(DBClient BlockInfoDB, rpcNodes map[int]string) *ContractHandler {
 	var contractTbls []*ContractTbl
 	for i := 1; i <= shardCount; i++ {
 		contractTbl := &ContractTbl{shardNumber: i, DBClient: DBClient}
@@ -99,6 +106,7 @@ func NewContractHandler(DBClient BlockInfoDB) *ContractHandler {
 	ret := &ContractHandler{
 		contractTbls: contractTbls,
 		DBClient:     DBClient,
+		rpcNodes:     rpcNodes,
 	}
 	ret.updateImpl()
 	return ret
@@ -284,5 +292,274 @@ func (h *ContractHandler) verifyContractImpl(address string, sourceCode string,a
 		log.Error("save contract verification info failed, address:%s", address)
 		return err
 	}
+	labelVerifiedContract(h.DBClient, address, sourceCode)
 	return nil
-}
\ No newline at end of file
+}
+
+//getContractABI get the verified contract and parse its abi
+func (h *ContractHandler) getContractABI(address string) (*database.DBAccount, *abi.ABI, error) {
+	data, err := h.DBClient.GetAccountByAddress(address)
+	if err != nil {
+		return nil, nil, errGetContractFromDB
+	}
+
+	if data.AccType != 1 || data.ABI == "" {
+		return nil, nil, errContractNotVerified
+	}
+
+	contractABI, err := abi.Parse(data.ABI)
+	if err != nil {
+		log.Error("parse contract abi failed, address:%s, err:%v", address, err)
+		return nil, nil, errContractABIInvalid
+	}
+
+	return data, contractABI, nil
+}
+
+//callContract execute the payload on a node of the contract shard
+func (h *ContractHandler) callContract(shardNumber int, address, payload string, height int64) (*rpc.Receipt, error) {
+	rpcURL, ok := h.rpcNodes[shardNumber]
+	if !ok {
+		return nil, errShardNodeNotFound
+	}
+
+	client := rpc.NewRPC(rpcURL)
+	defer client.Release()
+	if err := client.Connect(); err != nil {
+		log.Error("rpc init failed, connurl:%v", rpcURL)
+		return nil, errCallContract
+	}
+
+	receipt, err := client.Call(address, payload, height)
+	if err != nil {
+		log.Error("call contract failed, address:%s, err:%v", address, err)
+		return nil, errCallContract
+	}
+	return receipt, nil
+}
+
+//responseContractError response the error returned by getContractABI
+func responseContractError(c *gin.Context, err error) {
+	if err == errGetContractFromDB {
+		responseError(c, err, http.StatusInternalServerError, apiDBQueryError)
+	} else {
+		responseError(c, err, http.StatusBadRequest, apiParmaInvalid)
+	}
+}
+
+//GetContractMethods list the view methods of a verified contract
+func (h *ContractHandler) GetContractMethods() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		address := c.Query("address")
+		_, contractABI, err := h.getContractABI(address)
+		if err != nil {
+			responseContractError(c, err)
+			return
+		}
+
+		methods := make([]*RetContractMethod, 0)
+		viewMethods := contractABI.ViewMethods()
+		for i := range viewMethods {
+			methods = append(methods, createRetContractMethod(&viewMethods[i]))
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"code":    apiOk,
+			"message": "",
+			"data":    methods,
+		})
+	}
+}
+
+//ReadContract call a view method of a verified contract and decode the outputs with its abi
+func (h *ContractHandler) ReadContract() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		address := c.Query("address")
+		methodName := c.Query("method")
+		args := c.QueryArray("args")
+		height, err := strconv.ParseInt(c.DefaultQuery("height", "-1"), 10, 64)
+		if err != nil || height < -1 || methodName == "" {
+			responseError(c, errParamInvalid, http.StatusBadRequest, apiParmaInvalid)
+			return
+		}
+
+		data, contractABI, err := h.getContractABI(address)
+		if err != nil {
+			responseContractError(c, err)
+			return
+		}
+
+		method, err := contractABI.MethodByName(methodName, len(args))
+		if err != nil || !method.IsView() {
+			responseError(c, errParamInvalid, http.StatusBadRequest, apiParmaInvalid)
+			return
+		}
+
+		payload, err := method.Pack(args)
+		if err != nil {
+			responseError(c, err, http.StatusBadRequest, apiParmaInvalid)
+			return
+		}
+
+		receipt, err := h.callContract(data.ShardNumber, data.Address, payload, height)
+		if err != nil {
+			responseError(c, err, http.StatusInternalServerError, apiInternalError)
+			return
+		}
+
+		var values []interface{}
+		if !receipt.Failed {
+			values, err = method.Unpack(receipt.Result)
+			if err != nil {
+				log.Error("unpack contract output failed, address:%s, err:%v", address, err)
+				responseError(c, errContractABIInvalid, http.StatusInternalServerError, apiInternalError)
+				return
+			}
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"code":    apiOk,
+			"message": "",
+			"data":    createRetContractReadResult(data.Address, method, height, receipt, values),
+		})
+	}
+}
+
+//getContractPageParams parse the p and ps query params of the contract lists, p is zero based
+func getContractPageParams(c *gin.Context) (int, int) {
+	p, _ := strconv.Atoi(c.Query("p"))
+	ps, _ := strconv.Atoi(c.Query("ps"))
+	if ps <= 0 {
+		ps = blockItemNumsPrePage
+	} else if ps > maxItemNumsPrePage {
+		ps = maxItemNumsPrePage
+	}
+
+	if p >= 1 {
+		p--
+	} else {
+		p = 0
+	}
+	return p, ps
+}
+
+//responseContractList response a page of contracts
+func responseContractList(c *gin.Context, dbAccounts []*database.DBAccount, totalCount uint64, p, ps int, extra gin.H) {
+	contracts := make([]*RetSimpleContractInfo, 0)
+	for _, account := range dbAccounts {
+		contracts = append(contracts, createRetSimpleContractInfo(account))
+	}
+
+	data := gin.H{
+		"pageInfo": gin.H{
+			"totalCount": totalCount,
+			"begin":      p*ps + 1,
+			"end":        (p + 1) * ps,
+			"curPage":    p + 1,
+		},
+		"list": contracts,
+	}
+	for k, v := range extra {
+		data[k] = v
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    apiOk,
+		"message": "",
+		"data":    data,
+	})
+}
+
+//GetContractsByCreator get the contracts deployed by an address
+func (h *ContractHandler) GetContractsByCreator() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		creator := c.Query("address")
+		if creator == "" {
+			responseError(c, errParamInvalid, http.StatusBadRequest, apiParmaInvalid)
+			return
+		}
+		p, ps := getContractPageParams(c)
+
+		contractCnt, err := h.DBClient.GetContractCntByCreator(creator)
+		if err != nil {
+			responseError(c, errGetContractFromDB, http.StatusInternalServerError, apiDBQueryError)
+			return
+		}
+
+		contracts, err := h.DBClient.GetContractsByCreator(creator, p*ps, ps)
+		if err != nil {
+			responseError(c, errGetContractFromDB, http.StatusInternalServerError, apiDBQueryError)
+			return
+		}
+
+		responseContractList(c, contracts, contractCnt, p, ps, gin.H{"creator": creator})
+	}
+}
+
+//GetSimilarContracts get the contracts which have the same runtime bytecode as the given contract
+func (h *ContractHandler) GetSimilarContracts() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		address := c.Query("address")
+		p, ps := getContractPageParams(c)
+
+		data, err := h.DBClient.GetAccountByAddress(address)
+		if err != nil {
+			responseError(c, errGetContractFromDB, http.StatusInternalServerError, apiDBQueryError)
+			return
+		}
+
+		if data.AccType != 1 || data.CodeHash == "" {
+			responseError(c, errParamInvalid, http.StatusBadRequest, apiParmaInvalid)
+			return
+		}
+
+		contractCnt, err := h.DBClient.GetContractCntByCodeHash(data.CodeHash)
+		if err != nil {
+			responseError(c, errGetContractFromDB, http.StatusInternalServerError, apiDBQueryError)
+			return
+		}
+
+		contracts, err := h.DBClient.GetContractsByCodeHash(data.CodeHash, p*ps, ps)
+		if err != nil {
+			responseError(c, errGetContractFromDB, http.StatusInternalServerError, apiDBQueryError)
+			return
+		}
+
+		responseContractList(c, contracts, contractCnt, p, ps, gin.H{"codeHash": data.CodeHash})
+	}
+}
+
+//GetContractFamilies get a page of the bytecode hashes shared by several contracts, such as clones and proxy
+//families, with at most addresses contract addresses of every hash
+func (h *ContractHandler) GetContractFamilies() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		minCount, _ := strconv.Atoi(c.Query("min"))
+		if minCount < 2 {
+			minCount = 2
+		}
+		p, ps := getContractPageParams(c)
+		addresses, _ := strconv.Atoi(c.Query("addresses"))
+		if addresses <= 0 {
+			addresses = familyAddressNums
+		} else if addresses > maxItemNumsPrePage {
+			addresses = maxItemNumsPrePage
+		}
+
+		groups, err := h.DBClient.GetCodeHashGroups(minCount, addresses, p*ps, ps)
+		if err != nil {
+			responseError(c, errGetContractFromDB, http.StatusInternalServerError, apiDBQueryError)
+			return
+		}
+
+		families := make([]*RetCodeHashGroup, 0)
+		for _, group := range groups {
+			families = append(families, createRetCodeHashGroup(group))
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"code":    apiOk,
+			"message": "",
+			"data":    families,
+		})
+	}
+}
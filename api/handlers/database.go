@@ -10,6 +10,8 @@ type BlockInfoDB interface {
 	GetTxsDayCount() ([]*database.DBTx, error)
 	GetBlocksByHeight(shardNumber int, begin uint64, end uint64) ([]*database.DBBlock, error)
 	GetBlockByHash(hash string) (*database.DBBlock, error)
+	GetBlocksByHeights(shardNumber int, heights []uint64) ([]*database.DBBlock, error)
+	GetBlocksByCursor(shardNumber int, cursor string, limit int) ([]*database.DBBlock, *database.Page, error)
 	GetTxCnt() (uint64, error)
 	GetBlockCnt() (uint64, error)
 	GetBlockProTime() (int64, int64, error)
@@ -19,16 +21,33 @@ type BlockInfoDB interface {
 	GetdebtCntByShardNumber(shardNumber int) (uint64, error)
 	GetPendingTxCntByShardNumber(shardNumber int) (uint64, error)
 	GetTxByHash(hash string) (*database.DBTx, error)
+	GetTxsByHashes(hashes []string) ([]*database.DBTx, error)
 	GetPendingTxByHash(hash string) (*database.DBTx, error)
 	GetTxsByIdx(shardNumber int, begin uint64, end uint64) ([]*database.DBTx, error)
 	GetdebtsByIdx(shardNumber int, begin uint64, end uint64) ([]*database.Debt, error)
 	GetPendingTxsByIdx(shardNumber int, begin uint64, end uint64) ([]*database.DBTx, error)
+	GetTxsByCursor(shardNumber int, cursor string, limit int) ([]*database.DBTx, *database.Page, error)
+	GetdebtsByCursor(shardNumber int, cursor string, limit int) ([]*database.Debt, *database.Page, error)
+	GetPendingTxsByCursor(shardNumber int, cursor string, limit int) ([]*database.DBTx, *database.Page, error)
+	GetTxsByFilter(filter *database.TxFilter, cursor string, limit int) ([]*database.DBTx, *database.Page, error)
 	GetBlockfee(block uint64) (int64, error)
+	GetTxsByBlock(shardNumber int, height uint64) ([]*database.DBTx, error)
+	GetDebtsByBlock(shardNumber int, height uint64) ([]*database.Debt, error)
+	GetTxsByBlocks(shardNumber int, heights []uint64) ([]*database.DBTx, error)
+	GetDebtsByBlocks(shardNumber int, heights []uint64) ([]*database.Debt, error)
 	GetTxsByAddresses(address string, asc bool, limit int, skip int) ([]*database.DBTx, error)
+	GetTxsByAddressAndBlocks(address string, startBlock uint64, endBlock uint64, asc bool, limit int, skip int) ([]*database.DBTx, error)
+	GetAddressActivities(address string, direction string, limit int, skip int) ([]*database.DBAddressActivity, error)
+	GetAddressActivitiesByCursor(address string, direction string, cursor string, limit int) ([]*database.DBAddressActivity, *database.Page, error)
+	GetAddressActivityCnt(address string, direction string) (int64, error)
+	EachAddressActivity(address string, batchSize int, fn func(activities []*database.DBAddressActivity) error) error
 	GetPendingTxsByAddress(address string) ([]*database.DBTx, error)
 	GetAccountCntByShardNumber(shardNumber int) (uint64, error)
 	GetAccountByAddress(address string) (*database.DBAccount, error)
+	GetAccountsByAddresses(addresses []string) ([]*database.DBAccount, error)
 	GetAccountsByShardNumber(shardNumber int, max int) ([]*database.DBAccount, error)
+	GetAccountsByCursor(shardNumber int, cursor string, limit int) ([]*database.DBAccount, *database.Page, error)
+	GetAccountsByAddressPrefix(prefix string, limit int) ([]*database.DBAccount, error)
 	GetContractCntByShardNumber(shardNumber int) (uint64, error)
 	GetContractsByShardNumber(shardNumber int, max int) ([]*database.DBAccount, error)
 	GetTotalBalance() (map[int]int64, error)
@@ -36,11 +55,24 @@ type BlockInfoDB interface {
 	GetMinerAccounts(size int) ([]*database.DBMiner, error)
 	GetAccountsByHome() []*database.DBAccount
 	GetDebtByHash(hash string) (*database.Debt, error)
+	GetDebtsByHashes(hashes []string) ([]*database.Debt, error)
 	GetblockdebtCntByShardNumber(shardNumber int, height uint64) (uint64, error)
 	GetblockdebtsByIdx(shardNumber int, height uint64, begin uint64, end uint64) ([]*database.Debt, error)
 	GetTxHis(startDate, today string) ([]*database.DBSimpleTxs, error)
 	GetTxs(shardNumber int, sort string, desc bool , limit int, skip int) ([]*database.DBTx, error)
 	UpdateContract(address string, sourceCode string, abiJson string) (error)
+	GetContractsByCreator(creator string, skip int, limit int) ([]*database.DBAccount, error)
+	GetContractCntByCreator(creator string) (uint64, error)
+	GetContractsByCodeHash(codeHash string, skip int, limit int) ([]*database.DBAccount, error)
+	GetContractCntByCodeHash(codeHash string) (uint64, error)
+	GetCodeHashGroups(minCount int, maxAddresses int, skip int, limit int) ([]*database.DBCodeHashGroup, error)
+	GetSupply() ([]*database.DBSupply, error)
+	GetOneDaySupply(shardNumber int, zeroTime int64) (*database.DBOneDaySupply, error)
+	ReplicaStatus() ([]*database.ReplicaStatus, error)
+	SetLabel(label *database.DBLabel) error
+	GetLabelsByAddresses(addresses []string) ([]*database.DBLabel, error)
+	GetLabelsByName(name string, limit int) ([]*database.DBLabel, error)
+	GetLabelsByPrefix(prefix string, limit int) ([]*database.DBLabel, error)
 }
 
 // ChartInfoDB Warpper for access mongodb.
@@ -52,6 +84,9 @@ type ChartInfoDB interface {
 	GetHashRateChart() ([]*database.DBOneDayHashRate, error)
 	GetOneDayBlockAvgTimeChart() ([]*database.DBOneDayBlockAvgTime, error)
 	GetTopMinerChart() ([]*database.DBMinerRankInfo, error)
+	GetMinerRevenueChart(address string) ([]*database.DBOneDayMinerRevenue, error)
+	GetSupply() ([]*database.DBSupply, error)
+	GetSupplyChart() ([]*database.DBOneDaySupply, error)
 
 	GetTransInfoChartByShardNumber(shardNumber int) ([]*database.DBOneDayTxInfo, error)
 	GetOneDayAddressesChartByShardNumber(shardNumber int) ([]*database.DBOneDayAddressInfo, error)
@@ -60,6 +95,8 @@ type ChartInfoDB interface {
 	GetHashRateChartByShardNumber(shardNumber int) ([]*database.DBOneDayHashRate, error)
 	GetOneDayBlockAvgTimeChartByShardNumber(shardNumber int) ([]*database.DBOneDayBlockAvgTime, error)
 	GetTopMinerChartByShardNumber(shardNumber int) ([]*database.DBMinerRankInfo, error)
+	GetMinerRevenueChartByShardNumber(address string, shardNumber int) ([]*database.DBOneDayMinerRevenue, error)
+	GetSupplyChartByShardNumber(shardNumber int) ([]*database.DBOneDaySupply, error)
 }
 
 // NodeInfoDB Warpper for access mongodb.
@@ -0,0 +1,317 @@
+/**
+*  @file
+*  @copyright defined in scan-api/LICENSE
+ */
+
+package handlers
+
+import (
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/seeleteam/scan-api/database"
+	"github.com/seeleteam/scan-api/log"
+	"gopkg.in/mgo.v2"
+)
+
+// the kinds of the inputs of a search
+const (
+	SearchHeight      = "height"
+	SearchShardHeight = "shard:height"
+	SearchHash        = "hash"
+	SearchAddress     = "address"
+	SearchLabel       = "label"
+
+	// the limits of the suggestions of a prefix
+	DefaultSuggestLimit = 10
+	MaxSuggestLimit     = 20
+
+	searchLabelLimit      = 10
+	suggestMinLength      = 2
+	suggestAddressType    = "address"
+	suggestLabelType      = "label"
+	searchNotFoundMessage = "block, transaction or account"
+)
+
+var (
+	errSearchFromDB = errors.New("could not search the db")
+
+	heightPattern      = regexp.MustCompile(`^[0-9]+$`)
+	shardHeightPattern = regexp.MustCompile(`^([0-9]+):([0-9]+)$`)
+	hexPattern         = regexp.MustCompile(`^0x[0-9a-f]*$`)
+	// the name of the last contract declared in a source, which is the one deployed by solc
+	contractNamePattern = regexp.MustCompile(`\bcontract\s+([A-Za-z_$][A-Za-z0-9_$]*)`)
+)
+
+// RetLabel describle a label of an address
+type RetLabel struct {
+	Kind string `json:"kind" doc:"label, token or contract"`
+	Name string `json:"name"`
+}
+
+// RetSearchHit describle a block, a transaction, an account or a contract found by a search
+type RetSearchHit struct {
+	Type        string      `json:"type" doc:"block, transaction, account or contract"`
+	ShardNumber int         `json:"shardnumber"`
+	Height      uint64      `json:"height,omitempty" doc:"the height of the block, or the block of the transaction"`
+	Hash        string      `json:"hash,omitempty"`
+	Address     string      `json:"address,omitempty"`
+	Pending     bool        `json:"pending,omitempty" doc:"whether the transaction is pending"`
+	Labels      []*RetLabel `json:"labels,omitempty"`
+}
+
+// RetSearchSuggestion describle a completion of a search
+type RetSearchSuggestion struct {
+	Type    string `json:"type" doc:"address or label"`
+	Kind    string `json:"kind" doc:"account or contract for an address, label, token or contract for a label"`
+	Text    string `json:"text" doc:"the address or the name of the label"`
+	Address string `json:"address"`
+}
+
+// searchHit is a document found by a search
+type searchHit struct {
+	block   *database.DBBlock
+	tx      *database.DBTx
+	pending bool
+	account *database.DBAccount
+	labels  []*database.DBLabel
+}
+
+func (hit *searchHit) kind() string {
+	switch {
+	case hit.block != nil:
+		return blockTypestr
+	case hit.tx != nil:
+		return transTypeStr
+	case hit.account.AccType == 1:
+		return contractTypeStr
+	}
+	return accTypeStr
+}
+
+func (hit *searchHit) summary() *RetSearchHit {
+	ret := &RetSearchHit{Type: hit.kind(), Labels: retLabels(hit.labels)}
+	switch {
+	case hit.block != nil:
+		ret.ShardNumber, ret.Height, ret.Hash = hit.block.ShardNumber, uint64(hit.block.Height), hit.block.HeadHash
+	case hit.tx != nil:
+		ret.ShardNumber, ret.Height, ret.Hash, ret.Pending = hit.tx.ShardNumber, hit.tx.Block, hit.tx.Hash, hit.pending
+	default:
+		ret.ShardNumber, ret.Address = hit.account.ShardNumber, hit.account.Address
+	}
+	return ret
+}
+
+func retLabels(labels []*database.DBLabel) []*RetLabel {
+	ret := make([]*RetLabel, 0, len(labels))
+	for _, label := range labels {
+		ret = append(ret, &RetLabel{Kind: label.Kind, Name: label.Name})
+	}
+	return ret
+}
+
+func searchSummaries(hits []*searchHit) []*RetSearchHit {
+	ret := make([]*RetSearchHit, 0, len(hits))
+	for _, hit := range hits {
+		ret = append(ret, hit.summary())
+	}
+	return ret
+}
+
+// classifySearch return the kind of the input of a search, the hex inputs are lowercase
+func classifySearch(q string) string {
+	switch {
+	case heightPattern.MatchString(q):
+		return SearchHeight
+	case shardHeightPattern.MatchString(q):
+		return SearchShardHeight
+	case len(q) == txHashLength && hexPattern.MatchString(q):
+		return SearchHash
+	case len(q) == addressLength && hexPattern.MatchString(q):
+		return SearchAddress
+	}
+	return SearchLabel
+}
+
+// findSearchHits classify the input of a search and run only the lookups of its kind, a height
+// is found in every shard and a hash may be a block and a transaction, so there may be several hits
+func findSearchHits(db BlockInfoDB, q string) (string, []*searchHit, error) {
+	q = strings.TrimSpace(q)
+	if strings.HasPrefix(strings.ToLower(q), "0x") {
+		q = strings.ToLower(q)
+	}
+	match := classifySearch(q)
+	var hits []*searchHit
+	var err error
+	switch match {
+	case SearchHeight:
+		hits, err = searchHeight(db, 1, shardCount, q)
+	case SearchShardHeight:
+		parts := shardHeightPattern.FindStringSubmatch(q)
+		if shard, _ := strconv.Atoi(parts[1]); shard >= 1 && shard <= shardCount {
+			hits, err = searchHeight(db, shard, shard, parts[2])
+		}
+	case SearchHash:
+		hits, err = searchHash(db, q)
+	case SearchAddress:
+		hits, err = searchAddresses(db, []string{q})
+	default:
+		hits, err = searchLabel(db, q)
+	}
+	return match, hits, err
+}
+
+func searchHeight(db BlockInfoDB, fromShard int, toShard int, value string) ([]*searchHit, error) {
+	height, err := strconv.ParseUint(value, 10, 64)
+	if err != nil {
+		return nil, nil
+	}
+	var hits []*searchHit
+	for shard := fromShard; shard <= toShard; shard++ {
+		block, err := db.GetBlockByHeight(shard, height)
+		if err == mgo.ErrNotFound {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		hits = append(hits, &searchHit{block: block})
+	}
+	return hits, nil
+}
+
+func searchHash(db BlockInfoDB, hash string) ([]*searchHit, error) {
+	var hits []*searchHit
+	block, err := db.GetBlockByHash(hash)
+	if err == nil {
+		hits = append(hits, &searchHit{block: block})
+	} else if err != mgo.ErrNotFound {
+		return nil, err
+	}
+
+	tx, err := db.GetTxByHash(hash)
+	pending := false
+	if err == mgo.ErrNotFound {
+		tx, err = db.GetPendingTxByHash(hash)
+		pending = true
+	}
+	if err == nil {
+		hits = append(hits, &searchHit{tx: tx, pending: pending})
+	} else if err != mgo.ErrNotFound {
+		return nil, err
+	}
+	return hits, nil
+}
+
+// searchAddresses find the indexed accounts of the addresses with their labels, in the order of the addresses
+func searchAddresses(db BlockInfoDB, addresses []string) ([]*searchHit, error) {
+	accounts, err := db.GetAccountsByAddresses(addresses)
+	if err != nil || len(accounts) == 0 {
+		return nil, err
+	}
+	labels, err := db.GetLabelsByAddresses(addresses)
+	if err != nil {
+		return nil, err
+	}
+
+	byAddress := make(map[string]*searchHit)
+	for _, account := range accounts {
+		byAddress[account.Address] = &searchHit{account: account}
+	}
+	for _, label := range labels {
+		if hit := byAddress[label.Address]; hit != nil {
+			hit.labels = append(hit.labels, label)
+		}
+	}
+	var hits []*searchHit
+	for _, address := range addresses {
+		if hit := byAddress[address]; hit != nil {
+			hits = append(hits, hit)
+			delete(byAddress, address)
+		}
+	}
+	return hits, nil
+}
+
+// searchLabel find the accounts labeled by the name, such as a token symbol
+func searchLabel(db BlockInfoDB, name string) ([]*searchHit, error) {
+	labels, err := db.GetLabelsByName(name, searchLabelLimit)
+	if err != nil {
+		return nil, err
+	}
+	var addresses []string
+	for _, label := range labels {
+		addresses = append(addresses, label.Address)
+	}
+	if len(addresses) == 0 {
+		return nil, nil
+	}
+	return searchAddresses(db, addresses)
+}
+
+// suggestSearch complete the prefix of an address, or of the name of a label
+func suggestSearch(db BlockInfoDB, prefix string, limit int) ([]*RetSearchSuggestion, error) {
+	suggestions := make([]*RetSearchSuggestion, 0)
+	if lower := strings.ToLower(prefix); strings.HasPrefix(lower, "0x") && len(lower) <= addressLength && hexPattern.MatchString(lower) {
+		accounts, err := db.GetAccountsByAddressPrefix(lower, limit)
+		if err != nil {
+			return nil, err
+		}
+		for _, account := range accounts {
+			kind := accTypeStr
+			if account.AccType == 1 {
+				kind = contractTypeStr
+			}
+			suggestions = append(suggestions, &RetSearchSuggestion{Type: suggestAddressType, Kind: kind, Text: account.Address, Address: account.Address})
+		}
+		return suggestions, nil
+	}
+
+	labels, err := db.GetLabelsByPrefix(prefix, limit)
+	if err != nil {
+		return nil, err
+	}
+	for _, label := range labels {
+		suggestions = append(suggestions, &RetSearchSuggestion{Type: suggestLabelType, Kind: label.Kind, Text: label.Name, Address: label.Address})
+	}
+	return suggestions, nil
+}
+
+// parseSuggest parse the prefix and the limit of a suggestion
+func parseSuggest(c *gin.Context) (string, int, *V2Error) {
+	prefix := strings.TrimSpace(c.Query("q"))
+	if len(prefix) < suggestMinLength {
+		return "", 0, v2InvalidParam("q", "q must have "+strconv.Itoa(suggestMinLength)+" characters at least")
+	}
+	limit := DefaultSuggestLimit
+	if value, ok := c.GetQuery("limit"); ok {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 || n > MaxSuggestLimit {
+			return "", 0, v2InvalidParam("limit", "limit must be between 1 and "+strconv.Itoa(MaxSuggestLimit))
+		}
+		limit = n
+	}
+	return prefix, limit, nil
+}
+
+// contractName return the name of the last contract declared in the source code
+func contractName(sourceCode string) string {
+	matches := contractNamePattern.FindAllStringSubmatch(sourceCode, -1)
+	if len(matches) == 0 {
+		return ""
+	}
+	return matches[len(matches)-1][1]
+}
+
+// labelVerifiedContract label a verified contract with its name, so that it is searched by the name
+func labelVerifiedContract(db BlockInfoDB, address string, sourceCode string) {
+	name := contractName(sourceCode)
+	if name == "" {
+		return
+	}
+	if err := db.SetLabel(&database.DBLabel{Address: address, Kind: database.LabelContract, Name: name}); err != nil {
+		log.Error("[search] label the contract %s: %v", address, err)
+	}
+}
@@ -0,0 +1,134 @@
+/**
+*  @file
+*  @copyright defined in scan-api/LICENSE
+ */
+
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/seeleteam/scan-api/database"
+	"github.com/seeleteam/scan-api/log"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ClassifySearch(t *testing.T) {
+	for q, match := range map[string]string{
+		"12":                SearchHeight,
+		"2:12":              SearchShardHeight,
+		v2TestTxHash:        SearchHash,
+		v2TestAddress:       SearchAddress,
+		"0x0a":              SearchLabel,
+		"SEE":               SearchLabel,
+		"2:":                SearchLabel,
+		v2TestAddress + "0": SearchLabel,
+	} {
+		assert.Equal(t, classifySearch(q), match, q)
+	}
+
+	assert.Equal(t, contractName("pragma solidity ^0.4.0;\ncontract Base {}\ncontract SeeleToken is Base {}"), "SeeleToken")
+	assert.Equal(t, contractName("library Math {}"), "")
+}
+
+func Test_V2Search(t *testing.T) {
+	e := newV2TestRouter(t)
+
+	search := func(q string) (int, *V2SearchResult) {
+		status, envelope := getV2(e, "/api/v2/search?q="+url.QueryEscape(q))
+		var result V2SearchResult
+		json.Unmarshal(envelope.Data, &result)
+		return status, &result
+	}
+
+	// a height is a block of every shard
+	status, result := search("1")
+	assert.Equal(t, status, http.StatusOK)
+	assert.Equal(t, result.Match, SearchHeight)
+	assert.Equal(t, result.Block.Height, uint64(1))
+	assert.Equal(t, result.Hits, []*RetSearchHit{{Type: blockTypestr, ShardNumber: 1, Height: 1, Hash: "0x0b02"}})
+	_, result = search("1:0")
+	assert.Equal(t, result.Block.HeadHash, v2TestBlockHash)
+	status, _ = search("2:0")
+	assert.Equal(t, status, http.StatusNotFound)
+
+	// the hex inputs are not case sensitive
+	status, _ = search(strings.ToUpper(v2TestBlockHash[2:]))
+	assert.Equal(t, status, http.StatusNotFound)
+	_, result = search("0X" + strings.ToUpper(v2TestBlockHash[2:]))
+	assert.Equal(t, result.Match, SearchHash)
+	assert.Equal(t, result.Type, blockTypestr)
+
+	// a verified contract is found by its name, and so is a token by its symbol
+	form := url.Values{"sourceCode": {"contract Base {}\ncontract SeeleToken is Base {}"}, "abi": {"[]"}}
+	req := httptest.NewRequest("POST", "/api/v2/contracts/"+v2TestContract+"/verify", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	status, _ = serveV2(e, req)
+	assert.Equal(t, status, http.StatusOK)
+	status, result = search("seeletoken")
+	assert.Equal(t, status, http.StatusOK)
+	assert.Equal(t, result.Match, SearchLabel)
+	assert.Equal(t, result.Type, contractTypeStr)
+	assert.Equal(t, result.Account.Address, v2TestContract)
+	assert.Equal(t, result.Labels, []*RetLabel{{Kind: "contract", Name: "SeeleToken"}})
+	status, _ = search("Base")
+	assert.Equal(t, status, http.StatusNotFound)
+
+	var suggestions []*RetSearchSuggestion
+	_, envelope := getV2(e, "/api/v2/search/suggest?q=Seele")
+	assert.Nil(t, json.Unmarshal(envelope.Data, &suggestions))
+	assert.Equal(t, suggestions, []*RetSearchSuggestion{{Type: "label", Kind: "contract", Text: "SeeleToken", Address: v2TestContract}})
+	_, envelope = getV2(e, "/api/v2/search/suggest?limit=1&q=0x00")
+	assert.Nil(t, json.Unmarshal(envelope.Data, &suggestions))
+	assert.Equal(t, suggestions, []*RetSearchSuggestion{{Type: "address", Kind: accTypeStr, Text: v2TestAddress, Address: v2TestAddress}})
+	_, envelope = getV2(e, "/api/v2/search/suggest?q=0x11")
+	assert.Equal(t, string(envelope.Data), "[]")
+
+	for _, query := range []string{"q=s", "q=se&limit=0", "q=se&limit=21"} {
+		status, _ = getV2(e, "/api/v2/search/suggest?"+query)
+		assert.Equal(t, status, http.StatusBadRequest, query)
+	}
+}
+
+func Test_Search(t *testing.T) {
+	log.NewLogger("", "error", false)
+	db := database.NewMemoryClient(1)
+	for shard := 1; shard <= 2; shard++ {
+		assert.Nil(t, db.AddBlock(&database.DBBlock{HeadHash: "0x0b0" + strconv.Itoa(shard), Height: 7, ShardNumber: shard}))
+	}
+	gin.SetMode(gin.TestMode)
+	e := gin.New()
+	e.GET("/search", (&BlockHandler{DBClient: db}).Search(NewAccHandler(db), NewContractHandler(db, nil)))
+
+	// an ambiguous height has a hit in every shard, the first is detailed
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, httptest.NewRequest("GET", "/search?content=7", nil))
+	var body struct {
+		Code int `json:"code"`
+		Data struct {
+			Type  string             `json:"type"`
+			Info  RetDetailBlockInfo `json:"info"`
+			Match string             `json:"match"`
+			Hits  []*RetSearchHit    `json:"hits"`
+		} `json:"data"`
+	}
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &body))
+	assert.Equal(t, body.Code, apiOk)
+	assert.Equal(t, body.Data.Type, blockTypestr)
+	assert.Equal(t, body.Data.Info.HeadHash, "0x0b01")
+	assert.Equal(t, body.Data.Match, SearchHeight)
+	assert.Equal(t, body.Data.Hits, []*RetSearchHit{{Type: blockTypestr, ShardNumber: 1, Height: 7, Hash: "0x0b01"},
+		{Type: blockTypestr, ShardNumber: 2, Height: 7, Hash: "0x0b02"}})
+
+	w = httptest.NewRecorder()
+	e.ServeHTTP(w, httptest.NewRequest("GET", "/search?content=8", nil))
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &body))
+	assert.Equal(t, body.Code, apiDBQueryError)
+}
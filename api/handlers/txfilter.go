@@ -0,0 +1,119 @@
+/**
+*  @file
+*  @copyright defined in scan-api/LICENSE
+ */
+
+package handlers
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/seeleteam/scan-api/database"
+)
+
+// txFilterParams are the parameters of the transaction filter
+var txFilterParams = []string{"from", "to", "contract", "minamount", "maxamount", "minfee", "maxfee",
+	"startblock", "endblock", "starttime", "endtime", "type", "failed"}
+
+// hasTxFilter check whether the request filters the transactions
+func hasTxFilter(c *gin.Context) bool {
+	for _, param := range txFilterParams {
+		if _, ok := c.GetQuery(param); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// queryInt64 parse an optional number parameter, nil if it is missing
+func queryInt64(c *gin.Context, param string) (*int64, *V2Error) {
+	value, ok := c.GetQuery(param)
+	if !ok {
+		return nil, nil
+	}
+	n, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || n < 0 {
+		return nil, v2InvalidParam(param, param+" must be a number not less than 0")
+	}
+	return &n, nil
+}
+
+// queryRange parse the optional bounds of a range, the minimum is not greater than the maximum
+func queryRange(c *gin.Context, minParam, maxParam string) (*int64, *int64, *V2Error) {
+	min, verr := queryInt64(c, minParam)
+	if verr != nil {
+		return nil, nil, verr
+	}
+	max, verr := queryInt64(c, maxParam)
+	if verr != nil {
+		return nil, nil, verr
+	}
+	if min != nil && max != nil && *min > *max {
+		return nil, nil, v2InvalidParam(maxParam, maxParam+" must not be less than "+minParam)
+	}
+	return min, max, nil
+}
+
+// parseTxFilter parse the filter of a transaction listing of the shard, the addresses are checked,
+// and so are the kind and the ranges
+func parseTxFilter(c *gin.Context, shard int) (*database.TxFilter, *V2Error) {
+	filter := &database.TxFilter{
+		ShardNumber: shard,
+		From:        c.Query("from"),
+		To:          c.Query("to"),
+		Contract:    c.Query("contract"),
+		Kind:        c.Query("type"),
+	}
+	for _, param := range []string{"from", "to", "contract"} {
+		if address := c.Query(param); address != "" {
+			if verr := v2Address(param, address); verr != nil {
+				return nil, verr
+			}
+		}
+	}
+	switch filter.Kind {
+	case "", database.TxKindTransfer, database.TxKindCreate, database.TxKindCall:
+	default:
+		return nil, v2InvalidParam("type", "type must be transfer, create or call")
+	}
+	if value, ok := c.GetQuery("failed"); ok {
+		failed, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, v2InvalidParam("failed", "failed must be true or false")
+		}
+		filter.Failed = &failed
+	}
+
+	var verr *V2Error
+	if filter.MinAmount, filter.MaxAmount, verr = queryRange(c, "minamount", "maxamount"); verr != nil {
+		return nil, verr
+	}
+	if filter.MinFee, filter.MaxFee, verr = queryRange(c, "minfee", "maxfee"); verr != nil {
+		return nil, verr
+	}
+	if filter.StartTime, filter.EndTime, verr = queryRange(c, "starttime", "endtime"); verr != nil {
+		return nil, verr
+	}
+	startBlock, endBlock, verr := queryRange(c, "startblock", "endblock")
+	if verr != nil {
+		return nil, verr
+	}
+	if startBlock != nil {
+		start := uint64(*startBlock)
+		filter.StartBlock = &start
+	}
+	if endBlock != nil {
+		end := uint64(*endBlock)
+		filter.EndBlock = &end
+	}
+	return filter, nil
+}
+
+// txFilterError convert an error of a filtered listing, a filter which would scan a shard is an invalid parameter
+func txFilterError(err error) *V2Error {
+	if err == database.ErrUnboundedTxFilter {
+		return v2InvalidParam("endblock", err.Error())
+	}
+	return v2DBError(err, "transactions")
+}
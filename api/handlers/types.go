@@ -8,8 +8,10 @@ package handlers
 import (
 	"fmt"
 	"math/big"
+	"strconv"
 	"time"
 
+	"github.com/seeleteam/scan-api/abi"
 	"github.com/seeleteam/scan-api/database"
 	"github.com/seeleteam/scan-api/rpc"
 )
@@ -29,7 +31,11 @@ type RetSimpleBlockInfo struct {
 	Age         string `json:"age"`
 	Txn         int    `json:"txn"`
 	Miner       string `json:"miner"`
+	Pool        string `json:"pool"`
 	Reward      int64  `json:"reward"`
+	TxFee       int64  `json:"txFee"`
+	DebtFee     int64  `json:"debtFee"`
+	Revenue     int64  `json:"revenue"`
 	Fee         int64  `json:"fee"`
 	UsedGas     int64  `json:"usedGas"`
 	Gasprice    int64  `json:"gasprice"`
@@ -44,10 +50,25 @@ type RetDetailBlockInfo struct {
 	Age         string   `json:"age"`
 	Difficulty  *big.Int `json:"difficulty"`
 	Miner       string   `json:"miner"`
+	Pool        string   `json:"pool"`
 	Nonce       string   `json:"nonce"`
 	TxCount     int      `json:"txcount"`
 	DebtCount   int      `json:"debtCount"`
 
+	Timestamp       int64  `json:"timestamp"`
+	TotalDifficulty string `json:"totalDifficulty"`
+	StateHash       string `json:"stateHash"`
+	TxHash          string `json:"txHash"`
+	ReceiptHash     string `json:"receiptHash"`
+	TxDebtHash      string `json:"txDebtHash"`
+	DebtHash        string `json:"debtHash"`
+	ExtraData       string `json:"extraData"`
+
+	Reward  int64 `json:"reward"`
+	TxFee   int64 `json:"txFee"`
+	DebtFee int64 `json:"debtFee"`
+	Revenue int64 `json:"revenue"`
+
 	MaxHeight uint64 `json:"maxheight"`
 	MinHeight uint64 `json:"minheight"`
 }
@@ -148,6 +169,7 @@ type RetDetailAccountTxInfo struct {
 	Fee         int64  `json:"fee"`
 	InOrOut     bool   `json:"inorout"`
 	Pending     bool   `json:"pending"`
+	Kind        string `json:"kind,omitempty"`
 	Timestamp   string 	`json:"timestamp"`
 }
 
@@ -163,6 +185,30 @@ type RetDetailAccountInfo struct {
 	Txs                  []RetDetailAccountTxInfo `json:"txs"`
 	SourceCode  		 string 				  `json:"sourceCode"`
 	ABI					 string 				  `bson:"abi"`
+	Creator              string                   `json:"creator"`
+	CreationTx           string                   `json:"creationTx"`
+	CreationBlock        uint64                   `json:"creationBlock"`
+	CodeHash             string                   `json:"codeHash"`
+}
+
+//RetSimpleContractInfo describle the contract info in the creator and similar contract lists which send to the frontend
+type RetSimpleContractInfo struct {
+	ShardNumber   int    `json:"shardnumber"`
+	Address       string `json:"address"`
+	Balance       int64  `json:"balance"`
+	TxCount       int64  `json:"txcount"`
+	Verified      bool   `json:"verified"`
+	Creator       string `json:"creator"`
+	CreationTx    string `json:"creationTx"`
+	CreationBlock uint64 `json:"creationBlock"`
+	CodeHash      string `json:"codeHash"`
+}
+
+//RetCodeHashGroup describle the contracts deployed with the same runtime bytecode
+type RetCodeHashGroup struct {
+	CodeHash  string   `json:"codeHash"`
+	Count     int64    `json:"count"`
+	Addresses []string `json:"addresses"`
 }
 
 //createRetLastblockInfo converts the given dbblock to the Lastblock
@@ -187,12 +233,16 @@ func createRetSimpleBlockInfo(blockInfo *database.DBBlock) *RetSimpleBlockInfo {
 	var ret RetSimpleBlockInfo
 	var blockFee, gasprice int64
 	ret.Miner = blockInfo.Creator
+	ret.Pool = blockInfo.Pool
 	ret.Height = uint64(blockInfo.Height)
 	ret.Txn = len(blockInfo.Txs)
 	timeStamp := big.NewInt(blockInfo.Timestamp)
 	ret.Age = getElpasedTimeDesc(timeStamp)
 	ret.ShardNumber = blockInfo.ShardNumber
 	ret.Reward = blockInfo.Reward
+	ret.TxFee = blockInfo.TxFee
+	ret.DebtFee = blockInfo.DebtFee
+	ret.Revenue = blockInfo.Revenue()
 	txscnt := len(blockInfo.Txs)
 	for i := 0; i < txscnt; i++ {
 		blockFee += blockInfo.Txs[i].Fee
@@ -226,7 +276,20 @@ func createRetDetailBlockInfo(blockInfo *database.DBBlock, maxHeight, minHeight
 
 	ret.Miner = blockInfo.Creator
 
+	ret.Pool = blockInfo.Pool
 	ret.Nonce = blockInfo.Nonce
+	ret.Timestamp = blockInfo.Timestamp
+	ret.TotalDifficulty = blockInfo.TotalDifficulty
+	ret.StateHash = blockInfo.StateHash
+	ret.TxHash = blockInfo.TxHash
+	ret.ReceiptHash = blockInfo.ReceiptHash
+	ret.TxDebtHash = blockInfo.TxDebtHash
+	ret.DebtHash = blockInfo.DebtHash
+	ret.ExtraData = blockInfo.ExtraData
+	ret.Reward = blockInfo.Reward
+	ret.TxFee = blockInfo.TxFee
+	ret.DebtFee = blockInfo.DebtFee
+	ret.Revenue = blockInfo.Revenue()
 	ret.TxCount = len(blockInfo.Txs)
 	ret.DebtCount = len(blockInfo.Debts)
 	ret.MaxHeight = maxHeight
@@ -250,11 +313,8 @@ func createRetSimpleTxInfo(transaction *database.DBTx) *RetSimpleTxInfo {
 	ret.UsedGas = transaction.UsedGas
 	ret.Gasprice = transaction.GasPrice
 	ret.Nonce = transaction.AccountNonce
-	ret.Timestamp = transaction.Timestamp
-	timeStamp := big.NewInt(0)
-	if timeStamp.UnmarshalText([]byte(transaction.Timestamp)) == nil {
-		ret.Age = getElpasedTimeDesc(timeStamp)
-	}
+	ret.Timestamp = strconv.FormatInt(transaction.Timestamp, 10)
+	ret.Age = getElpasedTimeDesc(big.NewInt(transaction.Timestamp))
 	ret.ShardNumber = transaction.ShardNumber
 	ret.Receipt = transaction.Receipt
 	return &ret
@@ -286,11 +346,8 @@ func createRetDetailTxInfo(transaction *database.DBTx) *RetDetailTxInfo {
 	ret.Value = transaction.Amount
 	ret.Pending = transaction.Pending
 	ret.Fee = transaction.Fee
-	ret.Timestamp = transaction.Timestamp
-	timeStamp := big.NewInt(0)
-	if timeStamp.UnmarshalText([]byte(transaction.Timestamp)) == nil {
-		ret.Age = getElpasedTimeDesc(timeStamp)
-	}
+	ret.Timestamp = strconv.FormatInt(transaction.Timestamp, 10)
+	ret.Age = getElpasedTimeDesc(big.NewInt(transaction.Timestamp))
 	ret.ShardNumber = transaction.ShardNumber
 	ret.AccountNonce = transaction.AccountNonce
 	ret.Payload = transaction.Payload
@@ -333,6 +390,30 @@ func createHomeRetSimpleAccountInfo(account *database.DBAccount) *RetSimpleAccou
 	return &ret
 }
 
+//createRetSimpleContractInfo converts the given contract dbaccount to the RetSimpleContractInfo
+func createRetSimpleContractInfo(account *database.DBAccount) *RetSimpleContractInfo {
+	return &RetSimpleContractInfo{
+		ShardNumber:   account.ShardNumber,
+		Address:       account.Address,
+		Balance:       account.Balance,
+		TxCount:       account.TxCount,
+		Verified:      account.ABI != "",
+		Creator:       account.Creator,
+		CreationTx:    account.CreationTx,
+		CreationBlock: account.CreationBlock,
+		CodeHash:      account.CodeHash,
+	}
+}
+
+//createRetCodeHashGroup converts the given DBCodeHashGroup to the RetCodeHashGroup
+func createRetCodeHashGroup(group *database.DBCodeHashGroup) *RetCodeHashGroup {
+	return &RetCodeHashGroup{
+		CodeHash:  group.CodeHash,
+		Count:     group.Count,
+		Addresses: group.Addresses,
+	}
+}
+
 //createRetDetailAccountInfo converts the given dbaccount to the tetdetailaccountInfo
 func createRetDetailAccountInfo(account *database.DBAccount, txs []*database.DBTx, ttBalance int64) *RetDetailAccountInfo {
 	var ret RetDetailAccountInfo
@@ -344,6 +425,10 @@ func createRetDetailAccountInfo(account *database.DBAccount, txs []*database.DBT
 	if account.AccType == 1 {
 		ret.SourceCode = account.SourceCode
 		ret.ABI = account.ABI
+		ret.Creator = account.Creator
+		ret.CreationTx = account.CreationTx
+		ret.CreationBlock = account.CreationBlock
+		ret.CodeHash = account.CodeHash
 	}
 
 	for i := 0; i < len(txs); i++ {
@@ -360,10 +445,7 @@ func createRetDetailAccountInfo(account *database.DBAccount, txs []*database.DBT
 		} else {
 			tx.InOrOut = true
 		}
-		timeStamp := big.NewInt(0)
-		if timeStamp.UnmarshalText([]byte(txs[i].Timestamp)) == nil {
-			tx.Age = getElpasedTimeDesc(timeStamp)
-		}
+		tx.Age = getElpasedTimeDesc(big.NewInt(txs[i].Timestamp))
 
 		tx.Fee = txs[i].Fee
 		tx.Pending = txs[i].Pending
@@ -378,6 +460,33 @@ func createRetDetailAccountInfo(account *database.DBAccount, txs []*database.DBT
 	return &ret
 }
 
+//createRetAccountActivityInfos converts the given activities of an address to the RetDetailAccountTxInfo list,
+//the counterparty is the sender of the activities into the address
+func createRetAccountActivityInfos(activities []*database.DBAddressActivity) []*RetDetailAccountTxInfo {
+	var retTxs []*RetDetailAccountTxInfo
+	for _, activity := range activities {
+		tx := &RetDetailAccountTxInfo{
+			ShardNumber: activity.ShardNumber,
+			TxType:      activity.TxType,
+			Hash:        activity.Hash,
+			Block:       activity.Block,
+			From:        activity.Counterparty,
+			To:          activity.Address,
+			Value:       activity.Amount,
+			Age:         getElpasedTimeDesc(big.NewInt(activity.Timestamp)),
+			Fee:         activity.Fee,
+			InOrOut:     activity.In(),
+			Kind:        activity.Kind,
+			Timestamp:   strconv.FormatInt(activity.Timestamp, 10),
+		}
+		if !activity.In() {
+			tx.From, tx.To = activity.Address, activity.Counterparty
+		}
+		retTxs = append(retTxs, tx)
+	}
+	return retTxs
+}
+
 //getElpasedTimeDesc Get the elapsed time from then until now
 func getElpasedTimeDesc(t *big.Int) string {
 	curTimeStamp := time.Now().Unix()
@@ -421,3 +530,152 @@ type RetOneDayTxInfo struct {
 	TodayIncrease int64
 	TimeStamp     int64
 }
+
+//RetContractMethod describle a view method of the contract which could be read by the frontend
+type RetContractMethod struct {
+	Name      string         `json:"name"`
+	Signature string         `json:"signature"`
+	Inputs    []abi.Argument `json:"inputs"`
+	Outputs   []abi.Argument `json:"outputs"`
+}
+
+//RetContractOutput describle a decoded output value of a contract call
+type RetContractOutput struct {
+	Name  string      `json:"name"`
+	Type  string      `json:"type"`
+	Value interface{} `json:"value"`
+}
+
+//RetContractReadResult describle the result of a read-only contract call which send to the frontend
+type RetContractReadResult struct {
+	Address   string              `json:"address"`
+	Method    string              `json:"method"`
+	Signature string              `json:"signature"`
+	Height    int64               `json:"height"`
+	Failed    bool                `json:"failed"`
+	Result    string              `json:"result"`
+	UsedGas   int64               `json:"usedGas"`
+	Outputs   []RetContractOutput `json:"outputs"`
+}
+
+//createRetContractMethod converts the given abi method to the RetContractMethod
+func createRetContractMethod(method *abi.Method) *RetContractMethod {
+	return &RetContractMethod{
+		Name:      method.Name,
+		Signature: method.Signature(),
+		Inputs:    method.Inputs,
+		Outputs:   method.Outputs,
+	}
+}
+
+//createRetContractReadResult converts the call receipt and decoded values to the RetContractReadResult
+func createRetContractReadResult(address string, method *abi.Method, height int64, receipt *rpc.Receipt, values []interface{}) *RetContractReadResult {
+	ret := &RetContractReadResult{
+		Address:   address,
+		Method:    method.Name,
+		Signature: method.Signature(),
+		Height:    height,
+		Failed:    receipt.Failed,
+		Result:    receipt.Result,
+		UsedGas:   receipt.UsedGas,
+	}
+
+	for i, v := range values {
+		if i >= len(method.Outputs) {
+			break
+		}
+		ret.Outputs = append(ret.Outputs, RetContractOutput{
+			Name:  method.Outputs[i].Name,
+			Type:  method.Outputs[i].Type,
+			Value: v,
+		})
+	}
+	return ret
+}
+
+//RetShardSupply describle the supply of an shard
+type RetShardSupply struct {
+	ShardNumber int   `json:"shardnumber"`
+	Genesis     int64 `json:"genesis"`
+	Minted      int64 `json:"minted"`
+	Total       int64 `json:"total"`
+	MintedToday int64 `json:"mintedToday"`
+	FeesPaid    int64 `json:"feesPaid"`
+	FeesToday   int64 `json:"feesToday"`
+	Balance     int64 `json:"balance"`
+	Difference  int64 `json:"difference"`
+}
+
+//RetSupplyInfo describle the total supply and the supply of every shard which send to the frontend,
+//balance is the sum of the indexed account balances and difference is the part of total not covered by it
+type RetSupplyInfo struct {
+	Genesis     int64            `json:"genesis"`
+	Minted      int64            `json:"minted"`
+	Total       int64            `json:"total"`
+	MintedToday int64            `json:"mintedToday"`
+	FeesPaid    int64            `json:"feesPaid"`
+	FeesToday   int64            `json:"feesToday"`
+	Balance     int64            `json:"balance"`
+	Difference  int64            `json:"difference"`
+	Shards      []RetShardSupply `json:"shards"`
+}
+
+//RetOneDaySupply describle the supply at the end of an single day
+type RetOneDaySupply struct {
+	TimeStamp int64 `json:"timestamp"`
+	Blocks    int64 `json:"blocks"`
+	Minted    int64 `json:"minted"`
+	Fees      int64 `json:"fees"`
+	Total     int64 `json:"total"`
+}
+
+//createRetSupplyInfo sum up the supply of the shards and reconcile it with the balances
+func createRetSupplyInfo(supplies []*database.DBSupply, today map[int]*database.DBOneDaySupply, balances map[int]int64) *RetSupplyInfo {
+	ret := &RetSupplyInfo{Shards: []RetShardSupply{}}
+	for _, supply := range supplies {
+		shard := RetShardSupply{
+			ShardNumber: supply.ShardNumber,
+			Genesis:     supply.Genesis,
+			Minted:      supply.Minted,
+			Total:       supply.Total(),
+			FeesPaid:    supply.Fees,
+			Balance:     balances[supply.ShardNumber],
+		}
+		if oneDaySupply, ok := today[supply.ShardNumber]; ok {
+			shard.MintedToday = oneDaySupply.Minted
+			shard.FeesToday = oneDaySupply.Fees
+		}
+		shard.Difference = shard.Total - shard.Balance
+
+		ret.Genesis += shard.Genesis
+		ret.Minted += shard.Minted
+		ret.Total += shard.Total
+		ret.MintedToday += shard.MintedToday
+		ret.FeesPaid += shard.FeesPaid
+		ret.FeesToday += shard.FeesToday
+		ret.Balance += shard.Balance
+		ret.Shards = append(ret.Shards, shard)
+	}
+	ret.Difference = ret.Total - ret.Balance
+	return ret
+}
+
+//createRetSupplyChart add up the supply rows of the same day which are sorted by timestamp,
+//the total supply of the day is accumulated from the genesis supply
+func createRetSupplyChart(genesis int64, oneDaySupplies []*database.DBOneDaySupply) []*RetOneDaySupply {
+	ret := []*RetOneDaySupply{}
+	total := genesis
+	for _, oneDaySupply := range oneDaySupplies {
+		total += oneDaySupply.Minted
+		if len(ret) == 0 || ret[len(ret)-1].TimeStamp != oneDaySupply.TimeStamp {
+			ret = append(ret, &RetOneDaySupply{TimeStamp: oneDaySupply.TimeStamp})
+		}
+
+		day := ret[len(ret)-1]
+		day.Blocks += oneDaySupply.Blocks
+		day.Minted += oneDaySupply.Minted
+		day.Fees += oneDaySupply.Fees
+		day.Total = total
+	}
+	return ret
+}
@@ -37,6 +37,32 @@ func Test_CreateRetSimpleBlockInfo(t *testing.T) {
 	assert.Equal(t, got.Reward, header.Reward)
 }
 
+func Test_CreateRetDetailBlockInfo(t *testing.T) {
+	block := newTestDBBlock(t)
+	block.Pool = "PoolX"
+	block.ReceiptHash = "0x02fa1d68e7bbf0b833f6e8719efb11b32c7f760e4ae050a4f9b58b8dd8ad1620"
+	block.TxDebtHash = "0x58d7c36b25a715f5076ccb878940920f6bb333ab142287452509f881103960d2"
+	block.DebtHash = "0x0000000000000000000000000000000000000000000000000000000000000000"
+	block.ExtraData = "cG9vbHgvMS4w"
+	block.Nonce = "17825487295277268182"
+	block.Reward = 150000000
+	block.TxFee = 31000
+	block.DebtFee = 20000
+	got := createRetDetailBlockInfo(block, 133860, 0)
+
+	assert.Equal(t, got.Pool, block.Pool)
+	assert.Equal(t, got.ReceiptHash, block.ReceiptHash)
+	assert.Equal(t, got.TxDebtHash, block.TxDebtHash)
+	assert.Equal(t, got.DebtHash, block.DebtHash)
+	assert.Equal(t, got.ExtraData, block.ExtraData)
+	assert.Equal(t, got.Nonce, block.Nonce)
+	assert.Equal(t, got.MaxHeight, uint64(133860))
+	assert.Equal(t, got.Reward, int64(150000000))
+	assert.Equal(t, got.TxFee, int64(31000))
+	assert.Equal(t, got.DebtFee, int64(20000))
+	assert.Equal(t, got.Revenue, int64(150051000))
+}
+
 func Test_CreateRetLastblockInfo(t *testing.T) {
 	var lastblockHeight, lastblockTime int64
 	lastblockHeight = 10399
@@ -47,6 +73,29 @@ func Test_CreateRetLastblockInfo(t *testing.T) {
 	assert.Equal(t, got.LastblockTime, lastblockTime)
 }
 
+func Test_CreateRetSimpleContractInfo(t *testing.T) {
+	account := &database.DBAccount{
+		AccType:       1,
+		ShardNumber:   1,
+		Address:       "0x0a57a2714e193b7ac50475ce625f2dcfb483d742",
+		Creator:       "0x4c10f2cd2159bb432094e3be7e17904c2b4aeb21",
+		CreationTx:    "0x02c240f019adc8b267b82026aef6b677c67867624e2acc1418149e7f8083ba0e",
+		CreationBlock: 5567,
+		CodeHash:      "0x95645120bcdc5f07dc3b8f30f0f3d4069d3374cf0167575f8be474d6c3ad7038",
+	}
+	got := createRetSimpleContractInfo(account)
+
+	assert.Equal(t, got.Address, account.Address)
+	assert.Equal(t, got.Creator, account.Creator)
+	assert.Equal(t, got.CreationTx, account.CreationTx)
+	assert.Equal(t, got.CreationBlock, account.CreationBlock)
+	assert.Equal(t, got.CodeHash, account.CodeHash)
+	assert.Equal(t, got.Verified, false)
+
+	account.ABI = "[]"
+	assert.Equal(t, createRetSimpleContractInfo(account).Verified, true)
+}
+
 func Benchmark_isOneBitCharacter(b *testing.B) {
 	tests := struct {
 		lastblockHeight int64
@@ -60,3 +109,43 @@ func Benchmark_isOneBitCharacter(b *testing.B) {
 	}
 
 }
+
+func Test_CreateRetSupplyInfo(t *testing.T) {
+	supplies := []*database.DBSupply{
+		{ShardNumber: 1, Genesis: 1000000000, Minted: 450000000, Fees: 63000, Blocks: 3},
+		{ShardNumber: 2, Genesis: 2000000000, Minted: 150000000, Fees: 21000, Blocks: 1},
+	}
+	today := map[int]*database.DBOneDaySupply{
+		1: {ShardNumber: 1, Minted: 150000000, Fees: 21000, Blocks: 1},
+	}
+	balances := map[int]int64{1: 1449937000, 2: 2150000000}
+	got := createRetSupplyInfo(supplies, today, balances)
+
+	assert.Equal(t, len(got.Shards), 2)
+	assert.Equal(t, got.Shards[0].Total, int64(1450000000))
+	assert.Equal(t, got.Shards[0].MintedToday, int64(150000000))
+	assert.Equal(t, got.Shards[0].Difference, int64(63000))
+	assert.Equal(t, got.Shards[1].MintedToday, int64(0))
+	assert.Equal(t, got.Shards[1].Difference, int64(0))
+	assert.Equal(t, got.Total, int64(3600000000))
+	assert.Equal(t, got.MintedToday, int64(150000000))
+	assert.Equal(t, got.FeesPaid, int64(84000))
+	assert.Equal(t, got.Difference, int64(63000))
+}
+
+func Test_CreateRetSupplyChart(t *testing.T) {
+	oneDaySupplies := []*database.DBOneDaySupply{
+		{ShardNumber: 1, TimeStamp: 1539878400, Minted: 300000000, Fees: 42000, Blocks: 2},
+		{ShardNumber: 2, TimeStamp: 1539878400, Minted: 150000000, Fees: 21000, Blocks: 1},
+		{ShardNumber: 1, TimeStamp: 1539964800, Minted: 150000000, Fees: 21000, Blocks: 1},
+	}
+	got := createRetSupplyChart(3000000000, oneDaySupplies)
+
+	assert.Equal(t, len(got), 2)
+	assert.Equal(t, got[0].Blocks, int64(3))
+	assert.Equal(t, got[0].Minted, int64(450000000))
+	assert.Equal(t, got[0].Total, int64(3450000000))
+	assert.Equal(t, got[1].Fees, int64(21000))
+	assert.Equal(t, got[1].Total, int64(3600000000))
+	assert.Equal(t, len(createRetSupplyChart(0, nil)), 0)
+}
@@ -0,0 +1,505 @@
+/**
+*  @file
+*  @copyright defined in scan-api/LICENSE
+ */
+
+package handlers
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/seeleteam/scan-api/abi"
+	"github.com/seeleteam/scan-api/database"
+	"github.com/seeleteam/scan-api/log"
+	"gopkg.in/mgo.v2"
+)
+
+// V2Handler handle the requests of the v2 api, the responses are typed and wrapped in V2Envelope
+type V2Handler struct {
+	DBClient  BlockInfoDB
+	accounts  *AccountHandler
+	contracts *ContractHandler
+}
+
+// NewV2Handler return a v2 handler, the account and contract handlers provide the cached total balances
+func NewV2Handler(DBClient BlockInfoDB, accounts *AccountHandler, contracts *ContractHandler) *V2Handler {
+	return &V2Handler{
+		DBClient:  DBClient,
+		accounts:  accounts,
+		contracts: contracts,
+	}
+}
+
+// block find the block of the id parameter by its hash or by its height in the shard
+func (h *V2Handler) block(c *gin.Context) (*database.DBBlock, *V2Error) {
+	id := c.Param("id")
+	if strings.HasPrefix(id, "0x") {
+		if verr := v2Hash("id", id); verr != nil {
+			return nil, verr
+		}
+		block, err := h.DBClient.GetBlockByHash(id)
+		if err != nil {
+			return nil, v2DBError(err, "block")
+		}
+		return block, nil
+	}
+
+	height, err := strconv.ParseUint(id, 10, 64)
+	if err != nil {
+		return nil, v2InvalidParam("id", "id must be a block height or a block hash")
+	}
+	shard, verr := v2Shard(c)
+	if verr != nil {
+		return nil, verr
+	}
+	block, err := h.DBClient.GetBlockByHeight(shard, height)
+	if err != nil {
+		return nil, v2DBError(err, "block")
+	}
+	return block, nil
+}
+
+// Blocks list the blocks of a shard, the latest first
+func (h *V2Handler) Blocks() gin.HandlerFunc {
+	return v2Respond(func(c *gin.Context) (interface{}, *V2Error) {
+		params, verr := v2ListParams(c)
+		if verr != nil {
+			return nil, verr
+		}
+		total, err := h.DBClient.GetBlockHeight(params.Shard)
+		if err != nil {
+			return nil, v2DBError(err, "block height")
+		}
+		blocks, page, err := h.DBClient.GetBlocksByCursor(params.Shard, params.Cursor, params.Limit)
+		if err != nil {
+			return nil, v2DBError(err, "blocks")
+		}
+
+		list := &V2BlockList{Items: make([]*RetSimpleBlockInfo, 0, len(blocks)), Page: v2PageOf(total, page)}
+		for _, block := range blocks {
+			list.Items = append(list.Items, createRetSimpleBlockInfo(block))
+		}
+		return list, nil
+	})
+}
+
+// Block get a block by its height in the shard or by its hash
+func (h *V2Handler) Block() gin.HandlerFunc {
+	return v2Respond(func(c *gin.Context) (interface{}, *V2Error) {
+		block, verr := h.block(c)
+		if verr != nil {
+			return nil, verr
+		}
+		maxHeight, err := h.DBClient.GetBlockHeight(block.ShardNumber)
+		if err != nil {
+			return nil, v2DBError(err, "block height")
+		}
+		return createRetDetailBlockInfo(block, maxHeight, 0), nil
+	})
+}
+
+// BlockTxs list all the transactions of a block in the order of the block
+func (h *V2Handler) BlockTxs() gin.HandlerFunc {
+	return v2Respond(func(c *gin.Context) (interface{}, *V2Error) {
+		block, verr := h.block(c)
+		if verr != nil {
+			return nil, verr
+		}
+		txs, err := h.DBClient.GetTxsByBlock(block.ShardNumber, uint64(block.Height))
+		if err != nil {
+			return nil, v2DBError(err, "transactions")
+		}
+
+		list := &V2TxList{Items: make([]*RetSimpleTxInfo, 0, len(txs)), Page: V2Page{Total: uint64(len(txs))}}
+		for _, tx := range txs {
+			list.Items = append(list.Items, createRetSimpleTxInfo(tx))
+		}
+		return list, nil
+	})
+}
+
+// BlockDebts list all the debts of a block in the order of the block
+func (h *V2Handler) BlockDebts() gin.HandlerFunc {
+	return v2Respond(func(c *gin.Context) (interface{}, *V2Error) {
+		block, verr := h.block(c)
+		if verr != nil {
+			return nil, verr
+		}
+		debts, err := h.DBClient.GetDebtsByBlock(block.ShardNumber, uint64(block.Height))
+		if err != nil {
+			return nil, v2DBError(err, "debts")
+		}
+
+		list := &V2DebtList{Items: make([]*RetSimpledebtInfo, 0, len(debts)), Page: V2Page{Total: uint64(len(debts))}}
+		for _, debt := range debts {
+			list.Items = append(list.Items, createRetSimpledebtInfo(debt))
+		}
+		return list, nil
+	})
+}
+
+// Txs list the transactions of a shard, the latest first. A filtered listing is of the shard if no
+// address is filtered, and of all the shards otherwise, its total is not counted
+func (h *V2Handler) Txs() gin.HandlerFunc {
+	return v2Respond(func(c *gin.Context) (interface{}, *V2Error) {
+		params, verr := v2ListParams(c)
+		if verr != nil {
+			return nil, verr
+		}
+		if hasTxFilter(c) {
+			filter, verr := parseTxFilter(c, params.Shard)
+			if verr != nil {
+				return nil, verr
+			}
+			txs, page, err := h.DBClient.GetTxsByFilter(filter, params.Cursor, params.Limit)
+			if err != nil {
+				return nil, txFilterError(err)
+			}
+
+			list := &V2TxList{Items: make([]*RetSimpleTxInfo, 0, len(txs)), Page: v2PageOf(0, page)}
+			for _, tx := range txs {
+				list.Items = append(list.Items, createRetSimpleTxInfo(tx))
+			}
+			return list, nil
+		}
+		total, err := h.DBClient.GetTxCntByShardNumber(params.Shard)
+		if err != nil {
+			return nil, v2DBError(err, "transaction count")
+		}
+		txs, page, err := h.DBClient.GetTxsByCursor(params.Shard, params.Cursor, params.Limit)
+		if err != nil {
+			return nil, v2DBError(err, "transactions")
+		}
+
+		list := &V2TxList{Items: make([]*RetSimpleTxInfo, 0, len(txs)), Page: v2PageOf(total, page)}
+		for _, tx := range txs {
+			list.Items = append(list.Items, createRetSimpleTxInfo(tx))
+		}
+		return list, nil
+	})
+}
+
+// Tx get a transaction by its hash, a pending transaction has the pending flag
+func (h *V2Handler) Tx() gin.HandlerFunc {
+	return v2Respond(func(c *gin.Context) (interface{}, *V2Error) {
+		hash := c.Param("hash")
+		if verr := v2Hash("hash", hash); verr != nil {
+			return nil, verr
+		}
+		tx, err := h.DBClient.GetTxByHash(hash)
+		if err == mgo.ErrNotFound {
+			tx, err = h.DBClient.GetPendingTxByHash(hash)
+		}
+		if err != nil {
+			return nil, v2DBError(err, "transaction")
+		}
+		return createRetDetailTxInfo(tx), nil
+	})
+}
+
+// Debts list the debts of a shard, the latest first
+func (h *V2Handler) Debts() gin.HandlerFunc {
+	return v2Respond(func(c *gin.Context) (interface{}, *V2Error) {
+		params, verr := v2ListParams(c)
+		if verr != nil {
+			return nil, verr
+		}
+		total, err := h.DBClient.GetdebtCntByShardNumber(params.Shard)
+		if err != nil {
+			return nil, v2DBError(err, "debt count")
+		}
+		debts, page, err := h.DBClient.GetdebtsByCursor(params.Shard, params.Cursor, params.Limit)
+		if err != nil {
+			return nil, v2DBError(err, "debts")
+		}
+
+		list := &V2DebtList{Items: make([]*RetSimpledebtInfo, 0, len(debts)), Page: v2PageOf(total, page)}
+		for _, debt := range debts {
+			list.Items = append(list.Items, createRetSimpledebtInfo(debt))
+		}
+		return list, nil
+	})
+}
+
+// Debt get a debt by its hash
+func (h *V2Handler) Debt() gin.HandlerFunc {
+	return v2Respond(func(c *gin.Context) (interface{}, *V2Error) {
+		hash := c.Param("hash")
+		if verr := v2Hash("hash", hash); verr != nil {
+			return nil, verr
+		}
+		debt, err := h.DBClient.GetDebtByHash(hash)
+		if err != nil {
+			return nil, v2DBError(err, "debt")
+		}
+		return createRetDetailDebtInfo(debt), nil
+	})
+}
+
+// PendingTxs list the pending transactions of a shard
+func (h *V2Handler) PendingTxs() gin.HandlerFunc {
+	return v2Respond(func(c *gin.Context) (interface{}, *V2Error) {
+		params, verr := v2ListParams(c)
+		if verr != nil {
+			return nil, verr
+		}
+		total, err := h.DBClient.GetPendingTxCntByShardNumber(params.Shard)
+		if err != nil {
+			return nil, v2DBError(err, "pending transaction count")
+		}
+		txs, page, err := h.DBClient.GetPendingTxsByCursor(params.Shard, params.Cursor, params.Limit)
+		if err != nil {
+			return nil, v2DBError(err, "pending transactions")
+		}
+
+		list := &V2TxList{Items: make([]*RetSimpleTxInfo, 0, len(txs)), Page: v2PageOf(total, page)}
+		for _, tx := range txs {
+			list.Items = append(list.Items, createRetSimpleTxInfo(tx))
+		}
+		return list, nil
+	})
+}
+
+// Accounts list the accounts of a shard by balance, the richest first
+func (h *V2Handler) Accounts() gin.HandlerFunc {
+	return v2Respond(func(c *gin.Context) (interface{}, *V2Error) {
+		params, verr := v2ListParams(c)
+		if verr != nil {
+			return nil, verr
+		}
+		total, err := h.DBClient.GetAccountCntByShardNumber(params.Shard)
+		if err != nil {
+			return nil, v2DBError(err, "account count")
+		}
+		accounts, page, err := h.DBClient.GetAccountsByCursor(params.Shard, params.Cursor, params.Limit)
+		if err != nil {
+			return nil, v2DBError(err, "accounts")
+		}
+
+		totalBalance := h.accounts.accTbls[params.Shard-1].totalBalance
+		list := &V2AccountList{
+			Items:        make([]*RetSimpleAccountInfo, 0, len(accounts)),
+			Page:         v2PageOf(total, page),
+			TotalBalance: totalBalance,
+		}
+		for i, account := range accounts {
+			simpleAccount := createRetSimpleAccountInfo(account, totalBalance)
+			simpleAccount.Rank = page.Begin + i + 1
+			list.Items = append(list.Items, simpleAccount)
+		}
+		return list, nil
+	})
+}
+
+// account get the detail of an account or a contract, with its latest transactions
+func (h *V2Handler) account(address string) (*RetDetailAccountInfo, *V2Error) {
+	account, err := h.DBClient.GetAccountByAddress(address)
+	if err != nil {
+		return nil, v2DBError(err, "account")
+	}
+
+	var detail *RetDetailAccountInfo
+	if account.AccType == 1 {
+		detail = h.contracts.GetContractByAddressImpl(address)
+	} else {
+		detail = h.accounts.GetAccountByAddressImpl(address, "")
+	}
+	// the account exists, the detail is missing if a query of its transactions failed
+	if detail == nil {
+		return nil, v2DBError(errGetAccountFromDB, "account transactions")
+	}
+	return detail, nil
+}
+
+// Account get the detail of an account or a contract by its address
+func (h *V2Handler) Account() gin.HandlerFunc {
+	return v2Respond(func(c *gin.Context) (interface{}, *V2Error) {
+		address := c.Param("address")
+		if verr := v2Address("address", address); verr != nil {
+			return nil, verr
+		}
+		return h.account(address)
+	})
+}
+
+// AccountTxs list the activities of an address in the direction, the latest first
+func (h *V2Handler) AccountTxs() gin.HandlerFunc {
+	return v2Respond(func(c *gin.Context) (interface{}, *V2Error) {
+		address := c.Param("address")
+		if verr := v2Address("address", address); verr != nil {
+			return nil, verr
+		}
+		params, verr := v2ListParams(c)
+		if verr != nil {
+			return nil, verr
+		}
+		direction := c.Query("direction")
+		if !validDirection(direction) {
+			return nil, v2DBError(database.ErrInvalidDirection, "activities")
+		}
+
+		total, err := h.DBClient.GetAddressActivityCnt(address, direction)
+		if err != nil {
+			return nil, v2DBError(err, "activity count")
+		}
+		activities, page, err := h.DBClient.GetAddressActivitiesByCursor(address, direction, params.Cursor, params.Limit)
+		if err != nil {
+			return nil, v2DBError(err, "activities")
+		}
+
+		items := createRetAccountActivityInfos(activities)
+		if items == nil {
+			items = make([]*RetDetailAccountTxInfo, 0)
+		}
+		return &V2ActivityList{Items: items, Page: v2PageOf(uint64(total), page)}, nil
+	})
+}
+
+// Contract get the detail of a contract by its address
+func (h *V2Handler) Contract() gin.HandlerFunc {
+	return v2Respond(func(c *gin.Context) (interface{}, *V2Error) {
+		address := c.Param("address")
+		if verr := v2Address("address", address); verr != nil {
+			return nil, verr
+		}
+		account, err := h.DBClient.GetAccountByAddress(address)
+		if err != nil {
+			return nil, v2DBError(err, "contract")
+		}
+		if account.AccType != 1 {
+			return nil, v2NotFound("contract")
+		}
+		return h.account(address)
+	})
+}
+
+// VerifyContract save the source code and the abi of a contract, they are posted as form fields
+func (h *V2Handler) VerifyContract() gin.HandlerFunc {
+	return v2Respond(func(c *gin.Context) (interface{}, *V2Error) {
+		address := c.Param("address")
+		if verr := v2Address("address", address); verr != nil {
+			return nil, verr
+		}
+		sourceCode := c.PostForm("sourceCode")
+		if sourceCode == "" {
+			return nil, v2InvalidParam("sourceCode", "sourceCode is required")
+		}
+		abiJSON := c.PostForm("abi")
+		if _, err := abi.Parse(abiJSON); err != nil {
+			return nil, v2InvalidParam("abi", "abi is invalid: "+err.Error())
+		}
+
+		account, err := h.DBClient.GetAccountByAddress(address)
+		if err != nil {
+			return nil, v2DBError(err, "contract")
+		}
+		if account.AccType != 1 {
+			return nil, v2NotFound("contract")
+		}
+		if err := h.DBClient.UpdateContract(address, sourceCode, abiJSON); err != nil {
+			log.Error("save contract verification info failed, address:%s", address)
+			return nil, v2DBError(err, "contract")
+		}
+		labelVerifiedContract(h.DBClient, address, sourceCode)
+		return &V2VerifyResult{Address: address, Verified: true}, nil
+	})
+}
+
+// Search classify q and find the blocks of a height, the block or the transaction of a hash, the account
+// or the contract of an address, or the accounts labeled by a name. The first hit is detailed
+func (h *V2Handler) Search() gin.HandlerFunc {
+	return v2Respond(func(c *gin.Context) (interface{}, *V2Error) {
+		q := c.Query("q")
+		if q == "" {
+			return nil, v2InvalidParam("q", "q is required")
+		}
+
+		match, hits, err := findSearchHits(h.DBClient, q)
+		if err != nil {
+			return nil, v2DBError(err, searchNotFoundMessage)
+		}
+		if len(hits) == 0 {
+			return nil, v2NotFound(searchNotFoundMessage)
+		}
+
+		best := hits[0]
+		result := &V2SearchResult{Type: best.kind(), Match: match, Labels: retLabels(best.labels), Hits: searchSummaries(hits)}
+		switch {
+		case best.block != nil:
+			maxHeight, err := h.DBClient.GetBlockHeight(best.block.ShardNumber)
+			if err != nil {
+				return nil, v2DBError(err, "block height")
+			}
+			result.Block = createRetDetailBlockInfo(best.block, maxHeight, 0)
+		case best.tx != nil:
+			result.Tx = createRetDetailTxInfo(best.tx)
+		default:
+			detail, verr := h.account(best.account.Address)
+			if verr != nil {
+				return nil, verr
+			}
+			result.Account = detail
+		}
+		return result, nil
+	})
+}
+
+// SearchSuggest complete the prefix of an address, or of the name of a label, a token or a verified contract
+func (h *V2Handler) SearchSuggest() gin.HandlerFunc {
+	return v2Respond(func(c *gin.Context) (interface{}, *V2Error) {
+		prefix, limit, verr := parseSuggest(c)
+		if verr != nil {
+			return nil, verr
+		}
+		suggestions, err := suggestSearch(h.DBClient, prefix, limit)
+		if err != nil {
+			return nil, v2DBError(err, "suggestions")
+		}
+		return suggestions, nil
+	})
+}
+
+// Stats get the counts of the blocks, transactions, accounts and contracts of all the shards
+func (h *V2Handler) Stats() gin.HandlerFunc {
+	return v2Respond(func(c *gin.Context) (interface{}, *V2Error) {
+		var stats V2Stats
+		var err error
+		if stats.Blocks, err = h.DBClient.GetBlockCnt(); err != nil {
+			return nil, v2DBError(err, "block count")
+		}
+		if stats.Txs, err = h.DBClient.GetTxCnt(); err != nil {
+			return nil, v2DBError(err, "transaction count")
+		}
+		if stats.Accounts, err = h.DBClient.GetAccountCnt(); err != nil {
+			return nil, v2DBError(err, "account count")
+		}
+		if stats.Contracts, err = h.DBClient.GetContractCnt(); err != nil {
+			return nil, v2DBError(err, "contract count")
+		}
+		return &stats, nil
+	})
+}
+
+// Supply get the total supply, the supply of every shard and the coins minted today
+func (h *V2Handler) Supply() gin.HandlerFunc {
+	return v2Respond(func(c *gin.Context) (interface{}, *V2Error) {
+		supply, err := getSupplyInfo(h.DBClient)
+		if err != nil {
+			return nil, v2DBError(err, "supply")
+		}
+		return supply, nil
+	})
+}
+
+// Replicas get the members of the database and the block heights they have synchronized
+func (h *V2Handler) Replicas() gin.HandlerFunc {
+	return v2Respond(func(c *gin.Context) (interface{}, *V2Error) {
+		replicas, err := h.DBClient.ReplicaStatus()
+		if err != nil {
+			return nil, v2DBError(err, "replica status")
+		}
+		return replicas, nil
+	})
+}
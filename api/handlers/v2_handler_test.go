@@ -0,0 +1,184 @@
+/**
+*  @file
+*  @copyright defined in scan-api/LICENSE
+ */
+
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/seeleteam/scan-api/database"
+	"github.com/seeleteam/scan-api/log"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	v2TestBlockHash = "0x0000000000000000000000000000000000000000000000000000000000000b01"
+	v2TestTxHash    = "0x0000000000000000000000000000000000000000000000000000000000000a01"
+	v2TestAddress   = "0x0000000000000000000000000000000000000001"
+	v2TestContract  = "0x0000000000000000000000000000000000000002"
+)
+
+// v2TestEnvelope is the envelope with the raw data
+type v2TestEnvelope struct {
+	Data  json.RawMessage `json:"data"`
+	Error *V2Error        `json:"error"`
+}
+
+func newV2TestRouter(t *testing.T) *gin.Engine {
+	log.NewLogger("", "error", false)
+	db := database.NewMemoryClient(1)
+	assert.Nil(t, db.AddBlock(&database.DBBlock{HeadHash: v2TestBlockHash, Height: 0, ShardNumber: 1}))
+	assert.Nil(t, db.AddBlock(&database.DBBlock{HeadHash: "0x0b02", Height: 1, ShardNumber: 1}))
+	assert.Nil(t, db.AddTxs(&database.DBTx{Hash: v2TestTxHash, From: v2TestAddress, To: v2TestContract, Block: 0, Idx: 1, ShardNumber: 1}))
+	assert.Nil(t, db.AddAccount(&database.DBAccount{Address: v2TestAddress, ShardNumber: 1, Balance: 100}))
+	assert.Nil(t, db.AddAccount(&database.DBAccount{Address: v2TestContract, ShardNumber: 1, AccType: 1}))
+
+	h := NewV2Handler(db, NewAccHandler(db), NewContractHandler(db, nil))
+	gin.SetMode(gin.TestMode)
+	e := gin.New()
+	v2 := e.Group("/api/v2")
+	v2.GET("/blocks", h.Blocks())
+	v2.GET("/blocks/:id", h.Block())
+	v2.GET("/blocks/:id/txs", h.BlockTxs())
+	v2.GET("/txs/:hash", h.Tx())
+	v2.GET("/accounts/:address", h.Account())
+	v2.GET("/contracts/:address", h.Contract())
+	v2.POST("/contracts/:address/verify", h.VerifyContract())
+	v2.GET("/search", h.Search())
+	v2.GET("/search/suggest", h.SearchSuggest())
+	v2.GET("/stats", h.Stats())
+	return e
+}
+
+func serveV2(e *gin.Engine, req *http.Request) (int, *v2TestEnvelope) {
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, req)
+	envelope := new(v2TestEnvelope)
+	json.Unmarshal(w.Body.Bytes(), envelope)
+	return w.Code, envelope
+}
+
+func getV2(e *gin.Engine, uri string) (int, *v2TestEnvelope) {
+	return serveV2(e, httptest.NewRequest("GET", uri, nil))
+}
+
+func Test_V2Errors(t *testing.T) {
+	e := newV2TestRouter(t)
+
+	cases := []struct {
+		uri    string
+		status int
+		code   string
+		param  string
+	}{
+		{"/api/v2/blocks?limit=0", http.StatusBadRequest, V2ErrInvalidParam, "limit"},
+		{"/api/v2/blocks?limit=x", http.StatusBadRequest, V2ErrInvalidParam, "limit"},
+		{"/api/v2/blocks?shard=9", http.StatusBadRequest, V2ErrInvalidParam, "shard"},
+		{"/api/v2/blocks?cursor=invalid", http.StatusBadRequest, V2ErrInvalidParam, "cursor"},
+		{"/api/v2/blocks/abc", http.StatusBadRequest, V2ErrInvalidParam, "id"},
+		{"/api/v2/blocks/7", http.StatusNotFound, V2ErrNotFound, ""},
+		{"/api/v2/txs/0x01", http.StatusBadRequest, V2ErrInvalidParam, "hash"},
+		{"/api/v2/txs/" + strings.Replace(v2TestTxHash, "a01", "a02", 1), http.StatusNotFound, V2ErrNotFound, ""},
+		{"/api/v2/contracts/" + v2TestAddress, http.StatusNotFound, V2ErrNotFound, ""},
+		{"/api/v2/search", http.StatusBadRequest, V2ErrInvalidParam, "q"},
+		{"/api/v2/search?q=0x03", http.StatusNotFound, V2ErrNotFound, ""},
+	}
+	for _, c := range cases {
+		status, envelope := getV2(e, c.uri)
+		assert.Equal(t, status, c.status, c.uri)
+		if assert.NotNil(t, envelope.Error, c.uri) {
+			assert.Equal(t, envelope.Error.Code, c.code, c.uri)
+			assert.Equal(t, envelope.Error.Param, c.param, c.uri)
+		}
+		assert.Nil(t, envelope.Data, c.uri)
+	}
+}
+
+func Test_V2Responses(t *testing.T) {
+	e := newV2TestRouter(t)
+
+	status, envelope := getV2(e, "/api/v2/blocks?limit=1")
+	assert.Equal(t, status, http.StatusOK)
+	assert.Nil(t, envelope.Error)
+	var blocks V2BlockList
+	assert.Nil(t, json.Unmarshal(envelope.Data, &blocks))
+	assert.Equal(t, len(blocks.Items), 1)
+	assert.Equal(t, blocks.Items[0].Height, uint64(1))
+	assert.Equal(t, blocks.Page.Total, uint64(2))
+	assert.NotEqual(t, blocks.Page.Next, "")
+
+	status, envelope = getV2(e, "/api/v2/blocks?limit=1&cursor="+blocks.Page.Next)
+	assert.Equal(t, status, http.StatusOK)
+	assert.Nil(t, json.Unmarshal(envelope.Data, &blocks))
+	assert.Equal(t, blocks.Items[0].Height, uint64(0))
+
+	// a block is found by its height or its hash
+	var block RetDetailBlockInfo
+	_, envelope = getV2(e, "/api/v2/blocks/"+v2TestBlockHash)
+	assert.Nil(t, json.Unmarshal(envelope.Data, &block))
+	assert.Equal(t, block.Height, uint64(0))
+	var txs V2TxList
+	_, envelope = getV2(e, "/api/v2/blocks/0/txs")
+	assert.Nil(t, json.Unmarshal(envelope.Data, &txs))
+	assert.Equal(t, len(txs.Items), 1)
+	assert.Equal(t, txs.Items[0].TxHash, v2TestTxHash)
+
+	// an empty list is not null
+	status, envelope = getV2(e, "/api/v2/blocks/1/txs")
+	assert.Equal(t, status, http.StatusOK)
+	assert.Equal(t, string(envelope.Data), `{"items":[],"page":{"total":0,"next":"","prev":""}}`)
+
+	var result V2SearchResult
+	_, envelope = getV2(e, "/api/v2/search?q="+v2TestTxHash)
+	assert.Nil(t, json.Unmarshal(envelope.Data, &result))
+	assert.Equal(t, result.Type, transTypeStr)
+	assert.Equal(t, result.Tx.From, v2TestAddress)
+	_, envelope = getV2(e, "/api/v2/search?q="+v2TestContract)
+	assert.Nil(t, json.Unmarshal(envelope.Data, &result))
+	assert.Equal(t, result.Type, contractTypeStr)
+
+	var stats V2Stats
+	_, envelope = getV2(e, "/api/v2/stats")
+	assert.Nil(t, json.Unmarshal(envelope.Data, &stats))
+	assert.Equal(t, stats, V2Stats{Blocks: 2, Txs: 1, Accounts: 1, Contracts: 1})
+}
+
+func Test_V2VerifyContract(t *testing.T) {
+	e := newV2TestRouter(t)
+
+	verify := func(address string, form url.Values) (int, *v2TestEnvelope) {
+		req := httptest.NewRequest("POST", "/api/v2/contracts/"+address+"/verify", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		return serveV2(e, req)
+	}
+
+	status, envelope := verify(v2TestContract, url.Values{"abi": {"[]"}})
+	assert.Equal(t, status, http.StatusBadRequest)
+	assert.Equal(t, envelope.Error.Param, "sourceCode")
+
+	status, envelope = verify(v2TestContract, url.Values{"sourceCode": {"contract A {}"}, "abi": {"{"}})
+	assert.Equal(t, status, http.StatusBadRequest)
+	assert.Equal(t, envelope.Error.Param, "abi")
+
+	status, _ = verify(v2TestAddress, url.Values{"sourceCode": {"contract A {}"}, "abi": {"[]"}})
+	assert.Equal(t, status, http.StatusNotFound)
+
+	status, envelope = verify(v2TestContract, url.Values{"sourceCode": {"contract A {}"}, "abi": {"[]"}})
+	assert.Equal(t, status, http.StatusOK)
+	var result V2VerifyResult
+	assert.Nil(t, json.Unmarshal(envelope.Data, &result))
+	assert.Equal(t, result, V2VerifyResult{Address: v2TestContract, Verified: true})
+
+	var contract RetDetailAccountInfo
+	_, envelope = getV2(e, "/api/v2/contracts/"+v2TestContract)
+	assert.Nil(t, json.Unmarshal(envelope.Data, &contract))
+	assert.Equal(t, contract.SourceCode, "contract A {}")
+}
@@ -0,0 +1,215 @@
+/**
+*  @file
+*  @copyright defined in scan-api/LICENSE
+ */
+
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/seeleteam/scan-api/database"
+	"gopkg.in/mgo.v2"
+)
+
+// the stable error codes of the v2 api, the message may change but the codes do not
+const (
+	V2ErrInvalidParam = "invalid_param"
+	V2ErrNotFound     = "not_found"
+	V2ErrDatabase     = "database_error"
+
+	// the errors of the api keys and the rate limits, sent for the requests of every api version
+	V2ErrInvalidAPIKey = "invalid_api_key"
+	V2ErrForbidden     = "forbidden"
+	V2ErrRateLimited   = "rate_limited"
+	V2ErrQuotaExceeded = "quota_exceeded"
+)
+
+// the defaults and limits of the v2 parameters
+const (
+	v2DefaultLimit = 25
+	v2MaxLimit     = maxItemNumsPrePage
+	addressLength  = 42
+)
+
+// V2Error describle an error of the v2 api, it is sent with the http status of the error
+type V2Error struct {
+	Status  int    `json:"-"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	Param   string `json:"param,omitempty"` // the invalid parameter of an invalid_param error
+}
+
+func (e *V2Error) Error() string {
+	return e.Message
+}
+
+// V2Envelope is the body of every v2 response, data for a success and error for a failure
+type V2Envelope struct {
+	Data  interface{} `json:"data,omitempty"`
+	Error *V2Error    `json:"error,omitempty"`
+}
+
+// V2Page describle the position of a page listed by cursor
+type V2Page struct {
+	Total uint64 `json:"total"`
+	Next  string `json:"next"` // empty for the last page
+	Prev  string `json:"prev"` // empty for the first page
+}
+
+// V2BlockList is a page of the block list
+type V2BlockList struct {
+	Items []*RetSimpleBlockInfo `json:"items"`
+	Page  V2Page                `json:"page"`
+}
+
+// V2TxList is a page of a transaction list
+type V2TxList struct {
+	Items []*RetSimpleTxInfo `json:"items"`
+	Page  V2Page             `json:"page"`
+}
+
+// V2DebtList is a page of a debt list
+type V2DebtList struct {
+	Items []*RetSimpledebtInfo `json:"items"`
+	Page  V2Page               `json:"page"`
+}
+
+// V2AccountList is a page of the account list of a shard
+type V2AccountList struct {
+	Items        []*RetSimpleAccountInfo `json:"items"`
+	Page         V2Page                  `json:"page"`
+	TotalBalance int64                   `json:"totalBalance"`
+}
+
+// V2ActivityList is a page of the activities of an address
+type V2ActivityList struct {
+	Items []*RetDetailAccountTxInfo `json:"items"`
+	Page  V2Page                    `json:"page"`
+}
+
+// V2SearchResult describle the block, transaction, account or contract found first by a search,
+// and the summaries of all the hits
+type V2SearchResult struct {
+	Type    string                `json:"type"`
+	Match   string                `json:"match" doc:"the kind of q, height, shard:height, hash, address or label"`
+	Labels  []*RetLabel           `json:"labels" doc:"the labels of the account or the contract"`
+	Block   *RetDetailBlockInfo   `json:"block,omitempty"`
+	Tx      *RetDetailTxInfo      `json:"tx,omitempty"`
+	Account *RetDetailAccountInfo `json:"account,omitempty"`
+	Hits    []*RetSearchHit       `json:"hits"`
+}
+
+// V2Stats describle the counts of all the shards
+type V2Stats struct {
+	Blocks    uint64 `json:"blocks"`
+	Txs       uint64 `json:"txs"`
+	Accounts  uint64 `json:"accounts"`
+	Contracts uint64 `json:"contracts"`
+}
+
+// V2VerifyResult describle the result of a contract verification
+type V2VerifyResult struct {
+	Address  string `json:"address"`
+	Verified bool   `json:"verified"`
+}
+
+// v2HandlerFunc return the data of a v2 response or its error
+type v2HandlerFunc func(c *gin.Context) (interface{}, *V2Error)
+
+// v2Respond wrap the handler to send its data or its error in the envelope
+func v2Respond(fn v2HandlerFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		data, err := fn(c)
+		if err != nil {
+			c.JSON(err.Status, V2Envelope{Error: err})
+			return
+		}
+		c.JSON(http.StatusOK, V2Envelope{Data: data})
+	}
+}
+
+// v2InvalidParam return the error of an invalid parameter
+func v2InvalidParam(param string, message string) *V2Error {
+	return &V2Error{Status: http.StatusBadRequest, Code: V2ErrInvalidParam, Message: message, Param: param}
+}
+
+// v2NotFound return the error of a missing resource
+func v2NotFound(what string) *V2Error {
+	return &V2Error{Status: http.StatusNotFound, Code: V2ErrNotFound, Message: what + " is not found"}
+}
+
+// v2DBError convert an error of the database, a missing document is not found and a cursor
+// not created by the server is an invalid parameter
+func v2DBError(err error, what string) *V2Error {
+	switch err {
+	case mgo.ErrNotFound:
+		return v2NotFound(what)
+	case database.ErrInvalidCursor:
+		return v2InvalidParam("cursor", "cursor is invalid")
+	case database.ErrInvalidDirection:
+		return v2InvalidParam("direction", "direction must be in or out")
+	}
+	return &V2Error{Status: http.StatusInternalServerError, Code: V2ErrDatabase, Message: "could not get " + what + " from db"}
+}
+
+// V2ListParams are the parameters shared by the v2 lists
+type V2ListParams struct {
+	Shard  int
+	Limit  int
+	Cursor string
+}
+
+// v2Shard parse the shard parameter, the first shard by default
+func v2Shard(c *gin.Context) (int, *V2Error) {
+	value, ok := c.GetQuery("shard")
+	if !ok {
+		return 1, nil
+	}
+	shard, err := strconv.Atoi(value)
+	if err != nil || shard < 1 || shard > shardCount {
+		return 0, v2InvalidParam("shard", "shard must be a number from 1 to "+strconv.Itoa(shardCount))
+	}
+	return shard, nil
+}
+
+// v2ListParams parse the shard, the limit and the cursor of a list
+func v2ListParams(c *gin.Context) (*V2ListParams, *V2Error) {
+	shard, verr := v2Shard(c)
+	if verr != nil {
+		return nil, verr
+	}
+	params := &V2ListParams{Shard: shard, Limit: v2DefaultLimit, Cursor: c.Query("cursor")}
+	if value, ok := c.GetQuery("limit"); ok {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit < 1 || limit > v2MaxLimit {
+			return nil, v2InvalidParam("limit", "limit must be a number from 1 to "+strconv.Itoa(v2MaxLimit))
+		}
+		params.Limit = limit
+	}
+	return params, nil
+}
+
+// v2Hash check a block, transaction or debt hash of a path or query parameter
+func v2Hash(param string, hash string) *V2Error {
+	if len(hash) != txHashLength || !strings.HasPrefix(hash, "0x") {
+		return v2InvalidParam(param, param+" must be a 0x prefixed hash of 32 bytes")
+	}
+	return nil
+}
+
+// v2Address check an address of a path or query parameter
+func v2Address(param string, address string) *V2Error {
+	if len(address) != addressLength || !strings.HasPrefix(address, "0x") {
+		return v2InvalidParam(param, param+" must be a 0x prefixed address of 20 bytes")
+	}
+	return nil
+}
+
+// v2PageOf return the page info of a page listed by cursor
+func v2PageOf(total uint64, page *database.Page) V2Page {
+	return V2Page{Total: total, Next: page.Next, Prev: page.Prev}
+}
@@ -0,0 +1,99 @@
+/**
+*  @file
+*  @copyright defined in scan-api/LICENSE
+ */
+
+package ratelimit
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/seeleteam/scan-api/api/handlers"
+	"github.com/seeleteam/scan-api/database"
+)
+
+// MaxUsageDays is the number of days of the usage an admin can read
+const MaxUsageDays = 31
+
+// KeyUsage is the usage of an api key, or of the anonymous callers, on the last days
+type KeyUsage struct {
+	Key      string      `json:"key" doc:"the api key, anonymous for the callers without a key"`
+	Name     string      `json:"name"`
+	Tier     string      `json:"tier"`
+	Admin    bool        `json:"admin"`
+	Disabled bool        `json:"disabled"`
+	Requests int64       `json:"requests" doc:"the requests of the days"`
+	Limited  int64       `json:"limited" doc:"the requests refused by the rate or by the quota on the days"`
+	Days     []*DayUsage `json:"days" doc:"the days with requests, the oldest first"`
+}
+
+// DayUsage is the usage of a key on a utc day
+type DayUsage struct {
+	Day      string `json:"day"`
+	Requests int64  `json:"requests"`
+	Limited  int64  `json:"limited"`
+}
+
+// Usage respond the usage of every key on the last days, the most used first. Only an admin key
+// can read it, the usage of the last interval may not be stored yet
+func (l *Limiter) Usage() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if key, ok := c.Get(contextKey); !ok || !key.(*database.DBAPIKey).Admin {
+			c.JSON(http.StatusForbidden, handlers.V2Envelope{Error: &handlers.V2Error{
+				Code: handlers.V2ErrForbidden, Message: "an admin api key is required"}})
+			return
+		}
+		days, err := strconv.Atoi(c.DefaultQuery("days", "1"))
+		if err != nil || days < 1 || days > MaxUsageDays {
+			c.JSON(http.StatusBadRequest, handlers.V2Envelope{Error: &handlers.V2Error{
+				Code: handlers.V2ErrInvalidParam, Param: "days", Message: "days must be a number from 1 to " + strconv.Itoa(MaxUsageDays)}})
+			return
+		}
+
+		now := l.now().UTC()
+		usages, err := l.db.GetAPIUsage(now.AddDate(0, 0, 1-days).Format(dayFormat), now.Format(dayFormat))
+		if err == nil {
+			var keys []*database.DBAPIKey
+			if keys, err = l.db.GetAPIKeys(); err == nil {
+				c.JSON(http.StatusOK, handlers.V2Envelope{Data: keyUsages(keys, usages)})
+				return
+			}
+		}
+		c.JSON(http.StatusInternalServerError, handlers.V2Envelope{Error: &handlers.V2Error{
+			Code: handlers.V2ErrDatabase, Message: "could not get api usage from db"}})
+	}
+}
+
+// keyUsages group the usage by key, every key is listed and so are the anonymous callers
+func keyUsages(keys []*database.DBAPIKey, usages []*database.DBAPIUsage) []*KeyUsage {
+	byKey := map[string]*KeyUsage{AnonymousKey: {Key: AnonymousKey, Days: []*DayUsage{}}}
+	for _, key := range keys {
+		byKey[key.Key] = &KeyUsage{Key: key.Key, Name: key.Name, Tier: key.Tier, Admin: key.Admin, Disabled: key.Disabled, Days: []*DayUsage{}}
+	}
+	for _, u := range usages {
+		item := byKey[u.Key]
+		if item == nil {
+			// the key was removed from the database
+			item = &KeyUsage{Key: u.Key, Days: []*DayUsage{}}
+			byKey[u.Key] = item
+		}
+		item.Requests += u.Requests
+		item.Limited += u.Limited
+		item.Days = append(item.Days, &DayUsage{Day: u.Day, Requests: u.Requests, Limited: u.Limited})
+	}
+
+	result := make([]*KeyUsage, 0, len(byKey))
+	for _, item := range byKey {
+		result = append(result, item)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Requests != result[j].Requests {
+			return result[i].Requests > result[j].Requests
+		}
+		return result[i].Key < result[j].Key
+	})
+	return result
+}
@@ -0,0 +1,49 @@
+/**
+*  @file
+*  @copyright defined in scan-api/LICENSE
+ */
+
+package ratelimit
+
+import (
+	"math"
+	"time"
+)
+
+// bucket is the token bucket of a caller, it is full when it is created
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+func newBucket(tier Tier, now time.Time) *bucket {
+	return &bucket{tokens: tier.burst(), last: now}
+}
+
+// take take a token from the bucket, it return the tokens left and the time until a token is
+// refilled if there is none
+func (b *bucket) take(tier Tier, now time.Time) (ok bool, remaining int, wait time.Duration) {
+	if tier.RequestsPerSecond <= 0 {
+		return true, 0, 0
+	}
+	burst := tier.burst()
+	if elapsed := now.Sub(b.last).Seconds(); elapsed > 0 {
+		b.tokens = math.Min(burst, b.tokens+elapsed*tier.RequestsPerSecond)
+	}
+	b.last = now
+
+	if b.tokens < 1 {
+		wait = time.Duration((1 - b.tokens) / tier.RequestsPerSecond * float64(time.Second))
+		return false, 0, wait
+	}
+	b.tokens--
+	return true, int(b.tokens), 0
+}
+
+// full report whether the bucket is refilled, a full bucket can be dropped
+func (b *bucket) full(tier Tier, now time.Time) bool {
+	if tier.RequestsPerSecond <= 0 {
+		return true
+	}
+	return b.tokens+now.Sub(b.last).Seconds()*tier.RequestsPerSecond >= tier.burst()
+}
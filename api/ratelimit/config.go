@@ -0,0 +1,100 @@
+/**
+*  @file
+*  @copyright defined in scan-api/LICENSE
+ */
+
+package ratelimit
+
+import (
+	"fmt"
+	"math"
+	"net"
+	"strconv"
+	"strings"
+)
+
+// Tier is the limits of the callers of a tier, 0 is unlimited
+type Tier struct {
+	RequestsPerSecond float64 // the rate the bucket of a caller is refilled at
+	Burst             int     // the size of the bucket, the rate rounded up by default
+	DailyQuota        int64   // the requests of a utc day
+	ExportsPerHour    int     // the exports of the history of an address, limited by a bucket of their own
+}
+
+// DefaultMaxIPs is the number of the anonymous ips whose quotas are tracked by default
+const DefaultMaxIPs = 100000
+
+// Config is the tiers of the api keys and the limits of the anonymous callers, every ip of the
+// anonymous callers has its own bucket and quota. A key of an unknown tier has the anonymous limits.
+type Config struct {
+	Anonymous Tier
+	Tiers     map[string]Tier
+
+	// the ips or cidrs of the reverse proxies, the ip of a caller is read from the X-Forwarded-For
+	// header only when the request is from one of them, from the connection otherwise
+	TrustedProxies []string
+
+	// the anonymous ips whose quotas are tracked, DefaultMaxIPs if 0. When they are tracked, the
+	// idle ips are dropped for a new one, or the ip seen the longest ago, and start their quota again
+	MaxIPs int
+}
+
+// tier return the limits of a tier
+func (c *Config) tier(name string) Tier {
+	if tier, ok := c.Tiers[name]; ok {
+		return tier
+	}
+	return c.Anonymous
+}
+
+// maxIPs return the number of the anonymous ips whose quotas are tracked
+func (c *Config) maxIPs() int {
+	if c.MaxIPs > 0 {
+		return c.MaxIPs
+	}
+	return DefaultMaxIPs
+}
+
+// proxies parse the trusted proxies, a single ip is a network of its own. The invalid proxies
+// are skipped and returned in the error
+func (c *Config) proxies() ([]*net.IPNet, error) {
+	var proxies []*net.IPNet
+	var invalid []string
+	for _, proxy := range c.TrustedProxies {
+		if !strings.Contains(proxy, "/") {
+			ip := net.ParseIP(proxy)
+			if ip == nil {
+				invalid = append(invalid, proxy)
+				continue
+			}
+			bits := 8 * net.IPv4len
+			if ip.To4() == nil {
+				bits = 8 * net.IPv6len
+			}
+			proxy = proxy + "/" + strconv.Itoa(bits)
+		}
+		_, network, err := net.ParseCIDR(proxy)
+		if err != nil {
+			invalid = append(invalid, proxy)
+			continue
+		}
+		proxies = append(proxies, network)
+	}
+	if len(invalid) > 0 {
+		return proxies, fmt.Errorf("invalid trusted proxies %s", strings.Join(invalid, ", "))
+	}
+	return proxies, nil
+}
+
+// burst return the size of the bucket of the tier
+func (t Tier) burst() float64 {
+	if t.Burst > 0 {
+		return float64(t.Burst)
+	}
+	return math.Ceil(t.RequestsPerSecond)
+}
+
+// exports return the tier of the bucket of the exports, which is refilled over an hour
+func (t Tier) exports() Tier {
+	return Tier{RequestsPerSecond: float64(t.ExportsPerHour) / 3600, Burst: t.ExportsPerHour}
+}
@@ -0,0 +1,452 @@
+/**
+*  @file
+*  @copyright defined in scan-api/LICENSE
+ */
+
+package ratelimit
+
+import (
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/seeleteam/scan-api/api/handlers"
+	"github.com/seeleteam/scan-api/database"
+	"github.com/seeleteam/scan-api/log"
+)
+
+const (
+	// DefaultInterval is the period of the reloads of the keys and of the writes of the usage
+	DefaultInterval = 10 * time.Second
+
+	// the api key is sent in the header, or in the apikey parameter as the etherscan clients do
+	keyHeader = "X-API-Key"
+	keyParam  = "apikey"
+
+	// AnonymousKey is the key the usage of the anonymous callers is stored under
+	AnonymousKey = "anonymous"
+
+	contextKey = "apikey"
+	dayFormat  = "2006-01-02"
+
+	// ipv6Prefix is the length of the network an ipv6 caller is limited by, a single host is able to hold it
+	ipv6Prefix = 64
+)
+
+// KeyDB is the storage of the api keys and of their usage
+type KeyDB interface {
+	GetAPIKeys() ([]*database.DBAPIKey, error)
+	IncAPIUsage(key string, day string, requests, limited int64) error
+	GetAPIUsage(fromDay, toDay string) ([]*database.DBAPIUsage, error)
+}
+
+type usageKey struct {
+	key string
+	day string
+}
+
+type usage struct {
+	requests int64
+	limited  int64
+}
+
+// ipUsage is the requests of an anonymous ip on the day
+type ipUsage struct {
+	requests int64
+	seen     time.Time // the time of its last request
+}
+
+// Limiter limit the requests of every api key, and of every ip of the anonymous callers, by a
+// token bucket for the rate and by a daily quota. The buckets are in process, the usage is added
+// to the database every interval, so the quota of a key is shared by the servers within an
+// interval of requests.
+type Limiter struct {
+	Interval time.Duration
+
+	config  *Config
+	db      KeyDB
+	now     func() time.Time
+	proxies []*net.IPNet
+
+	mu       sync.Mutex
+	keys     map[string]*database.DBAPIKey
+	buckets  map[string]*bucket // by key, or by ip for the anonymous callers
+	exports  map[string]*bucket // the buckets of the exports, by the names of the buckets
+	day      string
+	stored   map[string]int64    // the requests of every key on the day, stored by all the servers
+	pending  map[usageKey]*usage // the usage of this server which is not stored yet
+	flushing map[usageKey]*usage // the usage being stored
+	ips      map[string]*ipUsage // the requests of the anonymous ips on the day, up to MaxIPs of the config
+}
+
+// New return a limiter of the tiers, the keys are loaded before it returns. The invalid trusted
+// proxies are logged and ignored.
+func New(db KeyDB, config *Config) *Limiter {
+	proxies, err := config.proxies()
+	if err != nil {
+		log.Error("[ratelimit] %v", err)
+	}
+	l := &Limiter{
+		Interval: DefaultInterval,
+		config:   config,
+		db:       db,
+		now:      time.Now,
+		keys:     make(map[string]*database.DBAPIKey),
+		buckets:  make(map[string]*bucket),
+		exports:  make(map[string]*bucket),
+		stored:   make(map[string]int64),
+		pending:  make(map[usageKey]*usage),
+		ips:      make(map[string]*ipUsage),
+		proxies:  proxies,
+	}
+	l.refresh()
+	return l
+}
+
+// Run reload the keys and store the usage every interval
+func (l *Limiter) Run() {
+	for {
+		time.Sleep(l.Interval)
+		l.refresh()
+	}
+}
+
+// refresh reload the keys, store the pending usage and read the usage of the day stored by all
+// the servers, the full buckets are dropped
+func (l *Limiter) refresh() {
+	if keys, err := l.db.GetAPIKeys(); err != nil {
+		log.Error("[ratelimit] get the api keys: %v", err)
+	} else {
+		loaded := make(map[string]*database.DBAPIKey, len(keys))
+		for _, key := range keys {
+			loaded[key.Key] = key
+		}
+		l.mu.Lock()
+		l.keys = loaded
+		l.mu.Unlock()
+	}
+
+	l.mu.Lock()
+	l.flushing, l.pending = l.pending, make(map[usageKey]*usage)
+	flushing := l.flushing
+	l.mu.Unlock()
+
+	failed := make(map[usageKey]*usage)
+	for k, u := range flushing {
+		if err := l.db.IncAPIUsage(k.key, k.day, u.requests, u.limited); err != nil {
+			log.Error("[ratelimit] store the usage of %s: %v", k.key, err)
+			failed[k] = u
+		}
+	}
+
+	day := l.today()
+	usages, err := l.db.GetAPIUsage(day, day)
+	if err != nil {
+		log.Error("[ratelimit] get the api usage: %v", err)
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	// a request may have started the next day during the refresh
+	if day >= l.day {
+		l.rollDay(day)
+		if err == nil {
+			l.stored = make(map[string]int64, len(usages))
+			for _, u := range usages {
+				l.stored[u.Key] = u.Requests
+			}
+		}
+	}
+	// the usage which is not stored is tried again by the next refresh
+	for k, u := range failed {
+		l.add(k, u.requests, u.limited)
+	}
+	l.flushing = nil
+
+	now := l.now()
+	for name, b := range l.buckets {
+		if b.full(l.tierOf(name), now) {
+			delete(l.buckets, name)
+		}
+	}
+	for name, b := range l.exports {
+		if b.full(l.tierOf(name).exports(), now) {
+			delete(l.exports, name)
+		}
+	}
+}
+
+func (l *Limiter) today() string {
+	return l.now().UTC().Format(dayFormat)
+}
+
+// rollDay start the counts of a new day
+func (l *Limiter) rollDay(day string) {
+	if day != l.day {
+		l.day = day
+		l.stored = make(map[string]int64)
+		l.ips = make(map[string]*ipUsage)
+	}
+}
+
+func (l *Limiter) add(k usageKey, requests, limited int64) {
+	u := l.pending[k]
+	if u == nil {
+		u = &usage{}
+		l.pending[k] = u
+	}
+	u.requests += requests
+	u.limited += limited
+}
+
+// used return the requests of a key on the day, stored or not
+func (l *Limiter) used(key string) int64 {
+	k := usageKey{key: key, day: l.day}
+	used := l.stored[key]
+	if u := l.pending[k]; u != nil {
+		used += u.requests
+	}
+	if u := l.flushing[k]; u != nil {
+		used += u.requests
+	}
+	return used
+}
+
+// clientIP return the ip of the caller of a request, the X-Forwarded-For header is read from the
+// right and the trusted proxies in it are skipped when the request is from a trusted proxy
+func (l *Limiter) clientIP(r *http.Request) string {
+	ip, _, err := net.SplitHostPort(strings.TrimSpace(r.RemoteAddr))
+	if err != nil {
+		ip = strings.TrimSpace(r.RemoteAddr)
+	}
+	if !l.trusted(ip) {
+		return ip
+	}
+
+	forwarded := strings.Split(r.Header.Get("X-Forwarded-For"), ",")
+	for i := len(forwarded) - 1; i >= 0; i-- {
+		hop := strings.TrimSpace(forwarded[i])
+		if hop == "" {
+			continue
+		}
+		if !l.trusted(hop) {
+			return hop
+		}
+		ip = hop
+	}
+	return ip
+}
+
+// trusted return true if the ip is of a trusted proxy
+func (l *Limiter) trusted(ip string) bool {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return false
+	}
+	for _, proxy := range l.proxies {
+		if proxy.Contains(parsed) {
+			return true
+		}
+	}
+	return false
+}
+
+// ipName return the name the limits of an anonymous ip are kept under, an ipv6 caller is kept
+// under its /64 network
+func ipName(ip string) string {
+	parsed := net.ParseIP(ip)
+	if parsed == nil || parsed.To4() != nil {
+		return ip
+	}
+	mask := net.CIDRMask(ipv6Prefix, 8*net.IPv6len)
+	return (&net.IPNet{IP: parsed.Mask(mask), Mask: mask}).String()
+}
+
+// trackIP return the requests of an anonymous ip on the day. When the MaxIPs of the config are
+// tracked, the ips whose buckets are refilled are dropped like the full buckets, or the ip seen
+// the longest ago if none is idle. A dropped ip starts its quota again.
+func (l *Limiter) trackIP(name string, now time.Time) *ipUsage {
+	u := l.ips[name]
+	if u == nil {
+		if len(l.ips) >= l.config.maxIPs() {
+			l.dropIPs(now)
+		}
+		u = &ipUsage{}
+		l.ips[name] = u
+	}
+	u.seen = now
+	return u
+}
+
+// dropIPs drop the idle ips, or the ip seen the longest ago if none is idle
+func (l *Limiter) dropIPs(now time.Time) {
+	oldest := ""
+	for name, u := range l.ips {
+		if b := l.buckets["ip:"+name]; b == nil || b.full(l.config.Anonymous, now) {
+			delete(l.ips, name)
+			continue
+		}
+		if oldest == "" || u.seen.Before(l.ips[oldest].seen) {
+			oldest = name
+		}
+	}
+	if len(l.ips) >= l.config.maxIPs() {
+		delete(l.ips, oldest)
+	}
+}
+
+// tierOf return the tier of a bucket
+func (l *Limiter) tierOf(name string) Tier {
+	if key, ok := l.keys[name]; ok {
+		return l.config.tier(key.Tier)
+	}
+	return l.config.Anonymous
+}
+
+// decision is the result of a request checked by the limiter
+type decision struct {
+	tier       Tier
+	remaining  int
+	quotaLeft  int64
+	retryAfter time.Duration
+	err        *handlers.V2Error
+}
+
+// Handler check the api key of a request and its limits, a refused request is answered with
+// the status and the v2 error
+func (l *Limiter) Handler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		name := c.GetHeader(keyHeader)
+		if name == "" {
+			name = c.Query(keyParam)
+		}
+
+		d := l.check(c, name)
+		if d.err == nil || d.err.Code != handlers.V2ErrInvalidAPIKey {
+			setHeaders(c, d, l.now())
+		}
+		if d.err != nil {
+			if d.retryAfter > 0 {
+				c.Header("Retry-After", strconv.FormatInt(int64(math.Ceil(d.retryAfter.Seconds())), 10))
+			}
+			c.AbortWithStatusJSON(d.err.Status, handlers.V2Envelope{Error: d.err})
+			return
+		}
+		c.Next()
+	}
+}
+
+// Export limit the exports of a caller by the exports per hour of its tier before the handler, the
+// request is counted by Handler already
+func (l *Limiter) Export(handler gin.HandlerFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ip := l.clientIP(c.Request)
+		now := l.now()
+		l.mu.Lock()
+		name := "ip:" + ipName(ip)
+		if key, ok := c.Get(contextKey); ok {
+			name = key.(*database.DBAPIKey).Key
+		}
+		tier := l.tierOf(name).exports()
+		b := l.exports[name]
+		if b == nil {
+			b = newBucket(tier, now)
+			l.exports[name] = b
+		}
+		ok, _, wait := b.take(tier, now)
+		l.mu.Unlock()
+
+		if !ok {
+			c.Header("Retry-After", strconv.FormatInt(int64(math.Ceil(wait.Seconds())), 10))
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, handlers.V2Envelope{Error: &handlers.V2Error{
+				Code: handlers.V2ErrRateLimited, Message: "the " + strconv.Itoa(tier.Burst) + " exports per hour are exceeded"}})
+			return
+		}
+		handler(c)
+	}
+}
+
+// check count a request of a key, or of the ip of the request if the key is empty. The quota is
+// checked before the rate, a refused request does not take a token
+func (l *Limiter) check(c *gin.Context, name string) *decision {
+	ip := l.clientIP(c.Request)
+	now := l.now()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.rollDay(now.UTC().Format(dayFormat))
+
+	var used int64
+	var bucketName, usageName string
+	var anonymous *ipUsage
+	if name != "" {
+		key, ok := l.keys[name]
+		if !ok || key.Disabled {
+			return &decision{err: &handlers.V2Error{Status: http.StatusUnauthorized, Code: handlers.V2ErrInvalidAPIKey,
+				Message: "the api key is unknown or disabled"}}
+		}
+		c.Set(contextKey, key)
+		bucketName, usageName, used = name, name, l.used(name)
+	} else {
+		ip = ipName(ip)
+		anonymous = l.trackIP(ip, now)
+		bucketName, usageName, used = "ip:"+ip, AnonymousKey, anonymous.requests
+	}
+	d := &decision{tier: l.tierOf(bucketName)}
+	k := usageKey{key: usageName, day: l.day}
+
+	if quota := d.tier.DailyQuota; quota > 0 {
+		if used >= quota {
+			l.add(k, 0, 1)
+			d.retryAfter = nextDay(now).Sub(now)
+			d.err = &handlers.V2Error{Status: http.StatusTooManyRequests, Code: handlers.V2ErrQuotaExceeded,
+				Message: "the daily quota of " + strconv.FormatInt(quota, 10) + " requests is used up"}
+			return d
+		}
+		d.quotaLeft = quota - used - 1
+	}
+
+	b := l.buckets[bucketName]
+	if b == nil {
+		b = newBucket(d.tier, now)
+		l.buckets[bucketName] = b
+	}
+	ok, remaining, wait := b.take(d.tier, now)
+	if !ok {
+		l.add(k, 0, 1)
+		d.quotaLeft++
+		d.retryAfter = wait
+		d.err = &handlers.V2Error{Status: http.StatusTooManyRequests, Code: handlers.V2ErrRateLimited,
+			Message: "the rate of " + strconv.FormatFloat(d.tier.RequestsPerSecond, 'f', -1, 64) + " requests per second is exceeded"}
+		return d
+	}
+	d.remaining = remaining
+	l.add(k, 1, 0)
+	if anonymous != nil {
+		anonymous.requests++
+	}
+	return d
+}
+
+// setHeaders send the limits of the caller, the headers of an unlimited rate or quota are omitted
+func setHeaders(c *gin.Context, d *decision, now time.Time) {
+	if d.tier.RequestsPerSecond > 0 {
+		c.Header("X-RateLimit-Limit", strconv.FormatFloat(d.tier.RequestsPerSecond, 'f', -1, 64))
+		c.Header("X-RateLimit-Burst", strconv.FormatFloat(d.tier.burst(), 'f', -1, 64))
+		c.Header("X-RateLimit-Remaining", strconv.Itoa(d.remaining))
+	}
+	if d.tier.DailyQuota > 0 {
+		c.Header("X-RateLimit-Quota", strconv.FormatInt(d.tier.DailyQuota, 10))
+		c.Header("X-RateLimit-Quota-Remaining", strconv.FormatInt(d.quotaLeft, 10))
+		c.Header("X-RateLimit-Reset", strconv.FormatInt(nextDay(now).Unix(), 10))
+	}
+}
+
+// nextDay return the start of the next utc day, when the quotas are reset
+func nextDay(now time.Time) time.Time {
+	return now.UTC().Truncate(24 * time.Hour).Add(24 * time.Hour)
+}
@@ -0,0 +1,245 @@
+/**
+*  @file
+*  @copyright defined in scan-api/LICENSE
+ */
+
+package ratelimit
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/seeleteam/scan-api/api/handlers"
+	"github.com/seeleteam/scan-api/database"
+	"github.com/seeleteam/scan-api/log"
+	"github.com/stretchr/testify/assert"
+)
+
+var testConfig = &Config{
+	Anonymous: Tier{RequestsPerSecond: 1, Burst: 2, DailyQuota: 3},
+	Tiers: map[string]Tier{
+		"pro":    {DailyQuota: 2},
+		"export": {ExportsPerHour: 2},
+	},
+}
+
+// newTestLimiter return a limiter at a fixed time, the clock is moved by the returned function
+func newTestLimiter(t *testing.T, db *database.Client) (*Limiter, *gin.Engine, func(time.Duration)) {
+	log.NewLogger("", "error", false)
+	gin.SetMode(gin.TestMode)
+	now := time.Date(2018, 10, 19, 12, 0, 0, 0, time.UTC)
+	l := New(db, testConfig)
+	l.now = func() time.Time { return now }
+	// New read the usage of the real day, the usage of the day of the clock is read again
+	l.day = ""
+	l.refresh()
+
+	e := gin.New()
+	e.Use(l.Handler())
+	e.GET("/blocks", func(c *gin.Context) {
+		c.JSON(http.StatusOK, handlers.V2Envelope{Data: "blocks"})
+	})
+	e.GET("/usage", l.Usage())
+	e.GET("/export", l.Export(func(c *gin.Context) {
+		c.String(http.StatusOK, "rows")
+	}))
+	return l, e, func(d time.Duration) { now = now.Add(d) }
+}
+
+func request(e *gin.Engine, path, ip, key string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("GET", path, nil)
+	r.RemoteAddr = ip + ":40000"
+	if key != "" {
+		r.Header.Set(keyHeader, key)
+	}
+	e.ServeHTTP(w, r)
+	return w
+}
+
+func errorCode(t *testing.T, w *httptest.ResponseRecorder) string {
+	var body handlers.V2Envelope
+	body.Error = &handlers.V2Error{}
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &body))
+	return body.Error.Code
+}
+
+func Test_Anonymous(t *testing.T) {
+	_, e, advance := newTestLimiter(t, database.NewMemoryClient(1))
+	reset := strconv.FormatInt(time.Date(2018, 10, 20, 0, 0, 0, 0, time.UTC).Unix(), 10)
+
+	w := request(e, "/blocks", "10.0.0.1", "")
+	assert.Equal(t, w.Code, http.StatusOK)
+	assert.Equal(t, w.Header().Get("X-RateLimit-Limit"), "1")
+	assert.Equal(t, w.Header().Get("X-RateLimit-Burst"), "2")
+	assert.Equal(t, w.Header().Get("X-RateLimit-Remaining"), "1")
+	assert.Equal(t, w.Header().Get("X-RateLimit-Quota"), "3")
+	assert.Equal(t, w.Header().Get("X-RateLimit-Quota-Remaining"), "2")
+	assert.Equal(t, w.Header().Get("X-RateLimit-Reset"), reset)
+	assert.Equal(t, request(e, "/blocks", "10.0.0.1", "").Header().Get("X-RateLimit-Remaining"), "0")
+
+	// the bucket is empty, it is refilled at the rate
+	w = request(e, "/blocks", "10.0.0.1", "")
+	assert.Equal(t, w.Code, http.StatusTooManyRequests)
+	assert.Equal(t, errorCode(t, w), handlers.V2ErrRateLimited)
+	assert.Equal(t, w.Header().Get("Retry-After"), "1")
+	assert.Equal(t, w.Header().Get("X-RateLimit-Quota-Remaining"), "1")
+	assert.Equal(t, request(e, "/blocks", "10.0.0.2", "").Code, http.StatusOK)
+	advance(time.Second)
+	assert.Equal(t, request(e, "/blocks", "10.0.0.1", "").Header().Get("X-RateLimit-Quota-Remaining"), "0")
+
+	// the quota is used up until the next utc day
+	advance(2 * time.Second)
+	w = request(e, "/blocks", "10.0.0.1", "")
+	assert.Equal(t, w.Code, http.StatusTooManyRequests)
+	assert.Equal(t, errorCode(t, w), handlers.V2ErrQuotaExceeded)
+	assert.Equal(t, w.Header().Get("Retry-After"), strconv.Itoa(12*3600-3))
+	advance(12 * time.Hour)
+	assert.Equal(t, request(e, "/blocks", "10.0.0.1", "").Code, http.StatusOK)
+}
+
+func Test_Keys(t *testing.T) {
+	db := database.NewMemoryClient(1)
+	assert.Nil(t, db.AddAPIKey(&database.DBAPIKey{Key: "k1", Tier: "pro"}))
+	assert.Nil(t, db.AddAPIKey(&database.DBAPIKey{Key: "k2", Tier: "pro", Disabled: true}))
+	l, e, _ := newTestLimiter(t, db)
+
+	// the rate of the tier is unlimited, the quota of the key is shared by its ips
+	w := request(e, "/blocks", "10.0.0.1", "k1")
+	assert.Equal(t, w.Code, http.StatusOK)
+	assert.Empty(t, w.Header().Get("X-RateLimit-Limit"))
+	assert.Equal(t, w.Header().Get("X-RateLimit-Quota-Remaining"), "1")
+	assert.Equal(t, request(e, "/blocks?apikey=k1", "10.0.0.2", "").Code, http.StatusOK)
+	assert.Equal(t, request(e, "/blocks", "10.0.0.3", "k1").Code, http.StatusTooManyRequests)
+	l.refresh()
+	assert.Equal(t, request(e, "/blocks", "10.0.0.3", "k1").Code, http.StatusTooManyRequests)
+
+	for _, key := range []string{"k2", "k3"} {
+		w = request(e, "/blocks", "10.0.0.1", key)
+		assert.Equal(t, w.Code, http.StatusUnauthorized)
+		assert.Equal(t, errorCode(t, w), handlers.V2ErrInvalidAPIKey)
+		assert.Empty(t, w.Header().Get("X-RateLimit-Quota"))
+	}
+
+	// the usage is stored by the refresh, so another server has the same quota
+	_, other, _ := newTestLimiter(t, db)
+	assert.Equal(t, request(other, "/blocks", "10.0.0.1", "k1").Code, http.StatusTooManyRequests)
+	usage, err := db.GetAPIUsage("2018-10-19", "2018-10-19")
+	assert.Nil(t, err)
+	assert.Equal(t, usage, []*database.DBAPIUsage{{Key: "k1", Day: "2018-10-19", Requests: 2, Limited: 1}})
+}
+
+func Test_Usage(t *testing.T) {
+	db := database.NewMemoryClient(1)
+	assert.Nil(t, db.AddAPIKey(&database.DBAPIKey{Key: "k1", Name: "wallet", Tier: "pro"}))
+	assert.Nil(t, db.AddAPIKey(&database.DBAPIKey{Key: "admin", Tier: "pro", Admin: true}))
+	assert.Nil(t, db.IncAPIUsage("k1", "2018-10-18", 7, 1))
+	l, e, _ := newTestLimiter(t, db)
+
+	request(e, "/blocks", "10.0.0.1", "k1")
+	request(e, "/blocks", "10.0.0.1", "")
+	assert.Equal(t, request(e, "/usage", "10.0.0.1", "").Code, http.StatusForbidden)
+	assert.Equal(t, errorCode(t, request(e, "/usage", "10.0.0.1", "k1")), handlers.V2ErrForbidden)
+	assert.Equal(t, request(e, "/usage?days=32", "10.0.0.1", "admin").Code, http.StatusBadRequest)
+	l.refresh()
+
+	var body struct{ Data []*KeyUsage }
+	w := request(e, "/usage?days=2", "10.0.0.1", "admin")
+	assert.Equal(t, w.Code, http.StatusOK)
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &body))
+	assert.Equal(t, body.Data, []*KeyUsage{
+		{Key: "k1", Name: "wallet", Tier: "pro", Requests: 9, Limited: 1, Days: []*DayUsage{
+			{Day: "2018-10-18", Requests: 7, Limited: 1}, {Day: "2018-10-19", Requests: 2},
+		}},
+		{Key: AnonymousKey, Requests: 2, Days: []*DayUsage{{Day: "2018-10-19", Requests: 2}}},
+		{Key: "admin", Tier: "pro", Admin: true, Requests: 1, Days: []*DayUsage{{Day: "2018-10-19", Requests: 1}}},
+	})
+}
+
+func Test_Export(t *testing.T) {
+	db := database.NewMemoryClient(1)
+	assert.Nil(t, db.AddAPIKey(&database.DBAPIKey{Key: "k1", Tier: "export"}))
+	_, e, advance := newTestLimiter(t, db)
+
+	// the exports of a key are limited by the tier, the anonymous exports are not
+	assert.Equal(t, request(e, "/export", "10.0.0.1", "k1").Code, http.StatusOK)
+	assert.Equal(t, request(e, "/export", "10.0.0.2", "k1").Code, http.StatusOK)
+	w := request(e, "/export", "10.0.0.1", "k1")
+	assert.Equal(t, w.Code, http.StatusTooManyRequests)
+	assert.Equal(t, errorCode(t, w), handlers.V2ErrRateLimited)
+	assert.Equal(t, w.Header().Get("Retry-After"), "1800")
+	assert.Equal(t, request(e, "/export", "10.0.0.1", "").Code, http.StatusOK)
+
+	advance(30 * time.Minute)
+	assert.Equal(t, request(e, "/export", "10.0.0.1", "k1").Code, http.StatusOK)
+}
+
+func Test_ClientIP(t *testing.T) {
+	log.NewLogger("", "error", false)
+	l := New(database.NewMemoryClient(1), &Config{TrustedProxies: []string{"10.0.0.1", "192.168.0.0/16", "bad"}})
+	forwarded := func(remote, header string) string {
+		r := httptest.NewRequest("GET", "/blocks", nil)
+		r.RemoteAddr = remote + ":40000"
+		r.Header.Set("X-Forwarded-For", header)
+		return l.clientIP(r)
+	}
+
+	// the header of a caller which is not a proxy is not trusted
+	assert.Equal(t, forwarded("10.0.0.2", "1.1.1.1"), "10.0.0.2")
+	assert.Equal(t, forwarded("10.0.0.1", "1.1.1.1"), "1.1.1.1")
+	// the ips added by the caller are before the ip the proxies saw
+	assert.Equal(t, forwarded("10.0.0.1", "2.2.2.2, 1.1.1.1, 192.168.1.1"), "1.1.1.1")
+	assert.Equal(t, forwarded("10.0.0.1", ""), "10.0.0.1")
+}
+
+func Test_MaxIPs(t *testing.T) {
+	log.NewLogger("", "error", false)
+	gin.SetMode(gin.TestMode)
+	now := time.Date(2018, 10, 19, 12, 0, 0, 0, time.UTC)
+	l := New(database.NewMemoryClient(1), &Config{Anonymous: Tier{RequestsPerSecond: 1, Burst: 1, DailyQuota: 2}, MaxIPs: 2})
+	l.now = func() time.Time { return now }
+	e := gin.New()
+	e.Use(l.Handler())
+	e.GET("/blocks", func(c *gin.Context) {
+		c.JSON(http.StatusOK, handlers.V2Envelope{Data: "blocks"})
+	})
+
+	assert.Equal(t, request(e, "/blocks", "10.0.0.1", "").Code, http.StatusOK)
+	assert.Equal(t, request(e, "/blocks", "10.0.0.2", "").Code, http.StatusOK)
+	// the table is full, the idle ips are dropped for a new one
+	now = now.Add(time.Second)
+	assert.Equal(t, request(e, "/blocks", "10.0.0.3", "").Code, http.StatusOK)
+	assert.Equal(t, len(l.ips), 1)
+	// a dropped ip gets its own quota again
+	now = now.Add(10 * time.Millisecond)
+	assert.Equal(t, request(e, "/blocks", "10.0.0.1", "").Code, http.StatusOK)
+	assert.Equal(t, len(l.ips), 2)
+
+	// none is idle, the ip seen the longest ago is dropped
+	now = now.Add(100 * time.Millisecond)
+	assert.Equal(t, request(e, "/blocks", "10.0.0.4", "").Code, http.StatusOK)
+	assert.Equal(t, len(l.ips), 2)
+	assert.Nil(t, l.ips["10.0.0.3"])
+	assert.NotNil(t, l.ips["10.0.0.1"])
+	now = now.Add(time.Second)
+	assert.Equal(t, request(e, "/blocks", "10.0.0.4", "").Code, http.StatusOK)
+	now = now.Add(time.Second)
+	w := request(e, "/blocks", "10.0.0.4", "")
+	assert.Equal(t, w.Code, http.StatusTooManyRequests)
+	assert.Equal(t, errorCode(t, w), handlers.V2ErrQuotaExceeded)
+}
+
+func Test_IPName(t *testing.T) {
+	assert.Equal(t, ipName("10.0.0.1"), "10.0.0.1")
+	// the ipv6 callers are limited by their /64 network
+	assert.Equal(t, ipName("2001:db8:0:1::1"), "2001:db8:0:1::/64")
+	assert.Equal(t, ipName("2001:db8:0:1:ffff::2"), "2001:db8:0:1::/64")
+	assert.Equal(t, ipName("2001:db8:0:2::1"), "2001:db8:0:2::/64")
+	assert.Equal(t, ipName("::ffff:10.0.0.1"), "::ffff:10.0.0.1")
+	assert.Equal(t, ipName("unknown"), "unknown")
+}
@@ -0,0 +1,310 @@
+/**
+*  @file
+*  @copyright defined in scan-api/LICENSE
+ */
+
+package routers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	gql "github.com/graphql-go/graphql"
+	"github.com/seeleteam/scan-api/api/cache"
+	"github.com/seeleteam/scan-api/api/docs"
+	"github.com/seeleteam/scan-api/api/etherscan"
+	"github.com/seeleteam/scan-api/api/graphql"
+	"github.com/seeleteam/scan-api/api/handlers"
+	"github.com/seeleteam/scan-api/api/ratelimit"
+	"github.com/seeleteam/scan-api/api/ws"
+	"github.com/seeleteam/scan-api/database"
+)
+
+// the info and the tags of the api document
+var (
+	apiInfo = docs.Info{
+		Title:   "Seele Scan API",
+		Version: "2",
+		Description: "The indexed blocks, transactions, debts, accounts and contracts of the seele shards.\n" +
+			"/api/v1 responds {code, message, data} with code 0 for a success. " +
+			"/api/v2 responds {data} for a success and {error: {code, message, param}} with the http status for an error.",
+	}
+	apiTags = []docs.Tag{
+		{Name: "blocks", Description: "blocks and the counters of the chain"},
+		{Name: "transactions", Description: "transactions and pending transactions"},
+		{Name: "debts", Description: "debts of the cross shard transactions"},
+		{Name: "accounts", Description: "accounts and miners"},
+		{Name: "contracts", Description: "contracts, their verification and reads"},
+		{Name: "charts", Description: "the daily charts"},
+		{Name: "nodes", Description: "the nodes of the network"},
+		{Name: "v2", Description: "the typed api with stable error codes"},
+		{Name: "graphql", Description: "the graphql queries over the blocks, transactions, accounts, charts and nodes"},
+		{Name: "etherscan", Description: "the etherscan compatible api for the wallets and the tools which speak it"},
+		{Name: "ws", Description: "the websocket subscriptions to the new blocks, transactions and pending transactions"},
+		{Name: "admin", Description: "the usage of the api keys, read with an admin api key"},
+		{Name: "docs", Description: "this document"},
+	}
+)
+
+// routeTable document the routes of a group by their method and path in the group, such as "GET /block"
+type routeTable map[string]docs.Route
+
+// routeGroup register the routes of a documented group with their docs in the route table of the group,
+// a route which is not in the table has no summary and fails the document test
+type routeGroup struct {
+	group  *docs.Group
+	routes routeTable
+}
+
+func newRouteGroup(group *docs.Group, routes routeTable) *routeGroup {
+	return &routeGroup{group: group, routes: routes}
+}
+
+// Group return the sub group documented by its own route table
+func (g *routeGroup) Group(relativePath string, routes routeTable) *routeGroup {
+	return newRouteGroup(g.group.Group(relativePath), routes)
+}
+
+// GET register a GET route of the group
+func (g *routeGroup) GET(relativePath string, handler gin.HandlerFunc) {
+	g.group.GET(relativePath, handler, g.routes[http.MethodGet+" "+relativePath])
+}
+
+// POST register a POST route of the group
+func (g *routeGroup) POST(relativePath string, handler gin.HandlerFunc) {
+	g.group.POST(relativePath, handler, g.routes[http.MethodPost+" "+relativePath])
+}
+
+// v1Envelope wrap the data in {code, message, data}, the errors have the same body with empty data
+var v1Envelope = &docs.Envelope{
+	Wrap: func(data *docs.Schema) *docs.Schema {
+		return v1Response(data)
+	},
+	Errors: []docs.Error{
+		{Status: http.StatusBadRequest, Description: "the parameters are invalid, code is 1", Body: v1ErrorBody},
+		{Status: http.StatusInternalServerError, Description: "the data could not be read, code is 2 or 3", Body: v1ErrorBody},
+	},
+}
+
+func v1Response(data *docs.Schema) *docs.Schema {
+	return &docs.Schema{
+		Type: docs.Object,
+		Properties: map[string]*docs.Schema{
+			"code":    {Type: docs.Integer, Description: "0 for a success, 1 for invalid parameters, 2 for an internal error and 3 for a database error"},
+			"message": {Type: docs.String, Description: "the error message"},
+			"data":    data,
+		},
+		Required: []string{"code", "data", "message"},
+	}
+}
+
+func v1ErrorBody(s *docs.Schemas) *docs.Schema {
+	return v1Response(&docs.Schema{Type: docs.Object, Description: "empty"})
+}
+
+// v2Envelope wrap the data in {data}, the errors are {error} with a stable code
+var v2Envelope = &docs.Envelope{
+	Wrap: func(data *docs.Schema) *docs.Schema {
+		return &docs.Schema{Type: docs.Object, Properties: map[string]*docs.Schema{"data": data}, Required: []string{"data"}}
+	},
+	Errors: []docs.Error{
+		{Status: http.StatusBadRequest, Description: "a parameter is invalid, code is " + handlers.V2ErrInvalidParam, Body: v2ErrorBody},
+		{Status: http.StatusNotFound, Description: "the resource is not found, code is " + handlers.V2ErrNotFound, Body: v2ErrorBody},
+		{Status: http.StatusInternalServerError, Description: "the database could not be read, code is " + handlers.V2ErrDatabase, Body: v2ErrorBody},
+	},
+}
+
+var v2ErrorBody = docs.Fields(map[string]docs.Body{"error": docs.Of(handlers.V2Error{})})
+
+// the graphql route responds the result of the query with 200 even if some fields failed, and 400 if
+// the query is invalid or over the limits
+var (
+	graphqlDescription = "Block, Transaction, Debt, Account, Contract, Miner, Node and ChartPoint can be selected with " +
+		"their nested documents in one query, such as a block with its transactions and their senders. " +
+		"A query is rejected with 400 if its depth is over " + strconv.Itoa(graphql.DefaultMaxDepth) +
+		" or its complexity is over " + strconv.Itoa(graphql.DefaultMaxComplexity) + ": every field costs 1 " +
+		"and the fields under a list are multiplied by its limit or first argument, or by an estimate of its size."
+	graphqlResult = docs.Of(gql.Result{})
+
+	etherscanDescription = "The modules and actions are account balance, balancemulti and txlist, block getblockreward, " +
+		"contract getabi, and proxy eth_blockNumber, eth_getBlockByNumber, eth_getBlockTransactionCountByNumber, " +
+		"eth_getTransactionByHash and eth_getTransactionByBlockNumberAndIndex. The blocks of the block and proxy modules " +
+		"are of the shard parameter, txlist lists the transactions of all the shards and its blocks are of the shard of " +
+		"every transaction. The apikey parameter is the api key of the rate limit, it is ignored if the rate limit is not configured."
+	etherscanResult = docs.OneOf(docs.Of(etherscan.Response{}), docs.Of(etherscan.ProxyResponse{}))
+
+	// the websocket answers the requests and pushes the events of the subscriptions
+	wsDescription = "A client sends {id, method, topic} messages, method is subscribe, unsubscribe or ping, and every " +
+		"request is answered by {id, result, topic} or {id, error}. The topics are newBlocks, newTxs and pendingTxs, " +
+		"of all the shards or of one shard such as newBlocks:1, and address:0x... for the transactions, pending " +
+		"transactions and debts of an address. A connection has at most " + strconv.Itoa(ws.DefaultMaxSubscriptions) +
+		" subscriptions, it is closed if it does not answer the pings or if it falls behind the events."
+
+	cacheDescription = "The blocks, transactions, debts, lists and counts are served from an in-process cache with a strong " +
+		"ETag, a request with a matching If-None-Match is answered with 304. A block, a transaction or a debt " +
+		strconv.Itoa(cache.DefaultConfirmations) + " blocks under the indexed height of its shard is settled and sent with a " +
+		"year long immutable Cache-Control unless it has the age or the maxheight, which change over time, the other responses are sent with no-cache and kept until the syncer moves the " +
+		"height of their shard, or of any shard for the responses of all the shards."
+
+	txFilterDescription = "The filters are combined. A listing filtered by from, to or contract is of all the shards and is " +
+		"read by the index of the address, a contract matches the transactions to it and its creation. Without an address " +
+		"the listing is of the shard, the time range is converted to its blocks, and the amount, fee, type and failed " +
+		"filters need a block or time range of at most " + strconv.Itoa(database.MaxTxFilterBlocks) + " blocks, a wider " +
+		"range is refused as an invalid parameter. The total of a filtered listing is not counted and is 0, its pages are " +
+		"listed by cursor, the latest first."
+
+	exportDescription = "The rows are streamed the oldest first, as csv with a header or as a json array. The balance of a row is " +
+		"summed from the first indexed entry of the address, the entries before from are read for it but not written, so " +
+		"the cost of an export grows with the history before from. The fee is paid by the address on the rows out, a failed " +
+		"transaction only moves the fee. When the rate limit is configured an export also takes a token of " +
+		"the ExportsPerHour of the tier of its api key, and is refused with 429 " + handlers.V2ErrRateLimited + " otherwise. " +
+		"An invalid parameter or a failure before the first row is responded as a v1 error."
+
+	searchDescription = "The input is classified first and only its lookups are run: a number is the height of a block in every " +
+		"shard, shard:height such as 1:100 is the block of a shard, a 0x hash of 32 bytes is a block, a transaction or a " +
+		"pending transaction, a 0x address of 20 bytes is an account or a contract, and any other text is the name of a " +
+		"label, a token symbol or a verified contract, ignoring the case. The first hit is detailed, all the hits are " +
+		"summarized in hits with the labels of their addresses, so an ambiguous input has several."
+	suggestDescription = "A 0x prefix completes the addresses of the indexed accounts and contracts in their order, any other " +
+		"prefix the names of the labels, token symbols and verified contracts in the order of the names, ignoring the case."
+
+	rateLimitDescription = "When the rate limit is configured every request is limited by a token bucket and a daily quota, of " +
+		"its api key, sent in the X-API-Key header or the apikey parameter, or of its ip for the anonymous callers. " +
+		"The limits are sent in the X-RateLimit-Limit, X-RateLimit-Burst and X-RateLimit-Remaining headers for the rate and " +
+		"X-RateLimit-Quota, X-RateLimit-Quota-Remaining and X-RateLimit-Reset for the quota. An unknown or disabled key is " +
+		"refused with 401 " + handlers.V2ErrInvalidAPIKey + ", a request over the limits with 429 " + handlers.V2ErrRateLimited +
+		" or " + handlers.V2ErrQuotaExceeded + " and Retry-After. The usage is stored every " +
+		strconv.Itoa(int(ratelimit.DefaultInterval.Seconds())) + " seconds and requires an admin key, 403 " + handlers.V2ErrForbidden + " otherwise."
+)
+
+// pageInfo is the page of a v1 list
+type pageInfo struct {
+	TotalCount   uint64 `json:"totalCount"`
+	Begin        uint64 `json:"begin"`
+	End          uint64 `json:"end"`
+	CurPage      uint64 `json:"curPage,omitempty" doc:"the page number, omitted when listed by cursor"`
+	Next         string `json:"next,omitempty" doc:"the cursor of the next page when listed by cursor"`
+	Prev         string `json:"prev,omitempty" doc:"the cursor of the previous page when listed by cursor"`
+	TotalBalance int64  `json:"totalBalance,omitempty" doc:"the total balance of the shard, only in the account list"`
+}
+
+// v1List is the data of a v1 list of items, the list of an empty page is null. extra are the other fields of the data
+func v1List(item interface{}, extra map[string]docs.Body) docs.Body {
+	fields := map[string]docs.Body{
+		"pageInfo": docs.Of(pageInfo{}),
+		"list":     docs.Nullable(docs.ArrayOf(item)),
+	}
+	for name, body := range extra {
+		fields[name] = body
+	}
+	return docs.Fields(fields)
+}
+
+// the parameters of the v1 lists
+func v1PageParams(size int) []*docs.Param {
+	return []*docs.Param{
+		docs.Query("p", docs.Integer, "the page number from 1").Default(1),
+		docs.Query("ps", docs.Integer, "the page size").Default(size).Range(1, 100),
+		docs.Query("s", docs.Integer, "the shard number").Default(1).Range(1, 4),
+	}
+}
+
+func cursorParam() *docs.Param {
+	return docs.Query("cursor", docs.String, "the next or prev cursor of the last page, empty for the first page. p is ignored when it is set")
+}
+
+func chartShardParam() *docs.Param {
+	return docs.Query("s", docs.Integer, "the shard number, 0 for all the shards").Default(0)
+}
+
+func addressParam(description string) *docs.Param {
+	return docs.Query("address", docs.String, description).Require()
+}
+
+func directionParam() *docs.Param {
+	return docs.Query("direction", docs.String, "in or out, empty for both").Enum("", "in", "out")
+}
+
+// the parameters of the v2 lists
+func v2ListParams() []*docs.Param {
+	return []*docs.Param{
+		v2ShardParam(),
+		docs.Query("limit", docs.Integer, "the page size").Default(25).Range(1, 100),
+		docs.Query("cursor", docs.String, "the next or prev cursor of the last page, empty for the first page"),
+	}
+}
+
+// the parameters of the transaction filter
+func txFilterParams() []*docs.Param {
+	return []*docs.Param{
+		docs.Query("from", docs.String, "the sender address"),
+		docs.Query("to", docs.String, "the receiver address"),
+		docs.Query("contract", docs.String, "the called or created contract address"),
+		docs.Query("minamount", docs.Integer, "the least amount"),
+		docs.Query("maxamount", docs.Integer, "the most amount"),
+		docs.Query("minfee", docs.Integer, "the least fee"),
+		docs.Query("maxfee", docs.Integer, "the most fee"),
+		docs.Query("startblock", docs.Integer, "the first block height"),
+		docs.Query("endblock", docs.Integer, "the last block height"),
+		docs.Query("starttime", docs.Integer, "the first unix time"),
+		docs.Query("endtime", docs.Integer, "the last unix time"),
+		docs.Query("type", docs.String, "transfer, create or call").Enum(database.TxKindTransfer, database.TxKindCreate, database.TxKindCall),
+		docs.Query("failed", docs.Boolean, "the failed or the succeeded transactions"),
+	}
+}
+
+func suggestParams() []*docs.Param {
+	return []*docs.Param{
+		docs.Query("q", docs.String, "the prefix of an address or of a label, of 2 characters at least").Require(),
+		docs.Query("limit", docs.Integer, "the count of the suggestions").Default(handlers.DefaultSuggestLimit).Range(1, handlers.MaxSuggestLimit),
+	}
+}
+
+func v2ShardParam() *docs.Param {
+	return docs.Query("shard", docs.Integer, "the shard number").Default(1).Range(1, 4)
+}
+
+func v2IDParam() *docs.Param {
+	return docs.Path("id", "the block height in the shard of the shard parameter, or the 0x prefixed block hash")
+}
+
+func v2HashParam(what string) *docs.Param {
+	return docs.Path("hash", "the 0x prefixed hash of the "+what)
+}
+
+func v2AddressParam() *docs.Param {
+	return docs.Path("address", "the 0x prefixed address of 20 bytes")
+}
+
+// countOf describe a counter
+func countOf(what string) docs.Body {
+	return docs.Describe(docs.Of(uint64(0)), "the number of "+what+" of all the shards")
+}
+
+// shardMap describe a map keyed by the shard number
+func shardMap(v interface{}) docs.Body {
+	return docs.MapOf("keyed by the shard number", v)
+}
+
+// etherscanParams return the parameters of the etherscan actions, the parameters of an action
+// are ignored by the other actions
+func etherscanParams() []*docs.Param {
+	return []*docs.Param{
+		docs.Query("module", docs.String, "account, block, contract or proxy").Require(),
+		docs.Query("action", docs.String, "the action of the module, the json-rpc method for proxy").Require(),
+		docs.Query("address", docs.String, "the address, the addresses separated by commas for balancemulti"),
+		docs.Query("startblock", docs.Integer, "the first block of txlist").Default(0),
+		docs.Query("endblock", docs.Integer, "the last block of txlist"),
+		docs.Query("page", docs.Integer, "the page of txlist").Default(1),
+		docs.Query("offset", docs.Integer, "the transactions of a page of txlist, page x offset is at most 10000"),
+		docs.Query("sort", docs.String, "asc or desc, the order of the blocks of txlist").Default("asc"),
+		docs.Query("blockno", docs.Integer, "the block of getblockreward"),
+		docs.Query("tag", docs.String, "the hex block number, latest, pending or earliest of proxy"),
+		docs.Query("boolean", docs.String, "true for the transactions of eth_getBlockByNumber instead of their hashes"),
+		docs.Query("txhash", docs.String, "the transaction hash of eth_getTransactionByHash"),
+		docs.Query("index", docs.String, "the hex index of eth_getTransactionByBlockNumberAndIndex"),
+		docs.Query("id", docs.String, "the json-rpc id of proxy").Default(1),
+		docs.Query("shard", docs.Integer, "the shard of the blocks of the block and proxy modules").Default(1).Range(1, 4),
+	}
+}
@@ -7,7 +7,13 @@ package routers
 
 import (
 	"github.com/gin-gonic/gin"
+	"github.com/seeleteam/scan-api/api/cache"
+	"github.com/seeleteam/scan-api/api/docs"
+	"github.com/seeleteam/scan-api/api/etherscan"
+	"github.com/seeleteam/scan-api/api/graphql"
 	"github.com/seeleteam/scan-api/api/handlers"
+	"github.com/seeleteam/scan-api/api/ratelimit"
+	"github.com/seeleteam/scan-api/api/ws"
 )
 
 //Router api router
@@ -17,13 +23,21 @@ type Router struct {
 	*handlers.BlockHandler
 	*handlers.ChartHandler
 	*handlers.NodeHandler
+	*handlers.V2Handler
+	GraphQL   *graphql.Handler
+	Etherscan *etherscan.Handler
+	Hub       *ws.Hub
+	Feed      *ws.Feed
+	Cache     *cache.Cache
+	Limiter   *ratelimit.Limiter // nil if the requests are not limited
 }
 
 //New return an router
-func New(blockDB handlers.BlockInfoDB, chartDB handlers.ChartInfoDB, nodeDB handlers.NodeInfoDB) *Router {
+func New(blockDB handlers.BlockInfoDB, chartDB handlers.ChartInfoDB, nodeDB handlers.NodeInfoDB, rpcNodes map[int]string) *Router {
 	accHandler := handlers.NewAccHandler(blockDB)
-	contractHandler := handlers.NewContractHandler(blockDB)
+	contractHandler := handlers.NewContractHandler(blockDB, rpcNodes)
 	nodeHandler := handlers.NewNodeHandler(nodeDB)
+	hub := ws.NewHub()
 
 	return &Router{
 		AccountHandler:  accHandler,
@@ -31,39 +45,60 @@ func New(blockDB handlers.BlockInfoDB, chartDB handlers.ChartInfoDB, nodeDB hand
 		BlockHandler:    &handlers.BlockHandler{DBClient: blockDB},
 		ChartHandler:    &handlers.ChartHandler{DBClient: chartDB},
 		NodeHandler:     nodeHandler,
+		V2Handler:       handlers.NewV2Handler(blockDB, accHandler, contractHandler),
+		GraphQL:         graphql.NewHandler(blockDB, chartDB, nodeDB),
+		Etherscan:       etherscan.NewHandler(blockDB),
+		Hub:             hub,
+		Feed:            ws.NewFeed(blockDB, hub),
+		Cache:           cache.New(blockDB),
 	}
 }
 
-//Init init all http handlers here
+//Init init all http handlers here, every route is documented by the route table of its group and the
+//OpenAPI document is served at /api/docs/openapi.json
 func (r *Router) Init(e *gin.Engine) {
-	v1 := e.Group("/api/v1")
+	spec := docs.NewSpec(apiInfo, apiTags...)
+	// the limiter is used before the routes, so every route is limited
+	if r.Limiter != nil {
+		e.Use(r.Limiter.Handler())
+	}
+
+	v1 := newRouteGroup(spec.Group(e.Group("/api/v1"), v1Envelope), v1Routes)
 	//v1.GET("/lastblock", r.BlockHandler.GetLastBlock())
 	//v1.GET("/bestblock", r.BlockHandler.GetBestBlock())
 	//v1.GET("/avgblocktime", r.BlockHandler.GetAvgBlockTime())
-	v1.GET("/accountcount", r.BlockHandler.GetAccountCnt())
-	v1.GET("/block", r.BlockHandler.GetBlock())
-	v1.GET("/blocks", r.BlockHandler.GetBlocks())
+	v1.GET("/accountcount", r.Cache.AllShards(r.BlockHandler.GetAccountCnt()))
+	v1.GET("/block", r.Cache.Settled(r.BlockHandler.GetBlock()))
+	v1.GET("/blocks", r.Cache.Shard(r.BlockHandler.GetBlocks()))
 	v1.GET("/blockTxsTps", r.BlockHandler.GetBlockTxsTps())
 	v1.GET("/blockprotime", r.BlockHandler.GetBlockProTime())
-	v1.GET("/blockcount", r.BlockHandler.GetBlockCnt())
-	v1.GET("/blockdebt", r.BlockHandler.GetBlockDebt())
-	v1.GET("/contractcount", r.BlockHandler.GetContractCnt())
-	v1.GET("/debts", r.BlockHandler.Getdebts())
-	v1.GET("/debt", r.BlockHandler.GetDebtByHash())
+	v1.GET("/blockcount", r.Cache.AllShards(r.BlockHandler.GetBlockCnt()))
+	v1.GET("/blockdebt", r.Cache.Shard(r.BlockHandler.GetBlockDebt()))
+	v1.GET("/contractcount", r.Cache.AllShards(r.BlockHandler.GetContractCnt()))
+	v1.GET("/debts", r.Cache.Shard(r.BlockHandler.Getdebts()))
+	v1.GET("/debt", r.Cache.Settled(r.BlockHandler.GetDebtByHash()))
 	v1.GET("/Homeaccounts", r.AccountHandler.GetHomeAccounts())
 	v1.GET("/pendingtxs", r.BlockHandler.GetPendingTxs())
-	v1.GET("/txcount", r.BlockHandler.GetTxCnt())
-	v1.GET("/txs", r.BlockHandler.GetTxs())
-	v1.GET("/tx", r.BlockHandler.GetTxByHash())
+	v1.GET("/txcount", r.Cache.AllShards(r.BlockHandler.GetTxCnt()))
+	v1.GET("/replicas", r.BlockHandler.GetReplicas())
+	v1.GET("/supply", r.Cache.AllShards(r.BlockHandler.GetSupply()))
+	v1.GET("/txs", r.Cache.AllShards(r.BlockHandler.GetTxs()))
+	v1.GET("/tx", r.Cache.Settled(r.BlockHandler.GetTxByHash()))
 	//ugly fix this
 	v1.GET("/search", r.BlockHandler.Search(r.AccountHandler, r.ContractHandler))
+	v1.GET("/search/suggest", r.BlockHandler.SearchSuggest())
 	v1.GET("/accounts", r.AccountHandler.GetAccounts())
-	v1.GET("/Txstat", r.BlockHandler.GetTxsDayCount())
+	v1.GET("/Txstat", r.Cache.AllShards(r.BlockHandler.GetTxsDayCount()))
 	v1.GET("/account", r.AccountHandler.GetAccountByAddress())
 	v1.GET("/miners", r.AccountHandler.GetMinerAccounts())
 	v1.GET("/contracts", r.ContractHandler.GetContracts())
 	v1.GET("/contract", r.ContractHandler.GetContractByAddress())
 	v1.GET("/verifyContract", r.ContractHandler.VerifyContract())
+	v1.GET("/contract/methods", r.ContractHandler.GetContractMethods())
+	v1.GET("/contract/read", r.ContractHandler.ReadContract())
+	v1.GET("/contract/similar", r.ContractHandler.GetSimilarContracts())
+	v1.GET("/contracts/creator", r.ContractHandler.GetContractsByCreator())
+	v1.GET("/contracts/families", r.ContractHandler.GetContractFamilies())
 
 	v1.GET("/Avegas", r.BlockHandler.GetGasPrice())
 	//v1.GET("/difficulty", r.BlockHandler.GetDifficulty())
@@ -73,7 +108,7 @@ func (r *Router) Init(e *gin.Engine) {
 	v1.GET("./node", r.NodeHandler.GetNode())
 	v1.GET("./nodemap", r.NodeHandler.GetNodeMap())
 
-	chartGrp := v1.Group("/chart")
+	chartGrp := v1.Group("/chart", chartRoutes)
 	chartGrp.GET("/tx", r.ChartHandler.GetTxHistory())
 	chartGrp.GET("/difficulty", r.ChartHandler.GetEveryDayBlockDifficulty())
 	chartGrp.GET("/address", r.ChartHandler.GetEveryDayAddress())
@@ -81,10 +116,75 @@ func (r *Router) Init(e *gin.Engine) {
 	chartGrp.GET("/hashrate", r.ChartHandler.GetEveryHashRate())
 	chartGrp.GET("/blocktime", r.ChartHandler.GetEveryDayBlockTime())
 	chartGrp.GET("/miner", r.ChartHandler.GetTopMiners())
+	chartGrp.GET("/minerrevenue", r.ChartHandler.GetMinerRevenue())
+	chartGrp.GET("/supply", r.ChartHandler.GetSupplyChart())
 	chartGrp.GET("/node", r.NodeHandler.GetNodeCntChart())
 
+	// v2 runs alongside v1, the responses are typed and the errors have stable codes and http statuses
+	v2 := newRouteGroup(spec.Group(e.Group("/api/v2"), v2Envelope), v2Routes)
+	v2.GET("/blocks", r.Cache.Shard(r.V2Handler.Blocks()))
+	v2.GET("/blocks/:id", r.Cache.Settled(r.V2Handler.Block()))
+	v2.GET("/blocks/:id/txs", r.Cache.Shard(r.V2Handler.BlockTxs()))
+	v2.GET("/blocks/:id/debts", r.Cache.Shard(r.V2Handler.BlockDebts()))
+	v2.GET("/txs", r.Cache.Shard(r.V2Handler.Txs()))
+	v2.GET("/txs/:hash", r.Cache.Settled(r.V2Handler.Tx()))
+	v2.GET("/debts", r.Cache.Shard(r.V2Handler.Debts()))
+	v2.GET("/debts/:hash", r.Cache.Settled(r.V2Handler.Debt()))
+	v2.GET("/pendingtxs", r.V2Handler.PendingTxs())
+	v2.GET("/accounts", r.V2Handler.Accounts())
+	v2.GET("/accounts/:address", r.V2Handler.Account())
+	v2.GET("/accounts/:address/txs", r.V2Handler.AccountTxs())
+	v2.GET("/contracts/:address", r.V2Handler.Contract())
+	v2.POST("/contracts/:address/verify", r.V2Handler.VerifyContract())
+	v2.GET("/search", r.V2Handler.Search())
+	v2.GET("/search/suggest", r.V2Handler.SearchSuggest())
+	v2.GET("/stats", r.Cache.AllShards(r.V2Handler.Stats()))
+	v2.GET("/supply", r.Cache.AllShards(r.V2Handler.Supply()))
+	v2.GET("/replicas", r.V2Handler.Replicas())
+	v2.GET("/cache", r.Cache.StatsHandler())
+
+	if r.Limiter != nil {
+		adminGrp := newRouteGroup(spec.Group(e.Group("/api/admin"), v2Envelope), adminRoutes)
+		adminGrp.GET("/usage", r.Limiter.Usage())
+	}
+
+	// graphql responds {data, errors} as the graphql clients expect, it has no envelope
+	gqlGrp := newRouteGroup(spec.Group(e.Group("/graphql"), nil), graphqlRoutes)
+	gqlGrp.GET("", r.GraphQL.Query())
+	gqlGrp.POST("", r.GraphQL.Query())
+
+	// the etherscan api responds {status, message, result} with 200 for the errors too, and json-rpc
+	// for the proxy module
+	esGrp := newRouteGroup(spec.Group(e.Group("/api"), nil), etherscanRoutes)
+	esGrp.GET("", r.Etherscan.API())
+	esGrp.POST("", r.Etherscan.API())
+
+	// the websocket pushes the events of its subscriptions instead of responding
+	wsGrp := newRouteGroup(spec.Group(e.Group("/ws"), nil), wsRoutes)
+	wsGrp.GET("", r.Hub.Serve())
+
+	// the export streams the rows of the history instead of wrapping them in the v1 envelope
+	exportGrp := newRouteGroup(spec.Group(e.Group("/api/v1/account"), nil), exportRoutes)
+	exportGrp.GET("/export", r.limitExport(r.AccountHandler.ExportAccount()))
+
+	docGrp := newRouteGroup(spec.Group(e.Group("/api/docs"), nil), docRoutes)
+	docGrp.GET("", docs.UIHandler())
+	docGrp.GET("/openapi.json", spec.JSONHandler())
 
 	go r.AccountHandler.Update()
 	go r.ContractHandler.Update()
 	go r.NodeHandler.Update()
+	go r.Feed.Run()
+	go r.Cache.Run()
+	if r.Limiter != nil {
+		go r.Limiter.Run()
+	}
+}
+
+// limitExport limit the exports by the tiers of the api keys if the requests are limited
+func (r *Router) limitExport(handler gin.HandlerFunc) gin.HandlerFunc {
+	if r.Limiter == nil {
+		return handler
+	}
+	return r.Limiter.Export(handler)
 }
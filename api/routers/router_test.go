@@ -0,0 +1,122 @@
+/**
+*  @file
+*  @copyright defined in scan-api/LICENSE
+ */
+
+package routers
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"path"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/seeleteam/scan-api/api/docs"
+	"github.com/seeleteam/scan-api/api/ratelimit"
+	"github.com/seeleteam/scan-api/database"
+	"github.com/seeleteam/scan-api/log"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestEngine() *gin.Engine {
+	log.NewLogger("", "error", false)
+	db := database.NewMemoryClient(1)
+	gin.SetMode(gin.TestMode)
+	e := gin.New()
+	r := New(db, db, db, nil)
+	// the anonymous callers are not limited, the limiter is set to document its routes
+	r.Limiter = ratelimit.New(db, &ratelimit.Config{})
+	r.Init(e)
+	return e
+}
+
+// refs collect the components referred by the schema
+func refs(schema *docs.Schema, found map[string]bool) {
+	if schema == nil {
+		return
+	}
+	if schema.Ref != "" {
+		found[strings.TrimPrefix(schema.Ref, "#/components/schemas/")] = true
+	}
+	refs(schema.Items, found)
+	refs(schema.AdditionalProperties, found)
+	for _, property := range schema.Properties {
+		refs(property, found)
+	}
+	for _, one := range append(schema.AllOf, schema.OneOf...) {
+		refs(one, found)
+	}
+}
+
+func Test_OpenAPI(t *testing.T) {
+	e := newTestEngine()
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, httptest.NewRequest("GET", "/api/docs/openapi.json", nil))
+	assert.Equal(t, w.Code, 200)
+
+	var doc docs.Document
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &doc))
+	assert.Equal(t, doc.OpenAPI, docs.OpenAPIVersion)
+
+	// every registered route is documented, a route registered on the engine directly fails here
+	found := make(map[string]bool)
+	for _, route := range e.Routes() {
+		op := doc.Paths[docs.OpenAPIPath(route.Path)][strings.ToLower(route.Method)]
+		if !assert.NotNil(t, op, "%s %s is not documented", route.Method, route.Path) {
+			continue
+		}
+		assert.NotEmpty(t, op.Summary, "%s %s has no summary", route.Method, route.Path)
+		assert.NotNil(t, op.Responses["200"], "%s %s has no success response", route.Method, route.Path)
+		for _, response := range op.Responses {
+			for _, media := range response.Content {
+				refs(media.Schema, found)
+			}
+		}
+	}
+	for _, schema := range doc.Components.Schemas {
+		refs(schema, found)
+	}
+	for name := range found {
+		assert.NotNil(t, doc.Components.Schemas[name], "component %s is not defined", name)
+	}
+
+	block := doc.Paths["/api/v2/blocks/{id}"]["get"]
+	assert.Equal(t, block.OperationID, "getApiV2BlocksId")
+	assert.Equal(t, block.Parameters[0].In, "path")
+	assert.NotNil(t, block.Responses["404"])
+	assert.NotNil(t, doc.Paths["/api/v1/nodes"]["get"])
+	assert.NotNil(t, doc.Paths["/api/v2/contracts/{address}/verify"]["post"].RequestBody)
+	assert.NotNil(t, doc.Paths["/graphql"]["post"].RequestBody.Content["application/json"])
+}
+
+func Test_RouteTables(t *testing.T) {
+	e := newTestEngine()
+	registered := make(map[string]bool)
+	for _, route := range e.Routes() {
+		registered[route.Method+" "+route.Path] = true
+	}
+
+	// every documented route is registered, a route which is renamed or removed fails here
+	groups := map[string]routeTable{
+		"/api/v1": v1Routes, "/api/v1/chart": chartRoutes, "/api/v1/account": exportRoutes,
+		"/api/v2": v2Routes, "/api/admin": adminRoutes, "/graphql": graphqlRoutes,
+		"/api": etherscanRoutes, "/ws": wsRoutes, "/api/docs": docRoutes,
+	}
+	for base, routes := range groups {
+		for key := range routes {
+			route := strings.SplitN(key, " ", 2)
+			assert.True(t, registered[route[0]+" "+path.Join(base, route[1])], "%s of %s is not registered", key, base)
+		}
+	}
+}
+
+func Test_DocsPage(t *testing.T) {
+	e := newTestEngine()
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, httptest.NewRequest("GET", "/api/docs", nil))
+	assert.Equal(t, w.Code, 200)
+	assert.Contains(t, w.Header().Get("Content-Type"), "text/html")
+	assert.Contains(t, w.Body.String(), "/openapi.json")
+}
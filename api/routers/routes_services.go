@@ -0,0 +1,63 @@
+/**
+*  @file
+*  @copyright defined in scan-api/LICENSE
+ */
+
+package routers
+
+import (
+	"github.com/seeleteam/scan-api/api/docs"
+	"github.com/seeleteam/scan-api/api/graphql"
+	"github.com/seeleteam/scan-api/api/ws"
+)
+
+// graphqlRoutes document the routes of /graphql
+var graphqlRoutes = routeTable{
+	"GET ": {
+		Tag: "graphql", Summary: "run a graphql query given in the url",
+		Description: graphqlDescription,
+		Params: []*docs.Param{
+			docs.Query("query", docs.String, "the graphql query").Require(),
+			docs.Query("variables", docs.String, "the json object of the values of the variables"),
+			docs.Query("operationName", docs.String, "the operation to run if the query has several operations"),
+		},
+		Data: graphqlResult,
+	},
+	"POST ": {
+		Tag: "graphql", Summary: "run a graphql query given in the json body",
+		Description: graphqlDescription,
+		Body:        docs.Of(graphql.Request{}),
+		Data:        graphqlResult,
+	},
+}
+
+// etherscanRoutes document the etherscan api of /api
+var etherscanRoutes = routeTable{
+	"GET ": {
+		Tag: "etherscan", Summary: "run an action of the etherscan api given in the url",
+		Description: etherscanDescription, Params: etherscanParams(), Data: etherscanResult,
+	},
+	"POST ": {
+		Tag: "etherscan", Summary: "run an action of the etherscan api given in the form",
+		Description: etherscanDescription, Form: etherscanParams(), Data: etherscanResult,
+	},
+}
+
+// wsRoutes document the websocket of /ws
+var wsRoutes = routeTable{
+	"GET ": {
+		Tag: "ws", Summary: "upgrade to a websocket which pushes the new blocks, transactions and pending transactions",
+		Description: wsDescription,
+		Data:        docs.Of(ws.Event{}),
+	},
+}
+
+// docRoutes document the routes of /api/docs
+var docRoutes = routeTable{
+	"GET ": {
+		Tag: "docs", Summary: "the docs page of this document", ContentType: "text/html",
+	},
+	"GET /openapi.json": {
+		Tag: "docs", Summary: "the OpenAPI 3 document of the api",
+	},
+}
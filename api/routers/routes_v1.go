@@ -0,0 +1,274 @@
+/**
+*  @file
+*  @copyright defined in scan-api/LICENSE
+ */
+
+package routers
+
+import (
+	"github.com/seeleteam/scan-api/api/docs"
+	"github.com/seeleteam/scan-api/api/handlers"
+	"github.com/seeleteam/scan-api/database"
+)
+
+// v1Routes document the routes of /api/v1
+var v1Routes = routeTable{
+	"GET /accountcount": {
+		Tag: "accounts", Summary: "get the number of accounts", Data: countOf("accounts"),
+	},
+	"GET /block": {
+		Tag: "blocks", Summary: "get a block by its hash or by its height in the shard",
+		Params: []*docs.Param{
+			docs.Query("hash", docs.String, "the block hash, height is ignored when it is set"),
+			docs.Query("height", docs.Integer, "the block height, required without hash"),
+			docs.Query("s", docs.Integer, "the shard number of the height"),
+		},
+		Data: docs.Of(handlers.RetDetailBlockInfo{}),
+	},
+	"GET /blocks": {
+		Tag: "blocks", Summary: "list the blocks of a shard, the latest first",
+		Params: append(v1PageParams(20), cursorParam()),
+		Data:   v1List(handlers.RetSimpleBlockInfo{}, nil),
+	},
+	"GET /blockTxsTps": {
+		Tag: "blocks", Summary: "get the transactions per second of the latest blocks",
+		Data: docs.Of(float64(0)),
+	},
+	"GET /blockprotime": {
+		Tag: "blocks", Summary: "get the height and the time of the last block",
+		Data: docs.Of(handlers.Lastblock{}),
+	},
+	"GET /blockcount": {
+		Tag: "blocks", Summary: "get the number of blocks", Data: countOf("blocks"),
+	},
+	"GET /blockdebt": {
+		Tag: "debts", Summary: "list the debts of a block",
+		Params: append(v1PageParams(25), docs.Query("block", docs.Integer, "the block height").Require()),
+		Data:   v1List(handlers.RetSimpledebtInfo{}, nil),
+	},
+	"GET /contractcount": {
+		Tag: "contracts", Summary: "get the number of contracts", Data: countOf("contracts"),
+	},
+	"GET /debts": {
+		Tag: "debts", Summary: "list the debts of a shard, the latest first",
+		Params: append(v1PageParams(25), cursorParam()),
+		Data:   v1List(handlers.RetSimpledebtInfo{}, nil),
+	},
+	"GET /debt": {
+		Tag: "debts", Summary: "get a debt by its hash",
+		Params: []*docs.Param{docs.Query("debtHash", docs.String, "the 0x prefixed debt hash").Require()},
+		Data:   docs.Of(handlers.RetSimpledebtInfo{}),
+	},
+	"GET /Homeaccounts": {
+		Tag: "accounts", Summary: "list the richest accounts of the home page",
+		Data: docs.ArrayOf(handlers.RetSimpleAccountHome{}),
+	},
+	"GET /pendingtxs": {
+		Tag: "transactions", Summary: "list the pending transactions of a shard",
+		Params: append(v1PageParams(25), cursorParam()),
+		Data:   v1List(handlers.RetSimpleTxInfo{}, nil),
+	},
+	"GET /txcount": {
+		Tag: "transactions", Summary: "get the number of transactions", Data: countOf("transactions"),
+	},
+	"GET /replicas": {
+		Tag: "blocks", Summary: "get the members of the database and the block heights they have synchronized",
+		Data: docs.ArrayOf(database.ReplicaStatus{}),
+	},
+	"GET /supply": {
+		Tag: "blocks", Summary: "get the total supply, the supply of every shard and the coins minted today",
+		Data: docs.Of(handlers.RetSupplyInfo{}),
+	},
+	"GET /txs": {
+		Tag: "transactions", Summary: "list the transactions of a shard, a block or an address",
+		Description: "The transactions of the block are listed if block is set, the activities of the address if address is set, " +
+			"the filtered transactions if a filter is set, otherwise the transactions of the shard, the latest first. " + txFilterDescription,
+		Params: append(append(v1PageParams(25), cursorParam(),
+			docs.Query("block", docs.Integer, "the block height"),
+			docs.Query("address", docs.String, "the address"),
+			directionParam()), txFilterParams()...),
+		Data: docs.OneOf(v1List(handlers.RetSimpleTxInfo{}, nil), v1List(handlers.RetDetailAccountTxInfo{}, nil)),
+	},
+	"GET /tx": {
+		Tag: "transactions", Summary: "get a transaction or a pending transaction by its hash",
+		Params: []*docs.Param{docs.Query("txhash", docs.String, "the 0x prefixed transaction hash").Require()},
+		Data:   docs.OneOf(docs.Of(handlers.RetDetailTxInfo{}), docs.Of(handlers.RetSimpleTxInfo{})),
+	},
+	"GET /search": {
+		Tag: "blocks", Summary: "find blocks, transactions, accounts or contracts by a height, a hash, an address or a label",
+		Description: searchDescription,
+		Params:      []*docs.Param{docs.Query("content", docs.String, "the height, shard:height, hash, address or label").Require()},
+		Data: docs.Fields(map[string]docs.Body{
+			"type": docs.Describe(docs.Of(""), "block, transaction, account or contract"),
+			"info": docs.OneOf(docs.Of(handlers.RetDetailBlockInfo{}), docs.Of(handlers.RetDetailTxInfo{}),
+				docs.Of(handlers.RetSimpleTxInfo{}), docs.Of(handlers.RetDetailAccountInfo{})),
+			"match":  docs.Describe(docs.Of(""), "the kind of the content, height, shard:height, hash, address or label"),
+			"labels": docs.ArrayOf(handlers.RetLabel{}),
+			"hits":   docs.ArrayOf(handlers.RetSearchHit{}),
+		}),
+	},
+	"GET /search/suggest": {
+		Tag: "blocks", Summary: "complete the prefix of an address or of a label",
+		Description: suggestDescription, Params: suggestParams(),
+		Data: docs.ArrayOf(handlers.RetSearchSuggestion{}),
+	},
+	"GET /accounts": {
+		Tag: "accounts", Summary: "list the accounts of a shard by balance, the richest first",
+		Params: append(v1PageParams(20), cursorParam()),
+		Data:   v1List(handlers.RetSimpleAccountInfo{}, nil),
+	},
+	"GET /Txstat": {
+		Tag: "charts", Summary: "get the daily transactions of the last 30 days",
+		Data: docs.ArrayOf(database.DBSimpleTxs{}),
+	},
+	"GET /account": {
+		Tag: "accounts", Summary: "get an account with its latest transactions, data is null if it is not found",
+		Params: []*docs.Param{addressParam("the account address"), directionParam()},
+		Data:   docs.Of(handlers.RetDetailAccountInfo{}),
+	},
+	"GET /miners": {
+		Tag: "accounts", Summary: "list the miners which mined the most blocks",
+		Data: docs.ArrayOf(database.DBMiner{}),
+	},
+	"GET /contracts": {
+		Tag: "contracts", Summary: "list the contracts of a shard by balance",
+		Params: v1PageParams(20),
+		Data:   v1List(handlers.RetSimpleAccountInfo{}, nil),
+	},
+	"GET /contract": {
+		Tag: "contracts", Summary: "get a contract with its latest transactions, data is null if it is not found",
+		Params: []*docs.Param{addressParam("the contract address")},
+		Data:   docs.Of(handlers.RetDetailAccountInfo{}),
+	},
+	"GET /verifyContract": {
+		Tag: "contracts", Summary: "save the source code and the abi of a contract, data is false and message is the error if it fails",
+		Params: []*docs.Param{
+			addressParam("the contract address"),
+			docs.Query("sourceCode", docs.String, "the source code").Require(),
+			docs.Query("abi", docs.String, "the abi json").Require(),
+		},
+		Data: docs.Of(false),
+	},
+	"GET /contract/methods": {
+		Tag: "contracts", Summary: "list the view methods of a verified contract",
+		Params: []*docs.Param{addressParam("the contract address")},
+		Data:   docs.ArrayOf(handlers.RetContractMethod{}),
+	},
+	"GET /contract/read": {
+		Tag: "contracts", Summary: "call a view method of a verified contract on the node of its shard",
+		Params: []*docs.Param{
+			addressParam("the contract address"),
+			docs.Query("method", docs.String, "the method name").Require(),
+			docs.Query("args", docs.String, "an argument of the method, repeated in the order of the inputs"),
+			docs.Query("height", docs.Integer, "the block height, -1 for the latest").Default(-1),
+		},
+		Data: docs.Of(handlers.RetContractReadResult{}),
+	},
+	"GET /contract/similar": {
+		Tag: "contracts", Summary: "list the contracts which have the same runtime bytecode as the contract",
+		Params: append(v1PageParams(20)[:2], addressParam("the contract address")),
+		Data:   v1List(handlers.RetSimpleContractInfo{}, map[string]docs.Body{"codeHash": docs.Of("")}),
+	},
+	"GET /contracts/creator": {
+		Tag: "contracts", Summary: "list the contracts deployed by an address",
+		Params: append(v1PageParams(20)[:2], addressParam("the creator address")),
+		Data:   v1List(handlers.RetSimpleContractInfo{}, map[string]docs.Body{"creator": docs.Of("")}),
+	},
+	"GET /contracts/families": {
+		Tag: "contracts", Summary: "list the runtime bytecodes shared by several contracts",
+		Params: []*docs.Param{
+			docs.Query("min", docs.Integer, "the min number of contracts of a bytecode").Default(2),
+			docs.Query("p", docs.Integer, "the page of the bytecodes, the largest families first").Default(1),
+			docs.Query("ps", docs.Integer, "the number of bytecodes").Default(20).Range(1, 100),
+			docs.Query("addresses", docs.Integer, "the number of contract addresses of every bytecode").Default(10).Range(1, 100),
+		},
+		Data: docs.ArrayOf(handlers.RetCodeHashGroup{}),
+	},
+	"GET /Avegas": {
+		Tag: "transactions", Summary: "get the highest, the lowest and the average gas price of the last 10 days",
+		Data: docs.Of(handlers.Walletgas{}),
+	},
+	"GET ./nodes": {
+		Tag: "nodes", Summary: "list the nodes of a shard",
+		Params: v1PageParams(20),
+		Data:   v1List(database.DBNodeInfo{}, nil),
+	},
+	"GET ./node": {
+		Tag: "nodes", Summary: "get a node by its id",
+		Params: []*docs.Param{docs.Query("id", docs.String, "the node id").Require()},
+		Data:   docs.Of(database.DBNodeInfo{}),
+	},
+	"GET ./nodemap": {
+		Tag: "nodes", Summary: "list the nodes of all the shards",
+		Data: docs.ArrayOf(database.DBNodeInfo{}),
+	},
+}
+
+// chartRoutes document the routes of /api/v1/chart
+var chartRoutes = routeTable{
+	"GET /tx": {
+		Tag: "charts", Summary: "get the daily transactions, blocks, addresses, difficulty and hash rate",
+		Params: []*docs.Param{chartShardParam()},
+		Data:   docs.ArrayOf(handlers.RetOneDayTxInfo{}),
+	},
+	"GET /difficulty": {
+		Tag: "charts", Summary: "get the daily average block difficulty",
+		Params: []*docs.Param{chartShardParam()},
+		Data:   docs.ArrayOf(database.DBOneDayBlockDifficulty{}),
+	},
+	"GET /address": {
+		Tag: "charts", Summary: "get the daily number of addresses",
+		Params: []*docs.Param{chartShardParam()},
+		Data:   docs.ArrayOf(database.DBOneDayAddressInfo{}),
+	},
+	"GET /blocks": {
+		Tag: "charts", Summary: "get the daily number of blocks and rewards",
+		Params: []*docs.Param{chartShardParam()},
+		Data:   docs.ArrayOf(database.DBOneDayBlockInfo{}),
+	},
+	"GET /hashrate": {
+		Tag: "charts", Summary: "get the daily average hash rate",
+		Params: []*docs.Param{chartShardParam()},
+		Data:   docs.ArrayOf(database.DBOneDayHashRate{}),
+	},
+	"GET /blocktime": {
+		Tag: "charts", Summary: "get the daily average block time",
+		Params: []*docs.Param{chartShardParam()},
+		Data:   docs.ArrayOf(database.DBOneDayBlockAvgTime{}),
+	},
+	"GET /miner": {
+		Tag: "charts", Summary: "get the rank of the miners",
+		Params: []*docs.Param{chartShardParam()},
+		Data:   docs.ArrayOf(database.DBMinerRankInfo{}),
+	},
+	"GET /minerrevenue": {
+		Tag: "charts", Summary: "get the daily revenue of a miner",
+		Params: []*docs.Param{addressParam("the miner address"), chartShardParam()},
+		Data:   docs.ArrayOf(database.DBOneDayMinerRevenue{}),
+	},
+	"GET /supply": {
+		Tag: "charts", Summary: "get the daily supply",
+		Params: []*docs.Param{chartShardParam()},
+		Data:   docs.ArrayOf(handlers.RetOneDaySupply{}),
+	},
+	"GET /node": {
+		Tag: "charts", Summary: "get the number of nodes of every shard",
+		Data: shardMap(0),
+	},
+}
+
+// exportRoutes document the export of /api/v1/account, which streams the rows instead of the v1 envelope
+var exportRoutes = routeTable{
+	"GET /export": {
+		Tag: "accounts", Summary: "export the transactions, debts and rewards of an address with the running balance",
+		Description: exportDescription,
+		Params: []*docs.Param{
+			addressParam("the account address"),
+			docs.Query("from", docs.String, "the first utc date, 2006-01-02, from the first entry by default"),
+			docs.Query("to", docs.String, "the last utc date, 2006-01-02, up to the latest entry by default"),
+			docs.Query("format", docs.String, "the format of the rows").Default(handlers.ExportCSV).Enum(handlers.ExportCSV, handlers.ExportJSON),
+		},
+		Data:        docs.ArrayOf(handlers.RetAccountHistoryRow{}),
+		ContentType: "text/csv",
+	},
+}
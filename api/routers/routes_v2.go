@@ -0,0 +1,120 @@
+/**
+*  @file
+*  @copyright defined in scan-api/LICENSE
+ */
+
+package routers
+
+import (
+	"github.com/seeleteam/scan-api/api/cache"
+	"github.com/seeleteam/scan-api/api/docs"
+	"github.com/seeleteam/scan-api/api/handlers"
+	"github.com/seeleteam/scan-api/api/ratelimit"
+	"github.com/seeleteam/scan-api/database"
+)
+
+// v2Routes document the routes of /api/v2
+var v2Routes = routeTable{
+	"GET /blocks": {
+		Tag: "v2", Summary: "list the blocks of a shard, the latest first",
+		Params: v2ListParams(), Data: docs.Of(handlers.V2BlockList{}),
+	},
+	"GET /blocks/:id": {
+		Tag: "v2", Summary: "get a block by its height in the shard or by its hash",
+		Params: []*docs.Param{v2IDParam(), v2ShardParam()}, Data: docs.Of(handlers.RetDetailBlockInfo{}),
+	},
+	"GET /blocks/:id/txs": {
+		Tag: "v2", Summary: "list all the transactions of a block in the order of the block",
+		Params: []*docs.Param{v2IDParam(), v2ShardParam()}, Data: docs.Of(handlers.V2TxList{}),
+	},
+	"GET /blocks/:id/debts": {
+		Tag: "v2", Summary: "list all the debts of a block in the order of the block",
+		Params: []*docs.Param{v2IDParam(), v2ShardParam()}, Data: docs.Of(handlers.V2DebtList{}),
+	},
+	"GET /txs": {
+		Tag: "v2", Summary: "list the transactions of a shard or the filtered transactions, the latest first",
+		Description: txFilterDescription,
+		Params:      append(v2ListParams(), txFilterParams()...), Data: docs.Of(handlers.V2TxList{}),
+	},
+	"GET /txs/:hash": {
+		Tag: "v2", Summary: "get a transaction by its hash, a pending transaction has the pending flag",
+		Params: []*docs.Param{v2HashParam("transaction")}, Data: docs.Of(handlers.RetDetailTxInfo{}),
+	},
+	"GET /debts": {
+		Tag: "v2", Summary: "list the debts of a shard, the latest first",
+		Params: v2ListParams(), Data: docs.Of(handlers.V2DebtList{}),
+	},
+	"GET /debts/:hash": {
+		Tag: "v2", Summary: "get a debt by its hash",
+		Params: []*docs.Param{v2HashParam("debt")}, Data: docs.Of(handlers.RetSimpledebtInfo{}),
+	},
+	"GET /pendingtxs": {
+		Tag: "v2", Summary: "list the pending transactions of a shard",
+		Params: v2ListParams(), Data: docs.Of(handlers.V2TxList{}),
+	},
+	"GET /accounts": {
+		Tag: "v2", Summary: "list the accounts of a shard by balance, the richest first",
+		Params: v2ListParams(), Data: docs.Of(handlers.V2AccountList{}),
+	},
+	"GET /accounts/:address": {
+		Tag: "v2", Summary: "get an account or a contract with its latest transactions",
+		Params: []*docs.Param{v2AddressParam()}, Data: docs.Of(handlers.RetDetailAccountInfo{}),
+	},
+	"GET /accounts/:address/txs": {
+		Tag: "v2", Summary: "list the activities of an address, the latest first",
+		Params: append([]*docs.Param{v2AddressParam(), directionParam()}, v2ListParams()[1:]...),
+		Data:   docs.Of(handlers.V2ActivityList{}),
+	},
+	"GET /contracts/:address": {
+		Tag: "v2", Summary: "get a contract, not_found if the address is not a contract",
+		Params: []*docs.Param{v2AddressParam()}, Data: docs.Of(handlers.RetDetailAccountInfo{}),
+	},
+	"POST /contracts/:address/verify": {
+		Tag: "v2", Summary: "save the source code and the abi of a contract",
+		Params: []*docs.Param{v2AddressParam()},
+		Form: []*docs.Param{
+			docs.Query("sourceCode", docs.String, "the source code").Require(),
+			docs.Query("abi", docs.String, "the abi json").Require(),
+		},
+		Data: docs.Of(handlers.V2VerifyResult{}),
+	},
+	"GET /search": {
+		Tag: "v2", Summary: "find blocks, transactions, accounts or contracts by a height, a hash, an address or a label",
+		Description: searchDescription,
+		Params:      []*docs.Param{docs.Query("q", docs.String, "the height, shard:height, hash, address or label").Require()},
+		Data:        docs.Of(handlers.V2SearchResult{}),
+	},
+	"GET /search/suggest": {
+		Tag: "v2", Summary: "complete the prefix of an address or of a label",
+		Description: suggestDescription, Params: suggestParams(),
+		Data: docs.ArrayOf(handlers.RetSearchSuggestion{}),
+	},
+	"GET /stats": {
+		Tag: "v2", Summary: "get the counts of the blocks, transactions, accounts and contracts of all the shards",
+		Data: docs.Of(handlers.V2Stats{}),
+	},
+	"GET /supply": {
+		Tag: "v2", Summary: "get the total supply, the supply of every shard and the coins minted today",
+		Data: docs.Of(handlers.RetSupplyInfo{}),
+	},
+	"GET /replicas": {
+		Tag: "v2", Summary: "get the members of the database and the block heights they have synchronized",
+		Data: docs.ArrayOf(database.ReplicaStatus{}),
+	},
+	"GET /cache": {
+		Tag: "v2", Summary: "get the hits, the misses and the hit rate of the response cache",
+		Description: cacheDescription, Data: docs.Of(cache.Stats{}),
+	},
+}
+
+// adminRoutes document the routes of /api/admin, which are registered with the rate limit
+var adminRoutes = routeTable{
+	"GET /usage": {
+		Tag: "admin", Summary: "list the requests of every api key and of the anonymous callers, the most used first",
+		Description: rateLimitDescription,
+		Params: []*docs.Param{
+			docs.Query("days", docs.Integer, "the number of days up to today").Default(1).Range(1, ratelimit.MaxUsageDays),
+		},
+		Data: docs.ArrayOf(ratelimit.KeyUsage{}),
+	},
+}
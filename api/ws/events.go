@@ -0,0 +1,134 @@
+/**
+*  @file
+*  @copyright defined in scan-api/LICENSE
+ */
+
+package ws
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	shardCount    = 4
+	addressLength = 42
+
+	// the topics, the block, transaction and pending transaction topics take an optional shard
+	// such as newBlocks:1, an address topic takes the address such as address:0x...
+	topicBlocks  = "newBlocks"
+	topicTxs     = "newTxs"
+	topicPending = "pendingTxs"
+	topicAddress = "address"
+
+	// the types of the events
+	typeBlock     = "block"
+	typeTx        = "tx"
+	typePendingTx = "pendingTx"
+	typeDebt      = "debt"
+
+	// the methods of the requests
+	methodSubscribe   = "subscribe"
+	methodUnsubscribe = "unsubscribe"
+	methodPing        = "ping"
+)
+
+var (
+	errInvalidShard   = errors.New("the shard of a topic must be a number from 1 to " + strconv.Itoa(shardCount))
+	errInvalidAddress = errors.New("the address of a topic must be a 0x prefixed address of 20 bytes")
+	errInvalidRequest = errors.New("the request must be a json object with a method")
+)
+
+// Request is a message of a client, the id is sent back in the reply
+type Request struct {
+	ID     json.RawMessage `json:"id,omitempty" doc:"any json value sent back in the reply"`
+	Method string          `json:"method" doc:"subscribe, unsubscribe or ping"`
+	Topic  string          `json:"topic,omitempty" doc:"newBlocks, newTxs, pendingTxs with an optional :shard, or address:0x..."`
+}
+
+// Reply answer a request, Error is set if the request failed
+type Reply struct {
+	ID     json.RawMessage `json:"id,omitempty"`
+	Result string          `json:"result,omitempty" doc:"subscribed, unsubscribed or pong"`
+	Topic  string          `json:"topic,omitempty" doc:"the topic of a subscription as it is published"`
+	Error  string          `json:"error,omitempty"`
+}
+
+// Event is a message published to the subscribers of a topic
+type Event struct {
+	Topic string      `json:"topic" doc:"the subscribed topic"`
+	Type  string      `json:"type" doc:"block, tx, pendingTx or debt"`
+	Data  interface{} `json:"data" doc:"a Block, a Tx or a Debt"`
+}
+
+// Block is the data of a block event
+type Block struct {
+	Shard     int    `json:"shard"`
+	Height    int64  `json:"height"`
+	Hash      string `json:"hash"`
+	Miner     string `json:"miner"`
+	Timestamp int64  `json:"timestamp"`
+	Txs       int    `json:"txs"`
+	Debts     int    `json:"debts"`
+	Reward    int64  `json:"reward"`
+}
+
+// Tx is the data of a transaction or a pending transaction event, a pending transaction has no block
+type Tx struct {
+	Shard     int    `json:"shard"`
+	Hash      string `json:"hash"`
+	Block     int64  `json:"block,omitempty"`
+	From      string `json:"from"`
+	To        string `json:"to"`
+	Amount    int64  `json:"amount"`
+	Fee       int64  `json:"fee"`
+	Timestamp int64  `json:"timestamp"`
+}
+
+// Debt is the data of a debt event, a debt pays the amount of a cross shard transaction to the account
+type Debt struct {
+	Shard   int    `json:"shard"`
+	Hash    string `json:"hash"`
+	TxHash  string `json:"txHash"`
+	Block   int64  `json:"block"`
+	Account string `json:"account"`
+	Amount  int64  `json:"amount"`
+}
+
+// parseTopic check a topic and return it as it is published
+func parseTopic(topic string) (string, error) {
+	name, arg := topic, ""
+	if i := strings.IndexByte(topic, ':'); i >= 0 {
+		name, arg = topic[:i], topic[i+1:]
+	}
+
+	switch name {
+	case topicBlocks, topicTxs, topicPending:
+		if arg == "" {
+			return name, nil
+		}
+		shard, err := strconv.Atoi(arg)
+		if err != nil || shard < 1 || shard > shardCount {
+			return "", errInvalidShard
+		}
+		return shardTopic(name, shard), nil
+	case topicAddress:
+		if len(arg) != addressLength || !strings.HasPrefix(arg, "0x") {
+			return "", errInvalidAddress
+		}
+		return addressTopic(arg), nil
+	}
+	return "", fmt.Errorf("unknown topic %q", topic)
+}
+
+func shardTopic(name string, shard int) string {
+	return name + ":" + strconv.Itoa(shard)
+}
+
+// addressTopic return the topic of an address, the addresses are compared in lower case
+func addressTopic(address string) string {
+	return topicAddress + ":" + strings.ToLower(address)
+}
@@ -0,0 +1,189 @@
+/**
+*  @file
+*  @copyright defined in scan-api/LICENSE
+ */
+
+package ws
+
+import (
+	"time"
+
+	"github.com/seeleteam/scan-api/api/handlers"
+	"github.com/seeleteam/scan-api/database"
+	"github.com/seeleteam/scan-api/log"
+)
+
+// the defaults of the feed
+const (
+	DefaultInterval = 2 * time.Second
+
+	// maxBlocksPerPoll is the number of blocks published by a poll of a shard, the feed skips the
+	// older blocks when the database jumps further, such as after an import
+	maxBlocksPerPoll = 100
+
+	// maxPendingTxs is the number of the latest pending transactions compared by a poll
+	maxPendingTxs = 500
+)
+
+// Feed follow the database and publish the new blocks, transactions, debts and pending
+// transactions to the hub. The block collection is not capped and the vendored mgo driver has no
+// change streams, so the feed tails the sync height of every shard, which the syncer moves when it
+// adds a block. A block holds its transactions and debts, so a new block is read by one query.
+type Feed struct {
+	Interval time.Duration
+
+	db      handlers.BlockInfoDB
+	hub     *Hub
+	heights map[int]uint64          // the next height to publish of every shard
+	pending map[int]map[string]bool // the pending transactions of every shard at the last poll
+}
+
+// NewFeed return a feed of the database to the hub
+func NewFeed(db handlers.BlockInfoDB, hub *Hub) *Feed {
+	return &Feed{
+		Interval: DefaultInterval,
+		db:       db,
+		hub:      hub,
+		heights:  make(map[int]uint64),
+		pending:  make(map[int]map[string]bool),
+	}
+}
+
+// Run poll the database every interval, the first poll publishes nothing
+func (f *Feed) Run() {
+	for {
+		f.poll()
+		time.Sleep(f.Interval)
+	}
+}
+
+func (f *Feed) poll() {
+	for shard := 1; shard <= shardCount; shard++ {
+		if err := f.pollBlocks(shard); err != nil {
+			log.Error("[ws] poll the blocks of shard %d: %v", shard, err)
+		}
+		if err := f.pollPendingTxs(shard); err != nil {
+			log.Error("[ws] poll the pending transactions of shard %d: %v", shard, err)
+		}
+	}
+}
+
+// pollBlocks publish the blocks of the shard added since the last poll
+func (f *Feed) pollBlocks(shard int) error {
+	height, err := f.db.GetBlockHeight(shard)
+	if err != nil {
+		return err
+	}
+	next, ok := f.heights[shard]
+	if !ok || height < next {
+		// the feed starts at the synchronized height, and starts again after the blocks of a
+		// fork are removed
+		f.heights[shard] = height
+		return nil
+	}
+	if height == next {
+		return nil
+	}
+	if height-next > maxBlocksPerPoll {
+		next = height - maxBlocksPerPoll
+	}
+
+	blocks, err := f.db.GetBlocksByHeight(shard, next, height)
+	if err != nil {
+		return err
+	}
+	// the blocks are sorted by height desc, they are published in the order of the chain
+	for i := len(blocks) - 1; i >= 0; i-- {
+		f.publishBlock(blocks[i])
+	}
+	f.heights[shard] = height
+	return nil
+}
+
+func (f *Feed) publishBlock(b *database.DBBlock) {
+	block := &Block{
+		Shard:     b.ShardNumber,
+		Height:    b.Height,
+		Hash:      b.HeadHash,
+		Miner:     b.Creator,
+		Timestamp: b.Timestamp,
+		Txs:       len(b.Txs),
+		Debts:     len(b.Debts),
+		Reward:    b.Reward,
+	}
+	f.hub.Publish(topicBlocks, typeBlock, block)
+	f.hub.Publish(shardTopic(topicBlocks, b.ShardNumber), typeBlock, block)
+
+	for _, t := range b.Txs {
+		tx := &Tx{
+			Shard:     b.ShardNumber,
+			Hash:      t.Hash,
+			Block:     b.Height,
+			From:      t.From,
+			To:        t.To,
+			Amount:    t.Amount,
+			Fee:       t.Fee,
+			Timestamp: b.Timestamp,
+		}
+		f.hub.Publish(topicTxs, typeTx, tx)
+		f.hub.Publish(shardTopic(topicTxs, b.ShardNumber), typeTx, tx)
+		f.publishAddresses(typeTx, tx, tx.From, tx.To)
+	}
+
+	for _, d := range b.Debts {
+		debt := &Debt{
+			Shard:   b.ShardNumber,
+			Hash:    d.Hash,
+			TxHash:  d.TxHash,
+			Block:   b.Height,
+			Account: d.Account,
+			Amount:  d.Amount,
+		}
+		f.publishAddresses(typeDebt, debt, debt.Account)
+	}
+}
+
+// publishAddresses publish an event to the topics of its addresses, once to an address
+func (f *Feed) publishAddresses(eventType string, data interface{}, addresses ...string) {
+	published := make(map[string]bool, len(addresses))
+	for _, address := range addresses {
+		if address == "" {
+			continue
+		}
+		topic := addressTopic(address)
+		if !published[topic] {
+			published[topic] = true
+			f.hub.Publish(topic, eventType, data)
+		}
+	}
+}
+
+// pollPendingTxs publish the pending transactions of the shard which were not pending at the last poll
+func (f *Feed) pollPendingTxs(shard int) error {
+	txs, _, err := f.db.GetPendingTxsByCursor(shard, "", maxPendingTxs)
+	if err != nil {
+		return err
+	}
+	last, started := f.pending[shard]
+	current := make(map[string]bool, len(txs))
+	for _, t := range txs {
+		current[t.Hash] = true
+		if !started || last[t.Hash] {
+			continue
+		}
+		tx := &Tx{
+			Shard:     shard,
+			Hash:      t.Hash,
+			From:      t.From,
+			To:        t.To,
+			Amount:    t.Amount,
+			Fee:       t.Fee,
+			Timestamp: t.Timestamp,
+		}
+		f.hub.Publish(topicPending, typePendingTx, tx)
+		f.hub.Publish(shardTopic(topicPending, shard), typePendingTx, tx)
+		f.publishAddresses(typePendingTx, tx, tx.From, tx.To)
+	}
+	f.pending[shard] = current
+	return nil
+}
@@ -0,0 +1,292 @@
+/**
+*  @file
+*  @copyright defined in scan-api/LICENSE
+ */
+
+package ws
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/seeleteam/scan-api/log"
+)
+
+// the defaults of the limits of a connection
+const (
+	DefaultMaxSubscriptions = 16
+	DefaultSendBuffer       = 256
+
+	// a client must answer the pings, or send a message, within pongWait
+	pongWait   = 60 * time.Second
+	pingPeriod = pongWait * 9 / 10
+	writeWait  = 10 * time.Second
+
+	// the requests are small json objects
+	maxMessageSize = 1024
+)
+
+var upgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 4096,
+	// the api allows all the origins by cors, so does the websocket
+	CheckOrigin: func(r *http.Request) bool { return true },
+}
+
+// Hub hold the connections and their subscriptions and publish the events to the subscribers
+type Hub struct {
+	MaxSubscriptions int // the subscriptions of a connection
+	SendBuffer       int // the messages queued for a connection, a connection which falls behind is closed
+
+	mu      sync.RWMutex
+	clients map[*client]bool
+	topics  map[string]map[*client]bool
+}
+
+// client is a connection of the hub, its messages are written by one goroutine from send
+type client struct {
+	hub    *Hub
+	conn   *websocket.Conn
+	send   chan []byte
+	topics map[string]bool // guarded by the mutex of the hub
+}
+
+// NewHub return a hub with the default limits
+func NewHub() *Hub {
+	return &Hub{
+		MaxSubscriptions: DefaultMaxSubscriptions,
+		SendBuffer:       DefaultSendBuffer,
+		clients:          make(map[*client]bool),
+		topics:           make(map[string]map[*client]bool),
+	}
+}
+
+// Serve upgrade a request to a websocket and handle its requests until it is closed
+func (h *Hub) Serve() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+		if err != nil {
+			// the upgrader responded the error
+			log.Debug("[ws] upgrade failed: %v", err)
+			return
+		}
+
+		cl := &client{hub: h, conn: conn, send: make(chan []byte, h.SendBuffer), topics: make(map[string]bool)}
+		h.mu.Lock()
+		h.clients[cl] = true
+		h.mu.Unlock()
+
+		go cl.write()
+		cl.read()
+	}
+}
+
+// Publish send an event to the subscribers of the topic
+func (h *Hub) Publish(topic string, eventType string, data interface{}) {
+	h.mu.RLock()
+	subscribers := h.topics[topic]
+	if len(subscribers) == 0 {
+		h.mu.RUnlock()
+		return
+	}
+	msg, err := json.Marshal(&Event{Topic: topic, Type: eventType, Data: data})
+	if err != nil {
+		h.mu.RUnlock()
+		log.Error("[ws] marshal %s event: %v", topic, err)
+		return
+	}
+	var slow []*client
+	for cl := range subscribers {
+		select {
+		case cl.send <- msg:
+		default:
+			slow = append(slow, cl)
+		}
+	}
+	h.mu.RUnlock()
+
+	for _, cl := range slow {
+		log.Debug("[ws] close a connection which falls behind on %s", topic)
+		h.remove(cl)
+	}
+}
+
+// Subscribers return the number of subscribers of the topic
+func (h *Hub) Subscribers(topic string) int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return len(h.topics[topic])
+}
+
+// Connections return the number of connections
+func (h *Hub) Connections() int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return len(h.clients)
+}
+
+func (h *Hub) subscribe(cl *client, topic string) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if cl.topics[topic] {
+		return nil
+	}
+	if h.MaxSubscriptions > 0 && len(cl.topics) >= h.MaxSubscriptions {
+		return errors.New("a connection has at most " + strconv.Itoa(h.MaxSubscriptions) + " subscriptions")
+	}
+	cl.topics[topic] = true
+	subscribers, ok := h.topics[topic]
+	if !ok {
+		subscribers = make(map[*client]bool)
+		h.topics[topic] = subscribers
+	}
+	subscribers[cl] = true
+	return nil
+}
+
+func (h *Hub) unsubscribe(cl *client, topic string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.unsubscribeLocked(cl, topic)
+}
+
+func (h *Hub) unsubscribeLocked(cl *client, topic string) {
+	delete(cl.topics, topic)
+	if subscribers, ok := h.topics[topic]; ok {
+		delete(subscribers, cl)
+		if len(subscribers) == 0 {
+			delete(h.topics, topic)
+		}
+	}
+}
+
+// remove drop the subscriptions of a client and close its send channel, the writer then closes
+// the connection
+func (h *Hub) remove(cl *client) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if !h.clients[cl] {
+		return
+	}
+	for topic := range cl.topics {
+		h.unsubscribeLocked(cl, topic)
+	}
+	delete(h.clients, cl)
+	close(cl.send)
+}
+
+// read handle the requests of the client until the connection fails or is closed
+func (cl *client) read() {
+	defer cl.hub.remove(cl)
+
+	cl.conn.SetReadLimit(maxMessageSize)
+	cl.conn.SetReadDeadline(time.Now().Add(pongWait))
+	cl.conn.SetPongHandler(func(string) error {
+		return cl.conn.SetReadDeadline(time.Now().Add(pongWait))
+	})
+
+	for {
+		_, msg, err := cl.conn.ReadMessage()
+		if err != nil {
+			if websocket.IsUnexpectedCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway) {
+				log.Debug("[ws] read: %v", err)
+			}
+			return
+		}
+		// a browser can not send a ping frame, its requests keep the connection alive too
+		cl.conn.SetReadDeadline(time.Now().Add(pongWait))
+
+		var req Request
+		if err := json.Unmarshal(msg, &req); err != nil || req.Method == "" {
+			cl.reply(&Reply{Error: errInvalidRequest.Error()})
+			continue
+		}
+		cl.reply(cl.handle(&req))
+	}
+}
+
+func (cl *client) handle(req *Request) *Reply {
+	reply := &Reply{ID: req.ID}
+	switch req.Method {
+	case methodSubscribe, methodUnsubscribe:
+		topic, err := parseTopic(req.Topic)
+		if err != nil {
+			reply.Error = err.Error()
+			return reply
+		}
+		reply.Topic = topic
+		if req.Method == methodUnsubscribe {
+			cl.hub.unsubscribe(cl, topic)
+			reply.Result = "unsubscribed"
+			return reply
+		}
+		if err := cl.hub.subscribe(cl, topic); err != nil {
+			reply.Error = err.Error()
+			return reply
+		}
+		reply.Result = "subscribed"
+	case methodPing:
+		reply.Result = "pong"
+	default:
+		reply.Error = "unknown method " + strconv.Quote(req.Method)
+	}
+	return reply
+}
+
+// reply queue a reply like an event, the client is closed if it falls behind
+func (cl *client) reply(reply *Reply) {
+	msg, err := json.Marshal(reply)
+	if err != nil {
+		log.Error("[ws] marshal reply: %v", err)
+		return
+	}
+	h := cl.hub
+	h.mu.RLock()
+	queued := true
+	if h.clients[cl] {
+		select {
+		case cl.send <- msg:
+		default:
+			queued = false
+		}
+	}
+	h.mu.RUnlock()
+	if !queued {
+		h.remove(cl)
+	}
+}
+
+// write send the queued messages and the pings, and close the connection when the send channel
+// is closed or a write fails
+func (cl *client) write() {
+	ticker := time.NewTicker(pingPeriod)
+	defer func() {
+		ticker.Stop()
+		cl.conn.Close()
+	}()
+
+	for {
+		select {
+		case msg, ok := <-cl.send:
+			cl.conn.SetWriteDeadline(time.Now().Add(writeWait))
+			if !ok {
+				cl.conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+				return
+			}
+			if err := cl.conn.WriteMessage(websocket.TextMessage, msg); err != nil {
+				return
+			}
+		case <-ticker.C:
+			cl.conn.SetWriteDeadline(time.Now().Add(writeWait))
+			if err := cl.conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				return
+			}
+		}
+	}
+}
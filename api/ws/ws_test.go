@@ -0,0 +1,198 @@
+/**
+*  @file
+*  @copyright defined in scan-api/LICENSE
+ */
+
+package ws
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/seeleteam/scan-api/database"
+	"github.com/seeleteam/scan-api/log"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	testSender   = "0x4c10f2cd2159bb432094e3be7e17904c2b4aeb21"
+	testReceiver = "0x1b9ddc9ea4a5d5d7e5e71df1e1b0f5e2c4d6b3b1"
+)
+
+func newTestServer(t *testing.T) (*database.Client, *Hub, *Feed, string, func()) {
+	log.NewLogger("", "error", false)
+	gin.SetMode(gin.TestMode)
+	db := database.NewMemoryClient(1)
+	hub := NewHub()
+	feed := NewFeed(db, hub)
+
+	e := gin.New()
+	e.GET("/ws", hub.Serve())
+	server := httptest.NewServer(e)
+	return db, hub, feed, "ws" + strings.TrimPrefix(server.URL, "http") + "/ws", server.Close
+}
+
+func dial(t *testing.T, url string) *websocket.Conn {
+	conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+	if !assert.Nil(t, err) {
+		t.FailNow()
+	}
+	return conn
+}
+
+func send(t *testing.T, conn *websocket.Conn, req *Request) *Reply {
+	assert.Nil(t, conn.WriteJSON(req))
+	var reply Reply
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	assert.Nil(t, conn.ReadJSON(&reply))
+	return &reply
+}
+
+// event read the next event, its data is decoded into data
+func event(t *testing.T, conn *websocket.Conn, data interface{}) *Event {
+	var raw struct {
+		Event
+		Data json.RawMessage `json:"data"`
+	}
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	if !assert.Nil(t, conn.ReadJSON(&raw)) {
+		t.FailNow()
+	}
+	assert.Nil(t, json.Unmarshal(raw.Data, data))
+	return &Event{Topic: raw.Topic, Type: raw.Type, Data: data}
+}
+
+func Test_ParseTopic(t *testing.T) {
+	for topic, want := range map[string]string{
+		"newBlocks":   "newBlocks",
+		"newBlocks:2": "newBlocks:2",
+		"newTxs:4":    "newTxs:4",
+		"pendingTxs":  "pendingTxs",
+		"address:0x4C10F2CD2159BB432094E3BE7E17904C2B4AEB21": "address:" + testSender,
+	} {
+		got, err := parseTopic(topic)
+		assert.Nil(t, err, topic)
+		assert.Equal(t, got, want)
+	}
+
+	for _, topic := range []string{"", "blocks", "newBlocks:0", "newBlocks:5", "newTxs:x", "address:", "address:0x12"} {
+		_, err := parseTopic(topic)
+		assert.NotNil(t, err, topic)
+	}
+}
+
+func Test_Requests(t *testing.T) {
+	_, hub, _, url, stop := newTestServer(t)
+	defer stop()
+	hub.MaxSubscriptions = 2
+	conn := dial(t, url)
+	defer conn.Close()
+
+	reply := send(t, conn, &Request{ID: json.RawMessage(`1`), Method: methodSubscribe, Topic: "newBlocks:1"})
+	assert.Equal(t, *reply, Reply{ID: json.RawMessage(`1`), Result: "subscribed", Topic: "newBlocks:1"})
+	assert.Equal(t, hub.Subscribers("newBlocks:1"), 1)
+
+	// a subscription is counted once
+	reply = send(t, conn, &Request{Method: methodSubscribe, Topic: "newBlocks:1"})
+	assert.Equal(t, reply.Error, "")
+	reply = send(t, conn, &Request{Method: methodSubscribe, Topic: "newTxs"})
+	assert.Equal(t, reply.Error, "")
+	reply = send(t, conn, &Request{ID: json.RawMessage(`"a"`), Method: methodSubscribe, Topic: "pendingTxs"})
+	assert.Equal(t, *reply, Reply{ID: json.RawMessage(`"a"`), Topic: "pendingTxs", Error: "a connection has at most 2 subscriptions"})
+
+	reply = send(t, conn, &Request{Method: methodUnsubscribe, Topic: "newTxs"})
+	assert.Equal(t, reply.Result, "unsubscribed")
+	assert.Equal(t, hub.Subscribers("newTxs"), 0)
+
+	reply = send(t, conn, &Request{Method: methodSubscribe, Topic: "newBlocks:9"})
+	assert.Equal(t, reply.Error, errInvalidShard.Error())
+	reply = send(t, conn, &Request{Method: methodPing})
+	assert.Equal(t, reply.Result, "pong")
+	reply = send(t, conn, &Request{Method: "watch"})
+	assert.Equal(t, reply.Error, `unknown method "watch"`)
+
+	assert.Nil(t, conn.WriteMessage(websocket.TextMessage, []byte("subscribe")))
+	var invalid Reply
+	assert.Nil(t, conn.ReadJSON(&invalid))
+	assert.Equal(t, invalid.Error, errInvalidRequest.Error())
+
+	// the subscriptions are dropped with the connection
+	conn.Close()
+	for i := 0; i < 100 && hub.Connections() > 0; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.Equal(t, hub.Connections(), 0)
+	assert.Equal(t, hub.Subscribers("newBlocks:1"), 0)
+}
+
+func Test_Feed(t *testing.T) {
+	db, _, feed, url, stop := newTestServer(t)
+	defer stop()
+	assert.Nil(t, db.AddBlock(&database.DBBlock{HeadHash: "0x0b00", Height: 0, ShardNumber: 1}))
+
+	conn := dial(t, url)
+	defer conn.Close()
+	for _, topic := range []string{"newBlocks:1", "newTxs", "pendingTxs:1", "address:" + testReceiver} {
+		assert.Equal(t, send(t, conn, &Request{Method: methodSubscribe, Topic: topic}).Error, "")
+	}
+
+	// the first poll starts at the synchronized height and publishes nothing
+	feed.poll()
+	assert.Equal(t, feed.heights[1], uint64(1))
+
+	assert.Nil(t, db.AddPendingTx(&database.DBTx{Hash: "0x0c01", From: testSender, To: testReceiver, Amount: 7, ShardNumber: 1}))
+	assert.Nil(t, db.AddBlock(&database.DBBlock{
+		HeadHash: "0x0b01", Height: 1, ShardNumber: 1, Creator: testSender, Timestamp: 1500000000, Reward: 150,
+		Txs: []database.DBSimpleTxInBlock{{Hash: "0x0a01", From: testSender, To: testReceiver, Amount: 5, Fee: 1}},
+	}))
+	feed.poll()
+
+	var block Block
+	e := event(t, conn, &block)
+	assert.Equal(t, e.Topic, "newBlocks:1")
+	assert.Equal(t, e.Type, typeBlock)
+	assert.Equal(t, block, Block{Shard: 1, Height: 1, Hash: "0x0b01", Miner: testSender, Timestamp: 1500000000, Txs: 1, Reward: 150})
+
+	var tx Tx
+	want := Tx{Shard: 1, Hash: "0x0a01", Block: 1, From: testSender, To: testReceiver, Amount: 5, Fee: 1, Timestamp: 1500000000}
+	e = event(t, conn, &tx)
+	assert.Equal(t, e.Topic, "newTxs")
+	assert.Equal(t, tx, want)
+	e = event(t, conn, &tx)
+	assert.Equal(t, e.Topic, "address:"+testReceiver)
+	assert.Equal(t, tx, want)
+
+	var pending Tx
+	e = event(t, conn, &pending)
+	assert.Equal(t, e.Topic, "pendingTxs:1")
+	assert.Equal(t, e.Type, typePendingTx)
+	assert.Equal(t, pending, Tx{Shard: 1, Hash: "0x0c01", From: testSender, To: testReceiver, Amount: 7})
+	e = event(t, conn, &pending)
+	assert.Equal(t, e.Topic, "address:"+testReceiver)
+	assert.Equal(t, e.Type, typePendingTx)
+
+	// nothing new is published again
+	feed.poll()
+	assert.Equal(t, send(t, conn, &Request{Method: methodPing}).Result, "pong")
+}
+
+func Test_SlowClient(t *testing.T) {
+	_, hub, _, url, stop := newTestServer(t)
+	defer stop()
+	hub.SendBuffer = 1
+	conn := dial(t, url)
+	defer conn.Close()
+	assert.Equal(t, send(t, conn, &Request{Method: methodSubscribe, Topic: "newBlocks"}).Result, "subscribed")
+
+	// the client reads nothing, the hub closes it once its buffer is full
+	for i := 0; i < 1000 && hub.Connections() > 0; i++ {
+		hub.Publish("newBlocks", typeBlock, &Block{Height: int64(i)})
+	}
+	assert.Equal(t, hub.Connections(), 0)
+	assert.Equal(t, hub.Subscribers("newBlocks"), 0)
+}
@@ -0,0 +1,300 @@
+/**
+*  @file
+*  @copyright defined in scan-api/LICENSE
+ */
+
+package audit
+
+import (
+	"math/rand"
+	"sort"
+
+	"github.com/seeleteam/scan-api/database"
+	"github.com/seeleteam/scan-api/rpc"
+	"gopkg.in/mgo.v2"
+)
+
+// the kinds of the audited documents
+const (
+	KindBlock   = "block"
+	KindTx      = "transaction"
+	KindReceipt = "receipt"
+	KindDebt    = "debt"
+	KindAccount = "account"
+)
+
+// accountBatchSize is the number of accounts read in a batch
+const accountBatchSize = 1000
+
+// Diff is a difference between the indexed data and the chain
+type Diff struct {
+	Kind   string      `json:"kind"`
+	Height uint64      `json:"height,omitempty"`
+	Key    string      `json:"key"` // the hash of the document or the address of the account
+	Field  string      `json:"field"`
+	Stored interface{} `json:"stored"`
+	Node   interface{} `json:"node"`
+}
+
+// Summary is the count of the audited documents and of the differences found
+type Summary struct {
+	Blocks   int64 `json:"blocks"`
+	Txs      int64 `json:"txs"`
+	Debts    int64 `json:"debts"`
+	Accounts int64 `json:"accounts"`
+	Diffs    int64 `json:"diffs"`
+}
+
+// Auditor compare the indexed data of a shard with the node, the differences are passed to the report
+type Auditor struct {
+	Summary
+	db          Database
+	node        Node
+	shardNumber int
+	report      func(d *Diff) error
+}
+
+// New return an auditor of the shard
+func New(db Database, node Node, shardNumber int, report func(d *Diff) error) *Auditor {
+	return &Auditor{
+		db:          db,
+		node:        node,
+		shardNumber: shardNumber,
+		report:      report,
+	}
+}
+
+// Heights return the heights to audit in [from, to], every height if sample is not positive or
+// sample random heights in the increasing order
+func Heights(from, to uint64, sample int, rnd *rand.Rand) []uint64 {
+	if to < from {
+		return nil
+	}
+	count := to - from + 1
+	if sample <= 0 || uint64(sample) >= count {
+		heights := make([]uint64, 0, count)
+		for h := from; h <= to; h++ {
+			heights = append(heights, h)
+		}
+		return heights
+	}
+
+	picked := make(map[uint64]bool)
+	for len(picked) < sample {
+		picked[from+uint64(rnd.Int63n(int64(count)))] = true
+	}
+	heights := make([]uint64, 0, sample)
+	for h := range picked {
+		heights = append(heights, h)
+	}
+	sort.Slice(heights, func(i, j int) bool { return heights[i] < heights[j] })
+	return heights
+}
+
+// diff report a difference if the stored value is not the value of the node
+func (a *Auditor) diff(kind string, height uint64, key string, field string, stored interface{}, node interface{}) error {
+	if stored == node {
+		return nil
+	}
+	a.Diffs++
+	return a.report(&Diff{Kind: kind, Height: height, Key: key, Field: field, Stored: stored, Node: node})
+}
+
+// diffList report a difference if the stored hashes are not the hashes of the node in the same order
+func (a *Auditor) diffList(kind string, height uint64, key string, field string, stored []string, node []string) error {
+	equal := len(stored) == len(node)
+	for i := 0; equal && i < len(stored); i++ {
+		equal = stored[i] == node[i]
+	}
+	if equal {
+		return nil
+	}
+	a.Diffs++
+	return a.report(&Diff{Kind: kind, Height: height, Key: key, Field: field, Stored: stored, Node: node})
+}
+
+// AuditBlock compare the block at the height with the node: the hashes of the block, the hashes and
+// the order of its transactions and their receipts, and its debts
+func (a *Auditor) AuditBlock(height uint64) error {
+	block, err := a.node.GetBlockByHeight(height, true)
+	if err != nil {
+		return err
+	}
+	a.Blocks++
+	stored, err := a.db.GetBlockByHeight(a.shardNumber, height)
+	if err == mgo.ErrNotFound {
+		return a.diff(KindBlock, height, block.Hash, "missing", true, false)
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := a.diff(KindBlock, height, block.Hash, "headHash", stored.HeadHash, block.Hash); err != nil {
+		return err
+	}
+	if err := a.diff(KindBlock, height, block.Hash, "preBlockHash", stored.PreHash, block.ParentHash); err != nil {
+		return err
+	}
+
+	var nodeTxs, blockTxs, storedTxs []string
+	for _, tx := range block.Txs {
+		nodeTxs = append(nodeTxs, tx.Hash)
+	}
+	for _, tx := range stored.Txs {
+		blockTxs = append(blockTxs, tx.Hash)
+	}
+	if err := a.diffList(KindBlock, height, block.Hash, "transactions", blockTxs, nodeTxs); err != nil {
+		return err
+	}
+
+	txs, err := a.db.GetTxsByBlock(a.shardNumber, height)
+	if err != nil {
+		return err
+	}
+	txByHash := make(map[string]*database.DBTx)
+	for _, tx := range txs {
+		storedTxs = append(storedTxs, tx.Hash)
+		txByHash[tx.Hash] = tx
+	}
+	if err := a.diffList(KindTx, height, block.Hash, "order", storedTxs, nodeTxs); err != nil {
+		return err
+	}
+
+	for _, tx := range block.Txs {
+		a.Txs++
+		storedTx, ok := txByHash[tx.Hash]
+		if !ok {
+			if err := a.diff(KindTx, height, tx.Hash, "missing", true, false); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := a.auditTx(height, storedTx, tx.From, tx.To, tx.Amount.Int64()); err != nil {
+			return err
+		}
+	}
+
+	return a.auditDebts(height, block.Hash, block.Debts)
+}
+
+// auditTx compare the transaction and its receipt with the node
+func (a *Auditor) auditTx(height uint64, tx *database.DBTx, from, to string, amount int64) error {
+	if err := a.diff(KindTx, height, tx.Hash, "from", tx.From, from); err != nil {
+		return err
+	}
+	if err := a.diff(KindTx, height, tx.Hash, "to", tx.To, to); err != nil {
+		return err
+	}
+	if err := a.diff(KindTx, height, tx.Hash, "amount", tx.Amount, amount); err != nil {
+		return err
+	}
+
+	receipt, err := a.node.GetReceiptByTxHash(tx.Hash)
+	if err != nil {
+		return err
+	}
+	if err := a.diff(KindReceipt, height, tx.Hash, "fee", tx.Fee, receipt.TotalFee); err != nil {
+		return err
+	}
+	if err := a.diff(KindReceipt, height, tx.Hash, "usedGas", tx.UsedGas, receipt.UsedGas); err != nil {
+		return err
+	}
+	// the receipt is stored for every transaction, the transactions synced before the receipts were
+	// stored differ until scan backfill receipts fills them
+	if err := a.diff(KindReceipt, height, tx.Hash, "txhash", tx.Receipt.TxHash, receipt.TxHash); err != nil {
+		return err
+	}
+	return a.diff(KindReceipt, height, tx.Hash, "failed", tx.Receipt.Failed, receipt.Failed)
+}
+
+// auditDebts compare the debts of the block with the node
+func (a *Auditor) auditDebts(height uint64, blockHash string, debts []rpc.Debt) error {
+	stored, err := a.db.GetDebtsByBlock(a.shardNumber, height)
+	if err != nil {
+		return err
+	}
+	var nodeDebts, storedDebts []string
+	for _, debt := range debts {
+		nodeDebts = append(nodeDebts, debt.Hash)
+	}
+	debtByHash := make(map[string]*database.Debt)
+	for _, debt := range stored {
+		storedDebts = append(storedDebts, debt.Hash)
+		debtByHash[debt.Hash] = debt
+	}
+	if err := a.diffList(KindBlock, height, blockHash, "debts", storedDebts, nodeDebts); err != nil {
+		return err
+	}
+
+	for _, debt := range debts {
+		a.Debts++
+		storedDebt, ok := debtByHash[debt.Hash]
+		if !ok {
+			if err := a.diff(KindDebt, height, debt.Hash, "missing", true, false); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := a.diff(KindDebt, height, debt.Hash, "txhash", storedDebt.TxHash, debt.TxHash); err != nil {
+			return err
+		}
+		if err := a.diff(KindDebt, height, debt.Hash, "to", storedDebt.To, debt.To); err != nil {
+			return err
+		}
+		if err := a.diff(KindDebt, height, debt.Hash, "amount", storedDebt.Amount, debt.Amount.Int64()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// AuditAccounts compare the stored balances of the accounts of the shard with the node, every account
+// if sample is negative or sample random accounts. The node returns the balances of its latest block,
+// an account changed after the synchronized height differs until it is synchronized.
+func (a *Auditor) AuditAccounts(sample int, rnd *rand.Rand) error {
+	if sample == 0 {
+		return nil
+	}
+
+	// every account is audited as it is read, the sampled ones are kept by a reservoir so that they are read once
+	var accounts []*database.DBAccount
+	var seen int
+	err := a.db.Export(database.EntityAccounts, a.shardNumber, 0, -1, nil, accountBatchSize, func(docs []interface{}, last []interface{}) error {
+		for _, doc := range docs {
+			account := doc.(*database.DBAccount)
+			if sample < 0 {
+				if err := a.auditAccount(account); err != nil {
+					return err
+				}
+				continue
+			}
+			seen++
+			if len(accounts) < sample {
+				accounts = append(accounts, account)
+			} else if i := rnd.Intn(seen); i < sample {
+				accounts[i] = account
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, account := range accounts {
+		if err := a.auditAccount(account); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// auditAccount compare the stored balance of the account with the node
+func (a *Auditor) auditAccount(account *database.DBAccount) error {
+	balance, err := a.node.GetBalance(account.Address)
+	if err != nil {
+		return err
+	}
+	a.Accounts++
+	return a.diff(KindAccount, 0, account.Address, "balance", account.Balance, balance)
+}
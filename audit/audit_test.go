@@ -0,0 +1,177 @@
+/**
+*  @file
+*  @copyright defined in scan-api/LICENSE
+ */
+
+package audit
+
+import (
+	"errors"
+	"math/big"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/seeleteam/scan-api/database"
+	"github.com/seeleteam/scan-api/log"
+	"github.com/seeleteam/scan-api/rpc"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeNode is a node of the blocks, receipts and balances
+type fakeNode struct {
+	blocks   map[uint64]*rpc.BlockInfo
+	receipts map[string]*rpc.Receipt
+	balances map[string]int64
+	calls    int
+}
+
+func (n *fakeNode) CurrentBlockHeight() (uint64, error) {
+	n.calls++
+	return uint64(len(n.blocks) - 1), nil
+}
+
+func (n *fakeNode) GetBlockByHeight(h uint64, fullTx bool) (*rpc.BlockInfo, error) {
+	n.calls++
+	if block, ok := n.blocks[h]; ok {
+		return block, nil
+	}
+	return nil, errors.New("block not found")
+}
+
+func (n *fakeNode) GetReceiptByTxHash(txhash string) (*rpc.Receipt, error) {
+	n.calls++
+	return n.receipts[txhash], nil
+}
+
+func (n *fakeNode) GetBalance(account string) (int64, error) {
+	n.calls++
+	return n.balances[account], nil
+}
+
+func newTestNode() *fakeNode {
+	node := &fakeNode{
+		blocks:   make(map[uint64]*rpc.BlockInfo),
+		receipts: make(map[string]*rpc.Receipt),
+		balances: map[string]int64{"0x01": 100, "0x02": 200},
+	}
+	for h := uint64(0); h < 3; h++ {
+		node.blocks[h] = &rpc.BlockInfo{
+			Hash:            string(rune('a'+h)) + "0",
+			ParentHash:      string(rune('a'+h-1)) + "0",
+			Height:          h,
+			Timestamp:       big.NewInt(1539931540 + int64(h)),
+			Difficulty:      big.NewInt(1),
+			TotalDifficulty: big.NewInt(1),
+			Txs: []rpc.Transaction{
+				{Hash: string(rune('a'+h)) + "1", From: "0x01", To: "0x02", Amount: big.NewInt(10)},
+				{Hash: string(rune('a'+h)) + "2", From: "0x02", To: "0x01", Amount: big.NewInt(20)},
+			},
+		}
+		for _, tx := range node.blocks[h].Txs {
+			node.receipts[tx.Hash] = &rpc.Receipt{TxHash: tx.Hash, TotalFee: 1, UsedGas: 21000}
+		}
+	}
+	node.blocks[2].Debts = []rpc.Debt{{Hash: "d1", TxHash: "a1", To: "0x03", Amount: big.NewInt(5)}}
+	return node
+}
+
+// syncTestNode store the blocks of the node like the syncer
+func syncTestNode(t *testing.T, c *database.Client, node *fakeNode) {
+	for h := uint64(0); h < uint64(len(node.blocks)); h++ {
+		block := node.blocks[h]
+		dbBlock := database.CreateDbBlock(block)
+		dbBlock.ShardNumber = 1
+		assert.Nil(t, c.AddBlock(dbBlock))
+		for i, tx := range block.Txs {
+			tx.Block = h
+			tx.Idx = h*10 + uint64(i)
+			dbTx := database.CreateDbTx(tx)
+			dbTx.ShardNumber = 1
+			dbTx.Fee = 1
+			dbTx.UsedGas = 21000
+			dbTx.Receipt = *node.receipts[tx.Hash]
+			assert.Nil(t, c.AddTxs(dbTx))
+		}
+		for _, debt := range block.Debts {
+			debt.Block = h
+			dbDebt := database.CreateDebtTx(debt)
+			dbDebt.ShardNumber = 1
+			assert.Nil(t, c.AddDebtTxs(dbDebt))
+		}
+	}
+	assert.Nil(t, c.AddAccount(&database.DBAccount{Address: "0x01", ShardNumber: 1, Balance: 100}))
+	assert.Nil(t, c.AddAccount(&database.DBAccount{Address: "0x02", ShardNumber: 1, Balance: 200}))
+}
+
+func Test_Audit(t *testing.T) {
+	log.NewLogger("", "error", false)
+	node := newTestNode()
+	c := database.NewMemoryClient(1)
+	syncTestNode(t, c, node)
+
+	var diffs []*Diff
+	auditor := New(c, node, 1, func(d *Diff) error {
+		diffs = append(diffs, d)
+		return nil
+	})
+	for _, h := range Heights(0, 2, 0, nil) {
+		assert.Nil(t, auditor.AuditBlock(h))
+	}
+	assert.Nil(t, auditor.AuditAccounts(-1, rand.New(rand.NewSource(1))))
+	assert.Equal(t, len(diffs), 0)
+	assert.Equal(t, auditor.Summary, Summary{Blocks: 3, Txs: 6, Debts: 1, Accounts: 2})
+
+	// the chain is reorganized at height 2 and the fee of a transaction of height 1 is changed
+	node.blocks[2].Hash = "c9"
+	node.blocks[2].Txs[0], node.blocks[2].Txs[1] = node.blocks[2].Txs[1], node.blocks[2].Txs[0]
+	node.blocks[2].Debts = nil
+	node.receipts["b1"].TotalFee = 2
+	node.receipts["b2"] = &rpc.Receipt{TxHash: "b2", TotalFee: 1, UsedGas: 21000, Failed: true}
+	node.balances["0x02"] = 150
+
+	diffs = nil
+	auditor = New(c, node, 1, func(d *Diff) error {
+		diffs = append(diffs, d)
+		return nil
+	})
+	for _, h := range Heights(1, 2, 0, nil) {
+		assert.Nil(t, auditor.AuditBlock(h))
+	}
+	assert.Nil(t, auditor.AuditAccounts(-1, rand.New(rand.NewSource(1))))
+
+	var fields []string
+	for _, d := range diffs {
+		fields = append(fields, d.Kind+"."+d.Field)
+	}
+	assert.Equal(t, fields, []string{"receipt.fee", "receipt.failed", "block.headHash", "block.transactions", "transaction.order", "block.debts", "account.balance"})
+	assert.Equal(t, diffs[0].Stored, int64(1))
+	assert.Equal(t, diffs[0].Node, int64(2))
+	assert.Equal(t, auditor.Diffs, int64(7))
+}
+
+func Test_Heights(t *testing.T) {
+	assert.Equal(t, Heights(3, 5, 0, nil), []uint64{3, 4, 5})
+	assert.Equal(t, Heights(3, 5, 10, nil), []uint64{3, 4, 5})
+	assert.Equal(t, len(Heights(5, 3, 0, nil)), 0)
+
+	heights := Heights(0, 1000, 20, rand.New(rand.NewSource(1)))
+	assert.Equal(t, len(heights), 20)
+	for i := 1; i < len(heights); i++ {
+		assert.True(t, heights[i-1] < heights[i])
+	}
+	// the same seed samples the same heights
+	assert.Equal(t, Heights(0, 1000, 20, rand.New(rand.NewSource(1))), heights)
+}
+
+func Test_LimitedNode(t *testing.T) {
+	node := newTestNode()
+	limited := NewLimitedNode(node, 100)
+	begin := time.Now()
+	for i := 0; i < 5; i++ {
+		limited.GetBalance("0x01")
+	}
+	assert.True(t, time.Since(begin) >= 40*time.Millisecond)
+	assert.Equal(t, node.calls, 5)
+	assert.Equal(t, NewLimitedNode(node, 0), Node(node))
+}
@@ -0,0 +1,17 @@
+/**
+*  @file
+*  @copyright defined in scan-api/LICENSE
+ */
+
+package audit
+
+import "github.com/seeleteam/scan-api/database"
+
+// Database wraps the read access to mongodb, the audit never writes
+type Database interface {
+	GetBlockHeight(shardNumber int) (uint64, error)
+	GetBlockByHeight(shardNumber int, height uint64) (*database.DBBlock, error)
+	GetTxsByBlock(shardNumber int, height uint64) ([]*database.DBTx, error)
+	GetDebtsByBlock(shardNumber int, height uint64) ([]*database.Debt, error)
+	Export(entity string, shardNumber int, from, to int64, after []interface{}, batchSize int, fn func(docs []interface{}, last []interface{}) error) error
+}
@@ -0,0 +1,70 @@
+/**
+*  @file
+*  @copyright defined in scan-api/LICENSE
+ */
+
+package audit
+
+import (
+	"sync"
+	"time"
+
+	"github.com/seeleteam/scan-api/rpc"
+)
+
+// Node wraps the calls to the seele node
+type Node interface {
+	CurrentBlockHeight() (uint64, error)
+	GetBlockByHeight(h uint64, fullTx bool) (*rpc.BlockInfo, error)
+	GetReceiptByTxHash(txhash string) (*rpc.Receipt, error)
+	GetBalance(account string) (int64, error)
+}
+
+// limitedNode is a node whose calls are spaced so that the node is not called more than rate times a second
+type limitedNode struct {
+	node     Node
+	interval time.Duration
+	mutex    sync.Mutex
+	next     time.Time
+}
+
+// NewLimitedNode return the node with its calls limited to rate a second, a rate which is not positive is unlimited
+func NewLimitedNode(node Node, rate float64) Node {
+	if rate <= 0 {
+		return node
+	}
+	return &limitedNode{node: node, interval: time.Duration(float64(time.Second) / rate)}
+}
+
+// wait block until the next call is allowed
+func (n *limitedNode) wait() {
+	n.mutex.Lock()
+	now := time.Now()
+	if n.next.Before(now) {
+		n.next = now
+	}
+	delay := n.next.Sub(now)
+	n.next = n.next.Add(n.interval)
+	n.mutex.Unlock()
+	time.Sleep(delay)
+}
+
+func (n *limitedNode) CurrentBlockHeight() (uint64, error) {
+	n.wait()
+	return n.node.CurrentBlockHeight()
+}
+
+func (n *limitedNode) GetBlockByHeight(h uint64, fullTx bool) (*rpc.BlockInfo, error) {
+	n.wait()
+	return n.node.GetBlockByHeight(h, fullTx)
+}
+
+func (n *limitedNode) GetReceiptByTxHash(txhash string) (*rpc.Receipt, error) {
+	n.wait()
+	return n.node.GetReceiptByTxHash(txhash)
+}
+
+func (n *limitedNode) GetBalance(account string) (int64, error) {
+	n.wait()
+	return n.node.GetBalance(account)
+}
@@ -79,11 +79,7 @@ func ProcessOneDayBlocks(shardNumber int, day time.Time) bool {
 
 	for i := 0; i < len(dbBlocks); i++ {
 		info.TotalBlocks++
-		txLen := len(dbBlocks[i].Txs)
-		if txLen > 0 {
-			tx := dbBlocks[i].Txs[txLen-1]
-			info.Rewards += tx.Amount
-		}
+		info.Rewards += dbBlocks[i].Reward
 	}
 
 	info.TimeStamp = lastDayZeroTime.Unix()
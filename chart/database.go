@@ -12,6 +12,8 @@ type ChartDB interface {
 	AddOneDayAddress(shardNumber int, t *database.DBOneDayAddressInfo) error
 	GetOneDayBlock(shardNumber int, zeroTime int64) (*database.DBOneDayBlockInfo, error)
 	AddOneDayBlock(shardNumber int, t *database.DBOneDayBlockInfo) error
+	GetOneDayMinerRevenue(shardNumber int, zeroTime int64) (*database.DBOneDayMinerRevenue, error)
+	AddOneDayMinerRevenue(shardNumber int, t *database.DBOneDayMinerRevenue) error
 	GetOneDayBlockDifficulty(shardNumber int, zeroTime int64) (*database.DBOneDayBlockDifficulty, error)
 	AddOneDayBlockDifficulty(shardNumber int, t *database.DBOneDayBlockDifficulty) error
 	GetOneDayBlockAvgTime(shardNumber int, zeroTime int64) (*database.DBOneDayBlockAvgTime, error)
@@ -0,0 +1,105 @@
+/**
+*  @file
+*  @copyright defined in scan-api/LICENSE
+ */
+
+package minerrevenue
+
+import (
+	"sync"
+	"time"
+
+	"github.com/seeleteam/scan-api/chart"
+	"github.com/seeleteam/scan-api/database"
+	mgo "gopkg.in/mgo.v2"
+)
+
+//Process set an timer to process miner revenue every day
+func Process(wg *sync.WaitGroup) {
+	defer wg.Done()
+	ProcessOldMinerRevenue()
+	for {
+		now := time.Now()
+		// calcuate next zero hour
+		next := now.Add(time.Hour * 24)
+		next = time.Date(next.Year(), next.Month(), next.Day(), 0, 0, 1, 0, next.Location())
+		t := time.NewTimer(next.Sub(now))
+		<-t.C
+		//calcuate last day miner revenue
+		for i := 1; i <= chart.ShardCount; i++ {
+			ProcessOneDayMinerRevenue(i, next)
+		}
+	}
+}
+
+//ProcessOldMinerRevenue Process miner revenue of the blocks mined in the past
+func ProcessOldMinerRevenue() {
+	for i := 1; i <= chart.ShardCount; i++ {
+		now := time.Now()
+		todayZeroTime := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+
+		for {
+			lastZeroTime := todayZeroTime.Add(-time.Hour * 24)
+			_, err := chart.GChartDB.GetOneDayMinerRevenue(i, lastZeroTime.Unix())
+			if err != mgo.ErrNotFound {
+				break
+			}
+			if !ProcessOneDayMinerRevenue(i, todayZeroTime) {
+				break
+			}
+			todayZeroTime = todayZeroTime.Add(-time.Hour * 24)
+		}
+	}
+}
+
+//ProcessOneDayMinerRevenue Process the revenue of every miner in an single day
+func ProcessOneDayMinerRevenue(shardNumber int, day time.Time) bool {
+	secondBlock, err := chart.GChartDB.GetBlockByHeight(shardNumber, 1)
+	if err != nil {
+		return false
+	}
+
+	if day.Unix() < secondBlock.Timestamp {
+		return false
+	}
+
+	thisZeroTime := time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, day.Location())
+	lastDayZeroTime := day.Add(-time.Hour * 24)
+	dbBlocks, err := chart.GChartDB.GetBlocksByTime(shardNumber, lastDayZeroTime.Unix(), thisZeroTime.Unix())
+	if err != nil {
+		return false
+	}
+
+	for _, info := range sumMinerRevenue(dbBlocks, lastDayZeroTime.Unix()) {
+		chart.GChartDB.AddOneDayMinerRevenue(shardNumber, info)
+	}
+	return true
+}
+
+//sumMinerRevenue sum the blocks and revenue breakdown by the block creator, keep the order of first mined
+func sumMinerRevenue(dbBlocks []*database.DBBlock, timestamp int64) []*database.DBOneDayMinerRevenue {
+	var infos []*database.DBOneDayMinerRevenue
+	miners := make(map[string]*database.DBOneDayMinerRevenue)
+	for _, block := range dbBlocks {
+		info, ok := miners[block.Creator]
+		if !ok {
+			info = &database.DBOneDayMinerRevenue{Address: block.Creator, TimeStamp: timestamp}
+			miners[block.Creator] = info
+			infos = append(infos, info)
+		}
+
+		if block.Pool != "" {
+			info.Pool = block.Pool
+		}
+		info.Blocks++
+		info.Reward += block.Reward
+		info.TxFee += block.TxFee
+		info.DebtFee += block.DebtFee
+		info.Revenue += block.Revenue()
+	}
+	return infos
+}
+
+func init() {
+	chart.RegisterProcessFunc(Process)
+}
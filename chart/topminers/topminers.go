@@ -68,15 +68,20 @@ func ProcessTopMiners(shardNumber int) bool {
 	}
 
 	miners := make(map[string]int)
+	pools := make(map[string]string)
 	for i := 0; i < len(dbBlocks); i++ {
 		block := dbBlocks[i]
 		miners[block.Creator]++
+		if block.Pool != "" {
+			pools[block.Creator] = block.Pool
+		}
 	}
 
 	var TopSevenDaysMiners MinerRankInfoSlice
 	for k, v := range miners {
 		miner := database.DBSingleMinerRankInfo{
 			Address:    k,
+			Pool:       pools[k],
 			Mined:      v,
 			Percentage: float64(v) / float64(len(dbBlocks)),
 		}
@@ -16,6 +16,7 @@ import (
 	_ "github.com/seeleteam/scan-api/chart/blockdifficulty"
 	_ "github.com/seeleteam/scan-api/chart/blocktime"
 	_ "github.com/seeleteam/scan-api/chart/hashrate"
+	_ "github.com/seeleteam/scan-api/chart/minerrevenue"
 	_ "github.com/seeleteam/scan-api/chart/topminers"
 	_ "github.com/seeleteam/scan-api/chart/txhistory"
 	"github.com/seeleteam/scan-api/database"
@@ -41,11 +42,16 @@ var rootCmd = &cobra.Command{
 			return
 		}
 
-		chart.GChartDB = database.NewDBClient(serverCfg.DataBase, 1)
-		if chart.GChartDB == nil {
+		dbClient := database.NewDBClient(serverCfg.DataBase, 1)
+		if dbClient == nil {
 			fmt.Printf("init database error")
 			return
 		}
+		if err := dbClient.CheckSchemaVersion(); err != nil {
+			fmt.Printf("check database schema failed %s", err.Error())
+			return
+		}
+		chart.GChartDB = dbClient
 
 		chart.ShardCount = serverCfg.ShardCount
 		processFuncs := chart.GetProcessFuncs()
@@ -38,6 +38,10 @@ var rootCmd = &cobra.Command{
 			fmt.Printf("init database error")
 			return
 		}
+		if err := dbClient.CheckSchemaVersion(); err != nil {
+			fmt.Printf("check database schema failed %s", err.Error())
+			return
+		}
 
 		var wg sync.WaitGroup
 		nodeService := node.New(&config, dbClient)
@@ -0,0 +1,124 @@
+/**
+*  @file
+*  @copyright defined in scan-api/LICENSE
+ */
+
+package cmd
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/seeleteam/scan-api/database"
+	"github.com/spf13/cobra"
+)
+
+var (
+	keyName  *string
+	keyTier  *string
+	keyAdmin *bool
+)
+
+// apiKeyCmd is the parent of the api key commands, scan_server reloads the keys every 10 seconds
+var apiKeyCmd = &cobra.Command{
+	Use:   "apikey",
+	Short: "manage the api keys of scan_server",
+}
+
+// createKeyCmd create a random api key of a tier
+var createKeyCmd = &cobra.Command{
+	Use:   "create",
+	Short: "create an api key, the tiers are configured in RateLimit of scan_server",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dbClient, err := openDatabase()
+		if err != nil {
+			return err
+		}
+
+		secret := make([]byte, 16)
+		if _, err := rand.Read(secret); err != nil {
+			return err
+		}
+		key := &database.DBAPIKey{
+			Key:        hex.EncodeToString(secret),
+			Name:       *keyName,
+			Tier:       *keyTier,
+			Admin:      *keyAdmin,
+			CreateTime: time.Now().Unix(),
+		}
+		if err := dbClient.AddAPIKey(key); err != nil {
+			return err
+		}
+		fmt.Println(key.Key)
+		return nil
+	},
+}
+
+// listKeysCmd print the api keys
+var listKeysCmd = &cobra.Command{
+	Use:   "list",
+	Short: "list the api keys",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dbClient, err := openDatabase()
+		if err != nil {
+			return err
+		}
+
+		keys, err := dbClient.GetAPIKeys()
+		if err != nil {
+			return err
+		}
+		for _, key := range keys {
+			state := "enabled"
+			if key.Disabled {
+				state = "disabled"
+			}
+			role := ""
+			if key.Admin {
+				role = "admin"
+			}
+			fmt.Printf("%s %-8s %-10s %-5s %s %s\n", key.Key, state, key.Tier, role,
+				time.Unix(key.CreateTime, 0).UTC().Format("2006-01-02"), key.Name)
+		}
+		return nil
+	},
+}
+
+// disableKeyCmd refuse the requests of an api key
+var disableKeyCmd = &cobra.Command{
+	Use:   "disable <key>",
+	Short: "disable an api key, its requests are refused",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return setKeyDisabled(args[0], true)
+	},
+}
+
+// enableKeyCmd accept the requests of a disabled api key again
+var enableKeyCmd = &cobra.Command{
+	Use:   "enable <key>",
+	Short: "enable a disabled api key",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return setKeyDisabled(args[0], false)
+	},
+}
+
+func setKeyDisabled(key string, disabled bool) error {
+	dbClient, err := openDatabase()
+	if err != nil {
+		return err
+	}
+	return dbClient.SetAPIKeyDisabled(key, disabled)
+}
+
+func init() {
+	keyName = createKeyCmd.Flags().String("name", "", "the owner of the key")
+	keyTier = createKeyCmd.Flags().String("tier", "", "the tier of the key (required)")
+	keyAdmin = createKeyCmd.Flags().Bool("admin", false, "the key can read the usage of all the keys")
+	createKeyCmd.MarkFlagRequired("tier")
+	apiKeyCmd.AddCommand(createKeyCmd, listKeysCmd, disableKeyCmd, enableKeyCmd)
+	rootCmd.AddCommand(apiKeyCmd)
+}
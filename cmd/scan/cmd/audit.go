@@ -0,0 +1,139 @@
+/**
+*  @file
+*  @copyright defined in scan-api/LICENSE
+ */
+
+package cmd
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"os"
+	"time"
+
+	"github.com/seeleteam/scan-api/audit"
+	"github.com/seeleteam/scan-api/rpc"
+	"github.com/spf13/cobra"
+)
+
+var (
+	auditShard    *int
+	auditFrom     *int64
+	auditTo       *int64
+	auditSample   *int
+	auditAccounts *int
+	auditRPC      *string
+	auditRate     *float64
+	auditOut      *string
+	auditSeed     *int64
+)
+
+// auditCmd compare the indexed blocks, transactions, debts and balances of a shard with the node
+var auditCmd = &cobra.Command{
+	Use:   "audit",
+	Short: "compare the indexed blocks, transactions, receipts, debts and balances of a shard with the node, read only",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := loadConfig()
+		if err != nil {
+			return err
+		}
+		// the audit runs against production, nothing is written to the database
+		cfg.DataBase.EnsureIndexes = false
+		dbClient, err := connectDatabase(cfg)
+		if err != nil {
+			return err
+		}
+
+		url := *auditRPC
+		if url == "" {
+			url = cfg.RPCNodes[*auditShard]
+		}
+		if url == "" {
+			return fmt.Errorf("no node of shard %d, set it with --rpc or RPCNodes in the config", *auditShard)
+		}
+		seeleRPC := rpc.NewRPC(url)
+		if err := seeleRPC.Connect(); err != nil {
+			return fmt.Errorf("connect to node %s failed %s", url, err)
+		}
+		defer seeleRPC.Release()
+		node := audit.NewLimitedNode(seeleRPC, *auditRate)
+
+		// the blocks which are not synchronized or not produced yet are not audited
+		synced, err := dbClient.GetBlockHeight(*auditShard)
+		if err != nil {
+			return err
+		}
+		nodeHeight, err := node.CurrentBlockHeight()
+		if err != nil {
+			return err
+		}
+		to := *auditTo
+		if to < 0 {
+			to = int64(synced) - 1
+		}
+		if to > int64(nodeHeight) {
+			to = int64(nodeHeight)
+		}
+
+		out := os.Stdout
+		if *auditOut != "" {
+			if out, err = os.Create(*auditOut); err != nil {
+				return err
+			}
+			defer out.Close()
+		}
+		w := bufio.NewWriter(out)
+		defer w.Flush()
+		encoder := json.NewEncoder(w)
+		auditor := audit.New(dbClient, node, *auditShard, func(d *audit.Diff) error {
+			return encoder.Encode(d)
+		})
+
+		seed := *auditSeed
+		if seed == 0 {
+			seed = time.Now().UnixNano()
+		}
+		rnd := rand.New(rand.NewSource(seed))
+
+		var heights []uint64
+		if to >= *auditFrom && *auditFrom >= 0 {
+			heights = audit.Heights(uint64(*auditFrom), uint64(to), *auditSample, rnd)
+		}
+		fmt.Fprintf(os.Stderr, "auditing %d blocks of shard %d in [%d, %d], seed %d\n", len(heights), *auditShard, *auditFrom, to, seed)
+		for i, height := range heights {
+			if err := auditor.AuditBlock(height); err != nil {
+				return fmt.Errorf("audit block %d failed %s", height, err)
+			}
+			if (i+1)%1000 == 0 {
+				w.Flush()
+				fmt.Fprintf(os.Stderr, "%d blocks are audited, %d differences\n", i+1, auditor.Diffs)
+			}
+		}
+		if err := auditor.AuditAccounts(*auditAccounts, rnd); err != nil {
+			return fmt.Errorf("audit accounts failed %s", err)
+		}
+
+		fmt.Fprintf(os.Stderr, "%d blocks, %d transactions, %d debts and %d accounts are audited\n",
+			auditor.Blocks, auditor.Txs, auditor.Debts, auditor.Accounts)
+		if auditor.Diffs > 0 {
+			return fmt.Errorf("%d differences are found", auditor.Diffs)
+		}
+		fmt.Fprintln(os.Stderr, "no difference is found")
+		return nil
+	},
+}
+
+func init() {
+	auditShard = auditCmd.Flags().IntP("shard", "s", 1, "the shard number to audit")
+	auditFrom = auditCmd.Flags().Int64("from", 0, "the first block height to audit")
+	auditTo = auditCmd.Flags().Int64("to", -1, "the last block height to audit, negative for the last synchronized block")
+	auditSample = auditCmd.Flags().Int("sample", 0, "the number of random heights to audit in the range, 0 audits every height")
+	auditAccounts = auditCmd.Flags().Int("accounts", 100, "the number of random accounts whose balance is audited, negative for all the accounts")
+	auditRPC = auditCmd.Flags().String("rpc", "", "the node of the shard, RPCNodes of the config by default")
+	auditRate = auditCmd.Flags().Float64("rate", 20, "the max number of node calls a second, 0 is unlimited")
+	auditOut = auditCmd.Flags().StringP("out", "o", "", "the report file of the differences as json lines, stdout if empty")
+	auditSeed = auditCmd.Flags().Int64("seed", 0, "the seed of the sampling to audit the same heights again, random if 0")
+	rootCmd.AddCommand(auditCmd)
+}
@@ -0,0 +1,106 @@
+/**
+*  @file
+*  @copyright defined in scan-api/LICENSE
+ */
+
+package cmd
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/seeleteam/scan-api/audit"
+	"github.com/seeleteam/scan-api/database"
+	"github.com/seeleteam/scan-api/rpc"
+	"github.com/spf13/cobra"
+)
+
+var (
+	backfillShard     *int
+	backfillRPC       *string
+	backfillRate      *float64
+	backfillBatchSize *int
+	backfillRestart   *bool
+	backfillOut       *string
+)
+
+// missingReceipt is a line of the report of the receipts the node failed to return
+type missingReceipt struct {
+	Hash  string `json:"hash"`
+	Block uint64 `json:"block"`
+	Error string `json:"error"`
+}
+
+// backfillCmd is the parent of the commands which backfill the data stored before it was indexed
+var backfillCmd = &cobra.Command{
+	Use:   "backfill",
+	Short: "backfill the data of the chain stored before it was indexed",
+}
+
+// receiptsCmd fill the receipts of the transactions of a shard synced before they were stored and flag the failed ones
+var receiptsCmd = &cobra.Command{
+	Use:   "receipts",
+	Short: "backfill the receipts of the transactions of a shard synced before they were stored and flag the failed ones, resumable",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := loadConfig()
+		if err != nil {
+			return err
+		}
+		dbClient, err := connectDatabase(cfg)
+		if err != nil {
+			return err
+		}
+
+		url := *backfillRPC
+		if url == "" {
+			url = cfg.RPCNodes[*backfillShard]
+		}
+		if url == "" {
+			return fmt.Errorf("no node of shard %d, set it with --rpc or RPCNodes in the config", *backfillShard)
+		}
+		seeleRPC := rpc.NewRPC(url)
+		if err := seeleRPC.Connect(); err != nil {
+			return fmt.Errorf("connect to node %s failed %s", url, err)
+		}
+		defer seeleRPC.Release()
+		node := audit.NewLimitedNode(seeleRPC, *backfillRate)
+
+		out := os.Stdout
+		if *backfillOut != "" {
+			// the report of a resumed backfill is appended
+			if out, err = os.OpenFile(*backfillOut, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644); err != nil {
+				return err
+			}
+			defer out.Close()
+		}
+		w := bufio.NewWriter(out)
+		defer w.Flush()
+		encoder := json.NewEncoder(w)
+
+		backfill, err := dbClient.BackfillReceipts(*backfillShard, node, *backfillBatchSize, *backfillRestart, func(tx *database.DBTx, err error) error {
+			return encoder.Encode(&missingReceipt{Hash: tx.Hash, Block: tx.Block, Error: err.Error()})
+		})
+		if err != nil {
+			return fmt.Errorf("backfill the receipts failed %s, run again to resume", err)
+		}
+		fmt.Fprintf(os.Stderr, "%d transactions of shard %d are scanned, %d receipts are filled, %d are missing, %d failed transactions are flagged\n",
+			backfill.Scanned, *backfillShard, backfill.Filled, backfill.Missing, backfill.Flagged)
+		if backfill.Missing > 0 {
+			fmt.Fprintln(os.Stderr, "run again to fetch the missing receipts")
+		}
+		return nil
+	},
+}
+
+func init() {
+	backfillShard = receiptsCmd.Flags().IntP("shard", "s", 1, "the shard number to backfill")
+	backfillRPC = receiptsCmd.Flags().String("rpc", "", "the node of the shard, RPCNodes of the config by default")
+	backfillRate = receiptsCmd.Flags().Float64("rate", 20, "the max number of node calls a second, 0 is unlimited")
+	backfillBatchSize = receiptsCmd.Flags().IntP("batch", "b", 1000, "the number of transactions scanned in a batch")
+	backfillRestart = receiptsCmd.Flags().Bool("restart", false, "start again from the first transaction instead of resuming")
+	backfillOut = receiptsCmd.Flags().StringP("out", "o", "", "the report of the missing receipts as json lines, stdout if empty")
+	backfillCmd.AddCommand(receiptsCmd)
+	rootCmd.AddCommand(backfillCmd)
+}
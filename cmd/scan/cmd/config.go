@@ -0,0 +1,38 @@
+/**
+*  @file
+*  @copyright defined in scan-api/LICENSE
+ */
+
+package cmd
+
+import (
+	"encoding/json"
+	"io/ioutil"
+
+	"github.com/seeleteam/scan-api/common"
+)
+
+var (
+	configFile *string
+)
+
+// Config is the config of the maintenance commands
+type Config struct {
+	WriteLog bool
+	LogLevel string
+	LogFile  string
+	DataBase *common.DataBaseConfig
+	RPCNodes map[int]string // the node of each shard, used by audit and the migrations
+}
+
+// LoadConfigFromFile unmarshal config from a file
+func LoadConfigFromFile(filepath string) (*Config, error) {
+	var config Config
+	buff, err := ioutil.ReadFile(filepath)
+	if err != nil {
+		return nil, err
+	}
+
+	err = json.Unmarshal(buff, &config)
+	return &config, err
+}
@@ -0,0 +1,349 @@
+/**
+*  @file
+*  @copyright defined in scan-api/LICENSE
+ */
+
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/seeleteam/scan-api/database"
+	"github.com/spf13/cobra"
+)
+
+// the formats of the exported files
+const (
+	formatJSONL = "jsonl"
+	formatCSV   = "csv"
+)
+
+// checkpointFile is the name of the checkpoint in the output directory
+const checkpointFile = "export.checkpoint.json"
+
+var (
+	exportShard      *int
+	exportFrom       *int64
+	exportTo         *int64
+	exportEntities   *string
+	exportFormat     *string
+	exportOut        *string
+	exportCheckpoint *string
+	exportBatchSize  *int
+)
+
+// exportProgress is the progress of an entity
+type exportProgress struct {
+	Last   []interface{} `json:"last,omitempty"` // the sort keys of the last exported document
+	Count  int64         `json:"count"`
+	Offset int64         `json:"offset"` // the size of the file after the last exported document
+	Done   bool          `json:"done"`
+}
+
+// exportState is the checkpoint of an export, an export with the same parameters resumes from it
+type exportState struct {
+	Shard    int                        `json:"shard"`
+	From     int64                      `json:"from"`
+	To       int64                      `json:"to"`
+	Format   string                     `json:"format"`
+	Entities map[string]*exportProgress `json:"entities"`
+}
+
+// loadExportState read the checkpoint, a missing checkpoint is a new export
+func loadExportState(path string, want *exportState) (*exportState, error) {
+	if path == "" {
+		return want, nil
+	}
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return want, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	state := new(exportState)
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(state); err != nil {
+		return nil, fmt.Errorf("read checkpoint %s failed %s", path, err)
+	}
+	if state.Shard != want.Shard || state.From != want.From || state.To != want.To || state.Format != want.Format {
+		return nil, fmt.Errorf("checkpoint %s is of another export, remove it to start again", path)
+	}
+	if state.Entities == nil {
+		state.Entities = make(map[string]*exportProgress)
+	}
+	// the sort keys are heights, indexes, hashes and addresses
+	for _, progress := range state.Entities {
+		for i, key := range progress.Last {
+			if n, ok := key.(json.Number); ok {
+				if progress.Last[i], err = n.Int64(); err != nil {
+					return nil, fmt.Errorf("invalid sort key %s in checkpoint %s", n, path)
+				}
+			}
+		}
+	}
+	return state, nil
+}
+
+// save write the checkpoint, it is replaced by rename so that an interrupted write keeps the previous one
+func (s *exportState) save(path string) error {
+	if path == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := ioutil.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// countingWriter count the bytes written to the file of an entity
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (cw *countingWriter) Write(p []byte) (int, error) {
+	n, err := cw.w.Write(p)
+	cw.n += int64(n)
+	return n, err
+}
+
+// openExportFile open the file of an entity, a resumed export drops what was written after the
+// checkpoint, so a batch interrupted before its checkpoint is not written twice
+func openExportFile(path string, progress *exportProgress, resumed bool) (*os.File, error) {
+	if !resumed {
+		return os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	}
+	if progress.Count > 0 && progress.Offset == 0 {
+		return nil, fmt.Errorf("the checkpoint has no offset of %s, remove it to start again", path)
+	}
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, err
+	}
+	info, err := file.Stat()
+	if err == nil && info.Size() < progress.Offset {
+		err = fmt.Errorf("%s is shorter than its checkpoint, remove the checkpoint to start again", path)
+	}
+	if err == nil {
+		err = file.Truncate(progress.Offset)
+	}
+	if err == nil {
+		_, err = file.Seek(progress.Offset, io.SeekStart)
+	}
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	return file, nil
+}
+
+// recordWriter write the documents of an entity in the format
+type recordWriter struct {
+	entity string
+	format string
+	buf    *bufio.Writer
+	csv    *csv.Writer
+}
+
+func newRecordWriter(w io.Writer, entity string, format string, header bool) (*recordWriter, error) {
+	rw := &recordWriter{entity: entity, format: format, buf: bufio.NewWriter(w)}
+	if format == formatCSV {
+		rw.csv = csv.NewWriter(rw.buf)
+		if header {
+			columns, err := database.Columns(entity)
+			if err != nil {
+				return nil, err
+			}
+			if err := rw.csv.Write(columns); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return rw, nil
+}
+
+func (rw *recordWriter) write(doc interface{}) error {
+	if rw.format == formatCSV {
+		record, err := database.EncodeCSV(rw.entity, doc)
+		if err != nil {
+			return err
+		}
+		return rw.csv.Write(record)
+	}
+
+	line, err := database.EncodeJSON(doc)
+	if err != nil {
+		return err
+	}
+	rw.buf.Write(line)
+	return rw.buf.WriteByte('\n')
+}
+
+func (rw *recordWriter) flush() error {
+	if rw.csv != nil {
+		rw.csv.Flush()
+		if err := rw.csv.Error(); err != nil {
+			return err
+		}
+	}
+	return rw.buf.Flush()
+}
+
+// parseEntities split the entities flag and check the names
+func parseEntities(s string) ([]string, error) {
+	var entities []string
+	for _, entity := range strings.Split(s, ",") {
+		entity = strings.TrimSpace(entity)
+		if entity == "" {
+			continue
+		}
+		known := false
+		for _, name := range database.Entities() {
+			known = known || name == entity
+		}
+		if !known {
+			return nil, fmt.Errorf("unknown entity %s, the entities are %s", entity, strings.Join(database.Entities(), ","))
+		}
+		entities = append(entities, entity)
+	}
+	if len(entities) == 0 {
+		return nil, errors.New("no entity to export")
+	}
+	return entities, nil
+}
+
+// exportCmd write the blocks, transactions, debts, accounts and miners of a shard to files or stdout
+var exportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "export the blocks, transactions, debts, accounts and miners of a shard in height order",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		entities, err := parseEntities(*exportEntities)
+		if err != nil {
+			return err
+		}
+		if *exportFormat != formatJSONL && *exportFormat != formatCSV {
+			return fmt.Errorf("unknown format %s, the formats are %s and %s", *exportFormat, formatJSONL, formatCSV)
+		}
+		if *exportOut == "" && len(entities) > 1 {
+			return errors.New("only one entity is exported to stdout, set the output directory with --out")
+		}
+
+		checkpoint := *exportCheckpoint
+		if checkpoint == "" && *exportOut != "" {
+			checkpoint = filepath.Join(*exportOut, checkpointFile)
+		}
+		if *exportOut != "" {
+			if err := os.MkdirAll(*exportOut, 0755); err != nil {
+				return err
+			}
+		}
+		state, err := loadExportState(checkpoint, &exportState{
+			Shard:    *exportShard,
+			From:     *exportFrom,
+			To:       *exportTo,
+			Format:   *exportFormat,
+			Entities: make(map[string]*exportProgress),
+		})
+		if err != nil {
+			return err
+		}
+
+		dbClient, err := openDatabase()
+		if err != nil {
+			return err
+		}
+
+		for _, entity := range entities {
+			progress, resumed := state.Entities[entity]
+			if !resumed {
+				progress = new(exportProgress)
+				state.Entities[entity] = progress
+			}
+			if progress.Done {
+				fmt.Fprintf(os.Stderr, "%s are exported already\n", entity)
+				continue
+			}
+
+			out := os.Stdout
+			if *exportOut != "" {
+				// a resumed export continues the file written before from the checkpoint
+				out, err = openExportFile(filepath.Join(*exportOut, entity+"."+*exportFormat), progress, resumed)
+				if err != nil {
+					return err
+				}
+			}
+			err = exportEntity(dbClient, state, entity, progress, out, !resumed, checkpoint)
+			if out != os.Stdout {
+				out.Close()
+			}
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(os.Stderr, "%d %s are exported\n", progress.Count, entity)
+		}
+		return nil
+	},
+}
+
+// exportEntity write the documents of the entity after the progress and record the progress after every batch
+func exportEntity(dbClient *database.Client, state *exportState, entity string, progress *exportProgress, out io.Writer, header bool, checkpoint string) error {
+	cw := &countingWriter{w: out, n: progress.Offset}
+	rw, err := newRecordWriter(cw, entity, state.Format, header)
+	if err != nil {
+		return err
+	}
+	err = dbClient.Export(entity, state.Shard, state.From, state.To, progress.Last, *exportBatchSize, func(docs []interface{}, last []interface{}) error {
+		for _, doc := range docs {
+			if err := rw.write(doc); err != nil {
+				return err
+			}
+		}
+		// the batch is written out before the checkpoint passes it
+		if err := rw.flush(); err != nil {
+			return err
+		}
+		progress.Last = last
+		progress.Count += int64(len(docs))
+		progress.Offset = cw.n
+		return state.save(checkpoint)
+	})
+	if err != nil {
+		return err
+	}
+	if err := rw.flush(); err != nil {
+		return err
+	}
+	progress.Offset = cw.n
+	progress.Done = true
+	return state.save(checkpoint)
+}
+
+func init() {
+	exportShard = exportCmd.Flags().IntP("shard", "s", 1, "the shard number to export")
+	exportFrom = exportCmd.Flags().Int64("from", 0, "the first block height to export")
+	exportTo = exportCmd.Flags().Int64("to", -1, "the last block height to export, negative for the latest block")
+	exportEntities = exportCmd.Flags().StringP("entities", "e", strings.Join(database.Entities(), ","), "the entities to export, separated by commas")
+	exportFormat = exportCmd.Flags().StringP("format", "f", formatJSONL, "the format of the output, jsonl or csv")
+	exportOut = exportCmd.Flags().StringP("out", "o", "", "the directory of the output files, one file per entity, stdout if empty")
+	exportCheckpoint = exportCmd.Flags().String("checkpoint", "", "the checkpoint file to resume from, <out>/"+checkpointFile+" by default")
+	exportBatchSize = exportCmd.Flags().IntP("batch", "b", 1000, "the number of documents read in a batch")
+	rootCmd.AddCommand(exportCmd)
+}
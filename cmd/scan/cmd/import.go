@@ -0,0 +1,191 @@
+/**
+*  @file
+*  @copyright defined in scan-api/LICENSE
+ */
+
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/seeleteam/scan-api/database"
+	"github.com/spf13/cobra"
+	"gopkg.in/mgo.v2"
+)
+
+// maxLineSize is the size limit of a line of the dumps, a block with its transactions is a line
+const maxLineSize = 64 * 1024 * 1024
+
+var (
+	importShard     *int
+	importIn        *string
+	importEntities  *string
+	importBatchSize *int
+)
+
+// importCmd load the jsonl dumps written by export into the database
+var importCmd = &cobra.Command{
+	Use:   "import",
+	Short: "import the jsonl dumps of the blocks, transactions, debts, accounts and miners of a shard, stop the syncer of the shard first",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		entities, err := parseEntities(*importEntities)
+		if err != nil {
+			return err
+		}
+		if *importIn == "" {
+			return fmt.Errorf("set the directory of the dumps with --in")
+		}
+
+		dbClient, err := openDatabase()
+		if err != nil {
+			return err
+		}
+		// the upserts of the import use the indexes
+		if err := dbClient.EnsureIndexes(); err != nil {
+			return err
+		}
+
+		for _, entity := range entities {
+			path := filepath.Join(*importIn, entity+"."+formatJSONL)
+			if _, err := os.Stat(path); os.IsNotExist(err) {
+				fmt.Printf("%s has no dump %s\n", entity, path)
+				continue
+			}
+			count, err := importEntity(dbClient, entity, path)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("%d %s are imported\n", count, entity)
+		}
+
+		drifts, err := dbClient.Recount(true, *importBatchSize)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%d counters are rebuilt\n", len(drifts))
+		if err := dbClient.RecountSupply(*importBatchSize); err != nil {
+			return err
+		}
+		fmt.Println("the supply is rebuilt from the blocks")
+
+		height, err := dbClient.GetBlockHeight(*importShard)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("the syncer of shard %d continues from block %d\n", *importShard, height)
+		return nil
+	},
+}
+
+// importEntity write the documents of the dump in batches, the blocks must continue each other
+func importEntity(dbClient *database.Client, entity string, path string) (int64, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+
+	var count int64
+	var parent *database.DBBlock
+	var docs []interface{}
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxLineSize)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		doc, err := database.DecodeJSON(entity, scanner.Bytes())
+		if err != nil {
+			return count, fmt.Errorf("%s line %d: %s", path, line, err)
+		}
+		if shard := documentShard(doc); shard != *importShard {
+			return count, fmt.Errorf("%s line %d: the document is of shard %d instead of %d", path, line, shard, *importShard)
+		}
+		if block, ok := doc.(*database.DBBlock); ok {
+			if parent, err = checkParent(dbClient, parent, block); err != nil {
+				return count, fmt.Errorf("%s line %d: %s", path, line, err)
+			}
+		}
+
+		docs = append(docs, doc)
+		if len(docs) >= *importBatchSize {
+			if err := dbClient.Import(entity, docs); err != nil {
+				return count, err
+			}
+			count += int64(len(docs))
+			docs = nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return count, err
+	}
+	if len(docs) > 0 {
+		if err := dbClient.Import(entity, docs); err != nil {
+			return count, err
+		}
+		count += int64(len(docs))
+	}
+	return count, nil
+}
+
+// documentShard return the shard number of a document of the dumps
+func documentShard(doc interface{}) int {
+	switch d := doc.(type) {
+	case *database.DBBlock:
+		return d.ShardNumber
+	case *database.DBTx:
+		return d.ShardNumber
+	case *database.Debt:
+		return d.ShardNumber
+	case *database.DBAccount:
+		return d.ShardNumber
+	case *database.DBMiner:
+		return d.ShardNumber
+	}
+	return -1
+}
+
+// checkParent check that the block follows its parent, the parent of the first block of the dump
+// is the stored one, which must exist unless the shard has no block yet. The block is returned as
+// the parent of the next block.
+func checkParent(dbClient *database.Client, parent *database.DBBlock, block *database.DBBlock) (*database.DBBlock, error) {
+	if parent == nil && block.Height > 0 {
+		stored, err := dbClient.GetBlockByHeight(block.ShardNumber, uint64(block.Height-1))
+		if err == nil {
+			parent = stored
+		} else if err != mgo.ErrNotFound {
+			return nil, err
+		} else {
+			// a dump starting above the stored blocks would leave a gap
+			height, err := dbClient.GetBlockHeight(block.ShardNumber)
+			if err != nil {
+				return nil, err
+			}
+			if height > 0 {
+				return nil, fmt.Errorf("the parent of block %d is not stored, the dump must start at or below block %d", block.Height, height)
+			}
+		}
+	}
+	if parent == nil {
+		return block, nil
+	}
+	if block.Height != parent.Height+1 {
+		return nil, fmt.Errorf("block %d follows block %d", block.Height, parent.Height)
+	}
+	if block.PreHash != parent.HeadHash {
+		return nil, fmt.Errorf("the parent hash %s of block %d is not the hash %s of block %d", block.PreHash, block.Height, parent.HeadHash, parent.Height)
+	}
+	return block, nil
+}
+
+func init() {
+	importShard = importCmd.Flags().IntP("shard", "s", 1, "the shard number of the dumps")
+	importIn = importCmd.Flags().StringP("in", "i", "", "the directory of the dumps, <entity>.jsonl for each entity")
+	importEntities = importCmd.Flags().StringP("entities", "e", strings.Join(database.Entities(), ","), "the entities to import, separated by commas")
+	importBatchSize = importCmd.Flags().IntP("batch", "b", 1000, "the number of documents written in a batch")
+	rootCmd.AddCommand(importCmd)
+}
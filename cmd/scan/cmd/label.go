@@ -0,0 +1,78 @@
+/**
+*  @file
+*  @copyright defined in scan-api/LICENSE
+ */
+
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/seeleteam/scan-api/database"
+	"github.com/spf13/cobra"
+)
+
+var labelKind *string
+
+// labelCmd is the parent of the label commands, the labels are searched by name and suggested by prefix
+var labelCmd = &cobra.Command{
+	Use:   "label",
+	Short: "manage the labels and the token symbols of the addresses searched by scan_server",
+}
+
+// setLabelCmd add or replace the label of a kind of an address
+var setLabelCmd = &cobra.Command{
+	Use:   "set <address> <name>",
+	Short: "set the label of an address, the label of the same kind is replaced",
+	Args:  cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dbClient, err := openDatabase()
+		if err != nil {
+			return err
+		}
+		return dbClient.SetLabel(&database.DBLabel{Address: strings.ToLower(args[0]), Kind: *labelKind, Name: args[1]})
+	},
+}
+
+// removeLabelCmd remove the label of a kind of an address
+var removeLabelCmd = &cobra.Command{
+	Use:   "remove <address>",
+	Short: "remove the label of an address",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dbClient, err := openDatabase()
+		if err != nil {
+			return err
+		}
+		return dbClient.RemoveLabel(strings.ToLower(args[0]), *labelKind)
+	},
+}
+
+// listLabelsCmd print the labels
+var listLabelsCmd = &cobra.Command{
+	Use:   "list",
+	Short: "list the labels",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dbClient, err := openDatabase()
+		if err != nil {
+			return err
+		}
+
+		labels, err := dbClient.GetLabels()
+		if err != nil {
+			return err
+		}
+		for _, label := range labels {
+			fmt.Printf("%s %-8s %s\n", label.Address, label.Kind, label.Name)
+		}
+		return nil
+	},
+}
+
+func init() {
+	labelKind = labelCmd.PersistentFlags().String("kind", database.LabelName,
+		"the kind of the label, "+database.LabelName+", "+database.LabelToken+" for a token symbol or "+database.LabelContract+" for a contract name")
+	labelCmd.AddCommand(setLabelCmd, removeLabelCmd, listLabelsCmd)
+	rootCmd.AddCommand(labelCmd)
+}
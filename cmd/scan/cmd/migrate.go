@@ -0,0 +1,177 @@
+/**
+*  @file
+*  @copyright defined in scan-api/LICENSE
+ */
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/seeleteam/scan-api/database"
+	"github.com/seeleteam/scan-api/rpc"
+	"github.com/spf13/cobra"
+)
+
+var (
+	dryRun    *bool
+	batchSize *int
+)
+
+// migrateCmd is the parent of the database migration commands
+var migrateCmd = &cobra.Command{
+	Use:   "migrate",
+	Short: "migrate the database",
+}
+
+// indexesCmd create the missing indexes and report the indexes which are not declared
+var indexesCmd = &cobra.Command{
+	Use:   "indexes",
+	Short: "ensure the declared indexes and report the index drift",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dbClient, err := openDatabase()
+		if err != nil {
+			return err
+		}
+
+		drifts, err := dbClient.GetIndexDrifts()
+		if err != nil {
+			return err
+		}
+		printIndexDrifts(drifts)
+		if *dryRun {
+			return nil
+		}
+
+		if err := dbClient.EnsureIndexes(); err != nil {
+			return err
+		}
+		fmt.Println("declared indexes are ensured, extra indexes are kept")
+		return nil
+	},
+}
+
+// statusCmd print the schema version of the database and the migrations
+var statusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "show the schema version and the migrations",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dbClient, err := openDatabase()
+		if err != nil {
+			return err
+		}
+
+		schema, err := dbClient.GetSchema()
+		if err != nil {
+			return err
+		}
+		fmt.Printf("database schema version %d, required version %d\n", schema.Version, database.SchemaVersion())
+		if schema.Target != schema.Version {
+			fmt.Printf("the migration to version %d is interrupted, it is resumed by the next run\n", schema.Target)
+		}
+		for _, migration := range database.Migrations() {
+			state := "pending"
+			if migration.Version <= schema.Version {
+				state = "applied"
+			}
+			fmt.Printf("%3d %-8s %s\n", migration.Version, state, migration.Description)
+		}
+		return nil
+	},
+}
+
+// upCmd migrate the schema up to the given version, the latest version by default
+var upCmd = &cobra.Command{
+	Use:   "up [version]",
+	Short: "migrate the schema up to the version, the required version by default",
+	Args:  cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		target := database.SchemaVersion()
+		if len(args) > 0 {
+			var err error
+			if target, err = strconv.Atoi(args[0]); err != nil {
+				return err
+			}
+		}
+		return migrateTo(target, func(version int) bool { return target >= version })
+	},
+}
+
+// downCmd migrate the schema down to the given version
+var downCmd = &cobra.Command{
+	Use:   "down <version>",
+	Short: "migrate the schema down to the version",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		target, err := strconv.Atoi(args[0])
+		if err != nil {
+			return err
+		}
+		return migrateTo(target, func(version int) bool { return target <= version })
+	},
+}
+
+// migrateTo run the migrations to the target version if valid return true for the current version
+func migrateTo(target int, valid func(version int) bool) error {
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	dbClient, err := connectDatabase(cfg)
+	if err != nil {
+		return err
+	}
+
+	schema, err := dbClient.GetSchema()
+	if err != nil {
+		return err
+	}
+	if !valid(schema.Version) {
+		return errors.New("the target version is in the other direction of the migration")
+	}
+
+	// the migrations which backfill the data of the chain read the nodes of the config,
+	// the data of a shard whose node is unavailable is skipped
+	nodes := make(map[int]database.MigrationNode)
+	for shard, url := range cfg.RPCNodes {
+		seeleRPC := rpc.NewRPC(url)
+		if err := seeleRPC.Connect(); err != nil {
+			fmt.Printf("connect to node %s of shard %d failed %s, its data of the chain is skipped\n", url, shard, err)
+			continue
+		}
+		defer seeleRPC.Release()
+		nodes[shard] = seeleRPC
+	}
+
+	if err := dbClient.Migrate(target, *batchSize, nodes); err != nil {
+		return err
+	}
+	fmt.Printf("database schema is migrated from version %d to %d\n", schema.Version, target)
+	return nil
+}
+
+// printIndexDrifts print the missing and extra indexes of each collection
+func printIndexDrifts(drifts []*database.IndexDrift) {
+	if len(drifts) == 0 {
+		fmt.Println("no index drift")
+		return
+	}
+	for _, drift := range drifts {
+		for _, index := range drift.Missing {
+			fmt.Printf("missing %s {%s}\n", drift.Collection, strings.Join(index.Key, ", "))
+		}
+		for _, index := range drift.Extra {
+			fmt.Printf("extra   %s {%s} %s\n", drift.Collection, strings.Join(index.Key, ", "), index.Name)
+		}
+	}
+}
+
+func init() {
+	dryRun = indexesCmd.Flags().BoolP("dry-run", "n", false, "only report the index drift")
+	batchSize = migrateCmd.PersistentFlags().IntP("batch", "b", 1000, "the number of documents migrated in a batch")
+	migrateCmd.AddCommand(indexesCmd, statusCmd, upCmd, downCmd)
+	rootCmd.AddCommand(migrateCmd)
+}
@@ -0,0 +1,68 @@
+/**
+*  @file
+*  @copyright defined in scan-api/LICENSE
+ */
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/seeleteam/scan-api/database"
+	"github.com/spf13/cobra"
+)
+
+var (
+	verifyOnly       *bool
+	recountBatchSize *int
+)
+
+// recountCmd count the documents again and correct the counters which drifted
+var recountCmd = &cobra.Command{
+	Use:   "recount",
+	Short: "verify or rebuild the counters of the blocks, transactions, debts and accounts, the supply and the miners, stop the syncers first",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dbClient, err := openDatabase()
+		if err != nil {
+			return err
+		}
+
+		drifts, err := dbClient.Recount(!*verifyOnly, *recountBatchSize)
+		if err != nil {
+			return err
+		}
+		printStatDrifts(drifts)
+		if *verifyOnly {
+			return nil
+		}
+		if len(drifts) > 0 {
+			fmt.Printf("%d counters are corrected\n", len(drifts))
+		}
+		if err := dbClient.RecountSupply(*recountBatchSize); err != nil {
+			return err
+		}
+		fmt.Println("the supply is rebuilt from the blocks")
+		if err := dbClient.RecountMiners(*recountBatchSize); err != nil {
+			return err
+		}
+		fmt.Println("the revenue of the miners is rebuilt from the blocks")
+		return nil
+	},
+}
+
+// printStatDrifts print the stored and the counted value of each drifted counter
+func printStatDrifts(drifts []*database.StatDrift) {
+	if len(drifts) == 0 {
+		fmt.Println("all the counters are correct")
+		return
+	}
+	for _, drift := range drifts {
+		fmt.Printf("%-10s shard %d %s stored %d counted %d\n", drift.Name, drift.ShardNumber, drift.Address, drift.Stored, drift.Counted)
+	}
+}
+
+func init() {
+	verifyOnly = recountCmd.Flags().BoolP("dry-run", "n", false, "only report the drifted counters")
+	recountBatchSize = recountCmd.Flags().IntP("batch", "b", 1000, "the number of documents counted in a batch")
+	rootCmd.AddCommand(recountCmd)
+}
@@ -0,0 +1,74 @@
+/**
+*  @file
+*  @copyright defined in scan-api/LICENSE
+ */
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/seeleteam/scan-api/database"
+	"github.com/seeleteam/scan-api/log"
+	"github.com/spf13/cobra"
+)
+
+// rootCmd represents the base command when called without any subcommands
+var rootCmd = &cobra.Command{
+	Use:   "scan",
+	Short: "scan database maintenance commands",
+
+	SilenceUsage:  true,
+	SilenceErrors: true,
+}
+
+// loadConfig load the config file and init the log
+func loadConfig() (*Config, error) {
+	cfg, err := LoadConfigFromFile(*configFile)
+	if err != nil {
+		return nil, fmt.Errorf("read config file failed %s", err.Error())
+	}
+
+	if log.NewLogger(cfg.LogFile, cfg.LogLevel, cfg.WriteLog) == nil {
+		return nil, errors.New("log init failed")
+	}
+
+	if cfg.DataBase == nil {
+		return nil, errors.New("database config is missing")
+	}
+	return cfg, nil
+}
+
+// openDatabase load the config file and connect to the database
+func openDatabase() (*database.Client, error) {
+	cfg, err := loadConfig()
+	if err != nil {
+		return nil, err
+	}
+	return connectDatabase(cfg)
+}
+
+// connectDatabase connect to the database of the config
+func connectDatabase(cfg *Config) (*database.Client, error) {
+	dbClient := database.NewDBClient(cfg.DataBase, 0)
+	if dbClient == nil {
+		return nil, errors.New("init database error")
+	}
+	return dbClient, nil
+}
+
+// Execute adds all child commands to the root command and sets flags appropriately.
+// This is called by main.main(). It only needs to happen once to the rootCmd.
+func Execute() {
+	if err := rootCmd.Execute(); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+}
+
+func init() {
+	configFile = rootCmd.PersistentFlags().StringP("config", "c", "", "config file (required)")
+	rootCmd.MarkPersistentFlagRequired("config")
+}
@@ -0,0 +1,12 @@
+/**
+*  @file
+*  @copyright defined in scan-api/LICENSE
+ */
+
+package main
+
+import "github.com/seeleteam/scan-api/cmd/scan/cmd"
+
+func main() {
+	cmd.Execute()
+}
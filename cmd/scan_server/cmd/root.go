@@ -43,6 +43,10 @@ var rootCmd = &cobra.Command{
 			fmt.Printf("init database error")
 			return
 		}
+		if err := dbClient.CheckSchemaVersion(); err != nil {
+			fmt.Printf("check database schema failed %s", err.Error())
+			return
+		}
 
 		scanServer := server.GetServer(&g, &serverCfg)
 		if scanServer != nil {
@@ -10,6 +10,7 @@ import (
 	"os"
 	"sync"
 
+	"github.com/seeleteam/scan-api/common"
 	"github.com/seeleteam/scan-api/database"
 	"github.com/seeleteam/scan-api/log"
 	"github.com/seeleteam/scan-api/syncer"
@@ -42,14 +43,26 @@ var rootCmd = &cobra.Command{
 			fmt.Printf("init database error")
 			return
 		}
-		for i:=1; i<=4; i++ {
-			dbClient.InitTxCntByShardNumber(i)
+		if err := dbClient.CheckSchemaVersion(); err != nil {
+			fmt.Printf("check database schema failed %s", err.Error())
+			return
 		}
 		if serverCfg.DataBase.DataBaseMode == "replset" {
 			dbClient.SetPrimaryMode()
 		}
 
-		syncer := syncer.NewSyncer(dbClient, serverCfg.RpcURL, serverCfg.ShardNumber)
+		if err := dbClient.SetGenesisSupply(serverCfg.ShardNumber, serverCfg.GenesisSupply()); err != nil {
+			fmt.Printf("set genesis supply failed %s", err.Error())
+			return
+		}
+
+		pools, err := common.NewPoolResolver(serverCfg.MinerPools)
+		if err != nil {
+			fmt.Printf("invalid miner pool config %s", err.Error())
+			return
+		}
+
+		syncer := syncer.NewSyncer(dbClient, serverCfg.RpcURL, serverCfg.ShardNumber, pools)
 		if syncer == nil {
 			fmt.Printf("can not connect to node")
 			return
@@ -9,4 +9,8 @@ type DataBaseConfig struct {
 	UseAuthentication   bool
 	User                string
 	Pwd                 string
+	EnsureIndexes       bool
+	// DataBaseMaxStaleness is the delay in seconds of the secondaries tolerated by the reads of the
+	// counters and charts in replset mode, 0 reads everything from the primary
+	DataBaseMaxStaleness int
 }
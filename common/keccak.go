@@ -0,0 +1,20 @@
+/**
+*  @file
+*  @copyright defined in scan-api/LICENSE
+ */
+
+package common
+
+import (
+	"golang.org/x/crypto/sha3"
+)
+
+// Keccak256 calculates the legacy Keccak-256 hash used by the seele chain,
+// which differs from the standardized SHA3-256 in its padding.
+func Keccak256(data ...[]byte) []byte {
+	d := sha3.NewLegacyKeccak256()
+	for _, b := range data {
+		d.Write(b)
+	}
+	return d.Sum(nil)
+}
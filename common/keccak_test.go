@@ -0,0 +1,19 @@
+/**
+*  @file
+*  @copyright defined in scan-api/LICENSE
+ */
+
+package common
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Keccak256(t *testing.T) {
+	assert.Equal(t, "c5d2460186f7233c927e7db2dcc703c0e500b653ca82273b7bfad8045d85a470", hex.EncodeToString(Keccak256()))
+	assert.Equal(t, "a9059cbb", hex.EncodeToString(Keccak256([]byte("transfer(address,uint256)"))[:4]))
+	assert.Equal(t, Keccak256([]byte("transfer(address,uint256)")), Keccak256([]byte("transfer("), []byte("address,uint256)")))
+}
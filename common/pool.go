@@ -0,0 +1,97 @@
+/**
+*  @file
+*  @copyright defined in scan-api/LICENSE
+ */
+
+package common
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// MinerPoolConfig maps coinbase addresses and block extra data patterns to a pool name
+type MinerPoolConfig struct {
+	Name              string
+	Addresses         []string
+	ExtraDataPatterns []string
+}
+
+type minerPool struct {
+	name     string
+	patterns []*regexp.Regexp
+}
+
+// PoolResolver identify the mining pool of a block by its creator and extra data
+type PoolResolver struct {
+	addresses map[string]string
+	pools     []minerPool
+}
+
+// NewPoolResolver compile the pool configs, return an error if any pattern is invalid
+func NewPoolResolver(configs []MinerPoolConfig) (*PoolResolver, error) {
+	resolver := &PoolResolver{addresses: make(map[string]string)}
+	for _, config := range configs {
+		for _, address := range config.Addresses {
+			resolver.addresses[strings.ToLower(address)] = config.Name
+		}
+
+		pool := minerPool{name: config.Name}
+		for _, pattern := range config.ExtraDataPatterns {
+			re, err := regexp.Compile(pattern)
+			if err != nil {
+				return nil, err
+			}
+			pool.patterns = append(pool.patterns, re)
+		}
+		if len(pool.patterns) > 0 {
+			resolver.pools = append(resolver.pools, pool)
+		}
+	}
+	return resolver, nil
+}
+
+// Resolve return the pool name of the block, the coinbase address mapping takes precedence
+// over the extra data patterns. An empty string is returned for unknown miners.
+func (r *PoolResolver) Resolve(creator string, extraData string) string {
+	if r == nil {
+		return ""
+	}
+
+	if name, ok := r.addresses[strings.ToLower(creator)]; ok {
+		return name
+	}
+
+	if extraData == "" {
+		return ""
+	}
+	text := ExtraDataText(extraData)
+	for _, pool := range r.pools {
+		for _, re := range pool.patterns {
+			if re.MatchString(text) {
+				return pool.name
+			}
+		}
+	}
+	return ""
+}
+
+// ExtraDataText decode the block extra data which is sent by the node as hex or base64 bytes,
+// the raw string is returned if it could not be decoded into readable text
+func ExtraDataText(extraData string) string {
+	var data []byte
+	var err error
+	if strings.HasPrefix(extraData, "0x") {
+		data, err = hex.DecodeString(extraData[2:])
+	} else {
+		data, err = base64.StdEncoding.DecodeString(extraData)
+	}
+
+	if err != nil || !utf8.Valid(data) {
+		return extraData
+	}
+	return string(data)
+}
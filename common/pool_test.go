@@ -0,0 +1,32 @@
+/**
+*  @file
+*  @copyright defined in scan-api/LICENSE
+ */
+
+package common
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_PoolResolver(t *testing.T) {
+	resolver, err := NewPoolResolver([]MinerPoolConfig{
+		{Name: "PoolX", Addresses: []string{"0x4C10f2cd2159bb432094e3be7e17904c2b4aeb21"}},
+		{Name: "PoolY", ExtraDataPatterns: []string{"^pooly/"}},
+	})
+	assert.NoError(t, err)
+
+	assert.Equal(t, "PoolX", resolver.Resolve("0x4c10f2cd2159bb432094e3be7e17904c2b4aeb21", ""))
+	// "pooly/1.0" in hex and in base64
+	assert.Equal(t, "PoolY", resolver.Resolve("0xec759db47a65f6537d630517f6cd3ca39c6f93d1", "0x706f6f6c792f312e30"))
+	assert.Equal(t, "PoolY", resolver.Resolve("0xec759db47a65f6537d630517f6cd3ca39c6f93d1", "cG9vbHkvMS4w"))
+	assert.Equal(t, "", resolver.Resolve("0xec759db47a65f6537d630517f6cd3ca39c6f93d1", "0x0102"))
+
+	var nilResolver *PoolResolver
+	assert.Equal(t, "", nilResolver.Resolve("0x4c10f2cd2159bb432094e3be7e17904c2b4aeb21", ""))
+
+	_, err = NewPoolResolver([]MinerPoolConfig{{Name: "bad", ExtraDataPatterns: []string{"("}}})
+	assert.Error(t, err)
+}
@@ -0,0 +1,96 @@
+/**
+*  @file
+*  @copyright defined in scan-api/LICENSE
+ */
+
+package database
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_AddressActivities(t *testing.T) {
+	c := NewMemoryClient(1)
+	var activities []*DBAddressActivity
+	for _, tx := range []*DBTx{
+		{Hash: "0x0a", From: coinbaseAddress, To: "0x01", Block: 1, Idx: 1, ShardNumber: 1},
+		{Hash: "0x0b", From: "0x01", To: "0x02", Block: 2, Idx: 2, ShardNumber: 1},
+		{Hash: "0x0c", From: "0x01", To: "0x01", Block: 2, Idx: 3, ShardNumber: 1},
+		{Hash: "0x0d", From: "0x01", To: "0x05", DebtTxHash: "0x0e", Block: 3, Idx: 4, ShardNumber: 1},
+	} {
+		activities = append(activities, CreateTxActivities(tx)...)
+	}
+	activities = append(activities, CreateDebtActivity(&Debt{Hash: "0x0f", To: "0x01", Height: 3, Idx: 1, ShardNumber: 2}, "0x06", 0))
+	assert.Nil(t, c.AddAddressActivities(activities...))
+
+	hashes := func(activities []*DBAddressActivity) []string {
+		var ret []string
+		for _, a := range activities {
+			ret = append(ret, a.Hash+" "+a.Kind)
+		}
+		return ret
+	}
+
+	all, err := c.GetAddressActivities("0x01", "", 0, 0)
+	assert.Nil(t, err)
+	assert.Equal(t, hashes(all), []string{"0x0d sent", "0x0f debt-in", "0x0c sent", "0x0c received", "0x0b sent", "0x0a reward"})
+	in, err := c.GetAddressActivities("0x01", ActivityIn, 2, 1)
+	assert.Nil(t, err)
+	assert.Equal(t, hashes(in), []string{"0x0c received", "0x0a reward"})
+	_, err = c.GetAddressActivities("0x01", "sideways", 0, 0)
+	assert.Equal(t, err, ErrInvalidDirection)
+
+	// the entries of a transaction are paged apart by cursor
+	var paged []*DBAddressActivity
+	cursor := ""
+	for {
+		page, p, err := c.GetAddressActivitiesByCursor("0x01", "", cursor, 2)
+		assert.Nil(t, err)
+		paged = append(paged, page...)
+		if p.Next == "" {
+			break
+		}
+		cursor = p.Next
+	}
+	assert.Equal(t, hashes(paged), hashes(all))
+	out, p, err := c.GetAddressActivitiesByCursor("0x01", ActivityOut, "", 2)
+	assert.Nil(t, err)
+	assert.Equal(t, hashes(out), []string{"0x0d sent", "0x0c sent"})
+	out, _, err = c.GetAddressActivitiesByCursor("0x01", ActivityOut, p.Next, 2)
+	assert.Nil(t, err)
+	assert.Equal(t, hashes(out), []string{"0x0b sent"})
+
+	// the activities are streamed the oldest first, the entries of a transaction are batched apart
+	var batches [][]string
+	err = c.EachAddressActivity("0x01", 2, func(activities []*DBAddressActivity) error {
+		batches = append(batches, hashes(activities))
+		return nil
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, batches, [][]string{{"0x0a reward", "0x0b sent"}, {"0x0c received", "0x0c sent"}, {"0x0f debt-in", "0x0d sent"}})
+	err = c.EachAddressActivity("0x01", 2, func(activities []*DBAddressActivity) error {
+		return ErrInvalidCursor
+	})
+	assert.Equal(t, err, ErrInvalidCursor)
+
+	cnt, err := c.GetAddressActivityCnt("0x01", "")
+	assert.Nil(t, err)
+	assert.Equal(t, cnt, int64(6))
+	cnt, _ = c.GetAddressActivityCnt("0x01", ActivityOut)
+	assert.Equal(t, cnt, int64(3))
+	cnt, _ = c.GetAddressActivityCnt("0x05", "")
+	assert.Equal(t, cnt, int64(0))
+
+	// the block 2 of shard 1 is reverted by a reorg
+	assert.Nil(t, c.RemoveAddressActivities(1, 2))
+	all, _ = c.GetAddressActivities("0x01", "", 0, 0)
+	assert.Equal(t, hashes(all), []string{"0x0d sent", "0x0f debt-in", "0x0a reward"})
+	cnt, _ = c.GetAddressActivityCnt("0x01", ActivityIn)
+	assert.Equal(t, cnt, int64(2))
+
+	drifts, err := c.Recount(false, 2)
+	assert.Nil(t, err)
+	assert.Empty(t, drifts)
+}
@@ -0,0 +1,72 @@
+/**
+*  @file
+*  @copyright defined in scan-api/LICENSE
+ */
+
+package database
+
+import (
+	"errors"
+
+	"gopkg.in/mgo.v2/bson"
+)
+
+// ErrAPIKeyExists is returned when an api key is added twice
+var ErrAPIKeyExists = errors.New("the api key exists already")
+
+// AddAPIKey add an api key
+// index: apikey {key}
+func (c *Client) AddAPIKey(key *DBAPIKey) error {
+	query := func(c collection) error {
+		n, err := c.Find(bson.M{"key": key.Key}).Count()
+		if err != nil {
+			return err
+		}
+		if n > 0 {
+			return ErrAPIKeyExists
+		}
+		return c.Insert(key)
+	}
+	return c.withCollection(apiKeyTbl, query)
+}
+
+// GetAPIKeys get all the api keys
+func (c *Client) GetAPIKeys() ([]*DBAPIKey, error) {
+	var keys []*DBAPIKey
+	query := func(c collection) error {
+		return c.Find(nil).All(&keys)
+	}
+	err := c.withCollection(apiKeyTbl, query)
+	return keys, err
+}
+
+// SetAPIKeyDisabled disable or enable an api key, mgo.ErrNotFound if the key does not exist
+// index: apikey {key}
+func (c *Client) SetAPIKeyDisabled(key string, disabled bool) error {
+	query := func(c collection) error {
+		return c.Update(bson.M{"key": key}, bson.M{"$set": bson.M{"disabled": disabled}})
+	}
+	return c.withCollection(apiKeyTbl, query)
+}
+
+// IncAPIUsage add the requests of an api key on a day, the usage of a new day is created
+// index: apikey_usage {key, day}
+func (c *Client) IncAPIUsage(key string, day string, requests, limited int64) error {
+	query := func(c collection) error {
+		_, err := c.Upsert(bson.M{"key": key, "day": day}, bson.M{"$inc": bson.M{"requests": requests, "limited": limited}})
+		return err
+	}
+	return c.withCollection(apiUsageTbl, query)
+}
+
+// GetAPIUsage get the usage of all the api keys on the days from fromDay to toDay, the days
+// are compared as strings
+// index: apikey_usage {day}
+func (c *Client) GetAPIUsage(fromDay, toDay string) ([]*DBAPIUsage, error) {
+	var usage []*DBAPIUsage
+	query := func(c collection) error {
+		return c.Find(bson.M{"day": bson.M{"$gte": fromDay, "$lte": toDay}}).Sort("day").All(&usage)
+	}
+	err := c.withCollection(apiUsageTbl, query)
+	return usage, err
+}
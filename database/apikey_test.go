@@ -0,0 +1,35 @@
+/**
+*  @file
+*  @copyright defined in scan-api/LICENSE
+ */
+
+package database
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_APIKeys(t *testing.T) {
+	c := NewMemoryClient(1)
+	assert.Nil(t, c.AddAPIKey(&DBAPIKey{Key: "k1", Tier: "free"}))
+	assert.Equal(t, c.AddAPIKey(&DBAPIKey{Key: "k1", Tier: "pro"}), ErrAPIKeyExists)
+	assert.Nil(t, c.AddAPIKey(&DBAPIKey{Key: "k2", Tier: "pro", Admin: true}))
+	assert.Nil(t, c.SetAPIKeyDisabled("k1", true))
+
+	keys, err := c.GetAPIKeys()
+	assert.Nil(t, err)
+	assert.Equal(t, keys, []*DBAPIKey{{Key: "k1", Tier: "free", Disabled: true}, {Key: "k2", Tier: "pro", Admin: true}})
+
+	// the usage of a day is created by its first requests
+	assert.Nil(t, c.IncAPIUsage("k1", "2018-10-18", 5, 1))
+	assert.Nil(t, c.IncAPIUsage("k1", "2018-10-19", 2, 0))
+	assert.Nil(t, c.IncAPIUsage("k1", "2018-10-19", 3, 2))
+	assert.Nil(t, c.IncAPIUsage("k2", "2018-10-20", 1, 0))
+	usage, err := c.GetAPIUsage("2018-10-19", "2018-10-19")
+	assert.Nil(t, err)
+	assert.Equal(t, usage, []*DBAPIUsage{{Key: "k1", Day: "2018-10-19", Requests: 5, Limited: 2}})
+	usage, _ = c.GetAPIUsage("2018-10-01", "2018-10-31")
+	assert.Equal(t, len(usage), 3)
+}
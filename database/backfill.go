@@ -0,0 +1,114 @@
+/**
+*  @file
+*  @copyright defined in scan-api/LICENSE
+ */
+
+package database
+
+import (
+	"github.com/seeleteam/scan-api/log"
+	"github.com/seeleteam/scan-api/rpc"
+	"gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// ReceiptNode is the node of a shard read by the receipt backfill
+type ReceiptNode interface {
+	GetReceiptByTxHash(txhash string) (*rpc.Receipt, error)
+}
+
+// GetReceiptBackfill get the progress of the receipt backfill of the shard, a backfill which is
+// not started has no progress
+// index: backfill {shardNumber}
+func (c *Client) GetReceiptBackfill(shard int) (*DBBackfill, error) {
+	var backfills []*DBBackfill
+	query := func(c collection) error {
+		return c.Find(bson.M{"shardNumber": shard}).All(&backfills)
+	}
+	if err := c.withCollection(backfillTbl, query); err != nil {
+		return nil, err
+	}
+	if len(backfills) == 0 {
+		return &DBBackfill{ShardNumber: shard}, nil
+	}
+	return backfills[0], nil
+}
+
+// saveReceiptBackfill store the progress of the receipt backfill of the shard
+// index: backfill {shardNumber}
+func (c *Client) saveReceiptBackfill(backfill *DBBackfill) error {
+	query := func(c collection) error {
+		_, err := c.Upsert(bson.M{"shardNumber": backfill.ShardNumber}, backfill)
+		return err
+	}
+	return c.withCollection(backfillTbl, query)
+}
+
+// BackfillReceipts fill the receipts of the transactions of the shard synced before the receipts were stored
+// and flag the activities of the failed transactions, which were written before the receipts were read.
+// The transactions are scanned in batches in the order of the export and the progress is saved after every
+// batch, a stopped backfill resumes from it unless restart, a finished one starts again. A receipt which the
+// node fails to return is passed to missing and left empty, the next run fetches it again.
+// index: transaction {shardNumber, block, idx}, transaction {hash}, address_activity {address, block, idx, kind}
+// and backfill {shardNumber}
+func (c *Client) BackfillReceipts(shard int, node ReceiptNode, batchSize int, restart bool, missing func(tx *DBTx, err error) error) (*DBBackfill, error) {
+	backfill, err := c.GetReceiptBackfill(shard)
+	if err != nil {
+		return nil, err
+	}
+	if restart || backfill.Done {
+		backfill = &DBBackfill{ShardNumber: shard}
+	}
+
+	err = c.Export(EntityTxs, shard, 0, -1, backfill.Last, batchSize, func(docs []interface{}, last []interface{}) error {
+		var activities []*DBAddressActivity
+		for _, doc := range docs {
+			tx := doc.(*DBTx)
+			if tx.Receipt.TxHash != "" {
+				if tx.Receipt.Failed {
+					activities = append(activities, CreateTxActivities(tx)...)
+					backfill.Flagged++
+				}
+				continue
+			}
+			receipt, err := node.GetReceiptByTxHash(tx.Hash)
+			if err != nil {
+				backfill.Missing++
+				if err := missing(tx, err); err != nil {
+					return err
+				}
+				continue
+			}
+
+			query := func(c collection) error {
+				return c.Update(bson.M{"hash": tx.Hash}, bson.M{"$set": bson.M{"receipt": receipt}})
+			}
+			// the transaction of a reorged block is removed by the syncer
+			if err := c.withCollection(txTbl, query); err == mgo.ErrNotFound {
+				continue
+			} else if err != nil {
+				return err
+			}
+			backfill.Filled++
+			if receipt.Failed {
+				tx.Receipt = *receipt
+				activities = append(activities, CreateTxActivities(tx)...)
+				backfill.Flagged++
+			}
+		}
+		if err := c.upsertAddressActivities(activities); err != nil {
+			return err
+		}
+
+		backfill.Last = last
+		backfill.Scanned += int64(len(docs))
+		log.Debug("[DB] backfill receipts of shard %d: %d transactions are scanned", shard, backfill.Scanned)
+		return c.saveReceiptBackfill(backfill)
+	})
+	if err != nil {
+		return backfill, err
+	}
+
+	backfill.Done = true
+	return backfill, c.saveReceiptBackfill(backfill)
+}
@@ -0,0 +1,103 @@
+/**
+*  @file
+*  @copyright defined in scan-api/LICENSE
+ */
+
+package database
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/seeleteam/scan-api/log"
+	"github.com/seeleteam/scan-api/rpc"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeReceiptNode return the receipts of the transactions, the hashes in unavailable fail
+type fakeReceiptNode struct {
+	failed      map[string]bool
+	unavailable map[string]bool
+}
+
+func (n *fakeReceiptNode) GetReceiptByTxHash(txhash string) (*rpc.Receipt, error) {
+	if n.unavailable[txhash] {
+		return nil, errors.New("receipt not found")
+	}
+	return &rpc.Receipt{TxHash: txhash, Failed: n.failed[txhash]}, nil
+}
+
+func Test_BackfillReceipts(t *testing.T) {
+	log.NewLogger("", "error", false)
+	c := NewMemoryClient(1)
+	txs := []*DBTx{
+		{Hash: "0x0a", From: "0x01", To: "0x02", Block: 1, Idx: 0, ShardNumber: 1},
+		{Hash: "0x0b", From: "0x01", To: "0x02", Block: 1, Idx: 1, ShardNumber: 1, Receipt: rpc.Receipt{TxHash: "0x0b"}},
+		{Hash: "0x0c", From: "0x02", To: "0x01", Block: 2, Idx: 0, ShardNumber: 1},
+		{Hash: "0x0d", From: "0x03", To: "0x01", Block: 3, Idx: 0, ShardNumber: 1, Receipt: rpc.Receipt{TxHash: "0x0d", Failed: true}},
+	}
+	for _, tx := range txs {
+		assert.Nil(t, c.AddTxs(tx))
+		// the activities were written without the failed flag
+		activities := CreateTxActivities(tx)
+		for _, activity := range activities {
+			activity.Failed = false
+		}
+		assert.Nil(t, c.AddAddressActivities(activities...))
+	}
+	node := &fakeReceiptNode{failed: map[string]bool{"0x0a": true}, unavailable: map[string]bool{"0x0c": true}}
+
+	// the progress is saved after every batch
+	errInterrupted := errors.New("interrupted")
+	_, err := c.BackfillReceipts(1, node, 1, false, func(tx *DBTx, err error) error {
+		return errInterrupted
+	})
+	assert.Equal(t, err, errInterrupted)
+	backfill, err := c.GetReceiptBackfill(1)
+	assert.Nil(t, err)
+	assert.Equal(t, backfill.Scanned, int64(2))
+	assert.Equal(t, backfill.Filled, int64(1))
+	assert.Equal(t, backfill.Flagged, int64(1))
+	assert.False(t, backfill.Done)
+
+	// the resumed backfill carries on after a missing receipt
+	var missing []string
+	backfill, err = c.BackfillReceipts(1, node, 1, false, func(tx *DBTx, err error) error {
+		missing = append(missing, tx.Hash)
+		return nil
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, missing, []string{"0x0c"})
+	assert.Equal(t, backfill.Scanned, int64(4))
+	assert.Equal(t, backfill.Filled, int64(1))
+	assert.Equal(t, backfill.Missing, int64(1))
+	assert.Equal(t, backfill.Flagged, int64(2))
+	assert.True(t, backfill.Done)
+
+	tx, err := c.GetTxByHash("0x0a")
+	assert.Nil(t, err)
+	assert.Equal(t, tx.Receipt.TxHash, "0x0a")
+	assert.True(t, tx.Receipt.Failed)
+	tx, err = c.GetTxByHash("0x0c")
+	assert.Nil(t, err)
+	assert.Empty(t, tx.Receipt.TxHash)
+
+	// the activities of the failed transactions are flagged, the stored failed receipts too
+	activities, err := c.GetAddressActivities("0x01", "", 0, 0)
+	assert.Nil(t, err)
+	assert.Equal(t, len(activities), 4)
+	for _, activity := range activities {
+		assert.Equal(t, activity.Failed, activity.Block == 1 && activity.Idx == 0 || activity.Block == 3, "%+v", activity)
+	}
+
+	// a finished backfill starts again and only fetches the missing receipts
+	node.unavailable = nil
+	backfill, err = c.BackfillReceipts(1, node, 10, false, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, backfill.Scanned, int64(4))
+	assert.Equal(t, backfill.Filled, int64(1))
+	assert.Equal(t, backfill.Missing, int64(0))
+	tx, err = c.GetTxByHash("0x0c")
+	assert.Nil(t, err)
+	assert.Equal(t, tx.Receipt.TxHash, "0x0c")
+}
@@ -22,6 +22,15 @@ const (
 	debtTbl       = "debt"
 	pendingTxTbl  = "pendingtx"
 	txHisTbl      = "txhistory"
+	supplyTbl     = "supply"
+	schemaTbl     = "schema"
+	statsTbl      = "stats"
+	activityTbl   = "address_activity"
+	syncStateTbl  = "syncstate"
+	apiKeyTbl     = "apikey"
+	apiUsageTbl   = "apikey_usage"
+	labelTbl      = "label"
+	backfillTbl   = "backfill"
 
 	chartTxTbl              = "chart_transhistory"
 	chartHashRateTbl        = "chart_hashrate"
@@ -31,6 +40,8 @@ const (
 	chartAddressTbl         = "chart_address"
 	chartSingleAddressTbl   = "chart_single_address"
 	chartTopMinerRankTbl    = "chart_topminer"
+	chartMinerRevenueTbl    = "chart_minerrevenue"
+	chartSupplyTbl          = "chart_supply"
 
 	nodeInfoTbl = "nodeinfo"
 )
@@ -40,11 +51,6 @@ var (
 	// db connect error
 	errDBConnect = errors.New("could not connect to database")
 )
-// in memory variables
-var (
-	txCntForShard = make([]uint64, 5)
-)
-
 // Client warpper for mongodb interactive
 type Client struct {
 	mgo               *mgo.Session
@@ -56,12 +62,33 @@ type Client struct {
 	user              string
 	pwd               string
 	shardNumber       int
+	mem               *memDatabase
+
+	maxStaleness time.Duration // the delay of the secondaries tolerated by the counter and chart reads
+	writes       writeTimes    // the time of the last write of the client to each table
+	lag          replicaLag
 }
 
-// NewDBClient reuturn an DB client
+// MemoryMode is the database mode which keeps all the data in memory, nothing is persisted
+const MemoryMode = "memory"
+
+// NewDBClient reuturn an DB client, the declared indexes are ensured if it is configured
 func NewDBClient(cfg *common.DataBaseConfig, shardNumber int) *Client {
+	client := newDBClient(cfg, shardNumber)
+	if client != nil && cfg.EnsureIndexes {
+		if err := client.EnsureIndexes(); err != nil {
+			log.Error("[DB] err : ensure indexes failed %v", err)
+		}
+	}
+	return client
+}
+
+// newDBClient connect to the database by the database mode
+func newDBClient(cfg *common.DataBaseConfig, shardNumber int) *Client {
 	mgo := new(mgo.Session)
-	if cfg.DataBaseMode == "single" {
+	if cfg.DataBaseMode == MemoryMode {
+		return NewMemoryClient(shardNumber)
+	} else if cfg.DataBaseMode == "single" {
 		if len(cfg.DataBaseConnURLs) != 1 {
 			log.Error("[DB] err : single mode database should have one db URL")
 		}
@@ -91,6 +118,17 @@ func NewDBClient(cfg *common.DataBaseConfig, shardNumber int) *Client {
 		user:              cfg.User,
 		pwd:               cfg.Pwd,
 		shardNumber:       shardNumber,
+		maxStaleness:      time.Duration(cfg.DataBaseMaxStaleness) * time.Second,
+	}
+}
+
+// NewMemoryClient return an DB client which keeps the data in memory,
+// the queries have the same sorting, paging and not found semantics as mongodb
+func NewMemoryClient(shardNumber int) *Client {
+	return &Client{
+		dbMode:      MemoryMode,
+		shardNumber: shardNumber,
+		mem:         newMemDatabase(),
 	}
 }
 
@@ -138,8 +176,13 @@ func (c *Client) getDBConnection() *mgo.Session {
 	return c.mgo.Clone()
 }
 
-// withCollection perform an database query
-func (c *Client) withCollection(collection string, s func(*mgo.Collection) error) error {
+// withCollection perform an database query, the reads of the counters and charts go to a secondary
+// if the replica set is not behind the max staleness
+func (c *Client) withCollection(name string, s func(collection) error) error {
+	if c.mem != nil {
+		return c.mem.withCollection(name, s)
+	}
+
 	session := c.getDBConnection()
 	defer func() {
 		if session != nil {
@@ -147,8 +190,10 @@ func (c *Client) withCollection(collection string, s func(*mgo.Collection) error
 		}
 	}()
 	if session != nil {
-		c := session.DB(c.dbName).C(collection)
-		err := s(c)
+		if c.readFromSecondary(name) {
+			session.SetMode(mgo.SecondaryPreferred, true)
+		}
+		err := s(writeTracker{mgoCollection{session.DB(c.dbName).C(name)}, c, name})
 		processDataBaseError(err)
 		return err
 	}
@@ -158,6 +203,12 @@ func (c *Client) withCollection(collection string, s func(*mgo.Collection) error
 
 // dropCollection test use remove the tbl
 func (c *Client) dropCollection(tbl string) error {
+	if c.mem != nil {
+		return c.mem.withCollection(tbl, func(c collection) error {
+			return c.DropCollection()
+		})
+	}
+
 	session := c.getDBConnection()
 	if session != nil {
 		c := session.DB(c.dbName).C(tbl)
@@ -171,31 +222,46 @@ func (c *Client) dropCollection(tbl string) error {
 
 // LiveServers return the URLs of the alive servers
 func (c *Client) LiveServers() []string {
+	if c.mem != nil {
+		return []string{MemoryMode}
+	}
 	return c.mgo.LiveServers()
 }
 
 // SetPrimaryMode set the primary mode for mongodb
 func (c *Client) SetPrimaryMode() {
+	if c.mem != nil {
+		return
+	}
 	c.mgo.SetMode(mgo.Primary, true)
 }
 
 // SetSecondaryPreferredMode set the SecondaryPreferred mode for mongodb
 func (c *Client) SetSecondaryPreferredMode() {
+	if c.mem != nil {
+		return
+	}
 	c.mgo.SetMode(mgo.SecondaryPreferred, true)
 }
 
 // AddBlock insert a block into database
 func (c *Client) AddBlock(b *DBBlock) error {
-	query := func(c *mgo.Collection) error {
+	query := func(c collection) error {
 		return c.Insert(b)
 	}
 	err := c.withCollection(blockTbl, query)
-	return err
+	if err != nil {
+		return err
+	}
+	if err = c.incStats(statDeltas{{name: statBlocks, shardNumber: b.ShardNumber}: 1}); err != nil {
+		return err
+	}
+	return c.moveSyncHeight(b.ShardNumber, "$max", uint64(b.Height)+1)
 }
 
 // AddLastBlocks insert last two blocks into database
 func (c *Client) AddLastBlocks(blocks ...interface{}) error {
-	query := func(c *mgo.Collection) error {
+	query := func(c collection) error {
 		return c.Insert(blocks...)
 	}
 	err := c.withCollection(lastBlocksTbl, query)
@@ -203,8 +269,9 @@ func (c *Client) AddLastBlocks(blocks ...interface{}) error {
 }
 
 // UpdateLastBlock update the last block
+// index: lastBlocks {shardNumber, height}
 func (c *Client) UpdateLastBlock(height int64, block *DBLastBlock) error {
-	query := func(c *mgo.Collection) error {
+	query := func(c collection) error {
 		err := c.Update(bson.M{"height": height, "shardNumber": block.ShardNumber}, block)
 		return err
 	}
@@ -216,9 +283,10 @@ func (c *Client) UpdateLastBlock(height int64, block *DBLastBlock) error {
 }
 
 // GetLastBlocksByShard get the last blocks by shard number
+// index: lastBlocks {shardNumber, height}
 func (c *Client) GetLastBlocksByShard(shard int) ([]*DBLastBlock, error) {
 	var blocks []*DBLastBlock
-	query := func(c *mgo.Collection) error {
+	query := func(c collection) error {
 		return c.Find(bson.M{"shardNumber": shard}).Sort("-height").All(&blocks)
 	}
 	err := c.withCollection(lastBlocksTbl, query)
@@ -226,8 +294,9 @@ func (c *Client) GetLastBlocksByShard(shard int) ([]*DBLastBlock, error) {
 }
 
 // RemoveLastBlocksByShard remove the last blocks by shard number
+// index: lastBlocks {shardNumber, height}
 func (c *Client) RemoveLastBlocksByShard(shard int) error {
-	query := func(c *mgo.Collection) error {
+	query := func(c collection) error {
 		_, err := c.RemoveAll(bson.M{"shardNumber": shard})
 		return err
 	}
@@ -236,18 +305,42 @@ func (c *Client) RemoveLastBlocksByShard(shard int) error {
 }
 
 // RemoveBlock test use  remove block by height from database
+// index: block {shardNumber, height}
 func (c *Client) RemoveBlock(shard int, height uint64) error {
-	query := func(c *mgo.Collection) error {
-		_, err := c.RemoveAll(bson.M{"height": height, "shardNumber": shard})
+	var changeInfo *mgo.ChangeInfo
+	query := func(c collection) error {
+		var err error
+		changeInfo, err = c.RemoveAll(bson.M{"height": height, "shardNumber": shard})
 		return err
 	}
 	err := c.withCollection(blockTbl, query)
-	return err
+	if err != nil {
+		return err
+	}
+	if changeInfo.Removed == 0 {
+		return nil
+	}
+	if err = c.incStats(statDeltas{{name: statBlocks, shardNumber: shard}: -int64(changeInfo.Removed)}); err != nil {
+		return err
+	}
+	// the removed block is synchronized again
+	return c.moveSyncHeight(shard, "$min", height)
+}
+
+// moveSyncHeight move the sync cursor of the shard with the operator, $max moves it forward and $min back
+// index: syncstate {shardNumber}
+func (c *Client) moveSyncHeight(shard int, op string, height uint64) error {
+	query := func(c collection) error {
+		_, err := c.Upsert(bson.M{"shardNumber": shard}, bson.M{op: bson.M{"height": height}})
+		return err
+	}
+	return c.withCollection(syncStateTbl, query)
 }
 
 // UpdateBlock update block by height and shard from database
+// index: block {shardNumber, height}
 func (c *Client) UpdateBlock(shard int, height uint64, b *DBBlock) error {
-	query := func(c *mgo.Collection) error {
+	query := func(c collection) error {
 		_, err := c.Upsert(bson.M{"height": height, "shardNumber": shard}, b)
 		return err
 	}
@@ -256,19 +349,32 @@ func (c *Client) UpdateBlock(shard int, height uint64, b *DBBlock) error {
 }
 
 // GetBlockByHeight get block from mongo by block height
+// index: block {shardNumber, height}
 func (c *Client) GetBlockByHeight(shardNumber int, height uint64) (*DBBlock, error) {
 	b := new(DBBlock)
-	query := func(c *mgo.Collection) error {
+	query := func(c collection) error {
 		return c.Find(bson.M{"height": height, "shardNumber": shardNumber}).One(b)
 	}
 	err := c.withCollection(blockTbl, query)
 	return b, err
 }
 
+// GetBlocksByHeights get the blocks of a shard by their heights, the blocks not found are left out
+// index: block {shardNumber, height}
+func (c *Client) GetBlocksByHeights(shardNumber int, heights []uint64) ([]*DBBlock, error) {
+	var blocks []*DBBlock
+	query := func(c collection) error {
+		return c.Find(bson.M{"shardNumber": shardNumber, "height": bson.M{"$in": heights}}).All(&blocks)
+	}
+	err := c.withCollection(blockTbl, query)
+	return blocks, err
+}
+
 // GetblockdebtCntByShardNumber get block from mongo by block height
+// index: debt {shardNumber, height, idx, hash}
 func (c *Client) GetblockdebtCntByShardNumber(shardNumber int, height uint64) (uint64, error) {
 	var debtCnt uint64
-	query := func(c *mgo.Collection) error {
+	query := func(c collection) error {
 		var temp int
 		var err error
 		temp, err = c.Find(bson.M{"height": height, "shardNumber": shardNumber}).Count()
@@ -280,9 +386,10 @@ func (c *Client) GetblockdebtCntByShardNumber(shardNumber int, height uint64) (u
 }
 
 // GetBlockByHash get a block from mongo by block header hash
+// index: block {headHash}
 func (c *Client) GetBlockByHash(hash string) (*DBBlock, error) {
 	b := new(DBBlock)
-	query := func(c *mgo.Collection) error {
+	query := func(c collection) error {
 		return c.Find(bson.M{"headHash": hash}).One(b)
 	}
 	err := c.withCollection(blockTbl, query)
@@ -290,10 +397,11 @@ func (c *Client) GetBlockByHash(hash string) (*DBBlock, error) {
 }
 
 // GetBlocksByHeight get a block list from mongo by height range
+// index: block {shardNumber, height}
 func (c *Client) GetBlocksByHeight(shardNumber int, begin uint64, end uint64) ([]*DBBlock, error) {
 	var blocks []*DBBlock
 
-	query := func(c *mgo.Collection) error {
+	query := func(c collection) error {
 
 		return c.Find(bson.M{"height": bson.M{"$gte": begin, "$lt": end}, "shardNumber": shardNumber}).Sort("-height").All(&blocks)
 	}
@@ -301,11 +409,20 @@ func (c *Client) GetBlocksByHeight(shardNumber int, begin uint64, end uint64) ([
 	return blocks, err
 }
 
+// GetBlocksByCursor get a page of the block list from mongo, the latest first
+// index: block {shardNumber, height}
+func (c *Client) GetBlocksByCursor(shardNumber int, cursor string, limit int) ([]*DBBlock, *Page, error) {
+	var blocks []*DBBlock
+	page, err := c.listByCursor(blockTbl, bson.M{"shardNumber": shardNumber}, []string{"-height"}, cursor, limit, &blocks)
+	return blocks, page, err
+}
+
 // GetBlocksByTime get a block list from mongo by time period
+// index: block {shardNumber, timestamp}
 func (c *Client) GetBlocksByTime(shardNumber int, beginTime, endTime int64) ([]*DBBlock, error) {
 	var blocks []*DBBlock
 
-	query := func(c *mgo.Collection) error {
+	query := func(c collection) error {
 
 		return c.Find(bson.M{"timestamp": bson.M{"$gte": beginTime, "$lte": endTime}, "shardNumber": shardNumber}).All(&blocks)
 	}
@@ -313,10 +430,23 @@ func (c *Client) GetBlocksByTime(shardNumber int, beginTime, endTime int64) ([]*
 	return blocks, err
 }
 
-// GetBlockHeight get row count of block table from mongo
+// GetBlockHeight get the height of the next block to sync of the shard, which is the number of
+// the blocks if the shard has no sync cursor
+// index: syncstate {shardNumber} and block {shardNumber, height}
 func (c *Client) GetBlockHeight(shardNumber int) (uint64, error) {
+	var states []*DBSyncState
+	query := func(c collection) error {
+		return c.Find(bson.M{"shardNumber": shardNumber}).All(&states)
+	}
+	if err := c.withCollection(syncStateTbl, query); err != nil {
+		return 0, err
+	}
+	if len(states) > 0 {
+		return states[0].Height, nil
+	}
+
 	var blockCnt uint64
-	query := func(c *mgo.Collection) error {
+	query = func(c collection) error {
 		var err error
 		//TODO: fix this overflow
 		var temp int
@@ -330,43 +460,77 @@ func (c *Client) GetBlockHeight(shardNumber int) (uint64, error) {
 
 // AddTx insert a transaction into mongo
 func (c *Client) AddTx(tx *DBTx) error {
-	query := func(c *mgo.Collection) error {
+	query := func(c collection) error {
 		return c.Insert(tx)
 	}
 	err := c.withCollection(txTbl, query)
-	if err ==nil {
-		txCntForShard[tx.ShardNumber] +=1
+	if err != nil {
+		return err
 	}
-	return err
+	deltas := make(statDeltas)
+	deltas.add(txStatKeys(tx), 1)
+	return c.incStats(deltas)
 }
 
 // AddTxs insert batch of transactions into mongo
 func (c *Client) AddTxs(txs ...interface{}) error {
-	query := func(c *mgo.Collection) error {
+	query := func(c collection) error {
 		return c.Insert(txs...)
 	}
 	err := c.withCollection(txTbl, query)
-	if err == nil {
-		for _,tx := range txs {
-			log.Debug("addTx shard %d",tx.(*DBTx).ShardNumber)
-			txCntForShard[tx.(*DBTx).ShardNumber]+=1
-		}
+	if err != nil {
+		return err
 	}
-	return err
+	deltas := make(statDeltas)
+	for _, tx := range txs {
+		deltas.add(txStatKeys(tx.(*DBTx)), 1)
+	}
+	return c.incStats(deltas)
 }
 
 // AddDebtTxs insert a transaction into mongo
 func (c *Client) AddDebtTxs(debttxs ...interface{}) error {
-	query := func(c *mgo.Collection) error {
+	query := func(c collection) error {
 		return c.Insert(debttxs...)
 	}
 	err := c.withCollection(debtTbl, query)
-	return err
+	if err != nil {
+		return err
+	}
+	deltas := make(statDeltas)
+	for _, debt := range debttxs {
+		deltas.add([]statKey{{name: statDebts, shardNumber: debt.(*Debt).ShardNumber}}, 1)
+	}
+	return c.incStats(deltas)
+}
+
+// RemoveDebts remove the debts of a block
+// index: debt {shardNumber, height, idx, hash}
+func (c *Client) RemoveDebts(shard int, height uint64) error {
+	var debts []*Debt
+	query := func(c collection) error {
+		selector := bson.M{"shardNumber": shard, "height": height}
+		// the removed debts are uncounted
+		if err := c.Find(selector).All(&debts); err != nil {
+			return err
+		}
+		_, err := c.RemoveAll(selector)
+		return err
+	}
+	err := c.withCollection(debtTbl, query)
+	if err != nil {
+		return err
+	}
+	deltas := make(statDeltas)
+	for _, debt := range debts {
+		deltas.add([]statKey{{name: statDebts, shardNumber: debt.ShardNumber}}, -1)
+	}
+	return c.incStats(deltas)
 }
 
 // AddPendingTx insert a pending transaction into mongo
 func (c *Client) AddPendingTx(tx *DBTx) error {
-	query := func(c *mgo.Collection) error {
+	query := func(c collection) error {
 		return c.Insert(tx)
 	}
 	err := c.withCollection(pendingTxTbl, query)
@@ -374,8 +538,9 @@ func (c *Client) AddPendingTx(tx *DBTx) error {
 }
 
 // RemoveAllPendingTxs remove all pending transactions
+// index: none, the whole collection is removed
 func (c *Client) RemoveAllPendingTxs() error {
-	query := func(c *mgo.Collection) error {
+	query := func(c collection) error {
 		_, err := c.RemoveAll(nil)
 		return err
 	}
@@ -385,7 +550,7 @@ func (c *Client) RemoveAllPendingTxs() error {
 
 // removeTx test use  remove tx by index from database
 func (c *Client) removeTx(idx uint64) error {
-	query := func(c *mgo.Collection) error {
+	query := func(c collection) error {
 		_, err := c.RemoveAll(bson.M{"idx": strconv.FormatUint(idx, 10)})
 		return err
 	}
@@ -394,24 +559,34 @@ func (c *Client) removeTx(idx uint64) error {
 }
 
 // RemoveTxs Txs by block height
+// index: transaction {shardNumber, block, idx}
 func (c *Client) RemoveTxs(shard int, blockHeight uint64) error {
-	var changeInfo *mgo.ChangeInfo
-	var err error
-	query := func(c *mgo.Collection) error {
-		changeInfo, err = c.RemoveAll(bson.M{"block": blockHeight, "shardNumber": shard})
+	var trans []*DBTx
+	query := func(c collection) error {
+		selector := bson.M{"block": blockHeight, "shardNumber": shard}
+		// the removed transactions are uncounted
+		if err := c.Find(selector).All(&trans); err != nil {
+			return err
+		}
+		_, err := c.RemoveAll(selector)
 		return err
 	}
-	err2 := c.withCollection(txTbl, query)
-	if err2 == nil {
-		txCntForShard[shard] = txCntForShard[shard] - uint64(changeInfo.Removed)
+	err := c.withCollection(txTbl, query)
+	if err != nil {
+		return err
+	}
+	deltas := make(statDeltas)
+	for _, tx := range trans {
+		deltas.add(txStatKeys(tx), -1)
 	}
-	return err2
+	return c.incStats(deltas)
 }
 
 // GetTxByIdx get transaction from mongo by idx
+// index: transaction {idx}
 func (c *Client) GetTxByIdx(idx uint64) (*DBTx, error) {
 	tx := new(DBTx)
-	query := func(c *mgo.Collection) error {
+	query := func(c collection) error {
 		return c.Find(bson.M{"idx": idx}).One(tx)
 	}
 	err := c.withCollection(txTbl, query)
@@ -419,36 +594,64 @@ func (c *Client) GetTxByIdx(idx uint64) (*DBTx, error) {
 }
 
 // GetTxsByIdx get a transaction list from mongo by time period
+// index: transaction {shardNumber, block, idx}
 func (c *Client) GetTxsByIdx(shardNumber int, begin uint64, end uint64) ([]*DBTx, error) {
 	var trans []*DBTx
-	query := func(c *mgo.Collection) error {
+	query := func(c collection) error {
 		return c.Find(bson.M{"shardNumber": shardNumber, "idx": bson.M{"$gte": begin, "$lt": end}}).Sort("-block").All(&trans)
 	}
 	err := c.withCollection(txTbl, query)
 	return trans, err
 }
 
+// GetTxsByCursor get a page of the transaction list from mongo, the latest first
+// index: transaction {shardNumber, block, idx}
+func (c *Client) GetTxsByCursor(shardNumber int, cursor string, limit int) ([]*DBTx, *Page, error) {
+	var trans []*DBTx
+	page, err := c.listByCursor(txTbl, bson.M{"shardNumber": shardNumber}, []string{"-block", "-idx"}, cursor, limit, &trans)
+	return trans, page, err
+}
+
 // GetdebtsByIdx get a debt list from mongo by time period
+// index: debt {shardNumber, height, idx, hash}
 func (c *Client) GetdebtsByIdx(shardNumber int, begin uint64, end uint64) ([]*Debt, error) {
 	var debts []*Debt
-	query := func(c *mgo.Collection) error {
+	query := func(c collection) error {
 		return c.Find(bson.M{"shardNumber": shardNumber, "idx": bson.M{"$gte": begin, "$lt": end}}).Sort("-height").All(&debts)
 	}
 	err := c.withCollection(debtTbl, query)
 	return debts, err
 }
 
+// GetdebtsByCursor get a page of the debt list from mongo, the latest first
+// index: debt {shardNumber, height, idx, hash}
+func (c *Client) GetdebtsByCursor(shardNumber int, cursor string, limit int) ([]*Debt, *Page, error) {
+	var debts []*Debt
+	page, err := c.listByCursor(debtTbl, bson.M{"shardNumber": shardNumber}, []string{"-height", "-idx"}, cursor, limit, &debts)
+	return debts, page, err
+}
+
 // GetPendingTxsByIdx get a transaction list from mongo by time period
+// index: pendingtx {shardNumber, block, idx}
 func (c *Client) GetPendingTxsByIdx(shardNumber int, begin uint64, end uint64) ([]*DBTx, error) {
 	var trans []*DBTx
-	query := func(c *mgo.Collection) error {
+	query := func(c collection) error {
 		return c.Find(bson.M{"shardNumber": shardNumber, "idx": bson.M{"$gt": begin, "$lte": end}}).Sort("-block").All(&trans)
 	}
 	err := c.withCollection(pendingTxTbl, query)
 	return trans, err
 }
 
+// GetPendingTxsByCursor get a page of the pending transaction list from mongo, the latest first
+// index: pendingtx {shardNumber, block, idx}
+func (c *Client) GetPendingTxsByCursor(shardNumber int, cursor string, limit int) ([]*DBTx, *Page, error) {
+	var trans []*DBTx
+	page, err := c.listByCursor(pendingTxTbl, bson.M{"shardNumber": shardNumber}, []string{"-block", "-idx"}, cursor, limit, &trans)
+	return trans, page, err
+}
+
 // GetTxByHash get transaction info by hash from mongo
+// index: transaction {hash}
 func (c *Client) GetTxByHash(hash string) (*DBTx, error) {
 	tx := new(DBTx)
 	if hash == "0x1a7fe6574649decbfb616deb7b40be87dab56b3a0f01725d9161e888b51b3375" {
@@ -456,27 +659,51 @@ func (c *Client) GetTxByHash(hash string) (*DBTx, error) {
 		tx.Fee = 0
 		return tx,nil
 	}
-	query := func(c *mgo.Collection) error {
+	query := func(c collection) error {
 		return c.Find(bson.M{"hash": hash}).One(tx)
 	}
 	err := c.withCollection(txTbl, query)
 	return tx, err
 }
 
+// GetTxsByHashes get the transactions by their hashes, the transactions not found are left out
+// index: transaction {hash}
+func (c *Client) GetTxsByHashes(hashes []string) ([]*DBTx, error) {
+	var trans []*DBTx
+	query := func(c collection) error {
+		return c.Find(bson.M{"hash": bson.M{"$in": hashes}}).All(&trans)
+	}
+	err := c.withCollection(txTbl, query)
+	return trans, err
+}
+
 // GetDebtByHash get debt info by hash from mongo
+// index: debt {hash}
 func (c *Client) GetDebtByHash(hash string) (*Debt, error) {
 	debt := new(Debt)
-	query := func(c *mgo.Collection) error {
+	query := func(c collection) error {
 		return c.Find(bson.M{"hash": hash}).One(debt)
 	}
 	err := c.withCollection(debtTbl, query)
 	return debt, err
 }
 
+// GetDebtsByHashes get the debts by their hashes, the debts not found are left out
+// index: debt {hash}
+func (c *Client) GetDebtsByHashes(hashes []string) ([]*Debt, error) {
+	var debts []*Debt
+	query := func(c collection) error {
+		return c.Find(bson.M{"hash": bson.M{"$in": hashes}}).All(&debts)
+	}
+	err := c.withCollection(debtTbl, query)
+	return debts, err
+}
+
 // GetblockdebtsByIdx get a debt list from mongo by time period
+// index: debt {shardNumber, height, idx, hash}
 func (c *Client) GetblockdebtsByIdx(shardNumber int, height uint64, begin uint64, end uint64) ([]*Debt, error) {
 	var debts []*Debt
-	query := func(c *mgo.Collection) error {
+	query := func(c collection) error {
 		return c.Find(bson.M{"shardNumber": shardNumber, "height": height}).Sort("-height").All(&debts)
 	}
 
@@ -484,10 +711,55 @@ func (c *Client) GetblockdebtsByIdx(shardNumber int, height uint64, begin uint64
 	return debts, err
 }
 
+// GetTxsByBlock get the transactions of a block in the order of the block
+// index: transaction {shardNumber, block, idx}
+func (c *Client) GetTxsByBlock(shardNumber int, height uint64) ([]*DBTx, error) {
+	var trans []*DBTx
+	query := func(c collection) error {
+		return c.Find(bson.M{"shardNumber": shardNumber, "block": height}).Sort("idx").All(&trans)
+	}
+	err := c.withCollection(txTbl, query)
+	return trans, err
+}
+
+// GetTxsByBlocks get the transactions of several blocks of a shard in the order of the blocks
+// index: transaction {shardNumber, block, idx}
+func (c *Client) GetTxsByBlocks(shardNumber int, heights []uint64) ([]*DBTx, error) {
+	var trans []*DBTx
+	query := func(c collection) error {
+		return c.Find(bson.M{"shardNumber": shardNumber, "block": bson.M{"$in": heights}}).Sort("block", "idx").All(&trans)
+	}
+	err := c.withCollection(txTbl, query)
+	return trans, err
+}
+
+// GetDebtsByBlock get the debts of a block in the order of the block
+// index: debt {shardNumber, height, idx, hash}
+func (c *Client) GetDebtsByBlock(shardNumber int, height uint64) ([]*Debt, error) {
+	var debts []*Debt
+	query := func(c collection) error {
+		return c.Find(bson.M{"shardNumber": shardNumber, "height": height}).Sort("idx", "hash").All(&debts)
+	}
+	err := c.withCollection(debtTbl, query)
+	return debts, err
+}
+
+// GetDebtsByBlocks get the debts of several blocks of a shard in the order of the blocks
+// index: debt {shardNumber, height, idx, hash}
+func (c *Client) GetDebtsByBlocks(shardNumber int, heights []uint64) ([]*Debt, error) {
+	var debts []*Debt
+	query := func(c collection) error {
+		return c.Find(bson.M{"shardNumber": shardNumber, "height": bson.M{"$in": heights}}).Sort("height", "idx", "hash").All(&debts)
+	}
+	err := c.withCollection(debtTbl, query)
+	return debts, err
+}
+
 // GetPendingTxByHash get pending transactions by hash
+// index: pendingtx {hash}
 func (c *Client) GetPendingTxByHash(hash string) (*DBTx, error) {
 	tx := new(DBTx)
-	query := func(c *mgo.Collection) error {
+	query := func(c collection) error {
 		return c.Find(bson.M{"hash": hash}).One(tx)
 	}
 	err := c.withCollection(pendingTxTbl, query)
@@ -495,6 +767,7 @@ func (c *Client) GetPendingTxByHash(hash string) (*DBTx, error) {
 }
 
 // GetTxsDayCount get row count of transaction table from mongo
+// index: transaction {timestamp}
 func (c *Client) GetTxsDayCount() ([]*DBTx, error) {
 	var txs []*DBTx
 	nTime := time.Now()
@@ -503,10 +776,8 @@ func (c *Client) GetTxsDayCount() ([]*DBTx, error) {
 	timeLayout := "20060102"
 	loc, _ := time.LoadLocation("Local")
 	theTime, _ := time.ParseInLocation(timeLayout, logDay, loc)
-	begin := theTime.Unix()
-
-	beginTime := strconv.FormatInt(begin, 10)
-	query := func(c *mgo.Collection) error {
+	beginTime := theTime.Unix()
+	query := func(c collection) error {
 		var err error
 		c.Find(bson.M{"timestamp": bson.M{"$gte": beginTime}}).All(&txs)
 		return err
@@ -517,75 +788,60 @@ func (c *Client) GetTxsDayCount() ([]*DBTx, error) {
 
 }
 
-// GetTxCnt get row count of transaction table from mongo
+// GetTxCnt get the transaction count of all the shards
+// index: stats {address, name, shardNumber}
 func (c *Client) GetTxCnt() (uint64, error) {
-	var txCnt uint64
-	query := func(c *mgo.Collection) error {
-		var err error
-		//TODO: fix this overflow
-		var temp int
-		temp, err = c.Count()
-		txCnt = uint64(temp)
-		return err
-	}
-	err := c.withCollection(txTbl, query)
-	return txCnt, err
+	txCnt, err := c.getStatOfAllShards(statTxs, "")
+	return uint64(txCnt), err
 }
 
 // GetBlockProTime gets the information of last two blocks
+// index: lastBlocks {timestamp}
 func (c *Client) GetBlockProTime() (int64, int64, error) {
 	var blocks []*DBLastBlock
 	var blockProTime, lastBlockHeight int64
-	query := func(c *mgo.Collection) error {
+	query := func(c collection) error {
 		err := c.Find(bson.M{}).Sort("-timestamp").Limit(2).All(&blocks)
-		begin := blocks[1].Timestamp
-		end := blocks[0].Timestamp
-		blockProTime = end - begin
+		if err != nil || len(blocks) == 0 {
+			return err
+		}
 		lastBlockHeight = blocks[0].Height
-		return err
+		// the production time needs two blocks, the first synced block has none
+		if len(blocks) > 1 {
+			blockProTime = blocks[0].Timestamp - blocks[1].Timestamp
+		}
+		return nil
 	}
 	err := c.withCollection(lastBlocksTbl, query)
 	return lastBlockHeight, blockProTime, err
 }
 
-// GetBlockCnt get row count of transaction table from mongo
+// GetBlockCnt get the block count of all the shards
+// index: stats {address, name, shardNumber}
 func (c *Client) GetBlockCnt() (uint64, error) {
-	var blockCnt uint64
-	query := func(c *mgo.Collection) error {
-		var err error
-		//TODO: fix this overflow
-		var temp int
-		temp, err = c.Count()
-		blockCnt = uint64(temp)
-		return err
-	}
-	err := c.withCollection(blockTbl, query)
-	return blockCnt, err
+	blockCnt, err := c.getStatOfAllShards(statBlocks, "")
+	return uint64(blockCnt), err
 }
 
 // GetAccountCnt get account count
+// index: stats {address, name, shardNumber}
 func (c *Client) GetAccountCnt() (uint64, error) {
-	var txCnt uint64
-	query := func(c *mgo.Collection) error {
-		var err error
-		//TODO: fix this overflow
-		var temp int
-		temp, err = c.Find(bson.M{"accType": 0}).Count()
-		txCnt = uint64(temp)
-		return err
-	}
-	err := c.withCollection(accTbl, query)
-	return txCnt, err
+	accountCnt, err := c.getStatOfAllShards(statAccounts, "")
+	return uint64(accountCnt), err
 }
 
 // GetBlockTxsTps  From a block transaction throughput TPS
+// index: lastBlocks {timestamp}
 func (c *Client) GetBlockTxsTps() (float64, error) {
 	var blocksTpx float64
 	var Txs, Blockprotime int64
-	query := func(c *mgo.Collection) error {
+	query := func(c collection) error {
 		var err error
 		var blocks []*DBLastBlock
 		c.Find(bson.M{}).Sort("-timestamp").Limit(2).All(&blocks)
+		if len(blocks) < 2 {
+			return err
+		}
 		Txs = int64(blocks[0].TxNumber)
 		Blockprotime = int64(blocks[0].Timestamp - blocks[1].Timestamp)
 		if Blockprotime == 0 {
@@ -601,98 +857,45 @@ func (c *Client) GetBlockTxsTps() (float64, error) {
 }
 
 // GetContractCnt get contract count
+// index: stats {address, name, shardNumber}
 func (c *Client) GetContractCnt() (uint64, error) {
-	var txCnt uint64
-	query := func(c *mgo.Collection) error {
-		var err error
-		//TODO: fix this overflow
-		var temp int
-		temp, err = c.Find(bson.M{"accType": 1}).Count()
-		txCnt = uint64(temp)
-		return err
-	}
-	err := c.withCollection(accTbl, query)
-	return txCnt, err
+	contractCnt, err := c.getStatOfAllShards(statContracts, "")
+	return uint64(contractCnt), err
 }
 
-// GetAccountCntByShardNumber get contract count
+// GetAccountCntByShardNumber get account count
+// index: stats {address, name, shardNumber}
 func (c *Client) GetAccountCntByShardNumber(shardNumber int) (uint64, error) {
-	var txCnt uint64
-	query := func(c *mgo.Collection) error {
-		var err error
-		//TODO: fix this overflow
-		var temp int
-		temp, err = c.Find(bson.M{"accType": 0, "shardnumber": shardNumber}).Count()
-		txCnt = uint64(temp)
-		return err
-	}
-	err := c.withCollection(accTbl, query)
-	return txCnt, err
+	accountCnt, err := c.getStat(statAccounts, shardNumber, "")
+	return uint64(accountCnt), err
 }
 
 // GetContractCntByShardNumber get contract count
+// index: stats {address, name, shardNumber}
 func (c *Client) GetContractCntByShardNumber(shardNumber int) (uint64, error) {
-	var txCnt uint64
-	query := func(c *mgo.Collection) error {
-		var err error
-		//TODO: fix this overflow
-		var temp int
-		temp, err = c.Find(bson.M{"accType": 1, "shardnumber": shardNumber}).Count()
-		txCnt = uint64(temp)
-		return err
-	}
-	err := c.withCollection(accTbl, query)
-	return txCnt, err
-}
-
-func (c *Client) InitTxCntByShardNumber(shardNumber int) (error) {
-	var txCnt uint64
-	query := func(c *mgo.Collection) error {
-		var err error
-		//TODO: fix this overflow
-		var temp int
-		temp, err = c.Find(bson.M{"shardNumber": shardNumber}).Count()
-		txCnt = uint64(temp)
-		return err
-	}
-	err := c.withCollection(txTbl, query)
-	if err == nil {
-		txCntForShard[shardNumber] = txCnt
-	}
-	return err
+	contractCnt, err := c.getStat(statContracts, shardNumber, "")
+	return uint64(contractCnt), err
 }
 
 // GetTxCntByShardNumber get tx count by shardNumber
+// index: stats {address, name, shardNumber}
 func (c *Client) GetTxCntByShardNumber(shardNumber int) (uint64, error) {
-	if txCntForShard[shardNumber] !=0 {
-		return txCntForShard[shardNumber],nil
-	}else{
-		err := c.InitTxCntByShardNumber(shardNumber)
-		if err != nil {
-			return 0, err
-		}
-		return txCntForShard[shardNumber],nil
-	}
+	txCnt, err := c.getStat(statTxs, shardNumber, "")
+	return uint64(txCnt), err
 }
 
-// GetdebtCntByShardNumber get tx count by shardNumber
+// GetdebtCntByShardNumber get debt count by shardNumber
+// index: stats {address, name, shardNumber}
 func (c *Client) GetdebtCntByShardNumber(shardNumber int) (uint64, error) {
-	var txCnt uint64
-	query := func(c *mgo.Collection) error {
-		var err error
-		var temp int
-		temp, err = c.Find(bson.M{"shardNumber": shardNumber}).Count()
-		txCnt = uint64(temp)
-		return err
-	}
-	err := c.withCollection(debtTbl, query)
-	return txCnt, err
+	debtCnt, err := c.getStat(statDebts, shardNumber, "")
+	return uint64(debtCnt), err
 }
 
 // GetPendingTxCntByShardNumber get pending transactions by shard number
+// index: pendingtx {shardNumber, block, idx}
 func (c *Client) GetPendingTxCntByShardNumber(shardNumber int) (uint64, error) {
 	var txCnt uint64
-	query := func(c *mgo.Collection) error {
+	query := func(c collection) error {
 		var err error
 		//TODO: fix this overflow
 		var temp int
@@ -704,29 +907,20 @@ func (c *Client) GetPendingTxCntByShardNumber(shardNumber int) (uint64, error) {
 	return txCnt, err
 }
 
-// GetTxCntByShardNumberAndAddress get tx count for the account
+// GetTxCntByShardNumberAndAddress get tx count for the account, the count of all the shards if shardNumber is negative
+// index: stats {address, name, shardNumber}
 func (c *Client) GetTxCntByShardNumberAndAddress(shardNumber int, address string) (int64, error) {
-	var txCnt int64
-	query := func(c *mgo.Collection) error {
-		var err error
-		//TODO: fix this overflow
-		var temp int
-		if shardNumber < 0 {
-			temp, err = c.Find(bson.M{"$or": []bson.M{bson.M{"from": address}, bson.M{"to": address}, bson.M{"contractAddress": address}}}).Count()
-		}else{
-			temp, err = c.Find(bson.M{"shardNumber": shardNumber, "$or": []bson.M{bson.M{"from": address}, bson.M{"to": address}, bson.M{"contractAddress": address}}}).Count()
-		}
-		txCnt = int64(temp)
-		return err
+	if shardNumber < 0 {
+		return c.getStatOfAllShards(statAddressTxs, address)
 	}
-	err := c.withCollection(txTbl, query)
-	return txCnt, err
+	return c.getStat(statAddressTxs, shardNumber, address)
 }
 
 // GetMinedBlocksCntByShardNumberAndAddress get the blocks number by the miner
+// index: block {shardNumber, creator}
 func (c *Client) GetMinedBlocksCntByShardNumberAndAddress(shardNumber int, address string) (int64, error) {
 	var blockCnt int64
-	query := func(c *mgo.Collection) error {
+	query := func(c collection) error {
 		var err error
 		//TODO: fix this overflow
 		var temp int
@@ -744,7 +938,7 @@ func (c *Client) GetMinedBlocksCntByShardNumberAndAddress(shardNumber int, addre
 //func (c *Client) GetMinedBlocksByShardNumberAndAddress(shardNumber int, address string) (int64, int64, int64, error) {
 //	var blockCnt, blockFee, blockAmount int64
 //	var blocks []*DBBlock
-//	query := func(c *mgo.Collection) error {
+//	query := func(c collection) error {
 //		var err error
 //		c.Find(bson.M{"shardNumber": shardNumber, "creator": address}).All(&blocks)
 //		blockCnt = int64(len(blocks))
@@ -771,10 +965,13 @@ func (c *Client) GetMinedBlocksCntByShardNumberAndAddress(shardNumber int, addre
 //	err := c.withCollection(blockTbl, query)
 //	return blockCnt, blockFee, blockAmount, err
 //}
+
+// GetMinedBlocksByShardNumberAndAddress get the mined block count, fees and rewards of the miner
+// index: miner {address}
 func (c *Client) GetMinedBlocksByShardNumberAndAddress(shardNumber int, address string) (int64, int64, int64, error) {
 	var blockCnt, blockFee, blockAmount int64
 	var miner *DBMiner
-	query := func(c *mgo.Collection) error {
+	query := func(c collection) error {
 		var err error
 		c.Find(bson.M{"shardNumber": shardNumber, "address": address}).One(&miner)
 		if miner !=nil {
@@ -790,9 +987,10 @@ func (c *Client) GetMinedBlocksByShardNumberAndAddress(shardNumber int, address
 	return blockCnt, blockFee, blockAmount, err
 }
 // GetBlockfee get the total fee of the block
+// index: transaction {block}
 func (c *Client) GetBlockfee(block uint64) (int64, error) {
 	var blockFee int64
-	query := func(c *mgo.Collection) error {
+	query := func(c collection) error {
 		var err error
 		var trans []*DBTx
 		c.Find(bson.M{"block": block}).All(&trans)
@@ -809,7 +1007,7 @@ func (c *Client) GetBlockfee(block uint64) (int64, error) {
 
 // removeAccount test use  remove account by address from database
 func (c *Client) removeAccount(address string) error {
-	query := func(c *mgo.Collection) error {
+	query := func(c collection) error {
 		return c.Remove(bson.M{"address": address})
 	}
 	err := c.withCollection(accTbl, query)
@@ -817,11 +1015,12 @@ func (c *Client) removeAccount(address string) error {
 }
 
 // GetTxsByAddresses return a tx list by address
+// index: transaction {from, block, idx}, {to, block, idx} and {contractAddress, block, idx}
 func (c *Client) GetTxsByAddresses(address string, asc bool, limit int, skip int) ([]*DBTx, error) {
 	var trans []*DBTx
 	sort1 := "block"
 	sort2 := "idx"
-	query := func(c *mgo.Collection) error {
+	query := func(c collection) error {
 		if !asc { //desc sort
 			sort1 = "-block"
 			sort2 = "-idx"
@@ -846,30 +1045,250 @@ func (c *Client) GetTxsByAddresses(address string, asc bool, limit int, skip int
 
 }
 
+// GetTxsByAddressAndBlocks return the transactions from or to the address in the blocks from
+// startBlock to endBlock, the blocks are of the shard of every transaction
+// index: transaction {from, block, idx}, {to, block, idx} and {contractAddress, block, idx}
+func (c *Client) GetTxsByAddressAndBlocks(address string, startBlock uint64, endBlock uint64, asc bool, limit int, skip int) ([]*DBTx, error) {
+	var trans []*DBTx
+	blocks := bson.M{"$gte": startBlock, "$lte": endBlock}
+	filter := bson.M{"$or": []bson.M{
+		{"from": address, "block": blocks},
+		{"to": address, "block": blocks},
+		{"contractAddress": address, "block": blocks},
+	}}
+	sort := []string{"block", "idx"}
+	if !asc {
+		sort = []string{"-block", "-idx"}
+	}
+	query := func(c collection) error {
+		q := c.Find(filter).Sort(sort...)
+		if skip > 0 {
+			q = q.Skip(skip)
+		}
+		if limit > 0 {
+			q = q.Limit(limit)
+		}
+		return q.All(&trans)
+	}
+	err := c.withCollection(txTbl, query)
+	return trans, err
+}
+
+// GetTxsByAddressCursor get a page of the transactions from or to the address, the latest first
+// index: transaction {from, block, idx}, {to, block, idx} and {contractAddress, block, idx}
+func (c *Client) GetTxsByAddressCursor(address string, cursor string, limit int) ([]*DBTx, *Page, error) {
+	var trans []*DBTx
+	filter := bson.M{"$or": []bson.M{{"from": address}, {"to": address}, {"contractAddress": address}}}
+	page, err := c.listByCursor(txTbl, filter, []string{"-block", "-idx"}, cursor, limit, &trans)
+	return trans, page, err
+}
+
 // GetPendingTxsByAddress return a pending tx list by address
+// index: pendingtx {from, block, idx}, {to, block, idx} and {contractAddress, block, idx}
 func (c *Client) GetPendingTxsByAddress(address string) ([]*DBTx, error) {
 	var trans []*DBTx
-	query := func(c *mgo.Collection) error {
+	query := func(c collection) error {
 		return c.Find(bson.M{"$or": []bson.M{bson.M{"from": address}, bson.M{"to": address}, bson.M{"contractAddress": address}}}).Sort("-block", "-idx").All(&trans)
 	}
 	err := c.withCollection(pendingTxTbl, query)
 	return trans, err
 }
 
+// AddAddressActivities insert the activity entries of the addresses into mongo
+func (c *Client) AddAddressActivities(activities ...*DBAddressActivity) error {
+	if len(activities) == 0 {
+		return nil
+	}
+	docs := make([]interface{}, len(activities))
+	deltas := make(statDeltas)
+	for i, activity := range activities {
+		docs[i] = activity
+		deltas.add(activityStatKeys(activity), 1)
+	}
+	query := func(c collection) error {
+		return c.Insert(docs...)
+	}
+	err := c.withCollection(activityTbl, query)
+	if err != nil {
+		return err
+	}
+	return c.incStats(deltas)
+}
+
+// upsertAddressActivities write the activity entries without maintaining the counters,
+// an entry which already exists is replaced
+// index: address_activity {address, block, idx, kind}
+func (c *Client) upsertAddressActivities(activities []*DBAddressActivity) error {
+	query := func(c collection) error {
+		for _, activity := range activities {
+			selector := bson.M{"address": activity.Address, "block": activity.Block, "idx": activity.Idx, "kind": activity.Kind, "shardNumber": activity.ShardNumber}
+			if _, err := c.Upsert(selector, activity); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return c.withCollection(activityTbl, query)
+}
+
+// RemoveAddressActivities remove the activity entries of a block
+// index: address_activity {shardNumber, block}
+func (c *Client) RemoveAddressActivities(shard int, blockHeight uint64) error {
+	var activities []*DBAddressActivity
+	query := func(c collection) error {
+		selector := bson.M{"shardNumber": shard, "block": blockHeight}
+		if err := c.Find(selector).All(&activities); err != nil {
+			return err
+		}
+		_, err := c.RemoveAll(selector)
+		return err
+	}
+	err := c.withCollection(activityTbl, query)
+	if err != nil {
+		return err
+	}
+	deltas := make(statDeltas)
+	for _, activity := range activities {
+		deltas.add(activityStatKeys(activity), -1)
+	}
+	return c.incStats(deltas)
+}
+
+// activityFilter return the filter of the activities of the address in the direction
+func activityFilter(address string, direction string) (bson.M, error) {
+	filter := bson.M{"address": address}
+	switch direction {
+	case "":
+	case ActivityOut:
+		filter["kind"] = ActivitySent
+	case ActivityIn:
+		filter["kind"] = bson.M{"$in": []string{ActivityReceived, ActivityCreated, ActivityDebtIn, ActivityReward}}
+	default:
+		return nil, ErrInvalidDirection
+	}
+	return filter, nil
+}
+
+// GetAddressActivities return the activities of the address in the direction, the latest first,
+// all the activities if direction is empty
+// index: address_activity {address, block, idx, kind}
+func (c *Client) GetAddressActivities(address string, direction string, limit int, skip int) ([]*DBAddressActivity, error) {
+	filter, err := activityFilter(address, direction)
+	if err != nil {
+		return nil, err
+	}
+	var activities []*DBAddressActivity
+	query := func(c collection) error {
+		q := c.Find(filter).Sort("-block", "-idx", "-kind")
+		if skip > 0 {
+			q = q.Skip(skip)
+		}
+		if limit > 0 {
+			q = q.Limit(limit)
+		}
+		return q.All(&activities)
+	}
+	err = c.withCollection(activityTbl, query)
+	return activities, err
+}
+
+// GetAddressActivitiesByCursor get a page of the activities of the address in the direction, the latest first
+// index: address_activity {address, block, idx, kind}
+func (c *Client) GetAddressActivitiesByCursor(address string, direction string, cursor string, limit int) ([]*DBAddressActivity, *Page, error) {
+	filter, err := activityFilter(address, direction)
+	if err != nil {
+		return nil, nil, err
+	}
+	var activities []*DBAddressActivity
+	page, err := c.listByCursor(activityTbl, filter, []string{"-block", "-idx", "-kind"}, cursor, limit, &activities)
+	return activities, page, err
+}
+
+// EachAddressActivity stream all the activities of the address in batches, the oldest first. fn is
+// called with every batch, the streaming stops at the first error of fn which is returned
+// index: address_activity {address, block, idx, kind}
+func (c *Client) EachAddressActivity(address string, batchSize int, fn func(activities []*DBAddressActivity) error) error {
+	if batchSize <= 0 {
+		batchSize = defaultMigrationBatchSize
+	}
+	sortFields := []string{"block", "idx", "kind"}
+	filter := bson.M{"address": address}
+	selector := filter
+	for {
+		var activities []*DBAddressActivity
+		query := func(c collection) error {
+			return c.Find(selector).Sort(sortFields...).Limit(batchSize).All(&activities)
+		}
+		if err := c.withCollection(activityTbl, query); err != nil {
+			return err
+		}
+		if len(activities) == 0 {
+			return nil
+		}
+		if err := fn(activities); err != nil {
+			return err
+		}
+		if len(activities) < batchSize {
+			return nil
+		}
+		last := activities[len(activities)-1]
+		selector = keysetFilter(filter, sortFields, []interface{}{last.Block, last.Idx, last.Kind})
+	}
+}
+
+// GetAddressActivityCnt get the number of the activities of the address in the direction in all the shards
+// index: stats {address, name, shardNumber}
+func (c *Client) GetAddressActivityCnt(address string, direction string) (int64, error) {
+	var names []string
+	switch direction {
+	case "":
+		names = []string{statActivitiesIn, statActivitiesOut}
+	case ActivityOut:
+		names = []string{statActivitiesOut}
+	case ActivityIn:
+		names = []string{statActivitiesIn}
+	default:
+		return 0, ErrInvalidDirection
+	}
+
+	var count int64
+	for _, name := range names {
+		n, err := c.getStatOfAllShards(name, address)
+		if err != nil {
+			return 0, err
+		}
+		count += n
+	}
+	return count, nil
+}
+
 //GetAccountByAddress get an dbaccount by account address
+// index: account {address}
 func (c *Client) GetAccountByAddress(address string) (*DBAccount, error) {
 	account := new(DBAccount)
-	query := func(c *mgo.Collection) error {
+	query := func(c collection) error {
 		return c.Find(bson.M{"address": address}).One(account)
 	}
 	err := c.withCollection(accTbl, query)
 	return account, err
 }
 
+// GetAccountsByAddresses get the accounts by their addresses, the accounts not found are left out
+// index: account {address}
+func (c *Client) GetAccountsByAddresses(addresses []string) ([]*DBAccount, error) {
+	var accounts []*DBAccount
+	query := func(c collection) error {
+		return c.Find(bson.M{"address": bson.M{"$in": addresses}}).All(&accounts)
+	}
+	err := c.withCollection(accTbl, query)
+	return accounts, err
+}
+
 // GetMinerAccountByAddress get an dbaccount by account address
+// index: miner {address}
 func (c *Client) GetMinerAccountByAddress(address string) (*DBMiner, error) {
 	miner := new(DBMiner)
-	query := func(c *mgo.Collection) error {
+	query := func(c collection) error {
 		return c.Find(bson.M{"address": address}).One(miner)
 	}
 	err := c.withCollection(minerTbl, query)
@@ -878,16 +1297,22 @@ func (c *Client) GetMinerAccountByAddress(address string) (*DBMiner, error) {
 
 //AddAccount insert an account into database
 func (c *Client) AddAccount(account *DBAccount) error {
-	query := func(c *mgo.Collection) error {
+	query := func(c collection) error {
 		return c.Insert(account)
 	}
 	err := c.withCollection(accTbl, query)
-	return err
+	if err != nil {
+		return err
+	}
+	deltas := make(statDeltas)
+	deltas.add(accountStatKeys(account.AccType, account.ShardNumber), 1)
+	return c.incStats(deltas)
 }
 
 // UpdateMinerAccount update account
+// index: miner {address}
 func (c *Client) UpdateMinerAccount(miner *DBMiner) error {
-	query := func(c *mgo.Collection) error {
+	query := func(c collection) error {
 		_, err := c.Upsert(bson.M{"address": miner.Address}, miner)
 		return err
 	}
@@ -899,33 +1324,86 @@ func (c *Client) UpdateMinerAccount(miner *DBMiner) error {
 	return err
 }
 
+// IncMinerAccount add the mined block count and the revenue breakdown of the miner,
+// negative values are used to revert the blocks removed by a reorg
+// index: miner {address}
+func (c *Client) IncMinerAccount(miner *DBMiner) error {
+	set := bson.M{"shardNumber": miner.ShardNumber}
+	if miner.TimeStamp > 0 {
+		set["timestamp"] = miner.TimeStamp
+	}
+	if miner.Pool != "" {
+		set["pool"] = miner.Pool
+	}
+
+	query := func(c collection) error {
+		_, err := c.Upsert(bson.M{"address": miner.Address}, bson.M{
+			"$set": set,
+			"$inc": bson.M{
+				"mined":   miner.Mined,
+				"reward":  miner.Reward,
+				"fee":     miner.TxFee,
+				"debtFee": miner.DebtFee,
+				"total":   miner.Revenue,
+			},
+		})
+		return err
+	}
+	return c.withCollection(minerTbl, query)
+}
+
 // GetMinerAccounts get the size of DBMiner
+// index: miner {total}
 func (c *Client) GetMinerAccounts(size int) ([]*DBMiner, error) {
 	var miners []*DBMiner
-	query := func(c *mgo.Collection) error {
+	query := func(c collection) error {
 		return c.Find(bson.M{}).Sort("-total").Limit(size).All(&miners)
 	}
 	err := c.withCollection(minerTbl, query)
 	return miners, err
 }
 
-// UpdateAccount update account
+// UpdateAccount update account, the verified source code and abi of a contract are kept
+// index: account {address}
 func (c *Client) UpdateAccount(account *DBAccount) error {
-	query := func(c *mgo.Collection) error {
-		_, err := c.Upsert(bson.M{"address": account.Address}, account)
+	fields := bson.M{
+		"accType":     account.AccType,
+		"address":     account.Address,
+		"balance":     account.Balance,
+		"shardNumber": account.ShardNumber,
+		"txCount":     account.TxCount,
+		"timestamp":   account.TimeStamp,
+	}
+	if account.Creator != "" {
+		fields["creator"] = account.Creator
+		fields["creationTx"] = account.CreationTx
+		fields["creationBlock"] = account.CreationBlock
+	}
+	if account.CodeHash != "" {
+		fields["codeHash"] = account.CodeHash
+	}
+
+	var changeInfo *mgo.ChangeInfo
+	query := func(c collection) error {
+		var err error
+		changeInfo, err = c.Upsert(bson.M{"address": account.Address}, bson.M{"$set": fields})
 		return err
 	}
 	err := c.withCollection(accTbl, query)
-	if err != nil {
+	if err != nil || changeInfo.UpsertedId == nil {
 		return err
 	}
 
-	return err
+	// a new account is inserted
+	deltas := make(statDeltas)
+	deltas.add(accountStatKeys(account.AccType, account.ShardNumber), 1)
+	return c.incStats(deltas)
 }
 
 // UpdateAccountMinedBlock update field mined block in the account info
+// index: account {address}
 func (c *Client) UpdateAccountMinedBlock(address string, mined int64) error {
-	query := func(c *mgo.Collection) error {
+	query := func(c collection) error {
 		return c.Update(bson.M{"address": address},
 			bson.M{"$set": bson.M{
 				"mined": mined,
@@ -936,41 +1414,120 @@ func (c *Client) UpdateAccountMinedBlock(address string, mined int64) error {
 }
 
 // GetAccountsByShardNumber get an dbaccount list sort by balance
+// index: account {accType, shardNumber, balance, address}
 func (c *Client) GetAccountsByShardNumber(shardNumber int, max int) ([]*DBAccount, error) {
 	var accounts []*DBAccount
-	query := func(c *mgo.Collection) error {
+	query := func(c collection) error {
 		return c.Find(bson.M{"accType": 0, "shardNumber": shardNumber}).Sort("-balance").Limit(max).All(&accounts)
 	}
 	err := c.withCollection(accTbl, query)
 	return accounts, err
 }
 
+// GetAccountsByCursor get a page of the account list sort by balance
+// index: account {accType, shardNumber, balance, address}
+func (c *Client) GetAccountsByCursor(shardNumber int, cursor string, limit int) ([]*DBAccount, *Page, error) {
+	var accounts []*DBAccount
+	filter := bson.M{"accType": 0, "shardNumber": shardNumber}
+	page, err := c.listByCursor(accTbl, filter, []string{"-balance", "-address"}, cursor, limit, &accounts)
+	return accounts, page, err
+}
+
 // GetContractsByShardNumber get the contracts number by shard number
+// index: account {accType, shardNumber, timestamp}
 func (c *Client) GetContractsByShardNumber(shardNumber int, max int) ([]*DBAccount, error) {
 	var accounts []*DBAccount
-	query := func(c *mgo.Collection) error {
+	query := func(c collection) error {
 		return c.Find(bson.M{"accType": 1, "shardNumber": shardNumber}).Sort("-timestamp").Limit(max).All(&accounts)
 	}
 	err := c.withCollection(accTbl, query)
 	return accounts, err
 }
 
+// GetContractsByCreator get the contracts deployed by the creator, the latest first
+// index: account {creator, creationBlock}
+func (c *Client) GetContractsByCreator(creator string, skip int, limit int) ([]*DBAccount, error) {
+	var accounts []*DBAccount
+	query := func(c collection) error {
+		return c.Find(bson.M{"accType": 1, "creator": creator}).Sort("-creationBlock").Skip(skip).Limit(limit).All(&accounts)
+	}
+	err := c.withCollection(accTbl, query)
+	return accounts, err
+}
+
+// GetContractCntByCreator get the number of contracts deployed by the creator
+// index: account {creator, creationBlock}
+func (c *Client) GetContractCntByCreator(creator string) (uint64, error) {
+	var contractCnt int
+	query := func(c collection) error {
+		var err error
+		contractCnt, err = c.Find(bson.M{"accType": 1, "creator": creator}).Count()
+		return err
+	}
+	err := c.withCollection(accTbl, query)
+	return uint64(contractCnt), err
+}
+
+// GetContractsByCodeHash get the contracts with the same runtime bytecode hash, the earliest first
+// index: account {codeHash, creationBlock}
+func (c *Client) GetContractsByCodeHash(codeHash string, skip int, limit int) ([]*DBAccount, error) {
+	var accounts []*DBAccount
+	query := func(c collection) error {
+		return c.Find(bson.M{"accType": 1, "codeHash": codeHash}).Sort("creationBlock").Skip(skip).Limit(limit).All(&accounts)
+	}
+	err := c.withCollection(accTbl, query)
+	return accounts, err
+}
+
+// GetContractCntByCodeHash get the number of contracts with the same runtime bytecode hash
+// index: account {codeHash, creationBlock}
+func (c *Client) GetContractCntByCodeHash(codeHash string) (uint64, error) {
+	var contractCnt int
+	query := func(c collection) error {
+		var err error
+		contractCnt, err = c.Find(bson.M{"accType": 1, "codeHash": codeHash}).Count()
+		return err
+	}
+	err := c.withCollection(accTbl, query)
+	return uint64(contractCnt), err
+}
+
+// GetCodeHashGroups get the bytecode hashes shared by at least minCount contracts, the largest group first
+// and the groups of the same size in the order of the hashes, so that the pages are stable.
+// At most maxAddresses contract addresses are returned for each group.
+// index: account {codeHash, creationBlock}
+func (c *Client) GetCodeHashGroups(minCount int, maxAddresses int, skip int, limit int) ([]*DBCodeHashGroup, error) {
+	var groups []*DBCodeHashGroup
+	query := func(c collection) error {
+		pipe := c.Pipe([]bson.M{
+			{"$match": bson.M{"accType": 1, "codeHash": bson.M{"$exists": true, "$ne": ""}}},
+			{"$group": bson.M{"_id": "$codeHash", "count": bson.M{"$sum": 1}, "addresses": bson.M{"$push": "$address"}}},
+			{"$match": bson.M{"count": bson.M{"$gte": minCount}}},
+			{"$sort": bson.D{{Name: "count", Value: -1}, {Name: "_id", Value: 1}}},
+			{"$skip": skip},
+			{"$limit": limit},
+			{"$project": bson.M{"count": 1, "addresses": bson.M{"$slice": []interface{}{"$addresses", maxAddresses}}}},
+		})
+		return pipe.All(&groups)
+	}
+	err := c.withCollection(accTbl, query)
+	return groups, err
+}
+
 // GetTotalBalance return the sum of all account
+// index: none, all the accounts are aggregated
 func (c *Client) GetTotalBalance() (map[int]int64, error) {
 	totalBalance := make(map[int]int64)
-	query := func(c *mgo.Collection) error {
+	query := func(c collection) error {
 
-		job := &mgo.MapReduce{
-			Map: "function() { emit(this.shardNumber, this.balance) }",
-			Reduce: `function(key, values) {
-						return Array.sum(values)
-					}`,
-		}
+		pipe := c.Pipe([]bson.M{
+			{"$group": bson.M{"_id": "$shardNumber", "value": bson.M{"$sum": "$balance"}}},
+		})
 		var result []struct {
-			ID    int "_id"
-			Value int64
+			ID    int   `bson:"_id"`
+			Value int64 `bson:"value"`
 		}
-		_, err := c.Find(nil).MapReduce(job, &result)
+		err := pipe.All(&result)
 		if err != nil {
 			return err
 		}
@@ -984,6 +1541,80 @@ func (c *Client) GetTotalBalance() (map[int]int64, error) {
 	return totalBalance, err
 }
 
+// SetGenesisSupply set the sum of the genesis allocations of the shard
+// index: supply {shardNumber}
+func (c *Client) SetGenesisSupply(shardNumber int, genesis int64) error {
+	query := func(c collection) error {
+		_, err := c.Upsert(bson.M{"shardNumber": shardNumber}, bson.M{"$set": bson.M{"genesis": genesis}})
+		return err
+	}
+	return c.withCollection(supplyTbl, query)
+}
+
+// IncSupply add the coins minted and fees paid by a block to the shard supply and the supply
+// of the day, negative values are used to revert the blocks removed by a reorg
+// index: supply {shardNumber} and chart_supply {shardnumber, timestamp}
+func (c *Client) IncSupply(t *DBOneDaySupply) error {
+	inc := bson.M{"$inc": bson.M{"minted": t.Minted, "fees": t.Fees, "blocks": t.Blocks}}
+	query := func(c collection) error {
+		_, err := c.Upsert(bson.M{"shardNumber": t.ShardNumber}, inc)
+		return err
+	}
+	if err := c.withCollection(supplyTbl, query); err != nil {
+		return err
+	}
+
+	query = func(c collection) error {
+		_, err := c.Upsert(bson.M{"timestamp": t.TimeStamp, "shardnumber": t.ShardNumber}, inc)
+		return err
+	}
+	return c.withCollection(chartSupplyTbl, query)
+}
+
+// GetSupply get the supply of all shards
+// index: supply {shardNumber}
+func (c *Client) GetSupply() ([]*DBSupply, error) {
+	var supplies []*DBSupply
+	query := func(c collection) error {
+		return c.Find(bson.M{}).Sort("shardNumber").All(&supplies)
+	}
+	err := c.withCollection(supplyTbl, query)
+	return supplies, err
+}
+
+// GetOneDaySupply get the supply of one day from mongo by zero hour timestamp
+// index: chart_supply {shardnumber, timestamp}
+func (c *Client) GetOneDaySupply(shardNumber int, zeroTime int64) (*DBOneDaySupply, error) {
+	oneDaySupply := new(DBOneDaySupply)
+	query := func(c collection) error {
+		return c.Find(bson.M{"timestamp": zeroTime, "shardnumber": shardNumber}).One(oneDaySupply)
+	}
+	err := c.withCollection(chartSupplyTbl, query)
+	return oneDaySupply, err
+}
+
+// GetSupplyChart get all rows in the supply chart table
+// index: chart_supply {timestamp}
+func (c *Client) GetSupplyChart() ([]*DBOneDaySupply, error) {
+	var oneDaySupplies []*DBOneDaySupply
+	query := func(c collection) error {
+		return c.Find(bson.M{}).Sort("timestamp").All(&oneDaySupplies)
+	}
+	err := c.withCollection(chartSupplyTbl, query)
+	return oneDaySupplies, err
+}
+
+// GetSupplyChartByShardNumber get supply chart of one day by shard number
+// index: chart_supply {shardnumber, timestamp}
+func (c *Client) GetSupplyChartByShardNumber(shardNumber int) ([]*DBOneDaySupply, error) {
+	var oneDaySupplies []*DBOneDaySupply
+	query := func(c collection) error {
+		return c.Find(bson.M{"shardnumber": shardNumber}).Sort("timestamp").All(&oneDaySupplies)
+	}
+	err := c.withCollection(chartSupplyTbl, query)
+	return oneDaySupplies, err
+}
+
 // processDataBaseError shutdown database connection and log it
 func processDataBaseError(err error) {
 	if err == nil || err == mgo.ErrNotFound || err == mgo.ErrCursor {
@@ -996,7 +1627,7 @@ func processDataBaseError(err error) {
 // AddOneDayTransInfo insert one dya transaction info into mongo
 func (c *Client) AddOneDayTransInfo(shardNumber int, t *DBOneDayTxInfo) error {
 	t.ShardNumber = shardNumber
-	query := func(c *mgo.Collection) error {
+	query := func(c collection) error {
 		return c.Insert(t)
 	}
 	err := c.withCollection(chartTxTbl, query)
@@ -1004,9 +1635,10 @@ func (c *Client) AddOneDayTransInfo(shardNumber int, t *DBOneDayTxInfo) error {
 }
 
 // GetOneDayTransInfo get one day transaction info from mongo by zero hour timestamp
+// index: chart_transhistory {shardnumber, timestamp}
 func (c *Client) GetOneDayTransInfo(shardNumber int, zeroTime int64) (*DBOneDayTxInfo, error) {
 	oneDayTransInfo := new(DBOneDayTxInfo)
-	query := func(c *mgo.Collection) error {
+	query := func(c collection) error {
 		return c.Find(bson.M{"timestamp": zeroTime, "shardnumber": shardNumber}).One(oneDayTransInfo)
 	}
 	err := c.withCollection(chartTxTbl, query)
@@ -1014,9 +1646,10 @@ func (c *Client) GetOneDayTransInfo(shardNumber int, zeroTime int64) (*DBOneDayT
 }
 
 // GetTransInfoChart get all rows int the transhistory table
+// index: chart_transhistory {timestamp}
 func (c *Client) GetTransInfoChart() ([]*DBOneDayTxInfo, error) {
 	var oneDayTrans []*DBOneDayTxInfo
-	query := func(c *mgo.Collection) error {
+	query := func(c collection) error {
 		return c.Find(bson.M{}).Sort("timestamp").All(&oneDayTrans)
 	}
 	err := c.withCollection(chartTxTbl, query)
@@ -1024,9 +1657,10 @@ func (c *Client) GetTransInfoChart() ([]*DBOneDayTxInfo, error) {
 }
 
 // GetTransInfoChartByShardNumber get transactions info chart by shard number
+// index: chart_transhistory {shardnumber, timestamp}
 func (c *Client) GetTransInfoChartByShardNumber(shardNumber int) ([]*DBOneDayTxInfo, error) {
 	var oneDayTrans []*DBOneDayTxInfo
-	query := func(c *mgo.Collection) error {
+	query := func(c collection) error {
 		return c.Find(bson.M{"shardnumber": shardNumber}).Sort("timestamp").All(&oneDayTrans)
 	}
 	err := c.withCollection(chartTxTbl, query)
@@ -1036,7 +1670,7 @@ func (c *Client) GetTransInfoChartByShardNumber(shardNumber int) ([]*DBOneDayTxI
 // AddOneDayHashRate insert one dya hashrate info into mongo
 func (c *Client) AddOneDayHashRate(shardNumber int, t *DBOneDayHashRate) error {
 	t.ShardNumber = shardNumber
-	query := func(c *mgo.Collection) error {
+	query := func(c collection) error {
 		return c.Insert(t)
 	}
 	err := c.withCollection(chartHashRateTbl, query)
@@ -1044,9 +1678,10 @@ func (c *Client) AddOneDayHashRate(shardNumber int, t *DBOneDayHashRate) error {
 }
 
 // GetOneDayHashRate get one day hashrate info from mongo by zero hour timestamp
+// index: chart_hashrate {shardnumber, timestamp}
 func (c *Client) GetOneDayHashRate(shardNumber int, zeroTime int64) (*DBOneDayHashRate, error) {
 	oneDayHashRate := new(DBOneDayHashRate)
-	query := func(c *mgo.Collection) error {
+	query := func(c collection) error {
 		return c.Find(bson.M{"timestamp": zeroTime, "shardnumber": shardNumber}).One(oneDayHashRate)
 	}
 	err := c.withCollection(chartHashRateTbl, query)
@@ -1054,9 +1689,10 @@ func (c *Client) GetOneDayHashRate(shardNumber int, zeroTime int64) (*DBOneDayHa
 }
 
 // GetHashRateChart get all rows int the hashrate table
+// index: chart_hashrate {timestamp}
 func (c *Client) GetHashRateChart() ([]*DBOneDayHashRate, error) {
 	var oneDayHashRates []*DBOneDayHashRate
-	query := func(c *mgo.Collection) error {
+	query := func(c collection) error {
 		return c.Find(bson.M{}).Sort("timestamp").All(&oneDayHashRates)
 	}
 	err := c.withCollection(chartHashRateTbl, query)
@@ -1064,9 +1700,10 @@ func (c *Client) GetHashRateChart() ([]*DBOneDayHashRate, error) {
 }
 
 // GetHashRateChartByShardNumber get ratechart by shardnumber
+// index: chart_hashrate {shardnumber, timestamp}
 func (c *Client) GetHashRateChartByShardNumber(shardNumber int) ([]*DBOneDayHashRate, error) {
 	var oneDayHashRates []*DBOneDayHashRate
-	query := func(c *mgo.Collection) error {
+	query := func(c collection) error {
 		return c.Find(bson.M{"shardnumber": shardNumber}).Sort("timestamp").All(&oneDayHashRates)
 	}
 	err := c.withCollection(chartHashRateTbl, query)
@@ -1076,7 +1713,7 @@ func (c *Client) GetHashRateChartByShardNumber(shardNumber int) ([]*DBOneDayHash
 // AddOneDayBlockDifficulty insert one dya avg block difficulty info into mongo
 func (c *Client) AddOneDayBlockDifficulty(shardNumber int, t *DBOneDayBlockDifficulty) error {
 	t.ShardNumber = shardNumber
-	query := func(c *mgo.Collection) error {
+	query := func(c collection) error {
 		return c.Insert(t)
 	}
 	err := c.withCollection(chartBlockDifficultyTbl, query)
@@ -1084,9 +1721,10 @@ func (c *Client) AddOneDayBlockDifficulty(shardNumber int, t *DBOneDayBlockDiffi
 }
 
 // GetOneDayBlockDifficulty get one day hashrate info from mongo by zero hour timestamp
+// index: chart_blockdifficulty {shardnumber, timestamp}
 func (c *Client) GetOneDayBlockDifficulty(shardNumber int, zeroTime int64) (*DBOneDayBlockDifficulty, error) {
 	oneDayBlockDifficulty := new(DBOneDayBlockDifficulty)
-	query := func(c *mgo.Collection) error {
+	query := func(c collection) error {
 		return c.Find(bson.M{"timestamp": zeroTime, "shardnumber": shardNumber}).One(oneDayBlockDifficulty)
 	}
 	err := c.withCollection(chartBlockDifficultyTbl, query)
@@ -1094,9 +1732,10 @@ func (c *Client) GetOneDayBlockDifficulty(shardNumber int, zeroTime int64) (*DBO
 }
 
 // GetAccountsByHome get an dbaccount list sort by balance
+// index: account {balance}
 func (c *Client) GetAccountsByHome() []*DBAccount {
 	var accounts []*DBAccount
-	query := func(c *mgo.Collection) error {
+	query := func(c collection) error {
 		return c.Find(bson.M{}).Sort("-balance").Limit(10).All(&accounts)
 	}
 	c.withCollection(accTbl, query)
@@ -1104,9 +1743,10 @@ func (c *Client) GetAccountsByHome() []*DBAccount {
 }
 
 // GetOneDayBlockDifficultyChart get all rows int the hashrate table
+// index: chart_blockdifficulty {timestamp}
 func (c *Client) GetOneDayBlockDifficultyChart() ([]*DBOneDayBlockDifficulty, error) {
 	var oneDayBlockDifficulties []*DBOneDayBlockDifficulty
-	query := func(c *mgo.Collection) error {
+	query := func(c collection) error {
 		return c.Find(bson.M{}).Sort("timestamp").All(&oneDayBlockDifficulties)
 	}
 	err := c.withCollection(chartBlockDifficultyTbl, query)
@@ -1114,9 +1754,10 @@ func (c *Client) GetOneDayBlockDifficultyChart() ([]*DBOneDayBlockDifficulty, er
 }
 
 // GetOneDayBlockDifficultyChartByShardNumber get the td chart of block by shard number
+// index: chart_blockdifficulty {shardnumber, timestamp}
 func (c *Client) GetOneDayBlockDifficultyChartByShardNumber(shardNumber int) ([]*DBOneDayBlockDifficulty, error) {
 	var oneDayBlockDifficulties []*DBOneDayBlockDifficulty
-	query := func(c *mgo.Collection) error {
+	query := func(c collection) error {
 		return c.Find(bson.M{"shardnumber": shardNumber}).Sort("timestamp").All(&oneDayBlockDifficulties)
 	}
 	err := c.withCollection(chartBlockDifficultyTbl, query)
@@ -1126,7 +1767,7 @@ func (c *Client) GetOneDayBlockDifficultyChartByShardNumber(shardNumber int) ([]
 // AddOneDayBlockAvgTime insert one dya avg block time info into mongo
 func (c *Client) AddOneDayBlockAvgTime(shardNumber int, t *DBOneDayBlockAvgTime) error {
 	t.ShardNumber = shardNumber
-	query := func(c *mgo.Collection) error {
+	query := func(c collection) error {
 		return c.Insert(t)
 	}
 	err := c.withCollection(chartBlockAvgTimeTbl, query)
@@ -1134,9 +1775,10 @@ func (c *Client) AddOneDayBlockAvgTime(shardNumber int, t *DBOneDayBlockAvgTime)
 }
 
 // GetOneDayBlockAvgTime get one day avg block time info from mongo by zero hour timestamp
+// index: chart_blockavgtime {shardnumber, timestamp}
 func (c *Client) GetOneDayBlockAvgTime(shardNumber int, zeroTime int64) (*DBOneDayBlockAvgTime, error) {
 	oneDayBlockAvgTime := new(DBOneDayBlockAvgTime)
-	query := func(c *mgo.Collection) error {
+	query := func(c collection) error {
 		return c.Find(bson.M{"timestamp": zeroTime, "shardnumber": shardNumber}).One(oneDayBlockAvgTime)
 	}
 	err := c.withCollection(chartBlockAvgTimeTbl, query)
@@ -1144,9 +1786,10 @@ func (c *Client) GetOneDayBlockAvgTime(shardNumber int, zeroTime int64) (*DBOneD
 }
 
 // GetOneDayBlockAvgTimeChart get all rows int the hashrate table
+// index: chart_blockavgtime {timestamp}
 func (c *Client) GetOneDayBlockAvgTimeChart() ([]*DBOneDayBlockAvgTime, error) {
 	var oneDayBlockAvgTimes []*DBOneDayBlockAvgTime
-	query := func(c *mgo.Collection) error {
+	query := func(c collection) error {
 		return c.Find(bson.M{}).Sort("timestamp").All(&oneDayBlockAvgTimes)
 	}
 	err := c.withCollection(chartBlockAvgTimeTbl, query)
@@ -1154,9 +1797,10 @@ func (c *Client) GetOneDayBlockAvgTimeChart() ([]*DBOneDayBlockAvgTime, error) {
 }
 
 // GetOneDayBlockAvgTimeChartByShardNumber get avg time chart of one day by shard number
+// index: chart_blockavgtime {shardnumber, timestamp}
 func (c *Client) GetOneDayBlockAvgTimeChartByShardNumber(shardNumber int) ([]*DBOneDayBlockAvgTime, error) {
 	var oneDayBlockAvgTimes []*DBOneDayBlockAvgTime
-	query := func(c *mgo.Collection) error {
+	query := func(c collection) error {
 		return c.Find(bson.M{"shardnumber": shardNumber}).Sort("timestamp").All(&oneDayBlockAvgTimes)
 	}
 	err := c.withCollection(chartBlockAvgTimeTbl, query)
@@ -1166,7 +1810,7 @@ func (c *Client) GetOneDayBlockAvgTimeChartByShardNumber(shardNumber int) ([]*DB
 // AddOneDayBlock insert one dya block info into mongo
 func (c *Client) AddOneDayBlock(shardNumber int, t *DBOneDayBlockInfo) error {
 	t.ShardNumber = shardNumber
-	query := func(c *mgo.Collection) error {
+	query := func(c collection) error {
 		return c.Insert(t)
 	}
 	err := c.withCollection(chartBlockTbl, query)
@@ -1174,9 +1818,10 @@ func (c *Client) AddOneDayBlock(shardNumber int, t *DBOneDayBlockInfo) error {
 }
 
 // GetOneDayBlock get one day block info from mongo by zero hour timestamp
+// index: chart_block {shardnumber, timestamp}
 func (c *Client) GetOneDayBlock(shardNumber int, zeroTime int64) (*DBOneDayBlockInfo, error) {
 	oneDayBlock := new(DBOneDayBlockInfo)
-	query := func(c *mgo.Collection) error {
+	query := func(c collection) error {
 		return c.Find(bson.M{"timestamp": zeroTime, "shardnumber": shardNumber}).One(oneDayBlock)
 	}
 	err := c.withCollection(chartBlockTbl, query)
@@ -1184,9 +1829,10 @@ func (c *Client) GetOneDayBlock(shardNumber int, zeroTime int64) (*DBOneDayBlock
 }
 
 // GetOneDayBlocksChart get all rows int the hashrate table
+// index: chart_block {timestamp}
 func (c *Client) GetOneDayBlocksChart() ([]*DBOneDayBlockInfo, error) {
 	var oneDayBlocks []*DBOneDayBlockInfo
-	query := func(c *mgo.Collection) error {
+	query := func(c collection) error {
 		return c.Find(bson.M{}).Sort("timestamp").All(&oneDayBlocks)
 	}
 	err := c.withCollection(chartBlockTbl, query)
@@ -1194,19 +1840,63 @@ func (c *Client) GetOneDayBlocksChart() ([]*DBOneDayBlockInfo, error) {
 }
 
 // GetOneDayBlocksChartByShardNumber get block chart of one day by shard number
+// index: chart_block {shardnumber, timestamp}
 func (c *Client) GetOneDayBlocksChartByShardNumber(shardNumber int) ([]*DBOneDayBlockInfo, error) {
 	var oneDayBlocks []*DBOneDayBlockInfo
-	query := func(c *mgo.Collection) error {
+	query := func(c collection) error {
 		return c.Find(bson.M{"shardnumber": shardNumber}).Sort("timestamp").All(&oneDayBlocks)
 	}
 	err := c.withCollection(chartBlockTbl, query)
 	return oneDayBlocks, err
 }
 
+// AddOneDayMinerRevenue insert the revenue of an miner in one day into mongo
+func (c *Client) AddOneDayMinerRevenue(shardNumber int, t *DBOneDayMinerRevenue) error {
+	t.ShardNumber = shardNumber
+	query := func(c collection) error {
+		return c.Insert(t)
+	}
+	err := c.withCollection(chartMinerRevenueTbl, query)
+	return err
+}
+
+// GetOneDayMinerRevenue get one miner revenue row of the day from mongo by zero hour timestamp
+// index: chart_minerrevenue {shardnumber, timestamp}
+func (c *Client) GetOneDayMinerRevenue(shardNumber int, zeroTime int64) (*DBOneDayMinerRevenue, error) {
+	oneDayMinerRevenue := new(DBOneDayMinerRevenue)
+	query := func(c collection) error {
+		return c.Find(bson.M{"timestamp": zeroTime, "shardnumber": shardNumber}).One(oneDayMinerRevenue)
+	}
+	err := c.withCollection(chartMinerRevenueTbl, query)
+	return oneDayMinerRevenue, err
+}
+
+// GetMinerRevenueChart get the daily revenue rows of the miner
+// index: chart_minerrevenue {address, timestamp}
+func (c *Client) GetMinerRevenueChart(address string) ([]*DBOneDayMinerRevenue, error) {
+	var oneDayMinerRevenues []*DBOneDayMinerRevenue
+	query := func(c collection) error {
+		return c.Find(bson.M{"address": address}).Sort("timestamp").All(&oneDayMinerRevenues)
+	}
+	err := c.withCollection(chartMinerRevenueTbl, query)
+	return oneDayMinerRevenues, err
+}
+
+// GetMinerRevenueChartByShardNumber get the daily revenue rows of the miner by shard number
+// index: chart_minerrevenue {address, timestamp}
+func (c *Client) GetMinerRevenueChartByShardNumber(address string, shardNumber int) ([]*DBOneDayMinerRevenue, error) {
+	var oneDayMinerRevenues []*DBOneDayMinerRevenue
+	query := func(c collection) error {
+		return c.Find(bson.M{"address": address, "shardnumber": shardNumber}).Sort("timestamp").All(&oneDayMinerRevenues)
+	}
+	err := c.withCollection(chartMinerRevenueTbl, query)
+	return oneDayMinerRevenues, err
+}
+
 // AddOneDayAddress insert one dya block info into mongo
 func (c *Client) AddOneDayAddress(shardNumber int, t *DBOneDayAddressInfo) error {
 	t.ShardNumber = shardNumber
-	query := func(c *mgo.Collection) error {
+	query := func(c collection) error {
 		return c.Insert(t)
 	}
 	err := c.withCollection(chartAddressTbl, query)
@@ -1214,9 +1904,10 @@ func (c *Client) AddOneDayAddress(shardNumber int, t *DBOneDayAddressInfo) error
 }
 
 // GetOneDayAddress get one day block info from mongo by zero hour timestamp
+// index: chart_address {shardnumber, timestamp}
 func (c *Client) GetOneDayAddress(shardNumber int, zeroTime int64) (*DBOneDayAddressInfo, error) {
 	oneDayAddress := new(DBOneDayAddressInfo)
-	query := func(c *mgo.Collection) error {
+	query := func(c collection) error {
 		return c.Find(bson.M{"timestamp": zeroTime, "shardnumber": shardNumber}).One(oneDayAddress)
 	}
 	err := c.withCollection(chartAddressTbl, query)
@@ -1224,9 +1915,10 @@ func (c *Client) GetOneDayAddress(shardNumber int, zeroTime int64) (*DBOneDayAdd
 }
 
 // GetOneDayAddressesChart get all rows int the address table
+// index: chart_address {timestamp}
 func (c *Client) GetOneDayAddressesChart() ([]*DBOneDayAddressInfo, error) {
 	var oneDayAddresses []*DBOneDayAddressInfo
-	query := func(c *mgo.Collection) error {
+	query := func(c collection) error {
 		return c.Find(bson.M{}).Sort("timestamp").All(&oneDayAddresses)
 	}
 	err := c.withCollection(chartAddressTbl, query)
@@ -1234,9 +1926,10 @@ func (c *Client) GetOneDayAddressesChart() ([]*DBOneDayAddressInfo, error) {
 }
 
 // GetOneDayAddressesChartByShardNumber get address chart of one day by shard number
+// index: chart_address {shardnumber, timestamp}
 func (c *Client) GetOneDayAddressesChartByShardNumber(shardNumber int) ([]*DBOneDayAddressInfo, error) {
 	var oneDayAddresses []*DBOneDayAddressInfo
-	query := func(c *mgo.Collection) error {
+	query := func(c collection) error {
 		return c.Find(bson.M{"shardnumber": shardNumber}).Sort("timestamp").All(&oneDayAddresses)
 	}
 	err := c.withCollection(chartAddressTbl, query)
@@ -1246,7 +1939,7 @@ func (c *Client) GetOneDayAddressesChartByShardNumber(shardNumber int) ([]*DBOne
 // AddOneDaySingleAddressInfo insert one dya single address info into mongo
 func (c *Client) AddOneDaySingleAddressInfo(shardNumber int, t *DBOneDaySingleAddressInfo) error {
 	t.ShardNumber = shardNumber
-	query := func(c *mgo.Collection) error {
+	query := func(c collection) error {
 		return c.Insert(t)
 	}
 	err := c.withCollection(chartSingleAddressTbl, query)
@@ -1254,9 +1947,10 @@ func (c *Client) AddOneDaySingleAddressInfo(shardNumber int, t *DBOneDaySingleAd
 }
 
 // GetOneDaySingleAddressInfo get one day block info from mongo by zero hour timestamp
+// index: chart_single_address {address}
 func (c *Client) GetOneDaySingleAddressInfo(shardNumber int, address string) (*DBOneDaySingleAddressInfo, error) {
 	oneDaySingleAddress := new(DBOneDaySingleAddressInfo)
-	query := func(c *mgo.Collection) error {
+	query := func(c collection) error {
 		return c.Find(bson.M{"address": address, "shardnumber": shardNumber}).One(oneDaySingleAddress)
 	}
 	err := c.withCollection(chartSingleAddressTbl, query)
@@ -1265,7 +1959,7 @@ func (c *Client) GetOneDaySingleAddressInfo(shardNumber int, address string) (*D
 
 // RemoveTopMinerInfo remove last 7 days top miner info
 func (c *Client) RemoveTopMinerInfo() error {
-	query := func(c *mgo.Collection) error {
+	query := func(c collection) error {
 		return c.DropCollection()
 	}
 	err := c.withCollection(chartTopMinerRankTbl, query)
@@ -1275,7 +1969,7 @@ func (c *Client) RemoveTopMinerInfo() error {
 // AddTopMinerInfo add top miner rank info into database
 func (c *Client) AddTopMinerInfo(shardNumber int, rankInfo *DBMinerRankInfo) error {
 	rankInfo.ShardNumber = shardNumber
-	query := func(c *mgo.Collection) error {
+	query := func(c collection) error {
 		return c.Insert(rankInfo)
 	}
 	err := c.withCollection(chartTopMinerRankTbl, query)
@@ -1283,9 +1977,10 @@ func (c *Client) AddTopMinerInfo(shardNumber int, rankInfo *DBMinerRankInfo) err
 }
 
 // GetTopMinerChart get all rows int the address table
+// index: none, the whole collection is returned
 func (c *Client) GetTopMinerChart() ([]*DBMinerRankInfo, error) {
 	var topMinerInfo []*DBMinerRankInfo
-	query := func(c *mgo.Collection) error {
+	query := func(c collection) error {
 		return c.Find(bson.M{}).All(&topMinerInfo)
 	}
 	err := c.withCollection(chartTopMinerRankTbl, query)
@@ -1293,9 +1988,10 @@ func (c *Client) GetTopMinerChart() ([]*DBMinerRankInfo, error) {
 }
 
 // GetTopMinerChartByShardNumber get top miner char by shard number
+// index: chart_topminer {shardnumber}
 func (c *Client) GetTopMinerChartByShardNumber(shardNumber int) ([]*DBMinerRankInfo, error) {
 	var topMinerInfo []*DBMinerRankInfo
-	query := func(c *mgo.Collection) error {
+	query := func(c collection) error {
 		return c.Find(bson.M{"shardnumber": shardNumber}).All(&topMinerInfo)
 	}
 	err := c.withCollection(chartTopMinerRankTbl, query)
@@ -1303,8 +1999,9 @@ func (c *Client) GetTopMinerChartByShardNumber(shardNumber int) ([]*DBMinerRankI
 }
 
 // AddNodeInfo add node info into database
+// index: nodeinfo {host, port}
 func (c *Client) AddNodeInfo(nodeInfo *DBNodeInfo) error {
-	query := func(c *mgo.Collection) error {
+	query := func(c collection) error {
 		_,err := c.Upsert(bson.M{"host":nodeInfo.Host,"port":nodeInfo.Port},nodeInfo)//Insert(nodeInfo)
 		return err
 	}
@@ -1313,8 +2010,9 @@ func (c *Client) AddNodeInfo(nodeInfo *DBNodeInfo) error {
 }
 
 // DeleteNodeInfo delete node info from database
+// index: nodeinfo {id}
 func (c *Client) DeleteNodeInfo(nodeInfo *DBNodeInfo) error {
-	query := func(c *mgo.Collection) error {
+	query := func(c collection) error {
 		return c.Remove(bson.M{"id": nodeInfo.ID})
 	}
 	err := c.withCollection(nodeInfoTbl, query)
@@ -1322,9 +2020,10 @@ func (c *Client) DeleteNodeInfo(nodeInfo *DBNodeInfo) error {
 }
 
 // GetNodeInfo get node info from database
+// index: nodeinfo {host, port}
 func (c *Client) GetNodeInfo(host string) (*DBNodeInfo, error) {
 	dbNodeInfo := new(DBNodeInfo)
-	query := func(c *mgo.Collection) error {
+	query := func(c collection) error {
 		return c.Find(bson.M{"host": host}).One(dbNodeInfo)
 	}
 	err := c.withCollection(nodeInfoTbl, query)
@@ -1332,9 +2031,10 @@ func (c *Client) GetNodeInfo(host string) (*DBNodeInfo, error) {
 }
 
 // GetNodeInfoByID get node info from database by node id
+// index: nodeinfo {id}
 func (c *Client) GetNodeInfoByID(id string) (*DBNodeInfo, error) {
 	dbNodeInfo := new(DBNodeInfo)
-	query := func(c *mgo.Collection) error {
+	query := func(c collection) error {
 		return c.Find(bson.M{"id": id}).One(dbNodeInfo)
 	}
 	err := c.withCollection(nodeInfoTbl, query)
@@ -1342,9 +2042,10 @@ func (c *Client) GetNodeInfoByID(id string) (*DBNodeInfo, error) {
 }
 
 // GetNodeInfosByShardNumber get all node infos from database by shardNumber
+// index: nodeinfo {shardNumber, lastseen}
 func (c *Client) GetNodeInfosByShardNumber(shardNumber int) ([]*DBNodeInfo, error) {
 	var nodeInfos []*DBNodeInfo
-	query := func(c *mgo.Collection) error {
+	query := func(c collection) error {
 		return c.Find(bson.M{"shardNumber": shardNumber}).Sort("-lastseen").All(&nodeInfos)
 	}
 	err := c.withCollection(nodeInfoTbl, query)
@@ -1353,9 +2054,10 @@ func (c *Client) GetNodeInfosByShardNumber(shardNumber int) ([]*DBNodeInfo, erro
 }
 
 // GetNodeInfos get all node infos from database
+// index: none, the whole collection is returned
 func (c *Client) GetNodeInfos() ([]*DBNodeInfo, error) {
 	var nodeInfos []*DBNodeInfo
-	query := func(c *mgo.Collection) error {
+	query := func(c collection) error {
 		return c.Find(bson.M{}).All(&nodeInfos)
 	}
 	err := c.withCollection(nodeInfoTbl, query)
@@ -1363,9 +2065,10 @@ func (c *Client) GetNodeInfos() ([]*DBNodeInfo, error) {
 }
 
 // GetNodeCntByShardNumber get row count of the node table
+// index: nodeinfo {shardNumber, lastseen}
 func (c *Client) GetNodeCntByShardNumber(shardNumber int) (uint64, error) {
 	var NodeCnt uint64
-	query := func(c *mgo.Collection) error {
+	query := func(c collection) error {
 		var err error
 		//TODO: fix this overflow
 		var temp int
@@ -1378,10 +2081,11 @@ func (c *Client) GetNodeCntByShardNumber(shardNumber int) (uint64, error) {
 }
 
 // GetTxsinfoByDate get row count of the transaction table
+// index: transaction {timetxs}
 func (c *Client) GetTxsinfoByDate(date string) (int64, int64, int64, int64, error) {
 	var txsCnt, gasPrice, highGasPrice, lowGasPrice int64
 	//var txsCnt, gasPrice, abc uint64
-	query := func(c *mgo.Collection) error {
+	query := func(c collection) error {
 		var err error
 		var trans []*DBTx
 		c.Find(bson.M{"timetxs": date}).All(&trans)
@@ -1409,8 +2113,9 @@ func (c *Client) GetTxsinfoByDate(date string) (int64, int64, int64, int64, erro
 }
 
 // UpdateTxsCntByDate get transaction count by date
+// index: txhistory {stime}
 func (c *Client) UpdateTxsCntByDate(tx *DBSimpleTxs) error {
-	query := func(c *mgo.Collection) error {
+	query := func(c collection) error {
 		_, err := c.Upsert(bson.M{"stime": tx.Stime}, tx)
 		return err
 	}
@@ -1418,9 +2123,10 @@ func (c *Client) UpdateTxsCntByDate(tx *DBSimpleTxs) error {
 }
 
 // GetTxHisCntByDate get transaction history count by date
+// index: txhistory {stime}
 func (c *Client) GetTxHisCntByDate(date string) (uint64, error) {
 	var txsCnt uint64
-	query := func(c *mgo.Collection) error {
+	query := func(c collection) error {
 		var err error
 		//TODO: fix this overflow
 		var temp int
@@ -1432,8 +2138,9 @@ func (c *Client) GetTxHisCntByDate(date string) (uint64, error) {
 }
 
 // RemoveOutDateByDate remove the outdate data by date
+// index: txhistory {stime}
 func (c *Client) RemoveOutDateByDate(date string) error {
-	query := func(c *mgo.Collection) error {
+	query := func(c collection) error {
 		_, err := c.RemoveAll(bson.M{"stime": bson.M{"$lt": date}})
 		return err
 	}
@@ -1441,9 +2148,10 @@ func (c *Client) RemoveOutDateByDate(date string) error {
 }
 
 // GetTxHis get transaction history
+// index: txhistory {stime}
 func (c *Client) GetTxHis(startDate, today string) ([]*DBSimpleTxs, error) {
 	var hisCounts []*DBSimpleTxs
-	queryTxHis := func(c *mgo.Collection) error {
+	queryTxHis := func(c collection) error {
 		var err error
 		c.Find(bson.M{"stime": bson.M{"$gt": startDate, "$lte": today}}).Sort("-stime").All(&hisCounts)
 		return err
@@ -1456,10 +2164,11 @@ func (c *Client) GetTxHis(startDate, today string) ([]*DBSimpleTxs, error) {
 }
 
 // GetTxCnt get current count from account table from mongo
+// index: account {address}
 func (c *Client) GetTxCntByAddressFromAccount(address string) (int64, error) {
 	var txCnt int64
 	accountInfo := new(DBAccount)
-	query := func(c *mgo.Collection) error {
+	query := func(c collection) error {
 		return c.Find(bson.M{"address": address}).One(&accountInfo)
 	}
 	err := c.withCollection(accTbl, query)
@@ -1473,10 +2182,12 @@ func (c *Client) GetTxCntByAddressFromAccount(address string) (int64, error) {
 }
 
 
+// GetTxCntAndAccTypeByAddressFromAccount get the tx count and the account type from account table
+// index: account {address}
 func (c *Client) GetTxCntAndAccTypeByAddressFromAccount(address string) (int64, int,error) {
 	var txCnt int64
 	accountInfo := new(DBAccount)
-	query := func(c *mgo.Collection) error {
+	query := func(c collection) error {
 		return c.Find(bson.M{"address": address}).One(&accountInfo)
 	}
 	err := c.withCollection(accTbl, query)
@@ -1492,9 +2203,10 @@ func (c *Client) GetTxCntAndAccTypeByAddressFromAccount(address string) (int64,
 // if sort is null, the result will not be sort by any fields
 // if limit <=0 , the result will get all the records
 // if skip <=0, the result will get records from the first one
+// index: transaction {shardNumber, block, idx}
 func (c *Client) GetTxs(shardNumber int, sort string, desc bool , limit int, skip int) ([]*DBTx, error) {
 	var trans []*DBTx
-	query := func(c *mgo.Collection) error {
+	query := func(c collection) error {
 		if sort != "" {
 			if desc {
 				sort = "-"+sort
@@ -1532,12 +2244,14 @@ func (c *Client) GetTxs(shardNumber int, sort string, desc bool , limit int, ski
 	return trans, err
 }
 
+// UpdateContract update the verified source code and abi of a contract
+// index: account {address}
 func (c *Client) UpdateContract(address string, sourceCode string, abiJson string) error {
-	query := func(c *mgo.Collection) error {
+	query := func(c collection) error {
 		err := c.Update(bson.M{"address": address},  bson.M{
 			"$set": bson.M{
 				"sourceCode": sourceCode,
-				"abiJSON": abiJson,
+				"abi":        abiJson,
 			},
 		})
 		return err
@@ -0,0 +1,75 @@
+/**
+*  @file
+*  @copyright defined in scan-api/LICENSE
+ */
+
+package database
+
+import (
+	"gopkg.in/mgo.v2"
+)
+
+// collection is the storage of one table, it is implemented by mongodb and by memory
+type collection interface {
+	Find(query interface{}) query
+	Pipe(pipeline interface{}) pipe
+	Insert(docs ...interface{}) error
+	Update(selector interface{}, update interface{}) error
+	Upsert(selector interface{}, update interface{}) (*mgo.ChangeInfo, error)
+	Remove(selector interface{}) error
+	RemoveAll(selector interface{}) (*mgo.ChangeInfo, error)
+	Count() (int, error)
+	DropCollection() error
+	EnsureIndex(index mgo.Index) error
+	Indexes() ([]mgo.Index, error)
+}
+
+// query is a prepared find of an collection
+type query interface {
+	Sort(fields ...string) query
+	Skip(n int) query
+	Limit(n int) query
+	All(result interface{}) error
+	One(result interface{}) error
+	Count() (int, error)
+}
+
+// pipe is a prepared aggregation of an collection
+type pipe interface {
+	All(result interface{}) error
+}
+
+// mgoCollection is the collection of mongodb
+type mgoCollection struct {
+	*mgo.Collection
+}
+
+// Find prepare a query of mongodb
+func (c mgoCollection) Find(q interface{}) query {
+	return mgoQuery{c.Collection.Find(q)}
+}
+
+// Pipe prepare an aggregation of mongodb
+func (c mgoCollection) Pipe(pipeline interface{}) pipe {
+	return c.Collection.Pipe(pipeline)
+}
+
+// mgoQuery is the query of mongodb
+type mgoQuery struct {
+	*mgo.Query
+}
+
+// Sort set the sort fields of the query
+func (q mgoQuery) Sort(fields ...string) query {
+	return mgoQuery{q.Query.Sort(fields...)}
+}
+
+// Skip skip the first n documents
+func (q mgoQuery) Skip(n int) query {
+	return mgoQuery{q.Query.Skip(n)}
+}
+
+// Limit return n documents at most
+func (q mgoQuery) Limit(n int) query {
+	return mgoQuery{q.Query.Limit(n)}
+}
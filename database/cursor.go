@@ -0,0 +1,220 @@
+/**
+*  @file
+*  @copyright defined in scan-api/LICENSE
+ */
+
+package database
+
+import (
+	"encoding/base64"
+	"errors"
+	"reflect"
+	"strings"
+
+	"gopkg.in/mgo.v2/bson"
+)
+
+// ErrInvalidCursor is returned for a page cursor which is not created by the client
+var ErrInvalidCursor = errors.New("invalid page cursor")
+
+// Page describe a page of a listing ordered by stable keys,
+// Next and Prev are the cursors of the following and the preceding pages, empty if there is no such page
+type Page struct {
+	Begin int // position of the first document of the page in the listing, starting from 0
+	Next  string
+	Prev  string
+}
+
+// pageCursor is the decoded content of a page cursor
+type pageCursor struct {
+	Keys   []interface{} `bson:"k"`
+	Before bool          `bson:"b,omitempty"`
+	Pos    int           `bson:"p,omitempty"`
+}
+
+// keyed is implemented by the documents which are listed by cursor,
+// the keys are the values of the sort fields of the listing
+type keyed interface {
+	cursorKeys() []interface{}
+}
+
+func (t *DBTx) cursorKeys() []interface{}      { return []interface{}{t.Block, t.Idx} }
+func (d *Debt) cursorKeys() []interface{}      { return []interface{}{d.Height, d.Idx} }
+func (b *DBBlock) cursorKeys() []interface{}   { return []interface{}{b.Height} }
+func (a *DBAccount) cursorKeys() []interface{} { return []interface{}{a.Balance, a.Address} }
+func (a *DBAddressActivity) cursorKeys() []interface{} {
+	return []interface{}{a.Block, a.Idx, a.Kind}
+}
+
+func encodeCursor(cursor *pageCursor) string {
+	data, err := bson.Marshal(cursor)
+	if err != nil {
+		return ""
+	}
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// keyKind return the kind of a cursor key, empty for a value which is not a scalar
+func keyKind(key interface{}) string {
+	switch key.(type) {
+	case int, int32, int64, uint, uint32, uint64:
+		return "int"
+	case float32, float64:
+		return "float"
+	case string:
+		return "string"
+	case bool:
+		return "bool"
+	}
+	return ""
+}
+
+// decodeCursor decode a cursor for the listing with the sort fields, an empty cursor is the first page.
+// sample is the keys of a document of the listing, every key must be a scalar of the kind of its sample
+// so that a cursor is not able to add operators to the filter.
+func decodeCursor(s string, sortFields []string, sample []interface{}) (*pageCursor, error) {
+	cursor := new(pageCursor)
+	if s == "" {
+		return cursor, nil
+	}
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	if err = bson.Unmarshal(data, cursor); err != nil || len(cursor.Keys) != len(sortFields) ||
+		len(sample) != len(sortFields) || cursor.Pos < 0 {
+		return nil, ErrInvalidCursor
+	}
+	for i, key := range cursor.Keys {
+		if kind := keyKind(key); kind == "" || kind != keyKind(sample[i]) {
+			return nil, ErrInvalidCursor
+		}
+	}
+	return cursor, nil
+}
+
+// keysetFilter return the filter selecting the documents following the keys in the sort order,
+// the branches of $or in the filter are expanded so that every branch is able to use an index.
+// The conditions of the filter on a sort field are kept with the range of the keys.
+func keysetFilter(filter bson.M, sortFields []string, keys []interface{}) bson.M {
+	base := bson.M{}
+	branches := []bson.M{{}}
+	for k, v := range filter {
+		if k == "$or" {
+			branches, _ = toDocList(v)
+		} else {
+			base[k] = v
+		}
+	}
+
+	var or []bson.M
+	for _, branch := range branches {
+		for i, field := range sortFields {
+			op := "$gt"
+			if strings.HasPrefix(field, "-") {
+				op = "$lt"
+			}
+
+			cond := bson.M{}
+			for k, v := range base {
+				cond[k] = v
+			}
+			for k, v := range branch {
+				cond[k] = v
+			}
+			for j := 0; j < i; j++ {
+				cond[strings.TrimPrefix(sortFields[j], "-")] = keys[j]
+			}
+			name := strings.TrimPrefix(field, "-")
+			rng := bson.M{op: keys[i]}
+			if prev, ok := cond[name]; ok {
+				ops, isOps := prev.(bson.M)
+				if !isOps || !isOperatorUpdate(ops) {
+					// the keys are of a selected document, no document equal to them follows in this branch
+					continue
+				}
+				for k, v := range ops {
+					rng[k] = v
+				}
+			}
+			cond[name] = rng
+			or = append(or, cond)
+		}
+	}
+	return bson.M{"$or": or}
+}
+
+// reverseSort return the sort fields in the opposite order
+func reverseSort(sortFields []string) []string {
+	var reversed []string
+	for _, field := range sortFields {
+		if strings.HasPrefix(field, "-") {
+			reversed = append(reversed, strings.TrimPrefix(field, "-"))
+		} else {
+			reversed = append(reversed, "-"+field)
+		}
+	}
+	return reversed
+}
+
+// listByCursor get a page of the documents selected by the filter in the sort order, the last sort field
+// must be unique among the documents with the same preceding sort fields. result is a pointer to
+// a slice of documents implementing keyed.
+func (c *Client) listByCursor(tbl string, filter bson.M, sortFields []string, s string, limit int, result interface{}) (*Page, error) {
+	sample := reflect.New(reflect.TypeOf(result).Elem().Elem().Elem()).Interface().(keyed)
+	cursor, err := decodeCursor(s, sortFields, sample.cursorKeys())
+	if err != nil {
+		return nil, err
+	}
+
+	order := sortFields
+	if cursor.Before {
+		order = reverseSort(sortFields)
+	}
+	if len(cursor.Keys) > 0 {
+		filter = keysetFilter(filter, order, cursor.Keys)
+	}
+
+	query := func(c collection) error {
+		// one more document tells whether there is a following page
+		return c.Find(filter).Sort(order...).Limit(limit + 1).All(result)
+	}
+	if err = c.withCollection(tbl, query); err != nil {
+		return nil, err
+	}
+
+	docs := reflect.ValueOf(result).Elem()
+	more := docs.Len() > limit
+	if more {
+		docs.Set(docs.Slice(0, limit))
+	}
+	n := docs.Len()
+	if cursor.Before {
+		swap := reflect.Swapper(docs.Interface())
+		for i, j := 0, n-1; i < j; i, j = i+1, j-1 {
+			swap(i, j)
+		}
+	}
+
+	page := &Page{Begin: cursor.Pos}
+	if cursor.Before {
+		page.Begin = cursor.Pos - n
+		if page.Begin < 0 {
+			page.Begin = 0
+		}
+	}
+	if n == 0 {
+		return page, nil
+	}
+
+	first := docs.Index(0).Interface().(keyed)
+	last := docs.Index(n - 1).Interface().(keyed)
+	// a cursor before a position is only created from a page which follows this one
+	if cursor.Before || more {
+		page.Next = encodeCursor(&pageCursor{Keys: last.cursorKeys(), Pos: page.Begin + n})
+	}
+	if (!cursor.Before && len(cursor.Keys) > 0) || (cursor.Before && more) {
+		page.Prev = encodeCursor(&pageCursor{Keys: first.cursorKeys(), Before: true, Pos: page.Begin})
+	}
+	return page, nil
+}
@@ -0,0 +1,161 @@
+/**
+*  @file
+*  @copyright defined in scan-api/LICENSE
+ */
+
+package database
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/mgo.v2/bson"
+)
+
+func Test_GetTxsByAddressCursor(t *testing.T) {
+	c := NewMemoryClient(1)
+	address := "0xa00d22dc3624d4696eff8d1641b442f79c3379b1"
+	for i := 0; i < 7; i++ {
+		tx := &DBTx{
+			Hash:        "0x" + string(rune('a'+i)),
+			From:        address,
+			To:          "0xec759db47a65f6537d630517f6cd3ca39c6f93d1",
+			Block:       uint64(i / 3),
+			Idx:         int64(i),
+			ShardNumber: 1,
+		}
+		if i%2 == 0 {
+			tx.From, tx.To = tx.To, address
+		}
+		assert.Nil(t, c.AddTx(tx))
+	}
+	assert.Nil(t, c.AddTx(&DBTx{Hash: "0xz", From: "0x01", To: "0x02", Block: 1, Idx: 7, ShardNumber: 1}))
+
+	var shapes []queryShape
+	c.mem.observer = func(shape queryShape) {
+		shapes = append(shapes, shape)
+	}
+
+	var idxs []int64
+	var begins []int
+	var prev string
+	cursor := ""
+	for {
+		txs, page, err := c.GetTxsByAddressCursor(address, cursor, 3)
+		assert.Nil(t, err)
+		for _, tx := range txs {
+			idxs = append(idxs, tx.Idx)
+		}
+		begins = append(begins, page.Begin)
+		prev = page.Prev
+		if page.Next == "" {
+			break
+		}
+		cursor = page.Next
+	}
+	assert.Equal(t, idxs, []int64{6, 5, 4, 3, 2, 1, 0})
+	assert.Equal(t, begins, []int{0, 3, 6})
+
+	txs, page, err := c.GetTxsByAddressCursor(address, prev, 3)
+	assert.Nil(t, err)
+	assert.Equal(t, len(txs), 3)
+	assert.Equal(t, txs[0].Idx, int64(3))
+	assert.Equal(t, txs[2].Idx, int64(1))
+	assert.Equal(t, page.Begin, 3)
+	assert.NotEmpty(t, page.Next)
+
+	txs, page, err = c.GetTxsByAddressCursor(address, page.Prev, 3)
+	assert.Nil(t, err)
+	assert.Equal(t, txs[0].Idx, int64(6))
+	assert.Equal(t, page.Begin, 0)
+	assert.Empty(t, page.Prev)
+
+	// the keyset queries use the declared indexes
+	for _, shape := range shapes {
+		covered := false
+		for _, index := range collectionIndexes[shape.Collection] {
+			covered = covered || shape.coveredBy(index)
+		}
+		assert.True(t, covered, "query %+v is not covered by the declared indexes", shape)
+	}
+
+	_, _, err = c.GetTxsByAddressCursor(address, "invalid", 3)
+	assert.Equal(t, err, ErrInvalidCursor)
+	_, _, err = c.GetBlocksByCursor(1, page.Next, 3)
+	assert.Equal(t, err, ErrInvalidCursor)
+}
+
+func Test_GetAccountsByCursor(t *testing.T) {
+	c := NewMemoryClient(1)
+	accounts := []*DBAccount{
+		{Address: "0x01", ShardNumber: 1, Balance: 300},
+		{Address: "0x02", ShardNumber: 1, Balance: 100},
+		{Address: "0x03", ShardNumber: 1, Balance: 100},
+		{Address: "0x04", ShardNumber: 1, Balance: 100},
+		{Address: "0x05", ShardNumber: 2, Balance: 500},
+		{Address: "0x06", ShardNumber: 1, AccType: 1, Balance: 900},
+	}
+	for _, account := range accounts {
+		assert.Nil(t, c.UpdateAccount(account))
+	}
+
+	got, page, err := c.GetAccountsByCursor(1, "", 2)
+	assert.Nil(t, err)
+	assert.Equal(t, got[0].Address, "0x01")
+	assert.Equal(t, got[1].Address, "0x04")
+	assert.Empty(t, page.Prev)
+
+	got, page, err = c.GetAccountsByCursor(1, page.Next, 2)
+	assert.Nil(t, err)
+	assert.Equal(t, len(got), 2)
+	assert.Equal(t, got[0].Address, "0x03")
+	assert.Equal(t, got[1].Address, "0x02")
+	assert.Equal(t, page.Begin, 2)
+	assert.Empty(t, page.Next)
+	assert.NotEmpty(t, page.Prev)
+}
+
+func Test_GetBlocksByCursor(t *testing.T) {
+	c := NewMemoryClient(1)
+	newTestMemoryBlocks(t, c, 1, 5)
+
+	blocks, page, err := c.GetBlocksByCursor(1, "", 5)
+	assert.Nil(t, err)
+	assert.Equal(t, len(blocks), 5)
+	assert.Equal(t, blocks[0].Height, int64(4))
+	assert.Empty(t, page.Next)
+	assert.Empty(t, page.Prev)
+
+	blocks, page, err = c.GetBlocksByCursor(2, "", 5)
+	assert.Nil(t, err)
+	assert.Empty(t, blocks)
+	assert.Equal(t, page, &Page{})
+
+	_, _, err = c.GetdebtsByCursor(1, "", 5)
+	assert.Nil(t, err)
+	_, _, err = c.GetPendingTxsByCursor(1, "", 5)
+	assert.Nil(t, err)
+}
+
+func Test_DecodeCursorKeys(t *testing.T) {
+	c := NewMemoryClient(1)
+	newTestMemoryBlocks(t, c, 1, 5)
+	_, page, err := c.GetBlocksByCursor(1, "", 2)
+	assert.Nil(t, err)
+	blocks, _, err := c.GetBlocksByCursor(1, page.Next, 2)
+	assert.Nil(t, err)
+	assert.Equal(t, blocks[0].Height, int64(2))
+
+	// a key must be a scalar of the kind of its sort field
+	for _, keys := range [][]interface{}{
+		{bson.M{"$ne": nil}},
+		{[]interface{}{1}},
+		{"3"},
+		{nil},
+	} {
+		_, _, err = c.GetBlocksByCursor(1, encodeCursor(&pageCursor{Keys: keys}), 2)
+		assert.Equal(t, err, ErrInvalidCursor)
+	}
+	_, _, err = c.GetAccountsByCursor(-1, encodeCursor(&pageCursor{Keys: []interface{}{int64(1), bson.M{"$gt": ""}}}), 2)
+	assert.Equal(t, err, ErrInvalidCursor)
+}
@@ -0,0 +1,227 @@
+/**
+*  @file
+*  @copyright defined in scan-api/LICENSE
+ */
+
+package database
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+
+	"gopkg.in/mgo.v2/bson"
+)
+
+// the entities which are exported and imported
+const (
+	EntityBlocks   = "blocks"
+	EntityTxs      = "txs"
+	EntityDebts    = "debts"
+	EntityAccounts = "accounts"
+	EntityMiners   = "miners"
+)
+
+// ErrUnknownEntity is returned for an entity which is not exported
+var ErrUnknownEntity = errors.New("unknown entity")
+
+// entitySpec describe where the documents of an entity are stored and their stable order
+type entitySpec struct {
+	tbl         string
+	heightField string   // the field selected by the height range, empty if the entity has no height
+	sortFields  []string // the last field is unique among the documents with the same preceding fields
+	docType     reflect.Type
+}
+
+var entitySpecs = map[string]*entitySpec{
+	EntityBlocks:   {blockTbl, "height", []string{"height"}, reflect.TypeOf(DBBlock{})},
+	EntityTxs:      {txTbl, "block", []string{"block", "idx"}, reflect.TypeOf(DBTx{})},
+	EntityDebts:    {debtTbl, "height", []string{"height", "idx", "hash"}, reflect.TypeOf(Debt{})},
+	EntityAccounts: {accTbl, "", []string{"address"}, reflect.TypeOf(DBAccount{})},
+	EntityMiners:   {minerTbl, "", []string{"address"}, reflect.TypeOf(DBMiner{})},
+}
+
+// Entities return the names of the exported entities in the order they are written
+func Entities() []string {
+	return []string{EntityBlocks, EntityTxs, EntityDebts, EntityAccounts, EntityMiners}
+}
+
+// Export stream the documents of the entity in the shard in batches of the stable order of the entity.
+// The documents with a height in [from, to] are exported, there is no upper limit if to is negative.
+// The export starts after the sort keys in after, or from the beginning if after is empty,
+// fn is called with every batch and the sort keys of its last document to record the progress.
+// index: block {shardNumber, height}, transaction {shardNumber, block, idx},
+// debt {shardNumber, height, idx, hash}, account {shardNumber, address} and miner {shardNumber, address}
+func (c *Client) Export(entity string, shardNumber int, from, to int64, after []interface{}, batchSize int, fn func(docs []interface{}, last []interface{}) error) error {
+	spec, ok := entitySpecs[entity]
+	if !ok {
+		return ErrUnknownEntity
+	}
+	if len(after) > 0 && len(after) != len(spec.sortFields) {
+		return ErrInvalidCursor
+	}
+	if batchSize <= 0 {
+		batchSize = defaultMigrationBatchSize
+	}
+
+	filter := bson.M{"shardNumber": shardNumber}
+	if spec.heightField != "" {
+		heights := bson.M{"$gte": from}
+		if to >= 0 {
+			heights["$lte"] = to
+		}
+		filter[spec.heightField] = heights
+	}
+
+	for {
+		selector := filter
+		if len(after) > 0 {
+			selector = keysetFilter(filter, spec.sortFields, after)
+		}
+
+		var raw []bson.M
+		query := func(c collection) error {
+			return c.Find(selector).Sort(spec.sortFields...).Limit(batchSize).All(&raw)
+		}
+		if err := c.withCollection(spec.tbl, query); err != nil {
+			return err
+		}
+		if len(raw) == 0 {
+			return nil
+		}
+
+		docs := make([]interface{}, len(raw))
+		for i, doc := range raw {
+			docs[i] = reflect.New(spec.docType).Interface()
+			if err := fromDoc(doc, docs[i]); err != nil {
+				return err
+			}
+		}
+		last := raw[len(raw)-1]
+		after = make([]interface{}, len(spec.sortFields))
+		for i, field := range spec.sortFields {
+			after[i] = last[field]
+		}
+		if err := fn(docs, after); err != nil {
+			return err
+		}
+		if len(raw) < batchSize {
+			return nil
+		}
+	}
+}
+
+// Columns return the stable columns of the entity, which are the stored names of the fields of its documents
+func Columns(entity string) ([]string, error) {
+	spec, ok := entitySpecs[entity]
+	if !ok {
+		return nil, ErrUnknownEntity
+	}
+	var columns []string
+	for i := 0; i < spec.docType.NumField(); i++ {
+		field := spec.docType.Field(i)
+		name := strings.Split(field.Tag.Get("bson"), ",")[0]
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = strings.ToLower(field.Name)
+		}
+		columns = append(columns, name)
+	}
+	return columns, nil
+}
+
+// storedFields return the fields of a document as they are stored, in the order of its columns
+func storedFields(doc interface{}) (bson.D, error) {
+	data, err := bson.Marshal(doc)
+	if err != nil {
+		return nil, err
+	}
+	var fields bson.D
+	err = bson.Unmarshal(data, &fields)
+	return fields, err
+}
+
+// EncodeJSON encode a document as a json object of its stored fields in the order of its columns
+func EncodeJSON(doc interface{}) ([]byte, error) {
+	fields, err := storedFields(doc)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	err = writeJSON(&buf, fields)
+	return buf.Bytes(), err
+}
+
+// writeJSON write a stored value as json, the embedded documents keep the order of their fields
+func writeJSON(buf *bytes.Buffer, v interface{}) error {
+	switch value := v.(type) {
+	case bson.D:
+		buf.WriteByte('{')
+		for i, elem := range value {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			name, _ := json.Marshal(elem.Name)
+			buf.Write(name)
+			buf.WriteByte(':')
+			if err := writeJSON(buf, elem.Value); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte('}')
+	case []interface{}:
+		buf.WriteByte('[')
+		for i, elem := range value {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			if err := writeJSON(buf, elem); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte(']')
+	default:
+		data, err := json.Marshal(value)
+		if err != nil {
+			return err
+		}
+		buf.Write(data)
+	}
+	return nil
+}
+
+// EncodeCSV encode a document as a csv record of the columns of the entity, the embedded documents
+// and arrays are encoded as json and the missing fields are empty
+func EncodeCSV(entity string, doc interface{}) ([]string, error) {
+	columns, err := Columns(entity)
+	if err != nil {
+		return nil, err
+	}
+	fields, err := storedFields(doc)
+	if err != nil {
+		return nil, err
+	}
+	values := fields.Map()
+
+	record := make([]string, len(columns))
+	for i, column := range columns {
+		value, ok := values[column]
+		if !ok || value == nil {
+			continue
+		}
+		if s, ok := value.(string); ok {
+			record[i] = s
+			continue
+		}
+		var buf bytes.Buffer
+		if err := writeJSON(&buf, value); err != nil {
+			return nil, err
+		}
+		record[i] = buf.String()
+	}
+	return record, nil
+}
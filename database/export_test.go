@@ -0,0 +1,109 @@
+/**
+*  @file
+*  @copyright defined in scan-api/LICENSE
+ */
+
+package database
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Export(t *testing.T) {
+	c := NewMemoryClient(1)
+	newTestMemoryBlocks(t, c, 1, 5)
+	newTestMemoryBlocks(t, c, 2, 2)
+	assert.Nil(t, c.AddDebtTxs(
+		&Debt{Hash: "0x0c", To: "0x01", Height: 2, ShardNumber: 1},
+		&Debt{Hash: "0x0b", To: "0x01", Height: 2, ShardNumber: 1},
+		&Debt{Hash: "0x0a", To: "0x01", Height: 4, ShardNumber: 1}))
+
+	var shapes []queryShape
+	c.mem.observer = func(shape queryShape) {
+		shapes = append(shapes, shape)
+	}
+
+	var heights []int64
+	var batches int
+	var last []interface{}
+	err := c.Export(EntityBlocks, 1, 1, 3, nil, 2, func(docs []interface{}, keys []interface{}) error {
+		for _, doc := range docs {
+			heights = append(heights, doc.(*DBBlock).Height)
+		}
+		batches++
+		last = keys
+		return nil
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, heights, []int64{1, 2, 3})
+	assert.Equal(t, batches, 2)
+
+	// resume after the first block
+	heights = nil
+	err = c.Export(EntityBlocks, 1, 0, -1, []interface{}{int64(1)}, 10, func(docs []interface{}, keys []interface{}) error {
+		for _, doc := range docs {
+			heights = append(heights, doc.(*DBBlock).Height)
+		}
+		last = keys
+		return nil
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, heights, []int64{2, 3, 4})
+	assert.Equal(t, last, []interface{}{int64(4)})
+
+	// the debts of a height are ordered by hash
+	var hashes []string
+	err = c.Export(EntityDebts, 1, 0, -1, nil, 1, func(docs []interface{}, keys []interface{}) error {
+		hashes = append(hashes, docs[0].(*Debt).Hash)
+		return nil
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, hashes, []string{"0x0b", "0x0c", "0x0a"})
+
+	for _, shape := range shapes {
+		covered := false
+		for _, index := range collectionIndexes[shape.Collection] {
+			covered = covered || shape.coveredBy(index)
+		}
+		assert.True(t, covered, "query %+v is not covered by the declared indexes", shape)
+	}
+
+	assert.Equal(t, c.Export("charts", 1, 0, -1, nil, 1, nil), ErrUnknownEntity)
+	assert.Equal(t, c.Export(EntityTxs, 1, 0, -1, []interface{}{int64(1)}, 1, nil), ErrInvalidCursor)
+}
+
+func Test_EncodeExport(t *testing.T) {
+	block := &DBBlock{
+		HeadHash:    "0x0a",
+		Height:      7,
+		ShardNumber: 1,
+		Txs:         []DBSimpleTxInBlock{{Hash: "0x0b", Amount: 10}},
+	}
+	line, err := EncodeJSON(block)
+	assert.Nil(t, err)
+	assert.Contains(t, string(line), `{"headHash":"0x0a","preBlockHash":"","height":7,`)
+	assert.Contains(t, string(line), `"transactions":[{"hash":"0x0b","from":"","to":"","amount":10,`)
+
+	columns, err := Columns(EntityAccounts)
+	assert.Nil(t, err)
+	assert.Equal(t, columns[:3], []string{"accType", "address", "balance"})
+	assert.Equal(t, columns[len(columns)-1], "codeHash")
+
+	record, err := EncodeCSV(EntityAccounts, &DBAccount{Address: "0x01", Balance: 300, ShardNumber: 2})
+	assert.Nil(t, err)
+	assert.Equal(t, len(record), len(columns))
+	assert.Equal(t, record[:4], []string{"0", "0x01", "300", "2"})
+	// the contract fields are omitted for a normal account
+	assert.Equal(t, record[len(record)-1], "")
+
+	record, err = EncodeCSV(EntityBlocks, block)
+	assert.Nil(t, err)
+	columns, _ = Columns(EntityBlocks)
+	for i, column := range columns {
+		if column == "transactions" {
+			assert.Contains(t, record[i], `[{"hash":"0x0b",`)
+		}
+	}
+}
@@ -0,0 +1,140 @@
+/**
+*  @file
+*  @copyright defined in scan-api/LICENSE
+ */
+
+package database
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+
+	"gopkg.in/mgo.v2/bson"
+)
+
+// DecodeJSON decode a json object written by EncodeJSON into a document of the entity
+func DecodeJSON(entity string, line []byte) (interface{}, error) {
+	spec, ok := entitySpecs[entity]
+	if !ok {
+		return nil, ErrUnknownEntity
+	}
+	decoder := json.NewDecoder(bytes.NewReader(line))
+	decoder.UseNumber()
+	var fields map[string]interface{}
+	if err := decoder.Decode(&fields); err != nil {
+		return nil, err
+	}
+
+	doc := reflect.New(spec.docType).Interface()
+	err := fromDoc(jsonNumbers(fields).(bson.M), doc)
+	return doc, err
+}
+
+// jsonNumbers convert the json objects to bson documents and the json numbers to int64 if they are integers
+func jsonNumbers(v interface{}) interface{} {
+	switch value := v.(type) {
+	case map[string]interface{}:
+		doc := bson.M{}
+		for k, e := range value {
+			doc[k] = jsonNumbers(e)
+		}
+		return doc
+	case []interface{}:
+		for i, e := range value {
+			value[i] = jsonNumbers(e)
+		}
+		return value
+	case json.Number:
+		if n, err := value.Int64(); err == nil {
+			return n
+		}
+		f, _ := value.Float64()
+		return f
+	}
+	return v
+}
+
+// Import write the documents of the entity, a document which is stored already is replaced so that
+// an import can be run again. The activities of the transactions and debts are written and the sync
+// cursor of the shards is moved after the imported blocks, the counters are not maintained and are
+// rebuilt by Recount after importing.
+// index: block {shardNumber, height}, transaction {shardNumber, block, idx},
+// debt {shardNumber, height, idx, hash}, account {shardNumber, address} and miner {shardNumber, address}
+func (c *Client) Import(entity string, docs []interface{}) error {
+	spec, ok := entitySpecs[entity]
+	if !ok {
+		return ErrUnknownEntity
+	}
+
+	query := func(c collection) error {
+		for _, doc := range docs {
+			fields, err := storedFields(doc)
+			if err != nil {
+				return err
+			}
+			values := fields.Map()
+			selector := bson.M{"shardNumber": values["shardNumber"]}
+			for _, field := range spec.sortFields {
+				selector[field] = values[field]
+			}
+			if _, err := c.Upsert(selector, doc); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := c.withCollection(spec.tbl, query); err != nil {
+		return err
+	}
+
+	switch entity {
+	case EntityBlocks:
+		next := make(map[int]uint64)
+		for _, doc := range docs {
+			block := doc.(*DBBlock)
+			if height := uint64(block.Height) + 1; height > next[block.ShardNumber] {
+				next[block.ShardNumber] = height
+			}
+		}
+		for shard, height := range next {
+			if err := c.moveSyncHeight(shard, "$max", height); err != nil {
+				return err
+			}
+		}
+	case EntityTxs:
+		var activities []*DBAddressActivity
+		for _, doc := range docs {
+			activities = append(activities, CreateTxActivities(doc.(*DBTx))...)
+		}
+		return c.upsertAddressActivities(activities)
+	case EntityDebts:
+		var activities []*DBAddressActivity
+		for _, doc := range docs {
+			if activity := c.debtActivity(doc.(*Debt)); activity != nil {
+				activities = append(activities, activity)
+			}
+		}
+		return c.upsertAddressActivities(activities)
+	}
+	return nil
+}
+
+// debtActivity create the activity of the receiver of a stored debt, the sender is found by the transaction
+// of the debt and the timestamp by its block. nil is returned for a debt without receiver.
+func (c *Client) debtActivity(debt *Debt) *DBAddressActivity {
+	if debt.To == "" {
+		return nil
+	}
+	from := debt.From
+	if from == "" {
+		if tx, err := c.GetTxByHash(debt.TxHash); err == nil {
+			from = tx.From
+		}
+	}
+	var timestamp int64
+	if block, err := c.GetBlockByHeight(debt.ShardNumber, debt.Height); err == nil {
+		timestamp = block.Timestamp
+	}
+	return CreateDebtActivity(debt, from, timestamp)
+}
@@ -0,0 +1,111 @@
+/**
+*  @file
+*  @copyright defined in scan-api/LICENSE
+ */
+
+package database
+
+import (
+	"testing"
+
+	"github.com/seeleteam/scan-api/rpc"
+	"github.com/stretchr/testify/assert"
+)
+
+// exportJSON export the entity of the shard as json lines
+func exportJSON(t *testing.T, c *Client, entity string, shardNumber int, from int64) [][]byte {
+	var lines [][]byte
+	err := c.Export(entity, shardNumber, from, -1, nil, 2, func(docs []interface{}, last []interface{}) error {
+		for _, doc := range docs {
+			line, err := EncodeJSON(doc)
+			assert.Nil(t, err)
+			lines = append(lines, line)
+		}
+		return nil
+	})
+	assert.Nil(t, err)
+	return lines
+}
+
+func Test_ImportExported(t *testing.T) {
+	src := NewMemoryClient(1)
+	newTestMemoryBlocks(t, src, 1, 5)
+	assert.Nil(t, src.AddTxs(
+		&DBTx{Hash: "0x0a", From: "0x01", To: "0x02", Amount: 10, Block: 3, Idx: 1, ShardNumber: 1, Timestamp: 1539931540},
+		&DBTx{Hash: "0x0b", From: "0x02", To: "0x03", Amount: 20, Block: 4, Idx: 2, ShardNumber: 1, Receipt: rpcReceipt()}))
+	assert.Nil(t, src.AddDebtTxs(&Debt{Hash: "0x0d", TxHash: "0x0a", To: "0x05", Height: 4, Idx: 1, ShardNumber: 1, Amount: 5}))
+	assert.Nil(t, src.UpdateAccount(&DBAccount{Address: "0x01", ShardNumber: 1, Balance: 90}))
+	assert.Nil(t, src.UpdateMinerAccount(&DBMiner{Address: "0x04", ShardNumber: 1, Revenue: 100}))
+
+	dst := NewMemoryClient(1)
+	for _, entity := range Entities() {
+		var docs []interface{}
+		for _, line := range exportJSON(t, src, entity, 1, 2) {
+			doc, err := DecodeJSON(entity, line)
+			assert.Nil(t, err)
+			docs = append(docs, doc)
+		}
+		assert.Nil(t, dst.Import(entity, docs))
+		// an import which is run again replaces the documents
+		assert.Nil(t, dst.Import(entity, docs))
+	}
+
+	block, err := dst.GetBlockByHeight(1, 3)
+	assert.Nil(t, err)
+	assert.Equal(t, block.HeadHash, "0x0d")
+	_, err = dst.GetBlockByHeight(1, 1)
+	assert.NotNil(t, err)
+	// the syncer continues after the imported blocks
+	height, err := dst.GetBlockHeight(1)
+	assert.Nil(t, err)
+	assert.Equal(t, height, uint64(5))
+
+	tx, err := dst.GetTxByHash("0x0b")
+	assert.Nil(t, err)
+	assert.Equal(t, tx.Amount, int64(20))
+	assert.Equal(t, tx.Receipt, rpcReceipt())
+	account, err := dst.GetAccountByAddress("0x01")
+	assert.Nil(t, err)
+	assert.Equal(t, account.Balance, int64(90))
+	miner, err := dst.GetMinerAccountByAddress("0x04")
+	assert.Nil(t, err)
+	assert.Equal(t, miner.Revenue, int64(100))
+
+	activities, err := dst.GetAddressActivities("0x05", "", 0, 0)
+	assert.Nil(t, err)
+	assert.Equal(t, len(activities), 1)
+	assert.Equal(t, activities[0].Counterparty, "0x01")
+	assert.Equal(t, activities[0].Timestamp, int64(1539931550))
+
+	drifts, err := dst.Recount(true, 0)
+	assert.Nil(t, err)
+	assert.NotEmpty(t, drifts)
+	txCnt, _ := dst.GetTxCntByShardNumber(1)
+	assert.Equal(t, txCnt, uint64(2))
+	blockCnt, _ := dst.GetBlockCnt()
+	assert.Equal(t, blockCnt, uint64(3))
+}
+
+func Test_SyncHeight(t *testing.T) {
+	c := NewMemoryClient(1)
+	height, _ := c.GetBlockHeight(1)
+	assert.Equal(t, height, uint64(0))
+
+	newTestMemoryBlocks(t, c, 1, 3)
+	height, _ = c.GetBlockHeight(1)
+	assert.Equal(t, height, uint64(3))
+
+	// a block which is synchronized again does not move the cursor back
+	assert.Nil(t, c.AddBlock(&DBBlock{Height: 1, ShardNumber: 1}))
+	height, _ = c.GetBlockHeight(1)
+	assert.Equal(t, height, uint64(3))
+
+	assert.Nil(t, c.RemoveBlock(1, 2))
+	assert.Nil(t, c.RemoveBlock(1, 5))
+	height, _ = c.GetBlockHeight(1)
+	assert.Equal(t, height, uint64(2))
+}
+
+func rpcReceipt() rpc.Receipt {
+	return rpc.Receipt{TxHash: "0x0b", UsedGas: 21000, TotalFee: 21000}
+}
@@ -0,0 +1,261 @@
+/**
+*  @file
+*  @copyright defined in scan-api/LICENSE
+ */
+
+package database
+
+import (
+	"sort"
+	"strings"
+
+	"gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// collectionIndexes declare the indexes required by the queries of each collection,
+// every query method of the client documents the index it relies on
+var collectionIndexes = map[string][]mgo.Index{
+	blockTbl: {
+		{Key: []string{"shardNumber", "height"}},
+		{Key: []string{"shardNumber", "timestamp"}},
+		{Key: []string{"shardNumber", "creator"}},
+		{Key: []string{"headHash"}},
+	},
+	txTbl: {
+		{Key: []string{"shardNumber", "block", "idx"}},
+		{Key: []string{"from", "block", "idx"}},
+		{Key: []string{"to", "block", "idx"}},
+		{Key: []string{"contractAddress", "block", "idx"}},
+		{Key: []string{"hash"}},
+		{Key: []string{"block"}},
+		{Key: []string{"idx"}},
+		{Key: []string{"timestamp"}},
+		{Key: []string{"timetxs"}},
+	},
+	lastBlocksTbl: {
+		{Key: []string{"shardNumber", "height"}},
+		{Key: []string{"timestamp"}},
+	},
+	accTbl: {
+		{Key: []string{"address"}},
+		{Key: []string{"accType", "shardNumber", "balance", "address"}},
+		{Key: []string{"shardNumber", "address"}},
+		{Key: []string{"accType", "shardNumber", "timestamp"}},
+		{Key: []string{"creator", "creationBlock"}},
+		{Key: []string{"codeHash", "creationBlock"}},
+		{Key: []string{"balance"}},
+	},
+	minerTbl: {
+		{Key: []string{"address"}},
+		{Key: []string{"shardNumber", "address"}},
+		{Key: []string{"total"}},
+	},
+	debtTbl: {
+		{Key: []string{"shardNumber", "height", "idx", "hash"}},
+		{Key: []string{"hash"}},
+	},
+	pendingTxTbl: {
+		{Key: []string{"shardNumber", "block", "idx"}},
+		{Key: []string{"from", "block", "idx"}},
+		{Key: []string{"to", "block", "idx"}},
+		{Key: []string{"contractAddress", "block", "idx"}},
+		{Key: []string{"hash"}},
+	},
+	txHisTbl: {
+		{Key: []string{"stime"}},
+	},
+	activityTbl: {
+		{Key: []string{"address", "block", "idx", "kind"}},
+		{Key: []string{"shardNumber", "block"}},
+	},
+	syncStateTbl: {
+		{Key: []string{"shardNumber"}},
+	},
+	statsTbl: {
+		{Key: []string{"address", "name", "shardNumber"}},
+	},
+	supplyTbl: {
+		{Key: []string{"shardNumber"}},
+	},
+	apiKeyTbl: {
+		{Key: []string{"key"}},
+	},
+	apiUsageTbl: {
+		{Key: []string{"key", "day"}},
+		{Key: []string{"day"}},
+	},
+	labelTbl: {
+		{Key: []string{"address", "kind"}},
+		{Key: []string{"key"}},
+	},
+	backfillTbl: {
+		{Key: []string{"shardNumber"}},
+	},
+	nodeInfoTbl: {
+		{Key: []string{"host", "port"}},
+		{Key: []string{"id"}},
+		{Key: []string{"shardNumber", "lastseen"}},
+	},
+
+	chartTxTbl:              dailyChartIndexes,
+	chartHashRateTbl:        dailyChartIndexes,
+	chartBlockDifficultyTbl: dailyChartIndexes,
+	chartBlockAvgTimeTbl:    dailyChartIndexes,
+	chartBlockTbl:           dailyChartIndexes,
+	chartAddressTbl:         dailyChartIndexes,
+	chartSupplyTbl:          dailyChartIndexes,
+	chartSingleAddressTbl: {
+		{Key: []string{"address"}},
+	},
+	chartTopMinerRankTbl: {
+		{Key: []string{"shardnumber"}},
+	},
+	chartMinerRevenueTbl: {
+		{Key: []string{"shardnumber", "timestamp"}},
+		{Key: []string{"address", "timestamp"}},
+	},
+}
+
+// dailyChartIndexes is the indexes of the chart tables which have one row per shard and day
+var dailyChartIndexes = []mgo.Index{
+	{Key: []string{"shardnumber", "timestamp"}},
+	{Key: []string{"timestamp"}},
+}
+
+// IndexDrift describe the difference between the declared and the existing indexes of a collection
+type IndexDrift struct {
+	Collection string
+	Missing    []mgo.Index
+	Extra      []mgo.Index
+}
+
+// indexKey return the identity of an index, the indexes with the same key are the same
+func indexKey(index mgo.Index) string {
+	return strings.Join(index.Key, ",")
+}
+
+// indexCollections return the names of the collections which have declared indexes in order
+func indexCollections() []string {
+	var names []string
+	for name := range collectionIndexes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// EnsureIndexes create the declared indexes which are missing, the indexes are built in background
+func (c *Client) EnsureIndexes() error {
+	for _, name := range indexCollections() {
+		query := func(c collection) error {
+			for _, index := range collectionIndexes[name] {
+				index.Background = true
+				if err := c.EnsureIndex(index); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+		if err := c.withCollection(name, query); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetIndexDrifts compare the declared indexes with the existing ones,
+// only the collections which have missing or extra indexes are returned
+func (c *Client) GetIndexDrifts() ([]*IndexDrift, error) {
+	var drifts []*IndexDrift
+	for _, name := range indexCollections() {
+		var existing []mgo.Index
+		query := func(c collection) error {
+			var err error
+			existing, err = c.Indexes()
+			return err
+		}
+		err := c.withCollection(name, query)
+		if err != nil && !isNamespaceNotFound(err) {
+			return nil, err
+		}
+
+		drift := &IndexDrift{Collection: name}
+		existingKeys := make(map[string]bool)
+		for _, index := range existing {
+			existingKeys[indexKey(index)] = true
+		}
+		declaredKeys := make(map[string]bool)
+		for _, index := range collectionIndexes[name] {
+			declaredKeys[indexKey(index)] = true
+			if !existingKeys[indexKey(index)] {
+				drift.Missing = append(drift.Missing, index)
+			}
+		}
+		for _, index := range existing {
+			if index.Name != "_id_" && !declaredKeys[indexKey(index)] {
+				drift.Extra = append(drift.Extra, index)
+			}
+		}
+
+		if len(drift.Missing) > 0 || len(drift.Extra) > 0 {
+			drifts = append(drifts, drift)
+		}
+	}
+	return drifts, nil
+}
+
+// isNamespaceNotFound check whether the error is returned for listing the indexes of a collection not created yet
+func isNamespaceNotFound(err error) bool {
+	if queryErr, ok := err.(*mgo.QueryError); ok && queryErr.Code == 26 {
+		return true
+	}
+	return strings.Contains(err.Error(), "ns does not exist")
+}
+
+// queryShape is the fields used by a query to select and sort the documents of a collection
+type queryShape struct {
+	Collection string
+	Equal      []string
+	Range      []string
+	Sort       []string
+}
+
+// newQueryShapes return the shapes of a query, each branch of $or is an own shape
+// since mongodb selects an index for each of them
+func newQueryShapes(collection string, filter bson.M, sortFields []string) []queryShape {
+	shape := queryShape{Collection: collection}
+	var branches []bson.M
+	for key, cond := range filter {
+		if key == "$or" {
+			branches, _ = toDocList(cond)
+			continue
+		}
+		if isOperatorUpdate(cond) {
+			shape.Range = append(shape.Range, key)
+		} else {
+			shape.Equal = append(shape.Equal, key)
+		}
+	}
+	sort.Strings(shape.Equal)
+	sort.Strings(shape.Range)
+	for _, field := range sortFields {
+		shape.Sort = append(shape.Sort, strings.TrimPrefix(field, "-"))
+	}
+
+	if len(branches) == 0 {
+		return []queryShape{shape}
+	}
+	var shapes []queryShape
+	for _, branch := range branches {
+		for _, s := range newQueryShapes(collection, branch, nil) {
+			s.Equal = append(s.Equal, shape.Equal...)
+			s.Range = append(s.Range, shape.Range...)
+			sort.Strings(s.Equal)
+			sort.Strings(s.Range)
+			s.Sort = shape.Sort
+			shapes = append(shapes, s)
+		}
+	}
+	return shapes
+}
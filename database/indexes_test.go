@@ -0,0 +1,537 @@
+/**
+*  @file
+*  @copyright defined in scan-api/LICENSE
+ */
+
+package database
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/seeleteam/scan-api/log"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// unselectiveFields is the fields which have only a few distinct values,
+// an index which only selects by them still scans a whole shard
+var unselectiveFields = map[string]bool{
+	"shardNumber": true,
+	"shardnumber": true,
+	"accType":     true,
+}
+
+// coveredBy check whether mongodb is able to select and sort the documents of the shape by the index:
+// the index starts with the equality fields of the shape, followed by the sort fields if any
+func (s queryShape) coveredBy(index mgo.Index) bool {
+	equal := make(map[string]bool)
+	selective := false
+	for _, field := range s.Equal {
+		equal[field] = true
+		selective = selective || !unselectiveFields[field]
+	}
+
+	i := 0
+	for i < len(index.Key) && equal[index.Key[i]] {
+		selective = selective && unselectiveFields[index.Key[i]]
+		i++
+	}
+	if selective {
+		// the selective equality fields are not in the index prefix
+		return false
+	}
+
+	var sortFields []string
+	for _, field := range s.Sort {
+		if !equal[field] {
+			sortFields = append(sortFields, field)
+		}
+	}
+	if i == 0 && len(sortFields) == 0 {
+		// no equality field, the index is used for the range
+		for _, field := range s.Range {
+			if index.Key[0] == field {
+				return true
+			}
+		}
+		return false
+	}
+	if len(index.Key)-i < len(sortFields) {
+		return false
+	}
+	for j, field := range sortFields {
+		if index.Key[i+j] != field {
+			return false
+		}
+	}
+	return true
+}
+
+// indexedCall is a call of a client method whose arguments take a branch of the filters of its queries
+type indexedCall struct {
+	method string
+	call   func(c *Client) error
+}
+
+// cursorAfter and cursorBefore return the cursors of the pages following and preceding the keys,
+// which select the documents by $lt and $gt of the descending sort keys
+func cursorAfter(keys ...interface{}) string {
+	return encodeCursor(&pageCursor{Keys: keys, Pos: 1})
+}
+
+func cursorBefore(keys ...interface{}) string {
+	return encodeCursor(&pageCursor{Keys: keys, Before: true, Pos: 1})
+}
+
+// indexedCalls return the calls of every exported method of the client in the order they run,
+// the documents written by the first calls are read by the following ones
+func indexedCalls() []indexedCall {
+	int64p := func(v int64) *int64 { return &v }
+	uint64p := func(v uint64) *uint64 { return &v }
+	boolp := func(v bool) *bool { return &v }
+	block := &DBBlock{HeadHash: "0xb1", Height: 1, Timestamp: 100, Creator: "0x01", ShardNumber: 1}
+	tx := &DBTx{Hash: "0x0a", From: "0x01", To: "0x02", Amount: 10, Block: 1, Idx: 0, ShardNumber: 1, Timestamp: 100}
+	creation := &DBTx{Hash: "0x0b", TxType: 1, From: "0x02", ContractAddress: "0x03", Block: 2, Idx: 0, ShardNumber: 1, Timestamp: 110}
+	debt := &Debt{Hash: "0xd1", TxHash: "0x0a", From: "0x01", To: "0x04", Height: 1, Idx: 0, ShardNumber: 1}
+	account := &DBAccount{Address: "0x01", Balance: 10, ShardNumber: 1, TxCount: 1}
+	contract := &DBAccount{AccType: 1, Address: "0x03", Creator: "0x02", CreationTx: "0x0b", CodeHash: "0xc1", ShardNumber: 1}
+	node := &DBNodeInfo{ID: "n1", Host: "h1", ShardNumber: 1}
+	txFilter := func(filter *TxFilter, cursor string) func(c *Client) error {
+		return func(c *Client) error {
+			_, _, err := c.GetTxsByFilter(filter, cursor, 10)
+			return err
+		}
+	}
+
+	return []indexedCall{
+		{"LiveServers", func(c *Client) error { c.LiveServers(); return nil }},
+		{"SetPrimaryMode", func(c *Client) error { c.SetPrimaryMode(); return nil }},
+		{"SetSecondaryPreferredMode", func(c *Client) error { c.SetSecondaryPreferredMode(); return nil }},
+		{"EnsureIndexes", func(c *Client) error { return c.EnsureIndexes() }},
+		{"GetIndexDrifts", func(c *Client) error { _, err := c.GetIndexDrifts(); return err }},
+		{"GetSchema", func(c *Client) error { _, err := c.GetSchema(); return err }},
+		{"CheckSchemaVersion", func(c *Client) error { return c.CheckSchemaVersion() }},
+		{"ReplicaStatus", func(c *Client) error { _, err := c.ReplicaStatus(); return err }},
+
+		// api keys
+		{"AddAPIKey", func(c *Client) error { return c.AddAPIKey(&DBAPIKey{Key: "k1", Name: "test"}) }},
+		{"GetAPIKeys", func(c *Client) error { _, err := c.GetAPIKeys(); return err }},
+		{"SetAPIKeyDisabled", func(c *Client) error { return c.SetAPIKeyDisabled("k1", true) }},
+		{"IncAPIUsage", func(c *Client) error { return c.IncAPIUsage("k1", "2018-10-19", 10, 1) }},
+		{"GetAPIUsage", func(c *Client) error { _, err := c.GetAPIUsage("2018-10-01", "2018-10-31"); return err }},
+
+		// blocks
+		{"AddBlock", func(c *Client) error { return c.AddBlock(block) }},
+		{"AddBlock", func(c *Client) error {
+			return c.AddBlock(&DBBlock{HeadHash: "0xb2", Height: 2, Timestamp: 110, Creator: "0x02", ShardNumber: 1})
+		}},
+		{"AddLastBlocks", func(c *Client) error {
+			return c.AddLastBlocks(&DBLastBlock{ShardNumber: 1, Height: 1, Timestamp: 100})
+		}},
+		{"UpdateLastBlock", func(c *Client) error {
+			return c.UpdateLastBlock(1, &DBLastBlock{ShardNumber: 1, Height: 1, Timestamp: 100, TxNumber: 1})
+		}},
+		{"GetLastBlocksByShard", func(c *Client) error { _, err := c.GetLastBlocksByShard(1); return err }},
+		{"UpdateBlock", func(c *Client) error { return c.UpdateBlock(1, 1, block) }},
+		{"GetBlockByHeight", func(c *Client) error { _, err := c.GetBlockByHeight(1, 1); return err }},
+		{"GetBlocksByHeights", func(c *Client) error { _, err := c.GetBlocksByHeights(1, []uint64{1, 2}); return err }},
+		{"GetblockdebtCntByShardNumber", func(c *Client) error { _, err := c.GetblockdebtCntByShardNumber(1, 1); return err }},
+		{"GetBlockByHash", func(c *Client) error { _, err := c.GetBlockByHash("0xb1"); return err }},
+		{"GetBlocksByHeight", func(c *Client) error { _, err := c.GetBlocksByHeight(1, 1, 2); return err }},
+		{"GetBlocksByCursor", func(c *Client) error { _, _, err := c.GetBlocksByCursor(1, "", 10); return err }},
+		{"GetBlocksByCursor", func(c *Client) error { _, _, err := c.GetBlocksByCursor(1, cursorAfter(int64(2)), 10); return err }},
+		{"GetBlocksByCursor", func(c *Client) error { _, _, err := c.GetBlocksByCursor(1, cursorBefore(int64(1)), 10); return err }},
+		{"GetBlocksByTime", func(c *Client) error { _, err := c.GetBlocksByTime(1, 100, 110); return err }},
+		{"GetBlockHeight", func(c *Client) error { _, err := c.GetBlockHeight(1); return err }},
+
+		// transactions and debts
+		{"AddTx", func(c *Client) error { return c.AddTx(tx) }},
+		{"AddTxs", func(c *Client) error { return c.AddTxs(creation) }},
+		{"AddDebtTxs", func(c *Client) error { return c.AddDebtTxs(debt) }},
+		{"AddPendingTx", func(c *Client) error {
+			return c.AddPendingTx(&DBTx{Hash: "0x0c", From: "0x01", To: "0x02", ShardNumber: 1, Pending: true})
+		}},
+		{"GetTxByIdx", func(c *Client) error { _, err := c.GetTxByIdx(0); return err }},
+		{"GetTxsByIdx", func(c *Client) error { _, err := c.GetTxsByIdx(1, 0, 10); return err }},
+		{"GetTxsByCursor", func(c *Client) error { _, _, err := c.GetTxsByCursor(1, "", 10); return err }},
+		{"GetTxsByCursor", func(c *Client) error {
+			_, _, err := c.GetTxsByCursor(1, cursorAfter(uint64(2), int64(0)), 10)
+			return err
+		}},
+		{"GetTxsByCursor", func(c *Client) error {
+			_, _, err := c.GetTxsByCursor(1, cursorBefore(uint64(1), int64(0)), 10)
+			return err
+		}},
+		{"GetdebtsByIdx", func(c *Client) error { _, err := c.GetdebtsByIdx(1, 0, 10); return err }},
+		{"GetdebtsByCursor", func(c *Client) error { _, _, err := c.GetdebtsByCursor(1, "", 10); return err }},
+		{"GetdebtsByCursor", func(c *Client) error {
+			_, _, err := c.GetdebtsByCursor(1, cursorAfter(uint64(2), uint64(0)), 10)
+			return err
+		}},
+		{"GetdebtsByCursor", func(c *Client) error {
+			_, _, err := c.GetdebtsByCursor(1, cursorBefore(uint64(1), uint64(0)), 10)
+			return err
+		}},
+		{"GetPendingTxsByIdx", func(c *Client) error { _, err := c.GetPendingTxsByIdx(1, 0, 10); return err }},
+		{"GetPendingTxsByCursor", func(c *Client) error { _, _, err := c.GetPendingTxsByCursor(1, "", 10); return err }},
+		{"GetPendingTxsByCursor", func(c *Client) error {
+			_, _, err := c.GetPendingTxsByCursor(1, cursorAfter(uint64(2), int64(0)), 10)
+			return err
+		}},
+		{"GetTxByHash", func(c *Client) error { _, err := c.GetTxByHash("0x0a"); return err }},
+		{"GetTxsByHashes", func(c *Client) error { _, err := c.GetTxsByHashes([]string{"0x0a", "0x0b"}); return err }},
+		{"GetDebtByHash", func(c *Client) error { _, err := c.GetDebtByHash("0xd1"); return err }},
+		{"GetDebtsByHashes", func(c *Client) error { _, err := c.GetDebtsByHashes([]string{"0xd1"}); return err }},
+		{"GetblockdebtsByIdx", func(c *Client) error { _, err := c.GetblockdebtsByIdx(1, 1, 0, 10); return err }},
+		{"GetTxsByBlock", func(c *Client) error { _, err := c.GetTxsByBlock(1, 1); return err }},
+		{"GetTxsByBlocks", func(c *Client) error { _, err := c.GetTxsByBlocks(1, []uint64{1, 2}); return err }},
+		{"GetDebtsByBlock", func(c *Client) error { _, err := c.GetDebtsByBlock(1, 1); return err }},
+		{"GetDebtsByBlocks", func(c *Client) error { _, err := c.GetDebtsByBlocks(1, []uint64{1, 2}); return err }},
+		{"GetPendingTxByHash", func(c *Client) error { _, err := c.GetPendingTxByHash("0x0c"); return err }},
+		{"GetTxs", func(c *Client) error { _, err := c.GetTxs(1, "", false, 0, 0); return err }},
+		{"GetTxs", func(c *Client) error { _, err := c.GetTxs(1, "block", true, 10, 1); return err }},
+
+		// the transactions of an address
+		{"GetTxsByAddresses", func(c *Client) error { _, err := c.GetTxsByAddresses("0x01", true, 0, 0); return err }},
+		{"GetTxsByAddresses", func(c *Client) error { _, err := c.GetTxsByAddresses("0x01", false, 10, 1); return err }},
+		{"GetTxsByAddressAndBlocks", func(c *Client) error {
+			_, err := c.GetTxsByAddressAndBlocks("0x01", 1, 2, true, 0, 0)
+			return err
+		}},
+		{"GetTxsByAddressAndBlocks", func(c *Client) error {
+			_, err := c.GetTxsByAddressAndBlocks("0x01", 1, 2, false, 10, 1)
+			return err
+		}},
+		{"GetTxsByAddressCursor", func(c *Client) error { _, _, err := c.GetTxsByAddressCursor("0x01", "", 10); return err }},
+		{"GetTxsByAddressCursor", func(c *Client) error {
+			_, _, err := c.GetTxsByAddressCursor("0x01", cursorAfter(uint64(2), int64(0)), 10)
+			return err
+		}},
+		{"GetTxsByAddressCursor", func(c *Client) error {
+			_, _, err := c.GetTxsByAddressCursor("0x01", cursorBefore(uint64(1), int64(0)), 10)
+			return err
+		}},
+		{"GetPendingTxsByAddress", func(c *Client) error { _, err := c.GetPendingTxsByAddress("0x01"); return err }},
+		{"GetTxCntByShardNumberAndAddress", func(c *Client) error {
+			_, err := c.GetTxCntByShardNumberAndAddress(1, "0x01")
+			return err
+		}},
+
+		// the filtered transactions of a shard, bounded by blocks or time, and of the addresses
+		{"GetTxsByFilter", txFilter(&TxFilter{ShardNumber: 1}, "")},
+		{"GetTxsByFilter", txFilter(&TxFilter{ShardNumber: 1, StartBlock: uint64p(1), EndBlock: uint64p(2),
+			Kind: TxKindTransfer, Failed: boolp(true)}, cursorAfter(uint64(2), int64(0)))},
+		{"GetTxsByFilter", txFilter(&TxFilter{ShardNumber: 1, StartTime: int64p(100), EndTime: int64p(110),
+			Kind: TxKindCreate, MinAmount: int64p(1)}, cursorBefore(uint64(1), int64(0)))},
+		{"GetTxsByFilter", txFilter(&TxFilter{From: "0x01", To: "0x02", Kind: TxKindCall, Failed: boolp(false),
+			MinAmount: int64p(1), MaxAmount: int64p(100), MinFee: int64p(0), MaxFee: int64p(10)}, cursorAfter(uint64(2), int64(0)))},
+		{"GetTxsByFilter", txFilter(&TxFilter{Contract: "0x03", StartTime: int64p(100), EndTime: int64p(110),
+			StartBlock: uint64p(1)}, cursorBefore(uint64(1), int64(0)))},
+
+		// address activities
+		{"AddAddressActivities", func(c *Client) error {
+			return c.AddAddressActivities(append(CreateTxActivities(tx), CreateTxActivities(creation)...)...)
+		}},
+		{"GetAddressActivities", func(c *Client) error { _, err := c.GetAddressActivities("0x01", "", 0, 0); return err }},
+		{"GetAddressActivities", func(c *Client) error {
+			_, err := c.GetAddressActivities("0x01", ActivityOut, 10, 1)
+			return err
+		}},
+		{"GetAddressActivities", func(c *Client) error {
+			_, err := c.GetAddressActivities("0x01", ActivityIn, 10, 0)
+			return err
+		}},
+		{"GetAddressActivitiesByCursor", func(c *Client) error {
+			_, _, err := c.GetAddressActivitiesByCursor("0x01", "", "", 10)
+			return err
+		}},
+		{"GetAddressActivitiesByCursor", func(c *Client) error {
+			_, _, err := c.GetAddressActivitiesByCursor("0x01", ActivityIn, cursorAfter(uint64(2), int64(0), ActivitySent), 10)
+			return err
+		}},
+		{"GetAddressActivitiesByCursor", func(c *Client) error {
+			_, _, err := c.GetAddressActivitiesByCursor("0x01", ActivityOut, cursorBefore(uint64(1), int64(0), ActivitySent), 10)
+			return err
+		}},
+		{"EachAddressActivity", func(c *Client) error {
+			return c.EachAddressActivity("0x01", 1, func(activities []*DBAddressActivity) error { return nil })
+		}},
+		{"GetAddressActivityCnt", func(c *Client) error { _, err := c.GetAddressActivityCnt("0x01", ""); return err }},
+		{"GetAddressActivityCnt", func(c *Client) error { _, err := c.GetAddressActivityCnt("0x01", ActivityIn); return err }},
+		{"GetAddressActivityCnt", func(c *Client) error { _, err := c.GetAddressActivityCnt("0x01", ActivityOut); return err }},
+
+		// accounts, contracts and miners
+		{"AddAccount", func(c *Client) error { return c.AddAccount(account) }},
+		{"AddAccount", func(c *Client) error { return c.AddAccount(contract) }},
+		{"UpdateAccount", func(c *Client) error { return c.UpdateAccount(account) }},
+		{"UpdateAccountMinedBlock", func(c *Client) error { return c.UpdateAccountMinedBlock("0x01", 1) }},
+		{"UpdateContract", func(c *Client) error { return c.UpdateContract("0x03", "contract C {}", "[]") }},
+		{"GetAccountByAddress", func(c *Client) error { _, err := c.GetAccountByAddress("0x01"); return err }},
+		{"GetAccountsByAddresses", func(c *Client) error { _, err := c.GetAccountsByAddresses([]string{"0x01", "0x03"}); return err }},
+		{"GetAccountsByShardNumber", func(c *Client) error { _, err := c.GetAccountsByShardNumber(1, 10); return err }},
+		{"GetAccountsByCursor", func(c *Client) error { _, _, err := c.GetAccountsByCursor(1, "", 10); return err }},
+		{"GetAccountsByCursor", func(c *Client) error {
+			_, _, err := c.GetAccountsByCursor(1, cursorAfter(int64(10), "0x01"), 10)
+			return err
+		}},
+		{"GetAccountsByCursor", func(c *Client) error {
+			_, _, err := c.GetAccountsByCursor(1, cursorBefore(int64(10), "0x01"), 10)
+			return err
+		}},
+		{"GetAccountsByHome", func(c *Client) error { c.GetAccountsByHome(); return nil }},
+		{"GetTxCntByAddressFromAccount", func(c *Client) error { _, err := c.GetTxCntByAddressFromAccount("0x01"); return err }},
+		{"GetTxCntAndAccTypeByAddressFromAccount", func(c *Client) error {
+			_, _, err := c.GetTxCntAndAccTypeByAddressFromAccount("0x01")
+			return err
+		}},
+		{"GetContractsByShardNumber", func(c *Client) error { _, err := c.GetContractsByShardNumber(1, 10); return err }},
+		{"GetContractsByCreator", func(c *Client) error { _, err := c.GetContractsByCreator("0x02", 0, 10); return err }},
+		{"GetContractCntByCreator", func(c *Client) error { _, err := c.GetContractCntByCreator("0x02"); return err }},
+		{"GetContractsByCodeHash", func(c *Client) error { _, err := c.GetContractsByCodeHash("0xc1", 0, 10); return err }},
+		{"GetContractCntByCodeHash", func(c *Client) error { _, err := c.GetContractCntByCodeHash("0xc1"); return err }},
+		{"GetCodeHashGroups", func(c *Client) error { _, err := c.GetCodeHashGroups(1, 10, 0, 10); return err }},
+		{"GetTotalBalance", func(c *Client) error { _, err := c.GetTotalBalance(); return err }},
+		{"UpdateMinerAccount", func(c *Client) error { return c.UpdateMinerAccount(&DBMiner{Address: "0x01", ShardNumber: 1}) }},
+		{"IncMinerAccount", func(c *Client) error {
+			return c.IncMinerAccount(&DBMiner{Address: "0x01", ShardNumber: 1, Reward: 10, Mined: 1})
+		}},
+		{"GetMinerAccountByAddress", func(c *Client) error { _, err := c.GetMinerAccountByAddress("0x01"); return err }},
+		{"GetMinerAccounts", func(c *Client) error { _, err := c.GetMinerAccounts(10); return err }},
+		{"GetMinedBlocksCntByShardNumberAndAddress", func(c *Client) error {
+			_, err := c.GetMinedBlocksCntByShardNumberAndAddress(1, "0x01")
+			return err
+		}},
+		{"GetMinedBlocksByShardNumberAndAddress", func(c *Client) error {
+			_, _, _, err := c.GetMinedBlocksByShardNumberAndAddress(1, "0x01")
+			return err
+		}},
+		{"GetBlockfee", func(c *Client) error { _, err := c.GetBlockfee(1); return err }},
+
+		// counters
+		{"GetTxsDayCount", func(c *Client) error { _, err := c.GetTxsDayCount(); return err }},
+		{"GetTxCnt", func(c *Client) error { _, err := c.GetTxCnt(); return err }},
+		{"GetBlockProTime", func(c *Client) error { _, _, err := c.GetBlockProTime(); return err }},
+		{"GetBlockCnt", func(c *Client) error { _, err := c.GetBlockCnt(); return err }},
+		{"GetAccountCnt", func(c *Client) error { _, err := c.GetAccountCnt(); return err }},
+		{"GetBlockTxsTps", func(c *Client) error { _, err := c.GetBlockTxsTps(); return err }},
+		{"GetContractCnt", func(c *Client) error { _, err := c.GetContractCnt(); return err }},
+		{"GetAccountCntByShardNumber", func(c *Client) error { _, err := c.GetAccountCntByShardNumber(1); return err }},
+		{"GetContractCntByShardNumber", func(c *Client) error { _, err := c.GetContractCntByShardNumber(1); return err }},
+		{"GetTxCntByShardNumber", func(c *Client) error { _, err := c.GetTxCntByShardNumber(1); return err }},
+		{"GetdebtCntByShardNumber", func(c *Client) error { _, err := c.GetdebtCntByShardNumber(1); return err }},
+		{"GetPendingTxCntByShardNumber", func(c *Client) error { _, err := c.GetPendingTxCntByShardNumber(1); return err }},
+
+		// supply and the daily charts
+		{"SetGenesisSupply", func(c *Client) error { return c.SetGenesisSupply(1, 100) }},
+		{"IncSupply", func(c *Client) error { return c.IncSupply(&DBOneDaySupply{ShardNumber: 1, Minted: 10, Blocks: 1}) }},
+		{"GetSupply", func(c *Client) error { _, err := c.GetSupply(); return err }},
+		{"GetOneDaySupply", func(c *Client) error { _, err := c.GetOneDaySupply(1, 0); return err }},
+		{"GetSupplyChart", func(c *Client) error { _, err := c.GetSupplyChart(); return err }},
+		{"GetSupplyChartByShardNumber", func(c *Client) error { _, err := c.GetSupplyChartByShardNumber(1); return err }},
+		{"AddOneDayTransInfo", func(c *Client) error { return c.AddOneDayTransInfo(1, &DBOneDayTxInfo{}) }},
+		{"GetOneDayTransInfo", func(c *Client) error { _, err := c.GetOneDayTransInfo(1, 0); return err }},
+		{"GetTransInfoChart", func(c *Client) error { _, err := c.GetTransInfoChart(); return err }},
+		{"GetTransInfoChartByShardNumber", func(c *Client) error { _, err := c.GetTransInfoChartByShardNumber(1); return err }},
+		{"AddOneDayHashRate", func(c *Client) error { return c.AddOneDayHashRate(1, &DBOneDayHashRate{}) }},
+		{"GetOneDayHashRate", func(c *Client) error { _, err := c.GetOneDayHashRate(1, 0); return err }},
+		{"GetHashRateChart", func(c *Client) error { _, err := c.GetHashRateChart(); return err }},
+		{"GetHashRateChartByShardNumber", func(c *Client) error { _, err := c.GetHashRateChartByShardNumber(1); return err }},
+		{"AddOneDayBlockDifficulty", func(c *Client) error { return c.AddOneDayBlockDifficulty(1, &DBOneDayBlockDifficulty{}) }},
+		{"GetOneDayBlockDifficulty", func(c *Client) error { _, err := c.GetOneDayBlockDifficulty(1, 0); return err }},
+		{"GetOneDayBlockDifficultyChart", func(c *Client) error { _, err := c.GetOneDayBlockDifficultyChart(); return err }},
+		{"GetOneDayBlockDifficultyChartByShardNumber", func(c *Client) error {
+			_, err := c.GetOneDayBlockDifficultyChartByShardNumber(1)
+			return err
+		}},
+		{"AddOneDayBlockAvgTime", func(c *Client) error { return c.AddOneDayBlockAvgTime(1, &DBOneDayBlockAvgTime{}) }},
+		{"GetOneDayBlockAvgTime", func(c *Client) error { _, err := c.GetOneDayBlockAvgTime(1, 0); return err }},
+		{"GetOneDayBlockAvgTimeChart", func(c *Client) error { _, err := c.GetOneDayBlockAvgTimeChart(); return err }},
+		{"GetOneDayBlockAvgTimeChartByShardNumber", func(c *Client) error {
+			_, err := c.GetOneDayBlockAvgTimeChartByShardNumber(1)
+			return err
+		}},
+		{"AddOneDayBlock", func(c *Client) error { return c.AddOneDayBlock(1, &DBOneDayBlockInfo{}) }},
+		{"GetOneDayBlock", func(c *Client) error { _, err := c.GetOneDayBlock(1, 0); return err }},
+		{"GetOneDayBlocksChart", func(c *Client) error { _, err := c.GetOneDayBlocksChart(); return err }},
+		{"GetOneDayBlocksChartByShardNumber", func(c *Client) error { _, err := c.GetOneDayBlocksChartByShardNumber(1); return err }},
+		{"AddOneDayMinerRevenue", func(c *Client) error { return c.AddOneDayMinerRevenue(1, &DBOneDayMinerRevenue{}) }},
+		{"GetOneDayMinerRevenue", func(c *Client) error { _, err := c.GetOneDayMinerRevenue(1, 0); return err }},
+		{"GetMinerRevenueChart", func(c *Client) error { _, err := c.GetMinerRevenueChart("0x01"); return err }},
+		{"GetMinerRevenueChartByShardNumber", func(c *Client) error {
+			_, err := c.GetMinerRevenueChartByShardNumber("0x01", 1)
+			return err
+		}},
+		{"AddOneDayAddress", func(c *Client) error { return c.AddOneDayAddress(1, &DBOneDayAddressInfo{}) }},
+		{"GetOneDayAddress", func(c *Client) error { _, err := c.GetOneDayAddress(1, 0); return err }},
+		{"GetOneDayAddressesChart", func(c *Client) error { _, err := c.GetOneDayAddressesChart(); return err }},
+		{"GetOneDayAddressesChartByShardNumber", func(c *Client) error { _, err := c.GetOneDayAddressesChartByShardNumber(1); return err }},
+		{"AddOneDaySingleAddressInfo", func(c *Client) error { return c.AddOneDaySingleAddressInfo(1, &DBOneDaySingleAddressInfo{}) }},
+		{"GetOneDaySingleAddressInfo", func(c *Client) error { _, err := c.GetOneDaySingleAddressInfo(1, "0x01"); return err }},
+		{"RemoveTopMinerInfo", func(c *Client) error { return c.RemoveTopMinerInfo() }},
+		{"AddTopMinerInfo", func(c *Client) error { return c.AddTopMinerInfo(1, &DBMinerRankInfo{}) }},
+		{"GetTopMinerChart", func(c *Client) error { _, err := c.GetTopMinerChart(); return err }},
+		{"GetTopMinerChartByShardNumber", func(c *Client) error { _, err := c.GetTopMinerChartByShardNumber(1); return err }},
+		{"UpdateTxsCntByDate", func(c *Client) error { return c.UpdateTxsCntByDate(&DBSimpleTxs{Stime: "2018-10-19", TxCount: 1}) }},
+		{"GetTxsinfoByDate", func(c *Client) error { _, _, _, _, err := c.GetTxsinfoByDate("2018-10-19"); return err }},
+		{"GetTxHisCntByDate", func(c *Client) error { _, err := c.GetTxHisCntByDate("2018-10-19"); return err }},
+		{"GetTxHis", func(c *Client) error { _, err := c.GetTxHis("2018-10-01", "2018-10-19"); return err }},
+		{"RemoveOutDateByDate", func(c *Client) error { return c.RemoveOutDateByDate("2018-10-01") }},
+
+		// nodes
+		{"AddNodeInfo", func(c *Client) error { return c.AddNodeInfo(node) }},
+		{"GetNodeInfo", func(c *Client) error { _, err := c.GetNodeInfo("h1"); return err }},
+		{"GetNodeInfoByID", func(c *Client) error { _, err := c.GetNodeInfoByID("n1"); return err }},
+		{"GetNodeInfosByShardNumber", func(c *Client) error { _, err := c.GetNodeInfosByShardNumber(1); return err }},
+		{"GetNodeInfos", func(c *Client) error { _, err := c.GetNodeInfos(); return err }},
+		{"GetNodeCntByShardNumber", func(c *Client) error { _, err := c.GetNodeCntByShardNumber(1); return err }},
+		{"DeleteNodeInfo", func(c *Client) error { return c.DeleteNodeInfo(node) }},
+
+		// labels
+		{"SetLabel", func(c *Client) error { return c.SetLabel(&DBLabel{Address: "0x01", Kind: LabelName, Name: "alice"}) }},
+		{"GetLabels", func(c *Client) error { _, err := c.GetLabels(); return err }},
+		{"GetLabelsByAddresses", func(c *Client) error { _, err := c.GetLabelsByAddresses([]string{"0x01"}); return err }},
+		{"GetLabelsByName", func(c *Client) error { _, err := c.GetLabelsByName("alice", 10); return err }},
+		{"GetLabelsByPrefix", func(c *Client) error { _, err := c.GetLabelsByPrefix("al", 10); return err }},
+		{"GetAccountsByAddressPrefix", func(c *Client) error { _, err := c.GetAccountsByAddressPrefix("0x0", 10); return err }},
+		{"RemoveLabel", func(c *Client) error { return c.RemoveLabel("0x01", LabelName) }},
+
+		// export, import and the maintenance
+		{"Export", func(c *Client) error {
+			for _, entity := range Entities() {
+				if err := c.Export(entity, 1, 1, 2, nil, 1, func(docs []interface{}, last []interface{}) error { return nil }); err != nil {
+					return err
+				}
+			}
+			return nil
+		}},
+		{"Export", func(c *Client) error {
+			return c.Export(EntityTxs, 1, 0, -1, []interface{}{uint64(1), int64(0)}, 1, func(docs []interface{}, last []interface{}) error { return nil })
+		}},
+		{"Import", func(c *Client) error { return c.Import(EntityTxs, []interface{}{tx}) }},
+		{"Recount", func(c *Client) error { _, err := c.Recount(false, 1); return err }},
+		{"Recount", func(c *Client) error { _, err := c.Recount(true, 1); return err }},
+		{"RecountSupply", func(c *Client) error { return c.RecountSupply(1) }},
+		{"RecountMiners", func(c *Client) error { return c.RecountMiners(1) }},
+		{"GetReceiptBackfill", func(c *Client) error { _, err := c.GetReceiptBackfill(1); return err }},
+		{"BackfillReceipts", func(c *Client) error {
+			node := &fakeReceiptNode{failed: map[string]bool{"0x0a": true}}
+			_, err := c.BackfillReceipts(1, node, 1, false, func(tx *DBTx, err error) error { return nil })
+			return err
+		}},
+		{"Migrate", func(c *Client) error { return c.Migrate(0, 1, nil) }},
+		{"Migrate", func(c *Client) error { return c.Migrate(SchemaVersion(), 1, testMigrationNodes()) }},
+
+		// the removal of the blocks of a reorg
+		{"RemoveAddressActivities", func(c *Client) error { return c.RemoveAddressActivities(1, 2) }},
+		{"RemoveTxs", func(c *Client) error { return c.RemoveTxs(1, 2) }},
+		{"RemoveDebts", func(c *Client) error { return c.RemoveDebts(1, 1) }},
+		{"RemoveBlock", func(c *Client) error { return c.RemoveBlock(1, 2) }},
+		{"RemoveAllPendingTxs", func(c *Client) error { return c.RemoveAllPendingTxs() }},
+		{"RemoveLastBlocksByShard", func(c *Client) error { return c.RemoveLastBlocksByShard(1) }},
+	}
+}
+
+func Test_DeclaredIndexesCoverQueries(t *testing.T) {
+	log.NewLogger("", "error", false)
+	c := NewMemoryClient(1)
+	type observedShape struct {
+		method string
+		shape  queryShape
+	}
+	var shapes []observedShape
+	method := ""
+	c.mem.observer = func(shape queryShape) {
+		shapes = append(shapes, observedShape{method, shape})
+	}
+
+	called := make(map[string]bool)
+	for _, call := range indexedCalls() {
+		method = call.method
+		called[call.method] = true
+		func() {
+			defer func() {
+				if r := recover(); r != nil {
+					t.Errorf("%s panics: %v", call.method, r)
+				}
+			}()
+			// a document which is not found is still queried
+			if err := call.call(c); err != nil && err != mgo.ErrNotFound {
+				t.Errorf("%s fails: %v", call.method, err)
+			}
+		}()
+	}
+	clientt := reflect.TypeOf(c)
+	for i := 0; i < clientt.NumMethod(); i++ {
+		assert.True(t, called[clientt.Method(i).Name], "method %s is not called", clientt.Method(i).Name)
+	}
+	assert.NotEmpty(t, shapes)
+
+	for _, observed := range shapes {
+		if observed.method == "Migrate" {
+			// the migrations scan the whole collections once
+			continue
+		}
+		shape := observed.shape
+		if len(shape.Equal) == 0 && len(shape.Range) == 0 && len(shape.Sort) == 0 {
+			// the whole collection is scanned
+			continue
+		}
+
+		covered := false
+		indexes := append([]mgo.Index{{Key: []string{"_id"}}}, collectionIndexes[shape.Collection]...)
+		for _, index := range indexes {
+			if shape.coveredBy(index) {
+				covered = true
+				break
+			}
+		}
+		assert.True(t, covered, "query %+v of %s is not covered by the declared indexes", shape, observed.method)
+	}
+}
+
+func Test_NewQueryShapes(t *testing.T) {
+	shapes := newQueryShapes(txTbl, bson.M{
+		"shardNumber": 1,
+		"$or":         []bson.M{{"from": "0x01"}, {"to": "0x01"}},
+	}, []string{"-block", "-idx"})
+	assert.Equal(t, len(shapes), 2)
+	assert.Equal(t, shapes[0].Equal, []string{"from", "shardNumber"})
+	assert.Equal(t, shapes[0].Sort, []string{"block", "idx"})
+	assert.Equal(t, shapes[1].Equal, []string{"shardNumber", "to"})
+	assert.True(t, shapes[1].coveredBy(mgo.Index{Key: []string{"to", "block", "idx"}}))
+	assert.False(t, shapes[1].coveredBy(mgo.Index{Key: []string{"shardNumber", "block", "idx"}}))
+
+	shape := newQueryShapes(blockTbl, bson.M{
+		"shardNumber": 1,
+		"height":      bson.M{"$gte": 1, "$lt": 10},
+	}, []string{"-height"})[0]
+	assert.Equal(t, shape.Range, []string{"height"})
+	assert.True(t, shape.coveredBy(mgo.Index{Key: []string{"shardNumber", "height"}}))
+	assert.False(t, shape.coveredBy(mgo.Index{Key: []string{"shardNumber", "timestamp"}}))
+}
+
+func Test_EnsureIndexes(t *testing.T) {
+	c := NewMemoryClient(1)
+	drifts, err := c.GetIndexDrifts()
+	assert.Nil(t, err)
+	assert.Equal(t, len(drifts), len(collectionIndexes))
+	assert.Equal(t, drifts[0].Missing, collectionIndexes[drifts[0].Collection])
+
+	assert.Nil(t, c.EnsureIndexes())
+	drifts, err = c.GetIndexDrifts()
+	assert.Nil(t, err)
+	assert.Empty(t, drifts)
+
+	err = c.withCollection(txTbl, func(c collection) error {
+		return c.EnsureIndex(mgo.Index{Key: []string{"gasPrice"}})
+	})
+	assert.Nil(t, err)
+	drifts, err = c.GetIndexDrifts()
+	assert.Nil(t, err)
+	assert.Equal(t, len(drifts), 1)
+	assert.Equal(t, drifts[0].Collection, txTbl)
+	assert.Empty(t, drifts[0].Missing)
+	assert.Equal(t, drifts[0].Extra, []mgo.Index{{Key: []string{"gasPrice"}}})
+}
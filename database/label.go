@@ -0,0 +1,109 @@
+/**
+*  @file
+*  @copyright defined in scan-api/LICENSE
+ */
+
+package database
+
+import (
+	"errors"
+	"strings"
+
+	"gopkg.in/mgo.v2/bson"
+)
+
+// ErrInvalidLabelKind is returned for an unknown kind of label
+var ErrInvalidLabelKind = errors.New("invalid label kind")
+
+// prefixEnd is appended to a prefix to select all the strings starting with it
+const prefixEnd = "￿"
+
+// LabelKey return the key a label name is searched by
+func LabelKey(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
+
+func validLabelKind(kind string) bool {
+	return kind == LabelName || kind == LabelToken || kind == LabelContract
+}
+
+// SetLabel add the label of an address or replace its label of the same kind
+// index: label {address, kind}
+func (c *Client) SetLabel(label *DBLabel) error {
+	if !validLabelKind(label.Kind) {
+		return ErrInvalidLabelKind
+	}
+	label.Key = LabelKey(label.Name)
+	query := func(c collection) error {
+		_, err := c.Upsert(bson.M{"address": label.Address, "kind": label.Kind}, bson.M{"$set": bson.M{"name": label.Name, "key": label.Key}})
+		return err
+	}
+	return c.withCollection(labelTbl, query)
+}
+
+// RemoveLabel remove the label of the kind of an address
+// index: label {address, kind}
+func (c *Client) RemoveLabel(address string, kind string) error {
+	query := func(c collection) error {
+		_, err := c.RemoveAll(bson.M{"address": address, "kind": kind})
+		return err
+	}
+	return c.withCollection(labelTbl, query)
+}
+
+// GetLabels get all the labels
+func (c *Client) GetLabels() ([]*DBLabel, error) {
+	var labels []*DBLabel
+	query := func(c collection) error {
+		return c.Find(nil).All(&labels)
+	}
+	err := c.withCollection(labelTbl, query)
+	return labels, err
+}
+
+// GetLabelsByAddresses get the labels of the addresses
+// index: label {address, kind}
+func (c *Client) GetLabelsByAddresses(addresses []string) ([]*DBLabel, error) {
+	var labels []*DBLabel
+	query := func(c collection) error {
+		return c.Find(bson.M{"address": bson.M{"$in": addresses}}).All(&labels)
+	}
+	err := c.withCollection(labelTbl, query)
+	return labels, err
+}
+
+// GetLabelsByName get the labels of the name, the case is ignored
+// index: label {key}
+func (c *Client) GetLabelsByName(name string, limit int) ([]*DBLabel, error) {
+	var labels []*DBLabel
+	query := func(c collection) error {
+		return c.Find(bson.M{"key": LabelKey(name)}).Limit(limit).All(&labels)
+	}
+	err := c.withCollection(labelTbl, query)
+	return labels, err
+}
+
+// GetLabelsByPrefix get the labels whose names start with the prefix in the order of the names,
+// the case is ignored
+// index: label {key}
+func (c *Client) GetLabelsByPrefix(prefix string, limit int) ([]*DBLabel, error) {
+	var labels []*DBLabel
+	key := LabelKey(prefix)
+	query := func(c collection) error {
+		return c.Find(bson.M{"key": bson.M{"$gte": key, "$lt": key + prefixEnd}}).Sort("key").Limit(limit).All(&labels)
+	}
+	err := c.withCollection(labelTbl, query)
+	return labels, err
+}
+
+// GetAccountsByAddressPrefix get the accounts whose addresses start with the prefix in the order of the addresses
+// index: account {address}
+func (c *Client) GetAccountsByAddressPrefix(prefix string, limit int) ([]*DBAccount, error) {
+	var accounts []*DBAccount
+	prefix = strings.ToLower(prefix)
+	query := func(c collection) error {
+		return c.Find(bson.M{"address": bson.M{"$gte": prefix, "$lt": prefix + prefixEnd}}).Sort("address").Limit(limit).All(&accounts)
+	}
+	err := c.withCollection(accTbl, query)
+	return accounts, err
+}
@@ -0,0 +1,67 @@
+/**
+*  @file
+*  @copyright defined in scan-api/LICENSE
+ */
+
+package database
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func labelNames(labels []*DBLabel) []string {
+	var names []string
+	for _, label := range labels {
+		names = append(names, label.Name)
+	}
+	return names
+}
+
+func Test_Labels(t *testing.T) {
+	c := NewMemoryClient(1)
+	assert.Nil(t, c.SetLabel(&DBLabel{Address: "0x01", Kind: LabelName, Name: "Exchange"}))
+	assert.Nil(t, c.SetLabel(&DBLabel{Address: "0x01", Kind: LabelName, Name: "Seele Exchange"}))
+	assert.Nil(t, c.SetLabel(&DBLabel{Address: "0x02", Kind: LabelToken, Name: "SEE"}))
+	assert.Nil(t, c.SetLabel(&DBLabel{Address: "0x03", Kind: LabelContract, Name: "SeeleToken"}))
+	assert.Equal(t, c.SetLabel(&DBLabel{Address: "0x04", Kind: "tag", Name: "x"}), ErrInvalidLabelKind)
+
+	// a label of the same kind is replaced
+	labels, err := c.GetLabelsByAddresses([]string{"0x01"})
+	assert.Nil(t, err)
+	assert.Equal(t, labelNames(labels), []string{"Seele Exchange"})
+
+	labels, err = c.GetLabelsByName(" see ", 10)
+	assert.Nil(t, err)
+	assert.Equal(t, labelNames(labels), []string{"SEE"})
+
+	labels, err = c.GetLabelsByPrefix("SEE", 10)
+	assert.Nil(t, err)
+	assert.Equal(t, labelNames(labels), []string{"SEE", "Seele Exchange", "SeeleToken"})
+	labels, err = c.GetLabelsByPrefix("seele", 1)
+	assert.Nil(t, err)
+	assert.Equal(t, labelNames(labels), []string{"Seele Exchange"})
+
+	assert.Nil(t, c.RemoveLabel("0x02", LabelToken))
+	labels, err = c.GetLabels()
+	assert.Nil(t, err)
+	assert.Equal(t, len(labels), 2)
+}
+
+func Test_GetAccountsByAddressPrefix(t *testing.T) {
+	c := NewMemoryClient(1)
+	for _, address := range []string{"0x1a02", "0x1a01", "0x1b01", "0x2a01"} {
+		assert.Nil(t, c.AddAccount(&DBAccount{Address: address, ShardNumber: 1}))
+	}
+
+	accounts, err := c.GetAccountsByAddressPrefix("0x1A", 10)
+	assert.Nil(t, err)
+	assert.Equal(t, len(accounts), 2)
+	assert.Equal(t, accounts[0].Address, "0x1a01")
+	assert.Equal(t, accounts[1].Address, "0x1a02")
+
+	accounts, err = c.GetAccountsByAddressPrefix("0x1", 1)
+	assert.Nil(t, err)
+	assert.Equal(t, len(accounts), 1)
+}
@@ -0,0 +1,965 @@
+/**
+*  @file
+*  @copyright defined in scan-api/LICENSE
+ */
+
+package database
+
+import (
+	"errors"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+
+	"gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+)
+
+var errUnsupportedOperator = errors.New("unsupported query operator")
+
+// memCollection is an in memory collection of bson documents kept in insertion order,
+// it supports the subset of the mongo query language used by the client
+type memCollection struct {
+	db      *memDatabase
+	name    string
+	docs    []bson.M
+	indexes []mgo.Index
+}
+
+// memDatabase is an in memory database of collections
+type memDatabase struct {
+	mu          sync.Mutex
+	collections map[string]*memCollection
+
+	// observer is called with the shape of every query if it is set
+	observer func(shape queryShape)
+}
+
+func newMemDatabase() *memDatabase {
+	return &memDatabase{collections: make(map[string]*memCollection)}
+}
+
+// withCollection perform an query on the collection, the queries are serialized
+func (db *memDatabase) withCollection(name string, s func(collection) error) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	c, ok := db.collections[name]
+	if !ok {
+		c = &memCollection{db: db, name: name}
+		db.collections[name] = c
+	}
+	return s(c)
+}
+
+// memPipe is the aggregation of an memCollection, it mirrors mgo.Pipe
+type memPipe struct {
+	c        *memCollection
+	pipeline []bson.M
+}
+
+// memQuery is the query of an memCollection, it mirrors mgo.Query
+type memQuery struct {
+	c      *memCollection
+	filter bson.M
+	sort   []string
+	skip   int
+	limit  int
+	err    error
+}
+
+// toDoc convert a struct or a map into a bson document by its bson tags
+func toDoc(v interface{}) (bson.M, error) {
+	data, err := bson.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	doc := bson.M{}
+	err = bson.Unmarshal(data, doc)
+	return doc, err
+}
+
+// toFilter convert a selector into a bson document, nil matches all documents
+func toFilter(v interface{}) (bson.M, error) {
+	if v == nil {
+		return nil, nil
+	}
+	if m, ok := v.(bson.M); ok {
+		return m, nil
+	}
+	return toDoc(v)
+}
+
+// fromDoc decode a bson document into result which is a pointer
+func fromDoc(doc bson.M, result interface{}) error {
+	data, err := bson.Marshal(doc)
+	if err != nil {
+		return err
+	}
+	return bson.Unmarshal(data, result)
+}
+
+// fromDocs decode the bson documents into result which is a pointer to a slice
+func fromDocs(docs []bson.M, result interface{}) error {
+	resultv := reflect.ValueOf(result)
+	if resultv.Kind() != reflect.Ptr || resultv.Elem().Kind() != reflect.Slice {
+		panic("result argument must be a slice address")
+	}
+	slicev := resultv.Elem()
+	slicev = slicev.Slice(0, slicev.Cap())
+	elemt := slicev.Type().Elem()
+	for i, doc := range docs {
+		var elemp reflect.Value
+		if elemt.Kind() == reflect.Ptr {
+			elemp = reflect.New(elemt.Elem())
+		} else {
+			elemp = reflect.New(elemt)
+		}
+		if err := fromDoc(doc, elemp.Interface()); err != nil {
+			return err
+		}
+		if elemt.Kind() != reflect.Ptr {
+			elemp = elemp.Elem()
+		}
+		if i < slicev.Len() {
+			slicev.Index(i).Set(elemp)
+		} else {
+			slicev = reflect.Append(slicev, elemp)
+		}
+	}
+	resultv.Elem().Set(slicev.Slice(0, len(docs)))
+	return nil
+}
+
+// Find prepare a query with the filter, a nil filter matches all documents
+func (c *memCollection) Find(filter interface{}) query {
+	m, err := toFilter(filter)
+	return &memQuery{c: c, filter: m, err: err}
+}
+
+// Pipe prepare an aggregation with the pipeline stages
+func (c *memCollection) Pipe(pipeline interface{}) pipe {
+	stages, _ := pipeline.([]bson.M)
+	return &memPipe{c: c, pipeline: stages}
+}
+
+// Sort set the sort fields, a field prefixed with "-" is sorted descending
+func (q *memQuery) Sort(fields ...string) query {
+	q.sort = fields
+	return q
+}
+
+// Skip skip the first n documents
+func (q *memQuery) Skip(n int) query {
+	q.skip = n
+	return q
+}
+
+// Limit return n documents at most, zero means no limit
+func (q *memQuery) Limit(n int) query {
+	if n < 0 {
+		n = -n
+	}
+	q.limit = n
+	return q
+}
+
+// docs return the matched documents after sorting, skipping and limiting
+func (q *memQuery) docs() ([]bson.M, error) {
+	if q.err != nil {
+		return nil, q.err
+	}
+	q.c.observe(q.filter, q.sort)
+	var docs []bson.M
+	for _, doc := range q.c.docs {
+		ok, err := matchDoc(doc, q.filter)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			docs = append(docs, doc)
+		}
+	}
+
+	sortDocs(docs, q.sort)
+	return skipAndLimit(docs, q.skip, q.limit), nil
+}
+
+// sortDocs sort the documents stably by the fields, a field prefixed with "-" is sorted descending
+func sortDocs(docs []bson.M, fields []string) {
+	if len(fields) == 0 {
+		return
+	}
+	sort.SliceStable(docs, func(i, j int) bool {
+		for _, field := range fields {
+			desc := strings.HasPrefix(field, "-")
+			field = strings.TrimPrefix(field, "-")
+			a, _ := lookup(docs[i], field)
+			b, _ := lookup(docs[j], field)
+			cmp := compareValues(a, b)
+			if cmp == 0 {
+				continue
+			}
+			if desc {
+				return cmp > 0
+			}
+			return cmp < 0
+		}
+		return false
+	})
+}
+
+func skipAndLimit(docs []bson.M, skip int, limit int) []bson.M {
+	if skip > 0 {
+		if skip >= len(docs) {
+			return nil
+		}
+		docs = docs[skip:]
+	}
+	if limit > 0 && limit < len(docs) {
+		docs = docs[:limit]
+	}
+	return docs
+}
+
+// All decode all the matched documents into result which is a pointer to a slice
+func (q *memQuery) All(result interface{}) error {
+	docs, err := q.docs()
+	if err != nil {
+		return err
+	}
+	return fromDocs(docs, result)
+}
+
+// One decode the first matched document into result, mgo.ErrNotFound is returned if nothing matched
+func (q *memQuery) One(result interface{}) error {
+	q.limit = 1
+	docs, err := q.docs()
+	if err != nil {
+		return err
+	}
+	if len(docs) == 0 {
+		return mgo.ErrNotFound
+	}
+	return fromDoc(docs[0], result)
+}
+
+// Count return the number of the matched documents
+func (q *memQuery) Count() (int, error) {
+	docs, err := q.docs()
+	return len(docs), err
+}
+
+// Count return the number of all documents
+func (c *memCollection) Count() (int, error) {
+	return len(c.docs), nil
+}
+
+// Insert insert the documents in order, an _id is generated for the documents without one
+func (c *memCollection) Insert(docs ...interface{}) error {
+	for _, v := range docs {
+		doc, err := toDoc(v)
+		if err != nil {
+			return err
+		}
+		c.append(doc)
+	}
+	return nil
+}
+
+// append add the document to the end of the collection, an _id is generated if it is missing
+func (c *memCollection) append(doc bson.M) {
+	if _, ok := doc["_id"]; !ok {
+		doc["_id"] = bson.NewObjectId()
+	}
+	c.docs = append(c.docs, doc)
+}
+
+// index return the position of the first document matched by the selector, -1 for not found
+func (c *memCollection) index(selector interface{}) (int, error) {
+	filter, err := toFilter(selector)
+	if err != nil {
+		return -1, err
+	}
+	c.observe(filter, nil)
+	for i, doc := range c.docs {
+		ok, err := matchDoc(doc, filter)
+		if err != nil {
+			return -1, err
+		}
+		if ok {
+			return i, nil
+		}
+	}
+	return -1, nil
+}
+
+// Update update the first document matched by the selector, mgo.ErrNotFound is returned if nothing matched
+func (c *memCollection) Update(selector interface{}, update interface{}) error {
+	i, err := c.index(selector)
+	if err != nil {
+		return err
+	}
+	if i < 0 {
+		return mgo.ErrNotFound
+	}
+	doc, err := applyUpdate(c.docs[i], update)
+	if err != nil {
+		return err
+	}
+	c.docs[i] = doc
+	return nil
+}
+
+// Upsert update the first document matched by the selector, or insert a new one built from
+// the equality fields of the selector if nothing matched
+func (c *memCollection) Upsert(selector interface{}, update interface{}) (*mgo.ChangeInfo, error) {
+	i, err := c.index(selector)
+	if err != nil {
+		return nil, err
+	}
+	if i >= 0 {
+		doc, err := applyUpdate(c.docs[i], update)
+		if err != nil {
+			return nil, err
+		}
+		c.docs[i] = doc
+		return &mgo.ChangeInfo{Updated: 1, Matched: 1}, nil
+	}
+
+	doc := bson.M{}
+	if isOperatorUpdate(update) {
+		filter, _ := toFilter(selector)
+		for k, v := range filter {
+			if _, ok := v.(bson.M); !ok && !strings.HasPrefix(k, "$") {
+				doc[k] = v
+			}
+		}
+		doc, err = toDoc(doc)
+		if err != nil {
+			return nil, err
+		}
+	}
+	doc, err = applyUpdate(doc, update)
+	if err != nil {
+		return nil, err
+	}
+	c.append(doc)
+	return &mgo.ChangeInfo{UpsertedId: doc["_id"]}, nil
+}
+
+// Remove remove the first document matched by the selector, mgo.ErrNotFound is returned if nothing matched
+func (c *memCollection) Remove(selector interface{}) error {
+	i, err := c.index(selector)
+	if err != nil {
+		return err
+	}
+	if i < 0 {
+		return mgo.ErrNotFound
+	}
+	c.docs = append(c.docs[:i], c.docs[i+1:]...)
+	return nil
+}
+
+// RemoveAll remove all the documents matched by the selector
+func (c *memCollection) RemoveAll(selector interface{}) (*mgo.ChangeInfo, error) {
+	filter, err := toFilter(selector)
+	if err != nil {
+		return nil, err
+	}
+	c.observe(filter, nil)
+	var kept []bson.M
+	removed := 0
+	for _, doc := range c.docs {
+		ok, err := matchDoc(doc, filter)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			removed++
+		} else {
+			kept = append(kept, doc)
+		}
+	}
+	c.docs = kept
+	return &mgo.ChangeInfo{Removed: removed, Matched: removed}, nil
+}
+
+// DropCollection remove all the documents and the indexes
+func (c *memCollection) DropCollection() error {
+	c.docs = nil
+	c.indexes = nil
+	return nil
+}
+
+// EnsureIndex record the index if no index with the same key exists, the documents are always scanned
+func (c *memCollection) EnsureIndex(index mgo.Index) error {
+	for _, existing := range c.indexes {
+		if indexKey(existing) == indexKey(index) {
+			return nil
+		}
+	}
+	c.indexes = append(c.indexes, index)
+	return nil
+}
+
+// Indexes return the default _id index and the recorded indexes
+func (c *memCollection) Indexes() ([]mgo.Index, error) {
+	return append([]mgo.Index{{Name: "_id_", Key: []string{"_id"}}}, c.indexes...), nil
+}
+
+// observe report the shapes of a query to the observer of the database
+func (c *memCollection) observe(filter bson.M, sort []string) {
+	if c.db == nil || c.db.observer == nil {
+		return
+	}
+	for _, shape := range newQueryShapes(c.name, filter, sort) {
+		c.db.observer(shape)
+	}
+}
+
+func isOperatorUpdate(update interface{}) bool {
+	m, ok := update.(bson.M)
+	if !ok {
+		return false
+	}
+	for k := range m {
+		if strings.HasPrefix(k, "$") {
+			return true
+		}
+	}
+	return false
+}
+
+// applyUpdate return the document updated by the $set, $inc, $max, $min, $unset and $rename operators,
+// or the replacement document which keeps the _id
+func applyUpdate(doc bson.M, update interface{}) (bson.M, error) {
+	if !isOperatorUpdate(update) {
+		replacement, err := toDoc(update)
+		if err != nil {
+			return nil, err
+		}
+		if id, ok := doc["_id"]; ok {
+			replacement["_id"] = id
+		}
+		return replacement, nil
+	}
+
+	for op, fields := range update.(bson.M) {
+		values, err := toDoc(fields)
+		if err != nil {
+			return nil, err
+		}
+		switch op {
+		case "$set":
+			for k, v := range values {
+				doc[k] = v
+			}
+		case "$inc":
+			for k, v := range values {
+				doc[k] = addValues(doc[k], v)
+			}
+		case "$max":
+			for k, v := range values {
+				if current, ok := doc[k]; !ok || compareValues(v, current) > 0 {
+					doc[k] = v
+				}
+			}
+		case "$min":
+			for k, v := range values {
+				if current, ok := doc[k]; !ok || compareValues(v, current) < 0 {
+					doc[k] = v
+				}
+			}
+		case "$unset":
+			for k := range values {
+				delete(doc, k)
+			}
+		case "$rename":
+			for k, v := range values {
+				if value, ok := doc[k]; ok {
+					delete(doc, k)
+					doc[v.(string)] = value
+				}
+			}
+		default:
+			return nil, errUnsupportedOperator
+		}
+	}
+	return doc, nil
+}
+
+// toDocList convert the conditions of $or and $and into bson documents
+func toDocList(v interface{}) ([]bson.M, error) {
+	if docs, ok := v.([]bson.M); ok {
+		return docs, nil
+	}
+	listv := reflect.ValueOf(v)
+	if listv.Kind() != reflect.Slice {
+		return nil, errUnsupportedOperator
+	}
+	var docs []bson.M
+	for i := 0; i < listv.Len(); i++ {
+		doc, err := toFilter(listv.Index(i).Interface())
+		if err != nil {
+			return nil, err
+		}
+		docs = append(docs, doc)
+	}
+	return docs, nil
+}
+
+// lookup return the value of the field, the dot notation is supported for embedded documents
+func lookup(doc bson.M, field string) (interface{}, bool) {
+	var v interface{} = doc
+	for _, key := range strings.Split(field, ".") {
+		m, ok := v.(bson.M)
+		if !ok {
+			return nil, false
+		}
+		if v, ok = m[key]; !ok {
+			return nil, false
+		}
+	}
+	return v, true
+}
+
+// matchDoc check whether the document matches the filter
+func matchDoc(doc bson.M, filter bson.M) (bool, error) {
+	for key, cond := range filter {
+		switch key {
+		case "$or", "$and":
+			conds, err := toDocList(cond)
+			if err != nil {
+				return false, err
+			}
+			matched := key == "$and"
+			for _, sub := range conds {
+				ok, err := matchDoc(doc, sub)
+				if err != nil {
+					return false, err
+				}
+				if key == "$or" && ok {
+					matched = true
+					break
+				}
+				if key == "$and" && !ok {
+					matched = false
+					break
+				}
+			}
+			if !matched {
+				return false, nil
+			}
+			continue
+		}
+
+		v, exists := lookup(doc, key)
+		ok, err := matchValue(v, exists, cond)
+		if err != nil || !ok {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// matchValue check whether the field value matches the condition
+func matchValue(v interface{}, exists bool, cond interface{}) (bool, error) {
+	ops, ok := cond.(bson.M)
+	if !ok || !isOperatorUpdate(ops) {
+		return equalValues(v, cond), nil
+	}
+
+	for op, arg := range ops {
+		var ok bool
+		switch op {
+		case "$gt":
+			ok = sameBracket(v, arg) && compareValues(v, arg) > 0
+		case "$gte":
+			ok = sameBracket(v, arg) && compareValues(v, arg) >= 0
+		case "$lt":
+			ok = sameBracket(v, arg) && compareValues(v, arg) < 0
+		case "$lte":
+			ok = sameBracket(v, arg) && compareValues(v, arg) <= 0
+		case "$ne":
+			ok = !equalValues(v, arg)
+		case "$exists":
+			want, _ := arg.(bool)
+			ok = exists == want
+		case "$in":
+			ok = inValues(v, arg)
+		case "$nin":
+			ok = !inValues(v, arg)
+		default:
+			return false, errUnsupportedOperator
+		}
+		if !ok {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+func inValues(v interface{}, arg interface{}) bool {
+	argv := reflect.ValueOf(arg)
+	if argv.Kind() != reflect.Slice {
+		return false
+	}
+	for i := 0; i < argv.Len(); i++ {
+		if equalValues(v, argv.Index(i).Interface()) {
+			return true
+		}
+	}
+	return false
+}
+
+// equalValues compare the values like mongo, an array field matches if any element is equal
+func equalValues(v interface{}, cond interface{}) bool {
+	if arr, ok := v.([]interface{}); ok {
+		for _, e := range arr {
+			if equalValues(e, cond) {
+				return true
+			}
+		}
+		return false
+	}
+	if v == nil || cond == nil {
+		return v == nil && cond == nil
+	}
+	return sameBracket(v, cond) && compareValues(v, cond) == 0
+}
+
+func toInt64(v interface{}) (int64, bool) {
+	switch n := v.(type) {
+	case int:
+		return int64(n), true
+	case int8:
+		return int64(n), true
+	case int16:
+		return int64(n), true
+	case int32:
+		return int64(n), true
+	case int64:
+		return n, true
+	case uint:
+		return int64(n), true
+	case uint8:
+		return int64(n), true
+	case uint16:
+		return int64(n), true
+	case uint32:
+		return int64(n), true
+	case uint64:
+		return int64(n), true
+	}
+	return 0, false
+}
+
+func toFloat64(v interface{}) (float64, bool) {
+	if n, ok := toInt64(v); ok {
+		return float64(n), true
+	}
+	switch n := v.(type) {
+	case float32:
+		return float64(n), true
+	case float64:
+		return n, true
+	}
+	return 0, false
+}
+
+// sameBracket check whether the values are of the same type bracket, mongo never matches
+// a range condition across different types
+func sameBracket(a, b interface{}) bool {
+	if _, ok := toFloat64(a); ok {
+		_, ok = toFloat64(b)
+		return ok
+	}
+	if _, ok := a.(string); ok {
+		_, ok = b.(string)
+		return ok
+	}
+	if _, ok := a.(bool); ok {
+		_, ok = b.(bool)
+		return ok
+	}
+	if _, ok := a.(bson.ObjectId); ok {
+		_, ok = b.(bson.ObjectId)
+		return ok
+	}
+	return false
+}
+
+// typeOrder return the sort order of the type bracket like mongo
+func typeOrder(v interface{}) int {
+	if v == nil {
+		return 0
+	}
+	if _, ok := toFloat64(v); ok {
+		return 1
+	}
+	switch v.(type) {
+	case string:
+		return 2
+	case bson.M:
+		return 3
+	case []interface{}:
+		return 4
+	case bson.ObjectId:
+		return 5
+	case bool:
+		return 6
+	}
+	return 7
+}
+
+// compareValues return -1, 0 or 1, values of different types are ordered by the type bracket
+func compareValues(a, b interface{}) int {
+	ta, tb := typeOrder(a), typeOrder(b)
+	if ta != tb {
+		if ta < tb {
+			return -1
+		}
+		return 1
+	}
+
+	switch ta {
+	case 1:
+		ia, aInt := toInt64(a)
+		ib, bInt := toInt64(b)
+		if aInt && bInt {
+			switch {
+			case ia < ib:
+				return -1
+			case ia > ib:
+				return 1
+			}
+			return 0
+		}
+		fa, _ := toFloat64(a)
+		fb, _ := toFloat64(b)
+		switch {
+		case fa < fb:
+			return -1
+		case fa > fb:
+			return 1
+		}
+	case 2:
+		return strings.Compare(a.(string), b.(string))
+	case 5:
+		return strings.Compare(string(a.(bson.ObjectId)), string(b.(bson.ObjectId)))
+	case 6:
+		ba, bb := a.(bool), b.(bool)
+		if ba != bb {
+			if bb {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
+
+// addValues add the increment to the value, integers are kept as int64
+func addValues(v interface{}, inc interface{}) interface{} {
+	if v == nil {
+		v = 0
+	}
+	ia, aInt := toInt64(v)
+	ib, bInt := toInt64(inc)
+	if aInt && bInt {
+		return ia + ib
+	}
+	fa, _ := toFloat64(v)
+	fb, _ := toFloat64(inc)
+	return fa + fb
+}
+
+// All run the aggregation and decode the result documents into result which is a pointer to a slice,
+// the $match, $group, $sort, $skip, $limit and $project stages are supported
+func (p *memPipe) All(result interface{}) error {
+	docs := p.c.docs
+	// only the leading $match stage is able to use an index
+	if len(p.pipeline) > 0 {
+		if filter, ok := p.pipeline[0]["$match"].(bson.M); ok {
+			p.c.observe(filter, nil)
+		}
+	}
+	for _, stage := range p.pipeline {
+		for op, arg := range stage {
+			var err error
+			switch op {
+			case "$match":
+				docs, err = matchStage(docs, arg)
+			case "$group":
+				docs, err = groupStage(docs, arg)
+			case "$sort":
+				docs, err = sortStage(docs, arg)
+			case "$skip":
+				n, _ := toInt64(arg)
+				docs = skipAndLimit(docs, int(n), 0)
+			case "$limit":
+				n, _ := toInt64(arg)
+				docs = skipAndLimit(docs, 0, int(n))
+			case "$project":
+				docs, err = projectStage(docs, arg)
+			default:
+				err = errUnsupportedOperator
+			}
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return fromDocs(docs, result)
+}
+
+func matchStage(docs []bson.M, arg interface{}) ([]bson.M, error) {
+	filter, err := toFilter(arg)
+	if err != nil {
+		return nil, err
+	}
+	var matched []bson.M
+	for _, doc := range docs {
+		ok, err := matchDoc(doc, filter)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			matched = append(matched, doc)
+		}
+	}
+	return matched, nil
+}
+
+// evalExpr return the value of the expression, a string prefixed with "$" is a field path
+func evalExpr(doc bson.M, expr interface{}) (interface{}, bool) {
+	if path, ok := expr.(string); ok && strings.HasPrefix(path, "$") {
+		return lookup(doc, path[1:])
+	}
+	return expr, true
+}
+
+// groupStage group the documents by _id in the order of first seen, $sum and $push are supported
+func groupStage(docs []bson.M, arg interface{}) ([]bson.M, error) {
+	spec, ok := arg.(bson.M)
+	if !ok {
+		return nil, errUnsupportedOperator
+	}
+
+	var groups []bson.M
+	for _, doc := range docs {
+		id, _ := evalExpr(doc, spec["_id"])
+		var group bson.M
+		for _, g := range groups {
+			if equalValues(g["_id"], id) {
+				group = g
+				break
+			}
+		}
+		if group == nil {
+			group = bson.M{"_id": id}
+			groups = append(groups, group)
+		}
+
+		for field, acc := range spec {
+			if field == "_id" {
+				continue
+			}
+			accs, ok := acc.(bson.M)
+			if !ok {
+				return nil, errUnsupportedOperator
+			}
+			for op, expr := range accs {
+				v, exists := evalExpr(doc, expr)
+				switch op {
+				case "$sum":
+					if _, ok := group[field]; !ok {
+						group[field] = 0
+					}
+					if _, ok := toFloat64(v); ok && exists {
+						group[field] = addValues(group[field], v)
+					}
+				case "$push":
+					list, _ := group[field].([]interface{})
+					if exists {
+						list = append(list, v)
+					}
+					group[field] = list
+				default:
+					return nil, errUnsupportedOperator
+				}
+			}
+		}
+	}
+	return groups, nil
+}
+
+func sortStage(docs []bson.M, arg interface{}) ([]bson.M, error) {
+	var fields []string
+	switch spec := arg.(type) {
+	case bson.D:
+		for _, e := range spec {
+			fields = append(fields, sortField(e.Name, e.Value))
+		}
+	case bson.M:
+		for name, order := range spec {
+			fields = append(fields, sortField(name, order))
+		}
+		sort.Strings(fields)
+	default:
+		return nil, errUnsupportedOperator
+	}
+
+	sorted := append([]bson.M(nil), docs...)
+	sortDocs(sorted, fields)
+	return sorted, nil
+}
+
+func sortField(name string, order interface{}) string {
+	if n, _ := toInt64(order); n < 0 {
+		return "-" + name
+	}
+	return name
+}
+
+// projectStage keep the included fields and the computed $slice fields, _id is kept unless excluded
+func projectStage(docs []bson.M, arg interface{}) ([]bson.M, error) {
+	spec, ok := arg.(bson.M)
+	if !ok {
+		return nil, errUnsupportedOperator
+	}
+
+	var projected []bson.M
+	for _, doc := range docs {
+		out := bson.M{}
+		if id, ok := doc["_id"]; ok {
+			out["_id"] = id
+		}
+		for field, v := range spec {
+			if expr, ok := v.(bson.M); ok {
+				args, _ := expr["$slice"].([]interface{})
+				if len(args) != 2 {
+					return nil, errUnsupportedOperator
+				}
+				list, _ := evalExpr(doc, args[0])
+				n, _ := toInt64(args[1])
+				items, _ := list.([]interface{})
+				if int64(len(items)) > n {
+					items = items[:n]
+				}
+				out[field] = items
+				continue
+			}
+
+			if include, _ := toInt64(v); include == 0 && v != true {
+				delete(out, field)
+			} else if value, ok := doc[field]; ok {
+				out[field] = value
+			}
+		}
+		projected = append(projected, out)
+	}
+	return projected, nil
+}
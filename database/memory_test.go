@@ -0,0 +1,194 @@
+/**
+*  @file
+*  @copyright defined in scan-api/LICENSE
+ */
+
+package database
+
+import (
+	"testing"
+
+	"github.com/seeleteam/scan-api/common"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/mgo.v2"
+)
+
+func newTestMemoryBlocks(t *testing.T, c *Client, shardNumber int, count int) {
+	for i := 0; i < count; i++ {
+		block := &DBBlock{
+			HeadHash:    "0x0" + string(rune('a'+i)),
+			Height:      int64(i),
+			Timestamp:   int64(1539931510 + i*10),
+			ShardNumber: shardNumber,
+			Creator:     "0xd5a145191b7ca9cb4f3dc850e426c1e853d2a9f1",
+		}
+		assert.Nil(t, c.AddBlock(block))
+	}
+}
+
+func TestMemoryClient_Blocks(t *testing.T) {
+	c := NewMemoryClient(1)
+	newTestMemoryBlocks(t, c, 1, 5)
+	newTestMemoryBlocks(t, c, 2, 3)
+
+	height, err := c.GetBlockHeight(1)
+	assert.Nil(t, err)
+	assert.Equal(t, height, uint64(5))
+
+	blocks, err := c.GetBlocksByHeight(1, 1, 4)
+	assert.Nil(t, err)
+	assert.Equal(t, len(blocks), 3)
+	assert.Equal(t, blocks[0].Height, int64(3))
+	assert.Equal(t, blocks[2].Height, int64(1))
+
+	block, err := c.GetBlockByHeight(2, 2)
+	assert.Nil(t, err)
+	assert.Equal(t, block.ShardNumber, 2)
+	assert.Equal(t, block.Timestamp, int64(1539931530))
+
+	_, err = c.GetBlockByHeight(2, 3)
+	assert.Equal(t, err, mgo.ErrNotFound)
+
+	blocks, err = c.GetBlocksByTime(1, 1539931520, 1539931540)
+	assert.Nil(t, err)
+	assert.Equal(t, len(blocks), 3)
+
+	assert.Nil(t, c.RemoveBlock(1, 4))
+	height, _ = c.GetBlockHeight(1)
+	assert.Equal(t, height, uint64(4))
+
+	blockCnt, _ := c.GetBlockCnt()
+	assert.Equal(t, blockCnt, uint64(7))
+}
+
+func TestMemoryClient_TxsPaging(t *testing.T) {
+	c := NewMemoryClient(1)
+	address := "0xa00d22dc3624d4696eff8d1641b442f79c3379b1"
+	for i := 0; i < 6; i++ {
+		tx := &DBTx{
+			Hash:        "0x" + string(rune('a'+i)),
+			From:        address,
+			To:          "0xec759db47a65f6537d630517f6cd3ca39c6f93d1",
+			Block:       uint64(i / 2),
+			Idx:         int64(i),
+			ShardNumber: 1,
+		}
+		if i%3 == 0 {
+			tx.From, tx.To = tx.To, address
+		}
+		assert.Nil(t, c.AddTx(tx))
+	}
+
+	txs, err := c.GetTxsByAddresses(address, false, 2, 1)
+	assert.Nil(t, err)
+	assert.Equal(t, len(txs), 2)
+	assert.Equal(t, txs[0].Idx, int64(4))
+	assert.Equal(t, txs[1].Idx, int64(3))
+
+	txs, err = c.GetTxsByAddresses(address, true, 0, 4)
+	assert.Nil(t, err)
+	assert.Equal(t, len(txs), 2)
+	assert.Equal(t, txs[0].Idx, int64(4))
+
+	txCnt, err := c.GetTxCntByShardNumber(1)
+	assert.Nil(t, err)
+	assert.Equal(t, txCnt, uint64(6))
+
+	assert.Nil(t, c.RemoveTxs(1, 0))
+	txCnt, _ = c.GetTxCntByShardNumber(1)
+	assert.Equal(t, txCnt, uint64(4))
+
+	_, err = c.GetTxByHash("0xa")
+	assert.Equal(t, err, mgo.ErrNotFound)
+	tx, err := c.GetTxByHash("0xc")
+	assert.Nil(t, err)
+	assert.Equal(t, tx.Block, uint64(1))
+}
+
+func TestMemoryClient_Accounts(t *testing.T) {
+	c := NewMemoryClient(1)
+	accounts := []*DBAccount{
+		{Address: "0x01", ShardNumber: 1, Balance: 300, AccType: 0},
+		{Address: "0x02", ShardNumber: 1, Balance: 100, AccType: 0},
+		{Address: "0x03", ShardNumber: 2, Balance: 200, AccType: 0},
+		{Address: "0x04", ShardNumber: 1, AccType: 1, Creator: "0x01", CreationBlock: 7, CodeHash: "0xaa"},
+		{Address: "0x05", ShardNumber: 2, AccType: 1, Creator: "0x01", CreationBlock: 9, CodeHash: "0xaa"},
+		{Address: "0x06", ShardNumber: 2, AccType: 1, Creator: "0x03", CreationBlock: 3, CodeHash: "0xbb"},
+	}
+	for _, account := range accounts {
+		assert.Nil(t, c.UpdateAccount(account))
+	}
+
+	// verified source code is kept by the following updates
+	assert.Nil(t, c.UpdateContract("0x04", "contract A {}", "[]"))
+	assert.Nil(t, c.UpdateAccount(&DBAccount{Address: "0x04", ShardNumber: 1, AccType: 1, TxCount: 2}))
+	contract, err := c.GetAccountByAddress("0x04")
+	assert.Nil(t, err)
+	assert.Equal(t, contract.SourceCode, "contract A {}")
+	assert.Equal(t, contract.TxCount, int64(2))
+	assert.Equal(t, contract.Creator, "0x01")
+
+	top, err := c.GetAccountsByShardNumber(1, 1)
+	assert.Nil(t, err)
+	assert.Equal(t, len(top), 1)
+	assert.Equal(t, top[0].Address, "0x01")
+
+	contracts, err := c.GetContractsByCreator("0x01", 0, 10)
+	assert.Nil(t, err)
+	assert.Equal(t, len(contracts), 2)
+	assert.Equal(t, contracts[0].Address, "0x05")
+
+	groups, err := c.GetCodeHashGroups(2, 1, 0, 10)
+	assert.Nil(t, err)
+	assert.Equal(t, len(groups), 1)
+	assert.Equal(t, groups[0].CodeHash, "0xaa")
+	assert.Equal(t, groups[0].Count, int64(2))
+	assert.Equal(t, groups[0].Addresses, []string{"0x04"})
+	groups, err = c.GetCodeHashGroups(2, 1, 1, 10)
+	assert.Nil(t, err)
+	assert.Empty(t, groups)
+
+	balances, err := c.GetTotalBalance()
+	assert.Nil(t, err)
+	assert.Equal(t, balances, map[int]int64{1: 400, 2: 200})
+}
+
+func TestMemoryClient_MinerAndSupply(t *testing.T) {
+	c := NewMemoryClient(1)
+	miner := &DBMiner{Address: "0x01", ShardNumber: 1, Mined: 1, Reward: 100, TxFee: 3, Revenue: 103, TimeStamp: 10}
+	assert.Nil(t, c.IncMinerAccount(miner))
+	assert.Nil(t, c.IncMinerAccount(miner))
+	assert.Nil(t, c.IncMinerAccount(&DBMiner{Address: "0x01", ShardNumber: 1, Mined: -1, Reward: -100, TxFee: -3, Revenue: -103}))
+
+	got, err := c.GetMinerAccountByAddress("0x01")
+	assert.Nil(t, err)
+	assert.Equal(t, got.Mined, int64(1))
+	assert.Equal(t, got.Revenue, int64(103))
+	assert.Equal(t, got.TimeStamp, int64(10))
+
+	assert.Nil(t, c.SetGenesisSupply(1, 1000))
+	assert.Nil(t, c.IncSupply(&DBOneDaySupply{ShardNumber: 1, TimeStamp: 86400, Minted: 100, Fees: 3, Blocks: 1}))
+	supplies, err := c.GetSupply()
+	assert.Nil(t, err)
+	assert.Equal(t, len(supplies), 1)
+	assert.Equal(t, supplies[0].Total(), int64(1100))
+
+	_, err = c.GetOneDaySupply(1, 0)
+	assert.Equal(t, err, mgo.ErrNotFound)
+}
+
+func TestNewDBClient_MemoryMode(t *testing.T) {
+	c := NewDBClient(&common.DataBaseConfig{DataBaseMode: MemoryMode}, 1)
+	assert.NotNil(t, c)
+	assert.Equal(t, c.LiveServers(), []string{MemoryMode})
+	c.SetPrimaryMode()
+
+	assert.Nil(t, c.AddNodeInfo(&DBNodeInfo{ID: "node1", Host: "127.0.0.1", Port: "8057", ShardNumber: 1}))
+	assert.Nil(t, c.AddNodeInfo(&DBNodeInfo{ID: "node1", Host: "127.0.0.1", Port: "8057", ShardNumber: 1, City: "Beijing"}))
+	nodeCnt, err := c.GetNodeCntByShardNumber(1)
+	assert.Nil(t, err)
+	assert.Equal(t, nodeCnt, uint64(1))
+	node, err := c.GetNodeInfoByID("node1")
+	assert.Nil(t, err)
+	assert.Equal(t, node.City, "Beijing")
+}
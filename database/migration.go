@@ -0,0 +1,282 @@
+/**
+*  @file
+*  @copyright defined in scan-api/LICENSE
+ */
+
+package database
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/seeleteam/scan-api/log"
+	"gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+)
+
+const (
+	schemaID = "schema"
+
+	defaultMigrationBatchSize = 1000
+)
+
+var (
+	errUnknownSchemaVersion = errors.New("unknown schema version")
+)
+
+// Migration moves the schema from the previous version to Version with Up, and back with Down.
+// The steps are able to run again after they are interrupted.
+type Migration struct {
+	Version     int
+	Description string
+	Up          func(r *MigrationRunner) error
+	Down        func(r *MigrationRunner) error
+}
+
+// MigrationNode is the node of a shard read by the migrations which backfill the data of the chain
+type MigrationNode interface {
+	GetCode(contract string, height int64) (string, error)
+}
+
+// MigrationRunner apply the steps of a migration in batches and records the progress,
+// an interrupted migration resumes from the last finished batch
+type MigrationRunner struct {
+	c         *Client
+	schema    *DBSchema
+	batchSize int
+	nodes     map[int]MigrationNode
+}
+
+// codeHash return the code hash of the contract created by the transaction, it is read from the node of its shard
+func (r *MigrationRunner) codeHash(tx *DBTx) (string, error) {
+	node := r.nodes[tx.ShardNumber]
+	if node == nil {
+		return "", fmt.Errorf("no node of shard %d in RPCNodes of the config", tx.ShardNumber)
+	}
+	code, err := node.GetCode(tx.ContractAddress, int64(tx.Block))
+	if err != nil {
+		return "", err
+	}
+	return CodeHash(code), nil
+}
+
+// SchemaVersion is the schema version required by the services of this build
+func SchemaVersion() int {
+	return migrations[len(migrations)-1].Version
+}
+
+// Migrations return the ordered migrations of the schema
+func Migrations() []*Migration {
+	return migrations
+}
+
+// GetSchema get the schema version of the database, a database which has no schema version
+// is of version 0, an empty database is of the current version
+func (c *Client) GetSchema() (*DBSchema, error) {
+	schema, _, err := c.getSchema()
+	return schema, err
+}
+
+// getSchema get the schema version of the database and whether it is stored
+func (c *Client) getSchema() (*DBSchema, bool, error) {
+	schema := new(DBSchema)
+	query := func(c collection) error {
+		return c.Find(bson.M{"_id": schemaID}).One(schema)
+	}
+	err := c.withCollection(schemaTbl, query)
+	if err == nil {
+		if schema.Cursors == nil {
+			schema.Cursors = make(map[string]interface{})
+		}
+		return schema, true, nil
+	}
+	if err != mgo.ErrNotFound {
+		return nil, false, err
+	}
+
+	// the block counter is missing before the counters are built
+	var blockCnt int
+	query = func(c collection) error {
+		var err error
+		blockCnt, err = c.Count()
+		return err
+	}
+	if err := c.withCollection(blockTbl, query); err != nil {
+		return nil, false, err
+	}
+	version := 0
+	if blockCnt == 0 {
+		version = SchemaVersion()
+	}
+	return &DBSchema{ID: schemaID, Version: version, Target: version, Cursors: make(map[string]interface{})}, false, nil
+}
+
+// saveSchema store the schema version and the migration progress
+func (c *Client) saveSchema(schema *DBSchema) error {
+	query := func(c collection) error {
+		_, err := c.Upsert(bson.M{"_id": schemaID}, schema)
+		return err
+	}
+	return c.withCollection(schemaTbl, query)
+}
+
+// CheckSchemaVersion check whether the database is of the schema version required by this build,
+// the version of an empty database is initialized, nothing is written otherwise
+func (c *Client) CheckSchemaVersion() error {
+	schema, stored, err := c.getSchema()
+	if err != nil {
+		return err
+	}
+	if schema.Target != schema.Version {
+		return fmt.Errorf("the migration of the database schema from version %d to %d is not finished, run scan migrate up", schema.Version, schema.Target)
+	}
+	if schema.Version != SchemaVersion() {
+		return fmt.Errorf("the database schema version %d is incompatible with the required version %d, run scan migrate", schema.Version, SchemaVersion())
+	}
+	if stored {
+		return nil
+	}
+	return c.saveSchema(schema)
+}
+
+// Migrate run the migrations up or down one by one until the schema is of the target version,
+// an interrupted migration is resumed. batchSize is the number of documents updated in a batch,
+// nodes are the nodes of the shards read by the migrations which backfill the data of the chain.
+func (c *Client) Migrate(target int, batchSize int, nodes map[int]MigrationNode) error {
+	if target < 0 || target > SchemaVersion() {
+		return errUnknownSchemaVersion
+	}
+	if batchSize <= 0 {
+		batchSize = defaultMigrationBatchSize
+	}
+
+	schema, err := c.GetSchema()
+	if err != nil {
+		return err
+	}
+	if schema.Version < 0 || schema.Version > SchemaVersion() {
+		return errUnknownSchemaVersion
+	}
+
+	runner := &MigrationRunner{c: c, schema: schema, batchSize: batchSize, nodes: nodes}
+	for schema.Version != target {
+		var next int
+		var step func(r *MigrationRunner) error
+		if target > schema.Version {
+			next = schema.Version + 1
+			step = migrations[schema.Version].Up
+		} else {
+			next = schema.Version - 1
+			step = migrations[schema.Version-1].Down
+		}
+
+		// the progress of an interrupted migration to another version is useless
+		if schema.Target != next {
+			schema.Target = next
+			schema.Cursors = make(map[string]interface{})
+			if err := c.saveSchema(schema); err != nil {
+				return err
+			}
+		}
+
+		log.Info("[DB] migrate schema from version %d to %d", schema.Version, next)
+		if err := step(runner); err != nil {
+			return err
+		}
+
+		schema.Version = next
+		schema.Cursors = make(map[string]interface{})
+		if err := c.saveSchema(schema); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Batch apply update to the documents of the collection matched by the filter in batches ordered by _id,
+// update return the update of a document, or nil to keep it. The _id of the last updated document is
+// recorded as the cursor of the name after every batch, a resumed migration continues from the cursor.
+func (r *MigrationRunner) Batch(name string, tbl string, filter bson.M, update func(doc bson.M) (interface{}, error)) error {
+	for {
+		selector := bson.M{}
+		for k, v := range filter {
+			selector[k] = v
+		}
+		if cursor, ok := r.schema.Cursors[name]; ok {
+			selector["_id"] = bson.M{"$gt": cursor}
+		}
+
+		var docs []bson.M
+		query := func(c collection) error {
+			if err := c.Find(selector).Sort("_id").Limit(r.batchSize).All(&docs); err != nil {
+				return err
+			}
+			for _, doc := range docs {
+				u, err := update(doc)
+				if err != nil {
+					return err
+				}
+				if u == nil {
+					continue
+				}
+				if err := c.Update(bson.M{"_id": doc["_id"]}, u); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+		if err := r.c.withCollection(tbl, query); err != nil {
+			return err
+		}
+		if len(docs) == 0 {
+			return nil
+		}
+
+		r.schema.Cursors[name] = docs[len(docs)-1]["_id"]
+		if err := r.c.saveSchema(r.schema); err != nil {
+			return err
+		}
+		log.Debug("[DB] migrate %s: %d documents of %s", name, len(docs), tbl)
+		if len(docs) < r.batchSize {
+			return nil
+		}
+	}
+}
+
+// Scan call fn with the documents of the collection matched by the filter in batches ordered by _id,
+// the cursor is recorded like Batch. fn is called outside of the query so that it is able to access
+// the database, it must be idempotent since the batch interrupted by a failure is scanned again.
+func (r *MigrationRunner) Scan(name string, tbl string, filter bson.M, fn func(docs []bson.M) error) error {
+	for {
+		selector := bson.M{}
+		for k, v := range filter {
+			selector[k] = v
+		}
+		if cursor, ok := r.schema.Cursors[name]; ok {
+			selector["_id"] = bson.M{"$gt": cursor}
+		}
+
+		var docs []bson.M
+		query := func(c collection) error {
+			return c.Find(selector).Sort("_id").Limit(r.batchSize).All(&docs)
+		}
+		if err := r.c.withCollection(tbl, query); err != nil {
+			return err
+		}
+		if len(docs) == 0 {
+			return nil
+		}
+		if err := fn(docs); err != nil {
+			return err
+		}
+
+		r.schema.Cursors[name] = docs[len(docs)-1]["_id"]
+		if err := r.c.saveSchema(r.schema); err != nil {
+			return err
+		}
+		log.Debug("[DB] migrate %s: %d documents of %s", name, len(docs), tbl)
+		if len(docs) < r.batchSize {
+			return nil
+		}
+	}
+}
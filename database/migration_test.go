@@ -0,0 +1,253 @@
+/**
+*  @file
+*  @copyright defined in scan-api/LICENSE
+ */
+
+package database
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/seeleteam/scan-api/log"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// newOldSchemaClient return a memory client with the documents stored before the schema version
+func newOldSchemaClient(t *testing.T) *Client {
+	log.NewLogger("", "error", false)
+	c := NewMemoryClient(1)
+	insert := func(tbl string, docs ...interface{}) {
+		err := c.withCollection(tbl, func(c collection) error {
+			return c.Insert(docs...)
+		})
+		assert.Nil(t, err)
+	}
+	insert(accTbl,
+		bson.M{"address": "0x01", "accType": 1, "abiJSON": "[]"},
+		bson.M{"address": "0x02", "accType": 1, "abiJSON": "[old]", "abi": "[new]"},
+		bson.M{"address": "0x03", "accType": 0})
+	insert(blockTbl, bson.M{
+		"height":       int64(1),
+		"shardNumber":  1,
+		"creator":      "0x09",
+		"reward":       int64(100),
+		"transactions": []bson.M{{"to": "0x01", "fee": int64(30)}, {"to": "0x02", "fee": int64(30)}},
+		"txDebt":       []bson.M{{"account": "0x02"}},
+		"debt":         []bson.M{{"fee": int64(3)}},
+	})
+	insert(txTbl,
+		bson.M{"hash": "0x0a", "from": "0x03", "to": "0x04", "block": int64(1), "shardNumber": 1, "timestamp": "1539931510"},
+		bson.M{"hash": "0x0b", "timestamp": "1539931520"})
+	// the fee of the miner included the debt fee
+	insert(minerTbl, bson.M{"address": "0x09", "shardNumber": 1, "mined": int64(1), "reward": int64(100), "fee": int64(42), "total": int64(142)})
+	insert(debtTbl, bson.M{"hash": "0x0d", "txhash": "0x0a", "to": "0x03", "height": int64(1), "shardNumber": 1})
+	insert(pendingTxTbl, bson.M{"hash": "0x0c", "timestamp": "1539931530"})
+	return c
+}
+
+func Test_Migrate(t *testing.T) {
+	c := newOldSchemaClient(t)
+	schema, err := c.GetSchema()
+	assert.Nil(t, err)
+	assert.Equal(t, schema.Version, 0)
+	assert.NotNil(t, c.CheckSchemaVersion())
+
+	assert.Nil(t, c.Migrate(SchemaVersion(), 1, testMigrationNodes()))
+	assert.Nil(t, c.CheckSchemaVersion())
+
+	account, err := c.GetAccountByAddress("0x01")
+	assert.Nil(t, err)
+	assert.Equal(t, account.ABI, "[]")
+	account, err = c.GetAccountByAddress("0x02")
+	assert.Nil(t, err)
+	assert.Equal(t, account.ABI, "[new]")
+
+	block, err := c.GetBlockByHeight(1, 1)
+	assert.Nil(t, err)
+	assert.Equal(t, block.TxFee, int64(40))
+	assert.Equal(t, block.DebtFee, int64(2))
+	miner, err := c.GetMinerAccountByAddress("0x09")
+	assert.Nil(t, err)
+	assert.Equal(t, miner.TxFee, int64(40))
+	assert.Equal(t, miner.DebtFee, int64(2))
+	assert.Equal(t, miner.Revenue, int64(142))
+
+	tx, err := c.GetTxByHash("0x0b")
+	assert.Nil(t, err)
+	assert.Equal(t, tx.Timestamp, int64(1539931520))
+	pendingTx, err := c.GetPendingTxByHash("0x0c")
+	assert.Nil(t, err)
+	assert.Equal(t, pendingTx.Timestamp, int64(1539931530))
+
+	contractCnt, err := c.GetContractCnt()
+	assert.Nil(t, err)
+	assert.Equal(t, contractCnt, uint64(2))
+	blockCnt, err := c.GetBlockCnt()
+	assert.Nil(t, err)
+	assert.Equal(t, blockCnt, uint64(1))
+
+	activities, err := c.GetAddressActivities("0x03", "", 0, 0)
+	assert.Nil(t, err)
+	assert.Equal(t, len(activities), 2)
+	assert.Equal(t, activities[0].Kind, ActivitySent)
+	assert.Equal(t, activities[0].Timestamp, int64(1539931510))
+	assert.Equal(t, activities[1].Kind, ActivityDebtIn)
+	assert.Equal(t, activities[1].Counterparty, "0x03")
+	activityCnt, err := c.GetAddressActivityCnt("0x04", ActivityIn)
+	assert.Nil(t, err)
+	assert.Equal(t, activityCnt, int64(1))
+
+	assert.Nil(t, c.Migrate(0, 0, nil))
+	schema, err = c.GetSchema()
+	assert.Nil(t, err)
+	assert.Equal(t, schema.Version, 0)
+	assert.Equal(t, schema.Target, 0)
+	activities, err = c.GetAddressActivities("0x03", "", 0, 0)
+	assert.Nil(t, err)
+	assert.Empty(t, activities)
+	err = c.withCollection(txTbl, func(c collection) error {
+		var doc bson.M
+		err := c.Find(bson.M{"hash": "0x0a"}).One(&doc)
+		assert.Equal(t, doc["timestamp"], "1539931510")
+		return err
+	})
+	assert.Nil(t, err)
+	err = c.withCollection(accTbl, func(c collection) error {
+		cnt, err := c.Find(bson.M{"abiJSON": bson.M{"$exists": true}}).Count()
+		assert.Equal(t, cnt, 2)
+		return err
+	})
+	assert.Nil(t, err)
+
+	assert.Equal(t, c.Migrate(SchemaVersion()+1, 0, nil), errUnknownSchemaVersion)
+}
+
+// fakeMigrationNode return the code of the contracts
+type fakeMigrationNode map[string]string
+
+func (n fakeMigrationNode) GetCode(contract string, height int64) (string, error) {
+	return n[contract], nil
+}
+
+// testMigrationNodes return the node of the shards of the old schema client
+func testMigrationNodes() map[int]MigrationNode {
+	node := fakeMigrationNode{"0x05": "0x6080"}
+	return map[int]MigrationNode{0: node, 1: node}
+}
+
+func Test_MigrateContractCreation(t *testing.T) {
+	c := newOldSchemaClient(t)
+	err := c.withCollection(accTbl, func(c collection) error {
+		return c.Insert(bson.M{"address": "0x05", "accType": 1})
+	})
+	assert.Nil(t, err)
+	err = c.withCollection(txTbl, func(c collection) error {
+		return c.Insert(bson.M{"hash": "0x0e", "from": "0x03", "txtype": 1, "contractAddress": "0x05",
+			"block": int64(1), "shardNumber": 1, "timestamp": "1539931540"})
+	})
+	assert.Nil(t, err)
+
+	// the code is read from the node of the shard
+	assert.Nil(t, c.Migrate(SchemaVersion(), 1, testMigrationNodes()))
+
+	account, err := c.GetAccountByAddress("0x05")
+	assert.Nil(t, err)
+	assert.Equal(t, account.Creator, "0x03")
+	assert.Equal(t, account.CreationTx, "0x0e")
+	assert.Equal(t, account.CreationBlock, uint64(1))
+	assert.Equal(t, account.CodeHash, CodeHash("0x6080"))
+	assert.NotEmpty(t, account.CodeHash)
+}
+
+// failedMigrationNode fail to return the code of every contract
+type failedMigrationNode struct{}
+
+func (n failedMigrationNode) GetCode(contract string, height int64) (string, error) {
+	return "", errors.New("node is unavailable")
+}
+
+func Test_MigrateContractCreationWithoutNode(t *testing.T) {
+	for _, nodes := range []map[int]MigrationNode{nil, {1: failedMigrationNode{}}} {
+		c := newOldSchemaClient(t)
+		err := c.withCollection(accTbl, func(c collection) error {
+			return c.Insert(bson.M{"address": "0x05", "accType": 1})
+		})
+		assert.Nil(t, err)
+		err = c.withCollection(txTbl, func(c collection) error {
+			return c.Insert(bson.M{"hash": "0x0e", "from": "0x03", "txtype": 1, "contractAddress": "0x05",
+				"block": int64(1), "shardNumber": 1, "timestamp": "1539931540"})
+		})
+		assert.Nil(t, err)
+
+		// the code hash is skipped, the provenance and the following migrations are not
+		assert.Nil(t, c.Migrate(SchemaVersion(), 1, nodes))
+		account, err := c.GetAccountByAddress("0x05")
+		assert.Nil(t, err)
+		assert.Equal(t, account.Creator, "0x03")
+		assert.Equal(t, account.CreationTx, "0x0e")
+		assert.Empty(t, account.CodeHash)
+		schema, err := c.GetSchema()
+		assert.Nil(t, err)
+		assert.Equal(t, schema.Version, SchemaVersion())
+	}
+}
+
+func Test_MigrationRunnerResume(t *testing.T) {
+	c := newOldSchemaClient(t)
+	schema, err := c.GetSchema()
+	assert.Nil(t, err)
+	runner := &MigrationRunner{c: c, schema: schema, batchSize: 1}
+
+	var migrated []string
+	errInterrupted := errors.New("interrupted")
+	update := func(doc bson.M) (interface{}, error) {
+		if doc["hash"] == "0x0b" && len(migrated) == 1 {
+			return nil, errInterrupted
+		}
+		migrated = append(migrated, doc["hash"].(string))
+		return nil, nil
+	}
+	assert.Equal(t, runner.Batch("tx", txTbl, nil, update), errInterrupted)
+
+	// the cursor is stored, the first batch is not migrated again
+	schema, err = c.GetSchema()
+	assert.Nil(t, err)
+	assert.NotNil(t, schema.Cursors["tx"])
+	runner = &MigrationRunner{c: c, schema: schema, batchSize: 1}
+	migrated = append(migrated, "resume")
+	assert.Nil(t, runner.Batch("tx", txTbl, nil, update))
+	assert.Equal(t, migrated, []string{"0x0a", "resume", "0x0b"})
+}
+
+func Test_CheckSchemaVersionOfEmptyDatabase(t *testing.T) {
+	c := NewMemoryClient(1)
+	assert.Nil(t, c.CheckSchemaVersion())
+	assert.Nil(t, c.AddBlock(&DBBlock{Height: 1, ShardNumber: 1}))
+
+	// the version is stored before the blocks are synchronized
+	schema, err := c.GetSchema()
+	assert.Nil(t, err)
+	assert.Equal(t, schema.Version, SchemaVersion())
+	assert.Nil(t, c.CheckSchemaVersion())
+}
+
+func Test_CheckSchemaVersionReadOnly(t *testing.T) {
+	c := NewMemoryClient(1)
+	err := c.withCollection(schemaTbl, func(c collection) error {
+		return c.Insert(bson.M{"_id": schemaID, "version": SchemaVersion(), "target": SchemaVersion(), "note": "kept"})
+	})
+	assert.Nil(t, err)
+	assert.Nil(t, c.AddBlock(&DBBlock{Height: 1, ShardNumber: 1}))
+
+	// the stored schema is not written again
+	assert.Nil(t, c.CheckSchemaVersion())
+	err = c.withCollection(schemaTbl, func(c collection) error {
+		var doc bson.M
+		err := c.Find(bson.M{"_id": schemaID}).One(&doc)
+		assert.Equal(t, doc["note"], "kept")
+		return err
+	})
+	assert.Nil(t, err)
+}
@@ -0,0 +1,263 @@
+/**
+*  @file
+*  @copyright defined in scan-api/LICENSE
+ */
+
+package database
+
+import (
+	"strconv"
+
+	"github.com/seeleteam/scan-api/log"
+	"gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// migrations is the ordered migrations of the schema, a new migration is appended with the next version
+var migrations = []*Migration{
+	{
+		Version:     1,
+		Description: "rename the abiJSON field of the contract accounts to abi",
+		Up:          upRenameContractABI,
+		Down:        downRenameContractABI,
+	},
+	{
+		Version:     2,
+		Description: "fill the tx fee and debt fee breakdown of the blocks",
+		Up:          upFillBlockFees,
+		Down:        downFillBlockFees,
+	},
+	{
+		Version:     3,
+		Description: "store the timestamp of the transactions as number instead of string",
+		Up:          upTxTimestampNumber,
+		Down:        downTxTimestampNumber,
+	},
+	{
+		Version:     4,
+		Description: "build the counters of the blocks, transactions, debts and accounts",
+		Up:          upBuildStats,
+		Down:        downBuildStats,
+	},
+	{
+		Version:     5,
+		Description: "build the activities of the addresses from the transactions and debts",
+		Up:          upBuildActivities,
+		Down:        downBuildActivities,
+	},
+	{
+		Version:     6,
+		Description: "backfill the creator, creation transaction and code hash of the contracts created before they were recorded",
+		Up:          upBackfillContractCreation,
+		Down:        downBackfillContractCreation,
+	},
+	{
+		Version:     7,
+		Description: "rebuild the supply of the shards and the supply chart from the blocks",
+		Up:          upRebuildSupply,
+		Down:        downRebuildSupply,
+	},
+	{
+		Version:     8,
+		Description: "rebuild the reward, tx fee and debt fee of the miners from the blocks",
+		Up:          upRebuildMiners,
+		Down:        downRebuildMiners,
+	},
+}
+
+func upRenameContractABI(r *MigrationRunner) error {
+	return r.Batch("account-abi", accTbl, bson.M{"abiJSON": bson.M{"$exists": true}}, func(doc bson.M) (interface{}, error) {
+		// the abi verified after the upgrade is newer
+		if abi, ok := doc["abi"].(string); ok && abi != "" {
+			return bson.M{"$unset": bson.M{"abiJSON": ""}}, nil
+		}
+		return bson.M{"$rename": bson.M{"abiJSON": "abi"}}, nil
+	})
+}
+
+func downRenameContractABI(r *MigrationRunner) error {
+	return r.Batch("account-abi", accTbl, bson.M{"abi": bson.M{"$exists": true}}, func(doc bson.M) (interface{}, error) {
+		return bson.M{"$rename": bson.M{"abi": "abiJSON"}}, nil
+	})
+}
+
+func upFillBlockFees(r *MigrationRunner) error {
+	return r.Batch("block-fees", blockTbl, bson.M{"txFee": bson.M{"$exists": false}}, func(doc bson.M) (interface{}, error) {
+		block := new(DBBlock)
+		if err := fromDoc(doc, block); err != nil {
+			return nil, err
+		}
+		block.SetBlockFees()
+		return bson.M{"$set": bson.M{"txFee": block.TxFee, "debtFee": block.DebtFee}}, nil
+	})
+}
+
+func downFillBlockFees(r *MigrationRunner) error {
+	return r.Batch("block-fees", blockTbl, bson.M{"txFee": bson.M{"$exists": true}}, func(doc bson.M) (interface{}, error) {
+		return bson.M{"$unset": bson.M{"txFee": "", "debtFee": ""}}, nil
+	})
+}
+
+func upTxTimestampNumber(r *MigrationRunner) error {
+	update := func(doc bson.M) (interface{}, error) {
+		timestamp, ok := doc["timestamp"].(string)
+		if !ok {
+			return nil, nil
+		}
+		value, err := strconv.ParseInt(timestamp, 10, 64)
+		if err != nil {
+			value = 0
+		}
+		return bson.M{"$set": bson.M{"timestamp": value}}, nil
+	}
+	if err := r.Batch("transaction-timestamp", txTbl, nil, update); err != nil {
+		return err
+	}
+	return r.Batch("pendingtx-timestamp", pendingTxTbl, nil, update)
+}
+
+func downTxTimestampNumber(r *MigrationRunner) error {
+	update := func(doc bson.M) (interface{}, error) {
+		timestamp, ok := toInt64(doc["timestamp"])
+		if !ok {
+			return nil, nil
+		}
+		return bson.M{"$set": bson.M{"timestamp": strconv.FormatInt(timestamp, 10)}}, nil
+	}
+	if err := r.Batch("transaction-timestamp", txTbl, nil, update); err != nil {
+		return err
+	}
+	return r.Batch("pendingtx-timestamp", pendingTxTbl, nil, update)
+}
+
+func upBuildStats(r *MigrationRunner) error {
+	// the counters are rebuilt from the beginning when an interrupted migration is resumed
+	_, err := r.c.Recount(true, r.batchSize)
+	return err
+}
+
+func downBuildStats(r *MigrationRunner) error {
+	query := func(c collection) error {
+		_, err := c.RemoveAll(nil)
+		return err
+	}
+	return r.c.withCollection(statsTbl, query)
+}
+
+func upBuildActivities(r *MigrationRunner) error {
+	err := r.Scan("activity-transaction", txTbl, nil, func(docs []bson.M) error {
+		var activities []*DBAddressActivity
+		for _, doc := range docs {
+			tx := new(DBTx)
+			if err := fromDoc(doc, tx); err != nil {
+				return err
+			}
+			activities = append(activities, CreateTxActivities(tx)...)
+		}
+		return r.c.upsertAddressActivities(activities)
+	})
+	if err != nil {
+		return err
+	}
+
+	err = r.Scan("activity-debt", debtTbl, nil, func(docs []bson.M) error {
+		var activities []*DBAddressActivity
+		for _, doc := range docs {
+			debt := new(Debt)
+			if err := fromDoc(doc, debt); err != nil {
+				return err
+			}
+			if activity := r.c.debtActivity(debt); activity != nil {
+				activities = append(activities, activity)
+			}
+		}
+		return r.c.upsertAddressActivities(activities)
+	})
+	if err != nil {
+		return err
+	}
+
+	// the activity counters are counted after the activities are built
+	_, err = r.c.Recount(true, r.batchSize)
+	return err
+}
+
+func downBuildActivities(r *MigrationRunner) error {
+	query := func(c collection) error {
+		_, err := c.RemoveAll(nil)
+		return err
+	}
+	if err := r.c.withCollection(activityTbl, query); err != nil {
+		return err
+	}
+	query = func(c collection) error {
+		_, err := c.RemoveAll(bson.M{"name": bson.M{"$in": []string{statActivitiesIn, statActivitiesOut}}})
+		return err
+	}
+	return r.c.withCollection(statsTbl, query)
+}
+
+func upBackfillContractCreation(r *MigrationRunner) error {
+	return r.Scan("contract-creation", txTbl, bson.M{"txtype": 1}, func(docs []bson.M) error {
+		for _, doc := range docs {
+			tx := new(DBTx)
+			if err := fromDoc(doc, tx); err != nil {
+				return err
+			}
+			if tx.ContractAddress == "" {
+				continue
+			}
+			account, err := r.c.GetAccountByAddress(tx.ContractAddress)
+			if err == mgo.ErrNotFound {
+				continue
+			} else if err != nil {
+				return err
+			}
+			if account.Creator != "" && account.CodeHash != "" {
+				continue
+			}
+
+			// the provenance is of the transaction, only the code hash is read from the node
+			fields := bson.M{"creator": tx.From, "creationTx": tx.Hash, "creationBlock": tx.Block}
+			if account.CodeHash == "" {
+				if codeHash, err := r.codeHash(tx); err != nil {
+					log.Warn("[DB] migrate contract-creation: the code hash of %s is skipped, %s", tx.ContractAddress, err)
+				} else if codeHash != "" {
+					fields["codeHash"] = codeHash
+				}
+			}
+			query := func(c collection) error {
+				return c.Update(bson.M{"address": tx.ContractAddress}, bson.M{"$set": fields})
+			}
+			if err := r.c.withCollection(accTbl, query); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func downBackfillContractCreation(r *MigrationRunner) error {
+	// the provenance is in the previous schema too, it is kept
+	return nil
+}
+
+func upRebuildSupply(r *MigrationRunner) error {
+	// the supply is summed from the beginning when an interrupted migration is resumed
+	return r.c.RecountSupply(r.batchSize)
+}
+
+func downRebuildSupply(r *MigrationRunner) error {
+	// the supply is in the previous schema too, it is kept
+	return nil
+}
+
+func upRebuildMiners(r *MigrationRunner) error {
+	// the miners are summed from the beginning when an interrupted migration is resumed
+	return r.c.RecountMiners(r.batchSize)
+}
+
+func downRebuildMiners(r *MigrationRunner) error {
+	// the breakdown is in the previous schema too, it is kept
+	return nil
+}
@@ -0,0 +1,259 @@
+/**
+*  @file
+*  @copyright defined in scan-api/LICENSE
+ */
+
+package database
+
+import (
+	"sync"
+	"time"
+
+	"github.com/seeleteam/scan-api/log"
+
+	"gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// lagCacheTTL is how long the replication lag of the replica set is reused before it is read again
+const lagCacheTTL = 10 * time.Second
+
+// replicaDialTimeout is the timeout of the direct connection to a member of the replica set
+const replicaDialTimeout = 5 * time.Second
+
+// secondaryTbls are the tables whose reads may be served by a secondary, the counters and the
+// charts are tolerant of a short delay. All the other reads go to the primary so that a document
+// is found right after it is indexed.
+var secondaryTbls = map[string]bool{
+	statsTbl:                true,
+	chartTxTbl:              true,
+	chartHashRateTbl:        true,
+	chartBlockDifficultyTbl: true,
+	chartBlockAvgTimeTbl:    true,
+	chartBlockTbl:           true,
+	chartAddressTbl:         true,
+	chartSingleAddressTbl:   true,
+	chartTopMinerRankTbl:    true,
+	chartMinerRevenueTbl:    true,
+	chartSupplyTbl:          true,
+}
+
+// replicaLag is the cached replication lag of the replica set
+type replicaLag struct {
+	mutex  sync.Mutex
+	lag    time.Duration
+	readAt time.Time
+}
+
+// ReplicaStatus is the state of a member of the replica set and the heights it has synchronized
+type ReplicaStatus struct {
+	Name    string         `json:"name"`
+	State   string         `json:"state"`
+	Healthy bool           `json:"healthy"`
+	Lag     int64          `json:"lag"` // seconds behind the primary
+	Heights map[int]uint64 `json:"heights"`
+	Error   string         `json:"error,omitempty"`
+}
+
+// writeTimes is the time of the last write of the client to each table
+type writeTimes struct {
+	mutex sync.Mutex
+	at    map[string]time.Time
+}
+
+// readFromSecondary return whether the reads of the table go to a secondary. It is only the case
+// for the tables tolerant of a delay, when the client has not written the table within the max
+// staleness and the secondaries are not further behind than it.
+func (c *Client) readFromSecondary(name string) bool {
+	if c.dbMode != "replset" || c.maxStaleness <= 0 || !secondaryTbls[name] {
+		return false
+	}
+	c.writes.mutex.Lock()
+	lastWrite := c.writes.at[name]
+	c.writes.mutex.Unlock()
+	if time.Since(lastWrite) < c.maxStaleness {
+		return false
+	}
+	return c.replicationLag() <= c.maxStaleness
+}
+
+// wrote record the time of a write of the client to the table, its reads of the table go to the
+// primary within the max staleness
+func (c *Client) wrote(name string) {
+	c.writes.mutex.Lock()
+	defer c.writes.mutex.Unlock()
+	if c.writes.at == nil {
+		c.writes.at = make(map[string]time.Time)
+	}
+	c.writes.at[name] = time.Now()
+}
+
+// replicationLag return the lag of the slowest healthy secondary, it is read again after lagCacheTTL.
+// The lag is unlimited if the replica set status could not be read so that the reads stay on the primary.
+func (c *Client) replicationLag() time.Duration {
+	c.lag.mutex.Lock()
+	defer c.lag.mutex.Unlock()
+	if time.Since(c.lag.readAt) < lagCacheTTL {
+		return c.lag.lag
+	}
+
+	c.lag.readAt = time.Now()
+	c.lag.lag = time.Duration(1<<63 - 1)
+	members, err := c.replSetStatus()
+	if err != nil {
+		log.Error("[DB] err : read replica set status failed %v", err)
+		return c.lag.lag
+	}
+	var lag time.Duration
+	for _, member := range members {
+		if member.Healthy && member.State == "SECONDARY" && time.Duration(member.Lag)*time.Second > lag {
+			lag = time.Duration(member.Lag) * time.Second
+		}
+	}
+	c.lag.lag = lag
+	return lag
+}
+
+// replSetStatus read the members of the replica set and their lag behind the primary
+func (c *Client) replSetStatus() ([]*ReplicaStatus, error) {
+	session := c.getDBConnection()
+	if session == nil {
+		return nil, errDBConnect
+	}
+	defer session.Close()
+
+	var result struct {
+		Members []struct {
+			Name       string    `bson:"name"`
+			StateStr   string    `bson:"stateStr"`
+			Health     float64   `bson:"health"`
+			OptimeDate time.Time `bson:"optimeDate"`
+		} `bson:"members"`
+	}
+	if err := session.DB("admin").Run(bson.D{{Name: "replSetGetStatus", Value: 1}}, &result); err != nil {
+		return nil, err
+	}
+
+	var primary time.Time
+	for _, member := range result.Members {
+		if member.StateStr == "PRIMARY" {
+			primary = member.OptimeDate
+		}
+	}
+	var members []*ReplicaStatus
+	for _, member := range result.Members {
+		status := &ReplicaStatus{
+			Name:    member.Name,
+			State:   member.StateStr,
+			Healthy: member.Health == 1,
+		}
+		if !primary.IsZero() && primary.After(member.OptimeDate) {
+			status.Lag = int64(primary.Sub(member.OptimeDate) / time.Second)
+		}
+		members = append(members, status)
+	}
+	return members, nil
+}
+
+// ReplicaStatus return the members of the database with the next block height of every shard they
+// have synchronized, a read of a secondary is stale if its height is behind the primary. The memory
+// and single mode databases have one member.
+// index: syncstate {shardNumber}
+func (c *Client) ReplicaStatus() ([]*ReplicaStatus, error) {
+	if c.dbMode != "replset" {
+		name := MemoryMode
+		if c.mem == nil && len(c.connURLs) > 0 {
+			name = c.connURLs[0]
+		}
+		status := &ReplicaStatus{Name: name, State: "PRIMARY", Healthy: true}
+		err := c.withCollection(syncStateTbl, func(c collection) error {
+			var err error
+			status.Heights, err = syncHeights(c)
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+		return []*ReplicaStatus{status}, nil
+	}
+
+	members, err := c.replSetStatus()
+	if err != nil {
+		return nil, err
+	}
+	for _, member := range members {
+		if !member.Healthy {
+			continue
+		}
+		if member.Heights, err = c.memberHeights(member.Name); err != nil {
+			member.Error = err.Error()
+		}
+	}
+	return members, nil
+}
+
+// memberHeights read the sync heights of a member by a direct connection to it
+func (c *Client) memberHeights(addr string) (map[int]uint64, error) {
+	session, err := mgo.DialWithInfo(&mgo.DialInfo{
+		Addrs:   []string{addr},
+		Direct:  true,
+		Timeout: replicaDialTimeout,
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer session.Close()
+	// a direct connection reads a secondary in the monotonic mode
+	session.SetMode(mgo.Monotonic, true)
+	return syncHeights(mgoCollection{session.DB(c.dbName).C(syncStateTbl)})
+}
+
+// syncHeights read the next block height to synchronize of every shard
+func syncHeights(c collection) (map[int]uint64, error) {
+	var states []*DBSyncState
+	if err := c.Find(bson.M{"shardNumber": bson.M{"$gte": 0}}).All(&states); err != nil {
+		return nil, err
+	}
+	heights := make(map[int]uint64)
+	for _, state := range states {
+		heights[state.ShardNumber] = state.Height
+	}
+	return heights, nil
+}
+
+// writeTracker is a collection which records the writes of the client to the table
+type writeTracker struct {
+	collection
+	client *Client
+	name   string
+}
+
+// Insert insert the documents and record the write
+func (w writeTracker) Insert(docs ...interface{}) error {
+	w.client.wrote(w.name)
+	return w.collection.Insert(docs...)
+}
+
+// Update update the document and record the write
+func (w writeTracker) Update(selector interface{}, update interface{}) error {
+	w.client.wrote(w.name)
+	return w.collection.Update(selector, update)
+}
+
+// Upsert upsert the document and record the write
+func (w writeTracker) Upsert(selector interface{}, update interface{}) (*mgo.ChangeInfo, error) {
+	w.client.wrote(w.name)
+	return w.collection.Upsert(selector, update)
+}
+
+// Remove remove the document and record the write
+func (w writeTracker) Remove(selector interface{}) error {
+	w.client.wrote(w.name)
+	return w.collection.Remove(selector)
+}
+
+// RemoveAll remove the documents and record the write
+func (w writeTracker) RemoveAll(selector interface{}) (*mgo.ChangeInfo, error) {
+	w.client.wrote(w.name)
+	return w.collection.RemoveAll(selector)
+}
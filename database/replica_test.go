@@ -0,0 +1,57 @@
+/**
+*  @file
+*  @copyright defined in scan-api/LICENSE
+ */
+
+package database
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ReplicaStatus(t *testing.T) {
+	c := NewMemoryClient(1)
+	newTestMemoryBlocks(t, c, 1, 3)
+	newTestMemoryBlocks(t, c, 2, 2)
+
+	replicas, err := c.ReplicaStatus()
+	assert.Nil(t, err)
+	assert.Equal(t, len(replicas), 1)
+	assert.Equal(t, replicas[0].Name, MemoryMode)
+	assert.Equal(t, replicas[0].Heights, map[int]uint64{1: 3, 2: 2})
+}
+
+func Test_ReadFromSecondary(t *testing.T) {
+	c := &Client{dbMode: "replset", maxStaleness: 30 * time.Second}
+	c.lag.readAt = time.Now()
+	c.lag.lag = 5 * time.Second
+
+	assert.Equal(t, c.readFromSecondary(statsTbl), true)
+	assert.Equal(t, c.readFromSecondary(chartTxTbl), true)
+	// the documents are read from the primary
+	assert.Equal(t, c.readFromSecondary(txTbl), false)
+
+	// the secondaries are further behind than the max staleness
+	c.lag.lag = time.Minute
+	assert.Equal(t, c.readFromSecondary(statsTbl), false)
+	c.lag.lag = 5 * time.Second
+
+	// the client reads its own writes of a table
+	mem := NewMemoryClient(1)
+	err := mem.withCollection(statsTbl, func(col collection) error {
+		return writeTracker{col, c, statsTbl}.Insert(&DBStat{Name: statTxs})
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, c.readFromSecondary(statsTbl), false)
+	assert.Equal(t, c.readFromSecondary(chartTxTbl), true)
+
+	c.writes.at[statsTbl] = time.Now().Add(-time.Minute)
+	assert.Equal(t, c.readFromSecondary(statsTbl), true)
+
+	// no max staleness reads everything from the primary
+	c.maxStaleness = 0
+	assert.Equal(t, c.readFromSecondary(statsTbl), false)
+}
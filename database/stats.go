@@ -0,0 +1,421 @@
+/**
+*  @file
+*  @copyright defined in scan-api/LICENSE
+ */
+
+package database
+
+import (
+	"sort"
+	"time"
+
+	"gopkg.in/mgo.v2/bson"
+)
+
+// the names of the counters
+const (
+	statBlocks     = "blocks"
+	statTxs        = "txs"
+	statDebts      = "debts"
+	statAccounts   = "accounts"
+	statContracts  = "contracts"
+	statAddressTxs = "addresstxs"
+
+	statActivitiesIn  = "activitiesin"
+	statActivitiesOut = "activitiesout"
+)
+
+// statKey identify a counter, address is empty for the counters of a shard
+type statKey struct {
+	name        string
+	shardNumber int
+	address     string
+}
+
+// statDeltas is the changes of the counters made by a write
+type statDeltas map[statKey]int64
+
+// add the keys to the deltas n times
+func (d statDeltas) add(keys []statKey, n int64) {
+	for _, key := range keys {
+		d[key] += n
+	}
+}
+
+// txStatKeys return the counters of a transaction, a transaction is counted once for every address
+// it is from, to or creates as the address transaction count queries do
+func txStatKeys(tx *DBTx) []statKey {
+	keys := []statKey{{name: statTxs, shardNumber: tx.ShardNumber}}
+	seen := make(map[string]bool)
+	for _, address := range []string{tx.From, tx.To, tx.ContractAddress} {
+		if address == "" || seen[address] {
+			continue
+		}
+		seen[address] = true
+		keys = append(keys, statKey{name: statAddressTxs, shardNumber: tx.ShardNumber, address: address})
+	}
+	return keys
+}
+
+// accountStatKeys return the counter of an account
+func accountStatKeys(accType int, shardNumber int) []statKey {
+	if accType == 1 {
+		return []statKey{{name: statContracts, shardNumber: shardNumber}}
+	}
+	return []statKey{{name: statAccounts, shardNumber: shardNumber}}
+}
+
+// activityStatKeys return the counter of an activity of an address
+func activityStatKeys(activity *DBAddressActivity) []statKey {
+	if activity.In() {
+		return []statKey{{name: statActivitiesIn, shardNumber: activity.ShardNumber, address: activity.Address}}
+	}
+	return []statKey{{name: statActivitiesOut, shardNumber: activity.ShardNumber, address: activity.Address}}
+}
+
+// incStats add the deltas to the counters, a missing counter is created
+// index: stats {address, name, shardNumber}
+func (c *Client) incStats(deltas statDeltas) error {
+	query := func(c collection) error {
+		for key, delta := range deltas {
+			if delta == 0 {
+				continue
+			}
+			selector := bson.M{"name": key.name, "shardNumber": key.shardNumber, "address": key.address}
+			if _, err := c.Upsert(selector, bson.M{"$inc": bson.M{"count": delta}}); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return c.withCollection(statsTbl, query)
+}
+
+// getStat get the counter of a shard, or of an address in a shard, a missing counter is zero
+// index: stats {address, name, shardNumber}
+func (c *Client) getStat(name string, shardNumber int, address string) (int64, error) {
+	var stats []*DBStat
+	query := func(c collection) error {
+		return c.Find(bson.M{"address": address, "name": name, "shardNumber": shardNumber}).All(&stats)
+	}
+	err := c.withCollection(statsTbl, query)
+	return sumStats(stats), err
+}
+
+// getStatOfAllShards get the sum of the counters of all the shards
+// index: stats {address, name, shardNumber}
+func (c *Client) getStatOfAllShards(name string, address string) (int64, error) {
+	var stats []*DBStat
+	query := func(c collection) error {
+		return c.Find(bson.M{"address": address, "name": name}).All(&stats)
+	}
+	err := c.withCollection(statsTbl, query)
+	return sumStats(stats), err
+}
+
+func sumStats(stats []*DBStat) int64 {
+	var count int64
+	for _, stat := range stats {
+		count += stat.Count
+	}
+	return count
+}
+
+// StatDrift is a counter which is different from the count of its documents
+type StatDrift struct {
+	Name        string
+	ShardNumber int
+	Address     string
+	Stored      int64
+	Counted     int64
+}
+
+// countStats count the documents of a collection in batches ordered by _id, keys return the counters of a document
+func (c *Client) countStats(tbl string, batchSize int, deltas statDeltas, keys func(doc bson.M) ([]statKey, error)) error {
+	var last interface{}
+	for {
+		selector := bson.M{}
+		if last != nil {
+			selector["_id"] = bson.M{"$gt": last}
+		}
+
+		var docs []bson.M
+		query := func(c collection) error {
+			return c.Find(selector).Sort("_id").Limit(batchSize).All(&docs)
+		}
+		if err := c.withCollection(tbl, query); err != nil {
+			return err
+		}
+		for _, doc := range docs {
+			k, err := keys(doc)
+			if err != nil {
+				return err
+			}
+			deltas.add(k, 1)
+		}
+		if len(docs) < batchSize {
+			return nil
+		}
+		last = docs[len(docs)-1]["_id"]
+	}
+}
+
+// Recount count the documents of every counter and compare the counts with the counters,
+// the drifted counters are returned and corrected if fix is set. The syncers should be stopped
+// while recounting, the documents they write during the count are not counted.
+func (c *Client) Recount(fix bool, batchSize int) ([]*StatDrift, error) {
+	if batchSize <= 0 {
+		batchSize = defaultMigrationBatchSize
+	}
+
+	counted := make(statDeltas)
+	err := c.countStats(blockTbl, batchSize, counted, func(doc bson.M) ([]statKey, error) {
+		block := new(DBBlock)
+		err := fromDoc(doc, block)
+		return []statKey{{name: statBlocks, shardNumber: block.ShardNumber}}, err
+	})
+	if err != nil {
+		return nil, err
+	}
+	err = c.countStats(txTbl, batchSize, counted, func(doc bson.M) ([]statKey, error) {
+		tx := new(DBTx)
+		err := fromDoc(doc, tx)
+		return txStatKeys(tx), err
+	})
+	if err != nil {
+		return nil, err
+	}
+	err = c.countStats(debtTbl, batchSize, counted, func(doc bson.M) ([]statKey, error) {
+		debt := new(Debt)
+		err := fromDoc(doc, debt)
+		return []statKey{{name: statDebts, shardNumber: debt.ShardNumber}}, err
+	})
+	if err != nil {
+		return nil, err
+	}
+	err = c.countStats(accTbl, batchSize, counted, func(doc bson.M) ([]statKey, error) {
+		account := new(DBAccount)
+		err := fromDoc(doc, account)
+		return accountStatKeys(account.AccType, account.ShardNumber), err
+	})
+	if err != nil {
+		return nil, err
+	}
+	err = c.countStats(activityTbl, batchSize, counted, func(doc bson.M) ([]statKey, error) {
+		activity := new(DBAddressActivity)
+		err := fromDoc(doc, activity)
+		return activityStatKeys(activity), err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var stored []*DBStat
+	query := func(c collection) error {
+		return c.Find(nil).All(&stored)
+	}
+	if err := c.withCollection(statsTbl, query); err != nil {
+		return nil, err
+	}
+
+	var drifts []*StatDrift
+	for _, stat := range stored {
+		key := statKey{name: stat.Name, shardNumber: stat.ShardNumber, address: stat.Address}
+		if counted[key] != stat.Count {
+			drifts = append(drifts, &StatDrift{stat.Name, stat.ShardNumber, stat.Address, stat.Count, counted[key]})
+		}
+		delete(counted, key)
+	}
+	for key, count := range counted {
+		drifts = append(drifts, &StatDrift{key.name, key.shardNumber, key.address, 0, count})
+	}
+	sort.Slice(drifts, func(i, j int) bool {
+		if drifts[i].Name != drifts[j].Name {
+			return drifts[i].Name < drifts[j].Name
+		}
+		if drifts[i].ShardNumber != drifts[j].ShardNumber {
+			return drifts[i].ShardNumber < drifts[j].ShardNumber
+		}
+		return drifts[i].Address < drifts[j].Address
+	})
+	if !fix {
+		return drifts, nil
+	}
+
+	query = func(c collection) error {
+		for _, drift := range drifts {
+			selector := bson.M{"name": drift.Name, "shardNumber": drift.ShardNumber, "address": drift.Address}
+			if drift.Counted == 0 {
+				if _, err := c.RemoveAll(selector); err != nil {
+					return err
+				}
+				continue
+			}
+			if _, err := c.Upsert(selector, bson.M{"$set": bson.M{"count": drift.Counted}}); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return drifts, c.withCollection(statsTbl, query)
+}
+
+// SupplyDay return the zero hour of the day of the block timestamp, the day of the supply chart
+func SupplyDay(timestamp int64) int64 {
+	t := time.Unix(timestamp, 0)
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location()).Unix()
+}
+
+// RecountSupply sum the coins minted and the fees paid by the stored blocks and replace the supply
+// of the shards and the supply chart with the sums, the genesis allocations are kept. The syncers
+// should be stopped while recounting.
+// index: supply {shardNumber} and chart_supply {shardnumber, timestamp}
+func (c *Client) RecountSupply(batchSize int) error {
+	if batchSize <= 0 {
+		batchSize = defaultMigrationBatchSize
+	}
+
+	shards := make(map[int]*DBOneDaySupply)
+	days := make(map[DBOneDaySupply]*DBOneDaySupply)
+	var last interface{}
+	for {
+		selector := bson.M{}
+		if last != nil {
+			selector["_id"] = bson.M{"$gt": last}
+		}
+
+		var docs []bson.M
+		query := func(c collection) error {
+			return c.Find(selector).Sort("_id").Limit(batchSize).All(&docs)
+		}
+		if err := c.withCollection(blockTbl, query); err != nil {
+			return err
+		}
+		for _, doc := range docs {
+			block := new(DBBlock)
+			if err := fromDoc(doc, block); err != nil {
+				return err
+			}
+			day := DBOneDaySupply{ShardNumber: block.ShardNumber, TimeStamp: SupplyDay(block.Timestamp)}
+			if shards[block.ShardNumber] == nil {
+				shards[block.ShardNumber] = &DBOneDaySupply{ShardNumber: block.ShardNumber}
+			}
+			if days[day] == nil {
+				days[day] = &DBOneDaySupply{ShardNumber: day.ShardNumber, TimeStamp: day.TimeStamp}
+			}
+			for _, supply := range []*DBOneDaySupply{shards[block.ShardNumber], days[day]} {
+				supply.Minted += block.Reward
+				supply.Fees += block.TxFee + block.DebtFee
+				supply.Blocks++
+			}
+		}
+		if len(docs) < batchSize {
+			break
+		}
+		last = docs[len(docs)-1]["_id"]
+	}
+
+	stored, err := c.GetSupply()
+	if err != nil {
+		return err
+	}
+	for _, supply := range stored {
+		if shards[supply.ShardNumber] == nil {
+			shards[supply.ShardNumber] = &DBOneDaySupply{ShardNumber: supply.ShardNumber}
+		}
+	}
+	query := func(c collection) error {
+		for _, supply := range shards {
+			set := bson.M{"$set": bson.M{"minted": supply.Minted, "fees": supply.Fees, "blocks": supply.Blocks}}
+			if _, err := c.Upsert(bson.M{"shardNumber": supply.ShardNumber}, set); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := c.withCollection(supplyTbl, query); err != nil {
+		return err
+	}
+
+	query = func(c collection) error {
+		if _, err := c.RemoveAll(nil); err != nil {
+			return err
+		}
+		for _, supply := range days {
+			if err := c.Insert(supply); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return c.withCollection(chartSupplyTbl, query)
+}
+
+// RecountMiners sum the blocks, rewards and fees of the creators of the stored blocks and replace the
+// revenue breakdown of the miners with the sums, the fee of the miners stored before the breakdown
+// included the debt fee. The syncers should be stopped while recounting.
+// index: miner {address}
+func (c *Client) RecountMiners(batchSize int) error {
+	if batchSize <= 0 {
+		batchSize = defaultMigrationBatchSize
+	}
+
+	miners := make(map[string]*DBMiner)
+	var last interface{}
+	for {
+		selector := bson.M{}
+		if last != nil {
+			selector["_id"] = bson.M{"$gt": last}
+		}
+
+		var docs []bson.M
+		query := func(c collection) error {
+			return c.Find(selector).Sort("_id").Limit(batchSize).All(&docs)
+		}
+		if err := c.withCollection(blockTbl, query); err != nil {
+			return err
+		}
+		for _, doc := range docs {
+			block := new(DBBlock)
+			if err := fromDoc(doc, block); err != nil {
+				return err
+			}
+			// the genesis block has no miner
+			if block.Creator == "" || block.Creator == coinbaseAddress {
+				continue
+			}
+			miner := miners[block.Creator]
+			if miner == nil {
+				miner = &DBMiner{Address: block.Creator, ShardNumber: block.ShardNumber}
+				miners[block.Creator] = miner
+			}
+			miner.Mined++
+			miner.Reward += block.Reward
+			miner.TxFee += block.TxFee
+			miner.DebtFee += block.DebtFee
+			miner.Revenue += block.Revenue()
+		}
+		if len(docs) < batchSize {
+			break
+		}
+		last = docs[len(docs)-1]["_id"]
+	}
+
+	query := func(c collection) error {
+		for _, miner := range miners {
+			set := bson.M{"$set": bson.M{
+				"shardNumber": miner.ShardNumber,
+				"mined":       miner.Mined,
+				"reward":      miner.Reward,
+				"fee":         miner.TxFee,
+				"debtFee":     miner.DebtFee,
+				"total":       miner.Revenue,
+			}}
+			if _, err := c.Upsert(bson.M{"address": miner.Address}, set); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return c.withCollection(minerTbl, query)
+}
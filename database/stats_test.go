@@ -0,0 +1,194 @@
+/**
+*  @file
+*  @copyright defined in scan-api/LICENSE
+ */
+
+package database
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/mgo.v2/bson"
+)
+
+func Test_StatsMaintainedByWrites(t *testing.T) {
+	c := NewMemoryClient(1)
+	newTestMemoryBlocks(t, c, 1, 3)
+	newTestMemoryBlocks(t, c, 2, 2)
+	assert.Nil(t, c.AddTxs(
+		&DBTx{Hash: "0x0a", From: "0x01", To: "0x02", Block: 1, ShardNumber: 1},
+		&DBTx{Hash: "0x0b", From: "0x01", To: "0x01", Block: 2, ShardNumber: 1},
+		&DBTx{Hash: "0x0c", From: "0x02", ContractAddress: "0x03", Block: 2, ShardNumber: 1}))
+	assert.Nil(t, c.AddTx(&DBTx{Hash: "0x0d", From: "0x01", To: "0x04", Block: 1, ShardNumber: 2}))
+	assert.Nil(t, c.AddDebtTxs(&Debt{Hash: "0x0e", To: "0x04", ShardNumber: 2}))
+	assert.Nil(t, c.UpdateAccount(&DBAccount{Address: "0x01", ShardNumber: 1}))
+	assert.Nil(t, c.UpdateAccount(&DBAccount{Address: "0x01", ShardNumber: 1, TxCount: 3}))
+	assert.Nil(t, c.UpdateAccount(&DBAccount{Address: "0x03", ShardNumber: 1, AccType: 1}))
+
+	blockCnt, _ := c.GetBlockCnt()
+	assert.Equal(t, blockCnt, uint64(5))
+	txCnt, _ := c.GetTxCnt()
+	assert.Equal(t, txCnt, uint64(4))
+	txCnt, _ = c.GetTxCntByShardNumber(1)
+	assert.Equal(t, txCnt, uint64(3))
+	debtCnt, _ := c.GetdebtCntByShardNumber(2)
+	assert.Equal(t, debtCnt, uint64(1))
+	accountCnt, _ := c.GetAccountCntByShardNumber(1)
+	assert.Equal(t, accountCnt, uint64(1))
+	contractCnt, _ := c.GetContractCnt()
+	assert.Equal(t, contractCnt, uint64(1))
+
+	addressTxCnt, _ := c.GetTxCntByShardNumberAndAddress(1, "0x01")
+	assert.Equal(t, addressTxCnt, int64(2))
+	addressTxCnt, _ = c.GetTxCntByShardNumberAndAddress(-1, "0x01")
+	assert.Equal(t, addressTxCnt, int64(3))
+	addressTxCnt, _ = c.GetTxCntByShardNumberAndAddress(-1, "0x03")
+	assert.Equal(t, addressTxCnt, int64(1))
+
+	// the block 2 of shard 1 is reverted by a reorg
+	assert.Nil(t, c.RemoveBlock(1, 2))
+	assert.Nil(t, c.RemoveTxs(1, 2))
+	blockCnt, _ = c.GetBlockCnt()
+	assert.Equal(t, blockCnt, uint64(4))
+	txCnt, _ = c.GetTxCntByShardNumber(1)
+	assert.Equal(t, txCnt, uint64(1))
+	addressTxCnt, _ = c.GetTxCntByShardNumberAndAddress(1, "0x02")
+	assert.Equal(t, addressTxCnt, int64(1))
+	addressTxCnt, _ = c.GetTxCntByShardNumberAndAddress(-1, "0x03")
+	assert.Equal(t, addressTxCnt, int64(0))
+
+	drifts, err := c.Recount(false, 2)
+	assert.Nil(t, err)
+	assert.Empty(t, drifts)
+}
+
+func Test_RemoveDebtsOfReorgedBlock(t *testing.T) {
+	c := NewMemoryClient(1)
+	newTestMemoryBlocks(t, c, 1, 3)
+	assert.Nil(t, c.AddDebtTxs(
+		&Debt{Hash: "0x0a", To: "0x01", Height: 1, ShardNumber: 1},
+		&Debt{Hash: "0x0b", To: "0x02", Height: 2, ShardNumber: 1},
+		&Debt{Hash: "0x0c", To: "0x03", Height: 2, ShardNumber: 1}))
+	assert.Nil(t, c.AddDebtTxs(&Debt{Hash: "0x0d", To: "0x04", Height: 2, ShardNumber: 2}))
+
+	// the block 2 of shard 1 is reverted by a reorg
+	assert.Nil(t, c.RemoveBlock(1, 2))
+	assert.Nil(t, c.RemoveTxs(1, 2))
+	assert.Nil(t, c.RemoveDebts(1, 2))
+	debtCnt, _ := c.GetdebtCntByShardNumber(1)
+	assert.Equal(t, debtCnt, uint64(1))
+	debtCnt, _ = c.GetdebtCntByShardNumber(2)
+	assert.Equal(t, debtCnt, uint64(1))
+	_, err := c.GetDebtByHash("0x0b")
+	assert.NotNil(t, err)
+
+	drifts, err := c.Recount(false, 0)
+	assert.Nil(t, err)
+	assert.Empty(t, drifts)
+}
+
+func Test_Recount(t *testing.T) {
+	c := NewMemoryClient(1)
+	newTestMemoryBlocks(t, c, 1, 3)
+	assert.Nil(t, c.AddTxs(
+		&DBTx{Hash: "0x0a", From: "0x01", To: "0x02", Block: 1, ShardNumber: 1},
+		&DBTx{Hash: "0x0b", From: "0x02", To: "0x03", Block: 2, ShardNumber: 1}))
+	assert.Nil(t, c.incStats(statDeltas{
+		{name: statTxs, shardNumber: 1}:                         5,
+		{name: statAddressTxs, shardNumber: 1, address: "0x09"}: 1,
+	}))
+	err := c.withCollection(txTbl, func(c collection) error {
+		// written without maintaining the counters
+		return c.Insert(&DBTx{Hash: "0x0c", From: "0x03", Block: 3, ShardNumber: 1})
+	})
+	assert.Nil(t, err)
+
+	drifts, err := c.Recount(false, 2)
+	assert.Nil(t, err)
+	assert.Equal(t, drifts, []*StatDrift{
+		{Name: statAddressTxs, ShardNumber: 1, Address: "0x03", Stored: 1, Counted: 2},
+		{Name: statAddressTxs, ShardNumber: 1, Address: "0x09", Stored: 1, Counted: 0},
+		{Name: statTxs, ShardNumber: 1, Stored: 7, Counted: 3},
+	})
+	txCnt, _ := c.GetTxCntByShardNumber(1)
+	assert.Equal(t, txCnt, uint64(7))
+
+	drifts, err = c.Recount(true, 2)
+	assert.Nil(t, err)
+	assert.Equal(t, len(drifts), 3)
+	txCnt, _ = c.GetTxCntByShardNumber(1)
+	assert.Equal(t, txCnt, uint64(3))
+	err = c.withCollection(statsTbl, func(c collection) error {
+		cnt, err := c.Find(bson.M{"address": "0x09"}).Count()
+		assert.Equal(t, cnt, 0)
+		return err
+	})
+	assert.Nil(t, err)
+
+	drifts, err = c.Recount(false, 0)
+	assert.Nil(t, err)
+	assert.Empty(t, drifts)
+}
+
+func Test_RecountSupply(t *testing.T) {
+	c := NewMemoryClient(1)
+	assert.Nil(t, c.SetGenesisSupply(1, 1000))
+	assert.Nil(t, c.IncSupply(&DBOneDaySupply{ShardNumber: 2, TimeStamp: 0, Minted: 7, Blocks: 1}))
+	err := c.withCollection(blockTbl, func(c collection) error {
+		// synchronized before the supply was maintained
+		return c.Insert(
+			&DBBlock{Height: 1, ShardNumber: 1, Timestamp: 86400*10 + 1, Reward: 100, TxFee: 3, DebtFee: 1},
+			&DBBlock{Height: 2, ShardNumber: 1, Timestamp: 86400*10 + 2, Reward: 100, TxFee: 5},
+			&DBBlock{Height: 3, ShardNumber: 1, Timestamp: 86400*11 + 1, Reward: 50})
+	})
+	assert.Nil(t, err)
+
+	assert.Nil(t, c.RecountSupply(2))
+	supplies, err := c.GetSupply()
+	assert.Nil(t, err)
+	assert.Equal(t, supplies, []*DBSupply{
+		{ShardNumber: 1, Genesis: 1000, Minted: 250, Fees: 9, Blocks: 3},
+		{ShardNumber: 2},
+	})
+	chart, err := c.GetSupplyChart()
+	assert.Nil(t, err)
+	assert.Equal(t, chart, []*DBOneDaySupply{
+		{ShardNumber: 1, TimeStamp: SupplyDay(86400*10 + 1), Minted: 200, Fees: 9, Blocks: 2},
+		{ShardNumber: 1, TimeStamp: SupplyDay(86400*11 + 1), Minted: 50, Blocks: 1},
+	})
+
+	// the sums are replaced, not added, when recounted again
+	assert.Nil(t, c.RecountSupply(0))
+	supplies, err = c.GetSupply()
+	assert.Nil(t, err)
+	assert.Equal(t, supplies[0].Minted, int64(250))
+}
+
+func Test_RecountMiners(t *testing.T) {
+	c := NewMemoryClient(1)
+	assert.Nil(t, c.IncMinerAccount(&DBMiner{Address: "0x01", ShardNumber: 1, Mined: 5, Reward: 500, TxFee: 9, Revenue: 509}))
+	err := c.withCollection(blockTbl, func(c collection) error {
+		return c.Insert(
+			&DBBlock{Height: 0, ShardNumber: 1, Creator: coinbaseAddress},
+			&DBBlock{Height: 1, ShardNumber: 1, Creator: "0x01", Reward: 100, TxFee: 3, DebtFee: 1},
+			&DBBlock{Height: 2, ShardNumber: 1, Creator: "0x02", Reward: 100, TxFee: 5},
+			&DBBlock{Height: 3, ShardNumber: 1, Creator: "0x01", Reward: 50, DebtFee: 2})
+	})
+	assert.Nil(t, err)
+
+	// the sums of the blocks replace the stored revenue
+	assert.Nil(t, c.RecountMiners(2))
+	miner, err := c.GetMinerAccountByAddress("0x01")
+	assert.Nil(t, err)
+	assert.Equal(t, miner.Mined, int64(2))
+	assert.Equal(t, miner.Reward, int64(150))
+	assert.Equal(t, miner.TxFee, int64(3))
+	assert.Equal(t, miner.DebtFee, int64(3))
+	assert.Equal(t, miner.Revenue, int64(156))
+	miner, err = c.GetMinerAccountByAddress("0x02")
+	assert.Nil(t, err)
+	assert.Equal(t, miner.Revenue, int64(105))
+	_, err = c.GetMinerAccountByAddress(coinbaseAddress)
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,230 @@
+/**
+*  @file
+*  @copyright defined in scan-api/LICENSE
+ */
+
+package database
+
+import (
+	"errors"
+	"strconv"
+
+	"gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// the kinds of the transactions selected by TxFilter.Kind
+const (
+	TxKindTransfer = "transfer" // a transaction without payload to an account
+	TxKindCreate   = "create"   // a contract creation
+	TxKindCall     = "call"     // a transaction with payload to a contract
+)
+
+// MaxTxFilterBlocks is the most blocks of a shard a filtered transaction listing without address scans
+const MaxTxFilterBlocks = 100000
+
+var (
+	// ErrInvalidTxKind is returned for an unknown kind of the transaction filter
+	ErrInvalidTxKind = errors.New("invalid transaction kind")
+
+	// ErrUnboundedTxFilter is returned for a transaction filter which would scan a whole shard:
+	// the amount, fee, kind and failed filters need an address or a block or time range of at
+	// most MaxTxFilterBlocks blocks
+	ErrUnboundedTxFilter = errors.New("the transaction filter needs an address or a block range of at most " +
+		strconv.Itoa(MaxTxFilterBlocks) + " blocks")
+)
+
+// TxFilter select the transactions of a listing, a nil or empty field does not filter. The listing is
+// of the shard if no address is filtered, and of all the shards otherwise.
+type TxFilter struct {
+	ShardNumber int
+	From        string
+	To          string
+	Contract    string // the called or the created contract
+
+	MinAmount, MaxAmount *int64
+	MinFee, MaxFee       *int64
+	StartBlock, EndBlock *uint64
+	StartTime, EndTime   *int64
+	Kind                 string
+	Failed               *bool
+}
+
+// byAddress check whether the filter selects by an address, whose index bounds the scan
+func (f *TxFilter) byAddress() bool {
+	return f.From != "" || f.To != "" || f.Contract != ""
+}
+
+// residual check whether the filter has the conditions which no index of the listing covers
+func (f *TxFilter) residual() bool {
+	return f.MinAmount != nil || f.MaxAmount != nil || f.MinFee != nil || f.MaxFee != nil ||
+		f.Kind != "" || f.Failed != nil
+}
+
+// int64Range return the range condition of the bounds, nil without bound
+func int64Range(min, max *int64) bson.M {
+	if min == nil && max == nil {
+		return nil
+	}
+	cond := bson.M{}
+	if min != nil {
+		cond["$gte"] = *min
+	}
+	if max != nil {
+		cond["$lte"] = *max
+	}
+	return cond
+}
+
+// selector return the mongo filter of the transactions in the blocks from start to end
+func (f *TxFilter) selector(start, end *uint64) (bson.M, error) {
+	filter := bson.M{}
+	if f.byAddress() {
+		if f.From != "" {
+			filter["from"] = f.From
+		}
+		if f.To != "" {
+			filter["to"] = f.To
+		}
+		if f.Contract != "" && f.To == "" {
+			filter["$or"] = []bson.M{{"to": f.Contract}, {"contractAddress": f.Contract}}
+		} else if f.Contract != "" && f.To != f.Contract {
+			// a transaction to an account is not to another contract, and no creation has a receiver
+			return nil, errEmptyTxFilter
+		}
+	} else {
+		filter["shardNumber"] = f.ShardNumber
+	}
+
+	if start != nil || end != nil {
+		blocks := bson.M{}
+		if start != nil {
+			blocks["$gte"] = *start
+		}
+		if end != nil {
+			blocks["$lte"] = *end
+		}
+		filter["block"] = blocks
+	}
+	if cond := int64Range(f.StartTime, f.EndTime); cond != nil && f.byAddress() {
+		// a listing of a shard has the time range converted to the blocks
+		filter["timestamp"] = cond
+	}
+	if cond := int64Range(f.MinAmount, f.MaxAmount); cond != nil {
+		filter["amount"] = cond
+	}
+	if cond := int64Range(f.MinFee, f.MaxFee); cond != nil {
+		filter["fee"] = cond
+	}
+
+	noPayload := []string{"", "0x"}
+	switch f.Kind {
+	case "":
+	case TxKindTransfer:
+		filter["txtype"] = bson.M{"$nin": []int{1}}
+		filter["payload"] = bson.M{"$in": noPayload}
+	case TxKindCreate:
+		filter["txtype"] = bson.M{"$in": []int{1}}
+	case TxKindCall:
+		filter["txtype"] = bson.M{"$nin": []int{1}}
+		filter["payload"] = bson.M{"$nin": noPayload}
+	default:
+		return nil, ErrInvalidTxKind
+	}
+	if f.Failed != nil {
+		// the transactions whose receipts are not backfilled yet by scan backfill receipts are not failed
+		if *f.Failed {
+			filter["receipt.failed"] = bson.M{"$in": []bool{true}}
+		} else {
+			filter["receipt.failed"] = bson.M{"$nin": []bool{true}}
+		}
+	}
+	return filter, nil
+}
+
+// errEmptyTxFilter is returned by the selector of a filter which no transaction matches
+var errEmptyTxFilter = errors.New("no transaction matches the filter")
+
+// blocksOfTime return the first and the last block of the shard in the time range, the blocks are
+// in the order of their time. ok is false if no block is in the range.
+// index: block {shardNumber, timestamp}
+func (c *Client) blocksOfTime(shardNumber int, startTime, endTime *int64) (start, end *uint64, ok bool, err error) {
+	bound := func(cond bson.M, sort string) (*uint64, error) {
+		var block DBBlock
+		query := func(c collection) error {
+			return c.Find(bson.M{"shardNumber": shardNumber, "timestamp": cond}).Sort(sort).Limit(1).One(&block)
+		}
+		err := c.withCollection(blockTbl, query)
+		if err != nil {
+			return nil, err
+		}
+		height := uint64(block.Height)
+		return &height, nil
+	}
+
+	if startTime != nil {
+		cond := bson.M{"$gte": *startTime}
+		if endTime != nil {
+			cond["$lte"] = *endTime
+		}
+		if start, err = bound(cond, "timestamp"); err != nil {
+			if err == mgo.ErrNotFound {
+				return nil, nil, false, nil
+			}
+			return nil, nil, false, err
+		}
+	}
+	if endTime != nil {
+		cond := bson.M{"$lte": *endTime}
+		if startTime != nil {
+			cond["$gte"] = *startTime
+		}
+		if end, err = bound(cond, "-timestamp"); err != nil {
+			if err == mgo.ErrNotFound {
+				return nil, nil, false, nil
+			}
+			return nil, nil, false, err
+		}
+	}
+	return start, end, true, nil
+}
+
+// GetTxsByFilter get a page of the transactions selected by the filter, the latest first. The
+// listing of an address is in the order of its index, the listing of a shard is in the order of
+// the shard index, and its time range is converted to the blocks of the time
+// index: transaction {shardNumber, block, idx}, {from, block, idx}, {to, block, idx} and {contractAddress, block, idx}
+func (c *Client) GetTxsByFilter(filter *TxFilter, cursor string, limit int) ([]*DBTx, *Page, error) {
+	var trans []*DBTx
+	start, end := filter.StartBlock, filter.EndBlock
+	if !filter.byAddress() && (filter.StartTime != nil || filter.EndTime != nil) {
+		startOfTime, endOfTime, ok, err := c.blocksOfTime(filter.ShardNumber, filter.StartTime, filter.EndTime)
+		if err != nil {
+			return nil, nil, err
+		}
+		if !ok {
+			return trans, &Page{}, nil
+		}
+		if startOfTime != nil && (start == nil || *startOfTime > *start) {
+			start = startOfTime
+		}
+		if endOfTime != nil && (end == nil || *endOfTime < *end) {
+			end = endOfTime
+		}
+	}
+	if start != nil && end != nil && *start > *end {
+		return trans, &Page{}, nil
+	}
+	if !filter.byAddress() && filter.residual() && (start == nil || end == nil || *end-*start >= MaxTxFilterBlocks) {
+		return nil, nil, ErrUnboundedTxFilter
+	}
+
+	selector, err := filter.selector(start, end)
+	if err == errEmptyTxFilter {
+		return trans, &Page{}, nil
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	page, err := c.listByCursor(txTbl, selector, []string{"-block", "-idx"}, cursor, limit, &trans)
+	return trans, page, err
+}
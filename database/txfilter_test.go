@@ -0,0 +1,98 @@
+/**
+*  @file
+*  @copyright defined in scan-api/LICENSE
+ */
+
+package database
+
+import (
+	"testing"
+
+	"github.com/seeleteam/scan-api/rpc"
+	"github.com/stretchr/testify/assert"
+)
+
+func newFilterClient(t *testing.T) *Client {
+	c := NewMemoryClient(1)
+	for height := int64(1); height <= 4; height++ {
+		assert.Nil(t, c.AddBlock(&DBBlock{HeadHash: "0x0b", Height: height, ShardNumber: 1, Timestamp: 100 * height}))
+	}
+	assert.Nil(t, c.AddTxs(
+		&DBTx{Hash: "0x0a", From: "0x01", To: "0x02", Amount: 10, Fee: 1, Block: 1, Idx: 1, ShardNumber: 1, Timestamp: 100},
+		&DBTx{Hash: "0x0b", From: "0x01", TxType: 1, ContractAddress: "0x0c", Payload: "0x6060", Fee: 5, Block: 2, Idx: 2, ShardNumber: 1, Timestamp: 200},
+		&DBTx{Hash: "0x0c", From: "0x02", To: "0x0c", Payload: "0xa9059cbb", Fee: 3, Block: 3, Idx: 3, ShardNumber: 1, Timestamp: 300,
+			Receipt: rpc.Receipt{Failed: true}},
+		&DBTx{Hash: "0x0d", From: "0x01", To: "0x0c", Amount: 20, Payload: "0xa9059cbb", Fee: 3, Block: 4, Idx: 4, ShardNumber: 1, Timestamp: 400},
+		&DBTx{Hash: "0x0e", From: "0x03", To: "0x01", Amount: 30, Fee: 1, Block: 4, Idx: 5, ShardNumber: 2, Timestamp: 400},
+	))
+	return c
+}
+
+func filteredHashes(t *testing.T, c *Client, filter *TxFilter) []string {
+	txs, _, err := c.GetTxsByFilter(filter, "", 10)
+	assert.Nil(t, err)
+	var hashes []string
+	for _, tx := range txs {
+		hashes = append(hashes, tx.Hash)
+	}
+	return hashes
+}
+
+func Test_GetTxsByFilter(t *testing.T) {
+	c := newFilterClient(t)
+	var shapes []queryShape
+	c.mem.observer = func(shape queryShape) {
+		shapes = append(shapes, shape)
+	}
+	i64 := func(n int64) *int64 { return &n }
+	u64 := func(n uint64) *uint64 { return &n }
+	yes, no := true, false
+
+	// an address is listed in all the shards
+	assert.Equal(t, filteredHashes(t, c, &TxFilter{ShardNumber: 1, From: "0x01"}), []string{"0x0d", "0x0b", "0x0a"})
+	assert.Equal(t, filteredHashes(t, c, &TxFilter{ShardNumber: 1, To: "0x01"}), []string{"0x0e"})
+	assert.Equal(t, filteredHashes(t, c, &TxFilter{Contract: "0x0c"}), []string{"0x0d", "0x0c", "0x0b"})
+	assert.Equal(t, filteredHashes(t, c, &TxFilter{From: "0x01", Contract: "0x0c", Kind: TxKindCall}), []string{"0x0d"})
+	assert.Empty(t, filteredHashes(t, c, &TxFilter{To: "0x02", Contract: "0x0c"}))
+	assert.Equal(t, filteredHashes(t, c, &TxFilter{From: "0x01", MinAmount: i64(10), MaxAmount: i64(15)}), []string{"0x0a"})
+	assert.Equal(t, filteredHashes(t, c, &TxFilter{From: "0x01", StartTime: i64(150), EndTime: i64(400)}), []string{"0x0d", "0x0b"})
+	assert.Equal(t, filteredHashes(t, c, &TxFilter{From: "0x01", Kind: TxKindCreate}), []string{"0x0b"})
+	assert.Equal(t, filteredHashes(t, c, &TxFilter{From: "0x01", Kind: TxKindTransfer}), []string{"0x0a"})
+
+	// a shard is listed in a bounded block range
+	assert.Equal(t, filteredHashes(t, c, &TxFilter{ShardNumber: 1, StartBlock: u64(2), EndBlock: u64(3)}), []string{"0x0c", "0x0b"})
+	assert.Equal(t, filteredHashes(t, c, &TxFilter{ShardNumber: 1, StartTime: i64(150), EndTime: i64(350)}), []string{"0x0c", "0x0b"})
+	assert.Equal(t, filteredHashes(t, c, &TxFilter{ShardNumber: 1, StartTime: i64(150), EndBlock: u64(4), Failed: &yes}), []string{"0x0c"})
+	assert.Equal(t, filteredHashes(t, c, &TxFilter{ShardNumber: 1, StartBlock: u64(1), EndBlock: u64(4), Failed: &no, MinFee: i64(3)}), []string{"0x0d", "0x0b"})
+	assert.Empty(t, filteredHashes(t, c, &TxFilter{ShardNumber: 1, StartTime: i64(410)}))
+	assert.Empty(t, filteredHashes(t, c, &TxFilter{ShardNumber: 1, StartBlock: u64(3), EndBlock: u64(2)}))
+
+	// a filter which would scan the shard is refused
+	for _, filter := range []*TxFilter{
+		{ShardNumber: 1, MinAmount: i64(1)},
+		{ShardNumber: 1, StartTime: i64(100), Kind: TxKindCall},
+		{ShardNumber: 1, StartBlock: u64(1), EndBlock: u64(MaxTxFilterBlocks + 1), Failed: &yes},
+	} {
+		_, _, err := c.GetTxsByFilter(filter, "", 10)
+		assert.Equal(t, err, ErrUnboundedTxFilter)
+	}
+	_, _, err := c.GetTxsByFilter(&TxFilter{From: "0x01", Kind: "swap"}, "", 10)
+	assert.Equal(t, err, ErrInvalidTxKind)
+
+	// the pages follow the stable order
+	txs, page, err := c.GetTxsByFilter(&TxFilter{From: "0x01"}, "", 2)
+	assert.Nil(t, err)
+	assert.Equal(t, len(txs), 2)
+	txs, _, err = c.GetTxsByFilter(&TxFilter{From: "0x01"}, page.Next, 2)
+	assert.Nil(t, err)
+	assert.Equal(t, txs[0].Hash, "0x0a")
+
+	for _, shape := range shapes {
+		covered := false
+		for _, index := range collectionIndexes[shape.Collection] {
+			covered = covered || shape.coveredBy(index)
+		}
+		assert.True(t, covered, "query %+v is not covered by the declared indexes", shape)
+	}
+	assert.NotEmpty(t, shapes)
+}
@@ -6,9 +6,13 @@
 package database
 
 import (
+	"encoding/hex"
+	"errors"
 	"strconv"
+	"strings"
 	"time"
 
+	"github.com/seeleteam/scan-api/common"
 	"github.com/seeleteam/scan-api/rpc"
 )
 
@@ -45,7 +49,14 @@ type DBBlock struct {
 	Creator         string                `bson:"creator"`
 	Nonce           string                `bson:"nonce"`
 	TxHash          string                `bson:"txHash"`
-	Reward          int64                 `bson:"reward"`
+	ReceiptHash     string                `bson:"receiptHash"`
+	TxDebtHash      string                `bson:"txDebtHash"`
+	DebtHash        string                `bson:"debtHash"`
+	ExtraData       string                `bson:"extraData"`
+	Pool            string                `bson:"pool,omitempty"`
+	Reward          int64                 `bson:"reward"`  // base reward paid by the coinbase tx
+	TxFee           int64                 `bson:"txFee"`   // miner's share of the tx fees
+	DebtFee         int64                 `bson:"debtFee"` // miner's share of the debt fees
 	UsedGas         int64                 `bson:"usedGas"`
 	Txs             []DBSimpleTxInBlock   `bson:"transactions"`
 	Debts           []DBSimpleDebtInBlock `bson:"debt"`
@@ -86,7 +97,7 @@ type DBTx struct {
 	To              string      `bson:"to"`
 	Amount          int64       `bson:"amount"`
 	AccountNonce    string      `bson:"accountNonce"`
-	Timestamp       string      `bson:"timestamp"`
+	Timestamp       int64       `bson:"timestamp"`
 	Timetxs         string      `bson:"timetxs"`
 	Payload         string      `bson:"payload"`
 	Block           uint64      `bson:"block"`
@@ -111,6 +122,28 @@ type DBAccount struct {
 	TimeStamp   int64  `bson:"timestamp"`
 	SourceCode  string `bson:"sourceCode"`
 	ABI         string `bson:"abi"`
+
+	// contract creation provenance, only set for contract accounts
+	Creator       string `bson:"creator,omitempty"`
+	CreationTx    string `bson:"creationTx,omitempty"`
+	CreationBlock uint64 `bson:"creationBlock,omitempty"`
+	CodeHash      string `bson:"codeHash,omitempty"`
+}
+
+// CodeHash return the keccak256 hash of the hex encoded runtime bytecode, empty for no code
+func CodeHash(code string) string {
+	bytecode, err := hex.DecodeString(strings.TrimPrefix(code, "0x"))
+	if err != nil || len(bytecode) == 0 {
+		return ""
+	}
+	return "0x" + hex.EncodeToString(common.Keccak256(bytecode))
+}
+
+//DBCodeHashGroup describle the contracts which share the same runtime bytecode
+type DBCodeHashGroup struct {
+	CodeHash  string   `bson:"_id"`
+	Count     int64    `bson:"count"`
+	Addresses []string `bson:"addresses"`
 }
 
 //DBMiner describle a miner account which stored in the database
@@ -122,6 +155,14 @@ type DBMiner struct {
 	TxFee       int64  `bson:"fee"`
 	TimeStamp   int64  `bson:"timestamp"`
 	Mined       int64  `bson:"mined"`
+	Pool        string `bson:"pool,omitempty"`
+	DebtFee     int64  `bson:"debtFee"`
+}
+
+//DBSyncState describle the sync cursor of a shard
+type DBSyncState struct {
+	ShardNumber int    `bson:"shardNumber"`
+	Height      uint64 `bson:"height"` // the height of the next block to sync
 }
 
 //CreateDbBlock convert an rpc block to an dbblock
@@ -137,6 +178,10 @@ func CreateDbBlock(b *rpc.BlockInfo) *DBBlock {
 	dbBlock.Nonce = strconv.FormatUint(b.Nonce, 10)
 	dbBlock.StateHash = b.StateHash
 	dbBlock.TxHash = b.TxHash
+	dbBlock.ReceiptHash = b.ReceiptHash
+	dbBlock.TxDebtHash = b.TxDebtHash
+	dbBlock.DebtHash = b.DebtHash
+	dbBlock.ExtraData = b.ExtraData
 	//exclude coinbase transaction
 
 	for i := 0; i < len(b.Txs); i++ {
@@ -149,18 +194,9 @@ func CreateDbBlock(b *rpc.BlockInfo) *DBBlock {
 		simpleTx.GasLimit = b.Txs[i].GasLimit
 		simpleTx.GasPrice = b.Txs[i].GasPrice
 		dbBlock.Txs = append(dbBlock.Txs, simpleTx)
-
-		//if i != len(b.Txs)-1 {
-		//	dbBlock.Reward += b.Txs[i].Fee
-		//}
-		
-		  if i != 0 {
-			dbBlock.Reward += b.Txs[i].Fee
-		}
-		
 	}
 
-	//coinbase reward
+	//coinbase reward, the fees are filled by SetBlockFees after the receipts are fetched
 	if len(b.Txs) > 0 {
 		//tx := b.Txs[len(b.Txs)-1]
 		tx := b.Txs[0]
@@ -194,6 +230,35 @@ func CreateDbBlock(b *rpc.BlockInfo) *DBBlock {
 	return &dbBlock
 }
 
+//SetBlockFees calculate the miner's share of the tx fees and debt fees, the fees of the txs and
+//debts should be filled from the receipts before. A cross shard tx pays one third of its fee to
+//the miner of the source shard and the other two thirds to the miner who packs its debt.
+func (b *DBBlock) SetBlockFees() {
+	txDebtsTo := make(map[string]bool)
+	for i := 0; i < len(b.TxDebts); i++ {
+		txDebtsTo[b.TxDebts[i].Account] = true
+	}
+
+	b.TxFee = 0
+	for i := 0; i < len(b.Txs); i++ {
+		if txDebtsTo[b.Txs[i].To] {
+			b.TxFee += b.Txs[i].Fee / 3
+		} else {
+			b.TxFee += b.Txs[i].Fee
+		}
+	}
+
+	b.DebtFee = 0
+	for i := 0; i < len(b.Debts); i++ {
+		b.DebtFee += b.Debts[i].Fee * 2 / 3
+	}
+}
+
+//Revenue return the total revenue of the block for the miner
+func (b *DBBlock) Revenue() int64 {
+	return b.Reward + b.TxFee + b.DebtFee
+}
+
 //CreateDbTx convert an rpc transaction to an dbtransaction
 func CreateDbTx(t rpc.Transaction) *DBTx {
 	var trans DBTx
@@ -205,7 +270,7 @@ func CreateDbTx(t rpc.Transaction) *DBTx {
 	trans.Amount = t.Amount.Int64()
 	timetxs := time.Unix(int64(t.Timestamp), 0).UTC()
 	trans.Timetxs = timetxs.Format("2006-01-02")
-	trans.Timestamp = strconv.FormatUint(t.Timestamp, 10)
+	trans.Timestamp = int64(t.Timestamp)
 	trans.AccountNonce = strconv.FormatUint(t.AccountNonce, 10)
 	trans.Payload = t.Payload
 	trans.Block = t.Block
@@ -225,10 +290,113 @@ func CreateDebtTx(t rpc.Debt) *Debt {
 	debts.Amount = t.Amount.Int64()
 	debts.Payload = t.Payload
 	debts.Height = t.Block
+	debts.Idx = t.Idx
 	debts.Fee = t.Fee
 	return &debts
 }
 
+// the kinds of the address activity
+const (
+	ActivitySent     = "sent"
+	ActivityReceived = "received"
+	ActivityCreated  = "created"
+	ActivityDebtIn   = "debt-in"
+	ActivityReward   = "reward"
+)
+
+// the directions of the address activity, the activities of all the directions are listed with an empty direction
+const (
+	ActivityIn  = "in"
+	ActivityOut = "out"
+)
+
+// ErrInvalidDirection is returned for an unknown direction of the address activity
+var ErrInvalidDirection = errors.New("invalid activity direction")
+
+// coinbaseAddress is the sender of the block reward transactions
+const coinbaseAddress = "0x0000000000000000000000000000000000000000"
+
+//DBAddressActivity describle an entry of the activity of an address, a transaction or a debt
+//has an entry for every address it involves
+type DBAddressActivity struct {
+	Address      string `bson:"address"`
+	ShardNumber  int    `bson:"shardNumber"`
+	Kind         string `bson:"kind"`
+	Counterparty string `bson:"counterparty"`
+	Amount       int64  `bson:"amount"`
+	Fee          int64  `bson:"fee"`
+	TxType       int    `bson:"txtype"`
+	Hash         string `bson:"hash"` // hash of the transaction or the debt
+	Block        uint64 `bson:"block"`
+	Idx          int64  `bson:"idx"`
+	Timestamp    int64  `bson:"timestamp"`
+	Failed       bool   `bson:"failed,omitempty"` // the transaction failed, only the fee is paid
+}
+
+// In return whether the activity moves coins into the address
+func (a *DBAddressActivity) In() bool {
+	return a.Kind != ActivitySent
+}
+
+func newActivity(tx *DBTx, address string, kind string, counterparty string) *DBAddressActivity {
+	return &DBAddressActivity{
+		Address:      address,
+		ShardNumber:  tx.ShardNumber,
+		Kind:         kind,
+		Counterparty: counterparty,
+		Amount:       tx.Amount,
+		Fee:          tx.Fee,
+		TxType:       tx.TxType,
+		Hash:         tx.Hash,
+		Block:        tx.Block,
+		Idx:          tx.Idx,
+		Timestamp:    tx.Timestamp,
+		Failed:       tx.Receipt.Failed,
+	}
+}
+
+//CreateTxActivities create the activity entries of a stored transaction. The receiver of
+//a cross-shard transaction has the debt-in entry in its own shard instead of a received entry.
+func CreateTxActivities(tx *DBTx) []*DBAddressActivity {
+	var activities []*DBAddressActivity
+	add := func(address string, kind string, counterparty string) {
+		if address != "" {
+			activities = append(activities, newActivity(tx, address, kind, counterparty))
+		}
+	}
+
+	switch {
+	case tx.From == coinbaseAddress:
+		add(tx.To, ActivityReward, tx.From)
+	case tx.To == "":
+		add(tx.From, ActivitySent, tx.ContractAddress)
+		add(tx.ContractAddress, ActivityCreated, tx.From)
+	default:
+		add(tx.From, ActivitySent, tx.To)
+		if tx.DebtTxHash == "" {
+			add(tx.To, ActivityReceived, tx.From)
+		}
+	}
+	return activities
+}
+
+//CreateDebtActivity create the activity entry of the receiver of a debt,
+//from is the sender of the transaction of the debt
+func CreateDebtActivity(debt *Debt, from string, timestamp int64) *DBAddressActivity {
+	return &DBAddressActivity{
+		Address:      debt.To,
+		ShardNumber:  debt.ShardNumber,
+		Kind:         ActivityDebtIn,
+		Counterparty: from,
+		Amount:       debt.Amount,
+		Fee:          debt.Fee,
+		Hash:         debt.Hash,
+		Block:        debt.Height,
+		Idx:          int64(debt.Idx),
+		Timestamp:    timestamp,
+	}
+}
+
 //CreateEmptyAccount create an empty dbaccount
 func CreateEmptyAccount(address string, shardNumber int) *DBAccount {
 	return &DBAccount{
@@ -274,6 +442,42 @@ type DBOneDayBlockInfo struct {
 	ShardNumber int   `bson:"shardnumber"`
 }
 
+//DBOneDayMinerRevenue describle the blocks and revenue of an miner in an single day
+type DBOneDayMinerRevenue struct {
+	Address     string `bson:"address"`
+	Pool        string `bson:"pool,omitempty"`
+	Blocks      int64  `bson:"blocks"`
+	Reward      int64  `bson:"reward"`
+	TxFee       int64  `bson:"txFee"`
+	DebtFee     int64  `bson:"debtFee"`
+	Revenue     int64  `bson:"revenue"`
+	TimeStamp   int64  `bson:"timestamp"`
+	ShardNumber int    `bson:"shardnumber"`
+}
+
+//DBSupply describle the coin supply of an shard, the genesis allocations plus the block rewards
+type DBSupply struct {
+	ShardNumber int   `bson:"shardNumber"`
+	Genesis     int64 `bson:"genesis"`
+	Minted      int64 `bson:"minted"`
+	Fees        int64 `bson:"fees"`
+	Blocks      int64 `bson:"blocks"`
+}
+
+//Total return the total supply of the shard
+func (s *DBSupply) Total() int64 {
+	return s.Genesis + s.Minted
+}
+
+//DBOneDaySupply describle the coins minted and the fees paid of an shard in an single day
+type DBOneDaySupply struct {
+	Minted      int64 `bson:"minted"`
+	Fees        int64 `bson:"fees"`
+	Blocks      int64 `bson:"blocks"`
+	TimeStamp   int64 `bson:"timestamp"`
+	ShardNumber int   `bson:"shardnumber"`
+}
+
 //DBOneDayAddressInfo describle all blocks in an single day
 type DBOneDayAddressInfo struct {
 	TotalAddresss int64 `bson:"totaladdresss"`
@@ -292,6 +496,7 @@ type DBOneDaySingleAddressInfo struct {
 //DBSingleMinerRankInfo describle single miner rank info
 type DBSingleMinerRankInfo struct {
 	Address    string  `bson:"address"`
+	Pool       string  `bson:"pool,omitempty"`
 	Mined      int     `bson:"mined"`
 	Percentage float64 `bson:"percentage"`
 }
@@ -324,3 +529,65 @@ type DBLastBlock struct {
 	Timestamp   int64 `bson:"timestamp"`
 	TxNumber    int   `bson:"txNumber"`
 }
+
+//DBStat describle a counter of the documents of a shard or of an address, it is maintained
+//in the write path of the documents instead of counting them on every query
+type DBStat struct {
+	Name        string `bson:"name"`
+	ShardNumber int    `bson:"shardNumber"`
+	Address     string `bson:"address"` // empty for the counters of a shard
+	Count       int64  `bson:"count"`
+}
+
+//DBSchema describle the schema version of the database and the progress of the running migration
+type DBSchema struct {
+	ID      string                 `bson:"_id"`
+	Version int                    `bson:"version"`
+	Target  int                    `bson:"target"`  // the version the running migration moves to, equal to Version if none is running
+	Cursors map[string]interface{} `bson:"cursors"` // the _id of the last migrated document of each batch
+}
+
+//DBBackfill describle the progress of the receipt backfill of a shard
+type DBBackfill struct {
+	ShardNumber int           `bson:"shardNumber"`
+	Last        []interface{} `bson:"last"`    // the block and idx of the last scanned transaction
+	Scanned     int64         `bson:"scanned"` // the transactions scanned
+	Filled      int64         `bson:"filled"`  // the receipts fetched from the node
+	Missing     int64         `bson:"missing"` // the receipts the node failed to return
+	Flagged     int64         `bson:"flagged"` // the failed transactions whose activities are flagged
+	Done        bool          `bson:"done"`
+}
+
+//DBAPIKey describle an api key of scan_server, the tier selects its rate and its daily quota
+type DBAPIKey struct {
+	Key        string `bson:"key"`
+	Name       string `bson:"name"` // the owner of the key
+	Tier       string `bson:"tier"`
+	Admin      bool   `bson:"admin"` // an admin key reads the usage of all the keys
+	Disabled   bool   `bson:"disabled"`
+	CreateTime int64  `bson:"createTime"`
+}
+
+//DBAPIUsage describle the requests of an api key on a day, the requests of the anonymous
+//callers are counted under one key
+type DBAPIUsage struct {
+	Key      string `bson:"key"`
+	Day      string `bson:"day"` // the utc day, such as 2018-10-19
+	Requests int64  `bson:"requests"`
+	Limited  int64  `bson:"limited"` // the requests refused by the rate or by the quota
+}
+
+// the kinds of the labels of the addresses
+const (
+	LabelName     = "label"    // a name given to an address by the operators
+	LabelToken    = "token"    // the symbol of a token contract
+	LabelContract = "contract" // the name of a verified contract
+)
+
+//DBLabel describle a name of an address which is searched, an address has a label of every kind at most
+type DBLabel struct {
+	Address string `bson:"address"`
+	Kind    string `bson:"kind"`
+	Name    string `bson:"name"`
+	Key     string `bson:"key"` // the lowercase name, which is searched by prefix
+}
@@ -30,19 +30,21 @@ func TestCreateDbBlock(t *testing.T) {
 	assert.Equal(t, block.Timestamp, int64(1539050098))
 	assert.Equal(t, block.Difficulty, big.NewInt(6563003).String())
 	assert.Equal(t, block.TotalDifficulty, big.NewInt(68985339754).String())
+	assert.Equal(t, block.Reward, int64(150000000))
 
+	// the transactions of the block take its timestamp
 	assert.Equal(t, len(block.Txs), 2)
 	for _, tx := range block.Txs {
 		if tx.Hash == "0x6fb17b265260caed33b4e8f58ad84b508dd8950b9bc93dae8518fc96912f76bb" {
 			assert.Equal(t, tx.From, "0x0000000000000000000000000000000000000000")
 			assert.Equal(t, tx.To, "0xd5a145191b7ca9cb4f3dc850e426c1e853d2a9f1")
 			assert.Equal(t, tx.Amount, int64(150000000))
-			assert.Equal(t, tx.Timestamp, strconv.Itoa(1539931510))
+			assert.Equal(t, tx.Timestamp, strconv.FormatInt(block.Timestamp, 10))
 		} else if tx.Hash == "0xf526dc404145cd409601e951fec4f2222f3abf578381cdaaea9db3a791a79cbd" {
 			assert.Equal(t, tx.From, "0xec759db47a65f6537d630517f6cd3ca39c6f93d1")
 			assert.Equal(t, tx.To, "0xa00d22dc3624d4696eff8d1641b442f79c3379b1")
 			assert.Equal(t, tx.Amount, int64(10000))
-			assert.Equal(t, tx.Timestamp, strconv.Itoa(0))
+			assert.Equal(t, tx.Timestamp, strconv.FormatInt(block.Timestamp, 10))
 		} else {
 			assert.Equal(t, tx.Hash, "")
 		}
@@ -74,3 +76,52 @@ func TestCreateDbBlock(t *testing.T) {
 		}
 	}
 }
+
+func TestSetBlockFees(t *testing.T) {
+	block := &DBBlock{
+		Reward: 150000000,
+		Txs: []DBSimpleTxInBlock{
+			{To: "0xd5a145191b7ca9cb4f3dc850e426c1e853d2a9f1", Fee: 0},
+			{To: "0xa00d22dc3624d4696eff8d1641b442f79c3379b1", Fee: 21000},
+			{To: "0x0ea2a45ab5a909c309439b0e004c61b7b2a3e831", Fee: 30000},
+		},
+		Debts:   []DBSimpleDebtInBlock{{Account: "0x0ea2a45ab5a909c309439b0e004c61b7b2a3e832", Fee: 30000}},
+		TxDebts: []DBSimpleDebtInBlock{{Account: "0x0ea2a45ab5a909c309439b0e004c61b7b2a3e831", Fee: 30000}},
+	}
+
+	block.SetBlockFees()
+	assert.Equal(t, block.TxFee, int64(21000+10000))
+	assert.Equal(t, block.DebtFee, int64(20000))
+	assert.Equal(t, block.Revenue(), int64(150000000+31000+20000))
+
+	// calculate again should not accumulate the fees
+	block.SetBlockFees()
+	assert.Equal(t, block.TxFee, int64(31000))
+}
+
+func TestCreateTxActivities(t *testing.T) {
+	kinds := func(activities []*DBAddressActivity) []string {
+		var ret []string
+		for _, a := range activities {
+			ret = append(ret, a.Address+" "+a.Kind+" "+a.Counterparty)
+		}
+		return ret
+	}
+
+	assert.Equal(t, kinds(CreateTxActivities(&DBTx{From: coinbaseAddress, To: "0x01"})),
+		[]string{"0x01 reward " + coinbaseAddress})
+	assert.Equal(t, kinds(CreateTxActivities(&DBTx{From: "0x01", To: "0x02"})),
+		[]string{"0x01 sent 0x02", "0x02 received 0x01"})
+	assert.Equal(t, kinds(CreateTxActivities(&DBTx{From: "0x01", ContractAddress: "0x03"})),
+		[]string{"0x01 sent 0x03", "0x03 created 0x01"})
+	// the receiver of a cross-shard transaction has a debt-in entry in its shard
+	assert.Equal(t, kinds(CreateTxActivities(&DBTx{From: "0x01", To: "0x02", DebtTxHash: "0x0d"})),
+		[]string{"0x01 sent 0x02"})
+
+	debt := &Debt{Hash: "0x0d", To: "0x02", Height: 5, Idx: 2, ShardNumber: 2, Amount: 10}
+	activity := CreateDebtActivity(debt, "0x01", 1539931510)
+	assert.Equal(t, activity.Kind, ActivityDebtIn)
+	assert.Equal(t, activity.Counterparty, "0x01")
+	assert.Equal(t, activity.Block, uint64(5))
+	assert.True(t, activity.In())
+}
@@ -49,6 +49,10 @@ type BlockInfo struct {
 	Creator         string        `json:"creator"`
 	Nonce           uint64        `json:"nonce"`
 	TxHash          string        `json:"txHash"`
+	ReceiptHash     string        `json:"receiptHash"`
+	TxDebtHash      string        `json:"txDebtHash"`
+	DebtHash        string        `json:"debtHash"`
+	ExtraData       string        `json:"extraData"`
 	Txs             []Transaction `json:"txs"`
 	Debts           []Debt        `json:"debts"`
 	TxDebts         []TxDebt      `json:"txDebts"`
@@ -6,10 +6,12 @@
 package rpc
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
 	"github.com/seeleteam/scan-api/log"
 	"math/big"
+	"strconv"
 )
 
 // CurrentBlockHeight gets the current blockchain height
@@ -31,8 +33,8 @@ func (rpc *SeeleRPC) GetBlockByHeight(h uint64, fullTx bool) (block *BlockInfo,
 	var req []interface{}
 	req = append(req, request.Height)
 	req = append(req, request.FullTx)
-	rpcOutputBlock := make(map[string]interface{})
-	if err := rpc.call("seele_getBlockByHeight", req, &rpcOutputBlock); err != nil {
+	var rawBlock json.RawMessage
+	if err := rpc.call("seele_getBlockByHeight", req, &rawBlock); err != nil {
 		return nil, err
 	}
 
@@ -108,12 +110,46 @@ func (rpc *SeeleRPC) GetBlockByHeight(h uint64, fullTx bool) (block *BlockInfo,
 	//       ]
 	//     ]
 	//   ]
+	return parseBlock(rawBlock, fullTx)
+}
+
+// parseBlock parse the raw block json to BlockInfo
+func parseBlock(rawBlock json.RawMessage, fullTx bool) (*BlockInfo, error) {
+	rpcOutputBlock := make(map[string]interface{})
+	json.Unmarshal(rawBlock, &rpcOutputBlock)
 	if rpcOutputBlock == nil || rpcOutputBlock["header"] == nil{
 		var err =errors.New("seele_rpc rpcOutputBlock is nil")
 		log.Error("seele_rpc rpcOutputBlock is nil")
 		return nil, err
 	}
-	return getBlockByHeight(rpcOutputBlock, fullTx), err
+
+	// the nonce is out of the float64 precision, decode it as a number literal
+	var rawHeader struct {
+		Header struct {
+			Nonce json.Number
+		} `json:"header"`
+	}
+	if err := json.Unmarshal(rawBlock, &rawHeader); err == nil && rawHeader.Header.Nonce != "" {
+		rpcOutputBlock["header"].(map[string]interface{})["Nonce"] = rawHeader.Header.Nonce
+	}
+
+	return getBlockByHeight(rpcOutputBlock, fullTx), nil
+}
+
+// getNonce convert the header nonce to uint64
+func getNonce(nonce interface{}) uint64 {
+	switch v := nonce.(type) {
+	case json.Number:
+		n, err := strconv.ParseUint(v.String(), 10, 64)
+		if err == nil {
+			return n
+		}
+		f, _ := v.Float64()
+		return uint64(f)
+	case float64:
+		return uint64(v)
+	}
+	return 0
 }
 
 // getBlockByHeight parse block map to BlockInfo
@@ -124,6 +160,11 @@ func getBlockByHeight(rpcOutputBlock map[string]interface{}, fullTx bool) *Block
 	parentHash := headerMp["PreviousBlockHash"].(string)
 	stateHash := headerMp["StateHash"].(string)
 	txHash := headerMp["TxHash"].(string)
+	receiptHash, _ := headerMp["ReceiptHash"].(string)
+	txDebtHash, _ := headerMp["TxDebtHash"].(string)
+	debtHash, _ := headerMp["DebtHash"].(string)
+	extraData, _ := headerMp["ExtraData"].(string)
+	nonce := getNonce(headerMp["Nonce"])
 	creator := headerMp["Creator"].(string)
 	timestamp := int64(headerMp["CreateTimestamp"].(float64))
 	difficulty := int64(headerMp["Difficulty"].(float64))
@@ -144,6 +185,9 @@ func getBlockByHeight(rpcOutputBlock map[string]interface{}, fullTx bool) *Block
 			tx.Payload = rpcTx["payload"].(string)
 			tx.GasLimit = int64(rpcTx["gasLimit"].(float64))
 			tx.GasPrice = int64(rpcTx["gasPrice"].(float64))
+			if timestamp, ok := rpcTx["timestamp"].(float64); ok {
+				tx.Timestamp = uint64(timestamp)
+			}
 			Txs = append(Txs, tx)
 		}
 	}
@@ -160,11 +204,15 @@ func getBlockByHeight(rpcOutputBlock map[string]interface{}, fullTx bool) *Block
 			de.Hash = rpcDebtinfo["Hash"].(string)
 			de.Block = height
 			de.To = dbets["Account"].(string)
-			//de.ShardNumber = int(dbets["Shard"].(float64))
+			if shard, ok := dbets["Shard"].(float64); ok {
+				de.ShardNumber = int(shard)
+			}
 			amount := int64(dbets["Amount"].(float64))
 			de.Amount = big.NewInt(amount)
 			de.Payload = dbets["Code"].(string)
-			//de.Fee = int64(dbets["Fee"].(float64))
+			if fee, ok := dbets["Fee"].(float64); ok {
+				de.Fee = int64(fee)
+			}
 			Debts = append(Debts, de)
 		}
 	}
@@ -180,11 +228,15 @@ func getBlockByHeight(rpcOutputBlock map[string]interface{}, fullTx bool) *Block
 			txDebt.Hash = rpcDebtinfo["Hash"].(string)
 			txDebt.TxHash = txdbets["TxHash"].(string)
 			txDebt.To = txdbets["Account"].(string)
-			//txDebt.ShardNumber = int(txdbets["Shard"].(float64))
+			if shard, ok := txdbets["Shard"].(float64); ok {
+				txDebt.ShardNumber = int(shard)
+			}
 			amount := int64(txdbets["Amount"].(float64))
 			txDebt.Amount = big.NewInt(amount)
 			txDebt.Payload = txdbets["Code"].(string)
-			//txDebt.Fee = int64(txdbets["Fee"].(float64))
+			if fee, ok := txdbets["Fee"].(float64); ok {
+				txDebt.Fee = int64(fee)
+			}
 			TxDebts = append(TxDebts, txDebt)
 		}
 	}
@@ -195,6 +247,11 @@ func getBlockByHeight(rpcOutputBlock map[string]interface{}, fullTx bool) *Block
 		ParentHash:      parentHash,
 		StateHash:       stateHash,
 		TxHash:          txHash,
+		ReceiptHash:     receiptHash,
+		TxDebtHash:      txDebtHash,
+		DebtHash:        debtHash,
+		ExtraData:       extraData,
+		Nonce:           nonce,
 		Creator:         creator,
 		Timestamp:       big.NewInt(timestamp),
 		Difficulty:      big.NewInt(difficulty),
@@ -376,8 +433,38 @@ func getPendingTransactions(txsMp []map[string]interface{}) []Transaction {
 		tx.Payload = rpcTx["payload"].(string)
 		tx.GasLimit = int64(rpcTx["gasLimit"].(float64))
 		tx.GasPrice = int64(rpcTx["gasPrice"].(float64))
+		if timestamp, ok := rpcTx["timestamp"].(float64); ok {
+			tx.Timestamp = uint64(timestamp)
+		}
 		Txs = append(Txs, tx)
 	}
 
 	return Txs
 }
+
+// Call executes a read-only contract call on the node at the given height, -1 for the latest block
+func (rpc *SeeleRPC) Call(contract, payload string, height int64) (*Receipt, error) {
+	receiptMp := make(map[string]interface{})
+	var request []interface{}
+	request = append(request, contract, payload, height)
+	if err := rpc.call("seele_call", request, &receiptMp); err != nil {
+		return nil, err
+	}
+
+	// result data struct is the same as seele_getReceiptByTxHash,
+	// the call output is returned as the receipt result
+
+	return getReceiptByTxHash(receiptMp), nil
+}
+
+// GetCode get the runtime bytecode of the contract at the given height, -1 for the latest block
+func (rpc *SeeleRPC) GetCode(contract string, height int64) (string, error) {
+	var code string
+	var request []interface{}
+	request = append(request, contract, height)
+	if err := rpc.call("seele_getCode", request, &code); err != nil {
+		return "", err
+	}
+
+	return code, nil
+}
@@ -303,3 +303,35 @@ func TestGetBlockByHeight(t *testing.T) {
 		}
 	}
 }
+
+func TestParseBlock(t *testing.T) {
+	blockJSON := `{
+					"debts": [],
+					"hash": "0x000002069d9de64bad509239e2a121afbf7de183576457a1d1fb077d19fa3e8c",
+					"header": {
+						"PreviousBlockHash": "0x000001cba2c0b82402b3d2d2ad49f50ca0b21aee18c8123486377b2ec93aa0e0",
+						"Creator": "0x4c10f2cd2159bb432094e3be7e17904c2b4aeb21",
+						"StateHash": "0x8af14975f636ace27571cfcdcd9a1a1b4a5b15228977cf6207e82f63abf96ffd",
+						"TxHash": "0xdb00575ff0cc0de89bd6c1799d37e5f600687963785176ca76e81bebfde6a03f",
+						"ReceiptHash": "0x02fa1d68e7bbf0b833f6e8719efb11b32c7f760e4ae050a4f9b58b8dd8ad1620",
+						"TxDebtHash": "0x58d7c36b25a715f5076ccb878940920f6bb333ab142287452509f881103960d2",
+						"DebtHash": "0x0000000000000000000000000000000000000000000000000000000000000000",
+						"Difficulty": 6563003,
+						"Height": 10368,
+						"CreateTimestamp": 1539050098,
+						"Nonce": 17825487295277268182,
+						"ExtraData": "cG9vbHkvMS4w"
+					},
+					"totalDifficulty": 68985339754,
+					"transactions": [],
+					"txDebts": []
+				}`
+
+	block, err := parseBlock(json.RawMessage(blockJSON), true)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, block.Nonce, uint64(17825487295277268182))
+	assert.Equal(t, block.ReceiptHash, "0x02fa1d68e7bbf0b833f6e8719efb11b32c7f760e4ae050a4f9b58b8dd8ad1620")
+	assert.Equal(t, block.TxDebtHash, "0x58d7c36b25a715f5076ccb878940920f6bb333ab142287452509f881103960d2")
+	assert.Equal(t, block.DebtHash, "0x0000000000000000000000000000000000000000000000000000000000000000")
+	assert.Equal(t, block.ExtraData, "cG9vbHkvMS4w")
+}
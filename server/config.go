@@ -8,6 +8,7 @@ package server
 import (
 	"time"
 
+	"github.com/seeleteam/scan-api/api/ratelimit"
 	"github.com/seeleteam/scan-api/common"
 )
 
@@ -31,4 +32,6 @@ type Config struct {
 	TransCacheLimit     int
 	DataBase            *common.DataBaseConfig
 	Interval            time.Duration
+	RPCNodes            map[int]string    // rpc address of a node for each shard
+	RateLimit           *ratelimit.Config // the tiers of the api keys, the requests are not limited without it
 }
@@ -11,6 +11,7 @@ import (
 
 	"time"
 
+	"github.com/seeleteam/scan-api/api/ratelimit"
 	"github.com/seeleteam/scan-api/api/routers"
 	"github.com/seeleteam/scan-api/database"
 	"github.com/seeleteam/scan-api/log"
@@ -77,7 +78,10 @@ func GetServer(g *errgroup.Group, config *Config) (server *ScanServer) {
 		return
 	}
 
-	router := routers.New(dbClient, dbClient, dbClient)
+	router := routers.New(dbClient, dbClient, dbClient, config.RPCNodes)
+	if config.RateLimit != nil {
+		router.Limiter = ratelimit.New(dbClient, config.RateLimit)
+	}
 	router.Init(ginHandler)
 
 	return &ScanServer{
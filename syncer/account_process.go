@@ -1,11 +1,12 @@
 package syncer
 
 import (
+	"sync"
+	"time"
+
 	"github.com/seeleteam/scan-api/database"
 	"github.com/seeleteam/scan-api/log"
 	"github.com/seeleteam/scan-api/rpc"
-	"sync"
-	"time"
 )
 
 const (
@@ -92,6 +93,7 @@ func (s *Syncer) accountSync(b *rpc.BlockInfo) error {
 			s.db.UpdateAccount(accounts)
 		}
 
+		var creation *database.DBAccount
 		if tx.To == "" {
 			//create contract transaction
 			//Get contract address from receipt
@@ -100,6 +102,7 @@ func (s *Syncer) accountSync(b *rpc.BlockInfo) error {
 				contractAddress := receipt.ContractAddress
 				address = contractAddress
 				AccType = 1
+				creation = s.getContractCreation(tx, contractAddress, b.Height)
 			}
 		} else {
 			address = tx.To // To might be another shard account for cross-shard transaction
@@ -131,6 +134,12 @@ func (s *Syncer) accountSync(b *rpc.BlockInfo) error {
 			Balance:     balance,
 			TimeStamp:   b.Timestamp.Int64(),
 		}
+		if creation != nil {
+			accounts.Creator = creation.Creator
+			accounts.CreationTx = creation.CreationTx
+			accounts.CreationBlock = creation.CreationBlock
+			accounts.CodeHash = creation.CodeHash
+		}
 		s.db.UpdateAccount(accounts)
 	}
 
@@ -164,69 +173,55 @@ func (s *Syncer) accountSync(b *rpc.BlockInfo) error {
 
 func (s *Syncer) minersaccountSync(b *rpc.BlockInfo) error {
 	//exclude genesis block
-	timeBegin := time.Now().Unix()
 	if b.Creator != nullAddress {
 		s.mu.Lock()
 		defer s.mu.Unlock()
-		blockCnt, blockFee, blockAmount, err := s.db.GetMinedBlocksByShardNumberAndAddress(s.shardNumber, b.Creator) // previous mined block info
-		log.Debug("Seele_syncer account_process mineraccount GetMinedBlocksByShardNumberAndAddress time %d(s)", time.Now().Unix()-timeBegin)
+		// the reward and fees breakdown is calculated when the block is stored
+		dbBlock, err := s.db.GetBlockByHeight(s.shardNumber, b.Height)
 		if err != nil {
 			log.Error(err)
-			blockCnt = 0
-		}
-		// add current mined block info
-		dbBlock := database.CreateDbBlock(b)
-		txDebtsTo := map[string]int{} // get all the txDebts in block
-		for i := 0; i < len(b.TxDebts); i++ {
-			txDebtsTo[b.TxDebts[i].To] = 1
-		}
-		for i := 0; i < len(dbBlock.Txs); i++ {
-			trans := dbBlock.Txs[i]
-			receipt, err := s.rpc.GetReceiptByTxHash(trans.Hash)
-			if err == nil {
-				dbBlock.Txs[i].Fee = receipt.TotalFee
-			}else{
-				return err
-			}
-		}
-		for i := 0; i < len(dbBlock.Debts); i++ {
-			getDebt : dbTx,err := s.db.GetTxByHash(dbBlock.Debts[i].TxHash) // transaction should be synchronized before this debt block is processed
-			if err == nil {
-				dbBlock.Debts[i].Fee = dbTx.Fee
-			}else{
-				time.Sleep(10*time.Second)
-				log.Info("Try again to get debt's fee from transaction hash:%s",dbBlock.Debts[i].TxHash)
-				goto getDebt
-				return err
-			}
-		}
-		for j := 0; j < len(dbBlock.Txs); j++ {
-			data := dbBlock.Txs[j]
-			if txDebtsTo[data.To] > 0 {
-				blockFee += data.Fee / 3 // cross shard txs
-			} else {
-				blockFee += data.Fee
-			}
-		}
-		for j := 0; j < len(dbBlock.Debts); j++ {
-			data := dbBlock.Debts[j]
-			blockFee += data.Fee * 2 / 3  //block fee for cross shard destination
-		}
-		blockAmount += dbBlock.Reward
-		blockCnt += 1
-		miners := &database.DBMiner{
-			ShardNumber: s.shardNumber,
-			Address:     b.Creator,
-			Reward:      blockAmount,
-			TxFee:       blockFee,
-			Revenue:     blockAmount + blockFee,
-			TimeStamp:   b.Timestamp.Int64(),
-			Mined:       blockCnt,
+			return err
+		}
+		miner := createMinerRevenue(dbBlock, 1)
+		miner.ShardNumber = s.shardNumber
+		miner.TimeStamp = b.Timestamp.Int64()
+		miner.Pool = dbBlock.Pool
+		timeBegin := time.Now().Unix()
+		if err := s.db.IncMinerAccount(miner); err != nil {
+			log.Error(err)
+			return err
 		}
-		timeBegin = time.Now().Unix()
-		s.db.UpdateMinerAccount(miners)
-		log.Debug("Seele_syncer account_process mineraccount UpdateMinerAccount time %d(s)", time.Now().Unix()-timeBegin)
+		log.Debug("Seele_syncer account_process mineraccount IncMinerAccount time %d(s)", time.Now().Unix()-timeBegin)
 	}
 	return nil
 
 }
+
+// createMinerRevenue return the revenue increment of the block creator, sign is -1 to revert the block
+func createMinerRevenue(dbBlock *database.DBBlock, sign int64) *database.DBMiner {
+	return &database.DBMiner{
+		Address: dbBlock.Creator,
+		Mined:   sign,
+		Reward:  sign * dbBlock.Reward,
+		TxFee:   sign * dbBlock.TxFee,
+		DebtFee: sign * dbBlock.DebtFee,
+		Revenue: sign * dbBlock.Revenue(),
+	}
+}
+
+// getContractCreation get the creation provenance and the runtime bytecode hash of a new contract
+func (s *Syncer) getContractCreation(tx rpc.Transaction, contractAddress string, height uint64) *database.DBAccount {
+	creation := &database.DBAccount{
+		Creator:       tx.From,
+		CreationTx:    tx.Hash,
+		CreationBlock: height,
+	}
+
+	code, err := s.rpc.GetCode(contractAddress, int64(height))
+	if err != nil {
+		log.Error("get contract code failed, address:%s, err:%v", contractAddress, err)
+		return creation
+	}
+	creation.CodeHash = database.CodeHash(code)
+	return creation
+}
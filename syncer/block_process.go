@@ -35,13 +35,18 @@ func (s *Syncer) blockSync(block *rpc.BlockInfo) error {
 	}
 	log.Debug("seele_syncer block_process getReceiptHash time:%d(s)",time.Now().Unix()-timeBegin )
 	dbBlock.UsedGas = blockgas
+	dbBlock.SetBlockFees()
 	dbBlock.ShardNumber = s.shardNumber
+	dbBlock.Pool = s.pools.Resolve(block.Creator, block.ExtraData)
 	// insert block info into database
 	timeBegin = time.Now().Unix()
 	if err := s.db.AddBlock(dbBlock); err != nil {
 		return err
 	}
 	log.Debug("seele_syncer block_process addBlock to db time:%d(s)",time.Now().Unix()-timeBegin )
+	if err := s.supplySync(dbBlock, 1); err != nil {
+		return err
+	}
 	// insert last block info into database to get final block produce rate
 	timeBegin = time.Now().Unix()
 	if err := storeLastBlocks(s.db, dbBlock); err != nil {
@@ -89,3 +94,15 @@ func initLastBlocks(db Database, last *database.DBLastBlock) error {
 	blocks = append(blocks, last, last)
 	return db.AddLastBlocks(blocks...)
 }
+
+// supplySync add the coins minted and the fees paid by the block to the shard supply,
+// sign is -1 to revert the block
+func (s *Syncer) supplySync(block *database.DBBlock, sign int64) error {
+	return s.db.IncSupply(&database.DBOneDaySupply{
+		ShardNumber: s.shardNumber,
+		TimeStamp:   database.SupplyDay(block.Timestamp),
+		Minted:      sign * block.Reward,
+		Fees:        sign * (block.TxFee + block.DebtFee),
+		Blocks:      sign,
+	})
+}
@@ -15,4 +15,17 @@ type Config struct {
 	DataBase     *common.DataBaseConfig
 	SyncInterval time.Duration
 	ShardNumber  int
+	MinerPools   []common.MinerPoolConfig
+
+	// GenesisAllocations is the balance of the accounts allocated in the genesis block of the shard
+	GenesisAllocations map[string]int64
+}
+
+// GenesisSupply return the sum of the genesis allocations
+func (c *Config) GenesisSupply() int64 {
+	var supply int64
+	for _, amount := range c.GenesisAllocations {
+		supply += amount
+	}
+	return supply
 }
@@ -13,17 +13,22 @@ type Database interface {
 	RemoveBlock(shard int, height uint64) error
 	UpdateBlock(shard int, height uint64, b *database.DBBlock) error
 	RemoveTxs(shard int, blockHeight uint64) error
+	RemoveDebts(shard int, height uint64) error
 	GetBlockByHeight(shardNumber int, height uint64) (*database.DBBlock, error)
 	RemoveAllPendingTxs() error
 	AddTx(tx *database.DBTx) error
 	AddTxs(tx ...interface{}) error
 	AddDebtTxs(debttxs ...interface{}) error
 	AddPendingTx(tx *database.DBTx) error
+	AddAddressActivities(activities ...*database.DBAddressActivity) error
+	RemoveAddressActivities(shard int, blockHeight uint64) error
 	GetAccountByAddress(address string) (*database.DBAccount, error)
 	GetMinerAccountByAddress(address string) (*database.DBMiner, error)
 	AddAccount(account *database.DBAccount) error
 	UpdateAccount(account *database.DBAccount) error
 	UpdateMinerAccount(account *database.DBMiner) error
+	IncMinerAccount(miner *database.DBMiner) error
+	IncSupply(t *database.DBOneDaySupply) error
 	UpdateAccountMinedBlock(address string, mined int64) error
 	GetTxCntByShardNumber(shardNumber int) (uint64, error)
 	GetPendingTxCntByShardNumber(shardNumber int) (uint64, error)
@@ -37,6 +42,5 @@ type Database interface {
 	GetTxHis(startDate, today string) ([]*database.DBSimpleTxs, error)
 	GetTxCntByAddressFromAccount(address string) (int64, error)
 	GetTxCntAndAccTypeByAddressFromAccount(address string) (int64, int,error)
-	InitTxCntByShardNumber(shardNumber int) (error)
 	GetTxByHash(hash string) (*database.DBTx, error)
 }
@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/gammazero/workerpool"
+	"github.com/seeleteam/scan-api/common"
 	"github.com/seeleteam/scan-api/database"
 	"github.com/seeleteam/scan-api/log"
 	"github.com/seeleteam/scan-api/rpc"
@@ -27,10 +28,11 @@ type Syncer struct {
 	updateAccount      map[string]*database.DBAccount
 	cacheMinerAccount  map[string]*database.DBMiner
 	updateMinerAccount map[string]*database.DBMiner
+	pools              *common.PoolResolver
 }
 
-// NewSyncer return a syncer to sync block data from seele node
-func NewSyncer(db Database, rpcConnURL string, shardNumber int) *Syncer {
+// NewSyncer return a syncer to sync block data from seele node, pools identify the mining pool of the blocks
+func NewSyncer(db Database, rpcConnURL string, shardNumber int, pools *common.PoolResolver) *Syncer {
 	rpc := rpc.NewRPC(rpcConnURL)
 	if rpc == nil {
 		return nil
@@ -51,6 +53,7 @@ func NewSyncer(db Database, rpcConnURL string, shardNumber int) *Syncer {
 		cacheMinerAccount:  make(map[string]*database.DBMiner),
 		updateMinerAccount: make(map[string]*database.DBMiner),
 		workerpool:         workerpool.New(maxInsertConn),
+		pools:              pools,
 	}
 }
 
@@ -91,6 +94,17 @@ func (s *Syncer) checkOlderBlocks() bool {
 		//Delete txs
 		s.db.RemoveTxs(s.shardNumber, i)
 
+		//Delete debts
+		s.db.RemoveDebts(s.shardNumber, i)
+
+		//Delete the activities of the addresses
+		s.db.RemoveAddressActivities(s.shardNumber, i)
+
+		//Revert supply
+		if err := s.supplySync(dbBlock, -1); err != nil {
+			log.Error("[DB] err : %v", err)
+		}
+
 		//Modify accounts
 		for j := 0; j < len(dbBlock.Txs); j++ {
 			tx := dbBlock.Txs[j]
@@ -165,6 +179,13 @@ func (s *Syncer) checkOlderBlocks() bool {
 		}
 
 		if dbBlock.Creator != nullAddress {
+			//revert the revenue of the removed block
+			miner := createMinerRevenue(dbBlock, -1)
+			miner.ShardNumber = s.shardNumber
+			if err := s.db.IncMinerAccount(miner); err != nil {
+				log.Error("[DB] err : %v", err)
+			}
+
 			minerAccount, err := s.db.GetAccountByAddress(dbBlock.Creator)
 			if err != nil {
 				minerAccount = database.CreateEmptyAccount(dbBlock.Creator, s.shardNumber)
@@ -39,10 +39,10 @@ func (s *Syncer) txSync(block *rpc.BlockInfo) error {
 		if err == nil {
 			dbTx.Fee = receipt.TotalFee
 			dbTx.UsedGas = receipt.UsedGas
+			dbTx.Receipt = *receipt
 			if trans.To == "" {
 				dbTx.TxType = 1
 				dbTx.ContractAddress = receipt.ContractAddress
-				dbTx.Receipt = *receipt
 			}
 		}
 		dbTxs = append(dbTxs, dbTx)
@@ -59,6 +59,14 @@ func (s *Syncer) txSync(block *rpc.BlockInfo) error {
 	}
 	log.Debug("seele_syncer tx_process AddTxs time:%d(s)",time.Now().Unix()-timeBegin )
 
+	var activities []*database.DBAddressActivity
+	for _, dbTx := range dbTxs {
+		activities = append(activities, database.CreateTxActivities(dbTx)...)
+	}
+	if err := s.db.AddAddressActivities(activities...); err != nil {
+		return err
+	}
+
 	// insert 30 days history transaction number into database
 	//s.txHisSync(dbTxs)    // this long-time process should be done in another way
 
@@ -172,6 +180,7 @@ func nextDate(date *string) {
 func (s *Syncer) debttxSync(block *rpc.BlockInfo) error {
 	debtIdx, _ := s.db.GetTxCntByShardNumber(s.shardNumber)
 	debttxs := []interface{}{}
+	var activities []*database.DBAddressActivity
 	for i := 0; i < len(block.Debts); i++ {
 		debts := block.Debts[i]
 		debts.Block = block.Height
@@ -180,13 +189,23 @@ func (s *Syncer) debttxSync(block *rpc.BlockInfo) error {
 		debtTx := database.CreateDebtTx(debts)
 		debtTx.ShardNumber = s.shardNumber
 		debttxs = append(debttxs, debtTx)
+
+		// the sender is known once the shard of the transaction is synchronized
+		var from string
+		if tx, err := s.db.GetTxByHash(debtTx.TxHash); err == nil {
+			from = tx.From
+		}
+		activities = append(activities, database.CreateDebtActivity(debtTx, from, block.Timestamp.Int64()))
 	}
 
 	if len(debttxs) == 0 {
 		return nil
 	}
 
-	return s.db.AddDebtTxs(debttxs...)
+	if err := s.db.AddDebtTxs(debttxs...); err != nil {
+		return err
+	}
+	return s.db.AddAddressActivities(activities...)
 }
 
 func (s *Syncer) pendingTxsSync() error {